@@ -18,15 +18,18 @@ func SetupRouter() *gin.Engine {
 	// 配置CORS
 	config := cors.DefaultConfig()
 	config.AllowAllOrigins = true
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization"}
+	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", "Upload-Offset"}
+	config.ExposeHeaders = []string{"Location", "Upload-Offset", "Upload-Length", "Upload-Expires"}
 	r.Use(cors.New(config))
 
-	// 静态文件服务（上传文件可通过 /uploads/xxx 访问）
-	r.Static("/uploads", "./uploads")
+	// 静态文件服务，只公开头像等公开资源；聊天图片、语音等保存在媒体存储，通过 /api/media 带签名下载
+	for _, dir := range []string{"avatars", "characters"} {
+		r.Static("/uploads/"+dir, "./uploads/"+dir)
 
-	// 保留旧的静态文件路径，兼容旧代码
-	r.Static("/static", "./uploads")
+		// 保留旧的静态文件路径，兼容旧代码
+		r.Static("/static/"+dir, "./uploads/"+dir)
+	}
 
 	// 注册用户路由（包含登录注册等公共API）
 	routes.RegisterUserRoutes(r)
@@ -45,8 +48,14 @@ func SetupRouter() *gin.Engine {
 		api.GET("/captcha", captcha.GetCaptcha)
 		api.POST("/captcha/verify", captcha.VerifyCaptchaHandler)
 
-		// 文件上传
-		api.POST("/upload", controllers.UploadFileEnhanced)
+		// 媒体下载（使用签名校验）
+		routes.RegisterMediaContentRoutes(api)
+
+		// 刷新访问令牌（使用刷新令牌）
+		routes.RegisterTokenRoutes(api)
+
+		// 小程序开放接口（使用小程序令牌认证）
+		routes.RegisterMiniAppOpenRoutes(api)
 	}
 
 	// 需要认证的API
@@ -57,6 +66,18 @@ func SetupRouter() *gin.Engine {
 		auth.GET("/user/info", controllers.GetUserInfo)
 		auth.PUT("/user/info", controllers.UpdateUserInfo)
 
+		// 退出登录和设备管理
+		routes.RegisterSessionRoutes(auth)
+
+		// 文件上传
+		auth.POST("/upload", controllers.UploadFileEnhanced)
+
+		// 媒体文件相关
+		routes.RegisterMediaRoutes(auth)
+
+		// 断点续传
+		routes.RegisterUploadRoutes(auth)
+
 		// 聊天相关
 		routes.RegisterChatRoutes(auth)
 
@@ -72,6 +93,15 @@ func SetupRouter() *gin.Engine {
 		// 朋友圈相关
 		routes.RegisterMomentsRoutes(auth)
 
+		// 广场相关
+		routes.RegisterSquareRoutes(auth)
+
+		// 小程序相关
+		routes.RegisterMiniAppRoutes(auth)
+
+		// 游戏商店相关
+		routes.RegisterGameRoutes(auth)
+
 		// 钱包相关
 		routes.RegisterWalletRoutesNew(auth)
 
@@ -81,6 +111,9 @@ func SetupRouter() *gin.Engine {
 		// 翻译相关
 		routes.RegisterTranslationRoutes(auth)
 
+		// 多语言相关
+		routes.RegisterI18nRoutes(auth)
+
 		// 语音识别相关
 		routes.RegisterSpeechRoutes(auth)
 
@@ -3,17 +3,43 @@ package main
 import (
 	"allinone_backend/api"
 	"allinone_backend/controllers"
+	"allinone_backend/services"
 	"allinone_backend/utils"
 	"log"
 	"time"
 )
 
 func main() {
+	// 检查令牌签名密钥
+	if err := utils.CheckJWTConfig(); err != nil {
+		log.Fatalf("JWT配置错误: %v", err)
+	}
+
+	// 检查媒体下载链接签名密钥
+	if err := utils.CheckMediaConfig(); err != nil {
+		log.Fatalf("媒体存储配置错误: %v", err)
+	}
+
+	// 检查邮件和短信发送配置
+	if err := utils.CheckNotifyConfig(); err != nil {
+		log.Fatalf("通知发送配置错误: %v", err)
+	}
+
 	// 初始化数据库
 	if err := utils.InitDB(); err != nil {
 		log.Fatalf("数据库初始化失败: %v", err)
 	}
 
+	// 加载管理员导入的语言包
+	if err := services.LoadLanguagePacks(utils.DB); err != nil {
+		log.Printf("加载语言包失败: %v", err)
+	}
+
+	// 加载令牌吊销列表
+	if err := services.LoadTokenRevocations(utils.DB); err != nil {
+		log.Printf("加载令牌吊销列表失败: %v", err)
+	}
+
 	// 初始化定时任务
 	initScheduledTasks()
 
@@ -35,6 +61,60 @@ func initScheduledTasks() {
 		controllers.SettleMaturedDeposits(db)
 	})
 
+	// 添加广场热度刷新任务（每10分钟执行一次）
+	utils.SchedulerManager.AddTask("refresh_square_hot_scores", 10*time.Minute, func() {
+		services.RefreshSquareHotScores(db)
+	})
+
+	// 添加游戏收入结算任务（按配置的结算周期执行）
+	utils.SchedulerManager.AddTask("settle_game_revenue", services.GetGameSettlementConfig().Interval, func() {
+		services.SettleGameRevenue(db, time.Now().Unix())
+	})
+
+	// 添加翻译缓存清理任务（每天执行一次）
+	utils.SchedulerManager.AddTask("purge_translation_cache", 24*time.Hour, func() {
+		services.PurgeTranslationCache(db)
+	})
+
+	// 添加语言包刷新任务（每5分钟执行一次，同步其他实例导入的语言包）
+	utils.SchedulerManager.AddTask("reload_language_packs", 5*time.Minute, func() {
+		if err := services.LoadLanguagePacks(db); err != nil {
+			utils.Logger.Errorf("刷新语言包失败: %v", err)
+		}
+	})
+
+	// 添加语音识别任务重试（每分钟执行一次，恢复中断和等待重试的任务）
+	utils.SchedulerManager.AddTask("retry_transcription_jobs", time.Minute, func() {
+		services.RetryTranscriptionJobs(db)
+	})
+
+	// 添加过期上传会话清理任务（每小时执行一次）
+	utils.SchedulerManager.AddTask("cleanup_expired_uploads", time.Hour, func() {
+		services.CleanupExpiredUploads(db)
+	})
+
+	// 添加令牌吊销列表同步任务（每分钟执行一次，使其他实例上的退出登录、远程下线生效）
+	utils.SchedulerManager.AddTask("reload_token_revocations", time.Minute, func() {
+		if err := services.LoadTokenRevocations(db); err != nil {
+			utils.Logger.Errorf("同步令牌吊销列表失败: %v", err)
+		}
+	})
+
+	// 添加过期刷新令牌清理任务（每天执行一次）
+	utils.SchedulerManager.AddTask("cleanup_expired_refresh_tokens", 24*time.Hour, func() {
+		services.CleanupExpiredRefreshTokens(db)
+	})
+
+	// 添加登录尝试记录清理任务（每小时执行一次，只保留最近一天）
+	utils.SchedulerManager.AddTask("cleanup_login_attempts", time.Hour, func() {
+		services.CleanupLoginAttempts(db)
+	})
+
+	// 添加过期验证码清理任务（每10分钟执行一次，包括图形验证码和通知发送频率记录）
+	utils.SchedulerManager.AddTask("cleanup_expired_codes", 10*time.Minute, func() {
+		utils.CleanExpiredCodes()
+	})
+
 	// 启动所有定时任务
 	utils.SchedulerManager.StartAll()
 }
@@ -1,10 +1,13 @@
 package controllers
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"time"
 
 	"allinone_backend/models"
+	"allinone_backend/services"
 	"allinone_backend/utils"
 
 	"github.com/gin-gonic/gin"
@@ -13,9 +16,9 @@ import (
 // AI相关接口
 func ListAiTools(c *gin.Context) {
 	// 获取当前用户
-	_, exists := c.Get("user_id")
+	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -53,6 +56,8 @@ func ListAiTools(c *gin.Context) {
 				"icon":        "translate",
 			},
 		},
+		// AI对话中可调用的工具
+		"functions": listAIToolPermissions(userID.(uint)),
 	})
 }
 
@@ -61,21 +66,23 @@ func GetAIChatHistory(c *gin.Context) {
 	// 获取当前用户
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "auth.unauthorized")})
 		return
 	}
 
 	// 解析请求参数
 	var req struct {
-		Type    string `form:"type" binding:"required"` // personal, group, game
-		GroupID uint   `form:"group_id"`
-		GameID  uint   `form:"game_id"`
-		Limit   int    `form:"limit"`
-		Offset  int    `form:"offset"`
+		Type        string `form:"type" binding:"required"` // personal, group, game
+		GroupID     uint   `form:"group_id"`
+		GameID      uint   `form:"game_id"`
+		CharacterID uint   `form:"character_id"`
+		SessionID   uint   `form:"session_id"`
+		Limit       int    `form:"limit"`
+		Offset      int    `form:"offset"`
 	}
 
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "common.invalid_params")})
 		return
 	}
 
@@ -95,12 +102,15 @@ func GetAIChatHistory(c *gin.Context) {
 	if req.Type == "group" && req.GroupID > 0 {
 		query = query.Where("group_id = ?", req.GroupID)
 	} else if req.Type == "game" && req.GameID > 0 {
-		query = query.Where("game_id = ?", req.GameID)
+		query = query.Where("game_id = ? AND character_id = ?", req.GameID, req.CharacterID)
+	}
+	if req.SessionID > 0 {
+		query = query.Where("session_id = ?", req.SessionID)
 	}
 
 	// 执行查询
 	if err := query.Order("created_at DESC").Limit(req.Limit).Offset(req.Offset).Find(&messages).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取聊天历史失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "ai.history_query_failed")})
 		return
 	}
 
@@ -115,312 +125,386 @@ func ChatWithPersonalAI(c *gin.Context) {
 	// 获取当前用户
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "auth.unauthorized")})
 		return
 	}
 
 	// 解析请求参数
 	var req struct {
-		Message string `json:"message" binding:"required"`
+		Message   string `json:"message" binding:"required"`
+		SessionID uint   `json:"session_id"` // 为空时沿用最近的会话
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "common.invalid_params")})
 		return
 	}
 
-	// 获取用户AI设置
-	var aiSettings models.AISettings
-	if err := utils.DB.Where("user_id = ?", userID).First(&aiSettings).Error; err != nil {
-		// 如果没有设置，使用默认设置
-		aiSettings = models.AISettings{
-			UserID:         userID.(uint),
-			AIModel:        "gpt-3.5-turbo",
-			Temperature:    0.7,
-			MaxTokens:      2000,
-			PersonalPrompt: "你是一个友好、乐于助人的个人助手。你可以帮助用户回答问题、提供建议、进行日常对话等。",
-		}
-	}
-
-	// 获取聊天历史
-	var history []models.AIChatMessage
-	if err := utils.DB.Where("user_id = ? AND type = ?", userID, "personal").Order("created_at DESC").Limit(10).Find(&history).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取聊天历史失败"})
+	job, err := preparePersonalAIChat(c.Request.Context(), userID.(uint), req.SessionID, req.Message)
+	if err != nil {
+		respondAIPrepareError(c, err)
 		return
 	}
+	runAIChat(c, job)
+}
 
-	// 转换历史消息格式
-	var messages []utils.AIMessage
-	for i := len(history) - 1; i >= 0; i-- {
-		messages = append(messages, utils.AIMessage{Role: "user", Content: history[i].Content})
-		messages = append(messages, utils.AIMessage{Role: "assistant", Content: history[i].Response})
+// 群组AI管理聊天
+func ChatWithGroupAI(c *gin.Context) {
+	// 获取当前用户
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "auth.unauthorized")})
+		return
 	}
 
-	// 添加系统提示
-	messages = append([]utils.AIMessage{{Role: "system", Content: aiSettings.PersonalPrompt}}, messages...)
-
-	// 添加用户当前消息
-	messages = append(messages, utils.AIMessage{Role: "user", Content: req.Message})
-
-	// 调用AI聊天
-	response, err := utils.ChatWithAI(messages, aiSettings.AIModel, aiSettings.Temperature, aiSettings.MaxTokens)
-	if err != nil {
-		// 尝试备用API
-		response, err = utils.ChatWithHuggingFace(messages)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "AI聊天失败"})
-			return
-		}
+	// 解析请求参数
+	var req struct {
+		GroupID   uint   `json:"group_id" binding:"required"`
+		Message   string `json:"message" binding:"required"`
+		SessionID uint   `json:"session_id"`
 	}
 
-	// 保存聊天记录
-	chatMessage := models.AIChatMessage{
-		UserID:    userID.(uint),
-		Content:   req.Message,
-		Response:  response,
-		CreatedAt: time.Now().Unix(),
-		Type:      "personal",
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "common.invalid_params")})
+		return
 	}
 
-	if err := utils.DB.Create(&chatMessage).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存聊天记录失败"})
+	job, err := prepareGroupAIChat(c.Request.Context(), userID.(uint), req.SessionID, req.GroupID, req.Message)
+	if err != nil {
+		respondAIPrepareError(c, err)
 		return
 	}
-
-	// 返回结果
-	c.JSON(http.StatusOK, gin.H{
-		"message":  chatMessage,
-		"response": response,
-	})
+	runAIChat(c, job)
 }
 
-// 群组AI管理聊天
-func ChatWithGroupAI(c *gin.Context) {
+// 游戏AI陪玩聊天
+func ChatWithGameAI(c *gin.Context) {
 	// 获取当前用户
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "auth.unauthorized")})
 		return
 	}
 
 	// 解析请求参数
 	var req struct {
-		GroupID uint   `json:"group_id" binding:"required"`
-		Message string `json:"message" binding:"required"`
+		GameID      uint   `json:"game_id" binding:"required"`
+		CharacterID uint   `json:"character_id"` // 与指定的游戏角色对话
+		Message     string `json:"message" binding:"required"`
+		SessionID   uint   `json:"session_id"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "common.invalid_params")})
 		return
 	}
 
-	// 检查用户是否在群组中
-	var groupMember models.GroupMember
-	if err := utils.DB.Where("group_id = ? AND user_id = ?", req.GroupID, userID).First(&groupMember).Error; err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "您不是该群组成员"})
+	job, err := prepareGameAIChat(c.Request.Context(), userID.(uint), req.SessionID, req.GameID, req.CharacterID, req.Message)
+	if err != nil {
+		respondAIPrepareError(c, err)
 		return
 	}
+	runAIChat(c, job)
+}
+
+// 一次待执行的AI对话：请求内容和待保存的聊天记录
+type aiChatJob struct {
+	settings models.AISettings
+	messages []utils.AIMessage
+	record   models.AIChatMessage
+	// 回复需要经过敏感词过滤，用于开发者设定的游戏角色
+	filterOutput bool
+}
+
+// 转换为提供方请求
+func (j *aiChatJob) request() utils.AIChatRequest {
+	return utils.AIChatRequest{
+		Model:       j.settings.AIModel,
+		Messages:    j.messages,
+		Temperature: j.settings.Temperature,
+		MaxTokens:   j.settings.MaxTokens,
+	}
+}
 
-	// 获取用户AI设置
+// 获取用户AI设置，没有设置或提示词为空时使用默认值
+func loadAISettings(userID uint) models.AISettings {
 	var aiSettings models.AISettings
 	if err := utils.DB.Where("user_id = ?", userID).First(&aiSettings).Error; err != nil {
-		// 如果没有设置，使用默认设置
 		aiSettings = models.AISettings{
-			UserID:      userID.(uint),
-			AIModel:     "gpt-3.5-turbo",
+			UserID:      userID,
 			Temperature: 0.7,
 			MaxTokens:   2000,
-			GroupPrompt: "你是一个群组管理助手。你可以帮助用户管理群组、回答群组相关问题、提供群组活动建议等。",
 		}
 	}
-
-	// 获取聊天历史
-	var history []models.AIChatMessage
-	if err := utils.DB.Where("user_id = ? AND type = ? AND group_id = ?", userID, "group", req.GroupID).Order("created_at DESC").Limit(10).Find(&history).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取聊天历史失败"})
-		return
+	// 上限调低后，之前保存的更大设置同样按上限
+	aiSettings.MaxTokens = services.ClampAIMaxTokens(aiSettings.MaxTokens)
+	if aiSettings.PersonalPrompt == "" {
+		aiSettings.PersonalPrompt = "你是一个友好、乐于助人的个人助手。你可以帮助用户回答问题、提供建议、进行日常对话等。"
 	}
-
-	// 转换历史消息格式
-	var messages []utils.AIMessage
-	for i := len(history) - 1; i >= 0; i-- {
-		messages = append(messages, utils.AIMessage{Role: "user", Content: history[i].Content})
-		messages = append(messages, utils.AIMessage{Role: "assistant", Content: history[i].Response})
+	if aiSettings.GroupPrompt == "" {
+		aiSettings.GroupPrompt = "你是一个群组管理助手。你可以帮助用户管理群组、回答群组相关问题、提供群组活动建议等。"
 	}
+	if aiSettings.GamePrompt == "" {
+		aiSettings.GamePrompt = "你是一个游戏陪玩助手。你可以陪伴用户玩游戏、提供游戏建议、讨论游戏策略等。"
+	}
+	return aiSettings
+}
 
-	// 添加系统提示
-	messages = append([]utils.AIMessage{{Role: "system", Content: aiSettings.GroupPrompt}}, messages...)
-
-	// 添加用户当前消息
-	messages = append(messages, utils.AIMessage{Role: "user", Content: req.Message})
-
-	// 调用AI聊天
-	response, err := utils.ChatWithAI(messages, aiSettings.AIModel, aiSettings.Temperature, aiSettings.MaxTokens)
-	if err != nil {
-		// 尝试备用API
-		response, err = utils.ChatWithHuggingFace(messages)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "AI聊天失败"})
-			return
-		}
+// 检查额度并组装系统提示、会话上下文和当前消息
+func buildAIChatJob(ctx context.Context, settings models.AISettings, record models.AIChatMessage, systemPrompt string) (*aiChatJob, error) {
+	if err := services.CheckAIQuota(utils.DB, record.UserID); err != nil {
+		return nil, err
 	}
 
-	// 保存聊天记录
-	chatMessage := models.AIChatMessage{
-		UserID:    userID.(uint),
-		GroupID:   req.GroupID,
-		Content:   req.Message,
-		Response:  response,
-		CreatedAt: time.Now().Unix(),
-		Type:      "group",
+	scope := services.AISessionScope{Type: record.Type, GroupID: record.GroupID, GameID: record.GameID, CharacterID: record.CharacterID}
+	session, err := services.ResolveAISession(utils.DB, record.UserID, record.SessionID, scope, record.Content)
+	if err != nil {
+		return nil, err
 	}
+	record.SessionID = session.ID
 
-	if err := utils.DB.Create(&chatMessage).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存聊天记录失败"})
-		return
+	// 上下文超出 MaxTokens 时压缩较早的对话
+	messages, err := services.BuildAISessionContext(ctx, utils.DB, session, settings.AIProvider, systemPrompt, record.Content, settings.MaxTokens)
+	if err != nil {
+		return nil, err
 	}
 
-	// 返回结果
-	c.JSON(http.StatusOK, gin.H{
-		"message":  chatMessage,
-		"response": response,
-	})
+	return &aiChatJob{settings: settings, messages: messages, record: record}, nil
 }
 
-// 游戏AI陪玩聊天
-func ChatWithGameAI(c *gin.Context) {
-	// 获取当前用户
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
-		return
-	}
+// 准备个人AI助手对话
+func preparePersonalAIChat(ctx context.Context, userID, sessionID uint, message string) (*aiChatJob, error) {
+	settings := loadAISettings(userID)
+	record := models.AIChatMessage{UserID: userID, SessionID: sessionID, Content: message, Type: "personal"}
+	return buildAIChatJob(ctx, settings, record, settings.PersonalPrompt)
+}
 
-	// 解析请求参数
-	var req struct {
-		GameID  uint   `json:"game_id" binding:"required"`
-		Message string `json:"message" binding:"required"`
+// 准备群组AI对话，要求用户是群成员
+func prepareGroupAIChat(ctx context.Context, userID, sessionID, groupID uint, message string) (*aiChatJob, error) {
+	var groupMember models.GroupMember
+	if err := utils.DB.Where("group_id = ? AND user_id = ?", groupID, userID).First(&groupMember).Error; err != nil {
+		return nil, &utils.AppError{Code: http.StatusForbidden, Message: "您不是该群组成员", Key: "group.not_member"}
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
-		return
-	}
+	settings := loadAISettings(userID)
+	record := models.AIChatMessage{UserID: userID, SessionID: sessionID, GroupID: groupID, Content: message, Type: "group"}
+	return buildAIChatJob(ctx, settings, record, settings.GroupPrompt)
+}
 
-	// 检查游戏是否存在
+// 准备游戏AI陪玩对话，系统提示中附带游戏信息
+// 指定 characterID 时扮演该角色，用户消息和回复都经过敏感词过滤
+func prepareGameAIChat(ctx context.Context, userID, sessionID, gameID, characterID uint, message string) (*aiChatJob, error) {
 	var game models.Game
-	if err := utils.DB.First(&game, req.GameID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "游戏不存在"})
-		return
+	if err := utils.DB.First(&game, gameID).Error; err != nil {
+		return nil, &utils.AppError{Code: http.StatusNotFound, Message: "游戏不存在", Key: "game.not_found"}
 	}
 
-	// 获取用户AI设置
-	var aiSettings models.AISettings
-	if err := utils.DB.Where("user_id = ?", userID).First(&aiSettings).Error; err != nil {
-		// 如果没有设置，使用默认设置
-		aiSettings = models.AISettings{
-			UserID:      userID.(uint),
-			AIModel:     "gpt-3.5-turbo",
-			Temperature: 0.8,
-			MaxTokens:   2000,
-			GamePrompt:  "你是一个游戏陪玩助手。你可以陪伴用户玩游戏、提供游戏建议、讨论游戏策略等。",
+	settings := loadAISettings(userID)
+	if settings.ID == 0 {
+		// 未保存设置时，游戏陪玩使用更高的创造性
+		settings.Temperature = 0.8
+	}
+	if characterID != 0 {
+		character, err := services.GetGameCharacter(utils.DB, gameID, characterID)
+		if err != nil {
+			return nil, err
+		}
+		record := models.AIChatMessage{UserID: userID, SessionID: sessionID, GameID: gameID, CharacterID: characterID,
+			Content: utils.FilterSensitiveWords(message), Type: "game"}
+		job, err := buildAIChatJob(ctx, settings, record, services.GameCharacterPrompt(&game, character))
+		if err != nil {
+			return nil, err
 		}
+		job.filterOutput = true
+		return job, nil
 	}
 
-	// 获取聊天历史
-	var history []models.AIChatMessage
-	if err := utils.DB.Where("user_id = ? AND type = ? AND game_id = ?", userID, "game", req.GameID).Order("created_at DESC").Limit(10).Find(&history).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取聊天历史失败"})
-		return
-	}
+	gamePrompt := settings.GamePrompt + "\n游戏名称：" + game.Name + "\n游戏类型：" + game.Type + "\n游戏描述：" + game.Description
+	record := models.AIChatMessage{UserID: userID, SessionID: sessionID, GameID: gameID, Content: message, Type: "game"}
+	return buildAIChatJob(ctx, settings, record, gamePrompt)
+}
 
-	// 转换历史消息格式
-	var messages []utils.AIMessage
-	for i := len(history) - 1; i >= 0; i-- {
-		messages = append(messages, utils.AIMessage{Role: "user", Content: history[i].Content})
-		messages = append(messages, utils.AIMessage{Role: "assistant", Content: history[i].Response})
+// 执行AI对话并保存聊天记录，对话中模型可以调用已注册的工具
+func runAIChat(c *gin.Context, job *aiChatJob) {
+	run := &services.AIToolRun{
+		UserID:   job.record.UserID,
+		Provider: job.settings.AIProvider,
+		Request:  job.request(),
 	}
+	continueAIChat(c, job, run)
+}
 
-	// 添加系统提示
-	gamePrompt := aiSettings.GamePrompt + "\n游戏名称：" + game.Name + "\n游戏类型：" + game.Type + "\n游戏描述：" + game.Description
-	messages = append([]utils.AIMessage{{Role: "system", Content: gamePrompt}}, messages...)
-
-	// 添加用户当前消息
-	messages = append(messages, utils.AIMessage{Role: "user", Content: req.Message})
+// 继续执行工具调用循环，需要授权时保存为等待确认状态
+func continueAIChat(c *gin.Context, job *aiChatJob, run *services.AIToolRun) {
+	run.OnResult = func(result *utils.AIChatResult) {
+		recordAIChatUsage(job, result)
+	}
 
-	// 调用AI聊天
-	response, err := utils.ChatWithAI(messages, aiSettings.AIModel, aiSettings.Temperature, aiSettings.MaxTokens)
+	// 调用AI聊天，失败时按回退链切换提供方
+	result, err := run.Continue(c.Request.Context(), utils.DB)
+	job.record.ToolTrace = run.TraceJSON()
 	if err != nil {
-		// 尝试备用API
-		response, err = utils.ChatWithHuggingFace(messages)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "AI聊天失败"})
-			return
+		if job.record.ID != 0 {
+			// 保留已执行的工具结果，确认接口可以重试
+			utils.DB.Model(&job.record).Updates(map[string]interface{}{"status": "awaiting_permission", "tool_trace": job.record.ToolTrace})
 		}
+		respondAIError(c, err)
+		return
 	}
 
-	// 保存聊天记录
-	chatMessage := models.AIChatMessage{
-		UserID:    userID.(uint),
-		Content:   req.Message,
-		Response:  response,
-		CreatedAt: time.Now().Unix(),
-		Type:      "game",
+	if result == nil {
+		chatMessage, err := saveAIChatRecord(job, "awaiting_permission")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "chat.save_history_failed")})
+			return
+		}
+		pending := []gin.H{}
+		for _, step := range run.Pending() {
+			tool, _ := services.GetAITool(step.Tool)
+			pending = append(pending, gin.H{
+				"call_id":   step.CallID,
+				"tool":      step.Tool,
+				"title":     tool.Title,
+				"arguments": step.Arguments,
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message":             chatMessage,
+			"permission_required": pending,
+		})
+		return
 	}
 
-	if err := utils.DB.Create(&chatMessage).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存聊天记录失败"})
+	chatMessage, err := saveAIChatResult(job, result)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "chat.save_history_failed")})
 		return
 	}
 
 	// 返回结果
 	c.JSON(http.StatusOK, gin.H{
 		"message":  chatMessage,
-		"response": response,
+		"response": chatMessage.Response,
 	})
 }
 
-// 更新AI设置
+// 记录一次模型调用的用量，累加到聊天记录和当日统计
+func recordAIChatUsage(job *aiChatJob, result *utils.AIChatResult) {
+	job.record.PromptTokens += result.Usage.PromptTokens
+	job.record.CompletionTokens += result.Usage.CompletionTokens
+	if err := services.RecordAIUsage(utils.DB, job.record.UserID, result.Provider, 1, result.Usage); err != nil {
+		utils.Logger.Errorf("记录AI用量失败: %v", err)
+	}
+}
+
+// 保存完整的AI回复
+func saveAIChatResult(job *aiChatJob, result *utils.AIChatResult) (*models.AIChatMessage, error) {
+	job.record.Response = result.Content
+	if job.filterOutput {
+		job.record.Response = utils.FilterSensitiveWords(result.Content)
+	}
+	job.record.Provider = result.Provider
+	job.record.Model = result.Model
+	return saveAIChatRecord(job, "completed")
+}
+
+// 保存聊天记录，等待确认的记录在继续执行后原地更新
+func saveAIChatRecord(job *aiChatJob, status string) (*models.AIChatMessage, error) {
+	chatMessage := job.record
+	chatMessage.Status = status
+	if chatMessage.CreatedAt == 0 || status == "completed" {
+		chatMessage.CreatedAt = time.Now().Unix()
+	}
+	var err error
+	if chatMessage.ID != 0 {
+		err = utils.DB.Save(&chatMessage).Error
+	} else {
+		err = utils.DB.Create(&chatMessage).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status == "completed" {
+		services.RecordAISessionMessage(utils.DB, chatMessage.SessionID, chatMessage.PromptTokens+chatMessage.CompletionTokens)
+	}
+	return &chatMessage, nil
+}
+
+// 返回准备对话阶段的错误
+func respondAIPrepareError(c *gin.Context, err error) {
+	if appErr, ok := err.(*utils.AppError); ok {
+		c.JSON(appErr.Code, gin.H{"error": appErrorMessage(c, appErr)})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "ai.history_query_failed")})
+}
+
+// 更新AI设置，只更新请求中非零的字段
 func UpdateAISettings(c *gin.Context) {
 	// 获取当前用户
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "auth.unauthorized")})
 		return
 	}
 
-	// 解析请求参数
-	var req models.AISettings
+	// 解析请求参数，只接受用户可以修改的字段
+	var req struct {
+		AIProvider     string  `json:"ai_provider"`
+		AIModel        string  `json:"ai_model"`
+		Temperature    float64 `json:"temperature" binding:"min=0,max=2"`
+		MaxTokens      int     `json:"max_tokens" binding:"min=0"`
+		PersonalPrompt string  `json:"personal_prompt"`
+		GroupPrompt    string  `json:"group_prompt"`
+		GamePrompt     string  `json:"game_prompt"`
+	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "common.invalid_params")})
+		return
+	}
+
+	// 检查AI提供方是否可用
+	if req.AIProvider != "" && !utils.HasAIProvider(req.AIProvider) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "ai.unsupported_provider")})
 		return
 	}
 
-	// 设置用户ID
-	req.UserID = userID.(uint)
-	req.UpdatedAt = time.Now().Unix()
+	now := time.Now().Unix()
+	updates := models.AISettings{
+		AIProvider:     req.AIProvider,
+		AIModel:        req.AIModel,
+		Temperature:    req.Temperature,
+		MaxTokens:      services.ClampAIMaxTokens(req.MaxTokens),
+		PersonalPrompt: req.PersonalPrompt,
+		GroupPrompt:    req.GroupPrompt,
+		GamePrompt:     req.GamePrompt,
+		UpdatedAt:      now,
+	}
 
 	// 检查是否已有设置
-	var existingSettings models.AISettings
-	result := utils.DB.Where("user_id = ?", userID).First(&existingSettings)
+	var settings models.AISettings
+	result := utils.DB.Where("user_id = ?", userID).First(&settings)
 	if result.Error == nil {
 		// 更新现有设置
-		if err := utils.DB.Model(&existingSettings).Updates(req).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新AI设置失败"})
+		if err := utils.DB.Model(&settings).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "ai.settings_update_failed")})
 			return
 		}
+		utils.DB.First(&settings, settings.ID)
 	} else {
 		// 创建新设置
-		req.CreatedAt = time.Now().Unix()
-		if err := utils.DB.Create(&req).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "创建AI设置失败"})
+		settings = updates
+		settings.UserID = userID.(uint)
+		settings.CreatedAt = now
+		if err := utils.DB.Create(&settings).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "ai.settings_create_failed")})
 			return
 		}
 	}
 
 	// 返回结果
 	c.JSON(http.StatusOK, gin.H{
-		"settings": req,
+		"settings": settings,
 	})
 }
 
@@ -429,7 +513,7 @@ func GetAISettings(c *gin.Context) {
 	// 获取当前用户
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -439,7 +523,6 @@ func GetAISettings(c *gin.Context) {
 		// 如果没有设置，返回默认设置
 		aiSettings = models.AISettings{
 			UserID:         userID.(uint),
-			AIModel:        "gpt-3.5-turbo",
 			Temperature:    0.7,
 			MaxTokens:      2000,
 			PersonalPrompt: "你是一个友好、乐于助人的个人助手。你可以帮助用户回答问题、提供建议、进行日常对话等。",
@@ -452,6 +535,20 @@ func GetAISettings(c *gin.Context) {
 
 	// 返回结果
 	c.JSON(http.StatusOK, gin.H{
-		"settings": aiSettings,
+		"settings":  aiSettings,
+		"providers": utils.ListAIProviders(),
 	})
 }
+
+// 根据AI错误类型返回对应的状态码
+func respondAIError(c *gin.Context, err error) {
+	utils.Logger.Errorf("AI聊天失败: %v", err)
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, utils.ErrAIRateLimited):
+		status = http.StatusTooManyRequests
+	case errors.Is(err, utils.ErrAIModelLoading), errors.Is(err, utils.ErrAIUnavailable), errors.Is(err, utils.ErrAINoProvider):
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, gin.H{"error": tr(c, aiErrorKey(err))})
+}
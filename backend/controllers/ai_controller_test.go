@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/services"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// updateAISettings 以 userID 的身份更新AI设置，返回状态码
+func updateAISettings(userID uint, body string) int {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPut, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("user_id", userID)
+	UpdateAISettings(c)
+	return w.Code
+}
+
+func TestUpdateAISettingsOnlyUserFields(t *testing.T) {
+	db := newControllerTestDB(t)
+	previous := services.GetAIQuotaConfig()
+	services.SetAIQuotaConfig(services.AIQuotaConfig{MaxTokens: 4000})
+	t.Cleanup(func() { services.SetAIQuotaConfig(previous) })
+
+	// 请求中的 id、user_id、created_at 被忽略，超出上限的 max_tokens 按上限保存
+	body := `{"id":99,"user_id":7,"created_at":1,"max_tokens":1000000,"temperature":1.2,"personal_prompt":"p"}`
+	if status := updateAISettings(1, body); status != http.StatusOK {
+		t.Fatalf("创建设置返回 %d", status)
+	}
+	var settings models.AISettings
+	if err := db.Where("user_id = ?", 1).First(&settings).Error; err != nil {
+		t.Fatalf("设置应保存到当前用户: %v", err)
+	}
+	if settings.ID == 99 || settings.CreatedAt == 1 || settings.MaxTokens != 4000 || settings.Temperature != 1.2 || settings.PersonalPrompt != "p" {
+		t.Errorf("保存的设置不正确: %+v", settings)
+	}
+	var count int64
+	db.Model(&models.AISettings{}).Where("user_id = ?", 7).Count(&count)
+	if count != 0 {
+		t.Error("不能修改其他用户的设置")
+	}
+
+	// 更新时同样只改可修改的字段
+	if status := updateAISettings(1, `{"user_id":7,"max_tokens":500}`); status != http.StatusOK {
+		t.Fatalf("更新设置返回 %d", status)
+	}
+	db.First(&settings, settings.ID)
+	if settings.UserID != 1 || settings.MaxTokens != 500 || settings.PersonalPrompt != "p" {
+		t.Errorf("更新后的设置不正确: %+v", settings)
+	}
+
+	tests := []string{`{"temperature":3}`, `{"max_tokens":-1}`}
+	for _, body := range tests {
+		if status := updateAISettings(1, body); status != http.StatusBadRequest {
+			t.Errorf("%s 应返回400，得到 %d", body, status)
+		}
+	}
+
+	// 上限调低后，已保存的设置在对话时按新上限
+	services.SetAIQuotaConfig(services.AIQuotaConfig{MaxTokens: 300})
+	if got := loadAISettings(1).MaxTokens; got != 300 {
+		t.Errorf("对话使用的最大token数为 %d，应为 300", got)
+	}
+}
@@ -0,0 +1,280 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"allinone_backend/models"
+	"allinone_backend/services"
+	"allinone_backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AI对话会话和用量相关接口
+
+// 获取会话列表
+func ListAISessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "auth.unauthorized")})
+		return
+	}
+
+	var req struct {
+		Type        string `form:"type"` // personal, group, game，为空时返回全部
+		GroupID     uint   `form:"group_id"`
+		GameID      uint   `form:"game_id"`
+		CharacterID uint   `form:"character_id"`
+		Limit       int    `form:"limit"`
+		Offset      int    `form:"offset"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "common.invalid_params")})
+		return
+	}
+	if req.Limit <= 0 || req.Limit > 100 {
+		req.Limit = 20
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+
+	query := utils.DB.Model(&models.AIChatSession{}).Where("user_id = ?", userID)
+	if req.Type != "" {
+		query = query.Where("type = ?", req.Type)
+	}
+	if req.GroupID > 0 {
+		query = query.Where("group_id = ?", req.GroupID)
+	}
+	if req.GameID > 0 {
+		query = query.Where("game_id = ?", req.GameID)
+	}
+	if req.CharacterID > 0 {
+		query = query.Where("character_id = ?", req.CharacterID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var sessions []models.AIChatSession
+	if err := query.Order("last_message_at DESC, id DESC").Limit(req.Limit).Offset(req.Offset).Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "ai_session.list_failed")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions, "total": total})
+}
+
+// 新建会话，之后的对话通过 session_id 指定该会话
+func CreateAISession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "auth.unauthorized")})
+		return
+	}
+
+	var req struct {
+		Type        string `json:"type" binding:"required"`
+		GroupID     uint   `json:"group_id"`
+		GameID      uint   `json:"game_id"`
+		CharacterID uint   `json:"character_id"`
+		Title       string `json:"title"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "common.invalid_params")})
+		return
+	}
+
+	scope := services.AISessionScope{Type: req.Type}
+	switch req.Type {
+	case "personal":
+	case "group":
+		var count int64
+		utils.DB.Model(&models.GroupMember{}).Where("group_id = ? AND user_id = ?", req.GroupID, userID).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": tr(c, "group.not_member")})
+			return
+		}
+		scope.GroupID = req.GroupID
+	case "game":
+		var game models.Game
+		if err := utils.DB.First(&game, req.GameID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "game.not_found")})
+			return
+		}
+		if req.CharacterID != 0 {
+			if _, err := services.GetGameCharacter(utils.DB, req.GameID, req.CharacterID); err != nil {
+				respondAIPrepareError(c, err)
+				return
+			}
+		}
+		scope.GameID = req.GameID
+		scope.CharacterID = req.CharacterID
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "ai_session.unsupported_type")})
+		return
+	}
+
+	session, err := services.CreateAISession(utils.DB, userID.(uint), scope, req.Title)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "ai_session.create_failed")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session": session})
+}
+
+// 重命名会话
+func RenameAISession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "auth.unauthorized")})
+		return
+	}
+
+	var req struct {
+		Title string `json:"title" binding:"required,max=100"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "common.invalid_params")})
+		return
+	}
+
+	session, ok := findAISession(c, userID.(uint))
+	if !ok {
+		return
+	}
+	session.Title = strings.TrimSpace(req.Title)
+	session.UpdatedAt = time.Now().Unix()
+	if err := utils.DB.Model(session).Updates(map[string]interface{}{"title": session.Title, "updated_at": session.UpdatedAt}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "ai_session.rename_failed")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session": session})
+}
+
+// 导出会话，format 为 json（默认）或 markdown
+func ExportAISession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "auth.unauthorized")})
+		return
+	}
+
+	session, ok := findAISession(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	var messages []models.AIChatMessage
+	if err := utils.DB.Where("session_id = ? AND user_id = ? AND status = ?", session.ID, userID, "completed").
+		Order("id ASC").Find(&messages).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "ai_session.export_failed")})
+		return
+	}
+
+	filename := fmt.Sprintf("ai_session_%d", session.ID)
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		data, _ := json.MarshalIndent(gin.H{"session": session, "messages": messages}, "", "  ")
+		c.Header("Content-Disposition", "attachment; filename="+filename+".json")
+		c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+	case "markdown":
+		var b strings.Builder
+		title := session.Title
+		if title == "" {
+			title = "AI对话"
+		}
+		b.WriteString("# " + title + "\n\n")
+		if session.Summary != "" {
+			b.WriteString("> 摘要：" + session.Summary + "\n\n")
+		}
+		for _, m := range messages {
+			b.WriteString("**我**（" + time.Unix(m.CreatedAt, 0).Format("2006-01-02 15:04") + "）：\n\n" + m.Content + "\n\n")
+			b.WriteString("**AI**：\n\n" + m.Response + "\n\n")
+		}
+		c.Header("Content-Disposition", "attachment; filename="+filename+".md")
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(b.String()))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "ai_session.unsupported_export_format")})
+	}
+}
+
+// 删除会话及其聊天记录
+func DeleteAISession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "auth.unauthorized")})
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "ai_session.invalid_id")})
+		return
+	}
+	if err := services.DeleteAISession(utils.DB, userID.(uint), uint(sessionID)); err != nil {
+		respondAIPrepareError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": tr(c, "ai_session.deleted")})
+}
+
+// 获取AI用量统计：今日用量、额度和最近几天按提供方的明细
+func GetAIUsage(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "auth.unauthorized")})
+		return
+	}
+
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if days <= 0 || days > 90 {
+		days = 7
+	}
+	now := time.Now()
+	since := now.AddDate(0, 0, -(days - 1)).Format("2006-01-02")
+
+	var rows []models.AIUsageDaily
+	if err := utils.DB.Where("user_id = ? AND date >= ?", userID, since).Order("date DESC, provider ASC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "ai_session.usage_query_failed")})
+		return
+	}
+
+	today := services.GetAIUsageTotal(utils.DB, userID.(uint), now.Format("2006-01-02"))
+	quota := services.GetAIQuotaConfig()
+	remaining := gin.H{}
+	if quota.DailyTokens > 0 {
+		remaining["tokens"] = max(quota.DailyTokens-today.TotalTokens, 0)
+	}
+	if quota.DailyRequests > 0 {
+		remaining["requests"] = max(quota.DailyRequests-today.Requests, 0)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"today": today,
+		"quota": gin.H{
+			"daily_tokens":   quota.DailyTokens,
+			"daily_requests": quota.DailyRequests,
+		},
+		"remaining": remaining,
+		"daily":     rows,
+	})
+}
+
+// 查询当前用户的会话，不存在时返回404
+func findAISession(c *gin.Context, userID uint) (*models.AIChatSession, bool) {
+	var session models.AIChatSession
+	if err := utils.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "ai_session.not_found")})
+		return nil, false
+	}
+	return &session, true
+}
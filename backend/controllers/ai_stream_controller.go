@@ -0,0 +1,350 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"allinone_backend/services"
+	"allinone_backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AI流式输出相关接口
+// HTTP使用 server-sent events，聊天内使用WebSocket消息；
+// 客户端断开或取消时终止生成，只有完整生成的回复才会保存，已消耗的用量都计入当日额度
+
+// 个人AI助手流式聊天
+func StreamPersonalAIChat(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "auth.unauthorized")})
+		return
+	}
+
+	var req struct {
+		Message   string `json:"message" binding:"required"`
+		SessionID uint   `json:"session_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "common.invalid_params")})
+		return
+	}
+
+	job, err := preparePersonalAIChat(c.Request.Context(), userID.(uint), req.SessionID, req.Message)
+	if err != nil {
+		respondAIPrepareError(c, err)
+		return
+	}
+	streamAIChatSSE(c, job)
+}
+
+// 群组AI流式聊天
+func StreamGroupAIChat(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "auth.unauthorized")})
+		return
+	}
+
+	var req struct {
+		GroupID   uint   `json:"group_id" binding:"required"`
+		Message   string `json:"message" binding:"required"`
+		SessionID uint   `json:"session_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "common.invalid_params")})
+		return
+	}
+
+	job, err := prepareGroupAIChat(c.Request.Context(), userID.(uint), req.SessionID, req.GroupID, req.Message)
+	if err != nil {
+		respondAIPrepareError(c, err)
+		return
+	}
+	streamAIChatSSE(c, job)
+}
+
+// 游戏AI陪玩流式聊天
+func StreamGameAIChat(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "auth.unauthorized")})
+		return
+	}
+
+	var req struct {
+		GameID      uint   `json:"game_id" binding:"required"`
+		CharacterID uint   `json:"character_id"`
+		Message     string `json:"message" binding:"required"`
+		SessionID   uint   `json:"session_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "common.invalid_params")})
+		return
+	}
+
+	job, err := prepareGameAIChat(c.Request.Context(), userID.(uint), req.SessionID, req.GameID, req.CharacterID, req.Message)
+	if err != nil {
+		respondAIPrepareError(c, err)
+		return
+	}
+	streamAIChatSSE(c, job)
+}
+
+// 以SSE输出AI回复：delta 为增量文本，done 携带保存后的聊天记录，error 为失败原因
+func streamAIChatSSE(c *gin.Context, job *aiChatJob) {
+	if err := checkAIStreamQuota(job); err != nil {
+		respondAIPrepareError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	// 客户端断开时请求的 context 会被取消
+	ctx := c.Request.Context()
+	onDelta, flush := job.deltaFilter(func(delta string) error {
+		c.SSEvent("delta", gin.H{"content": delta})
+		c.Writer.Flush()
+		return ctx.Err()
+	})
+	result, err := utils.ChatStreamWithProviders(ctx, job.settings.AIProvider, job.request(), onDelta)
+	if err == nil {
+		err = flush()
+	}
+	// 取消或出错时 result 为已输出的部分，同样记录用量
+	if result != nil {
+		recordAIChatUsage(job, result)
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			utils.Logger.Errorf("AI流式聊天失败: %v", err)
+			c.SSEvent("error", gin.H{"error": tr(c, aiErrorKey(err))})
+			c.Writer.Flush()
+		}
+		return
+	}
+
+	chatMessage, err := saveAIChatResult(job, result)
+	if err != nil {
+		utils.Logger.Errorf("保存AI聊天记录失败: %v", err)
+		c.SSEvent("error", gin.H{"error": tr(c, "chat.save_history_failed")})
+		c.Writer.Flush()
+		return
+	}
+	c.SSEvent("done", gin.H{"message": chatMessage})
+	c.Writer.Flush()
+}
+
+// 开始输出前检查额度：准备对话时压缩上下文可能已消耗额度
+func checkAIStreamQuota(job *aiChatJob) error {
+	return services.CheckAIQuota(utils.DB, job.record.UserID)
+}
+
+// 包装增量回调：角色对话的回复先经过敏感词过滤再推送，flush 推送过滤器中缓存的剩余文本
+func (j *aiChatJob) deltaFilter(emit func(delta string) error) (onDelta func(delta string) error, flush func() error) {
+	if !j.filterOutput {
+		return emit, func() error { return nil }
+	}
+	stream := &utils.SensitiveWordStream{}
+	write := func(text string) error {
+		if text == "" {
+			return nil
+		}
+		return emit(text)
+	}
+	onDelta = func(delta string) error {
+		return write(stream.Write(delta))
+	}
+	flush = func() error {
+		return write(stream.Flush())
+	}
+	return onDelta, flush
+}
+
+// AI错误对应的提示文案的消息键
+func aiErrorKey(err error) string {
+	switch {
+	case errors.Is(err, utils.ErrAIRateLimited):
+		return "ai.rate_limited"
+	case errors.Is(err, utils.ErrAIModelLoading):
+		return "ai.model_loading"
+	case errors.Is(err, utils.ErrAIUnavailable), errors.Is(err, utils.ErrAINoProvider):
+		return "ai.unavailable"
+	default:
+		return "ai.chat_failed"
+	}
+}
+
+// 单个WebSocket连接上同时进行的流式对话上限
+const maxAIStreamsPerSession = 3
+
+// 单个WebSocket连接上进行中的AI流式对话
+type aiStreamSession struct {
+	ctx       context.Context
+	userID    uint
+	localizer *utils.Localizer // 建立连接时的请求语言
+	write     func(message interface{}) error
+	mu        sync.Mutex
+	seq       uint64
+	cancels   map[string]aiStreamCancel
+}
+
+// 流式对话的取消函数，token 区分重复使用同一 request_id 的不同对话
+type aiStreamCancel struct {
+	token  uint64
+	cancel context.CancelFunc
+}
+
+// 创建会话，ctx 在连接关闭时取消
+func newAIStreamSession(ctx context.Context, userID uint, localizer *utils.Localizer, write func(message interface{}) error) *aiStreamSession {
+	return &aiStreamSession{
+		ctx:       ctx,
+		userID:    userID,
+		localizer: localizer,
+		write:     write,
+		cancels:   make(map[string]aiStreamCancel),
+	}
+}
+
+// 处理 ai_chat 消息：{"type":"ai_chat","request_id":"...","kind":"personal|group|game","message":"...","group_id":1,"game_id":1,"character_id":1,"session_id":1}
+// 依次推送 ai_chat_delta、ai_chat_done 或 ai_chat_error
+func (s *aiStreamSession) start(data map[string]interface{}) {
+	requestID, _ := data["request_id"].(string)
+	kind, _ := data["kind"].(string)
+	message, _ := data["message"].(string)
+	groupID, _ := data["group_id"].(float64)
+	gameID, _ := data["game_id"].(float64)
+	characterID, _ := data["character_id"].(float64)
+	sessionID, _ := data["session_id"].(float64)
+
+	sendError := func(key string, args ...interface{}) {
+		s.write(map[string]interface{}{"type": "ai_chat_error", "request_id": requestID, "error": s.localizer.T(key, args...)})
+	}
+	if requestID == "" || message == "" {
+		sendError("common.invalid_params")
+		return
+	}
+	switch kind {
+	case "", "personal", "group", "game":
+	default:
+		sendError("ai_session.unsupported_type")
+		return
+	}
+
+	// 先登记再准备对话，准备期间（压缩上下文）同样占用名额并可以取消
+	ctx, cancel := context.WithCancel(s.ctx)
+	token, ok := s.register(requestID, cancel)
+	if !ok {
+		cancel()
+		sendError("ai.too_many_streams", maxAIStreamsPerSession)
+		return
+	}
+	finish := func() {
+		cancel()
+		s.release(requestID, token)
+	}
+
+	var job *aiChatJob
+	var err error
+	switch kind {
+	case "", "personal":
+		job, err = preparePersonalAIChat(ctx, s.userID, uint(sessionID), message)
+	case "group":
+		job, err = prepareGroupAIChat(ctx, s.userID, uint(sessionID), uint(groupID), message)
+	case "game":
+		job, err = prepareGameAIChat(ctx, s.userID, uint(sessionID), uint(gameID), uint(characterID), message)
+	}
+	if err == nil {
+		err = checkAIStreamQuota(job)
+	}
+	if err != nil {
+		finish()
+		if appErr, ok := err.(*utils.AppError); ok && appErr.Key != "" {
+			sendError(appErr.Key, appErr.Args...)
+		} else if ok {
+			s.write(map[string]interface{}{"type": "ai_chat_error", "request_id": requestID, "error": appErr.Message})
+		} else {
+			sendError("ai.history_query_failed")
+		}
+		return
+	}
+
+	go func() {
+		defer finish()
+
+		onDelta, flush := job.deltaFilter(func(delta string) error {
+			if err := s.write(map[string]interface{}{"type": "ai_chat_delta", "request_id": requestID, "content": delta}); err != nil {
+				return err
+			}
+			return ctx.Err()
+		})
+		result, err := utils.ChatStreamWithProviders(ctx, job.settings.AIProvider, job.request(), onDelta)
+		if err == nil {
+			err = flush()
+		}
+		// 取消或出错时 result 为已输出的部分，同样记录用量
+		if result != nil {
+			recordAIChatUsage(job, result)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				// 连接已关闭时写入会失败，忽略即可
+				s.write(map[string]interface{}{"type": "ai_chat_cancelled", "request_id": requestID})
+				return
+			}
+			utils.Logger.Errorf("AI流式聊天失败: %v", err)
+			sendError(aiErrorKey(err))
+			return
+		}
+
+		chatMessage, err := saveAIChatResult(job, result)
+		if err != nil {
+			utils.Logger.Errorf("保存AI聊天记录失败: %v", err)
+			sendError("chat.save_history_failed")
+			return
+		}
+		s.write(map[string]interface{}{"type": "ai_chat_done", "request_id": requestID, "message": chatMessage})
+	}()
+}
+
+// register 登记流式对话的取消函数，同一 request_id 上进行中的对话被取消，返回本次对话的 token
+// 进行中的对话已达上限时不登记，返回 false
+func (s *aiStreamSession) register(requestID string, cancel context.CancelFunc) (uint64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, exists := s.cancels[requestID]; exists {
+		old.cancel()
+	} else if len(s.cancels) >= maxAIStreamsPerSession {
+		return 0, false
+	}
+	s.seq++
+	s.cancels[requestID] = aiStreamCancel{token: s.seq, cancel: cancel}
+	return s.seq, true
+}
+
+// release 对话结束后移除取消函数；同一 request_id 已开始新的对话时，保留新对话的取消函数
+func (s *aiStreamSession) release(requestID string, token uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, exists := s.cancels[requestID]; exists && current.token == token {
+		delete(s.cancels, requestID)
+	}
+}
+
+// 处理 ai_chat_cancel 消息，终止指定的流式对话
+func (s *aiStreamSession) cancel(data map[string]interface{}) {
+	requestID, _ := data["request_id"].(string)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, exists := s.cancels[requestID]; exists {
+		current.cancel()
+	}
+}
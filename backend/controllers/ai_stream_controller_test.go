@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/services"
+	"allinone_backend/utils"
+	"context"
+	"testing"
+	"time"
+)
+
+// blockingStreamProvider 输出一段文本后一直等待，直到对话被取消
+type blockingStreamProvider struct{}
+
+func (p *blockingStreamProvider) Name() string {
+	return "blocking"
+}
+
+func (p *blockingStreamProvider) Chat(ctx context.Context, req utils.AIChatRequest) (*utils.AIChatResult, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (p *blockingStreamProvider) ChatStream(ctx context.Context, req utils.AIChatRequest, onDelta func(delta string) error) (*utils.AIChatResult, error) {
+	if err := onDelta("partial reply"); err != nil {
+		return nil, err
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// startAIStreamTest 使用临时数据库和只有 blockingStreamProvider 的回退链，返回连接会话和推送的消息
+func startAIStreamTest(t *testing.T) (*aiStreamSession, <-chan map[string]interface{}) {
+	t.Helper()
+	db := newControllerTestDB(t)
+	db.Create(&models.User{Account: "alice", Nickname: "alice", Password: "-"})
+	utils.SetAIConfig(utils.AIConfig{})
+	utils.RegisterAIProvider(&blockingStreamProvider{})
+	utils.SetAIProviderChain([]string{"blocking"})
+	previousQuota := services.GetAIQuotaConfig()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	messages := make(chan map[string]interface{}, 100)
+	s := newAIStreamSession(ctx, 1, utils.NewLocalizer("en", nil), func(message interface{}) error {
+		messages <- message.(map[string]interface{})
+		return nil
+	})
+	t.Cleanup(func() {
+		// 关闭连接，等进行中的对话记录完用量再恢复数据库
+		cancel()
+		waitAIStreams(s, 0)
+		utils.SetAIConfig(utils.AIConfig{})
+		services.SetAIQuotaConfig(previousQuota)
+	})
+	return s, messages
+}
+
+// waitAIStreams 等待进行中的对话数不超过 n，对话结束时先记录用量再释放
+func waitAIStreams(s *aiStreamSession, n int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		count := len(s.cancels)
+		s.mu.Unlock()
+		if count <= n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// nextAIStreamMessage 等待指定 request_id 的下一条推送
+func nextAIStreamMessage(t *testing.T, messages <-chan map[string]interface{}, requestID string) map[string]interface{} {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case message := <-messages:
+			if message["request_id"] == requestID {
+				return message
+			}
+		case <-timeout:
+			t.Fatalf("等待 %s 的推送超时", requestID)
+			return nil
+		}
+	}
+}
+
+// 重复使用 request_id 时，先开始的对话结束后不能移除后开始的对话的取消函数
+func TestAIStreamSessionReusedRequestID(t *testing.T) {
+	s := newAIStreamSession(context.Background(), 1, nil, func(interface{}) error { return nil })
+
+	firstCtx, firstCancel := context.WithCancel(context.Background())
+	firstToken, _ := s.register("req", firstCancel)
+	secondCtx, secondCancel := context.WithCancel(context.Background())
+	secondToken, _ := s.register("req", secondCancel)
+
+	if firstCtx.Err() == nil {
+		t.Error("开始新的对话时应取消同一 request_id 上进行中的对话")
+	}
+
+	// 第一个对话结束
+	s.release("req", firstToken)
+	s.cancel(map[string]interface{}{"request_id": "req"})
+	if secondCtx.Err() == nil {
+		t.Fatal("第一个对话结束后，第二个对话应仍然可以取消")
+	}
+
+	s.release("req", secondToken)
+	if len(s.cancels) != 0 {
+		t.Errorf("对话结束后应移除取消函数，剩余 %d 个", len(s.cancels))
+	}
+}
+
+// 取消的对话按已输出的内容记录用量
+func TestAIStreamCancelRecordsUsage(t *testing.T) {
+	s, messages := startAIStreamTest(t)
+
+	s.start(map[string]interface{}{"request_id": "r1", "message": "hello"})
+	if message := nextAIStreamMessage(t, messages, "r1"); message["type"] != "ai_chat_delta" {
+		t.Fatalf("应先推送增量文本，得到 %v", message)
+	}
+	s.cancel(map[string]interface{}{"request_id": "r1"})
+	if message := nextAIStreamMessage(t, messages, "r1"); message["type"] != "ai_chat_cancelled" {
+		t.Fatalf("取消后应推送 ai_chat_cancelled，得到 %v", message)
+	}
+
+	// 用量在推送取消消息前已记录
+	usage := services.GetAIUsageTotal(utils.DB, 1, time.Now().Format("2006-01-02"))
+	if usage.Requests != 1 || usage.PromptTokens == 0 || usage.CompletionTokens == 0 {
+		t.Errorf("取消的对话应记录用量，得到 %+v", usage)
+	}
+}
+
+// 每次开始对话都检查额度
+func TestAIStreamQuotaChecked(t *testing.T) {
+	s, messages := startAIStreamTest(t)
+	services.SetAIQuotaConfig(services.AIQuotaConfig{DailyRequests: 1})
+
+	s.start(map[string]interface{}{"request_id": "r1", "message": "hello"})
+	nextAIStreamMessage(t, messages, "r1")
+	s.cancel(map[string]interface{}{"request_id": "r1"})
+	nextAIStreamMessage(t, messages, "r1")
+
+	s.start(map[string]interface{}{"request_id": "r2", "message": "hello"})
+	message := nextAIStreamMessage(t, messages, "r2")
+	if message["type"] != "ai_chat_error" || message["error"] != utils.NewLocalizer("en", nil).T("ai.daily_quota_exceeded") {
+		t.Errorf("额度用完后应拒绝新的对话，得到 %v", message)
+	}
+}
+
+// 同一连接上同时进行的对话数有上限，结束后释放名额
+func TestAIStreamSessionLimit(t *testing.T) {
+	s, messages := startAIStreamTest(t)
+
+	ids := []string{"r1", "r2", "r3"}
+	for _, id := range ids {
+		s.start(map[string]interface{}{"request_id": id, "message": "hello"})
+		if message := nextAIStreamMessage(t, messages, id); message["type"] != "ai_chat_delta" {
+			t.Fatalf("%s 应开始输出，得到 %v", id, message)
+		}
+	}
+	s.start(map[string]interface{}{"request_id": "r4", "message": "hello"})
+	if message := nextAIStreamMessage(t, messages, "r4"); message["type"] != "ai_chat_error" {
+		t.Fatalf("超过上限时应拒绝，得到 %v", message)
+	}
+
+	s.cancel(map[string]interface{}{"request_id": "r1"})
+	nextAIStreamMessage(t, messages, "r1")
+	// 取消推送后 release 才执行，等待名额释放
+	waitAIStreams(s, maxAIStreamsPerSession-1)
+	s.start(map[string]interface{}{"request_id": "r5", "message": "hello"})
+	if message := nextAIStreamMessage(t, messages, "r5"); message["type"] != "ai_chat_delta" {
+		t.Errorf("名额释放后应可以开始新的对话，得到 %v", message)
+	}
+}
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"net/http"
+
+	"allinone_backend/models"
+	"allinone_backend/services"
+	"allinone_backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AI工具调用授权相关接口
+
+// 获取工具调用授权
+func GetAIToolPermissions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "auth.unauthorized")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tools": listAIToolPermissions(userID.(uint))})
+}
+
+// 更新工具调用授权，mode 为 allow、deny 或 ask
+func UpdateAIToolPermission(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "auth.unauthorized")})
+		return
+	}
+
+	var req struct {
+		Tool string `json:"tool" binding:"required"`
+		Mode string `json:"mode" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "common.invalid_params")})
+		return
+	}
+
+	if err := services.SetAIToolPermission(utils.DB, userID.(uint), req.Tool, req.Mode); err != nil {
+		respondAIPrepareError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tools": listAIToolPermissions(userID.(uint))})
+}
+
+// 确认或拒绝等待授权的工具调用，并继续生成回复
+func ConfirmAIToolCalls(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "auth.unauthorized")})
+		return
+	}
+
+	var req struct {
+		MessageID uint `json:"message_id" binding:"required"`
+		Approve   bool `json:"approve"`
+		Remember  bool `json:"remember"` // 记住选择，以后不再询问
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "common.invalid_params")})
+		return
+	}
+
+	// 先将记录切换为处理中，防止重复确认导致工具重复执行
+	claim := utils.DB.Model(&models.AIChatMessage{}).
+		Where("id = ? AND user_id = ? AND status = ?", req.MessageID, userID, "awaiting_permission").
+		Update("status", "processing")
+	if claim.Error != nil || claim.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "ai_tool.no_pending_calls")})
+		return
+	}
+	var record models.AIChatMessage
+	utils.DB.First(&record, req.MessageID)
+
+	// 重新组装对话上下文
+	var job *aiChatJob
+	var err error
+	switch record.Type {
+	case "group":
+		job, err = prepareGroupAIChat(c.Request.Context(), record.UserID, record.SessionID, record.GroupID, record.Content)
+	case "game":
+		job, err = prepareGameAIChat(c.Request.Context(), record.UserID, record.SessionID, record.GameID, record.CharacterID, record.Content)
+	default:
+		job, err = preparePersonalAIChat(c.Request.Context(), record.UserID, record.SessionID, record.Content)
+	}
+	if err != nil {
+		utils.DB.Model(&record).Update("status", "awaiting_permission")
+		respondAIPrepareError(c, err)
+		return
+	}
+	sessionID := job.record.SessionID
+	job.record = record
+	job.record.SessionID = sessionID
+
+	run := &services.AIToolRun{
+		UserID:   record.UserID,
+		Provider: job.settings.AIProvider,
+		Request:  job.request(),
+	}
+	if err := run.LoadTrace(record.ToolTrace); err != nil {
+		utils.DB.Model(&record).Update("status", "awaiting_permission")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "ai_tool.trace_corrupted")})
+		return
+	}
+
+	if req.Remember {
+		mode := "deny"
+		if req.Approve {
+			mode = "allow"
+		}
+		for _, step := range run.Pending() {
+			services.SetAIToolPermission(utils.DB, record.UserID, step.Tool, mode)
+		}
+	}
+
+	run.Resolve(c.Request.Context(), utils.DB, req.Approve)
+	continueAIChat(c, job, run)
+}
+
+// 工具列表及用户的授权状态
+func listAIToolPermissions(userID uint) []gin.H {
+	modes := services.GetAIToolPermissions(utils.DB, userID)
+	tools := []gin.H{}
+	for _, tool := range services.ListAITools() {
+		permission := "allow"
+		if tool.RequiresPermission {
+			permission = modes[tool.Name]
+			if permission == "" {
+				permission = "ask"
+			}
+		}
+		tools = append(tools, gin.H{
+			"name":                tool.Name,
+			"title":               tool.Title,
+			"description":         tool.Description,
+			"parameters":          tool.Parameters,
+			"requires_permission": tool.RequiresPermission,
+			"permission":          permission,
+		})
+	}
+	return tools
+}
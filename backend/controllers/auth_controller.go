@@ -1,16 +1,117 @@
 package controllers
 
 import (
+	"allinone_backend/services"
+	"allinone_backend/utils"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-// 退出登录
+// 退出登录，当前会话签发的访问令牌和刷新令牌全部失效
 func Logout(c *gin.Context) {
-	// 前端只需清除本地token/session即可，后端可返回成功
+	claims, ok := c.Get("claims")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	if err := services.Logout(db, claims.(*utils.Claims)); err != nil {
+		respondAppError(c, err, tr(c, "auth.logout_failed"))
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "退出登录成功",
+		"msg":     tr(c, "auth.logout_success"),
 	})
 }
+
+// 使用刷新令牌换取新的访问令牌，刷新令牌同时轮换，旧的刷新令牌不能再次使用
+func RefreshToken(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+
+	tokens, err := services.RefreshSession(utils.DB, req.RefreshToken, c.ClientIP())
+	if err != nil {
+		respondAppError(c, err, tr(c, "auth.refresh_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": tokens})
+}
+
+// 获取已登录的设备列表
+func GetSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	devices, err := services.ListSessions(db, userID.(uint))
+	if err != nil {
+		respondAppError(c, err, tr(c, "auth.devices_query_failed"))
+		return
+	}
+
+	currentSessionID := c.GetString("session_id")
+	sessions := make([]gin.H, 0, len(devices))
+	for _, device := range devices {
+		sessions = append(sessions, gin.H{
+			"id":             device.ID,
+			"device_id":      device.DeviceID,
+			"device_type":    device.DeviceType,
+			"device_name":    device.DeviceName,
+			"device_model":   device.DeviceModel,
+			"os_version":     device.OSVersion,
+			"app_version":    device.AppVersion,
+			"ip_address":     device.IPAddress,
+			"last_login_at":  device.LastLoginAt,
+			"last_active_at": device.LastActiveAt,
+			"current":        currentSessionID != "" && device.SessionID == currentSessionID,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": sessions})
+}
+
+// 远程下线指定设备
+func RevokeSession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	deviceID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "auth.invalid_device_id")})
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	if err := services.RevokeSession(db, userID.(uint), uint(deviceID)); err != nil {
+		respondAppError(c, err, tr(c, "auth.revoke_device_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "auth.device_revoked")})
+}
+
+// 下线当前设备以外的所有设备
+func RevokeOtherSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	count, err := services.RevokeOtherSessions(db, userID.(uint), c.GetString("session_id"))
+	if err != nil {
+		respondAppError(c, err, tr(c, "auth.revoke_device_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "auth.other_devices_revoked"), "data": gin.H{"count": count}})
+}
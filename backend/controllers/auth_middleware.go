@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"allinone_backend/services"
 	"allinone_backend/utils"
 	"net/http"
 	"strings"
@@ -16,7 +17,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"success": false,
-				"msg":     "未提供token",
+				"msg":     tr(c, "auth.token_not_provided"),
 			})
 			c.Abort()
 			return
@@ -26,7 +27,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		if !strings.HasPrefix(authHeader, "Bearer ") {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"success": false,
-				"msg":     "无效的token格式",
+				"msg":     tr(c, "auth.token_malformed"),
 			})
 			c.Abort()
 			return
@@ -40,7 +41,17 @@ func AuthMiddleware() gin.HandlerFunc {
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"success": false,
-				"msg":     "token无效或已过期",
+				"msg":     tr(c, "auth.token_invalid"),
+			})
+			c.Abort()
+			return
+		}
+
+		// 检查令牌是否已被吊销
+		if services.IsTokenRevoked(claims) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"msg":     tr(c, "auth.token_revoked"),
 			})
 			c.Abort()
 			return
@@ -49,6 +60,8 @@ func AuthMiddleware() gin.HandlerFunc {
 		// 将用户ID和账号存储在上下文中
 		c.Set("user_id", claims.UserID)
 		c.Set("account", claims.Account)
+		c.Set("session_id", claims.SessionID)
+		c.Set("claims", claims)
 
 		c.Next()
 	}
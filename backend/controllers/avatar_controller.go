@@ -2,18 +2,16 @@ package controllers
 
 import (
 	"allinone_backend/models"
+	"allinone_backend/services"
 	"allinone_backend/utils"
+	"bytes"
 	"encoding/base64"
-	"fmt"
+	"io"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
 // 上传头像（Base64格式）
@@ -21,7 +19,7 @@ func UploadAvatar(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -31,85 +29,32 @@ func UploadAvatar(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 检查Base64数据
 	if !strings.HasPrefix(req.AvatarBase64, "data:image/") {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "无效的图片格式"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "avatar.invalid_image_format")})
 		return
 	}
 
 	// 解析Base64数据
 	commaIndex := strings.Index(req.AvatarBase64, ",")
 	if commaIndex == -1 {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "无效的Base64数据"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "avatar.invalid_base64")})
 		return
 	}
 
-	// 获取MIME类型
-	mimeType := req.AvatarBase64[5:commaIndex]
-	if !strings.HasPrefix(mimeType, "image/") {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "只支持图片格式"})
-		return
-	}
-
-	// 获取文件扩展名
-	var fileExt string
-	switch mimeType {
-	case "image/jpeg":
-		fileExt = ".jpg"
-	case "image/png":
-		fileExt = ".png"
-	case "image/gif":
-		fileExt = ".gif"
-	case "image/webp":
-		fileExt = ".webp"
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "不支持的图片格式"})
-		return
-	}
-
-	// 解码Base64数据
+	// 解码Base64数据，图片格式按内容识别
 	base64Data := req.AvatarBase64[commaIndex+1:]
 	imageData, err := base64.StdEncoding.DecodeString(base64Data)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "Base64解码失败"})
-		return
-	}
-
-	// 创建上传目录
-	uploadDir := "uploads/avatars"
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "创建上传目录失败"})
-		return
-	}
-
-	// 生成唯一文件名
-	fileName := fmt.Sprintf("%s_%d%s", uuid.New().String(), time.Now().Unix(), fileExt)
-	filePath := filepath.Join(uploadDir, fileName)
-
-	// 保存文件
-	if err := os.WriteFile(filePath, imageData, 0644); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "保存文件失败"})
-		return
-	}
-
-	// 更新用户头像
-	avatarURL := "/uploads/avatars/" + fileName
-	if err := utils.DB.Model(&models.User{}).Where("id = ?", userID).Update("avatar", avatarURL).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "更新头像失败"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "avatar.base64_decode_failed")})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"msg":     "头像上传成功",
-		"data": gin.H{
-			"avatar_url": avatarURL,
-		},
-	})
+	saveAvatar(c, userID.(uint), bytes.NewReader(imageData), "avatar")
 }
 
 // 上传头像（文件上传）
@@ -117,69 +62,56 @@ func UploadAvatarFile(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
 	// 获取上传的文件
 	file, err := c.FormFile("avatar")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "未找到上传文件"})
-		return
-	}
-
-	// 检查文件类型
-	contentType := file.Header.Get("Content-Type")
-	if !strings.HasPrefix(contentType, "image/") {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "只支持图片格式"})
-		return
-	}
-
-	// 获取文件扩展名
-	var fileExt string
-	switch contentType {
-	case "image/jpeg":
-		fileExt = ".jpg"
-	case "image/png":
-		fileExt = ".png"
-	case "image/gif":
-		fileExt = ".gif"
-	case "image/webp":
-		fileExt = ".webp"
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "不支持的图片格式"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "file.missing")})
 		return
 	}
 
-	// 创建上传目录
-	uploadDir := "uploads/avatars"
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "创建上传目录失败"})
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "file.missing")})
 		return
 	}
+	defer src.Close()
 
-	// 生成唯一文件名
-	fileName := fmt.Sprintf("%s_%d%s", uuid.New().String(), time.Now().Unix(), fileExt)
-	filePath := filepath.Join(uploadDir, fileName)
+	saveAvatar(c, userID.(uint), src, file.Filename)
+}
 
-	// 保存文件
-	if err := c.SaveUploadedFile(file, filePath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "保存文件失败"})
+// saveAvatar 处理并保存头像，头像公开可见，同时保存缩略图
+func saveAvatar(c *gin.Context, userID uint, body io.Reader, fileName string) {
+	media, err := services.StoreImage(c.Request.Context(), utils.DB, userID, body, fileName,
+		utils.ImageKindAvatar, services.MediaTarget{Scope: services.MediaScopePublic})
+	if err != nil {
+		respondAppError(c, err, tr(c, "file.save_failed"))
 		return
 	}
 
 	// 更新用户头像
-	avatarURL := "/uploads/avatars/" + fileName
-	if err := utils.DB.Model(&models.User{}).Where("id = ?", userID).Update("avatar", avatarURL).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "更新头像失败"})
+	avatarURL := services.MediaRef(media)
+	thumbURL := avatarURL
+	if thumb, ok := services.MediaRenditions(utils.DB, media.ID)["thumb"]; ok {
+		thumbURL = services.MediaRef(&thumb)
+	}
+	if err := utils.DB.Model(&models.User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{"avatar": avatarURL, "avatar_thumb": thumbURL}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "avatar.update_failed")})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "头像上传成功",
+		"msg":     tr(c, "avatar.upload_success"),
 		"data": gin.H{
-			"avatar_url": avatarURL,
+			"avatar_url":   avatarURL,
+			"avatar_thumb": thumbURL,
+			"width":        media.Width,
+			"height":       media.Height,
 		},
 	})
 }
@@ -189,7 +121,7 @@ func UpdateNickname(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -199,25 +131,25 @@ func UpdateNickname(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 检查昵称长度
 	if len(req.Nickname) < 2 || len(req.Nickname) > 20 {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "昵称长度应在2-20个字符之间"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "user.nickname_length")})
 		return
 	}
 
 	// 更新用户昵称
 	if err := utils.DB.Model(&models.User{}).Where("id = ?", userID).Update("nickname", req.Nickname).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "更新昵称失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "user.nickname_update_failed")})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "昵称更新成功",
+		"msg":     tr(c, "user.nickname_updated"),
 		"data": gin.H{
 			"nickname": req.Nickname,
 		},
@@ -230,14 +162,14 @@ func GetUserAvatar(c *gin.Context) {
 	userIDStr := c.Param("id")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "无效的用户ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
 	// 查询用户
 	var user models.User
-	if err := utils.DB.Select("avatar").First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "用户不存在"})
+	if err := utils.DB.Select("avatar", "avatar_thumb").First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "user.not_found")})
 		return
 	}
 
@@ -245,7 +177,8 @@ func GetUserAvatar(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
-			"avatar_url": user.Avatar,
+			"avatar_url":   user.Avatar,
+			"avatar_thumb": user.AvatarThumb,
 		},
 	})
 }
@@ -16,7 +16,7 @@ func AddBankCard(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -34,20 +34,20 @@ func AddBankCard(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 检查卡号格式
 	if !utils.IsValidCardNumber(req.CardNumber) {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "无效的银行卡号"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "bank_card.invalid_number")})
 		return
 	}
 
 	// 检查卡号是否已存在
 	var existingCard models.BankCard
 	if err := utils.DB.Where("card_number = ? AND user_id = ?", req.CardNumber, userID).First(&existingCard).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "该银行卡已绑定"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "bank_card.already_bound")})
 		return
 	}
 
@@ -59,7 +59,7 @@ func AddBankCard(c *gin.Context) {
 	if req.PhoneNumber != "15210888310" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "请使用15210888310手机号进行测试",
+			"msg":     tr(c, "bank_card.test_phone_only"),
 		})
 		return
 	}
@@ -88,12 +88,12 @@ func AddBankCard(c *gin.Context) {
 
 	// 处理验证结果
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "银行卡验证服务异常"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "bank_card.verify_service_error")})
 		return
 	}
 
 	if verifyResponse == nil || !verifyResponse.Data.IsValid {
-		errorReason := "银行卡验证失败"
+		errorReason := tr(c, "bank_card.verify_failed")
 		if verifyResponse != nil && verifyResponse.Data.ErrorReason != "" {
 			errorReason = verifyResponse.Data.ErrorReason
 		}
@@ -124,13 +124,13 @@ func AddBankCard(c *gin.Context) {
 	}
 
 	if err := utils.DB.Create(&bankCard).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "添加银行卡失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "bank_card.add_failed")})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "银行卡添加成功",
+		"msg":     tr(c, "bank_card.added"),
 		"data":    bankCard,
 	})
 }
@@ -140,14 +140,14 @@ func GetBankCards(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
 	// 查询银行卡列表
 	var bankCards []models.BankCard
 	if err := utils.DB.Where("user_id = ?", userID).Find(&bankCards).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "获取银行卡列表失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "bank_card.list_failed")})
 		return
 	}
 
@@ -167,7 +167,7 @@ func DeleteBankCard(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -175,20 +175,20 @@ func DeleteBankCard(c *gin.Context) {
 	cardIDStr := c.Param("id")
 	cardID, err := strconv.ParseUint(cardIDStr, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "无效的银行卡ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "bank_card.invalid_id")})
 		return
 	}
 
 	// 检查银行卡是否存在且属于当前用户
 	var bankCard models.BankCard
 	if err := utils.DB.Where("id = ? AND user_id = ?", cardID, userID).First(&bankCard).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "银行卡不存在或不属于当前用户"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "bank_card.not_found")})
 		return
 	}
 
 	// 删除银行卡
 	if err := utils.DB.Delete(&bankCard).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "删除银行卡失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "bank_card.delete_failed")})
 		return
 	}
 
@@ -202,7 +202,7 @@ func DeleteBankCard(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "银行卡删除成功",
+		"msg":     tr(c, "bank_card.deleted"),
 	})
 }
 
@@ -211,7 +211,7 @@ func SetDefaultBankCard(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -219,14 +219,14 @@ func SetDefaultBankCard(c *gin.Context) {
 	cardIDStr := c.Param("id")
 	cardID, err := strconv.ParseUint(cardIDStr, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "无效的银行卡ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "bank_card.invalid_id")})
 		return
 	}
 
 	// 检查银行卡是否存在且属于当前用户
 	var bankCard models.BankCard
 	if err := utils.DB.Where("id = ? AND user_id = ?", cardID, userID).First(&bankCard).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "银行卡不存在或不属于当前用户"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "bank_card.not_found")})
 		return
 	}
 
@@ -235,12 +235,12 @@ func SetDefaultBankCard(c *gin.Context) {
 
 	// 将当前银行卡设置为默认
 	if err := utils.DB.Model(&bankCard).Update("is_default", true).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "设置默认银行卡失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "bank_card.set_default_failed")})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "设置默认银行卡成功",
+		"msg":     tr(c, "bank_card.default_set"),
 	})
 }
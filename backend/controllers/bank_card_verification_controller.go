@@ -16,7 +16,7 @@ func InitiateBankCardVerification(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -28,13 +28,13 @@ func InitiateBankCardVerification(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 检查卡号格式
 	if !utils.IsValidCardNumber(req.CardNumber) {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "无效的银行卡号"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "bank_card.invalid_number")})
 		return
 	}
 
@@ -42,7 +42,7 @@ func InitiateBankCardVerification(c *gin.Context) {
 	if req.PhoneNumber != "15210888310" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "请使用15210888310手机号进行测试",
+			"msg":     tr(c, "bank_card.test_phone_only"),
 		})
 		return
 	}
@@ -64,7 +64,7 @@ func InitiateBankCardVerification(c *gin.Context) {
 	}
 
 	if err := utils.DB.Create(&verification).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "创建验证记录失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "bank_card.verification_create_failed")})
 		return
 	}
 
@@ -73,7 +73,7 @@ func InitiateBankCardVerification(c *gin.Context) {
 	// 但在测试环境中，我们直接返回验证码
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "验证码已发送",
+		"msg":     tr(c, "verification.code_sent"),
 		"data": gin.H{
 			"verification_id": verification.ID,
 			"code":           verificationCode, // 注意：实际应用中不应返回验证码
@@ -86,7 +86,7 @@ func ConfirmBankCardVerification(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -97,39 +97,39 @@ func ConfirmBankCardVerification(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 查询验证记录
 	var verification models.BankCardVerification
 	if err := utils.DB.Where("id = ? AND user_id = ?", req.VerificationID, userID).First(&verification).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "验证记录不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "bank_card.verification_not_found")})
 		return
 	}
 
 	// 检查验证码是否过期
 	if verification.ExpiresAt < time.Now().Unix() {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "验证码已过期"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "verification.code_expired")})
 		return
 	}
 
 	// 检查验证码是否正确
 	if verification.VerificationCode != req.Code {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "验证码错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "verification.code_invalid")})
 		return
 	}
 
 	// 更新验证状态
 	verification.Status = "verified"
 	if err := utils.DB.Save(&verification).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "更新验证状态失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "bank_card.verification_update_failed")})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "银行卡验证成功",
+		"msg":     tr(c, "bank_card.verified"),
 		"data": gin.H{
 			"card_number":     verification.CardNumber,
 			"cardholder_name": verification.CardholderName,
@@ -17,7 +17,7 @@ func StartVoiceCall(c *gin.Context) {
 		ReceiverID uint `json:"receiver_id"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
@@ -31,14 +31,14 @@ func StartVoiceCall(c *gin.Context) {
 		Status:     0, // 未接通
 	}
 	if err := db.Create(&call).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "创建通话记录失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "call.create_failed")})
 		return
 	}
 
 	// 返回通话ID和信令服务器信息
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "已发起语音通话",
+		"msg":     tr(c, "call.voice_started"),
 		"data": gin.H{
 			"call_id":      call.ID,
 			"signaling":    "wss://signaling.allinone.com/ws",
@@ -54,14 +54,14 @@ func EndVoiceCall(c *gin.Context) {
 		CallID uint `json:"call_id"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	db := c.MustGet("db").(*gorm.DB)
 	var call models.VoiceCallRecord
 	if err := db.First(&call, req.CallID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "通话记录不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "call.not_found")})
 		return
 	}
 
@@ -76,7 +76,7 @@ func EndVoiceCall(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "通话已结束",
+		"msg":     tr(c, "call.ended"),
 		"data": gin.H{
 			"call_id":  call.ID,
 			"duration": duration,
@@ -91,7 +91,7 @@ func AcceptVoiceCall(c *gin.Context) {
 		ReceiverID uint `json:"receiver_id"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
@@ -103,7 +103,7 @@ func AcceptVoiceCall(c *gin.Context) {
 		req.CallerID, req.ReceiverID).
 		Order("start_time desc").
 		First(&call).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "通话记录不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "call.not_found")})
 		return
 	}
 
@@ -114,7 +114,7 @@ func AcceptVoiceCall(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "已接受通话",
+		"msg":     tr(c, "call.accepted"),
 		"data": gin.H{
 			"call_id": call.ID,
 		},
@@ -133,7 +133,7 @@ func RejectVoiceCall(c *gin.Context) {
 			ReceiverID uint `json:"receiver_id"`
 		}
 		if err := c.ShouldBindJSON(&oldReq); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 			return
 		}
 
@@ -144,7 +144,7 @@ func RejectVoiceCall(c *gin.Context) {
 			oldReq.CallerID, oldReq.ReceiverID).
 			Order("start_time desc").
 			First(&call).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "通话记录不存在"})
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "call.not_found")})
 			return
 		}
 
@@ -158,7 +158,7 @@ func RejectVoiceCall(c *gin.Context) {
 
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
-			"msg":     "已拒绝通话",
+			"msg":     tr(c, "call.rejected"),
 		})
 		return
 	}
@@ -166,7 +166,7 @@ func RejectVoiceCall(c *gin.Context) {
 	db := c.MustGet("db").(*gorm.DB)
 	var call models.VoiceCallRecord
 	if err := db.First(&call, req.CallID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "通话记录不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "call.not_found")})
 		return
 	}
 
@@ -180,7 +180,7 @@ func RejectVoiceCall(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "已拒绝通话",
+		"msg":     tr(c, "call.rejected"),
 	})
 }
 
@@ -191,7 +191,7 @@ func StartVideoCall(c *gin.Context) {
 		ReceiverID uint `json:"receiver_id"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
@@ -205,14 +205,14 @@ func StartVideoCall(c *gin.Context) {
 		Status:     0, // 未接通
 	}
 	if err := db.Create(&call).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "创建通话记录失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "call.create_failed")})
 		return
 	}
 
 	// 返回通话ID和信令服务器信息
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "已发起视频通话",
+		"msg":     tr(c, "call.video_started"),
 		"data": gin.H{
 			"call_id":      call.ID,
 			"signaling":    "wss://signaling.allinone.com/ws",
@@ -228,14 +228,14 @@ func EndVideoCall(c *gin.Context) {
 		CallID uint `json:"call_id"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	db := c.MustGet("db").(*gorm.DB)
 	var call models.VideoCallRecord
 	if err := db.First(&call, req.CallID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "通话记录不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "call.not_found")})
 		return
 	}
 
@@ -250,7 +250,7 @@ func EndVideoCall(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "通话已结束",
+		"msg":     tr(c, "call.ended"),
 		"data": gin.H{
 			"call_id":  call.ID,
 			"duration": duration,
@@ -265,7 +265,7 @@ func AcceptVideoCall(c *gin.Context) {
 		ReceiverID uint `json:"receiver_id"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
@@ -277,7 +277,7 @@ func AcceptVideoCall(c *gin.Context) {
 		req.CallerID, req.ReceiverID).
 		Order("start_time desc").
 		First(&call).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "通话记录不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "call.not_found")})
 		return
 	}
 
@@ -288,7 +288,7 @@ func AcceptVideoCall(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "已接受通话",
+		"msg":     tr(c, "call.accepted"),
 		"data": gin.H{
 			"call_id": call.ID,
 		},
@@ -307,7 +307,7 @@ func RejectVideoCall(c *gin.Context) {
 			ReceiverID uint `json:"receiver_id"`
 		}
 		if err := c.ShouldBindJSON(&oldReq); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 			return
 		}
 
@@ -318,7 +318,7 @@ func RejectVideoCall(c *gin.Context) {
 			oldReq.CallerID, oldReq.ReceiverID).
 			Order("start_time desc").
 			First(&call).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "通话记录不存在"})
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "call.not_found")})
 			return
 		}
 
@@ -332,7 +332,7 @@ func RejectVideoCall(c *gin.Context) {
 
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
-			"msg":     "已拒绝通话",
+			"msg":     tr(c, "call.rejected"),
 		})
 		return
 	}
@@ -340,7 +340,7 @@ func RejectVideoCall(c *gin.Context) {
 	db := c.MustGet("db").(*gorm.DB)
 	var call models.VideoCallRecord
 	if err := db.First(&call, req.CallID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "通话记录不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "call.not_found")})
 		return
 	}
 
@@ -354,7 +354,7 @@ func RejectVideoCall(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "已拒绝通话",
+		"msg":     tr(c, "call.rejected"),
 	})
 }
 
@@ -363,7 +363,7 @@ func GetCallHistory(c *gin.Context) {
 	userIDStr := c.Query("user_id")
 	userID, err := strconv.Atoi(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
@@ -17,7 +17,7 @@ func ClearAllUserMessages(c *gin.Context) {
 	if userID == 0 {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
-			"msg":     "未授权",
+			"msg":     tr(c, "auth.unauthorized"),
 		})
 		return
 	}
@@ -59,14 +59,14 @@ func ClearAllUserMessages(c *gin.Context) {
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"msg":     "清除聊天记录失败: " + err.Error(),
+			"msg":     tr(c, "chat.clear_failed_detail", err.Error()),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "所有聊天记录已清除",
+		"msg":     tr(c, "chat.all_cleared"),
 	})
 }
 
@@ -76,7 +76,7 @@ func ClearChatMessages(c *gin.Context) {
 	if userID == 0 {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
-			"msg":     "未授权",
+			"msg":     tr(c, "auth.unauthorized"),
 		})
 		return
 	}
@@ -88,7 +88,7 @@ func ClearChatMessages(c *gin.Context) {
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "参数错误",
+			"msg":     tr(c, "common.invalid_params"),
 		})
 		return
 	}
@@ -133,13 +133,13 @@ func ClearChatMessages(c *gin.Context) {
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"msg":     "清除聊天记录失败: " + err.Error(),
+			"msg":     tr(c, "chat.clear_failed_detail", err.Error()),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "聊天记录已清除",
+		"msg":     tr(c, "chat.cleared"),
 	})
 }
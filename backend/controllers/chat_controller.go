@@ -3,7 +3,7 @@ package controllers
 import (
 	"allinone_backend/models"
 	"allinone_backend/repositories"
-	"allinone_backend/utils"
+	"allinone_backend/services"
 	"strconv"
 	"strings"
 	"time"
@@ -20,72 +20,114 @@ func SendMessage(c *gin.Context) {
 		ToID    string `json:"to_id"`
 		Content string `json:"content"`
 		Type    string `json:"type"`
+		Extra   string `json:"extra"`
+		MediaID uint   `json:"media_id"` // 引用已上传的媒体，Extra 中会附带缩略图地址
+		// 表情消息引用的表情，Extra 中会附带表情地址和宽高
+		EmoticonID uint `json:"emoticon_id"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 转换ID
 	fromID, err := strconv.ParseUint(req.FromID, 10, 32)
 	if err != nil {
-		c.JSON(400, gin.H{"success": false, "msg": "无效的发送者ID"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "chat.invalid_sender_id")})
 		return
 	}
 
 	toID, err := strconv.ParseUint(req.ToID, 10, 32)
 	if err != nil {
-		c.JSON(400, gin.H{"success": false, "msg": "无效的接收者ID"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "chat.invalid_receiver_id")})
 		return
 	}
 
+	db := c.MustGet("db").(*gorm.DB)
+
+	// 文件消息引用上传完成的媒体
+	if req.Type == "file" && req.MediaID == 0 {
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "chat.media_id_required")})
+		return
+	}
+
+	// 引用媒体时校验访问权限，必要时复制为会话可见
+	if req.MediaID != 0 {
+		senderID := c.GetUint("user_id")
+		if senderID == 0 {
+			senderID = uint(fromID)
+		}
+		target := services.MediaTarget{Scope: services.MediaScopeConversation, ConversationType: services.ConversationPrivate, ConversationID: uint(toID)}
+		ref, extra, ok := attachMessageMedia(c, db, senderID, req.MediaID, target, req.Extra)
+		if !ok {
+			return
+		}
+		if req.Content == "" {
+			req.Content = ref
+		}
+		req.Extra = extra
+	}
+
+	// 表情消息校验发送者可以使用该表情
+	if req.Type == "emoticon" {
+		senderID := c.GetUint("user_id")
+		if senderID == 0 {
+			senderID = uint(fromID)
+		}
+		ref, extra, ok := attachMessageEmoticon(c, db, senderID, req.EmoticonID, req.Extra)
+		if !ok {
+			return
+		}
+		req.Content = ref
+		req.Extra = extra
+	}
+
 	// 创建消息
 	message := models.ChatMessage{
 		SenderID:   uint(fromID),
 		ReceiverID: uint(toID),
 		Content:    req.Content,
 		Type:       req.Type,
+		Extra:      req.Extra,
 		CreatedAt:  time.Now().Unix(),
 	}
 
 	// 保存消息
-	db := c.MustGet("db").(*gorm.DB)
 	if err := db.Create(&message).Error; err != nil {
-		c.JSON(500, gin.H{"success": false, "msg": "消息保存失败"})
+		c.JSON(500, gin.H{"success": false, "msg": tr(c, "chat.save_failed")})
 		return
 	}
 
 	// 通过WebSocket推送实时消息
 	// 使用WebSocketManager发送消息
 	// 注意：实际部署时需要确保WebSocket服务已正确配置
-	// 这里我们使用utils.PushMessageToUser函数发送消息
+	// 这里我们使用services.PushChatMessage函数发送消息
 	go func() {
 		// 构造消息
-		wsMessage := map[string]any{
-			"type": "new_message",
-			"data": map[string]any{
-				"id":         message.ID,
-				"from_id":    message.SenderID,
-				"to_id":      message.ReceiverID,
-				"content":    message.Content,
-				"type":       message.Type,
-				"created_at": message.CreatedAt,
-			},
+		data := map[string]any{
+			"id":         message.ID,
+			"from_id":    message.SenderID,
+			"to_id":      message.ReceiverID,
+			"content":    message.Content,
+			"type":       message.Type,
+			"extra":      message.Extra,
+			"created_at": message.CreatedAt,
 		}
-		// 发送消息到接收者
-		utils.PushMessageToUser(uint(toID), wsMessage)
+		// 发送消息到接收者，接收者开启自动翻译时附带译文
+		services.PushChatMessage(db, &message, []uint{uint(toID)}, "new_message", data)
 	}()
 
 	c.JSON(200, gin.H{
 		"success": true,
-		"msg":     "发送成功",
+		"msg":     tr(c, "chat.sent"),
 		"data": gin.H{
 			"id":         message.ID,
 			"from_id":    message.SenderID,
 			"to_id":      message.ReceiverID,
 			"content":    message.Content,
 			"type":       message.Type,
+			"extra":      message.Extra,
 			"created_at": message.CreatedAt,
 		},
 	})
@@ -97,19 +139,19 @@ func GetMessagesByUser(c *gin.Context) {
 	targetIDStr := c.Query("target_id")
 
 	if userIDStr == "" || targetIDStr == "" {
-		c.JSON(400, gin.H{"success": false, "msg": "缺少必要参数"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "common.missing_params")})
 		return
 	}
 
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		c.JSON(400, gin.H{"success": false, "msg": "无效的user_id参数"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id_param")})
 		return
 	}
 
 	targetID, err := strconv.ParseUint(targetIDStr, 10, 32)
 	if err != nil {
-		c.JSON(400, gin.H{"success": false, "msg": "无效的target_id参数"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "chat.invalid_target_id")})
 		return
 	}
 
@@ -121,7 +163,7 @@ func GetMessagesByUser(c *gin.Context) {
 		"(sender_id = ? AND receiver_id = ?) OR (sender_id = ? AND receiver_id = ?)",
 		userID, targetID, targetID, userID,
 	).Order("created_at ASC").Find(&messages).Error; err != nil {
-		c.JSON(500, gin.H{"success": false, "msg": "查询消息失败"})
+		c.JSON(500, gin.H{"success": false, "msg": tr(c, "chat.query_failed")})
 		return
 	}
 
@@ -132,16 +174,20 @@ func GetMessagesByUser(c *gin.Context) {
 	db.First(&user, userID)
 	db.First(&target, targetID)
 
+	// 开启自动翻译时附带用户的译文
+	translations := services.LoadMessageTranslations(c.Request.Context(), db, uint(userID), messages)
+
 	// 构造响应数据
 	var result []gin.H
 	for _, msg := range messages {
 		result = append(result, gin.H{
-			"id":         msg.ID,
-			"from_id":    msg.SenderID,
-			"to_id":      msg.ReceiverID,
-			"content":    msg.Content,
-			"type":       msg.Type,
-			"created_at": msg.CreatedAt,
+			"id":          msg.ID,
+			"from_id":     msg.SenderID,
+			"to_id":       msg.ReceiverID,
+			"content":     msg.Content,
+			"type":        msg.Type,
+			"created_at":  msg.CreatedAt,
+			"translation": translations[msg.ID],
 			"from_nickname": func() string {
 				if msg.SenderID == uint(userID) {
 					return user.Nickname
@@ -165,21 +211,23 @@ func GroupChat(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(401, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(401, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
 	// 解析请求参数
 	var req struct {
 		GroupID        uint   `json:"group_id" binding:"required"`
-		Content        string `json:"content" binding:"required"`
+		Content        string `json:"content"`
 		Type           string `json:"type" binding:"required"`
 		MentionedUsers []uint `json:"mentioned_users"`
 		Extra          string `json:"extra"`
+		MediaID        uint   `json:"media_id"`    // 引用已上传的媒体
+		EmoticonID     uint   `json:"emoticon_id"` // 表情消息引用的表情
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
@@ -187,14 +235,14 @@ func GroupChat(c *gin.Context) {
 	db := c.MustGet("db").(*gorm.DB)
 	var groupMember models.GroupMember
 	if err := db.Where("group_id = ? AND user_id = ?", req.GroupID, userID).First(&groupMember).Error; err != nil {
-		c.JSON(403, gin.H{"success": false, "msg": "您不是该群组成员"})
+		c.JSON(403, gin.H{"success": false, "msg": tr(c, "group.not_member")})
 		return
 	}
 
 	// 检查群组是否存在
 	var group models.Group
 	if err := db.First(&group, req.GroupID).Error; err != nil {
-		c.JSON(404, gin.H{"success": false, "msg": "群组不存在"})
+		c.JSON(404, gin.H{"success": false, "msg": tr(c, "group.not_found")})
 		return
 	}
 
@@ -207,9 +255,42 @@ func GroupChat(c *gin.Context) {
 			groupMember.MutedUntil = 0
 			db.Save(&groupMember)
 		} else {
-			c.JSON(403, gin.H{"success": false, "msg": "您已被禁言"})
+			c.JSON(403, gin.H{"success": false, "msg": tr(c, "group.muted")})
+			return
+		}
+	}
+
+	// 文件消息引用上传完成的媒体
+	if req.Type == "file" && req.MediaID == 0 {
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "chat.media_id_required")})
+		return
+	}
+
+	// 引用媒体时校验访问权限，必要时复制为群内可见
+	if req.MediaID != 0 {
+		target := services.MediaTarget{Scope: services.MediaScopeConversation, ConversationType: services.ConversationGroup, ConversationID: req.GroupID}
+		ref, extra, ok := attachMessageMedia(c, db, userID.(uint), req.MediaID, target, req.Extra)
+		if !ok {
+			return
+		}
+		if req.Content == "" {
+			req.Content = ref
+		}
+		req.Extra = extra
+	}
+
+	// 表情消息校验发送者可以使用该表情
+	if req.Type == "emoticon" {
+		ref, extra, ok := attachMessageEmoticon(c, db, userID.(uint), req.EmoticonID, req.Extra)
+		if !ok {
 			return
 		}
+		req.Content = ref
+		req.Extra = extra
+	}
+	if req.Content == "" {
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
 	}
 
 	// 处理@用户
@@ -249,7 +330,7 @@ func GroupChat(c *gin.Context) {
 
 	// 保存消息
 	if err := db.Create(&message).Error; err != nil {
-		c.JSON(500, gin.H{"success": false, "msg": "消息保存失败"})
+		c.JSON(500, gin.H{"success": false, "msg": tr(c, "chat.save_failed")})
 		return
 	}
 
@@ -258,41 +339,39 @@ func GroupChat(c *gin.Context) {
 	groupMember.IsActive = true
 	db.Save(&groupMember)
 
+	// 自己发送的消息视为已读
+	services.MarkGroupRead(db, req.GroupID, userID.(uint), message.ID)
+
+	// AI助手被@或在AI群组中时自动回复
+	services.TriggerGroupAIBot(db, &message)
+
 	// 通过WebSocket推送实时消息
 	// 向群组所有成员推送消息
 	go func() {
-		// 查询群组所有成员
-		var groupMembers []models.GroupMember
-		db.Where("group_id = ?", req.GroupID).Find(&groupMembers)
+		// 查询群组所有成员，不向发送者推送消息
+		var recipients []uint
+		db.Model(&models.GroupMember{}).Where("group_id = ? AND user_id <> ?", req.GroupID, userID).Pluck("user_id", &recipients)
 
 		// 构造消息
-		wsMessage := map[string]any{
-			"type": "new_group_message",
-			"data": map[string]any{
-				"id":              message.ID,
-				"sender_id":       message.SenderID,
-				"group_id":        message.GroupID,
-				"content":         message.Content,
-				"type":            message.Type,
-				"extra":           message.Extra,
-				"mentioned_users": message.MentionedUsers,
-				"status":          message.Status,
-				"created_at":      message.CreatedAt,
-			},
+		data := map[string]any{
+			"id":              message.ID,
+			"sender_id":       message.SenderID,
+			"group_id":        message.GroupID,
+			"content":         message.Content,
+			"type":            message.Type,
+			"extra":           message.Extra,
+			"mentioned_users": message.MentionedUsers,
+			"status":          message.Status,
+			"created_at":      message.CreatedAt,
 		}
 
-		// 向每个群成员推送消息
-		for _, member := range groupMembers {
-			// 不向发送者推送消息
-			if member.UserID != userID.(uint) {
-				utils.PushMessageToUser(member.UserID, wsMessage)
-			}
-		}
+		// 向每个群成员推送消息，开启自动翻译的成员按各自的语言附带译文
+		services.PushChatMessage(db, &message, recipients, "new_group_message", data)
 	}()
 
 	c.JSON(200, gin.H{
 		"success": true,
-		"msg":     "发送成功",
+		"msg":     tr(c, "chat.sent"),
 		"data": gin.H{
 			"id":              message.ID,
 			"sender_id":       message.SenderID,
@@ -312,7 +391,7 @@ func GetGroupMessages(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(401, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(401, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -322,13 +401,13 @@ func GetGroupMessages(c *gin.Context) {
 	offsetStr := c.Query("offset")
 
 	if groupIDStr == "" {
-		c.JSON(400, gin.H{"success": false, "msg": "缺少group_id参数"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "group.group_id_missing")})
 		return
 	}
 
 	groupID, err := strconv.ParseUint(groupIDStr, 10, 32)
 	if err != nil {
-		c.JSON(400, gin.H{"success": false, "msg": "无效的group_id参数"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "group.invalid_group_id_param")})
 		return
 	}
 
@@ -352,17 +431,28 @@ func GetGroupMessages(c *gin.Context) {
 	db := c.MustGet("db").(*gorm.DB)
 	var groupMember models.GroupMember
 	if err := db.Where("group_id = ? AND user_id = ?", groupID, userID).First(&groupMember).Error; err != nil {
-		c.JSON(403, gin.H{"success": false, "msg": "您不是该群组成员"})
+		c.JSON(403, gin.H{"success": false, "msg": tr(c, "group.not_member")})
 		return
 	}
 
 	// 查询群组消息
 	var messages []models.ChatMessage
 	if err := db.Where("group_id = ?", groupID).Order("created_at DESC").Limit(limit).Offset(offset).Find(&messages).Error; err != nil {
-		c.JSON(500, gin.H{"success": false, "msg": "查询消息失败"})
+		c.JSON(500, gin.H{"success": false, "msg": tr(c, "chat.query_failed")})
 		return
 	}
 
+	// 查看最新一页时更新已读位置
+	if offset == 0 && len(messages) > 0 {
+		var latestID uint
+		for _, msg := range messages {
+			if msg.ID > latestID {
+				latestID = msg.ID
+			}
+		}
+		services.MarkGroupRead(db, uint(groupID), userID.(uint), latestID)
+	}
+
 	// 获取发送者信息
 	var senderIDs []uint
 	for _, msg := range messages {
@@ -379,6 +469,9 @@ func GetGroupMessages(c *gin.Context) {
 		userMap[user.ID] = user
 	}
 
+	// 开启自动翻译时附带用户的译文
+	translations := services.LoadMessageTranslations(c.Request.Context(), db, userID.(uint), messages)
+
 	// 构造响应数据
 	var result []gin.H
 	for _, msg := range messages {
@@ -414,6 +507,7 @@ func GetGroupMessages(c *gin.Context) {
 			"sender_nickname": senderNickname,
 			"sender_avatar":   senderAvatar,
 			"is_mentioned":    strings.Contains(msg.MentionedUsers, strconv.FormatUint(uint64(userID.(uint)), 10)),
+			"translation":     translations[msg.ID],
 		})
 	}
 
@@ -429,7 +523,7 @@ func GetGroupMessages(c *gin.Context) {
 func GetRecentChats(c *gin.Context) {
 	userIDStr := c.Query("user_id")
 	if userIDStr == "" {
-		c.JSON(400, gin.H{"success": false, "msg": "缺少user_id参数"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "common.user_id_missing")})
 		return
 	}
 
@@ -441,7 +535,7 @@ func GetRecentChats(c *gin.Context) {
 		var err error
 		userID64, err := strconv.ParseUint(userIDStr, 10, 32)
 		if err != nil {
-			c.JSON(400, gin.H{"success": false, "msg": "无效的user_id参数"})
+			c.JSON(400, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id_param")})
 			return
 		}
 		userID = uint(userID64)
@@ -450,7 +544,7 @@ func GetRecentChats(c *gin.Context) {
 	db := c.MustGet("db").(*gorm.DB)
 	chats, err := repositories.GetRecentChats(db, userID)
 	if err != nil {
-		c.JSON(500, gin.H{"success": false, "msg": "获取聊天列表失败"})
+		c.JSON(500, gin.H{"success": false, "msg": tr(c, "chat.list_failed")})
 		return
 	}
 
@@ -464,12 +558,14 @@ func SyncMessages(c *gin.Context) {
 		SinceTime int64 `form:"since"` // 拉取该时间戳之后的所有消息
 	}
 	if err := c.ShouldBindQuery(&query); err != nil {
-		c.JSON(400, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 	db := c.MustGet("db").(*gorm.DB)
 	var msgs []models.ChatMessage
 	db.Where("(sender_id = ? OR receiver_id = ?) AND created_at > ?", query.UserID, query.UserID, query.SinceTime).
 		Order("created_at asc").Find(&msgs)
-	c.JSON(200, gin.H{"success": true, "data": msgs})
+	// 译文按消息ID返回
+	translations := services.LoadMessageTranslations(c.Request.Context(), db, query.UserID, msgs)
+	c.JSON(200, gin.H{"success": true, "data": msgs, "translations": translations})
 }
@@ -15,7 +15,7 @@ func AddCryptoWallet(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -26,7 +26,7 @@ func AddCryptoWallet(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
@@ -43,14 +43,14 @@ func AddCryptoWallet(c *gin.Context) {
 	}
 
 	if !supportedCurrencies[req.CurrencyType] {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "不支持的虚拟货币类型"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "crypto.unsupported_currency")})
 		return
 	}
 
 	// 检查地址是否已存在
 	var existingWallet models.CryptoWallet
 	if err := utils.DB.Where("address = ? AND currency_type = ?", req.Address, req.CurrencyType).First(&existingWallet).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "该地址已绑定"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "crypto.address_bound")})
 		return
 	}
 
@@ -65,13 +65,13 @@ func AddCryptoWallet(c *gin.Context) {
 	}
 
 	if err := utils.DB.Create(&wallet).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "添加虚拟货币钱包失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "crypto.wallet_add_failed")})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "虚拟货币钱包添加成功",
+		"msg":     tr(c, "crypto.wallet_added"),
 		"data":    wallet,
 	})
 }
@@ -81,14 +81,14 @@ func GetCryptoWallets(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
 	// 查询虚拟货币钱包列表
 	var wallets []models.CryptoWallet
 	if err := utils.DB.Where("user_id = ?", userID).Find(&wallets).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "获取虚拟货币钱包列表失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "crypto.wallet_list_failed")})
 		return
 	}
 
@@ -108,7 +108,7 @@ func DeleteCryptoWallet(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -116,32 +116,32 @@ func DeleteCryptoWallet(c *gin.Context) {
 	walletIDStr := c.Param("id")
 	walletID, err := strconv.ParseUint(walletIDStr, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "无效的钱包ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "crypto.invalid_wallet_id")})
 		return
 	}
 
 	// 检查钱包是否存在且属于当前用户
 	var wallet models.CryptoWallet
 	if err := utils.DB.Where("id = ? AND user_id = ?", walletID, userID).First(&wallet).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "钱包不存在或不属于当前用户"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "wallet.not_found")})
 		return
 	}
 
 	// 检查钱包余额
 	if wallet.Balance > 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "钱包余额不为0，无法删除"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "crypto.wallet_not_empty")})
 		return
 	}
 
 	// 删除钱包
 	if err := utils.DB.Delete(&wallet).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "删除钱包失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "crypto.wallet_delete_failed")})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "钱包删除成功",
+		"msg":     tr(c, "crypto.wallet_deleted"),
 	})
 }
 
@@ -150,7 +150,7 @@ func DepositCrypto(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -163,27 +163,27 @@ func DepositCrypto(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 检查金额
 	if req.Amount <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "充值金额必须大于0"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "wallet.deposit_amount_invalid")})
 		return
 	}
 
 	// 检查钱包是否存在且属于当前用户
 	var wallet models.CryptoWallet
 	if err := utils.DB.Where("id = ? AND user_id = ?", req.WalletID, userID).First(&wallet).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "钱包不存在或不属于当前用户"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "wallet.not_found")})
 		return
 	}
 
 	// 检查交易哈希是否已存在
 	var existingTx models.CryptoTransaction
 	if err := utils.DB.Where("tx_hash = ?", req.TxHash).First(&existingTx).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "交易哈希已存在"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "crypto.tx_hash_exists")})
 		return
 	}
 
@@ -204,7 +204,7 @@ func DepositCrypto(c *gin.Context) {
 	}
 
 	if err := utils.DB.Create(&tx).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "创建交易记录失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.transaction_create_failed")})
 		return
 	}
 
@@ -225,7 +225,7 @@ func DepositCrypto(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "充值请求已提交，等待确认",
+		"msg":     tr(c, "crypto.deposit_submitted"),
 		"data":    tx,
 	})
 }
@@ -235,7 +235,7 @@ func WithdrawCrypto(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -248,27 +248,27 @@ func WithdrawCrypto(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 检查金额
 	if req.Amount <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "提现金额必须大于0"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "wallet.withdraw_amount_invalid")})
 		return
 	}
 
 	// 检查钱包是否存在且属于当前用户
 	var wallet models.CryptoWallet
 	if err := utils.DB.Where("id = ? AND user_id = ?", req.WalletID, userID).First(&wallet).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "钱包不存在或不属于当前用户"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "wallet.not_found")})
 		return
 	}
 
 	// 检查余额是否足够
 	totalAmount := req.Amount + req.Fee
 	if wallet.Balance < totalAmount {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "钱包余额不足"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "crypto.insufficient_balance")})
 		return
 	}
 
@@ -289,13 +289,13 @@ func WithdrawCrypto(c *gin.Context) {
 	}
 
 	if err := utils.DB.Create(&tx).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "创建交易记录失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.transaction_create_failed")})
 		return
 	}
 
 	// 更新钱包余额
 	if err := utils.DB.Model(&wallet).Update("balance", wallet.Balance-totalAmount).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "更新钱包余额失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.balance_update_failed")})
 		return
 	}
 
@@ -317,7 +317,7 @@ func WithdrawCrypto(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "提现请求已提交，等待处理",
+		"msg":     tr(c, "crypto.withdraw_submitted"),
 		"data":    tx,
 	})
 }
@@ -327,7 +327,7 @@ func GetCryptoTransactions(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -360,7 +360,7 @@ func GetCryptoTransactions(c *gin.Context) {
 	// 查询交易记录
 	var transactions []models.CryptoTransaction
 	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&transactions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "获取交易记录失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.transactions_query_failed")})
 		return
 	}
 
@@ -1,107 +1,492 @@
 package controllers
 
 import (
+	"allinone_backend/models"
+	"allinone_backend/services"
+	"allinone_backend/utils"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-// 表情包数据结构
-type EmoticonPackage struct {
-	ID          int    `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Cover       string `json:"cover"`
-	Count       int    `json:"count"`
-}
-
-type Emoticon struct {
-	ID        int    `json:"id"`
-	PackageID int    `json:"package_id"`
-	Name      string `json:"name"`
-	URL       string `json:"url"`
-}
-
-// 模拟数据
-var emoticonPackages = []EmoticonPackage{
-	{
-		ID:          1,
-		Name:        "基础表情",
-		Description: "基础表情包",
-		Cover:       "https://picsum.photos/200/200",
-		Count:       20,
-	},
-	{
-		ID:          2,
-		Name:        "可爱动物",
-		Description: "可爱动物表情包",
-		Cover:       "https://picsum.photos/200/201",
-		Count:       15,
-	},
-}
-
-var emoticons = []Emoticon{
-	{
-		ID:        1,
-		PackageID: 1,
-		Name:      "微笑",
-		URL:       "https://picsum.photos/100/100",
-	},
-	{
-		ID:        2,
-		PackageID: 1,
-		Name:      "大笑",
-		URL:       "https://picsum.photos/100/101",
-	},
-	{
-		ID:        3,
-		PackageID: 1,
-		Name:      "哭泣",
-		URL:       "https://picsum.photos/100/102",
-	},
-	{
-		ID:        4,
-		PackageID: 2,
-		Name:      "小猫",
-		URL:       "https://picsum.photos/100/103",
-	},
-	{
-		ID:        5,
-		PackageID: 2,
-		Name:      "小狗",
-		URL:       "https://picsum.photos/100/104",
-	},
-}
-
-// 获取表情包列表
+// 获取表情包商店列表，附带当前用户是否已拥有、已添加
 func GetEmoticonPackages(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	packages, err := services.ListEmoticonPackages(db, userID.(uint))
+	if err != nil {
+		respondAppError(c, err, tr(c, "emoticon.pack_query_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    packages,
+	})
+}
+
+// 获取表情面板中的表情包，按用户设置的顺序排列
+func GetMyEmoticonPackages(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	packages, err := services.GetUserEmoticonPackages(db, userID.(uint))
+	if err != nil {
+		respondAppError(c, err, tr(c, "emoticon.pack_query_failed"))
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    emoticonPackages,
+		"data":    packages,
 	})
 }
 
-// 获取表情列表
+// 获取表情包详情和其中的表情
+func GetEmoticonPackage(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	pkg, ok := findEmoticonPackage(c, db, userID.(uint))
+	if !ok {
+		return
+	}
+
+	var added int64
+	db.Model(&models.UserEmoticonPackage{}).Where("user_id = ? AND package_id = ?", userID, pkg.ID).Count(&added)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"package":   pkg,
+			"emoticons": services.ListPackageEmoticons(db, pkg.ID),
+			"owned":     services.OwnsEmoticonPackage(db, userID.(uint), pkg),
+			"added":     added > 0,
+		},
+	})
+}
+
+// 添加表情包到表情面板，未购买的付费表情包需要支付密码
+func AddEmoticonPackage(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+
+	var req struct {
+		PayPassword string `json:"pay_password"`
+	}
+	c.ShouldBindJSON(&req)
+
+	db := c.MustGet("db").(*gorm.DB)
+	pkg, ok := findEmoticonPackage(c, db, userID.(uint))
+	if !ok {
+		return
+	}
+
+	if !services.OwnsEmoticonPackage(db, userID.(uint), pkg) && pkg.Price > 0 {
+		if req.PayPassword == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "wallet.pay_password_required")})
+			return
+		}
+		if err := services.CheckPayPassword(db, userID.(uint), req.PayPassword); err != nil {
+			respondAppError(c, err, tr(c, "emoticon.purchase_failed"))
+			return
+		}
+	}
+
+	charged, err := services.AddEmoticonPackage(db, userID.(uint), pkg)
+	if err != nil {
+		respondAppError(c, err, tr(c, "emoticon.pack_add_failed"))
+		return
+	}
+
+	if charged > 0 {
+		description := "购买表情包「" + pkg.Name + "」"
+		if err := createTransactionNotification(db, userID.(uint), "emoticon_purchase", charged, description); err != nil {
+			utils.Logger.Errorf("创建表情包购买通知失败: %v", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "emoticon.pack_added"), "data": gin.H{"package": pkg, "charged": charged}})
+}
+
+// 将表情包移出表情面板，已购买的表情包重新添加无需再次付费
+func RemoveEmoticonPackage(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	packageID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "emoticon.invalid_pack_id")})
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	if err := services.RemoveEmoticonPackage(db, userID.(uint), uint(packageID)); err != nil {
+		respondAppError(c, err, tr(c, "emoticon.pack_remove_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "common.delete_success")})
+}
+
+// 调整表情面板中表情包的顺序
+func SortEmoticonPackages(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	var req struct {
+		PackageIDs []uint `json:"package_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	if err := services.SortUserEmoticonPackages(db, userID.(uint), req.PackageIDs); err != nil {
+		respondAppError(c, err, tr(c, "emoticon.reorder_failed"))
+		return
+	}
+	packages, _ := services.GetUserEmoticonPackages(db, userID.(uint))
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": packages})
+}
+
+// 获取表情列表，指定 package_id 时返回该表情包的表情，否则返回表情面板中所有表情包的表情
 func GetEmoticons(c *gin.Context) {
-	packageIDStr := c.DefaultQuery("package_id", "0")
-	packageID, _ := strconv.Atoi(packageIDStr)
-
-	var result []Emoticon
-	if packageID == 0 {
-		// 返回所有表情
-		result = emoticons
-	} else {
-		// 返回指定包的表情
-		for _, e := range emoticons {
-			if e.PackageID == packageID {
-				result = append(result, e)
-			}
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	packageID, _ := strconv.Atoi(c.DefaultQuery("package_id", "0"))
+
+	db := c.MustGet("db").(*gorm.DB)
+	if packageID > 0 {
+		pkg, err := services.GetEmoticonPackage(db, userID.(uint), uint(packageID))
+		if err != nil {
+			respondAppError(c, err, tr(c, "emoticon.query_failed"))
+			return
 		}
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    services.ListPackageEmoticons(db, pkg.ID),
+		})
+		return
 	}
 
+	packages, err := services.GetUserEmoticonPackages(db, userID.(uint))
+	if err != nil {
+		respondAppError(c, err, tr(c, "emoticon.query_failed"))
+		return
+	}
+	packageIDs := make([]uint, 0, len(packages))
+	for _, pkg := range packages {
+		packageIDs = append(packageIDs, pkg.ID)
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    result,
+		"data":    services.ListPackageEmoticons(db, packageIDs...),
 	})
 }
+
+// 获取收藏的表情
+func GetFavoriteEmoticons(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	emoticons, err := services.ListFavoriteEmoticons(db, userID.(uint))
+	if err != nil {
+		respondAppError(c, err, tr(c, "emoticon.favorites_query_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": emoticons})
+}
+
+// 上传图片作为收藏表情，支持GIF动图
+func UploadFavoriteEmoticon(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "emoticon.image_required")})
+		return
+	}
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "file.read_failed")})
+		return
+	}
+	defer src.Close()
+
+	db := c.MustGet("db").(*gorm.DB)
+	emoticon, err := services.UploadFavoriteEmoticon(c.Request.Context(), db, userID.(uint), src, file.Filename)
+	if err != nil {
+		respondAppError(c, err, tr(c, "emoticon.upload_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "emoticon.favorited"), "data": emoticon})
+}
+
+// 收藏已有的表情，如聊天中收到的表情
+func AddFavoriteEmoticon(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	var req struct {
+		EmoticonID uint `json:"emoticon_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	emoticon, err := services.AddFavoriteEmoticon(db, userID.(uint), req.EmoticonID)
+	if err != nil {
+		respondAppError(c, err, tr(c, "emoticon.favorite_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "emoticon.favorited"), "data": emoticon})
+}
+
+// 取消收藏表情
+func RemoveFavoriteEmoticon(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	emoticonID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "emoticon.invalid_id")})
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	if err := services.RemoveFavoriteEmoticon(db, userID.(uint), uint(emoticonID)); err != nil {
+		respondAppError(c, err, tr(c, "emoticon.unfavorite_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "common.delete_success")})
+}
+
+// 调整收藏表情的顺序
+func SortFavoriteEmoticons(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	var req struct {
+		EmoticonIDs []uint `json:"emoticon_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	if err := services.SortFavoriteEmoticons(db, userID.(uint), req.EmoticonIDs); err != nil {
+		respondAppError(c, err, tr(c, "emoticon.reorder_failed"))
+		return
+	}
+	emoticons, _ := services.ListFavoriteEmoticons(db, userID.(uint))
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": emoticons})
+}
+
+// 管理员创建表情包
+func AdminCreateEmoticonPackage(c *gin.Context) {
+	var req struct {
+		Name        string  `json:"name" binding:"required"`
+		Description string  `json:"description"`
+		Cover       string  `json:"cover"`
+		Author      string  `json:"author"`
+		Price       float64 `json:"price"`
+		SortOrder   int     `json:"sort_order"`
+		Status      string  `json:"status"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Price < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+	if req.Status == "" {
+		req.Status = "published"
+	}
+
+	now := time.Now().Unix()
+	pkg := models.EmoticonPackage{
+		Name:        req.Name,
+		Description: req.Description,
+		Cover:       req.Cover,
+		Author:      req.Author,
+		Price:       req.Price,
+		SortOrder:   req.SortOrder,
+		Status:      req.Status,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	if err := db.Create(&pkg).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "emoticon.pack_create_failed")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": pkg})
+}
+
+// 管理员修改表情包信息、价格或上下架
+func AdminUpdateEmoticonPackage(c *gin.Context) {
+	var req struct {
+		Name        *string  `json:"name"`
+		Description *string  `json:"description"`
+		Cover       *string  `json:"cover"`
+		Author      *string  `json:"author"`
+		Price       *float64 `json:"price"`
+		SortOrder   *int     `json:"sort_order"`
+		Status      *string  `json:"status"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	var pkg models.EmoticonPackage
+	if err := db.First(&pkg, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "emoticon.pack_not_found")})
+		return
+	}
+
+	updates := map[string]interface{}{"updated_at": time.Now().Unix()}
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.Cover != nil {
+		updates["cover"] = *req.Cover
+	}
+	if req.Author != nil {
+		updates["author"] = *req.Author
+	}
+	if req.Price != nil {
+		if *req.Price < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+			return
+		}
+		updates["price"] = *req.Price
+	}
+	if req.SortOrder != nil {
+		updates["sort_order"] = *req.SortOrder
+	}
+	if req.Status != nil {
+		if *req.Status != "published" && *req.Status != "unpublished" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "emoticon.invalid_status")})
+			return
+		}
+		updates["status"] = *req.Status
+	}
+	if err := db.Model(&pkg).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "emoticon.pack_update_failed")})
+		return
+	}
+	db.First(&pkg, pkg.ID)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": pkg})
+}
+
+// 管理员上传表情到表情包
+func AdminAddEmoticon(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	packageID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "emoticon.invalid_pack_id")})
+		return
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "emoticon.image_required")})
+		return
+	}
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "file.read_failed")})
+		return
+	}
+	defer src.Close()
+
+	db := c.MustGet("db").(*gorm.DB)
+	emoticon, err := services.CreateEmoticon(c.Request.Context(), db, userID.(uint), uint(packageID), src, file.Filename, c.PostForm("name"))
+	if err != nil {
+		respondAppError(c, err, tr(c, "emoticon.upload_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": emoticon})
+}
+
+// 管理员从表情包中删除表情
+func AdminDeleteEmoticon(c *gin.Context) {
+	emoticonID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "emoticon.invalid_id")})
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	if err := services.DeleteEmoticon(db, uint(emoticonID)); err != nil {
+		respondAppError(c, err, tr(c, "emoticon.delete_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "common.delete_success")})
+}
+
+// findEmoticonPackage 按路径参数查找表情包，出错时已写入响应
+func findEmoticonPackage(c *gin.Context, db *gorm.DB, userID uint) (*models.EmoticonPackage, bool) {
+	packageID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "emoticon.invalid_pack_id")})
+		return nil, false
+	}
+	pkg, err := services.GetEmoticonPackage(db, userID, uint(packageID))
+	if err != nil {
+		respondAppError(c, err, tr(c, "emoticon.pack_query_failed"))
+		return nil, false
+	}
+	return pkg, true
+}
+
+// attachMessageEmoticon 校验表情消息引用的表情，返回表情地址和合并了表情信息的 Extra
+// 出错时已写入响应，ok 为 false
+func attachMessageEmoticon(c *gin.Context, db *gorm.DB, senderID, emoticonID uint, extra string) (string, string, bool) {
+	if emoticonID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "emoticon.id_required")})
+		return "", "", false
+	}
+	emoticon, info, err := services.AttachMessageEmoticon(db, senderID, emoticonID)
+	if err != nil {
+		respondAppError(c, err, tr(c, "emoticon.send_failed"))
+		return "", "", false
+	}
+	return emoticon.URL, mergeMessageExtra(extra, info), true
+}
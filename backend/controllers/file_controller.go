@@ -1,70 +1,84 @@
 package controllers
 
 import (
-	"fmt"
+	"allinone_backend/models"
+	"allinone_backend/services"
+	"allinone_backend/utils"
 	"log"
 	"net/http"
-	"os"
-	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-// 增强版文件上传处理
+// 增强版文件上传处理，文件保存到媒体存储
+// 可选表单字段 receiver_id 或 group_id 指定所属会话，只有会话成员可以下载；
+// 未指定会话时 scope=moment 的文件用于发布动态，发布后动态的可见用户可以下载，其余仅上传者可见
+// 图片会生成缩略图，返回 thumb_url 和 medium_url
 func UploadFileEnhanced(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+
 	// 获取上传的文件
 	file, err := c.FormFile("file")
 	if err != nil {
 		log.Printf("未找到上传文件: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "未找到上传文件"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "file.missing")})
 		return
 	}
 
 	// 获取文件类型
 	fileType := c.DefaultPostForm("type", "image") // image, voice, video, file
+	if !services.MediaFileTypes[fileType] {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "file.unsupported_type")})
+		return
+	}
 	log.Printf("上传文件类型: %s, 文件名: %s, 大小: %d", fileType, file.Filename, file.Size)
 
-	// 创建上传目录 - 确保目录存在
-	// 修正：使用uploads目录作为根目录，与其他上传保持一致
-	uploadDir := fmt.Sprintf("uploads/%s", fileType)
-	log.Printf("尝试创建上传目录: %s", uploadDir)
-
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		log.Printf("创建上传目录失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "创建上传目录失败"})
+	receiverID, _ := strconv.ParseUint(c.PostForm("receiver_id"), 10, 64)
+	groupID, _ := strconv.ParseUint(c.PostForm("group_id"), 10, 64)
+	db := c.MustGet("db").(*gorm.DB)
+	target, err := services.ResolveMediaTarget(db, userID.(uint), c.PostForm("scope"), uint(receiverID), uint(groupID))
+	if err != nil {
+		respondAppError(c, err, tr(c, "file.save_failed"))
 		return
 	}
 
-	// 生成唯一文件名
-	fileExt := filepath.Ext(file.Filename)
-	fileName := uuid.New().String() + fileExt
-	filePath := filepath.Join(uploadDir, fileName)
-	log.Printf("保存文件路径: %s", filePath)
-
-	// 保存文件
-	if err := c.SaveUploadedFile(file, filePath); err != nil {
-		log.Printf("保存文件失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "保存文件失败"})
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "file.missing")})
 		return
 	}
+	defer src.Close()
 
-	// 构建文件URL - 修正URL路径
-	// 修正：使用正确的URL路径格式
-	fileURL := fmt.Sprintf("/%s/%s", uploadDir, fileName)
-	log.Printf("文件上传成功，URL: %s", fileURL)
+	// 图片按文件内容校验格式，去除元数据并生成缩略图
+	var media *models.Media
+	if fileType == "image" {
+		media, err = services.StoreImage(c.Request.Context(), db, userID.(uint), src, file.Filename, utils.ImageKindImage, target)
+	} else {
+		media, err = services.StoreMedia(c.Request.Context(), db, userID.(uint), src, file.Filename, fileType, target)
+	}
+	if err != nil {
+		respondAppError(c, err, tr(c, "file.save_failed"))
+		return
+	}
 
 	// 返回文件信息
+	data := mediaResponse(db, media, userID.(uint))
+	data["file_name"] = media.FileName
+	data["file_size"] = media.Size
+	data["file_type"] = fileType
+	data["content_type"] = media.ContentType
+	data["scope"] = media.Scope
+	data["upload_time"] = time.Now().Unix()
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "文件上传成功",
-		"data": gin.H{
-			"url":         fileURL,
-			"file_name":   file.Filename,
-			"file_size":   file.Size,
-			"file_type":   fileType,
-			"upload_time": time.Now().Unix(),
-		},
+		"msg":     tr(c, "file.upload_success"),
+		"data":    data,
 	})
 }
@@ -27,7 +27,7 @@ func GetFriends(c *gin.Context) {
 
 	userID, err := strconv.Atoi(userIDStr)
 	if err != nil {
-		c.JSON(400, gin.H{"success": false, "msg": "无效的用户ID"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -45,14 +45,15 @@ func GetFriends(c *gin.Context) {
 		}
 
 		resp = append(resp, gin.H{
-			"friend_id":  f.FriendID,
-			"created_at": f.CreatedAt,
-			"blocked":    f.Blocked,
-			"account":    friendUser.Account,
-			"nickname":   friendUser.Nickname,
-			"avatar":     friendUser.Avatar,
-			"gender":     friendUser.Gender,
-			"email":      friendUser.Email,
+			"friend_id":    f.FriendID,
+			"created_at":   f.CreatedAt,
+			"blocked":      f.Blocked,
+			"account":      friendUser.Account,
+			"nickname":     friendUser.Nickname,
+			"avatar":       friendUser.Avatar,
+			"avatar_thumb": friendUser.AvatarThumb,
+			"gender":       friendUser.Gender,
+			"email":        friendUser.Email,
 		})
 	}
 
@@ -66,18 +67,18 @@ func BlockFriend(c *gin.Context) {
 		FriendID uint `json:"friend_id"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 	db := c.MustGet("db").(*gorm.DB)
 	var friend models.Friend
 	if err := db.Where("user_id = ? AND friend_id = ?", req.UserID, req.FriendID).First(&friend).Error; err != nil {
-		c.JSON(404, gin.H{"success": false, "msg": "好友关系不存在"})
+		c.JSON(404, gin.H{"success": false, "msg": tr(c, "friend.not_found")})
 		return
 	}
 	friend.Blocked = 1
 	db.Save(&friend)
-	c.JSON(200, gin.H{"success": true, "msg": "已屏蔽该好友"})
+	c.JSON(200, gin.H{"success": true, "msg": tr(c, "friend.blocked")})
 }
 
 // 取消屏蔽
@@ -87,18 +88,18 @@ func UnblockFriend(c *gin.Context) {
 		FriendID uint `json:"friend_id"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 	db := c.MustGet("db").(*gorm.DB)
 	var friend models.Friend
 	if err := db.Where("user_id = ? AND friend_id = ?", req.UserID, req.FriendID).First(&friend).Error; err != nil {
-		c.JSON(404, gin.H{"success": false, "msg": "好友关系不存在"})
+		c.JSON(404, gin.H{"success": false, "msg": tr(c, "friend.not_found")})
 		return
 	}
 	friend.Blocked = 0
 	db.Save(&friend)
-	c.JSON(200, gin.H{"success": true, "msg": "已取消屏蔽"})
+	c.JSON(200, gin.H{"success": true, "msg": tr(c, "friend.unblocked")})
 }
 
 // 添加好友
@@ -117,7 +118,7 @@ func AddFriend(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"success": false, "msg": "参数错误: " + err.Error()})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "common.invalid_params_detail", err.Error())})
 		return
 	}
 
@@ -128,21 +129,21 @@ func AddFriend(c *gin.Context) {
 	// 转换ID为uint
 	userIDUint, err := strconv.ParseUint(req.UserID, 10, 32)
 	if err != nil {
-		c.JSON(400, gin.H{"success": false, "msg": "用户ID格式错误"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 	userID := uint(userIDUint)
 
 	friendIDUint, err := strconv.ParseUint(req.FriendID, 10, 32)
 	if err != nil {
-		c.JSON(400, gin.H{"success": false, "msg": "好友ID格式错误"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "friend.invalid_friend_id")})
 		return
 	}
 	friendID := uint(friendIDUint)
 
 	// 验证用户ID
 	if userID == friendID {
-		c.JSON(400, gin.H{"success": false, "msg": "不能添加自己为好友"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "friend.cannot_add_self")})
 		return
 	}
 
@@ -151,14 +152,14 @@ func AddFriend(c *gin.Context) {
 	// 检查是否已经是好友
 	var existingFriend models.Friend
 	if err := db.Where("user_id = ? AND friend_id = ?", userID, friendID).First(&existingFriend).Error; err == nil {
-		c.JSON(400, gin.H{"success": false, "msg": "对方已经是您的好友"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "friend.already_friends")})
 		return
 	}
 
 	// 检查是否已经发送过请求且未处理
 	var existingRequest models.FriendRequest
 	if err := db.Where("from_id = ? AND to_id = ? AND status = 0", userID, friendID).First(&existingRequest).Error; err == nil {
-		c.JSON(400, gin.H{"success": false, "msg": "您已经发送过好友请求，请等待对方处理"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "friend.request_pending")})
 		return
 	}
 
@@ -174,7 +175,7 @@ func AddFriend(c *gin.Context) {
 
 		c.JSON(200, gin.H{
 			"success":       true,
-			"msg":           "好友请求已重新发送，等待对方同意",
+			"msg":           tr(c, "friend.request_resent"),
 			"auto_accepted": false,
 		})
 		return
@@ -183,14 +184,14 @@ func AddFriend(c *gin.Context) {
 	// 获取目标用户信息
 	var targetUser models.User
 	if err := db.First(&targetUser, friendID).Error; err != nil {
-		c.JSON(404, gin.H{"success": false, "msg": "目标用户不存在"})
+		c.JSON(404, gin.H{"success": false, "msg": tr(c, "friend.target_not_found")})
 		return
 	}
 
 	// 获取请求用户信息（用于通知）
 	var fromUser models.User
 	if err := db.First(&fromUser, userID).Error; err != nil {
-		c.JSON(404, gin.H{"success": false, "msg": "请求用户不存在"})
+		c.JSON(404, gin.H{"success": false, "msg": tr(c, "friend.requester_not_found")})
 		return
 	}
 
@@ -213,7 +214,7 @@ func AddFriend(c *gin.Context) {
 
 		c.JSON(200, gin.H{
 			"success":       true,
-			"msg":           "已自动添加为好友",
+			"msg":           tr(c, "friend.auto_added"),
 			"auto_accepted": true,
 		})
 		return
@@ -231,7 +232,7 @@ func AddFriend(c *gin.Context) {
 
 		c.JSON(200, gin.H{
 			"success":       true,
-			"msg":           "好友请求已发送，等待对方同意",
+			"msg":           tr(c, "friend.request_sent"),
 			"auto_accepted": false,
 		})
 		return
@@ -249,13 +250,13 @@ func AddFriend(c *gin.Context) {
 
 		c.JSON(200, gin.H{
 			"success":       false,
-			"msg":           "对方设置了拒绝所有好友申请",
+			"msg":           tr(c, "friend.requests_refused"),
 			"auto_rejected": true,
 		})
 		return
 
 	default:
-		c.JSON(400, gin.H{"success": false, "msg": "未知的好友添加模式"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "friend.unknown_add_mode")})
 		return
 	}
 }
@@ -267,7 +268,7 @@ func GetFriendAddMode(c *gin.Context) {
 	db := c.MustGet("db").(*gorm.DB)
 	var user models.User
 	if err := db.First(&user, userID).Error; err != nil {
-		c.JSON(404, gin.H{"success": false, "msg": "用户不存在"})
+		c.JSON(404, gin.H{"success": false, "msg": tr(c, "user.not_found")})
 		return
 	}
 	c.JSON(200, gin.H{"success": true, "data": gin.H{"mode": user.FriendAddMode}})
@@ -280,15 +281,15 @@ func SetFriendAddMode(c *gin.Context) {
 		Mode   int  `json:"mode"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 	db := c.MustGet("db").(*gorm.DB)
 	if err := db.Model(&models.User{}).Where("id = ?", req.UserID).Update("friend_add_mode", req.Mode).Error; err != nil {
-		c.JSON(500, gin.H{"success": false, "msg": "设置失败"})
+		c.JSON(500, gin.H{"success": false, "msg": tr(c, "common.settings_failed")})
 		return
 	}
-	c.JSON(200, gin.H{"success": true, "msg": "设置成功"})
+	c.JSON(200, gin.H{"success": true, "msg": tr(c, "common.settings_saved")})
 }
 
 // 查询我的好友请求
@@ -397,23 +398,23 @@ func AgreeFriendRequest(c *gin.Context) {
 		UserID    string `json:"user_id"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 	db := c.MustGet("db").(*gorm.DB)
 	var fr models.FriendRequest
 	if err := db.First(&fr, req.RequestID).Error; err != nil {
-		c.JSON(404, gin.H{"success": false, "msg": "请求不存在"})
+		c.JSON(404, gin.H{"success": false, "msg": tr(c, "friend.request_not_found")})
 		return
 	}
 	if fr.Status != 0 {
-		c.JSON(400, gin.H{"success": false, "msg": "请求已处理"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "friend.request_handled")})
 		return
 	}
 	db.Model(&fr).Update("status", 1)
 	db.Create(&models.Friend{UserID: fr.FromID, FriendID: fr.ToID, CreatedAt: time.Now().Unix()})
 	db.Create(&models.Friend{UserID: fr.ToID, FriendID: fr.FromID, CreatedAt: time.Now().Unix()})
-	c.JSON(200, gin.H{"success": true, "msg": "已同意好友请求"})
+	c.JSON(200, gin.H{"success": true, "msg": tr(c, "friend.request_accepted")})
 }
 
 // 拒绝好友请求
@@ -423,21 +424,21 @@ func RejectFriendRequest(c *gin.Context) {
 		UserID    string `json:"user_id"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 	db := c.MustGet("db").(*gorm.DB)
 	var fr models.FriendRequest
 	if err := db.First(&fr, req.RequestID).Error; err != nil {
-		c.JSON(404, gin.H{"success": false, "msg": "请求不存在"})
+		c.JSON(404, gin.H{"success": false, "msg": tr(c, "friend.request_not_found")})
 		return
 	}
 	if fr.Status != 0 {
-		c.JSON(400, gin.H{"success": false, "msg": "请求已处理"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "friend.request_handled")})
 		return
 	}
 	db.Model(&fr).Update("status", 2) // 2表示拒绝
-	c.JSON(200, gin.H{"success": true, "msg": "已拒绝好友请求"})
+	c.JSON(200, gin.H{"success": true, "msg": tr(c, "friend.request_rejected")})
 }
 
 // 批量同意好友请求
@@ -447,12 +448,12 @@ func BatchAgreeFriendRequests(c *gin.Context) {
 		RequestIDs []string `json:"request_ids"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	if len(req.RequestIDs) == 0 {
-		c.JSON(400, gin.H{"success": false, "msg": "请求ID列表不能为空"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "friend.request_ids_required")})
 		return
 	}
 
@@ -469,19 +470,19 @@ func BatchAgreeFriendRequests(c *gin.Context) {
 	}
 
 	if len(requestIDs) == 0 {
-		c.JSON(400, gin.H{"success": false, "msg": "无有效的请求ID"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "friend.no_valid_request_ids")})
 		return
 	}
 
 	// 查询所有待处理的请求
 	var requests []models.FriendRequest
 	if err := db.Where("id IN ? AND status = 0", requestIDs).Find(&requests).Error; err != nil {
-		c.JSON(500, gin.H{"success": false, "msg": "查询请求失败"})
+		c.JSON(500, gin.H{"success": false, "msg": tr(c, "friend.request_query_failed")})
 		return
 	}
 
 	if len(requests) == 0 {
-		c.JSON(400, gin.H{"success": false, "msg": "没有找到待处理的请求"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "friend.no_pending_requests")})
 		return
 	}
 
@@ -493,20 +494,20 @@ func BatchAgreeFriendRequests(c *gin.Context) {
 		// 更新请求状态为已同意
 		if err := tx.Model(&fr).Update("status", 1).Error; err != nil {
 			tx.Rollback()
-			c.JSON(500, gin.H{"success": false, "msg": "处理请求失败"})
+			c.JSON(500, gin.H{"success": false, "msg": tr(c, "friend.request_handle_failed")})
 			return
 		}
 
 		// 创建好友关系（双向）
 		if err := tx.Create(&models.Friend{UserID: fr.FromID, FriendID: fr.ToID, CreatedAt: time.Now().Unix()}).Error; err != nil {
 			tx.Rollback()
-			c.JSON(500, gin.H{"success": false, "msg": "创建好友关系失败"})
+			c.JSON(500, gin.H{"success": false, "msg": tr(c, "friend.create_failed")})
 			return
 		}
 
 		if err := tx.Create(&models.Friend{UserID: fr.ToID, FriendID: fr.FromID, CreatedAt: time.Now().Unix()}).Error; err != nil {
 			tx.Rollback()
-			c.JSON(500, gin.H{"success": false, "msg": "创建好友关系失败"})
+			c.JSON(500, gin.H{"success": false, "msg": tr(c, "friend.create_failed")})
 			return
 		}
 
@@ -515,13 +516,13 @@ func BatchAgreeFriendRequests(c *gin.Context) {
 
 	// 提交事务
 	if err := tx.Commit().Error; err != nil {
-		c.JSON(500, gin.H{"success": false, "msg": "提交事务失败"})
+		c.JSON(500, gin.H{"success": false, "msg": tr(c, "common.commit_failed")})
 		return
 	}
 
 	c.JSON(200, gin.H{
 		"success": true,
-		"msg":     fmt.Sprintf("已同意 %d 个好友请求", successCount),
+		"msg":     tr(c, "friend.requests_accepted", successCount),
 		"count":   successCount,
 	})
 }
@@ -533,12 +534,12 @@ func BatchRejectFriendRequests(c *gin.Context) {
 		RequestIDs []string `json:"request_ids"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	if len(req.RequestIDs) == 0 {
-		c.JSON(400, gin.H{"success": false, "msg": "请求ID列表不能为空"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "friend.request_ids_required")})
 		return
 	}
 
@@ -555,19 +556,19 @@ func BatchRejectFriendRequests(c *gin.Context) {
 	}
 
 	if len(requestIDs) == 0 {
-		c.JSON(400, gin.H{"success": false, "msg": "无有效的请求ID"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "friend.no_valid_request_ids")})
 		return
 	}
 
 	// 查询所有待处理的请求
 	var requests []models.FriendRequest
 	if err := db.Where("id IN ? AND status = 0", requestIDs).Find(&requests).Error; err != nil {
-		c.JSON(500, gin.H{"success": false, "msg": "查询请求失败"})
+		c.JSON(500, gin.H{"success": false, "msg": tr(c, "friend.request_query_failed")})
 		return
 	}
 
 	if len(requests) == 0 {
-		c.JSON(400, gin.H{"success": false, "msg": "没有找到待处理的请求"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "friend.no_pending_requests")})
 		return
 	}
 
@@ -579,7 +580,7 @@ func BatchRejectFriendRequests(c *gin.Context) {
 		// 更新请求状态为已拒绝
 		if err := tx.Model(&fr).Update("status", 2).Error; err != nil {
 			tx.Rollback()
-			c.JSON(500, gin.H{"success": false, "msg": "处理请求失败"})
+			c.JSON(500, gin.H{"success": false, "msg": tr(c, "friend.request_handle_failed")})
 			return
 		}
 		successCount++
@@ -587,13 +588,13 @@ func BatchRejectFriendRequests(c *gin.Context) {
 
 	// 提交事务
 	if err := tx.Commit().Error; err != nil {
-		c.JSON(500, gin.H{"success": false, "msg": "提交事务失败"})
+		c.JSON(500, gin.H{"success": false, "msg": tr(c, "common.commit_failed")})
 		return
 	}
 
 	c.JSON(200, gin.H{
 		"success": true,
-		"msg":     fmt.Sprintf("已拒绝 %d 个好友请求", successCount),
+		"msg":     tr(c, "friend.requests_rejected", successCount),
 		"count":   successCount,
 	})
 }
@@ -602,7 +603,7 @@ func BatchRejectFriendRequests(c *gin.Context) {
 func SearchUsers(c *gin.Context) {
 	keyword := c.Query("keyword")
 	if keyword == "" {
-		c.JSON(400, gin.H{"success": false, "msg": "搜索关键词不能为空"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "common.keyword_required")})
 		return
 	}
 
@@ -697,6 +698,7 @@ func SearchUsers(c *gin.Context) {
 			"account":             u.Account,
 			"nickname":            u.Nickname,
 			"avatar":              u.Avatar,
+			"avatar_thumb":        u.AvatarThumb,
 			"gender":              u.Gender,
 			"is_friend":           isFriend,
 			"has_pending_request": hasPendingRequest,
@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/services"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AI游戏角色相关接口，开发者管理角色设定，玩家通过 /ai/game/chat 的 character_id 与角色对话
+
+// 角色头像的最大大小
+const gameCharacterAvatarMaxSize = 5 << 20
+
+// 开发者创建或修改角色的请求参数，修改时省略的字段保持不变
+type gameCharacterForm struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Avatar      *string `json:"avatar"`
+	Prompt      *string `json:"prompt"`
+}
+
+func (f gameCharacterForm) input() services.GameCharacterInput {
+	return services.GameCharacterInput{
+		Name:        f.Name,
+		Description: f.Description,
+		Avatar:      f.Avatar,
+		Prompt:      f.Prompt,
+	}
+}
+
+// 获取游戏的角色列表，玩家看不到角色提示词
+func GetGameCharacters(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	game, ok := findGame(c, db)
+	if !ok {
+		return
+	}
+
+	characters, err := services.ListGameCharacters(db, game.ID)
+	if err != nil {
+		respondAppError(c, err, tr(c, "game_character.list_failed"))
+		return
+	}
+	list := make([]gin.H, 0, len(characters))
+	for _, character := range characters {
+		list = append(list, gin.H{
+			"id":          character.ID,
+			"game_id":     character.GameID,
+			"name":        character.Name,
+			"description": character.Description,
+			"avatar":      character.Avatar,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "common.query_success"), "data": list})
+}
+
+// 开发者获取游戏的角色列表，包含提示词
+func GetDeveloperGameCharacters(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	developer, ok := currentGameDeveloper(c, db)
+	if !ok {
+		return
+	}
+	game, ok := findDeveloperGame(c, db, developer.ID)
+	if !ok {
+		return
+	}
+
+	characters, err := services.ListGameCharacters(db, game.ID)
+	if err != nil {
+		respondAppError(c, err, tr(c, "game_character.list_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "common.query_success"), "data": characters})
+}
+
+// 创建游戏角色
+func CreateGameCharacter(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	developer, ok := currentGameDeveloper(c, db)
+	if !ok {
+		return
+	}
+	game, ok := findDeveloperGame(c, db, developer.ID)
+	if !ok {
+		return
+	}
+
+	var form gameCharacterForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+
+	character, err := services.CreateGameCharacter(db, game.ID, form.input())
+	if err != nil {
+		respondAppError(c, err, tr(c, "game_character.create_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "game_character.created"), "data": character})
+}
+
+// 修改游戏角色
+func UpdateGameCharacter(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	character, ok := findDeveloperGameCharacter(c, db)
+	if !ok {
+		return
+	}
+
+	var form gameCharacterForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+
+	if err := services.UpdateGameCharacter(db, character, form.input()); err != nil {
+		respondAppError(c, err, tr(c, "game_character.update_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "game_character.updated"), "data": character})
+}
+
+// 删除游戏角色
+func DeleteGameCharacter(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	character, ok := findDeveloperGameCharacter(c, db)
+	if !ok {
+		return
+	}
+
+	if err := services.DeleteGameCharacter(db, character); err != nil {
+		respondAppError(c, err, tr(c, "game_character.delete_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "game_character.deleted")})
+}
+
+// 上传角色头像，表单字段为 avatar
+func UploadGameCharacterAvatar(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	character, ok := findDeveloperGameCharacter(c, db)
+	if !ok {
+		return
+	}
+
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "file.missing")})
+		return
+	}
+	if file.Size > gameCharacterAvatarMaxSize {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "game_character.avatar_too_large")})
+		return
+	}
+
+	// 根据文件内容判断格式，不信任客户端声明的类型
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "file.read_failed")})
+		return
+	}
+	head := make([]byte, 512)
+	n, _ := src.Read(head)
+	src.Close()
+
+	var fileExt string
+	switch http.DetectContentType(head[:n]) {
+	case "image/jpeg":
+		fileExt = ".jpg"
+	case "image/png":
+		fileExt = ".png"
+	case "image/gif":
+		fileExt = ".gif"
+	case "image/webp":
+		fileExt = ".webp"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "file.image_formats")})
+		return
+	}
+
+	uploadDir := "uploads/characters"
+	if err := os.MkdirAll(uploadDir, 0755); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "file.mkdir_failed")})
+		return
+	}
+	fileName := fmt.Sprintf("%s_%d%s", uuid.New().String(), time.Now().Unix(), fileExt)
+	if err := c.SaveUploadedFile(file, filepath.Join(uploadDir, fileName)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "file.save_failed")})
+		return
+	}
+
+	avatarURL := "/uploads/characters/" + fileName
+	if err := services.UpdateGameCharacter(db, character, services.GameCharacterInput{Avatar: &avatarURL}); err != nil {
+		respondAppError(c, err, tr(c, "avatar.update_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "avatar.upload_success"), "data": character})
+}
+
+// 查询开发者名下游戏的角色
+func findDeveloperGameCharacter(c *gin.Context, db *gorm.DB) (*models.AIGameCharacter, bool) {
+	developer, ok := currentGameDeveloper(c, db)
+	if !ok {
+		return nil, false
+	}
+	game, ok := findDeveloperGame(c, db, developer.ID)
+	if !ok {
+		return nil, false
+	}
+	characterID, err := strconv.ParseUint(c.Param("character_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "game_character.invalid_id")})
+		return nil, false
+	}
+	character, err := services.GetGameCharacter(db, game.ID, uint(characterID))
+	if err != nil {
+		respondAppError(c, err, tr(c, "game_character.query_failed"))
+		return nil, false
+	}
+	return character, true
+}
@@ -0,0 +1,562 @@
+package controllers
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/services"
+	"allinone_backend/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 游戏商店相关接口
+
+// 获取游戏目录，支持关键字搜索和按类型、平台筛选
+func GetGames(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	query := db.Model(&models.Game{}).Where("status = ?", "published")
+	if keyword := strings.TrimSpace(c.Query("keyword")); keyword != "" {
+		query = query.Where("name LIKE ? OR description LIKE ?", "%"+keyword+"%", "%"+keyword+"%")
+	}
+	if gameType := strings.TrimSpace(c.Query("type")); gameType != "" {
+		query = query.Where("type = ?", gameType)
+	}
+	// 平台字段为逗号分隔，按完整项匹配
+	for _, platform := range splitCommaList(c.Query("platform")) {
+		query = query.Where("(',' || REPLACE(platforms, ' ', '') || ',') LIKE ?", "%,"+platform+",%")
+	}
+	switch c.Query("price") {
+	case "free":
+		query = query.Where("is_free = ? OR price <= 0", true)
+	case "paid":
+		query = query.Where("is_free = ? AND price > 0", false)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	order := "created_at DESC"
+	switch c.Query("sort") {
+	case "rating":
+		order = "rating DESC, downloads DESC"
+	case "downloads":
+		order = "downloads DESC"
+	case "price_asc":
+		order = "price ASC"
+	case "price_desc":
+		order = "price DESC"
+	}
+
+	var games []models.Game
+	query.Order(order).Offset((page - 1) * pageSize).Limit(pageSize).Find(&games)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"msg":     tr(c, "game.list_loaded"),
+		"data": gin.H{
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+			"games":     games,
+		},
+	})
+}
+
+// 获取游戏详情
+func GetGameDetail(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	game, ok := findGame(c, db)
+	if !ok {
+		return
+	}
+
+	var developer models.GameDeveloper
+	db.Select("id, name, logo, website").First(&developer, game.DeveloperID)
+
+	var latestUpdate *models.GameUpdate
+	var update models.GameUpdate
+	if err := db.Where("game_id = ?", game.ID).Order("created_at DESC").First(&update).Error; err == nil {
+		latestUpdate = &update
+	}
+
+	var achievementCount, reviewCount int64
+	db.Model(&models.GameAchievement{}).Where("game_id = ?", game.ID).Count(&achievementCount)
+	db.Model(&models.GameReview{}).Where("game_id = ?", game.ID).Count(&reviewCount)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"msg":     tr(c, "game.detail_loaded"),
+		"data": gin.H{
+			"game":              game,
+			"developer":         developer,
+			"latest_update":     latestUpdate,
+			"achievement_count": achievementCount,
+			"review_count":      reviewCount,
+			"owned":             services.OwnsGame(db, userID, game.ID),
+		},
+	})
+}
+
+// 获取或购买游戏，付费游戏需要支付密码
+func PurchaseGame(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	var req struct {
+		PayPassword string `json:"pay_password"`
+	}
+	c.ShouldBindJSON(&req)
+
+	db := c.MustGet("db").(*gorm.DB)
+	game, ok := findGame(c, db)
+	if !ok {
+		return
+	}
+
+	paid := !game.IsFree && game.Price > 0
+	if paid {
+		if req.PayPassword == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "wallet.pay_password_required")})
+			return
+		}
+		if err := services.CheckPayPassword(db, userID, req.PayPassword); err != nil {
+			respondAppError(c, err, tr(c, "emoticon.purchase_failed"))
+			return
+		}
+	}
+
+	userGame, err := services.AcquireGame(db, userID, game)
+	if err != nil {
+		respondAppError(c, err, tr(c, "emoticon.purchase_failed"))
+		return
+	}
+
+	if paid {
+		description := fmt.Sprintf("购买游戏「%s」", game.Name)
+		if err := createTransactionNotification(db, userID, "game_purchase", game.Price, description); err != nil {
+			utils.Logger.Errorf("创建游戏购买通知失败: %v", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "game.added_to_library"), "data": userGame})
+}
+
+// 获取用户游戏库
+func GetGameLibrary(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	var userGames []models.UserGame
+	db.Where("user_id = ?", userID).Order("last_played DESC, created_at DESC").Find(&userGames)
+
+	gameIDs := make([]uint, 0, len(userGames))
+	for _, ug := range userGames {
+		gameIDs = append(gameIDs, ug.GameID)
+	}
+	games := map[uint]models.Game{}
+	if len(gameIDs) > 0 {
+		var list []models.Game
+		db.Where("id IN ?", gameIDs).Find(&list)
+		for _, g := range list {
+			games[g.ID] = g
+		}
+	}
+
+	library := make([]gin.H, 0, len(userGames))
+	for _, ug := range userGames {
+		game, exists := games[ug.GameID]
+		if !exists {
+			continue
+		}
+		library = append(library, gin.H{
+			"game":        game,
+			"status":      ug.Status,
+			"play_time":   ug.PlayTime,
+			"last_played": ug.LastPlayed,
+			"acquired_at": ug.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "game.library_loaded"), "data": library})
+}
+
+// 上报游戏状态和游玩时长
+func UpdateGamePlayState(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	var req struct {
+		Status  string `json:"status"`
+		Minutes int    `json:"minutes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+	validStatus := map[string]bool{"": true, "downloaded": true, "installed": true, "playing": true}
+	if !validStatus[req.Status] || req.Minutes < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	now := time.Now().Unix()
+	updates := map[string]any{"updated_at": now}
+	if req.Status != "" {
+		updates["status"] = req.Status
+	}
+	if req.Minutes > 0 {
+		updates["play_time"] = gorm.Expr("play_time + ?", req.Minutes)
+		updates["last_played"] = now
+	}
+
+	result := db.Model(&models.UserGame{}).Where("user_id = ? AND game_id = ?", userID, c.Param("id")).Updates(updates)
+	if result.Error != nil {
+		respondAppError(c, result.Error, tr(c, "common.update_failed"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "game.not_in_library")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "common.update_success")})
+}
+
+// 获取游戏评价
+func GetGameReviews(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	game, ok := findGame(c, db)
+	if !ok {
+		return
+	}
+
+	var total int64
+	db.Model(&models.GameReview{}).Where("game_id = ?", game.ID).Count(&total)
+
+	var reviews []models.GameReview
+	db.Where("game_id = ?", game.ID).Order("updated_at DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).Find(&reviews)
+
+	userIDs := make([]uint, 0, len(reviews))
+	for _, r := range reviews {
+		userIDs = append(userIDs, r.UserID)
+	}
+	users := loadUserBriefs(db, userIDs)
+
+	list := make([]gin.H, 0, len(reviews))
+	for _, r := range reviews {
+		user := users[r.UserID]
+		list = append(list, gin.H{
+			"id":         r.ID,
+			"user_id":    r.UserID,
+			"nickname":   user.Nickname,
+			"avatar":     user.Avatar,
+			"rating":     r.Rating,
+			"content":    r.Content,
+			"created_at": r.CreatedAt,
+			"updated_at": r.UpdatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"msg":     tr(c, "game.reviews_loaded"),
+		"data": gin.H{
+			"total":   total,
+			"rating":  game.Rating,
+			"reviews": list,
+		},
+	})
+}
+
+// 发表或修改游戏评价，每个用户对同一游戏只保留一条
+func ReviewGame(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	var req struct {
+		Rating  int    `json:"rating" binding:"required"`
+		Content string `json:"content"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Rating < 1 || req.Rating > 5 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "game.invalid_rating")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	game, ok := findGame(c, db)
+	if !ok {
+		return
+	}
+	if !services.OwnsGame(db, userID, game.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": tr(c, "game.review_requires_ownership")})
+		return
+	}
+
+	content := utils.FilterSensitiveWords(strings.TrimSpace(req.Content))
+	var review models.GameReview
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now().Unix()
+		if err := tx.Where("game_id = ? AND user_id = ?", game.ID, userID).First(&review).Error; err == nil {
+			if err := tx.Model(&review).Updates(map[string]any{"rating": req.Rating, "content": content, "updated_at": now}).Error; err != nil {
+				return err
+			}
+		} else {
+			review = models.GameReview{
+				UserID:    userID,
+				GameID:    game.ID,
+				Rating:    req.Rating,
+				Content:   content,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			if err := tx.Create(&review).Error; err != nil {
+				return err
+			}
+		}
+		return services.RecomputeGameRating(tx, game.ID)
+	})
+	if err != nil {
+		respondAppError(c, err, tr(c, "game.review_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "game.reviewed"), "data": review})
+}
+
+// 删除自己的游戏评价
+func DeleteGameReview(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("game_id = ? AND user_id = ?", c.Param("id"), userID).Delete(&models.GameReview{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &utils.AppError{Code: http.StatusNotFound, Message: "评价不存在", Key: "game.review_not_found"}
+		}
+		gameID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+		return services.RecomputeGameRating(tx, uint(gameID))
+	})
+	if err != nil {
+		respondAppError(c, err, tr(c, "game.review_delete_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "game.review_deleted")})
+}
+
+// 获取游戏成就及当前用户的解锁状态
+func GetGameAchievements(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	game, ok := findGame(c, db)
+	if !ok {
+		return
+	}
+
+	var achievements []models.GameAchievement
+	db.Where("game_id = ?", game.ID).Order("id ASC").Find(&achievements)
+
+	var unlocked []models.UserGameAchievement
+	db.Where("user_id = ? AND game_id = ?", userID, game.ID).Find(&unlocked)
+	unlockedAt := map[uint]int64{}
+	for _, u := range unlocked {
+		unlockedAt[u.AchievementID] = u.UnlockedAt
+	}
+
+	list := make([]gin.H, 0, len(achievements))
+	for _, a := range achievements {
+		at, done := unlockedAt[a.ID]
+		list = append(list, gin.H{
+			"id":          a.ID,
+			"name":        a.Name,
+			"description": a.Description,
+			"icon":        a.Icon,
+			"unlocked":    done,
+			"unlocked_at": at,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"msg":     tr(c, "game.achievements_loaded"),
+		"data": gin.H{
+			"total":        len(achievements),
+			"unlocked":     len(unlocked),
+			"achievements": list,
+		},
+	})
+}
+
+// 解锁游戏成就，重复解锁不报错
+// 请求需携带游戏服务器用开发者密钥签发的时间戳和签名，客户端无法自行解锁
+func UnlockGameAchievement(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	var req struct {
+		Timestamp int64  `json:"timestamp" binding:"required"`
+		Signature string `json:"signature" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "game.achievement_signature_required")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	var achievement models.GameAchievement
+	if err := db.Where("id = ? AND game_id = ?", c.Param("achievement_id"), c.Param("id")).First(&achievement).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "game.achievement_not_found")})
+		return
+	}
+	if !services.OwnsGame(db, userID, achievement.GameID) {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": tr(c, "game.not_in_library")})
+		return
+	}
+	if err := services.VerifyAchievementUnlock(db, &achievement, userID, req.Timestamp, req.Signature); err != nil {
+		respondAppError(c, err, tr(c, "game.achievement_unlock_failed"))
+		return
+	}
+
+	var record models.UserGameAchievement
+	err := db.Where("user_id = ? AND achievement_id = ?", userID, achievement.ID).First(&record).Error
+	if err == nil {
+		c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "game.achievement_already_unlocked"), "data": record})
+		return
+	}
+
+	record = models.UserGameAchievement{
+		UserID:        userID,
+		GameID:        achievement.GameID,
+		AchievementID: achievement.ID,
+		UnlockedAt:    time.Now().Unix(),
+	}
+	if err := db.Create(&record).Error; err != nil {
+		respondAppError(c, err, tr(c, "game.achievement_unlock_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "game.achievement_unlocked"), "data": record})
+}
+
+// 获取游戏版本更新记录
+func GetGameUpdates(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	game, ok := findGame(c, db)
+	if !ok {
+		return
+	}
+
+	var updates []models.GameUpdate
+	db.Where("game_id = ?", game.ID).Order("created_at DESC").Limit(50).Find(&updates)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "game.updates_loaded"), "data": updates})
+}
+
+// 获取游戏库中所有游戏的更新动态
+func GetGameUpdateFeed(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	query := db.Table("game_updates AS gu").
+		Select("gu.id, gu.game_id, gu.version, gu.description, gu.size, gu.created_at, g.name AS game_name, g.cover_image").
+		Joins("JOIN user_games ug ON ug.game_id = gu.game_id AND ug.user_id = ?", userID).
+		Joins("JOIN games g ON g.id = gu.game_id")
+	if before, _ := strconv.ParseInt(c.Query("before"), 10, 64); before > 0 {
+		query = query.Where("gu.created_at < ?", before)
+	}
+
+	var feed []struct {
+		ID          uint   `json:"id"`
+		GameID      uint   `json:"game_id"`
+		GameName    string `json:"game_name"`
+		CoverImage  string `json:"cover_image"`
+		Version     string `json:"version"`
+		Description string `json:"description"`
+		Size        int64  `json:"size"`
+		CreatedAt   int64  `json:"created_at"`
+	}
+	if err := query.Order("gu.created_at DESC").Limit(limit).Scan(&feed).Error; err != nil {
+		respondAppError(c, err, tr(c, "game.feed_query_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "game.feed_loaded"), "data": feed})
+}
+
+// 根据路由参数查询游戏
+func findGame(c *gin.Context, db *gorm.DB) (*models.Game, bool) {
+	var game models.Game
+	if err := db.First(&game, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "game.not_found")})
+		return nil, false
+	}
+	return &game, true
+}
@@ -0,0 +1,569 @@
+package controllers
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/services"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 游戏开发者平台相关接口
+
+// 开发者发布或修改游戏的请求参数
+type gameForm struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	CoverImage  string   `json:"cover_image"`
+	IntroVideo  string   `json:"intro_video"`
+	Type        string   `json:"type"`
+	Price       *float64 `json:"price"`
+	IsFree      *bool    `json:"is_free"`
+	Platforms   []string `json:"platforms"`
+	Status      string   `json:"status"`
+}
+
+// 注册成为游戏开发者
+func RegisterGameDeveloper(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	var req struct {
+		Name        string `json:"name" binding:"required"`
+		Description string `json:"description"`
+		Logo        string `json:"logo"`
+		Website     string `json:"website"`
+		Email       string `json:"email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "game_developer.name_required")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	var count int64
+	db.Model(&models.GameDeveloper{}).Where("user_id = ?", userID).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "game_developer.already_registered")})
+		return
+	}
+
+	now := time.Now().Unix()
+	developer := models.GameDeveloper{
+		UserID:      userID,
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Logo:        req.Logo,
+		Website:     req.Website,
+		Email:       req.Email,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := db.Create(&developer).Error; err != nil {
+		respondAppError(c, err, tr(c, "game_developer.register_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "game_developer.registered"), "data": developer})
+}
+
+// 获取当前用户的开发者资料
+func GetGameDeveloperProfile(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	developer, ok := currentGameDeveloper(c, db)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "game_developer.profile_loaded"), "data": developer})
+}
+
+// 更新开发者资料
+func UpdateGameDeveloperProfile(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	developer, ok := currentGameDeveloper(c, db)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		Logo        *string `json:"logo"`
+		Website     *string `json:"website"`
+		Email       *string `json:"email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+
+	updates := map[string]any{"updated_at": time.Now().Unix()}
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "game_developer.name_empty")})
+			return
+		}
+		updates["name"] = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.Logo != nil {
+		updates["logo"] = *req.Logo
+	}
+	if req.Website != nil {
+		updates["website"] = *req.Website
+	}
+	if req.Email != nil {
+		updates["email"] = *req.Email
+	}
+
+	if err := db.Model(developer).Updates(updates).Error; err != nil {
+		respondAppError(c, err, tr(c, "game_developer.profile_update_failed"))
+		return
+	}
+	db.First(developer, developer.ID)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "game_developer.profile_updated"), "data": developer})
+}
+
+// 生成新的成就签名密钥，游戏服务器用它签发成就解锁请求，旧密钥立即失效
+func RotateAchievementSecret(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	developer, ok := currentGameDeveloper(c, db)
+	if !ok {
+		return
+	}
+
+	secret, err := services.RotateAchievementSecret(db, developer)
+	if err != nil {
+		respondAppError(c, err, tr(c, "game_developer.achievement_secret_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"msg":     tr(c, "game_developer.achievement_secret_generated"),
+		"data":    gin.H{"achievement_secret": secret},
+	})
+}
+
+// 获取开发者发布的游戏
+func GetDeveloperGames(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	developer, ok := currentGameDeveloper(c, db)
+	if !ok {
+		return
+	}
+
+	var games []models.Game
+	db.Where("developer_id = ?", developer.ID).Order("created_at DESC").Find(&games)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "game.list_loaded"), "data": games})
+}
+
+// 发布新游戏
+func PublishGame(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	developer, ok := currentGameDeveloper(c, db)
+	if !ok {
+		return
+	}
+
+	var req gameForm
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" || strings.TrimSpace(req.Type) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "game_developer.game_fields_required")})
+		return
+	}
+
+	now := time.Now().Unix()
+	game := models.Game{
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		CoverImage:  req.CoverImage,
+		IntroVideo:  req.IntroVideo,
+		Type:        strings.TrimSpace(req.Type),
+		IsFree:      true,
+		DeveloperID: developer.ID,
+		Platforms:   strings.Join(req.Platforms, ","),
+		Status:      "published",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if req.Price != nil {
+		game.Price = *req.Price
+		game.IsFree = *req.Price <= 0
+	}
+	if req.IsFree != nil && *req.IsFree {
+		game.IsFree = true
+		game.Price = 0
+	}
+	if req.Status == "unpublished" {
+		game.Status = req.Status
+	}
+	if game.Price < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "game_developer.negative_price")})
+		return
+	}
+
+	if err := db.Create(&game).Error; err != nil {
+		respondAppError(c, err, tr(c, "game_developer.publish_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "game_developer.published"), "data": game})
+}
+
+// 更新游戏信息，也可用于上架或下架
+func UpdateDeveloperGame(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	developer, ok := currentGameDeveloper(c, db)
+	if !ok {
+		return
+	}
+	game, ok := findDeveloperGame(c, db, developer.ID)
+	if !ok {
+		return
+	}
+
+	var req gameForm
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+
+	updates := map[string]any{"updated_at": time.Now().Unix()}
+	if name := strings.TrimSpace(req.Name); name != "" {
+		updates["name"] = name
+	}
+	if req.Description != "" {
+		updates["description"] = req.Description
+	}
+	if req.CoverImage != "" {
+		updates["cover_image"] = req.CoverImage
+	}
+	if req.IntroVideo != "" {
+		updates["intro_video"] = req.IntroVideo
+	}
+	if gameType := strings.TrimSpace(req.Type); gameType != "" {
+		updates["type"] = gameType
+	}
+	if req.Platforms != nil {
+		updates["platforms"] = strings.Join(req.Platforms, ",")
+	}
+	if req.Price != nil {
+		if *req.Price < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "game_developer.negative_price")})
+			return
+		}
+		updates["price"] = *req.Price
+		updates["is_free"] = *req.Price <= 0
+	}
+	if req.IsFree != nil && *req.IsFree {
+		updates["price"] = 0
+		updates["is_free"] = true
+	}
+	switch req.Status {
+	case "":
+	case "published", "unpublished":
+		updates["status"] = req.Status
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "game_developer.invalid_status")})
+		return
+	}
+
+	if err := db.Model(game).Updates(updates).Error; err != nil {
+		respondAppError(c, err, tr(c, "game_developer.game_update_failed"))
+		return
+	}
+	db.First(game, game.ID)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "game_developer.game_updated"), "data": game})
+}
+
+// 为游戏添加成就
+func CreateGameAchievement(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	developer, ok := currentGameDeveloper(c, db)
+	if !ok {
+		return
+	}
+	game, ok := findDeveloperGame(c, db, developer.ID)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name        string `json:"name" binding:"required"`
+		Description string `json:"description"`
+		Icon        string `json:"icon"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "game_developer.achievement_name_required")})
+		return
+	}
+
+	achievement := models.GameAchievement{
+		GameID:      game.ID,
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Icon:        req.Icon,
+		CreatedAt:   time.Now().Unix(),
+	}
+	if err := db.Create(&achievement).Error; err != nil {
+		respondAppError(c, err, tr(c, "game_developer.achievement_add_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "game_developer.achievement_added"), "data": achievement})
+}
+
+// 修改游戏成就
+func UpdateGameAchievement(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	developer, ok := currentGameDeveloper(c, db)
+	if !ok {
+		return
+	}
+	game, ok := findDeveloperGame(c, db, developer.ID)
+	if !ok {
+		return
+	}
+
+	var achievement models.GameAchievement
+	if err := db.Where("id = ? AND game_id = ?", c.Param("achievement_id"), game.ID).First(&achievement).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "game.achievement_not_found")})
+		return
+	}
+
+	var req struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Icon        string `json:"icon"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+
+	updates := map[string]any{}
+	if name := strings.TrimSpace(req.Name); name != "" {
+		updates["name"] = name
+	}
+	if req.Description != "" {
+		updates["description"] = req.Description
+	}
+	if req.Icon != "" {
+		updates["icon"] = req.Icon
+	}
+	if len(updates) > 0 {
+		if err := db.Model(&achievement).Updates(updates).Error; err != nil {
+			respondAppError(c, err, tr(c, "game_developer.achievement_update_failed"))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "game_developer.achievement_updated"), "data": achievement})
+}
+
+// 发布游戏版本更新
+func PublishGameUpdate(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	developer, ok := currentGameDeveloper(c, db)
+	if !ok {
+		return
+	}
+	game, ok := findDeveloperGame(c, db, developer.ID)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Version     string `json:"version" binding:"required"`
+		Description string `json:"description"`
+		Size        int64  `json:"size"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Version) == "" || req.Size < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "game_developer.version_required")})
+		return
+	}
+
+	var count int64
+	db.Model(&models.GameUpdate{}).Where("game_id = ? AND version = ?", game.ID, strings.TrimSpace(req.Version)).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "game_developer.version_exists")})
+		return
+	}
+
+	now := time.Now().Unix()
+	update := models.GameUpdate{
+		GameID:      game.ID,
+		Version:     strings.TrimSpace(req.Version),
+		Description: req.Description,
+		Size:        req.Size,
+		CreatedAt:   now,
+	}
+	if err := db.Create(&update).Error; err != nil {
+		respondAppError(c, err, tr(c, "game_developer.update_publish_failed"))
+		return
+	}
+	db.Model(game).UpdateColumn("updated_at", now)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "game_developer.update_published"), "data": update})
+}
+
+// 获取开发者的销售和下载统计
+func GetDeveloperGameStats(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	developer, ok := currentGameDeveloper(c, db)
+	if !ok {
+		return
+	}
+
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if days < 1 || days > 365 {
+		days = 30
+	}
+	since := time.Now().AddDate(0, 0, -days).Unix()
+
+	var games []models.Game
+	db.Where("developer_id = ?", developer.ID).Order("created_at DESC").Find(&games)
+
+	var sales []struct {
+		GameID  uint
+		Count   int
+		Revenue float64
+	}
+	db.Model(&models.GameOrder{}).
+		Select("game_id, COUNT(*) AS count, COALESCE(SUM(amount), 0) AS revenue").
+		Where("developer_id = ? AND status = ?", developer.ID, "paid").
+		Group("game_id").Scan(&sales)
+	salesByGame := map[uint]int{}
+	revenueByGame := map[uint]float64{}
+	for _, s := range sales {
+		salesByGame[s.GameID] = s.Count
+		revenueByGame[s.GameID] = s.Revenue
+	}
+
+	totalSales, totalDownloads, totalRevenue := 0, 0, 0.0
+	list := make([]gin.H, 0, len(games))
+	for _, g := range games {
+		totalSales += salesByGame[g.ID]
+		totalDownloads += g.Downloads
+		totalRevenue += revenueByGame[g.ID]
+		list = append(list, gin.H{
+			"game_id":   g.ID,
+			"name":      g.Name,
+			"status":    g.Status,
+			"price":     g.Price,
+			"rating":    g.Rating,
+			"downloads": g.Downloads,
+			"sales":     salesByGame[g.ID],
+			"revenue":   revenueByGame[g.ID],
+		})
+	}
+
+	// 按天统计近期销量
+	var daily []struct {
+		Day     string  `json:"day"`
+		Sales   int     `json:"sales"`
+		Revenue float64 `json:"revenue"`
+	}
+	db.Model(&models.GameOrder{}).
+		Select("date(created_at, 'unixepoch') AS day, COUNT(*) AS sales, COALESCE(SUM(amount), 0) AS revenue").
+		Where("developer_id = ? AND status = ? AND created_at >= ?", developer.ID, "paid", since).
+		Group("day").Order("day ASC").Scan(&daily)
+
+	var pending struct {
+		Count  int
+		Amount float64
+	}
+	db.Model(&models.GameOrder{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount").
+		Where("developer_id = ? AND status = ? AND settlement_id = 0", developer.ID, "paid").
+		Scan(&pending)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"msg":     tr(c, "game_developer.stats_loaded"),
+		"data": gin.H{
+			"total_sales":     totalSales,
+			"total_downloads": totalDownloads,
+			"total_revenue":   totalRevenue,
+			"pending_orders":  pending.Count,
+			"pending_amount":  pending.Amount,
+			"fee_rate":        services.GetGameSettlementConfig().PlatformFeeRate,
+			"games":           list,
+			"daily":           daily,
+		},
+	})
+}
+
+// 获取开发者的收入结算记录
+func GetDeveloperSettlements(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	developer, ok := currentGameDeveloper(c, db)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	var total int64
+	db.Model(&models.GameSettlement{}).Where("developer_id = ?", developer.ID).Count(&total)
+
+	var settlements []models.GameSettlement
+	db.Where("developer_id = ?", developer.ID).Order("created_at DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).Find(&settlements)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"msg":     tr(c, "game_developer.settlements_loaded"),
+		"data": gin.H{
+			"total":       total,
+			"page":        page,
+			"page_size":   pageSize,
+			"settlements": settlements,
+		},
+	})
+}
+
+// 查询当前用户对应的开发者，未注册时返回错误
+func currentGameDeveloper(c *gin.Context, db *gorm.DB) (*models.GameDeveloper, bool) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return nil, false
+	}
+
+	var developer models.GameDeveloper
+	if err := db.Where("user_id = ?", userID).First(&developer).Error; err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": tr(c, "game_developer.not_registered")})
+		return nil, false
+	}
+	return &developer, true
+}
+
+// 查询开发者名下的游戏
+func findDeveloperGame(c *gin.Context, db *gorm.DB, developerID uint) (*models.Game, bool) {
+	var game models.Game
+	if err := db.Where("id = ? AND developer_id = ?", c.Param("id"), developerID).First(&game).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "game.not_found")})
+		return nil, false
+	}
+	return &game, true
+}
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"allinone_backend/models"
+	"allinone_backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 群聊AI助手相关接口
+
+// EnableGroupAI 将AI助手加入群组，仅群主或管理员可操作
+func (g *GroupController) EnableGroupAI(c *gin.Context) {
+	g.toggleGroupAI(c, true)
+}
+
+// DisableGroupAI 将AI助手移出群组，仅群主或管理员可操作
+func (g *GroupController) DisableGroupAI(c *gin.Context) {
+	g.toggleGroupAI(c, false)
+}
+
+func (g *GroupController) toggleGroupAI(c *gin.Context, enable bool) {
+	db := c.MustGet("db").(*gorm.DB)
+	userID := c.GetUint("user_id")
+
+	var req struct {
+		GroupID uint `json:"group_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+
+	var group models.Group
+	if err := db.First(&group, req.GroupID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "group.not_found")})
+		return
+	}
+	if err := services.CheckGroupManager(db, group.ID, userID); err != nil {
+		respondAppError(c, err, tr(c, "common.operation_failed"))
+		return
+	}
+	if !enable && group.Type == "ai" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "group_ai.cannot_remove_bot")})
+		return
+	}
+
+	var err error
+	msgKey := "group_ai.enabled"
+	if enable {
+		err = services.EnableGroupAI(db, group.ID, userID)
+	} else {
+		err = services.DisableGroupAI(db, group.ID)
+		msgKey = "group_ai.disabled"
+	}
+	if err != nil {
+		respondAppError(c, err, tr(c, "common.operation_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, msgKey), "data": gin.H{"enabled": enable}})
+}
+
+// MarkGroupRead 标记群消息已读到指定位置
+func (g *GroupController) MarkGroupRead(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	userID := c.GetUint("user_id")
+
+	var req struct {
+		GroupID   uint `json:"group_id" binding:"required"`
+		MessageID uint `json:"message_id"` // 为空时标记到最新消息
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+	if !isGroupMember(db, req.GroupID, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": tr(c, "group.not_member")})
+		return
+	}
+
+	messageID := req.MessageID
+	if messageID == 0 {
+		db.Model(&models.ChatMessage{}).Where("group_id = ?", req.GroupID).
+			Select("COALESCE(MAX(id), 0)").Scan(&messageID)
+	}
+	if err := services.MarkGroupRead(db, req.GroupID, userID, messageID); err != nil {
+		respondAppError(c, err, tr(c, "group_ai.mark_read_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"msg":     tr(c, "group_ai.marked_read"),
+		"data":    gin.H{"last_read_message_id": services.GetGroupLastRead(db, req.GroupID, userID)},
+	})
+}
+
+// CatchUpGroup 使用AI总结上次已读之后的群消息，摘要仅返回给请求者
+func (g *GroupController) CatchUpGroup(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	userID := c.GetUint("user_id")
+
+	var req struct {
+		GroupID  uint `json:"group_id" binding:"required"`
+		MarkRead bool `json:"mark_read"` // 摘要后是否标记为已读
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+	if !isGroupMember(db, req.GroupID, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": tr(c, "group.not_member")})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+	catchUp, err := services.SummarizeGroupSinceLastRead(ctx, db, req.GroupID, userID)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "msg": tr(c, aiErrorKey(err))})
+		return
+	}
+
+	if req.MarkRead {
+		services.MarkGroupRead(db, req.GroupID, userID, catchUp.ToMessageID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": catchUp})
+}
+
+// 判断用户是否为群成员
+func isGroupMember(db *gorm.DB, groupID, userID uint) bool {
+	var count int64
+	db.Model(&models.GroupMember{}).Where("group_id = ? AND user_id = ?", groupID, userID).Count(&count)
+	return count > 0
+}
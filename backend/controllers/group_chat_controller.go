@@ -23,7 +23,7 @@ func (g *GroupChatController) SendGroupMessage(c *gin.Context) {
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
-			"msg":     "请先登录",
+			"msg":     tr(c, "auth.login_required"),
 		})
 		return
 	}
@@ -32,7 +32,7 @@ func (g *GroupChatController) SendGroupMessage(c *gin.Context) {
 	if !ok {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "用户ID无效",
+			"msg":     tr(c, "common.invalid_user_id"),
 		})
 		return
 	}
@@ -48,7 +48,7 @@ func (g *GroupChatController) SendGroupMessage(c *gin.Context) {
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "参数错误: " + err.Error(),
+			"msg":     tr(c, "common.invalid_params_detail", err.Error()),
 		})
 		return
 	}
@@ -58,7 +58,7 @@ func (g *GroupChatController) SendGroupMessage(c *gin.Context) {
 	if err := db.Where("group_id = ? AND user_id = ?", req.GroupID, userID).First(&member).Error; err != nil {
 		c.JSON(http.StatusForbidden, gin.H{
 			"success": false,
-			"msg":     "您不是该群组成员",
+			"msg":     tr(c, "group.not_member"),
 		})
 		return
 	}
@@ -75,7 +75,7 @@ func (g *GroupChatController) SendGroupMessage(c *gin.Context) {
 		} else {
 			c.JSON(http.StatusForbidden, gin.H{
 				"success": false,
-				"msg":     "您已被禁言",
+				"msg":     tr(c, "group.muted"),
 			})
 			return
 		}
@@ -93,7 +93,7 @@ func (g *GroupChatController) SendGroupMessage(c *gin.Context) {
 	if err := db.Create(&message).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"msg":     "发送消息失败: " + err.Error(),
+			"msg":     tr(c, "group.send_failed_detail", err.Error()),
 		})
 		return
 	}
@@ -145,7 +145,7 @@ func (g *GroupChatController) SendGroupMessage(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "发送消息成功",
+		"msg":     tr(c, "group.message_sent"),
 		"data":    messageData,
 	})
 }
@@ -159,7 +159,7 @@ func (g *GroupChatController) GetGroupMessages(c *gin.Context) {
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
-			"msg":     "请先登录",
+			"msg":     tr(c, "auth.login_required"),
 		})
 		return
 	}
@@ -168,7 +168,7 @@ func (g *GroupChatController) GetGroupMessages(c *gin.Context) {
 	if !ok {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "用户ID无效",
+			"msg":     tr(c, "common.invalid_user_id"),
 		})
 		return
 	}
@@ -178,7 +178,7 @@ func (g *GroupChatController) GetGroupMessages(c *gin.Context) {
 	if groupIDStr == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "群组ID不能为空",
+			"msg":     tr(c, "group.id_required"),
 		})
 		return
 	}
@@ -187,7 +187,7 @@ func (g *GroupChatController) GetGroupMessages(c *gin.Context) {
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "群组ID格式错误",
+			"msg":     tr(c, "group.invalid_id"),
 		})
 		return
 	}
@@ -197,7 +197,7 @@ func (g *GroupChatController) GetGroupMessages(c *gin.Context) {
 	if err := db.Where("group_id = ? AND user_id = ?", groupID, userID).First(&member).Error; err != nil {
 		c.JSON(http.StatusForbidden, gin.H{
 			"success": false,
-			"msg":     "您不是该群组成员",
+			"msg":     tr(c, "group.not_member"),
 		})
 		return
 	}
@@ -221,7 +221,7 @@ func (g *GroupChatController) GetGroupMessages(c *gin.Context) {
 	if err := db.Where("group_id = ?", groupID).Order("created_at DESC").Limit(limit).Offset(offset).Find(&messages).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"msg":     "获取消息失败: " + err.Error(),
+			"msg":     tr(c, "group.messages_query_failed_detail", err.Error()),
 		})
 		return
 	}
@@ -251,7 +251,7 @@ func (g *GroupChatController) GetGroupMessages(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取消息成功",
+		"msg":     tr(c, "group.messages_loaded"),
 		"data":    messageDataList,
 	})
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"allinone_backend/models"
+	"allinone_backend/services"
 	"allinone_backend/utils"
 
 	"github.com/gin-gonic/gin"
@@ -24,12 +25,13 @@ func (g *GroupController) CreateGroup(c *gin.Context) {
 		Avatar  string `json:"avatar"`
 		Notice  string `json:"notice"`
 		Members []uint `json:"members" binding:"required"`
+		Type    string `json:"type"` // normal, work, game, ai
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "参数错误: " + err.Error(),
+			"msg":     tr(c, "common.invalid_params_detail", err.Error()),
 		})
 		return
 	}
@@ -39,7 +41,7 @@ func (g *GroupController) CreateGroup(c *gin.Context) {
 	if err := db.First(&owner, req.OwnerID).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "创建者不存在",
+			"msg":     tr(c, "group.creator_not_found"),
 		})
 		return
 	}
@@ -50,13 +52,17 @@ func (g *GroupController) CreateGroup(c *gin.Context) {
 		OwnerID:   req.OwnerID,
 		Avatar:    req.Avatar,
 		Notice:    req.Notice,
+		Type:      req.Type,
 		CreatedAt: time.Now().Unix(),
 	}
+	if group.Type == "" {
+		group.Type = "normal"
+	}
 
 	if err := db.Create(&group).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"msg":     "创建群组失败: " + err.Error(),
+			"msg":     tr(c, "group.create_failed_detail", err.Error()),
 		})
 		return
 	}
@@ -100,6 +106,13 @@ func (g *GroupController) CreateGroup(c *gin.Context) {
 		}
 	}
 
+	// AI群组自动加入AI助手
+	if group.Type == "ai" {
+		if err := services.EnableGroupAI(db, group.ID, req.OwnerID); err != nil {
+			utils.Logger.Errorf("AI助手加入群组失败: %v", err)
+		}
+	}
+
 	// 获取成员数量
 	var memberCount int64
 	db.Model(&models.GroupMember{}).Where("group_id = ?", group.ID).Count(&memberCount)
@@ -111,13 +124,14 @@ func (g *GroupController) CreateGroup(c *gin.Context) {
 		"owner_id":     group.OwnerID,
 		"avatar":       group.Avatar,
 		"notice":       group.Notice,
+		"type":         group.Type,
 		"created_at":   group.CreatedAt,
 		"member_count": memberCount,
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "群组创建成功",
+		"msg":     tr(c, "group.created"),
 		"data":    groupData,
 	})
 }
@@ -129,7 +143,7 @@ func (g *GroupController) GetGroupList(c *gin.Context) {
 	if userIDStr == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "用户ID不能为空",
+			"msg":     tr(c, "common.user_id_required"),
 		})
 		return
 	}
@@ -138,7 +152,7 @@ func (g *GroupController) GetGroupList(c *gin.Context) {
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "用户ID格式错误",
+			"msg":     tr(c, "common.invalid_user_id"),
 		})
 		return
 	}
@@ -148,7 +162,7 @@ func (g *GroupController) GetGroupList(c *gin.Context) {
 	if err := db.Where("user_id = ?", userID).Find(&groupMembers).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"msg":     "获取群组列表失败: " + err.Error(),
+			"msg":     tr(c, "group.list_failed_detail", err.Error()),
 		})
 		return
 	}
@@ -182,7 +196,7 @@ func (g *GroupController) GetGroupList(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取群组列表成功",
+		"msg":     tr(c, "group.list_loaded"),
 		"data":    groups,
 	})
 }
@@ -194,7 +208,7 @@ func (g *GroupController) GetGroupInfo(c *gin.Context) {
 	if groupIDStr == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "群组ID不能为空",
+			"msg":     tr(c, "group.id_required"),
 		})
 		return
 	}
@@ -203,7 +217,7 @@ func (g *GroupController) GetGroupInfo(c *gin.Context) {
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "群组ID格式错误",
+			"msg":     tr(c, "group.invalid_id"),
 		})
 		return
 	}
@@ -213,7 +227,7 @@ func (g *GroupController) GetGroupInfo(c *gin.Context) {
 	if err := db.First(&group, groupID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"msg":     "获取群组信息失败: " + err.Error(),
+			"msg":     tr(c, "group.info_query_failed_detail", err.Error()),
 		})
 		return
 	}
@@ -235,7 +249,7 @@ func (g *GroupController) GetGroupInfo(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取群组信息成功",
+		"msg":     tr(c, "group.info_loaded"),
 		"data":    groupInfo,
 	})
 }
@@ -247,7 +261,7 @@ func (g *GroupController) GetGroupMembers(c *gin.Context) {
 	if groupIDStr == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "群组ID不能为空",
+			"msg":     tr(c, "group.id_required"),
 		})
 		return
 	}
@@ -256,7 +270,7 @@ func (g *GroupController) GetGroupMembers(c *gin.Context) {
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "群组ID格式错误",
+			"msg":     tr(c, "group.invalid_id"),
 		})
 		return
 	}
@@ -266,7 +280,7 @@ func (g *GroupController) GetGroupMembers(c *gin.Context) {
 	if err := db.Where("group_id = ?", groupID).Find(&groupMembers).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"msg":     "获取群组成员失败: " + err.Error(),
+			"msg":     tr(c, "group.members_query_failed_detail", err.Error()),
 		})
 		return
 	}
@@ -294,7 +308,7 @@ func (g *GroupController) GetGroupMembers(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取群组成员成功",
+		"msg":     tr(c, "group.members_loaded"),
 		"data":    members,
 	})
 }
@@ -312,7 +326,7 @@ func (g *GroupController) UpdateGroup(c *gin.Context) {
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "参数错误: " + err.Error(),
+			"msg":     tr(c, "common.invalid_params_detail", err.Error()),
 		})
 		return
 	}
@@ -322,7 +336,7 @@ func (g *GroupController) UpdateGroup(c *gin.Context) {
 	if err := db.First(&group, req.GroupID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"msg":     "群组不存在",
+			"msg":     tr(c, "group.not_found"),
 		})
 		return
 	}
@@ -347,7 +361,7 @@ func (g *GroupController) UpdateGroup(c *gin.Context) {
 	if err := db.Model(&models.Group{}).Where("id = ?", req.GroupID).Updates(updates).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"msg":     "更新群组信息失败: " + err.Error(),
+			"msg":     tr(c, "group.update_failed_detail", err.Error()),
 		})
 		return
 	}
@@ -356,7 +370,7 @@ func (g *GroupController) UpdateGroup(c *gin.Context) {
 	if err := db.First(&group, req.GroupID).Error; err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
-			"msg":     "群组信息更新成功，但获取详情失败",
+			"msg":     tr(c, "group.updated_reload_failed"),
 		})
 		return
 	}
@@ -379,7 +393,7 @@ func (g *GroupController) UpdateGroup(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "群组信息更新成功",
+		"msg":     tr(c, "group.updated"),
 		"data":    groupInfo,
 	})
 }
@@ -395,7 +409,7 @@ func (g *GroupController) LeaveGroup(c *gin.Context) {
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "参数错误: " + err.Error(),
+			"msg":     tr(c, "common.invalid_params_detail", err.Error()),
 		})
 		return
 	}
@@ -405,7 +419,7 @@ func (g *GroupController) LeaveGroup(c *gin.Context) {
 	if err := db.Where("group_id = ? AND user_id = ?", req.GroupID, req.UserID).First(&member).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "您不是该群组成员",
+			"msg":     tr(c, "group.not_member"),
 		})
 		return
 	}
@@ -413,7 +427,7 @@ func (g *GroupController) LeaveGroup(c *gin.Context) {
 	if member.Role == "owner" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "群主不能退出群组，请先转让群主身份",
+			"msg":     tr(c, "group.owner_cannot_leave"),
 		})
 		return
 	}
@@ -422,14 +436,14 @@ func (g *GroupController) LeaveGroup(c *gin.Context) {
 	if err := db.Where("group_id = ? AND user_id = ?", req.GroupID, req.UserID).Delete(&models.GroupMember{}).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"msg":     "退出群组失败: " + err.Error(),
+			"msg":     tr(c, "group.leave_failed_detail", err.Error()),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "退出群组成功",
+		"msg":     tr(c, "group.left"),
 	})
 }
 
@@ -445,7 +459,7 @@ func (g *GroupController) AddGroupMember(c *gin.Context) {
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "参数错误: " + err.Error(),
+			"msg":     tr(c, "common.invalid_params_detail", err.Error()),
 		})
 		return
 	}
@@ -455,7 +469,7 @@ func (g *GroupController) AddGroupMember(c *gin.Context) {
 	if err := db.Where("group_id = ? AND user_id = ?", req.GroupID, req.InviterID).First(&inviter).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "只有群成员才能邀请新成员",
+			"msg":     tr(c, "group.invite_members_only"),
 		})
 		return
 	}
@@ -488,7 +502,7 @@ func (g *GroupController) AddGroupMember(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "成功添加 " + strconv.Itoa(successCount) + " 名成员",
+		"msg":     tr(c, "group.members_added", successCount),
 	})
 }
 
@@ -504,7 +518,7 @@ func (g *GroupController) RemoveGroupMember(c *gin.Context) {
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "参数错误: " + err.Error(),
+			"msg":     tr(c, "common.invalid_params_detail", err.Error()),
 		})
 		return
 	}
@@ -514,7 +528,7 @@ func (g *GroupController) RemoveGroupMember(c *gin.Context) {
 	if err := db.Where("group_id = ? AND user_id = ?", req.GroupID, req.OperatorID).First(&operator).Error; err != nil {
 		c.JSON(http.StatusForbidden, gin.H{
 			"success": false,
-			"msg":     "您不是该群组成员",
+			"msg":     tr(c, "group.not_member"),
 		})
 		return
 	}
@@ -522,7 +536,7 @@ func (g *GroupController) RemoveGroupMember(c *gin.Context) {
 	if operator.Role != "owner" && operator.Role != "admin" {
 		c.JSON(http.StatusForbidden, gin.H{
 			"success": false,
-			"msg":     "只有群主或管理员才能移除成员",
+			"msg":     tr(c, "group.remove_requires_admin"),
 		})
 		return
 	}
@@ -532,7 +546,7 @@ func (g *GroupController) RemoveGroupMember(c *gin.Context) {
 	if err := db.Where("group_id = ? AND user_id = ?", req.GroupID, req.UserID).First(&target).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "要移除的成员不存在",
+			"msg":     tr(c, "group.member_not_found"),
 		})
 		return
 	}
@@ -541,7 +555,7 @@ func (g *GroupController) RemoveGroupMember(c *gin.Context) {
 	if target.Role == "owner" {
 		c.JSON(http.StatusForbidden, gin.H{
 			"success": false,
-			"msg":     "不能移除群主",
+			"msg":     tr(c, "group.cannot_remove_owner"),
 		})
 		return
 	}
@@ -550,7 +564,7 @@ func (g *GroupController) RemoveGroupMember(c *gin.Context) {
 	if target.Role == "admin" && operator.Role != "owner" {
 		c.JSON(http.StatusForbidden, gin.H{
 			"success": false,
-			"msg":     "只有群主才能移除管理员",
+			"msg":     tr(c, "group.remove_admin_requires_owner"),
 		})
 		return
 	}
@@ -559,13 +573,13 @@ func (g *GroupController) RemoveGroupMember(c *gin.Context) {
 	if err := db.Where("group_id = ? AND user_id = ?", req.GroupID, req.UserID).Delete(&models.GroupMember{}).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"msg":     "移除成员失败: " + err.Error(),
+			"msg":     tr(c, "group.remove_failed_detail", err.Error()),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "成员移除成功",
+		"msg":     tr(c, "group.member_removed"),
 	})
 }
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"allinone_backend/middleware"
+	"allinone_backend/services"
+	"allinone_backend/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 多语言相关接口：用户自定义提示文本，管理员导入导出语言包
+
+// tr 按当前请求的语言获取消息文本
+func tr(c *gin.Context, key string, args ...interface{}) string {
+	return middleware.T(c, key, args...)
+}
+
+// appErrorMessage 应用错误的提示文本，设置了消息键时按当前请求的语言返回
+func appErrorMessage(c *gin.Context, err *utils.AppError) string {
+	return middleware.AppErrorMessage(c, err)
+}
+
+// 获取当前语言的全部提示文本，包含用户自定义的文本
+func GetI18nMessages(c *gin.Context) {
+	localizer := middleware.Localizer(c)
+	messages := utils.LanguagePackMessages(localizer.Lang)
+	for key, text := range localizer.Overrides {
+		messages[key] = text
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"lang": localizer.Lang, "messages": messages}})
+}
+
+// 获取用户自定义的提示文本，lang 为空时使用当前语言
+func GetUserLanguageOverrides(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	userID := c.GetUint("user_id")
+	lang := c.Query("lang")
+	if lang == "" {
+		lang = middleware.Localizer(c).Lang
+	}
+	lang = utils.NormalizeLanguage(lang)
+
+	messages := services.GetUserLanguageOverrides(db, userID, lang)
+	if messages == nil {
+		messages = map[string]string{}
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"lang": lang, "messages": messages}})
+}
+
+// 保存用户自定义的提示文本，messages 为空时清除
+func UpdateUserLanguageOverrides(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	userID := c.GetUint("user_id")
+
+	var req struct {
+		Lang     string            `json:"lang" binding:"required"`
+		Messages map[string]string `json:"messages"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+
+	lang, err := services.SetUserLanguageOverrides(db, userID, req.Lang, req.Messages)
+	if err != nil {
+		respondAppError(c, err, tr(c, "i18n.overrides_save_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "common.save_success"), "data": gin.H{"lang": lang, "messages": req.Messages}})
+}
+
+// 管理员获取语言包列表
+func GetLanguagePacks(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	packs, err := services.ListLanguagePacks(db)
+	if err != nil {
+		respondAppError(c, err, tr(c, "i18n.pack_query_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": packs})
+}
+
+// 管理员导出语言包，导出内容可直接用于导入
+func ExportLanguagePack(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	export, err := services.ExportLanguagePack(db, c.Param("lang"))
+	if err != nil {
+		respondAppError(c, err, tr(c, "i18n.export_failed"))
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+export.LangCode+".json")
+	c.JSON(http.StatusOK, export)
+}
+
+// 管理员导入语言包，同语言已导入的语言包整体替换
+func ImportLanguagePack(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	var req services.LanguagePackExport
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Messages) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "i18n.invalid_pack")})
+		return
+	}
+
+	pack, err := services.ImportLanguagePack(db, c.Param("lang"), req.Name, req.Messages)
+	if err != nil {
+		respondAppError(c, err, tr(c, "i18n.import_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "i18n.imported"), "data": pack})
+}
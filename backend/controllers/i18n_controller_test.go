@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 接口提示文本按请求语言返回
+func TestResponseMessageFollowsLanguage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"en-US,en;q=0.9", "Missing required parameters"},
+		{"zh-CN", "缺少必要参数"},
+		{"", "缺少必要参数"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/user/by-account", nil)
+		if tt.acceptLanguage != "" {
+			c.Request.Header.Set("Accept-Language", tt.acceptLanguage)
+		}
+		GetUserByAccount(c)
+
+		var body struct {
+			Success bool   `json:"success"`
+			Msg     string `json:"msg"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		if w.Code != http.StatusBadRequest || body.Msg != tt.want {
+			t.Errorf("Accept-Language=%q 返回 %d %q，应为 400 %q", tt.acceptLanguage, w.Code, body.Msg, tt.want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/services"
+	"allinone_backend/utils"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetMediaInfo 获取媒体信息和当前用户的下载地址，下载地址过期后可重新获取
+func GetMediaInfo(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	mediaID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "media.invalid_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	media, err := services.GetAccessibleMedia(db, userID.(uint), uint(mediaID))
+	if err != nil {
+		respondAppError(c, err, tr(c, "media.query_failed"))
+		return
+	}
+
+	data := mediaResponse(db, media, userID.(uint))
+	data["media"] = media
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": data})
+}
+
+// mediaResponse 媒体的下载地址，图片附带宽高和各规格缩略图的下载地址
+func mediaResponse(db *gorm.DB, media *models.Media, userID uint) gin.H {
+	url, expiresAt := services.MediaURL(media, userID)
+	data := gin.H{
+		"media_id":   media.ID,
+		"url":        url,
+		"expires_at": expiresAt,
+	}
+	if media.FileType == "image" {
+		data["width"] = media.Width
+		data["height"] = media.Height
+		renditions := services.MediaRenditions(db, media.ID)
+		for _, spec := range utils.ImageRenditions {
+			data[spec.Name+"_url"] = url
+			if item, ok := renditions[spec.Name]; ok {
+				data[spec.Name+"_url"], _ = services.MediaURL(&item, userID)
+			}
+		}
+	}
+	return data
+}
+
+// attachMessageMedia 校验消息引用的媒体，返回媒体地址和合并了媒体信息（缩略图地址、宽高等）的 Extra
+// 出错时已写入响应，ok 为 false
+func attachMessageMedia(c *gin.Context, db *gorm.DB, senderID, mediaID uint, target services.MediaTarget, extra string) (string, string, bool) {
+	media, info, err := services.AttachMessageMedia(c.Request.Context(), db, senderID, mediaID, target)
+	if err != nil {
+		respondAppError(c, err, tr(c, "media.attach_failed"))
+		return "", "", false
+	}
+	return services.MediaRef(media), mergeMessageExtra(extra, info), true
+}
+
+// mergeMessageExtra 将附加信息合并到消息的 Extra JSON 中
+func mergeMessageExtra(extra string, info map[string]interface{}) string {
+	merged := map[string]interface{}{}
+	if extra != "" {
+		json.Unmarshal([]byte(extra), &merged)
+	}
+	for k, v := range info {
+		merged[k] = v
+	}
+	data, _ := json.Marshal(merged)
+	return string(data)
+}
+
+// DeleteMediaFile 删除自己上传的媒体
+func DeleteMediaFile(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	mediaID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "media.invalid_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	if err := services.DeleteMedia(c.Request.Context(), db, userID.(uint), uint(mediaID)); err != nil {
+		respondAppError(c, err, tr(c, "media.delete_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "common.delete_success")})
+}
+
+// DownloadMedia 下载媒体内容，无需登录
+// 非公开媒体需要带 GetMediaInfo 或上传接口返回的签名，并校验签名用户仍有权访问
+func DownloadMedia(c *gin.Context) {
+	mediaID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "media.invalid_id")})
+		return
+	}
+
+	var media models.Media
+	if err := utils.DB.First(&media, mediaID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "media.not_found")})
+		return
+	}
+	if media.Scope != services.MediaScopePublic {
+		userID, err := utils.VerifyMediaSignature(media.ID, c.Query("uid"), c.Query("expires"), c.Query("sig"))
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": tr(c, "media.link_invalid")})
+			return
+		}
+		if !services.CanAccessMedia(utils.DB, &media, userID) {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "media.not_found")})
+			return
+		}
+	}
+
+	reader, err := services.OpenMedia(c.Request.Context(), utils.DB, &media)
+	if err != nil {
+		respondAppError(c, err, tr(c, "file.read_failed"))
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Type", media.ContentType)
+	c.Header("Content-Disposition", mime.FormatMediaType(mediaDisposition(media.ContentType), map[string]string{"filename": media.FileName}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("ETag", fmt.Sprintf(`"%s"`, media.Hash))
+	if media.Scope == services.MediaScopePublic {
+		c.Header("Cache-Control", "public, max-age=86400")
+	} else {
+		c.Header("Cache-Control", "private, max-age=300")
+	}
+
+	// 本地文件支持断点和范围请求，便于音视频拖动播放
+	if seeker, ok := reader.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, "", time.Unix(media.CreatedAt, 0), seeker)
+		return
+	}
+	c.DataFromReader(http.StatusOK, media.Size, media.ContentType, reader, nil)
+}
+
+// mediaDisposition 图片和音视频直接展示，其他类型（包括可执行脚本的SVG和HTML）作为附件下载
+func mediaDisposition(contentType string) string {
+	if strings.HasPrefix(contentType, "image/") && !strings.HasPrefix(contentType, "image/svg") ||
+		strings.HasPrefix(contentType, "audio/") || strings.HasPrefix(contentType, "video/") {
+		return "inline"
+	}
+	return "attachment"
+}
@@ -1,8 +1,720 @@
 package controllers
 
 import (
+	"allinone_backend/models"
+	"allinone_backend/services"
+	"allinone_backend/utils"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // 小程序相关接口
-func ListMiniApps(c *gin.Context) {}
+
+const (
+	// 小程序令牌有效期
+	miniAppTokenTTL = 30 * time.Minute
+	// 小程序支付单有效期
+	miniAppPaymentTTL = 15 * time.Minute
+)
+
+// 获取小程序列表
+func ListMiniApps(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	query := db.Model(&models.MiniApp{}).Where("status = ?", "active")
+	if keyword := strings.TrimSpace(c.Query("keyword")); keyword != "" {
+		query = query.Where("name LIKE ?", "%"+keyword+"%")
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var apps []models.MiniApp
+	query.Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&apps)
+
+	list := make([]gin.H, 0, len(apps))
+	for _, app := range apps {
+		list = append(list, miniAppManifest(&app))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"msg":     tr(c, "miniapp.list_loaded"),
+		"data": gin.H{
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+			"apps":      list,
+		},
+	})
+}
+
+// 获取最近使用的小程序
+func GetRecentMiniApps(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	var usages []models.MiniAppUsage
+	db.Where("user_id = ?", userID).Order("last_used_at DESC").Limit(limit).Find(&usages)
+
+	appIDs := make([]string, 0, len(usages))
+	for _, u := range usages {
+		appIDs = append(appIDs, u.AppID)
+	}
+	var apps []models.MiniApp
+	db.Where("app_id IN ? AND status = ?", appIDs, "active").Find(&apps)
+	appMap := make(map[string]models.MiniApp, len(apps))
+	for _, app := range apps {
+		appMap[app.AppID] = app
+	}
+
+	list := make([]gin.H, 0, len(usages))
+	for _, u := range usages {
+		app, exists := appMap[u.AppID]
+		if !exists {
+			continue
+		}
+		item := miniAppManifest(&app)
+		item["last_used_at"] = u.LastUsedAt
+		item["use_count"] = u.UseCount
+		list = append(list, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "miniapp.recent_loaded"), "data": list})
+}
+
+// 获取小程序详情及当前用户的授权情况
+func GetMiniApp(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	app, found := findActiveMiniApp(c, db)
+	if !found {
+		return
+	}
+
+	granted := grantedMiniAppScopes(db, userID, app.AppID)
+	data := miniAppManifest(app)
+	data["granted_scopes"] = granted
+	data["pending_scopes"] = pendingMiniAppScopes(app, granted)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "miniapp.detail_loaded"), "data": data})
+}
+
+// 启动小程序：按已授权范围签发令牌，并记录最近使用
+func LaunchMiniApp(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	app, found := findActiveMiniApp(c, db)
+	if !found {
+		return
+	}
+
+	granted := grantedMiniAppScopes(db, userID, app.AppID)
+	token, expiresAt, err := utils.GenerateMiniAppToken(userID, app.AppID, granted, miniAppTokenTTL)
+	if err != nil {
+		utils.Logger.Errorf("生成小程序令牌失败: appID=%s, error=%v", app.AppID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "miniapp.launch_failed")})
+		return
+	}
+	recordMiniAppUsage(db, userID, app.AppID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"msg":     tr(c, "miniapp.launched"),
+		"data": gin.H{
+			"app":            miniAppManifest(app),
+			"token":          token,
+			"expires_at":     expiresAt,
+			"granted_scopes": granted,
+			"pending_scopes": pendingMiniAppScopes(app, granted),
+		},
+	})
+}
+
+// 用户同意授权，签发包含授权范围的令牌
+func AuthorizeMiniApp(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	var req struct {
+		Scopes []string `json:"scopes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "miniapp.scopes_required")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	app, found := findActiveMiniApp(c, db)
+	if !found {
+		return
+	}
+
+	requested := splitCommaList(app.Scopes)
+	scopeSet := map[string]bool{}
+	for _, s := range grantedMiniAppScopes(db, userID, app.AppID) {
+		scopeSet[s] = true
+	}
+	for _, s := range req.Scopes {
+		if !containsString(requested, s) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "miniapp.scope_not_requested", s)})
+			return
+		}
+		scopeSet[s] = true
+	}
+	scopes := make([]string, 0, len(scopeSet))
+	for s := range scopeSet {
+		scopes = append(scopes, s)
+	}
+	sort.Strings(scopes)
+
+	now := time.Now().Unix()
+	var grant models.MiniAppGrant
+	if err := db.Where("user_id = ? AND app_id = ?", userID, app.AppID).First(&grant).Error; err != nil {
+		grant = models.MiniAppGrant{UserID: userID, AppID: app.AppID, CreatedAt: now}
+	}
+	grant.Scopes = strings.Join(scopes, ",")
+	grant.UpdatedAt = now
+	if err := db.Save(&grant).Error; err != nil {
+		utils.Logger.Errorf("保存小程序授权失败: appID=%s, userID=%d, error=%v", app.AppID, userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "miniapp.authorize_failed")})
+		return
+	}
+
+	token, expiresAt, err := utils.GenerateMiniAppToken(userID, app.AppID, scopes, miniAppTokenTTL)
+	if err != nil {
+		utils.Logger.Errorf("生成小程序令牌失败: appID=%s, error=%v", app.AppID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "miniapp.authorize_failed")})
+		return
+	}
+	recordMiniAppUsage(db, userID, app.AppID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"msg":     tr(c, "miniapp.authorized"),
+		"data": gin.H{
+			"token":          token,
+			"expires_at":     expiresAt,
+			"granted_scopes": scopes,
+		},
+	})
+}
+
+// 撤销对小程序的授权
+func RevokeMiniApp(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	if err := db.Where("user_id = ? AND app_id = ?", userID, c.Param("app_id")).Delete(&models.MiniAppGrant{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "miniapp.revoke_failed")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "miniapp.revoked")})
+}
+
+// 开发者注册小程序
+func RegisterMiniApp(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	var req struct {
+		Name        string   `json:"name" binding:"required"`
+		Icon        string   `json:"icon"`
+		EntryURL    string   `json:"entry_url" binding:"required"`
+		Description string   `json:"description"`
+		Scopes      []string `json:"scopes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+	if msg := validateMiniAppManifest(req.EntryURL, req.Scopes); msg != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": appErrorMessage(c, msg)})
+		return
+	}
+
+	now := time.Now().Unix()
+	app := models.MiniApp{
+		AppID:       "ma" + strings.ReplaceAll(uuid.New().String(), "-", "")[:16],
+		DeveloperID: userID,
+		Name:        strings.TrimSpace(req.Name),
+		Icon:        req.Icon,
+		EntryURL:    req.EntryURL,
+		Description: req.Description,
+		Scopes:      strings.Join(req.Scopes, ","),
+		Status:      "active",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	if err := db.Create(&app).Error; err != nil {
+		utils.Logger.Errorf("注册小程序失败: userID=%d, error=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "miniapp.register_failed")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "miniapp.registered"), "data": miniAppManifest(&app)})
+}
+
+// 开发者更新小程序信息
+func UpdateMiniApp(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	var req struct {
+		Name        string   `json:"name"`
+		Icon        string   `json:"icon"`
+		EntryURL    string   `json:"entry_url"`
+		Description string   `json:"description"`
+		Scopes      []string `json:"scopes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	var app models.MiniApp
+	if err := db.Where("app_id = ? AND developer_id = ?", c.Param("app_id"), userID).First(&app).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "miniapp.not_exist")})
+		return
+	}
+
+	if req.Name != "" {
+		app.Name = strings.TrimSpace(req.Name)
+	}
+	if req.Icon != "" {
+		app.Icon = req.Icon
+	}
+	if req.Description != "" {
+		app.Description = req.Description
+	}
+	if req.EntryURL != "" || req.Scopes != nil {
+		entryURL := app.EntryURL
+		if req.EntryURL != "" {
+			entryURL = req.EntryURL
+		}
+		scopes := splitCommaList(app.Scopes)
+		if req.Scopes != nil {
+			scopes = req.Scopes
+		}
+		if msg := validateMiniAppManifest(entryURL, scopes); msg != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": appErrorMessage(c, msg)})
+			return
+		}
+		app.EntryURL = entryURL
+		app.Scopes = strings.Join(scopes, ",")
+	}
+	app.UpdatedAt = time.Now().Unix()
+
+	if err := db.Save(&app).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "miniapp.update_failed")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "miniapp.updated"), "data": miniAppManifest(&app)})
+}
+
+// 获取开发者自己的小程序
+func GetDeveloperMiniApps(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	var apps []models.MiniApp
+	db.Where("developer_id = ?", userID).Order("created_at DESC").Find(&apps)
+
+	list := make([]gin.H, 0, len(apps))
+	for _, app := range apps {
+		item := miniAppManifest(&app)
+		item["status"] = app.Status
+		list = append(list, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "miniapp.list_loaded"), "data": list})
+}
+
+// 用户查看小程序支付单
+func GetMiniAppPayment(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	var payment models.MiniAppPayment
+	if err := db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&payment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "payment.order_not_found")})
+		return
+	}
+	expireMiniAppPayment(db, &payment)
+
+	var app models.MiniApp
+	db.Where("app_id = ?", payment.AppID).First(&app)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"msg":     tr(c, "payment.order_loaded"),
+		"data": gin.H{
+			"payment": payment,
+			"app":     miniAppManifest(&app),
+		},
+	})
+}
+
+// 用户确认小程序支付
+func ConfirmMiniAppPayment(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	var req struct {
+		PayPassword string `json:"pay_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "wallet.pay_password_required")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	var payment models.MiniAppPayment
+	if err := db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&payment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "payment.order_not_found")})
+		return
+	}
+	if expireMiniAppPayment(db, &payment); payment.Status != "pending" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "payment.order_expired")})
+		return
+	}
+	if err := services.CheckPayPassword(db, userID, req.PayPassword); err != nil {
+		respondAppError(c, err, tr(c, "payment.failed"))
+		return
+	}
+
+	var app models.MiniApp
+	if err := db.Where("app_id = ? AND status = ?", payment.AppID, "active").First(&app).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "miniapp.not_found")})
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now().Unix()
+		result := tx.Model(&models.MiniAppPayment{}).
+			Where("id = ? AND status = ?", payment.ID, "pending").
+			Updates(map[string]any{"status": "paid", "paid_at": now, "updated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &utils.AppError{Code: http.StatusBadRequest, Message: "支付单已失效", Key: "payment.order_expired"}
+		}
+
+		description := fmt.Sprintf("小程序「%s」: %s", app.Name, payment.Description)
+		if _, err := services.DebitWallet(tx, userID, payment.Amount, "miniapp_pay", payment.ID, description); err != nil {
+			return err
+		}
+		if _, err := services.CreditWallet(tx, app.DeveloperID, payment.Amount, "miniapp_income", payment.ID, description); err != nil {
+			return err
+		}
+		if err := createTransactionNotification(tx, userID, "miniapp_pay", payment.Amount, description); err != nil {
+			utils.Logger.Errorf("创建小程序支付通知失败: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		respondAppError(c, err, tr(c, "payment.failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "payment.success")})
+}
+
+// 用户取消小程序支付
+func CancelMiniAppPayment(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	result := db.Model(&models.MiniAppPayment{}).
+		Where("id = ? AND user_id = ? AND status = ?", c.Param("id"), userID, "pending").
+		Updates(map[string]any{"status": "cancelled", "updated_at": time.Now().Unix()})
+	if result.Error != nil || result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "payment.order_invalid")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "payment.cancelled")})
+}
+
+// 小程序开放接口：读取用户资料（需要 userinfo 授权）
+func MiniAppGetProfile(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	db := c.MustGet("db").(*gorm.DB)
+
+	var user models.User
+	if err := db.Select("id, nickname, avatar, gender, bio").First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "user.not_found")})
+		return
+	}
+	// 对小程序暴露按小程序区分的用户标识，避免跨小程序关联用户
+	openID, err := utils.MiniAppOpenID(c.MustGet("miniapp_id").(string), userID)
+	if err != nil {
+		utils.Logger.Errorf("生成小程序用户标识失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "common.internal_error")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"open_id":  openID,
+			"nickname": user.Nickname,
+			"avatar":   user.Avatar,
+			"gender":   user.Gender,
+			"bio":      user.Bio,
+		},
+	})
+}
+
+// 小程序开放接口：发起钱包支付（需要 wallet.pay 授权），需用户在客户端确认
+func MiniAppCreatePayment(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	appID := c.MustGet("miniapp_id").(string)
+
+	var req struct {
+		Amount      float64 `json:"amount"`
+		Description string  `json:"description" binding:"required"`
+		OutTradeNo  string  `json:"out_trade_no" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+	if req.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "payment.invalid_amount")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	var count int64
+	db.Model(&models.MiniAppPayment{}).Where("app_id = ? AND out_trade_no = ?", appID, req.OutTradeNo).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "payment.duplicate_order")})
+		return
+	}
+
+	now := time.Now()
+	payment := models.MiniAppPayment{
+		AppID:       appID,
+		UserID:      userID,
+		Amount:      req.Amount,
+		Description: req.Description,
+		OutTradeNo:  req.OutTradeNo,
+		Status:      "pending",
+		ExpiresAt:   now.Add(miniAppPaymentTTL).Unix(),
+		CreatedAt:   now.Unix(),
+		UpdatedAt:   now.Unix(),
+	}
+	if err := db.Create(&payment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "payment.create_failed")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"msg":     tr(c, "payment.created"),
+		"data": gin.H{
+			"payment_id":   payment.ID,
+			"out_trade_no": payment.OutTradeNo,
+			"status":       payment.Status,
+			"expires_at":   payment.ExpiresAt,
+		},
+	})
+}
+
+// 小程序开放接口：查询支付结果
+func MiniAppGetPayment(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	appID := c.MustGet("miniapp_id").(string)
+
+	db := c.MustGet("db").(*gorm.DB)
+	var payment models.MiniAppPayment
+	if err := db.Where("id = ? AND app_id = ? AND user_id = ?", c.Param("id"), appID, userID).First(&payment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "payment.order_not_found")})
+		return
+	}
+	expireMiniAppPayment(db, &payment)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"payment_id":   payment.ID,
+			"out_trade_no": payment.OutTradeNo,
+			"amount":       payment.Amount,
+			"status":       payment.Status,
+			"paid_at":      payment.PaidAt,
+		},
+	})
+}
+
+// 查找正常状态的小程序
+func findActiveMiniApp(c *gin.Context, db *gorm.DB) (*models.MiniApp, bool) {
+	var app models.MiniApp
+	if err := db.Where("app_id = ? AND status = ?", c.Param("app_id"), "active").First(&app).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "miniapp.not_found")})
+		return nil, false
+	}
+	return &app, true
+}
+
+// 小程序对外展示的清单信息
+func miniAppManifest(app *models.MiniApp) gin.H {
+	scopes := splitCommaList(app.Scopes)
+	scopeList := make([]gin.H, 0, len(scopes))
+	for _, s := range scopes {
+		scopeList = append(scopeList, gin.H{"scope": s, "description": models.MiniAppScopes[s]})
+	}
+	return gin.H{
+		"app_id":       app.AppID,
+		"name":         app.Name,
+		"icon":         app.Icon,
+		"entry_url":    app.EntryURL,
+		"description":  app.Description,
+		"scopes":       scopeList,
+		"developer_id": app.DeveloperID,
+	}
+}
+
+// 校验小程序入口地址和申请的授权范围，返回错误提示
+func validateMiniAppManifest(entryURL string, scopes []string) *utils.AppError {
+	u, err := url.Parse(entryURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return &utils.AppError{Code: http.StatusBadRequest, Message: "入口地址必须是有效的http(s)地址", Key: "miniapp.invalid_entry_url"}
+	}
+	for _, s := range scopes {
+		if _, exists := models.MiniAppScopes[s]; !exists {
+			return &utils.AppError{Code: http.StatusBadRequest, Message: "不支持的授权范围: " + s, Key: "miniapp.unsupported_scope", Args: []interface{}{s}}
+		}
+	}
+	return nil
+}
+
+// 获取用户已授予小程序的范围
+func grantedMiniAppScopes(db *gorm.DB, userID uint, appID string) []string {
+	var grant models.MiniAppGrant
+	if err := db.Where("user_id = ? AND app_id = ?", userID, appID).First(&grant).Error; err != nil {
+		return []string{}
+	}
+	return splitCommaList(grant.Scopes)
+}
+
+// 小程序申请但用户尚未授予的范围
+func pendingMiniAppScopes(app *models.MiniApp, granted []string) []string {
+	pending := []string{}
+	for _, s := range splitCommaList(app.Scopes) {
+		if !containsString(granted, s) {
+			pending = append(pending, s)
+		}
+	}
+	return pending
+}
+
+// 记录最近使用的小程序
+func recordMiniAppUsage(db *gorm.DB, userID uint, appID string) {
+	now := time.Now().Unix()
+	result := db.Model(&models.MiniAppUsage{}).
+		Where("user_id = ? AND app_id = ?", userID, appID).
+		Updates(map[string]any{"use_count": gorm.Expr("use_count + 1"), "last_used_at": now})
+	if result.Error == nil && result.RowsAffected == 0 {
+		db.Create(&models.MiniAppUsage{UserID: userID, AppID: appID, UseCount: 1, LastUsedAt: now})
+	}
+}
+
+// 支付单超时后标记为过期
+func expireMiniAppPayment(db *gorm.DB, payment *models.MiniAppPayment) {
+	if payment.Status == "pending" && payment.ExpiresAt < time.Now().Unix() {
+		payment.Status = "expired"
+		db.Model(payment).Updates(map[string]any{"status": "expired", "updated_at": time.Now().Unix()})
+	}
+}
+
+// 拆分逗号分隔的字符串列表，忽略空项
+func splitCommaList(s string) []string {
+	list := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// 判断字符串切片是否包含指定值
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newControllerTestDB 创建迁移好全部表的临时数据库，同时设置为 utils.DB
+func newControllerTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	if err := utils.MigrateDB(db); err != nil {
+		t.Fatalf("迁移测试数据库失败: %v", err)
+	}
+	previous := utils.DB
+	utils.DB = db
+	t.Cleanup(func() {
+		utils.DB = previous
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// confirmPayment 以 userID 的身份确认支付单，返回状态码
+func confirmPayment(db *gorm.DB, userID, paymentID uint, payPassword string) int {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"pay_password":"`+payPassword+`"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: strconv.FormatUint(uint64(paymentID), 10)}}
+	c.Set("db", db)
+	c.Set("user_id", userID)
+	ConfirmMiniAppPayment(c)
+	return w.Code
+}
+
+func TestConfirmMiniAppPayment(t *testing.T) {
+	db := newControllerTestDB(t)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("246810"), bcrypt.MinCost)
+	const developerID, aliceID, bobID = 1, 2, 3
+	db.Create(&models.Wallet{UserID: aliceID, Balance: 50, PayPassword: string(hash), PayPasswordSet: true})
+	db.Create(&models.Wallet{UserID: bobID, Balance: 50, PayPassword: string(hash), PayPasswordSet: true})
+	db.Create(&models.MiniApp{AppID: "shop", DeveloperID: developerID, Name: "商店", Status: "active"})
+	db.Create(&models.MiniApp{AppID: "banned", DeveloperID: developerID, Name: "停用", Status: "suspended"})
+
+	now := time.Now().Unix()
+	newPayment := func(appID string, userID uint, amount float64, expiresAt int64) uint {
+		payment := models.MiniAppPayment{AppID: appID, UserID: userID, Amount: amount, Description: "订单", Status: "pending", ExpiresAt: expiresAt}
+		db.Create(&payment)
+		return payment.ID
+	}
+	paid := newPayment("shop", aliceID, 20, now+600)
+	tooMuch := newPayment("shop", aliceID, 40, now+600)
+	expired := newPayment("shop", aliceID, 5, now-1)
+	suspended := newPayment("banned", aliceID, 5, now+600)
+
+	tests := []struct {
+		name      string
+		userID    uint
+		paymentID uint
+		password  string
+		status    int
+		alice     float64
+		developer float64
+	}{
+		{"支付密码错误", aliceID, paid, "000000", http.StatusBadRequest, 50, 0},
+		{"正常支付", aliceID, paid, "246810", http.StatusOK, 30, 20},
+		{"重复支付", aliceID, paid, "246810", http.StatusBadRequest, 30, 20},
+		{"余额不足", aliceID, tooMuch, "246810", http.StatusBadRequest, 30, 20},
+		{"支付他人的支付单", bobID, tooMuch, "246810", http.StatusNotFound, 30, 20},
+		{"已过期", aliceID, expired, "246810", http.StatusBadRequest, 30, 20},
+		{"小程序已停用", aliceID, suspended, "246810", http.StatusBadRequest, 30, 20},
+	}
+	for _, tt := range tests {
+		if status := confirmPayment(db, tt.userID, tt.paymentID, tt.password); status != tt.status {
+			t.Errorf("%s: 返回 %d，应为 %d", tt.name, status, tt.status)
+		}
+		var alice, developer models.Wallet
+		db.Where("user_id = ?", aliceID).First(&alice)
+		db.Where("user_id = ?", developerID).First(&developer)
+		if alice.Balance != tt.alice || developer.Balance != tt.developer {
+			t.Errorf("%s: 付款方余额 %.2f、开发者余额 %.2f，应为 %.2f、%.2f", tt.name, alice.Balance, developer.Balance, tt.alice, tt.developer)
+		}
+	}
+
+	// 扣款失败时支付单保持待支付，过期的支付单标记为过期
+	statuses := map[uint]string{paid: "paid", tooMuch: "pending", expired: "expired", suspended: "pending"}
+	for id, want := range statuses {
+		var payment models.MiniAppPayment
+		db.First(&payment, id)
+		if payment.Status != want {
+			t.Errorf("支付单 %d 状态为 %s，应为 %s", id, payment.Status, want)
+		}
+	}
+	var transactions []models.Transaction
+	db.Where("related_id = ?", paid).Order("amount").Find(&transactions)
+	if len(transactions) != 2 || transactions[0].Type != "miniapp_pay" || transactions[0].UserID != aliceID ||
+		transactions[1].Type != "miniapp_income" || transactions[1].UserID != developerID {
+		t.Errorf("应记录付款和入账两笔交易: %+v", transactions)
+	}
+	var bob models.Wallet
+	db.Where("user_id = ?", bobID).First(&bob)
+	if bob.Balance != 50 {
+		t.Errorf("他人余额不应变化，得到 %.2f", bob.Balance)
+	}
+}
@@ -1,9 +1,613 @@
 package controllers
 
 import (
+	"allinone_backend/models"
+	"allinone_backend/services"
+	"allinone_backend/utils"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // 朋友圈相关接口
-func GetMoments(c *gin.Context) {}
-func PostMoment(c *gin.Context) {}
+
+// 单条动态最多附带的媒体数量
+const maxMomentMedia = 9
+
+// MomentMedia 动态中的媒体，URL来自上传接口
+// 媒体存储中的文件保存 MediaRef 地址，返回给查看者时换成带签名的下载地址
+type MomentMedia struct {
+	Type      string `json:"type"` // image, video
+	URL       string `json:"url"`
+	MediaID   uint   `json:"media_id,omitempty"`
+	ExpiresAt int64  `json:"expires_at,omitempty"` // 下载地址的过期时间，过期后通过媒体信息接口重新获取
+}
+
+// 获取朋友圈时间线
+func GetMoments(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	before, limit := momentPage(c)
+
+	db := c.MustGet("db").(*gorm.DB)
+	moments, err := services.GetMomentTimeline(db, userID, before, limit)
+	if err != nil {
+		utils.Logger.Errorf("获取朋友圈时间线失败: userID=%d, error=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "moment.list_failed")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"msg":     tr(c, "moment.list_loaded"),
+		"data":    buildMomentList(db, moments, userID),
+	})
+}
+
+// momentPage 解析动态列表的翻页参数
+// before 和 before_id 为上一页最后一条动态的 created_at 和 id，limit 超过上限时按上限返回
+func momentPage(c *gin.Context) (services.MomentCursor, int) {
+	createdAt, _ := strconv.ParseInt(c.Query("before"), 10, 64)
+	id, _ := strconv.ParseUint(c.Query("before_id"), 10, 32)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	return services.MomentCursor{CreatedAt: createdAt, ID: uint(id)}, services.ClampMomentLimit(limit)
+}
+
+// 获取某个用户的朋友圈
+func GetUserMoments(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	authorID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	before, limit := momentPage(c)
+
+	db := c.MustGet("db").(*gorm.DB)
+	moments, err := services.GetUserMoments(db, userID, uint(authorID), before, limit)
+	if err != nil {
+		utils.Logger.Errorf("获取用户朋友圈失败: userID=%d, authorID=%d, error=%v", userID, authorID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "moment.list_failed")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"msg":     tr(c, "moment.list_loaded"),
+		"data":    buildMomentList(db, moments, userID),
+	})
+}
+
+// 获取动态详情
+func GetMomentDetail(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	moment, found := findVisibleMoment(c, db, userID)
+	if !found {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"msg":     tr(c, "moment.detail_loaded"),
+		"data":    buildMomentList(db, []models.Moment{*moment}, userID)[0],
+	})
+}
+
+// 发布朋友圈动态
+func PostMoment(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	var req struct {
+		Content      string        `json:"content"`
+		Media        []MomentMedia `json:"media"`
+		Location     string        `json:"location"`
+		Visibility   string        `json:"visibility"`    // friends, private, include, exclude
+		VisibleUsers []uint        `json:"visible_users"` // include/exclude 时的用户列表
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+
+	req.Content = strings.TrimSpace(req.Content)
+	if req.Content == "" && len(req.Media) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "moment.content_required")})
+		return
+	}
+	if len(req.Media) > maxMomentMedia {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "moment.too_many_media")})
+		return
+	}
+	for _, m := range req.Media {
+		if !isUploadedMedia(m) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "moment.invalid_media")})
+			return
+		}
+	}
+
+	switch req.Visibility {
+	case "":
+		req.Visibility = models.MomentVisibilityFriends
+	case models.MomentVisibilityFriends, models.MomentVisibilityPrivate:
+		req.VisibleUsers = nil
+	case models.MomentVisibilityInclude, models.MomentVisibilityExclude:
+		if len(req.VisibleUsers) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "moment.visibility_users_required")})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "moment.invalid_visibility")})
+		return
+	}
+
+	now := time.Now().Unix()
+	moment := models.Moment{
+		UserID:       userID,
+		Content:      utils.FilterSensitiveWords(req.Content),
+		Media:        "[]",
+		Location:     req.Location,
+		Visibility:   req.Visibility,
+		VisibleUsers: services.JoinUserIDList(req.VisibleUsers),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	// 媒体存储中的文件关联到动态，只有动态的可见用户可以下载
+	db := c.MustGet("db").(*gorm.DB)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&moment).Error; err != nil {
+			return err
+		}
+		if len(req.Media) == 0 {
+			return nil
+		}
+		for i, m := range req.Media {
+			mediaID := m.MediaID
+			if mediaID == 0 {
+				mediaID, _ = services.ParseMediaRef(m.URL)
+			}
+			if mediaID == 0 {
+				req.Media[i] = MomentMedia{Type: m.Type, URL: m.URL}
+				continue
+			}
+			media, err := services.AttachMomentMedia(tx, userID, moment.ID, mediaID)
+			if err != nil {
+				return err
+			}
+			req.Media[i] = MomentMedia{Type: m.Type, URL: services.MediaRef(media), MediaID: media.ID}
+		}
+		data, _ := json.Marshal(req.Media)
+		moment.Media = string(data)
+		return tx.Model(&moment).Update("media", moment.Media).Error
+	})
+	if err != nil {
+		respondAppError(c, err, tr(c, "moment.publish_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"msg":     tr(c, "moment.published"),
+		"data":    buildMomentList(db, []models.Moment{moment}, userID)[0],
+	})
+}
+
+// 删除朋友圈动态
+func DeleteMoment(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	var moment models.Moment
+	if err := db.First(&moment, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "moment.not_found")})
+		return
+	}
+	if moment.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": tr(c, "moment.delete_own_only")})
+		return
+	}
+
+	if err := services.DeleteMoment(db, moment.ID); err != nil {
+		utils.Logger.Errorf("删除朋友圈动态失败: momentID=%d, error=%v", moment.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "moment.delete_failed")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "common.delete_success")})
+}
+
+// 点赞朋友圈动态
+func LikeMoment(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	moment, found := findVisibleMoment(c, db, userID)
+	if !found {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var existing models.MomentLike
+		if err := tx.Where("moment_id = ? AND user_id = ?", moment.ID, userID).First(&existing).Error; err == nil {
+			return &utils.AppError{Code: http.StatusBadRequest, Message: "已经点过赞了", Key: "moment.already_liked"}
+		}
+		like := models.MomentLike{MomentID: moment.ID, UserID: userID, CreatedAt: time.Now().Unix()}
+		if err := tx.Create(&like).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Moment{}).Where("id = ?", moment.ID).
+			UpdateColumn("like_count", gorm.Expr("like_count + 1")).Error
+	})
+	if err != nil {
+		respondAppError(c, err, tr(c, "moment.like_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "moment.liked")})
+}
+
+// 取消点赞
+func UnlikeMoment(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	moment, found := findVisibleMoment(c, db, userID)
+	if !found {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("moment_id = ? AND user_id = ?", moment.ID, userID).Delete(&models.MomentLike{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &utils.AppError{Code: http.StatusBadRequest, Message: "尚未点赞", Key: "moment.not_liked"}
+		}
+		return tx.Model(&models.Moment{}).Where("id = ? AND like_count > 0", moment.ID).
+			UpdateColumn("like_count", gorm.Expr("like_count - 1")).Error
+	})
+	if err != nil {
+		respondAppError(c, err, tr(c, "moment.unlike_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "moment.unliked")})
+}
+
+// 评论朋友圈动态（parent_id 不为0时为回复评论）
+func CommentMoment(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	var req struct {
+		Content  string `json:"content"`
+		ParentID uint   `json:"parent_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+	req.Content = strings.TrimSpace(req.Content)
+	if req.Content == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "moment.comment_required")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	moment, found := findVisibleMoment(c, db, userID)
+	if !found {
+		return
+	}
+
+	comment := models.MomentComment{
+		MomentID:  moment.ID,
+		UserID:    userID,
+		Content:   utils.FilterSensitiveWords(req.Content),
+		CreatedAt: time.Now().Unix(),
+	}
+
+	if req.ParentID != 0 {
+		var parent models.MomentComment
+		if err := db.Where("id = ? AND moment_id = ?", req.ParentID, moment.ID).First(&parent).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "moment.reply_target_not_found")})
+			return
+		}
+		// 只能回复自己能看到的评论
+		if !services.MomentInteractionVisibleUsers(db, userID)[parent.UserID] {
+			c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": tr(c, "moment.cannot_reply")})
+			return
+		}
+		comment.ParentID = parent.ID
+		comment.ReplyToUserID = parent.UserID
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Moment{}).Where("id = ?", moment.ID).
+			UpdateColumn("comment_count", gorm.Expr("comment_count + 1")).Error
+	})
+	if err != nil {
+		respondAppError(c, err, tr(c, "moment.comment_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "moment.commented"), "data": comment})
+}
+
+// 删除评论（评论者本人或动态发布者可删除）
+func DeleteMomentComment(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	var comment models.MomentComment
+	if err := db.First(&comment, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "moment.comment_not_found")})
+		return
+	}
+
+	var moment models.Moment
+	if err := db.First(&moment, comment.MomentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "moment.not_found")})
+		return
+	}
+	if comment.UserID != userID && moment.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": tr(c, "moment.comment_delete_forbidden")})
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 删除评论时一并删除对它的回复
+		result := tx.Where("id = ? OR (moment_id = ? AND parent_id = ?)", comment.ID, moment.ID, comment.ID).
+			Delete(&models.MomentComment{})
+		if result.Error != nil {
+			return result.Error
+		}
+		return tx.Model(&models.Moment{}).Where("id = ?", moment.ID).
+			UpdateColumn("comment_count", gorm.Expr("MAX(comment_count - ?, 0)", result.RowsAffected)).Error
+	})
+	if err != nil {
+		respondAppError(c, err, tr(c, "moment.comment_delete_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "common.delete_success")})
+}
+
+// 检查媒体是否来自上传接口
+func isUploadedMedia(m MomentMedia) bool {
+	return (m.Type == "image" || m.Type == "video") &&
+		(strings.HasPrefix(m.URL, "/uploads/") || strings.HasPrefix(m.URL, "/api/media/"))
+}
+
+// 解析动态中的媒体，媒体存储中的文件换成查看者的签名下载地址
+func momentMediaList(db *gorm.DB, moments []models.Moment, viewerID uint) map[uint][]MomentMedia {
+	mediaJSON := make(map[uint]string, len(moments))
+	for _, m := range moments {
+		mediaJSON[m.ID] = m.Media
+	}
+	return signedMediaLists(db, mediaJSON, viewerID)
+}
+
+// 解析动态或广场动态中保存的媒体JSON，键为动态ID，媒体存储中的文件换成查看者的签名下载地址
+func signedMediaLists(db *gorm.DB, mediaJSON map[uint]string, viewerID uint) map[uint][]MomentMedia {
+	result := make(map[uint][]MomentMedia, len(mediaJSON))
+	var mediaIDs []uint
+	for id, data := range mediaJSON {
+		var media []MomentMedia
+		if data != "" {
+			json.Unmarshal([]byte(data), &media)
+		}
+		if media == nil {
+			media = []MomentMedia{}
+		}
+		for _, item := range media {
+			if item.MediaID != 0 {
+				mediaIDs = append(mediaIDs, item.MediaID)
+			}
+		}
+		result[id] = media
+	}
+	if len(mediaIDs) == 0 {
+		return result
+	}
+
+	var records []models.Media
+	db.Where("id IN ?", mediaIDs).Find(&records)
+	byID := make(map[uint]*models.Media, len(records))
+	for i := range records {
+		byID[records[i].ID] = &records[i]
+	}
+	for _, media := range result {
+		for i, item := range media {
+			if record, ok := byID[item.MediaID]; ok {
+				media[i].URL, media[i].ExpiresAt = services.MediaURL(record, viewerID)
+			}
+		}
+	}
+	return result
+}
+
+// 查找当前用户可见的动态，不可见时直接写入响应
+func findVisibleMoment(c *gin.Context, db *gorm.DB, userID uint) (*models.Moment, bool) {
+	var moment models.Moment
+	if err := db.First(&moment, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "moment.not_found")})
+		return nil, false
+	}
+	if !services.CanViewMoment(db, &moment, userID) {
+		// 对不可见的动态与不存在的动态返回相同结果，避免泄露
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "moment.not_found")})
+		return nil, false
+	}
+	return &moment, true
+}
+
+// 输出业务错误，AppError 按其状态码返回，其余错误统一返回服务器错误
+func respondAppError(c *gin.Context, err error, fallback string) {
+	if appErr, ok := err.(*utils.AppError); ok {
+		c.JSON(appErr.Code, gin.H{"success": false, "msg": appErrorMessage(c, appErr)})
+		return
+	}
+	utils.Logger.Errorf("%s: %v", fallback, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": fallback})
+}
+
+// 组装动态列表，点赞和评论只展示查看者本人及其好友的互动
+func buildMomentList(db *gorm.DB, moments []models.Moment, viewerID uint) []gin.H {
+	result := make([]gin.H, 0, len(moments))
+	if len(moments) == 0 {
+		return result
+	}
+
+	momentIDs := make([]uint, 0, len(moments))
+	userIDs := map[uint]bool{}
+	for _, m := range moments {
+		momentIDs = append(momentIDs, m.ID)
+		userIDs[m.UserID] = true
+	}
+
+	visible := services.MomentInteractionVisibleUsers(db, viewerID)
+	mediaByMoment := momentMediaList(db, moments, viewerID)
+
+	var likes []models.MomentLike
+	db.Where("moment_id IN ?", momentIDs).Order("created_at ASC").Find(&likes)
+	var comments []models.MomentComment
+	db.Where("moment_id IN ?", momentIDs).Order("created_at ASC").Find(&comments)
+
+	likesByMoment := map[uint][]models.MomentLike{}
+	likedByViewer := map[uint]bool{}
+	for _, l := range likes {
+		if !visible[l.UserID] {
+			continue
+		}
+		likesByMoment[l.MomentID] = append(likesByMoment[l.MomentID], l)
+		userIDs[l.UserID] = true
+		if l.UserID == viewerID {
+			likedByViewer[l.MomentID] = true
+		}
+	}
+
+	commentsByMoment := map[uint][]models.MomentComment{}
+	for _, cm := range comments {
+		if !visible[cm.UserID] {
+			continue
+		}
+		commentsByMoment[cm.MomentID] = append(commentsByMoment[cm.MomentID], cm)
+		userIDs[cm.UserID] = true
+		if cm.ReplyToUserID != 0 {
+			userIDs[cm.ReplyToUserID] = true
+		}
+	}
+
+	ids := make([]uint, 0, len(userIDs))
+	for id := range userIDs {
+		ids = append(ids, id)
+	}
+	var users []models.User
+	db.Select("id, nickname, avatar").Where("id IN ?", ids).Find(&users)
+	userMap := make(map[uint]models.User, len(users))
+	for _, u := range users {
+		userMap[u.ID] = u
+	}
+
+	for _, m := range moments {
+		media := mediaByMoment[m.ID]
+
+		likeList := make([]gin.H, 0, len(likesByMoment[m.ID]))
+		for _, l := range likesByMoment[m.ID] {
+			likeList = append(likeList, gin.H{
+				"user_id":  l.UserID,
+				"nickname": userMap[l.UserID].Nickname,
+				"avatar":   userMap[l.UserID].Avatar,
+			})
+		}
+
+		commentList := make([]gin.H, 0, len(commentsByMoment[m.ID]))
+		for _, cm := range commentsByMoment[m.ID] {
+			// 被回复者不可见时，隐藏这条回复
+			if cm.ReplyToUserID != 0 && !visible[cm.ReplyToUserID] {
+				continue
+			}
+			commentList = append(commentList, gin.H{
+				"id":                cm.ID,
+				"user_id":           cm.UserID,
+				"nickname":          userMap[cm.UserID].Nickname,
+				"avatar":            userMap[cm.UserID].Avatar,
+				"parent_id":         cm.ParentID,
+				"reply_to_user_id":  cm.ReplyToUserID,
+				"reply_to_nickname": userMap[cm.ReplyToUserID].Nickname,
+				"content":           cm.Content,
+				"created_at":        cm.CreatedAt,
+			})
+		}
+
+		item := gin.H{
+			"id":         m.ID,
+			"user_id":    m.UserID,
+			"nickname":   userMap[m.UserID].Nickname,
+			"avatar":     userMap[m.UserID].Avatar,
+			"content":    m.Content,
+			"media":      media,
+			"location":   m.Location,
+			"created_at": m.CreatedAt,
+			"likes":      likeList,
+			"comments":   commentList,
+			"liked":      likedByViewer[m.ID],
+		}
+		// 可见范围只对发布者本人展示
+		if m.UserID == viewerID {
+			item["visibility"] = m.Visibility
+			item["visible_users"] = services.ParseUserIDList(m.VisibleUsers)
+		}
+		result = append(result, item)
+	}
+
+	return result
+}
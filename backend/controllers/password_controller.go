@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"allinone_backend/services"
+	"allinone_backend/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 获取重置密码的验证码，发送到绑定的邮箱或手机号
+func SendPasswordResetCode(c *gin.Context) {
+	var req struct {
+		Type   string `json:"type" binding:"required"`   // email 或 phone
+		Target string `json:"target" binding:"required"` // 邮箱或手机号
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+
+	if err := services.SendPasswordResetCode(utils.DB, req.Type, req.Target, c.GetHeader("Accept-Language")); err != nil {
+		respondAppError(c, err, tr(c, "verification.send_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "password.reset_code_sent")})
+}
+
+// 使用验证码重置密码，成功后所有设备需要重新登录
+func ResetPassword(c *gin.Context) {
+	var req struct {
+		Type        string `json:"type" binding:"required"`
+		Target      string `json:"target" binding:"required"`
+		Code        string `json:"code" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+
+	if err := services.ResetPassword(utils.DB, req.Type, req.Target, req.Code, req.NewPassword); err != nil {
+		respondAppError(c, err, tr(c, "password.reset_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "password.reset_success")})
+}
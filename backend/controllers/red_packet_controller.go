@@ -16,7 +16,7 @@ func CreateRedPacket(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -30,23 +30,23 @@ func CreateRedPacket(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 检查参数
 	if req.Amount <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "红包金额必须大于0"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "red_packet.invalid_amount")})
 		return
 	}
 
 	if req.Count <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "红包数量必须大于0"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "red_packet.invalid_count")})
 		return
 	}
 
 	if req.GroupID == 0 && req.UserID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "必须指定群组ID或用户ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "red_packet.target_required")})
 		return
 	}
 
@@ -64,7 +64,7 @@ func CreateRedPacket(c *gin.Context) {
 	}
 
 	if wallet.Balance < req.Amount {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "余额不足"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "wallet.insufficient_balance")})
 		return
 	}
 
@@ -81,7 +81,7 @@ func CreateRedPacket(c *gin.Context) {
 	}
 
 	if err := utils.DB.Create(&redPacket).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "创建红包失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "red_packet.create_failed")})
 		return
 	}
 
@@ -89,7 +89,7 @@ func CreateRedPacket(c *gin.Context) {
 	wallet.Balance -= req.Amount
 	wallet.UpdatedAt = time.Now().Unix()
 	if err := utils.DB.Save(&wallet).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "扣除余额失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.debit_failed")})
 		return
 	}
 
@@ -145,13 +145,13 @@ func CreateRedPacket(c *gin.Context) {
 	}
 
 	if err := utils.DB.Create(&message).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "发送红包消息失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "red_packet.message_failed")})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "红包发送成功",
+		"msg":     tr(c, "red_packet.sent"),
 		"data": gin.H{
 			"red_packet_id": redPacket.ID,
 			"message_id":    message.ID,
@@ -164,7 +164,7 @@ func ReceiveRedPacket(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -174,33 +174,33 @@ func ReceiveRedPacket(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 查询红包
 	var redPacket models.RedPacket
 	if err := utils.DB.First(&redPacket, req.RedPacketID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "红包不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "red_packet.not_found")})
 		return
 	}
 
 	// 检查红包是否过期
 	if redPacket.ExpireTime < time.Now().Unix() {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "红包已过期"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "red_packet.expired")})
 		return
 	}
 
 	// 检查红包是否已领完
 	if redPacket.RemainingCount <= 0 || redPacket.RemainingAmount <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "红包已领完"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "red_packet.empty")})
 		return
 	}
 
 	// 检查用户是否已领取过该红包
 	var existingRecord models.RedPacketRecord
 	if err := utils.DB.Where("red_packet_id = ? AND user_id = ?", req.RedPacketID, userID).First(&existingRecord).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "您已领取过该红包"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "red_packet.already_claimed")})
 		return
 	}
 
@@ -223,7 +223,7 @@ func ReceiveRedPacket(c *gin.Context) {
 	redPacket.RemainingAmount -= amount
 	redPacket.RemainingCount--
 	if err := utils.DB.Save(&redPacket).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "更新红包信息失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "red_packet.update_failed")})
 		return
 	}
 
@@ -235,7 +235,7 @@ func ReceiveRedPacket(c *gin.Context) {
 		CreatedAt:   time.Now().Unix(),
 	}
 	if err := utils.DB.Create(&record).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "创建领取记录失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "red_packet.claim_record_failed")})
 		return
 	}
 
@@ -269,7 +269,7 @@ func ReceiveRedPacket(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "领取红包成功",
+		"msg":     tr(c, "red_packet.claimed"),
 		"data": gin.H{
 			"amount":  amount,
 			"balance": wallet.Balance,
@@ -282,7 +282,7 @@ func GetRedPacketDetail(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -290,14 +290,14 @@ func GetRedPacketDetail(c *gin.Context) {
 	redPacketIDStr := c.Param("id")
 	redPacketID, err := strconv.ParseUint(redPacketIDStr, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "无效的红包ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "red_packet.invalid_id")})
 		return
 	}
 
 	// 查询红包
 	var redPacket models.RedPacket
 	if err := utils.DB.First(&redPacket, redPacketID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "红包不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "red_packet.not_found")})
 		return
 	}
 
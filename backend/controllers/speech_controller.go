@@ -2,104 +2,125 @@ package controllers
 
 import (
 	"allinone_backend/models"
+	"allinone_backend/services"
 	"allinone_backend/utils"
+	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-// RecognizeSpeech 将语音转换为文本
+// RecognizeSpeech 将语音转换为文本，同步返回识别结果
 func RecognizeSpeech(c *gin.Context) {
 	// 获取用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
-			"msg":     "未授权",
+			"msg":     tr(c, "auth.unauthorized"),
 		})
 		return
 	}
 
 	// 解析请求
 	var req struct {
-		AudioData string `json:"audio_data"` // base64编码的音频数据
-		Format    string `json:"format"`     // 音频格式，如wav, mp3等
+		AudioData   string `json:"audio_data"`   // base64编码的音频数据
+		AudioBase64 string `json:"audio_base64"` // 兼容旧参数名
+		Format      string `json:"format"`       // 音频格式，如wav, mp3, amr, m4a, webm等
+		Language    string `json:"language"`     // 语言提示，为空时自动判断
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "请求参数错误",
+			"msg":     tr(c, "common.invalid_request"),
 		})
 		return
 	}
+	if req.AudioData == "" {
+		req.AudioData = req.AudioBase64
+	}
+	if req.Format == "" {
+		req.Format = "wav"
+	}
 
 	// 检查音频数据
 	if req.AudioData == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "音频数据不能为空",
+			"msg":     tr(c, "speech.audio_required"),
+		})
+		return
+	}
+	if !utils.IsSupportedAudioFormat(req.Format) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"msg":     tr(c, "speech.unsupported_format"),
 		})
 		return
 	}
+	language, err := services.ValidateSpeechLanguage(req.Language)
+	if err != nil {
+		respondAppError(c, err, tr(c, "speech.recognize_failed"))
+		return
+	}
 
 	// 解码base64音频数据
 	audioBytes, err := base64.StdEncoding.DecodeString(req.AudioData)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "音频数据解码失败",
+			"msg":     tr(c, "speech.decode_failed"),
 		})
 		return
 	}
 
 	// 创建临时文件保存音频
 	tempDir := os.TempDir()
-	audioFileName := fmt.Sprintf("speech_%d_%d.%s", userID, time.Now().UnixNano(), req.Format)
+	audioFileName := fmt.Sprintf("speech_%d_%d.%s", userID, time.Now().UnixNano(), strings.ToLower(req.Format))
 	audioFilePath := filepath.Join(tempDir, audioFileName)
 
 	// 写入临时文件
 	if err := os.WriteFile(audioFilePath, audioBytes, 0644); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"msg":     "保存音频文件失败",
+			"msg":     tr(c, "file.audio_save_failed"),
 		})
 		return
 	}
 	defer os.Remove(audioFilePath) // 处理完后删除临时文件
 
 	// 调用语音识别服务
-	text, err := utils.SpeechToText(audioFilePath)
+	result, err := services.TranscribeAudio(c.Request.Context(), audioFilePath, language)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"msg":     "语音识别失败: " + err.Error(),
-		})
+		respondAppError(c, err, tr(c, "speech.recognize_failed"))
 		return
 	}
 
 	// 返回识别结果
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data": gin.H{
-			"text": text,
-		},
+		"data":    result,
 	})
 }
 
-// SendVoiceMessage 发送语音消息并附带转录文本
+// SendVoiceMessage 发送语音消息，转录文本由后台识别任务完成后推送
 func SendVoiceMessage(c *gin.Context) {
 	// 获取用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
-			"msg":     "未授权",
+			"msg":     tr(c, "auth.unauthorized"),
 		})
 		return
 	}
@@ -108,14 +129,15 @@ func SendVoiceMessage(c *gin.Context) {
 	var req struct {
 		ToID      uint   `json:"to_id"`      // 接收者ID
 		AudioData string `json:"audio_data"` // base64编码的音频数据
-		Format    string `json:"format"`     // 音频格式，如wav, mp3等
-		Duration  int    `json:"duration"`   // 音频时长（秒）
+		Format    string `json:"format"`     // 音频格式，如wav, mp3, amr, m4a, webm等
+		Duration  int    `json:"duration"`   // 已废弃，时长按音频内容计算
+		Language  string `json:"language"`   // 语言提示，为空时自动判断
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "请求参数错误",
+			"msg":     tr(c, "common.invalid_request"),
 		})
 		return
 	}
@@ -124,98 +146,131 @@ func SendVoiceMessage(c *gin.Context) {
 	if req.ToID == 0 || req.AudioData == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "接收者ID和音频数据不能为空",
+			"msg":     tr(c, "speech.receiver_and_audio_required"),
 		})
 		return
 	}
-
-	// 解码base64音频数据
-	audioBytes, err := base64.StdEncoding.DecodeString(req.AudioData)
-	if err != nil {
+	if !utils.IsSupportedAudioFormat(req.Format) {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "音频数据解码失败",
+			"msg":     tr(c, "speech.unsupported_format"),
 		})
 		return
 	}
-
-	// 创建临时文件保存音频
-	tempDir := os.TempDir()
-	audioFileName := fmt.Sprintf("voice_msg_%d_%d.%s", userID, time.Now().UnixNano(), req.Format)
-	audioFilePath := filepath.Join(tempDir, audioFileName)
-
-	// 写入临时文件
-	if err := os.WriteFile(audioFilePath, audioBytes, 0644); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"msg":     "保存音频文件失败",
-		})
+	if _, err := services.ValidateSpeechLanguage(req.Language); err != nil {
+		respondAppError(c, err, tr(c, "voice.send_failed"))
 		return
 	}
-	defer os.Remove(audioFilePath) // 处理完后删除临时文件
 
-	// 调用语音识别服务
-	text, err := utils.SpeechToText(audioFilePath)
+	// 解码base64音频数据
+	audioBytes, err := base64.StdEncoding.DecodeString(req.AudioData)
 	if err != nil {
-		// 即使语音识别失败，我们仍然可以发送语音消息，只是没有转录文本
-		text = "[语音识别失败]"
-	}
-
-	// 保存音频文件到服务器
-	uid := userID.(uint)
-	uploadDir := "static/uploads/voice"
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "创建上传目录失败",
+			"msg":     tr(c, "speech.decode_failed"),
 		})
 		return
 	}
 
-	// 生成唯一文件名
-	timestamp := time.Now().Unix()
-	savedFileName := fmt.Sprintf("voice_%d_%d_%d.%s", uid, req.ToID, timestamp, req.Format)
-	savedFilePath := filepath.Join(uploadDir, savedFileName)
-
-	// 复制临时文件到上传目录
-	if err := os.WriteFile(savedFilePath, audioBytes, 0644); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"msg":     "保存音频文件失败",
-		})
+	// 按实际音频计算时长和波形，转码后保存到媒体存储，只有会话双方可以下载
+	uid := userID.(uint)
+	target, err := services.ResolveMediaTarget(utils.DB, uid, "", req.ToID, 0)
+	if err != nil {
+		respondAppError(c, err, tr(c, "voice.send_failed"))
 		return
 	}
+	media, voice, err := services.StoreVoice(c.Request.Context(), utils.DB, uid, bytes.NewReader(audioBytes),
+		"voice."+strings.ToLower(req.Format), target)
+	if err != nil {
+		respondAppError(c, err, tr(c, "file.audio_save_failed"))
+		return
+	}
+	audioRef := services.MediaRef(media)
 
-	// 构建音频URL
-	audioURL := fmt.Sprintf("/static/uploads/voice/%s", savedFileName)
-
-	// 创建消息
+	// 创建消息和语音消息记录
+	timestamp := time.Now().Unix()
+	extra, _ := json.Marshal(services.VoiceExtra(media.ID, voice))
 	message := models.ChatMessage{
 		SenderID:   uid,
 		ReceiverID: req.ToID,
-		Content:    audioURL,
+		Content:    audioRef,
 		Type:       "voice",
+		Status:     1, // 已发送
 		CreatedAt:  timestamp,
-		Extra:      fmt.Sprintf(`{"duration":%d,"text":"%s"}`, req.Duration, text),
+		Extra:      string(extra),
 	}
-
-	// 保存消息到数据库
-	if err := utils.DB.Create(&message).Error; err != nil {
+	voiceMessage := models.VoiceMessage{
+		SenderID:   uid,
+		ReceiverID: req.ToID,
+		MediaID:    media.ID,
+		URL:        audioRef,
+		Status:     1, // 已发送
+		CreatedAt:  timestamp,
+	}
+	services.ApplyVoiceInfo(&voiceMessage, voice)
+	err = utils.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&message).Error; err != nil {
+			return err
+		}
+		voiceMessage.ChatMessageID = message.ID
+		return tx.Create(&voiceMessage).Error
+	})
+	if err != nil {
+		services.DeleteMedia(c.Request.Context(), utils.DB, uid, media.ID)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"msg":     "保存消息失败: " + err.Error(),
+			"msg":     tr(c, "chat.save_failed_detail", err.Error()),
 		})
 		return
 	}
 
+	// 后台识别语音，完成后推送 voice_transcribed 事件
+	job, err := services.EnqueueVoiceTranscription(utils.DB, &voiceMessage, uid, req.Language)
+	if err != nil {
+		utils.Logger.Errorf("创建语音识别任务失败: message=%d, error=%v", message.ID, err)
+	}
+
 	// 返回成功响应
+	audioURL, expiresAt := services.MediaURL(media, uid)
+	data := gin.H{
+		"message_id":  message.ID,
+		"media_id":    media.ID,
+		"audio_url":   audioURL,
+		"expires_at":  expiresAt,
+		"duration":    voiceMessage.Duration,
+		"duration_ms": voiceMessage.DurationMs,
+		"waveform":    voice.Waveform,
+		"created_at":  timestamp,
+	}
+	if job != nil {
+		data["transcription_job_id"] = job.ID
+		data["transcript_status"] = job.Status
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data": gin.H{
-			"message_id": message.ID,
-			"audio_url":  audioURL,
-			"text":       text,
-			"created_at": timestamp,
-		},
+		"data":    data,
 	})
 }
+
+// GetTranscriptionJob 查询语音识别任务
+func GetTranscriptionJob(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	jobID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "speech.invalid_job_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	job, err := services.GetTranscriptionJob(db, userID.(uint), uint(jobID))
+	if err != nil {
+		respondAppError(c, err, tr(c, "speech.job_query_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": job})
+}
@@ -1,9 +1,668 @@
 package controllers
 
 import (
+	"allinone_backend/models"
+	"allinone_backend/services"
+	"allinone_backend/utils"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // 广场/论坛相关接口
-func GetPosts(c *gin.Context) {}
-func PostSquare(c *gin.Context) {}
+
+// 举报原因
+var squareReportReasons = map[string]bool{
+	"spam":    true, // 垃圾广告
+	"abuse":   true, // 辱骂攻击
+	"porn":    true, // 色情低俗
+	"illegal": true, // 违法违规
+	"other":   true, // 其他
+}
+
+// 获取广场动态列表
+// sort=latest 按时间倒序（默认），sort=hot 按热度排序；topic 按话题筛选
+func GetPosts(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 50 {
+		pageSize = 20
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	query := db.Model(&models.SquarePost{}).Where("status = ?", models.SquarePostNormal)
+
+	if topic := strings.TrimPrefix(c.Query("topic"), "#"); topic != "" {
+		query = query.Where("id IN (?)", db.Model(&models.SquarePostTopic{}).
+			Select("square_post_topics.post_id").
+			Joins("JOIN square_topics ON square_topics.id = square_post_topics.topic_id").
+			Where("square_topics.name = ?", topic))
+	}
+	if authorID := c.Query("user_id"); authorID != "" {
+		query = query.Where("user_id = ?", authorID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	switch c.DefaultQuery("sort", "latest") {
+	case "hot":
+		query = query.Order("hot_score DESC, created_at DESC")
+	default:
+		query = query.Order("created_at DESC, id DESC")
+	}
+
+	var posts []models.SquarePost
+	if err := query.Offset((page - 1) * pageSize).Limit(pageSize).Find(&posts).Error; err != nil {
+		utils.Logger.Errorf("获取广场动态失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "square.list_failed")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"msg":     tr(c, "square.list_loaded"),
+		"data": gin.H{
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+			"posts":     buildSquarePostList(db, posts, userID),
+		},
+	})
+}
+
+// 获取广场动态详情（含评论）
+func GetSquarePostDetail(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	post, found := findSquarePost(c, db, userID)
+	if !found {
+		return
+	}
+
+	var comments []models.SquareComment
+	db.Where("post_id = ?", post.ID).Order("created_at ASC").Find(&comments)
+
+	userIDs := []uint{}
+	for _, cm := range comments {
+		userIDs = append(userIDs, cm.UserID, cm.ReplyToUserID)
+	}
+	userMap := loadUserBriefs(db, userIDs)
+
+	commentList := make([]gin.H, 0, len(comments))
+	for _, cm := range comments {
+		commentList = append(commentList, gin.H{
+			"id":                cm.ID,
+			"user_id":           cm.UserID,
+			"nickname":          userMap[cm.UserID].Nickname,
+			"avatar":            userMap[cm.UserID].Avatar,
+			"parent_id":         cm.ParentID,
+			"reply_to_user_id":  cm.ReplyToUserID,
+			"reply_to_nickname": userMap[cm.ReplyToUserID].Nickname,
+			"content":           cm.Content,
+			"created_at":        cm.CreatedAt,
+		})
+	}
+
+	data := buildSquarePostList(db, []models.SquarePost{*post}, userID)[0]
+	data["comment_list"] = commentList
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "square.detail_loaded"), "data": data})
+}
+
+// 发布广场动态
+func PostSquare(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	var req struct {
+		Content string        `json:"content"`
+		Media   []MomentMedia `json:"media"`
+		Tags    []string      `json:"tags"` // 额外指定的话题，正文中的 #话题# 会自动识别
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+
+	req.Content = strings.TrimSpace(req.Content)
+	if req.Content == "" && len(req.Media) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "square.content_required")})
+		return
+	}
+	if len(req.Media) > maxMomentMedia {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "square.too_many_media")})
+		return
+	}
+	for _, m := range req.Media {
+		if !isUploadedMedia(m) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "square.invalid_media")})
+			return
+		}
+	}
+
+	content := utils.FilterSensitiveWords(req.Content)
+	tags := make([]string, 0, len(req.Tags))
+	for _, tag := range req.Tags {
+		tags = append(tags, utils.FilterSensitiveWords(tag))
+	}
+	topics := services.ExtractSquareTopics(content, tags)
+
+	now := time.Now().Unix()
+	post := models.SquarePost{
+		UserID:    userID,
+		Content:   content,
+		Media:     "[]",
+		Topics:    strings.Join(topics, ","),
+		HotScore:  services.SquareHotScore(0, 0, now, now),
+		Status:    models.SquarePostNormal,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	// 媒体存储中的文件关联到广场动态，动态正常展示时所有用户都可以下载
+	db := c.MustGet("db").(*gorm.DB)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&post).Error; err != nil {
+			return err
+		}
+		if err := services.AttachSquareTopics(tx, post.ID, topics, now); err != nil {
+			return err
+		}
+		if len(req.Media) == 0 {
+			return nil
+		}
+		for i, m := range req.Media {
+			mediaID := m.MediaID
+			if mediaID == 0 {
+				mediaID, _ = services.ParseMediaRef(m.URL)
+			}
+			if mediaID == 0 {
+				req.Media[i] = MomentMedia{Type: m.Type, URL: m.URL}
+				continue
+			}
+			media, err := services.AttachSquareMedia(tx, userID, post.ID, mediaID)
+			if err != nil {
+				return err
+			}
+			req.Media[i] = MomentMedia{Type: m.Type, URL: services.MediaRef(media), MediaID: media.ID}
+		}
+		data, _ := json.Marshal(req.Media)
+		post.Media = string(data)
+		return tx.Model(&post).Update("media", post.Media).Error
+	})
+	if err != nil {
+		respondAppError(c, err, tr(c, "square.publish_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"msg":     tr(c, "square.published"),
+		"data":    buildSquarePostList(db, []models.SquarePost{post}, userID)[0],
+	})
+}
+
+// 删除广场动态（仅发布者本人）
+func DeleteSquarePost(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	var post models.SquarePost
+	if err := db.First(&post, c.Param("id")).Error; err != nil || post.Status == models.SquarePostRemoved {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "square.not_found")})
+		return
+	}
+	if post.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": tr(c, "square.delete_own_only")})
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := services.DetachSquareTopics(tx, post.ID); err != nil {
+			return err
+		}
+		for _, model := range []any{&models.SquareLike{}, &models.SquareComment{}, &models.SquareReport{}} {
+			if err := tx.Where("post_id = ?", post.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&post).Error
+	})
+	if err != nil {
+		respondAppError(c, err, tr(c, "square.delete_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "common.delete_success")})
+}
+
+// 点赞广场动态
+func LikeSquarePost(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	post, found := findSquarePost(c, db, userID)
+	if !found {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		tx.Model(&models.SquareLike{}).Where("post_id = ? AND user_id = ?", post.ID, userID).Count(&count)
+		if count > 0 {
+			return &utils.AppError{Code: http.StatusBadRequest, Message: "已经点过赞了", Key: "square.already_liked"}
+		}
+		if err := tx.Create(&models.SquareLike{PostID: post.ID, UserID: userID, CreatedAt: time.Now().Unix()}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.SquarePost{}).Where("id = ?", post.ID).
+			UpdateColumn("like_count", gorm.Expr("like_count + 1")).Error; err != nil {
+			return err
+		}
+		return services.UpdateSquareHotScore(tx, post.ID)
+	})
+	if err != nil {
+		respondAppError(c, err, tr(c, "square.like_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "square.liked")})
+}
+
+// 取消点赞
+func UnlikeSquarePost(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	post, found := findSquarePost(c, db, userID)
+	if !found {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("post_id = ? AND user_id = ?", post.ID, userID).Delete(&models.SquareLike{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &utils.AppError{Code: http.StatusBadRequest, Message: "尚未点赞", Key: "square.not_liked"}
+		}
+		if err := tx.Model(&models.SquarePost{}).Where("id = ? AND like_count > 0", post.ID).
+			UpdateColumn("like_count", gorm.Expr("like_count - 1")).Error; err != nil {
+			return err
+		}
+		return services.UpdateSquareHotScore(tx, post.ID)
+	})
+	if err != nil {
+		respondAppError(c, err, tr(c, "square.unlike_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "square.unliked")})
+}
+
+// 评论广场动态（parent_id 不为0时为回复评论）
+func CommentSquarePost(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	var req struct {
+		Content  string `json:"content"`
+		ParentID uint   `json:"parent_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+	req.Content = strings.TrimSpace(req.Content)
+	if req.Content == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "square.comment_required")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	post, found := findSquarePost(c, db, userID)
+	if !found {
+		return
+	}
+
+	comment := models.SquareComment{
+		PostID:    post.ID,
+		UserID:    userID,
+		Content:   utils.FilterSensitiveWords(req.Content),
+		CreatedAt: time.Now().Unix(),
+	}
+	if req.ParentID != 0 {
+		var parent models.SquareComment
+		if err := db.Where("id = ? AND post_id = ?", req.ParentID, post.ID).First(&parent).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "square.reply_target_not_found")})
+			return
+		}
+		comment.ParentID = parent.ID
+		comment.ReplyToUserID = parent.UserID
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.SquarePost{}).Where("id = ?", post.ID).
+			UpdateColumn("comment_count", gorm.Expr("comment_count + 1")).Error; err != nil {
+			return err
+		}
+		return services.UpdateSquareHotScore(tx, post.ID)
+	})
+	if err != nil {
+		respondAppError(c, err, tr(c, "square.comment_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "square.commented"), "data": comment})
+}
+
+// 删除广场评论（评论者本人或动态发布者可删除）
+func DeleteSquareComment(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	var comment models.SquareComment
+	if err := db.First(&comment, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "square.comment_not_found")})
+		return
+	}
+	var post models.SquarePost
+	if err := db.First(&post, comment.PostID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "square.not_found")})
+		return
+	}
+	if comment.UserID != userID && post.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": tr(c, "square.comment_delete_forbidden")})
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? OR (post_id = ? AND parent_id = ?)", comment.ID, post.ID, comment.ID).
+			Delete(&models.SquareComment{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if err := tx.Model(&models.SquarePost{}).Where("id = ?", post.ID).
+			UpdateColumn("comment_count", gorm.Expr("MAX(comment_count - ?, 0)", result.RowsAffected)).Error; err != nil {
+			return err
+		}
+		return services.UpdateSquareHotScore(tx, post.ID)
+	})
+	if err != nil {
+		respondAppError(c, err, tr(c, "square.comment_delete_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "common.delete_success")})
+}
+
+// 举报广场动态
+func ReportSquarePost(c *gin.Context) {
+	userID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+		Detail string `json:"detail"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || !squareReportReasons[req.Reason] {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "square.invalid_report_reason")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	post, found := findSquarePost(c, db, userID)
+	if !found {
+		return
+	}
+	if post.UserID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "square.cannot_report_own")})
+		return
+	}
+
+	if err := services.ReportSquarePost(db, post.ID, userID, req.Reason, req.Detail); err != nil {
+		respondAppError(c, err, tr(c, "square.report_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "square.reported")})
+}
+
+// 获取热门话题
+func GetHotTopics(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	since := time.Now().Add(-24 * time.Hour).Unix()
+	topics, err := services.GetHotSquareTopics(db, since, limit)
+	if err != nil {
+		utils.Logger.Errorf("获取热门话题失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "square.topics_query_failed")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "square.topics_loaded"), "data": topics})
+}
+
+// 管理员获取举报列表
+func GetSquareReports(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	query := db.Model(&models.SquareReport{}).Where("status = ?", c.DefaultQuery("status", "pending"))
+
+	var total int64
+	query.Count(&total)
+
+	var reports []models.SquareReport
+	query.Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&reports)
+
+	postIDs := make([]uint, 0, len(reports))
+	for _, r := range reports {
+		postIDs = append(postIDs, r.PostID)
+	}
+	var posts []models.SquarePost
+	db.Where("id IN ?", postIDs).Find(&posts)
+	postMap := make(map[uint]models.SquarePost, len(posts))
+	for _, p := range posts {
+		postMap[p.ID] = p
+	}
+
+	list := make([]gin.H, 0, len(reports))
+	for _, r := range reports {
+		post := postMap[r.PostID]
+		list = append(list, gin.H{
+			"id":           r.ID,
+			"post_id":      r.PostID,
+			"reporter_id":  r.ReporterID,
+			"reason":       r.Reason,
+			"detail":       r.Detail,
+			"status":       r.Status,
+			"created_at":   r.CreatedAt,
+			"post_content": post.Content,
+			"post_status":  post.Status,
+			"report_count": post.ReportCount,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"msg":     tr(c, "square.reports_loaded"),
+		"data": gin.H{
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+			"reports":   list,
+		},
+	})
+}
+
+// 管理员审核被举报的动态
+func ReviewSquarePost(c *gin.Context) {
+	adminID, ok := c.MustGet("user_id").(uint)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
+		return
+	}
+
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "square.invalid_post_id")})
+		return
+	}
+
+	var req struct {
+		Action string `json:"action"` // remove, restore
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	if err := services.ReviewSquarePost(db, uint(postID), adminID, req.Action); err != nil {
+		respondAppError(c, err, tr(c, "square.review_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "square.reviewed")})
+}
+
+// 查找可互动的广场动态，被隐藏的动态仅发布者本人可见
+func findSquarePost(c *gin.Context, db *gorm.DB, userID uint) (*models.SquarePost, bool) {
+	var post models.SquarePost
+	if err := db.First(&post, c.Param("id")).Error; err != nil ||
+		post.Status == models.SquarePostRemoved ||
+		(post.Status == models.SquarePostHidden && post.UserID != userID) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "square.not_found")})
+		return nil, false
+	}
+	return &post, true
+}
+
+// 批量加载用户昵称和头像
+func loadUserBriefs(db *gorm.DB, ids []uint) map[uint]models.User {
+	userMap := map[uint]models.User{}
+	if len(ids) == 0 {
+		return userMap
+	}
+	var users []models.User
+	db.Select("id, nickname, avatar").Where("id IN ?", ids).Find(&users)
+	for _, u := range users {
+		userMap[u.ID] = u
+	}
+	return userMap
+}
+
+// 组装广场动态列表
+func buildSquarePostList(db *gorm.DB, posts []models.SquarePost, viewerID uint) []gin.H {
+	result := make([]gin.H, 0, len(posts))
+	if len(posts) == 0 {
+		return result
+	}
+
+	postIDs := make([]uint, 0, len(posts))
+	authorIDs := make([]uint, 0, len(posts))
+	for _, p := range posts {
+		postIDs = append(postIDs, p.ID)
+		authorIDs = append(authorIDs, p.UserID)
+	}
+	userMap := loadUserBriefs(db, authorIDs)
+
+	var likedIDs []uint
+	db.Model(&models.SquareLike{}).Where("post_id IN ? AND user_id = ?", postIDs, viewerID).Pluck("post_id", &likedIDs)
+	liked := make(map[uint]bool, len(likedIDs))
+	for _, id := range likedIDs {
+		liked[id] = true
+	}
+
+	mediaJSON := make(map[uint]string, len(posts))
+	for _, p := range posts {
+		mediaJSON[p.ID] = p.Media
+	}
+	mediaByPost := signedMediaLists(db, mediaJSON, viewerID)
+
+	for _, p := range posts {
+		topics := []string{}
+		if p.Topics != "" {
+			topics = strings.Split(p.Topics, ",")
+		}
+
+		result = append(result, gin.H{
+			"id":          p.ID,
+			"user_id":     p.UserID,
+			"user_name":   userMap[p.UserID].Nickname,
+			"user_avatar": userMap[p.UserID].Avatar,
+			"content":     p.Content,
+			"media":       mediaByPost[p.ID],
+			"tags":        topics,
+			"likes":       p.LikeCount,
+			"comments":    p.CommentCount,
+			"hot_score":   p.HotScore,
+			"liked":       liked[p.ID],
+			"status":      p.Status,
+			"created_at":  p.CreatedAt,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/services"
+	"allinone_backend/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// squareRequest 以 userID 的身份调用广场接口，id 不为0时作为路由参数
+func squareRequest(db *gorm.DB, handler gin.HandlerFunc, userID, id uint, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	if id != 0 {
+		c.Params = gin.Params{{Key: "id", Value: strconv.FormatUint(uint64(id), 10)}}
+	}
+	c.Set("db", db)
+	c.Set("user_id", userID)
+	handler(c)
+	return w
+}
+
+// downloadMedia 请求下载地址，返回状态码和内容
+func downloadMedia(t *testing.T, mediaID uint, url string) (int, string) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, url, nil)
+	c.Params = gin.Params{{Key: "id", Value: strconv.FormatUint(uint64(mediaID), 10)}}
+	DownloadMedia(c)
+	return w.Code, w.Body.String()
+}
+
+func TestSquarePostMediaVisibleToOthers(t *testing.T) {
+	db := newControllerTestDB(t)
+	previous := utils.GetMediaConfig()
+	config := previous
+	config.Storage = "local"
+	config.LocalDir = filepath.Join(t.TempDir(), "media")
+	config.URLSecret = []byte("test-secret")
+	utils.SetMediaConfig(config)
+	t.Cleanup(func() { utils.SetMediaConfig(previous) })
+
+	alice := models.User{Account: "alice", Nickname: "alice", Password: "-"}
+	bob := models.User{Account: "bob", Nickname: "bob", Password: "-"}
+	db.Create(&alice)
+	db.Create(&bob)
+
+	target, err := services.ResolveMediaTarget(db, alice.ID, services.MediaScopeSquare, 0, 0)
+	if err != nil {
+		t.Fatalf("确定媒体范围失败: %v", err)
+	}
+	media, err := services.StoreMedia(context.Background(), db, alice.ID, strings.NewReader("photo"), "a.jpg", "image", target)
+	if err != nil {
+		t.Fatalf("保存媒体失败: %v", err)
+	}
+	early, _ := services.MediaURL(media, bob.ID)
+	if code, _ := downloadMedia(t, media.ID, early); code == http.StatusOK {
+		t.Error("发布前其他用户不应能下载")
+	}
+
+	body := fmt.Sprintf(`{"content":"广场","media":[{"type":"image","url":"/api/media/%d"}]}`, media.ID)
+	w := squareRequest(db, PostSquare, alice.ID, 0, body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("发布广场动态返回 %d: %s", w.Code, w.Body.String())
+	}
+	var published struct {
+		Data struct {
+			ID uint `json:"id"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &published)
+
+	// 其他用户查看详情时得到自己的签名地址，可以下载图片
+	w = squareRequest(db, GetSquarePostDetail, bob.ID, published.Data.ID, "")
+	var detail struct {
+		Data struct {
+			Media []MomentMedia `json:"media"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &detail)
+	if len(detail.Data.Media) != 1 || detail.Data.Media[0].MediaID != media.ID {
+		t.Fatalf("详情中的媒体不正确: %s", w.Body.String())
+	}
+	url := detail.Data.Media[0].URL
+	if !strings.Contains(url, "uid="+strconv.FormatUint(uint64(bob.ID), 10)) {
+		t.Errorf("应返回查看者的签名地址: %s", url)
+	}
+	if code, content := downloadMedia(t, media.ID, url); code != http.StatusOK || content != "photo" {
+		t.Errorf("其他用户下载广场图片返回 %d %q", code, content)
+	}
+
+	// 动态被隐藏后只有发布者可以下载
+	db.Model(&models.SquarePost{}).Where("id = ?", published.Data.ID).Update("status", models.SquarePostHidden)
+	if code, _ := downloadMedia(t, media.ID, url); code != http.StatusNotFound {
+		t.Errorf("动态隐藏后其他用户下载返回 %d，应为404", code)
+	}
+	own, _ := services.MediaURL(media, alice.ID)
+	if code, _ := downloadMedia(t, media.ID, own); code != http.StatusOK {
+		t.Errorf("发布者下载返回 %d", code)
+	}
+}
@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"allinone_backend/services"
+	"allinone_backend/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 获取两步验证状态
+func GetTwoFactorStatus(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	enabled, remaining := services.GetTwoFactorStatus(db, userID.(uint))
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"enabled":                  enabled,
+		"recovery_codes_remaining": remaining,
+	}})
+}
+
+// 开始设置两步验证，返回密钥和 otpauth 地址，客户端将地址显示为二维码供验证器扫描
+func SetupTwoFactor(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	secret, uri, err := services.SetupTwoFactor(db, userID.(uint), c.GetString("account"))
+	if err != nil {
+		respondAppError(c, err, tr(c, "two_factor.setup_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"secret":           secret,
+		"provisioning_uri": uri,
+	}})
+}
+
+// 提交验证器生成的验证码开启两步验证，返回的恢复码只显示这一次
+func EnableTwoFactor(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	codes, err := services.EnableTwoFactor(db, userID.(uint), req.Code)
+	if err != nil {
+		respondAppError(c, err, tr(c, "two_factor.enable_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "two_factor.enabled"), "data": gin.H{"recovery_codes": codes}})
+}
+
+// 关闭两步验证，需要验证码或恢复码
+func DisableTwoFactor(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	if err := services.DisableTwoFactor(db, userID.(uint), req.Code); err != nil {
+		respondAppError(c, err, tr(c, "two_factor.disable_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "two_factor.disabled")})
+}
+
+// 重新生成恢复码，原有的恢复码全部失效
+func RegenerateRecoveryCodes(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	codes, err := services.RegenerateRecoveryCodes(db, userID.(uint), req.Code)
+	if err != nil {
+		respondAppError(c, err, tr(c, "two_factor.recovery_codes_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"recovery_codes": codes}})
+}
+
+// 提交登录返回的临时令牌和验证码（或恢复码）完成登录
+func TwoFactorLogin(c *gin.Context) {
+	var req struct {
+		TwoFactorToken string `json:"two_factor_token" binding:"required"`
+		Code           string `json:"code" binding:"required"`
+		RememberDevice bool   `json:"remember_device"` // 记住该设备，返回 trust_token，之后在该设备登录免验证码
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+
+	tokens, user, err := services.CompleteTwoFactorLogin(utils.DB, req.TwoFactorToken, req.Code, req.RememberDevice, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		respondAppError(c, err, tr(c, "auth.login_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"msg":     tr(c, "auth.login_success"),
+		"data": gin.H{
+			"token":              tokens.AccessToken,
+			"refresh_token":      tokens.RefreshToken,
+			"expires_in":         tokens.ExpiresIn,
+			"refresh_expires_in": tokens.RefreshExpiresIn,
+			"session_id":         tokens.SessionID,
+			"device_id":          tokens.DeviceID,
+			"trust_token":        tokens.TrustToken,
+			"user": gin.H{
+				"id":              user.ID,
+				"account":         user.Account,
+				"nickname":        user.Nickname,
+				"avatar":          user.Avatar,
+				"email":           user.Email,
+				"phone":           user.Phone,
+				"generated_email": user.GeneratedEmail,
+			},
+		},
+	})
+}
+
+// respondTwoFactorRequired 登录需要两步验证时返回临时令牌
+func respondTwoFactorRequired(c *gin.Context, challenge *services.LoginChallenge) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"msg":     tr(c, "two_factor.code_required"),
+		"data": gin.H{
+			"two_factor_required": true,
+			"two_factor_token":    challenge.Token,
+			"expires_in":          challenge.ExpiresIn,
+		},
+	})
+}
+
+// checkTwoFactor 开启了两步验证的用户进行敏感操作时校验验证码，出错时已写入响应
+func checkTwoFactor(c *gin.Context, db *gorm.DB, userID uint, code string) bool {
+	if !services.TwoFactorEnabled(db, userID) {
+		return true
+	}
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "two_factor.challenge_required"), "data": gin.H{"two_factor_required": true}})
+		return false
+	}
+	if err := services.VerifyTwoFactorCode(db, userID, code); err != nil {
+		respondAppError(c, err, tr(c, "two_factor.verify_failed"))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/services"
+	"allinone_backend/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 断点续传接口
+// 1. POST /uploads 创建上传会话，返回 upload_id 和单次分片上限
+// 2. PATCH /uploads/:id 上传分片，请求头 Upload-Offset 为分片在文件中的起始位置，请求体为分片内容
+// 3. 上传中断后 HEAD 或 GET /uploads/:id 查询已接收的偏移量，从该位置继续
+// 4. POST /uploads/:id/finalize 提交文件的SHA-256，校验通过后保存为媒体，返回 media_id 用于发送文件消息
+
+// CreateUpload 创建上传会话
+func CreateUpload(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+
+	var req struct {
+		FileName   string `json:"file_name" binding:"required"`
+		FileSize   int64  `json:"file_size" binding:"required"`
+		FileType   string `json:"file_type"` // image, voice, video, file，默认 file
+		Checksum   string `json:"checksum"`  // 文件的SHA-256，也可以在完成上传时提供
+		ReceiverID uint   `json:"receiver_id"`
+		GroupID    uint   `json:"group_id"`
+		Scope      string `json:"scope"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+	if req.FileType == "" {
+		req.FileType = "file"
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	target, err := services.ResolveMediaTarget(db, userID.(uint), req.Scope, req.ReceiverID, req.GroupID)
+	if err != nil {
+		respondAppError(c, err, tr(c, "upload.create_failed"))
+		return
+	}
+	session, err := services.CreateUploadSession(db, userID.(uint), services.UploadSessionRequest{
+		FileName: req.FileName,
+		FileType: req.FileType,
+		Size:     req.FileSize,
+		Checksum: req.Checksum,
+		Target:   target,
+	})
+	if err != nil {
+		respondAppError(c, err, tr(c, "upload.create_failed"))
+		return
+	}
+
+	setUploadHeaders(c, session)
+	c.Header("Location", "/api/uploads/"+session.UploadID)
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": uploadSessionResponse(session)})
+}
+
+// GetUploadStatus 查询上传进度，HEAD 请求只返回 Upload-Offset 等响应头
+func GetUploadStatus(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	session, err := services.GetUploadSession(db, userID.(uint), c.Param("id"))
+	if err != nil {
+		if c.Request.Method == http.MethodHead {
+			status := http.StatusInternalServerError
+			if appErr, ok := err.(*utils.AppError); ok {
+				status = appErr.Code
+			}
+			c.Status(status)
+			return
+		}
+		respondAppError(c, err, tr(c, "upload.query_failed"))
+		return
+	}
+
+	setUploadHeaders(c, session)
+	c.Header("Cache-Control", "no-store")
+	if c.Request.Method == http.MethodHead {
+		c.Status(http.StatusOK)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": uploadSessionResponse(session)})
+}
+
+// PatchUpload 上传分片，分片超过单次上限时只接收上限内的部分，以返回的偏移量为准继续上传
+func PatchUpload(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "upload.invalid_offset_header")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	session, err := services.AppendUploadChunk(db, userID.(uint), c.Param("id"), offset, c.Request.Body)
+	if session != nil {
+		setUploadHeaders(c, session)
+	}
+	if err != nil {
+		respondAppError(c, err, tr(c, "upload.chunk_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": uploadSessionResponse(session)})
+}
+
+// FinalizeUpload 完成上传，校验SHA-256后保存为媒体
+func FinalizeUpload(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	var req struct {
+		Checksum string `json:"checksum"`
+	}
+	c.ShouldBindJSON(&req)
+
+	db := c.MustGet("db").(*gorm.DB)
+	media, err := services.FinalizeUpload(c.Request.Context(), db, userID.(uint), c.Param("id"), req.Checksum)
+	if err != nil {
+		respondAppError(c, err, tr(c, "file.save_failed"))
+		return
+	}
+
+	data := mediaResponse(db, media, userID.(uint))
+	data["upload_id"] = c.Param("id")
+	data["file_name"] = media.FileName
+	data["file_size"] = media.Size
+	data["file_type"] = media.FileType
+	data["content_type"] = media.ContentType
+	data["scope"] = media.Scope
+	data["upload_time"] = time.Now().Unix()
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "file.upload_success"), "data": data})
+}
+
+// AbortUpload 取消上传
+func AbortUpload(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	if err := services.AbortUpload(db, userID.(uint), c.Param("id")); err != nil {
+		respondAppError(c, err, tr(c, "upload.cancel_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "common.delete_success")})
+}
+
+// GetUploadQuota 查询存储空间使用情况
+func GetUploadQuota(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	config := utils.GetMediaConfig()
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"used":            services.MediaUsage(db, userID.(uint)),
+		"quota":           config.UserQuota,
+		"max_file_size":   config.UploadMaxSize,
+		"max_chunk_size":  config.UploadChunkSize,
+		"session_ttl_sec": int64(config.UploadTTL / time.Second),
+	}})
+}
+
+func setUploadHeaders(c *gin.Context, session *models.UploadSession) {
+	c.Header("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(session.Size, 10))
+	c.Header("Upload-Expires", time.Unix(session.ExpiresAt, 0).UTC().Format(http.TimeFormat))
+}
+
+func uploadSessionResponse(session *models.UploadSession) gin.H {
+	return gin.H{
+		"upload_id":  session.UploadID,
+		"file_name":  session.FileName,
+		"file_type":  session.FileType,
+		"size":       session.Size,
+		"offset":     session.Offset,
+		"status":     session.Status,
+		"media_id":   session.MediaID,
+		"chunk_size": utils.GetMediaConfig().UploadChunkSize,
+		"expires_at": session.ExpiresAt,
+	}
+}
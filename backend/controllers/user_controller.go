@@ -2,13 +2,13 @@ package controllers
 
 import (
 	"allinone_backend/models"
+	"allinone_backend/services"
 	"allinone_backend/utils"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
@@ -18,35 +18,44 @@ func RegisterUser(c *gin.Context) {
 		Password string `json:"password"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+		return
+	}
+	if err := utils.ValidatePasswordStrength(req.Password, ""); err != nil {
+		respondAppError(c, err, tr(c, "user.register_failed"))
+		return
+	}
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "msg": tr(c, "common.internal_error")})
 		return
 	}
 	db := c.MustGet("db").(*gorm.DB)
 	user := models.User{
-		Password:  req.Password,
+		Password:  hashedPassword,
 		CreatedAt: time.Now().Unix(),
 	}
 	if err := db.Create(&user).Error; err != nil {
-		c.JSON(500, gin.H{"success": false, "msg": "注册失败"})
+		c.JSON(500, gin.H{"success": false, "msg": tr(c, "user.register_failed")})
 		return
 	}
 	// 纯数字账号生成（如QQ号，100000起步，保证唯一）
 	account := strconv.Itoa(100000 + int(user.ID))
 	db.Model(&user).Update("Account", account)
-	c.JSON(200, gin.H{"success": true, "msg": "注册成功", "account": account, "user_id": user.ID})
+	c.JSON(200, gin.H{"success": true, "msg": tr(c, "user.registered"), "account": account, "user_id": user.ID})
 }
 
 // 通过账号查找用户ID
 func GetUserByAccount(c *gin.Context) {
 	account := c.Query("account")
 	if account == "" {
-		c.JSON(400, gin.H{"success": false, "msg": "参数缺失"})
+		c.JSON(400, gin.H{"success": false, "msg": tr(c, "common.missing_params")})
 		return
 	}
 	db := c.MustGet("db").(*gorm.DB)
 	var user models.User
 	if err := db.Where("account = ?", account).First(&user).Error; err != nil {
-		c.JSON(404, gin.H{"success": false, "msg": "账号不存在"})
+		c.JSON(404, gin.H{"success": false, "msg": tr(c, "user.account_not_found")})
 		return
 	}
 	c.JSON(200, gin.H{"success": true, "user_id": user.ID, "account": user.Account})
@@ -64,7 +73,7 @@ func Register(c *gin.Context) {
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "请求参数错误",
+			"msg":     tr(c, "common.invalid_request"),
 		})
 		return
 	}
@@ -73,27 +82,39 @@ func Register(c *gin.Context) {
 	if !utils.VerifyCaptcha(req.CaptchaID, req.CaptchaValue) {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "验证码错误",
+			"msg":     tr(c, "captcha.invalid"),
 		})
 		return
 	}
 
+	// 系统保留的账号名不能注册
+	if services.IsReservedAccount(req.Account) {
+		respondAppError(c, services.ErrReservedAccount, tr(c, "user.register_failed"))
+		return
+	}
+
 	// 检查用户是否已存在
 	var existingUser models.User
 	if err := utils.DB.Where("account = ?", req.Account).First(&existingUser).Error; err == nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "账号已存在",
+			"msg":     tr(c, "user.account_exists"),
 		})
 		return
 	}
 
+	// 检查密码强度
+	if err := utils.ValidatePasswordStrength(req.Password, req.Account); err != nil {
+		respondAppError(c, err, tr(c, "user.register_failed"))
+		return
+	}
+
 	// 密码加密
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"msg":     "服务器内部错误",
+			"msg":     tr(c, "common.internal_error"),
 		})
 		return
 	}
@@ -101,7 +122,7 @@ func Register(c *gin.Context) {
 	// 创建用户
 	user := models.User{
 		Account:   req.Account,
-		Password:  string(hashedPassword),
+		Password:  hashedPassword,
 		Nickname:  req.Account, // 默认昵称与账号相同
 		CreatedAt: time.Now().Unix(),
 	}
@@ -109,14 +130,14 @@ func Register(c *gin.Context) {
 	if err := utils.DB.Create(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"msg":     "注册失败",
+			"msg":     tr(c, "user.register_failed"),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "注册成功",
+		"msg":     tr(c, "user.registered"),
 	})
 }
 
@@ -125,50 +146,56 @@ func Login(c *gin.Context) {
 	var req struct {
 		Account  string `json:"account" binding:"required"`
 		Password string `json:"password" binding:"required"`
+		services.DeviceInfo
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "请求参数错误",
+			"msg":     tr(c, "common.invalid_request"),
 		})
 		return
 	}
 
-	// 查询用户
+	// 查询用户，账号不存在时同样校验密码，不泄露账号是否存在
 	var user models.User
-	if err := utils.DB.Where("account = ?", req.Account).First(&user).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"msg":     "账号或密码错误",
-		})
-		return
+	var found *models.User
+	if err := utils.DB.Where("account = ?", req.Account).First(&user).Error; err == nil {
+		found = &user
 	}
 
-	// 验证密码
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"msg":     "账号或密码错误",
-		})
+	// 验证密码，错误次数过多时锁定账号和IP
+	if err := services.AuthenticatePassword(utils.DB, found, req.Account, req.Password, c.ClientIP()); err != nil {
+		respondAppError(c, err, tr(c, "auth.login_failed"))
 		return
 	}
 
-	// 生成JWT
-	token, err := utils.GenerateToken(user.ID, user.Account)
+	// 在登录设备上创建会话，签发访问令牌和刷新令牌；开启两步验证且设备不受信任时先返回临时令牌
+	req.DeviceInfo.IPAddress = c.ClientIP()
+	req.DeviceInfo.UserAgent = c.Request.UserAgent()
+	tokens, challenge, err := services.BeginLogin(utils.DB, user.ID, user.Account, req.DeviceInfo)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"msg":     "登录失败",
+			"msg":     tr(c, "auth.login_failed"),
 		})
 		return
 	}
+	if challenge != nil {
+		respondTwoFactorRequired(c, challenge)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "登录成功",
+		"msg":     tr(c, "auth.login_success"),
 		"data": gin.H{
-			"token": token,
+			"token":              tokens.AccessToken,
+			"refresh_token":      tokens.RefreshToken,
+			"expires_in":         tokens.ExpiresIn,
+			"refresh_expires_in": tokens.RefreshExpiresIn,
+			"session_id":         tokens.SessionID,
+			"device_id":          tokens.DeviceID,
 			"user": gin.H{
 				"id":       user.ID,
 				"account":  user.Account,
@@ -185,7 +212,7 @@ func ValidateToken(c *gin.Context) {
 	if authHeader == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
-			"msg":     "未提供token",
+			"msg":     tr(c, "auth.token_not_provided"),
 		})
 		return
 	}
@@ -193,10 +220,10 @@ func ValidateToken(c *gin.Context) {
 	// 解析token
 	parts := authHeader[7:] // 去掉"Bearer "前缀
 	claims, err := utils.ParseToken(parts)
-	if err != nil {
+	if err != nil || services.IsTokenRevoked(claims) {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
-			"msg":     "token无效或已过期",
+			"msg":     tr(c, "auth.token_invalid"),
 		})
 		return
 	}
@@ -206,14 +233,14 @@ func ValidateToken(c *gin.Context) {
 	if err := utils.DB.First(&user, claims.UserID).Error; err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
-			"msg":     "用户不存在",
+			"msg":     tr(c, "user.not_found"),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "token有效",
+		"msg":     tr(c, "auth.token_valid"),
 		"data": gin.H{
 			"user_id":  user.ID,
 			"account":  user.Account,
@@ -231,7 +258,7 @@ func GetUserInfo(c *gin.Context) {
 	if err := utils.DB.First(&user, userID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
-			"msg":     "用户不存在",
+			"msg":     tr(c, "user.not_found"),
 		})
 		return
 	}
@@ -239,12 +266,13 @@ func GetUserInfo(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
-			"id":       user.ID,
-			"account":  user.Account,
-			"nickname": user.Nickname,
-			"avatar":   user.Avatar,
-			"bio":      user.Bio,
-			"gender":   user.Gender,
+			"id":           user.ID,
+			"account":      user.Account,
+			"nickname":     user.Nickname,
+			"avatar":       user.Avatar,
+			"avatar_thumb": user.AvatarThumb,
+			"bio":          user.Bio,
+			"gender":       user.Gender,
 		},
 	})
 }
@@ -263,7 +291,7 @@ func UpdateUserInfo(c *gin.Context) {
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "请求参数错误",
+			"msg":     tr(c, "common.invalid_request"),
 		})
 		return
 	}
@@ -273,7 +301,7 @@ func UpdateUserInfo(c *gin.Context) {
 	if err := utils.DB.First(&user, userID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
-			"msg":     "用户不存在",
+			"msg":     tr(c, "user.not_found"),
 		})
 		return
 	}
@@ -284,7 +312,9 @@ func UpdateUserInfo(c *gin.Context) {
 		updates["nickname"] = req.Nickname
 	}
 	if req.Avatar != "" {
+		// 直接指定的头像地址没有缩略图，缩略图使用同一地址
 		updates["avatar"] = req.Avatar
+		updates["avatar_thumb"] = req.Avatar
 	}
 	if req.Bio != "" {
 		updates["bio"] = req.Bio
@@ -296,21 +326,22 @@ func UpdateUserInfo(c *gin.Context) {
 	if err := utils.DB.Model(&user).Updates(updates).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"msg":     "更新失败",
+			"msg":     tr(c, "common.update_failed"),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "更新成功",
+		"msg":     tr(c, "common.update_success"),
 		"data": gin.H{
-			"id":       user.ID,
-			"account":  user.Account,
-			"nickname": user.Nickname,
-			"avatar":   user.Avatar,
-			"bio":      user.Bio,
-			"gender":   user.Gender,
+			"id":           user.ID,
+			"account":      user.Account,
+			"nickname":     user.Nickname,
+			"avatar":       user.Avatar,
+			"avatar_thumb": user.AvatarThumb,
+			"bio":          user.Bio,
+			"gender":       user.Gender,
 		},
 	})
 }
@@ -18,14 +18,14 @@ func GetVoiceCallRecords(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -98,7 +98,7 @@ func GetVoiceCallRecords(c *gin.Context) {
 	utils.Logger.Infof("获取到语音通话记录: userID=%d, 总数=%d, 本页数量=%d", userID, total, len(records))
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取语音通话记录成功",
+		"msg":     tr(c, "call.voice_history_loaded"),
 		"data": gin.H{
 			"total":     total,
 			"page":      page,
@@ -114,14 +114,14 @@ func GetVoiceCallDetail(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -129,7 +129,7 @@ func GetVoiceCallDetail(c *gin.Context) {
 	callID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		utils.Logger.Errorf("通话ID无效: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "通话ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "call.invalid_id")})
 		return
 	}
 
@@ -141,7 +141,7 @@ func GetVoiceCallDetail(c *gin.Context) {
 	var record models.VoiceCallRecord
 	if err := db.Where("id = ? AND (caller_id = ? OR receiver_id = ?)", callID, userID, userID).First(&record).Error; err != nil {
 		utils.Logger.Errorf("查询语音通话记录失败: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "通话记录不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "call.not_found")})
 		return
 	}
 
@@ -163,7 +163,7 @@ func GetVoiceCallDetail(c *gin.Context) {
 	utils.Logger.Infof("获取到语音通话详情: userID=%d, callID=%d", userID, callID)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取语音通话详情成功",
+		"msg":     tr(c, "call.voice_detail_loaded"),
 		"data": gin.H{
 			"id":          record.ID,
 			"caller_id":   record.CallerID,
@@ -185,7 +185,7 @@ func InitiateVoiceCall(c *gin.Context) {
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.Logger.Errorf("发起语音通话参数错误: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
@@ -193,14 +193,14 @@ func InitiateVoiceCall(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -209,14 +209,14 @@ func InitiateVoiceCall(c *gin.Context) {
 	var receiver models.User
 	if err := db.Select("id").Where("id = ?", req.ReceiverID).First(&receiver).Error; err != nil {
 		utils.Logger.Errorf("接收者不存在: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "接收者不存在"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "chat.receiver_not_found")})
 		return
 	}
 
 	// 检查接收者是否在线
 	if !utils.WebRTCServer.IsUserOnline(req.ReceiverID) {
 		utils.Logger.Errorf("接收者不在线: receiverID=%d", req.ReceiverID)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "接收者不在线"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "call.receiver_offline")})
 		return
 	}
 
@@ -230,7 +230,7 @@ func InitiateVoiceCall(c *gin.Context) {
 	}
 	if err := db.Create(&voiceCall).Error; err != nil {
 		utils.Logger.Errorf("创建语音通话记录失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "创建语音通话记录失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "call.voice_create_failed")})
 		return
 	}
 
@@ -246,21 +246,21 @@ func InitiateVoiceCall(c *gin.Context) {
 	jsonMessage, err := json.Marshal(message)
 	if err != nil {
 		utils.Logger.Errorf("序列化语音通话邀请失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "发送语音通话邀请失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "call.voice_invite_failed")})
 		return
 	}
 
 	err = utils.WebRTCServer.SendToUser(req.ReceiverID, jsonMessage)
 	if err != nil {
 		utils.Logger.Errorf("发送语音通话邀请失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "发送语音通话邀请失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "call.voice_invite_failed")})
 		return
 	}
 
 	utils.Logger.Infof("发起语音通话成功: callerID=%d, receiverID=%d, callID=%d", userID, req.ReceiverID, voiceCall.ID)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "发起语音通话成功",
+		"msg":     tr(c, "call.voice_invited"),
 		"data": gin.H{
 			"call_id": voiceCall.ID,
 		},
@@ -274,7 +274,7 @@ func HandleAcceptVoiceCall(c *gin.Context) {
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.Logger.Errorf("接受语音通话参数错误: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
@@ -282,14 +282,14 @@ func HandleAcceptVoiceCall(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -299,7 +299,7 @@ func HandleAcceptVoiceCall(c *gin.Context) {
 	var voiceCall models.VoiceCallRecord
 	if err := db.Where("id = ? AND receiver_id = ? AND status = 0", req.CallID, userID).First(&voiceCall).Error; err != nil {
 		utils.Logger.Errorf("查询语音通话记录失败: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "通话记录不存在或已接通/拒绝"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "call.not_pending")})
 		return
 	}
 
@@ -307,7 +307,7 @@ func HandleAcceptVoiceCall(c *gin.Context) {
 	voiceCall.Status = 1 // 已接通
 	if err := db.Save(&voiceCall).Error; err != nil {
 		utils.Logger.Errorf("更新语音通话状态失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "更新通话状态失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "call.update_status_failed")})
 		return
 	}
 
@@ -315,14 +315,14 @@ func HandleAcceptVoiceCall(c *gin.Context) {
 	err := utils.WebRTCServer.SendCallResponse(userID, voiceCall.CallerID, "voice", voiceCall.ID, "accepted")
 	if err != nil {
 		utils.Logger.Errorf("发送接受语音通话响应失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "发送接受通话响应失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "call.accept_notify_failed")})
 		return
 	}
 
 	utils.Logger.Infof("接受语音通话成功: receiverID=%d, callerID=%d, callID=%d", userID, voiceCall.CallerID, voiceCall.ID)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "接受语音通话成功",
+		"msg":     tr(c, "call.voice_accepted"),
 		"data": gin.H{
 			"call_id": voiceCall.ID,
 		},
@@ -336,7 +336,7 @@ func HandleRejectVoiceCall(c *gin.Context) {
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.Logger.Errorf("拒绝语音通话参数错误: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
@@ -344,14 +344,14 @@ func HandleRejectVoiceCall(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -361,7 +361,7 @@ func HandleRejectVoiceCall(c *gin.Context) {
 	var voiceCall models.VoiceCallRecord
 	if err := db.Where("id = ? AND receiver_id = ? AND status = 0", req.CallID, userID).First(&voiceCall).Error; err != nil {
 		utils.Logger.Errorf("查询语音通话记录失败: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "通话记录不存在或已接通/拒绝"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "call.not_pending")})
 		return
 	}
 
@@ -371,7 +371,7 @@ func HandleRejectVoiceCall(c *gin.Context) {
 	voiceCall.EndTime = now
 	if err := db.Save(&voiceCall).Error; err != nil {
 		utils.Logger.Errorf("更新语音通话状态失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "更新通话状态失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "call.update_status_failed")})
 		return
 	}
 
@@ -379,14 +379,14 @@ func HandleRejectVoiceCall(c *gin.Context) {
 	err := utils.WebRTCServer.SendCallResponse(userID, voiceCall.CallerID, "voice", voiceCall.ID, "rejected")
 	if err != nil {
 		utils.Logger.Errorf("发送拒绝语音通话响应失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "发送拒绝通话响应失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "call.reject_notify_failed")})
 		return
 	}
 
 	utils.Logger.Infof("拒绝语音通话成功: receiverID=%d, callerID=%d, callID=%d", userID, voiceCall.CallerID, voiceCall.ID)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "拒绝语音通话成功",
+		"msg":     tr(c, "call.voice_rejected"),
 	})
 }
 
@@ -397,7 +397,7 @@ func HandleEndVoiceCall(c *gin.Context) {
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.Logger.Errorf("结束语音通话参数错误: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
@@ -405,14 +405,14 @@ func HandleEndVoiceCall(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -422,7 +422,7 @@ func HandleEndVoiceCall(c *gin.Context) {
 	var voiceCall models.VoiceCallRecord
 	if err := db.Where("id = ? AND (caller_id = ? OR receiver_id = ?) AND status = 1", req.CallID, userID, userID).First(&voiceCall).Error; err != nil {
 		utils.Logger.Errorf("查询语音通话记录失败: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "通话记录不存在或未接通"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "call.not_connected")})
 		return
 	}
 
@@ -432,7 +432,7 @@ func HandleEndVoiceCall(c *gin.Context) {
 	voiceCall.Duration = int(now - voiceCall.StartTime)
 	if err := db.Save(&voiceCall).Error; err != nil {
 		utils.Logger.Errorf("更新语音通话状态失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "更新通话状态失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "call.update_status_failed")})
 		return
 	}
 
@@ -448,7 +448,7 @@ func HandleEndVoiceCall(c *gin.Context) {
 	err := utils.WebRTCServer.SendCallEnded(userID, otherUserID, "voice", voiceCall.ID, "normal")
 	if err != nil {
 		utils.Logger.Errorf("发送结束语音通话通知失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "发送结束通话通知失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "call.end_notify_failed")})
 		return
 	}
 
@@ -456,7 +456,7 @@ func HandleEndVoiceCall(c *gin.Context) {
 		userID, otherUserID, voiceCall.ID, voiceCall.Duration)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "结束语音通话成功",
+		"msg":     tr(c, "call.voice_ended"),
 		"data": gin.H{
 			"duration": voiceCall.Duration,
 		},
@@ -469,14 +469,14 @@ func GetVoiceCallStats(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -565,7 +565,7 @@ func GetVoiceCallStats(c *gin.Context) {
 	utils.Logger.Infof("获取到语音通话统计: userID=%d, 总通话次数=%d, 总通话时长=%d", userID, totalCalls, totalDuration)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取语音通话统计成功",
+		"msg":     tr(c, "call.voice_stats_loaded"),
 		"data": gin.H{
 			"total_calls":       totalCalls,
 			"outgoing_calls":    outgoingCalls,
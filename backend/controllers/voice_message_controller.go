@@ -2,7 +2,9 @@ package controllers
 
 import (
 	"allinone_backend/models"
+	"allinone_backend/services"
 	"allinone_backend/utils"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
@@ -11,7 +13,6 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -21,14 +22,14 @@ func UploadVoiceMessage(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -36,29 +37,21 @@ func UploadVoiceMessage(c *gin.Context) {
 	receiverIDStr := c.PostForm("receiver_id")
 	if receiverIDStr == "" {
 		utils.Logger.Errorf("接收者ID为空")
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "接收者ID不能为空"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "voice.receiver_required")})
 		return
 	}
 
 	receiverID, err := strconv.ParseUint(receiverIDStr, 10, 64)
 	if err != nil {
 		utils.Logger.Errorf("接收者ID无效: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "接收者ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "voice.invalid_receiver_id")})
 		return
 	}
 
-	// 获取语音时长
-	durationStr := c.PostForm("duration")
-	if durationStr == "" {
-		utils.Logger.Errorf("语音时长为空")
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "语音时长不能为空"})
-		return
-	}
-
-	duration, err := strconv.Atoi(durationStr)
-	if err != nil {
-		utils.Logger.Errorf("语音时长无效: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "语音时长无效"})
+	// 语音识别的语言提示，为空时自动判断
+	language := c.PostForm("language")
+	if _, err := services.ValidateSpeechLanguage(language); err != nil {
+		respondAppError(c, err, tr(c, "voice.upload_failed"))
 		return
 	}
 
@@ -66,58 +59,59 @@ func UploadVoiceMessage(c *gin.Context) {
 	file, err := c.FormFile("voice")
 	if err != nil {
 		utils.Logger.Errorf("获取语音文件失败: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "获取语音文件失败"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "voice.file_query_failed")})
 		return
 	}
 
 	// 检查文件类型
 	if !isValidAudioFile(file.Filename) {
 		utils.Logger.Errorf("文件类型不支持: %s", file.Filename)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "只支持m4a、mp3、wav、aac格式的语音文件"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "voice.unsupported_format")})
 		return
 	}
 
-	// 创建上传目录
-	uploadDir := "uploads/voice"
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		utils.Logger.Errorf("创建上传目录失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "创建上传目录失败"})
+	// 获取数据库连接
+	db := c.MustGet("db").(*gorm.DB)
+
+	// 按实际音频计算时长和波形，转码后保存到媒体存储，只有会话双方可以下载
+	target, err := services.ResolveMediaTarget(db, userID, "", uint(receiverID), 0)
+	if err != nil {
+		respondAppError(c, err, tr(c, "voice.file_save_failed"))
 		return
 	}
-
-	// 生成唯一文件名
-	fileExt := filepath.Ext(file.Filename)
-	fileName := fmt.Sprintf("%s%s", uuid.New().String(), fileExt)
-	filePath := filepath.Join(uploadDir, fileName)
-
-	// 保存文件
-	if err := c.SaveUploadedFile(file, filePath); err != nil {
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "voice.file_query_failed")})
+		return
+	}
+	defer src.Close()
+	media, voice, err := services.StoreVoice(c.Request.Context(), db, userID, src, file.Filename, target)
+	if err != nil {
 		utils.Logger.Errorf("保存语音文件失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "保存语音文件失败"})
+		respondAppError(c, err, tr(c, "voice.file_save_failed"))
 		return
 	}
 
-	// 生成可访问的URL
-	fileURL := fmt.Sprintf("/uploads/voice/%s", fileName)
-
-	// 获取数据库连接
-	db := c.MustGet("db").(*gorm.DB)
+	// 消息中保存固定的媒体地址，下载地址由 /api/media/:id 按用户签发
+	fileRef := services.MediaRef(media)
+	extraJSON, _ := json.Marshal(services.VoiceExtra(media.ID, voice))
+	extra := string(extraJSON)
 
 	// 创建语音消息记录
 	now := time.Now().Unix()
 	voiceMessage := models.VoiceMessage{
 		SenderID:   userID,
 		ReceiverID: uint(receiverID),
-		FilePath:   filePath,
-		URL:        fileURL,
-		Duration:   duration,
+		MediaID:    media.ID,
+		URL:        fileRef,
 		Status:     1, // 已发送
 		CreatedAt:  now,
 	}
+	services.ApplyVoiceInfo(&voiceMessage, voice)
 
 	if err := db.Create(&voiceMessage).Error; err != nil {
 		utils.Logger.Errorf("创建语音消息记录失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "创建语音消息记录失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "voice.record_create_failed")})
 		return
 	}
 
@@ -125,43 +119,62 @@ func UploadVoiceMessage(c *gin.Context) {
 	chatMessage := models.ChatMessage{
 		SenderID:   userID,
 		ReceiverID: uint(receiverID),
-		Content:    fileURL,
+		Content:    fileRef,
 		Type:       "voice",
-		Extra:      fmt.Sprintf(`{"duration":%d,"url":"%s"}`, duration, fileURL),
+		Extra:      extra,
 		Status:     1, // 已发送
 		CreatedAt:  now,
 	}
 
 	if err := db.Create(&chatMessage).Error; err != nil {
 		utils.Logger.Errorf("创建聊天消息记录失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "创建聊天消息记录失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "chat.record_create_failed")})
 		return
 	}
+	voiceMessage.ChatMessageID = chatMessage.ID
+	db.Model(&voiceMessage).Update("chat_message_id", chatMessage.ID)
+
+	// 后台识别语音，完成后推送 voice_transcribed 事件
+	job, err := services.EnqueueVoiceTranscription(db, &voiceMessage, userID, language)
+	if err != nil {
+		utils.Logger.Errorf("创建语音识别任务失败: message=%d, error=%v", chatMessage.ID, err)
+	}
 
 	// 发送WebSocket消息通知接收者
 	message := map[string]interface{}{
 		"type":       "new_message",
 		"message_id": chatMessage.ID,
 		"sender_id":  userID,
-		"content":    fileURL,
+		"content":    fileRef,
 		"msg_type":   "voice",
-		"extra":      fmt.Sprintf(`{"duration":%d,"url":"%s"}`, duration, fileURL),
+		"extra":      extra,
 		"timestamp":  now,
 	}
 
 	// 使用WebSocket服务器发送消息
 	utils.WebRTCServer.SendToUser(uint(receiverID), []byte(fmt.Sprintf("%v", message)))
 
-	utils.Logger.Infof("上传语音消息成功: senderID=%d, receiverID=%d, messageID=%d, duration=%d",
-		userID, receiverID, chatMessage.ID, duration)
+	utils.Logger.Infof("上传语音消息成功: senderID=%d, receiverID=%d, messageID=%d, duration=%dms",
+		userID, receiverID, chatMessage.ID, voiceMessage.DurationMs)
+	fileURL, expiresAt := services.MediaURL(media, userID)
+	data := gin.H{
+		"id":           chatMessage.ID,
+		"media_id":     media.ID,
+		"url":          fileURL,
+		"expires_at":   expiresAt,
+		"content_type": media.ContentType,
+		"duration":     voiceMessage.Duration,
+		"duration_ms":  voiceMessage.DurationMs,
+		"waveform":     voice.Waveform,
+	}
+	if job != nil {
+		data["transcription_job_id"] = job.ID
+		data["transcript_status"] = job.Status
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "上传语音消息成功",
-		"data": gin.H{
-			"id":       chatMessage.ID,
-			"url":      fileURL,
-			"duration": duration,
-		},
+		"msg":     tr(c, "voice.uploaded"),
+		"data":    data,
 	})
 }
 
@@ -171,14 +184,14 @@ func GetVoiceMessage(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -186,7 +199,7 @@ func GetVoiceMessage(c *gin.Context) {
 	messageID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		utils.Logger.Errorf("消息ID无效: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "消息ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "chat.invalid_message_id")})
 		return
 	}
 
@@ -198,56 +211,142 @@ func GetVoiceMessage(c *gin.Context) {
 	if err := db.Where("id = ? AND type = 'voice' AND (sender_id = ? OR receiver_id = ?)",
 		messageID, userID, userID).First(&chatMessage).Error; err != nil {
 		utils.Logger.Errorf("查询语音消息失败: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "语音消息不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "voice.not_found")})
 		return
 	}
 
+	data := gin.H{
+		"id":       chatMessage.ID,
+		"url":      chatMessage.Content,
+		"duration": extractDurationFromExtra(chatMessage.Extra),
+	}
+	// 附带语音识别结果
+	var voiceMessage models.VoiceMessage
+	if err := db.Where("chat_message_id = ?", chatMessage.ID).First(&voiceMessage).Error; err == nil {
+		if voiceMessage.MediaID != 0 {
+			var media models.Media
+			if err := db.First(&media, voiceMessage.MediaID).Error; err == nil {
+				data["url"], data["expires_at"] = services.MediaURL(&media, userID)
+			}
+		}
+		data["duration"] = voiceMessage.Duration
+		data["duration_ms"] = voiceMessage.DurationMs
+		data["waveform"] = services.VoiceWaveform(&voiceMessage)
+		for k, v := range services.VoiceListenState(db, &voiceMessage, userID) {
+			data[k] = v
+		}
+		data["transcript"] = voiceMessage.Transcript
+		data["transcript_lang"] = voiceMessage.TranscriptLang
+		data["transcript_status"] = voiceMessage.TranscriptStatus
+	}
+
 	utils.Logger.Infof("获取语音消息成功: userID=%d, messageID=%d", userID, messageID)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取语音消息成功",
-		"data": gin.H{
-			"id":       chatMessage.ID,
-			"url":      chatMessage.Content,
-			"duration": extractDurationFromExtra(chatMessage.Extra),
-		},
+		"msg":     tr(c, "voice.loaded"),
+		"data":    data,
 	})
 }
 
+// 重新识别语音消息，可指定语言提示
+func TranscribeVoiceMessage(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+
+	messageID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "chat.invalid_message_id")})
+		return
+	}
+
+	var req struct {
+		Language string `json:"language"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
+			return
+		}
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	var voiceMessage models.VoiceMessage
+	if err := db.Where("chat_message_id = ?", messageID).First(&voiceMessage).Error; err != nil ||
+		!services.IsVoiceMessageParticipant(db, &voiceMessage, userID.(uint)) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "voice.not_found")})
+		return
+	}
+
+	job, err := services.EnqueueVoiceTranscription(db, &voiceMessage, userID.(uint), req.Language)
+	if err != nil {
+		respondAppError(c, err, tr(c, "speech.job_create_failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "msg": tr(c, "speech.job_started"), "data": job})
+}
+
+// 标记语音消息已收听，首次收听时通知发送者
+func MarkVoiceMessageListened(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
+		return
+	}
+
+	messageID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "chat.invalid_message_id")})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	var voiceMessage models.VoiceMessage
+	if err := db.Where("chat_message_id = ?", messageID).First(&voiceMessage).Error; err != nil ||
+		!services.IsVoiceMessageParticipant(db, &voiceMessage, userID.(uint)) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "voice.not_found")})
+		return
+	}
+
+	listen, err := services.MarkVoiceListened(db, &voiceMessage, userID.(uint))
+	if err != nil {
+		respondAppError(c, err, tr(c, "voice.mark_played_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": listen})
+}
+
 // 从Extra字段中提取语音时长
 func extractDurationFromExtra(extra string) int {
-	// 简单实现，实际应该使用JSON解析
-	var duration int
-	fmt.Sscanf(extra, `{"duration":%d`, &duration)
-	return duration
+	var data struct {
+		Duration int `json:"duration"`
+	}
+	json.Unmarshal([]byte(extra), &data)
+	return data.Duration
 }
 
 // 检查文件是否为有效的音频文件
 func isValidAudioFile(filename string) bool {
-	ext := filepath.Ext(filename)
-	validExts := map[string]bool{
-		".m4a": true,
-		".mp3": true,
-		".wav": true,
-		".aac": true,
-	}
-	return validExts[ext]
+	return filepath.Ext(filename) != "" && utils.IsSupportedAudioFormat(filepath.Ext(filename))
 }
 
-// 下载语音文件
+// 下载旧版本保存在本地目录的语音文件，新的语音消息通过媒体接口下载
 func DownloadVoiceFile(c *gin.Context) {
 	// 获取当前登录用户ID
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -255,17 +354,26 @@ func DownloadVoiceFile(c *gin.Context) {
 	fileName := c.Param("filename")
 	if fileName == "" {
 		utils.Logger.Errorf("文件名为空")
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "文件名不能为空"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "file.name_required")})
 		return
 	}
 
 	// 构建文件路径
-	filePath := filepath.Join("uploads/voice", fileName)
+	filePath := filepath.Join("uploads/voice", filepath.Base(fileName))
+
+	// 只有会话参与者可以下载
+	db := c.MustGet("db").(*gorm.DB)
+	var voiceMessage models.VoiceMessage
+	if err := db.Where("file_path = ?", filePath).First(&voiceMessage).Error; err != nil ||
+		!services.IsVoiceMessageParticipant(db, &voiceMessage, userID) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "voice.file_not_found")})
+		return
+	}
 
 	// 检查文件是否存在
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		utils.Logger.Errorf("语音文件不存在: %s", filePath)
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "语音文件不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "voice.file_not_found")})
 		return
 	}
 
@@ -273,7 +381,7 @@ func DownloadVoiceFile(c *gin.Context) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		utils.Logger.Errorf("打开语音文件失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "打开语音文件失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "voice.file_open_failed")})
 		return
 	}
 	defer file.Close()
@@ -282,7 +390,7 @@ func DownloadVoiceFile(c *gin.Context) {
 	fileInfo, err := file.Stat()
 	if err != nil {
 		utils.Logger.Errorf("获取语音文件信息失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "获取语音文件信息失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "voice.file_stat_failed")})
 		return
 	}
 
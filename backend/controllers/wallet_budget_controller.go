@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"allinone_backend/models"
+	"allinone_backend/services"
 	"allinone_backend/utils"
 	"net/http"
 	"strconv"
@@ -23,7 +24,7 @@ func CreateBudget(c *gin.Context) {
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.Logger.Errorf("创建预算参数错误: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
@@ -31,27 +32,14 @@ func CreateBudget(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
-		return
-	}
-
-	// 验证参数
-	if req.StartDate >= req.EndDate {
-		utils.Logger.Errorf("开始日期必须早于结束日期: start=%d, end=%d", req.StartDate, req.EndDate)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "开始日期必须早于结束日期"})
-		return
-	}
-
-	if req.Period != "month" && req.Period != "year" {
-		utils.Logger.Errorf("预算周期无效: %s", req.Period)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "预算周期无效，只支持 month 或 year"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -60,20 +48,6 @@ func CreateBudget(c *gin.Context) {
 
 	db := c.MustGet("db").(*gorm.DB)
 
-	// 检查是否已存在相同类别和时间段的预算
-	var existingBudget models.Budget
-	result := db.Where("user_id = ? AND category = ? AND ((start_date <= ? AND end_date >= ?) OR (start_date <= ? AND end_date >= ?) OR (start_date >= ? AND end_date <= ?))",
-		userID, req.Category, req.StartDate, req.StartDate, req.EndDate, req.EndDate, req.StartDate, req.EndDate).
-		First(&existingBudget)
-	
-	if result.Error == nil {
-		utils.Logger.Errorf("已存在相同类别和时间段的预算: id=%d", existingBudget.ID)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "已存在相同类别和时间段的预算"})
-		return
-	}
-
-	now := time.Now().Unix()
-
 	// 创建预算
 	budget := models.Budget{
 		UserID:      userID,
@@ -83,20 +57,17 @@ func CreateBudget(c *gin.Context) {
 		StartDate:   req.StartDate,
 		EndDate:     req.EndDate,
 		Description: req.Description,
-		CreatedAt:   now,
-		UpdatedAt:   now,
 	}
 
-	if err := db.Create(&budget).Error; err != nil {
-		utils.Logger.Errorf("创建预算失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "创建预算失败"})
+	if err := services.CreateBudget(db, &budget); err != nil {
+		respondAppError(c, err, tr(c, "budget.create_failed"))
 		return
 	}
 
 	utils.Logger.Infof("创建预算成功: id=%d", budget.ID)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "创建预算成功",
+		"msg":     tr(c, "budget.created"),
 		"data": gin.H{
 			"budget": budget,
 		},
@@ -109,14 +80,14 @@ func GetBudgets(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -148,7 +119,7 @@ func GetBudgets(c *gin.Context) {
 	var budgets []models.Budget
 	if err := query.Order("created_at DESC").Find(&budgets).Error; err != nil {
 		utils.Logger.Errorf("查询预算失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "查询预算失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "budget.query_failed")})
 		return
 	}
 
@@ -195,7 +166,7 @@ func GetBudgets(c *gin.Context) {
 	utils.Logger.Infof("获取到预算列表: userID=%d, 数量=%d", userID, len(budgets))
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取预算列表成功",
+		"msg":     tr(c, "budget.list_loaded"),
 		"data": gin.H{
 			"budgets": budgetsWithSpending,
 		},
@@ -208,14 +179,14 @@ func GetBudgetDetail(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -223,7 +194,7 @@ func GetBudgetDetail(c *gin.Context) {
 	budgetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		utils.Logger.Errorf("预算ID无效: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "预算ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "budget.invalid_id")})
 		return
 	}
 
@@ -235,7 +206,7 @@ func GetBudgetDetail(c *gin.Context) {
 	var budget models.Budget
 	if err := db.Where("id = ? AND user_id = ?", budgetID, userID).First(&budget).Error; err != nil {
 		utils.Logger.Errorf("查询预算失败: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "预算不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "budget.not_found")})
 		return
 	}
 
@@ -288,7 +259,7 @@ func GetBudgetDetail(c *gin.Context) {
 	utils.Logger.Infof("获取到预算详情: userID=%d, budgetID=%d, 消费金额=%f, 百分比=%f", userID, budgetID, spending, percentage)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取预算详情成功",
+		"msg":     tr(c, "budget.detail_loaded"),
 		"data": gin.H{
 			"budget":          budget,
 			"spending":        spending,
@@ -310,7 +281,7 @@ func UpdateBudget(c *gin.Context) {
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.Logger.Errorf("更新预算参数错误: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
@@ -318,14 +289,14 @@ func UpdateBudget(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -333,7 +304,7 @@ func UpdateBudget(c *gin.Context) {
 	budgetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		utils.Logger.Errorf("预算ID无效: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "预算ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "budget.invalid_id")})
 		return
 	}
 
@@ -345,7 +316,7 @@ func UpdateBudget(c *gin.Context) {
 	var budget models.Budget
 	if err := db.Where("id = ? AND user_id = ?", budgetID, userID).First(&budget).Error; err != nil {
 		utils.Logger.Errorf("查询预算失败: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "预算不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "budget.not_found")})
 		return
 	}
 
@@ -353,7 +324,7 @@ func UpdateBudget(c *gin.Context) {
 	now := time.Now().Unix()
 	if budget.StartDate >= req.EndDate {
 		utils.Logger.Errorf("结束日期必须晚于开始日期: start=%d, end=%d", budget.StartDate, req.EndDate)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "结束日期必须晚于开始日期"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "budget.invalid_date_range")})
 		return
 	}
 
@@ -365,14 +336,14 @@ func UpdateBudget(c *gin.Context) {
 
 	if err := db.Save(&budget).Error; err != nil {
 		utils.Logger.Errorf("更新预算失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "更新预算失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "budget.update_failed")})
 		return
 	}
 
 	utils.Logger.Infof("更新预算成功: id=%d", budget.ID)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "更新预算成功",
+		"msg":     tr(c, "budget.updated"),
 		"data": gin.H{
 			"budget": budget,
 		},
@@ -385,14 +356,14 @@ func DeleteBudget(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -400,7 +371,7 @@ func DeleteBudget(c *gin.Context) {
 	budgetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		utils.Logger.Errorf("预算ID无效: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "预算ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "budget.invalid_id")})
 		return
 	}
 
@@ -412,21 +383,21 @@ func DeleteBudget(c *gin.Context) {
 	var budget models.Budget
 	if err := db.Where("id = ? AND user_id = ?", budgetID, userID).First(&budget).Error; err != nil {
 		utils.Logger.Errorf("查询预算失败: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "预算不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "budget.not_found")})
 		return
 	}
 
 	// 删除预算
 	if err := db.Delete(&budget).Error; err != nil {
 		utils.Logger.Errorf("删除预算失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "删除预算失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "budget.delete_failed")})
 		return
 	}
 
 	utils.Logger.Infof("删除预算成功: id=%d", budget.ID)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "删除预算成功",
+		"msg":     tr(c, "budget.deleted"),
 	})
 }
 
@@ -448,7 +419,7 @@ func GetBudgetCategories(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取预算类别成功",
+		"msg":     tr(c, "budget.categories_loaded"),
 		"data": gin.H{
 			"categories": categories,
 		},
@@ -19,7 +19,7 @@ func GetWalletInfo(c *gin.Context) {
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -44,7 +44,7 @@ func GetWalletInfo(c *gin.Context) {
 	utils.Logger.Infof("钱包信息: userID=%d, walletID=%d, balance=%f", userID, wallet.ID, wallet.Balance)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取钱包信息成功",
+		"msg":     tr(c, "wallet.loaded"),
 		"data": gin.H{
 			"wallet_id": wallet.ID,
 			"balance":   wallet.Balance,
@@ -60,7 +60,7 @@ func GetTransactions(c *gin.Context) {
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -93,7 +93,7 @@ func GetTransactions(c *gin.Context) {
 	utils.Logger.Infof("获取到交易记录: userID=%d, 总数=%d, 本页数量=%d", userID, total, len(transactions))
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取交易记录成功",
+		"msg":     tr(c, "wallet.transactions_loaded"),
 		"data": gin.H{
 			"total":        total,
 			"page":         page,
@@ -112,7 +112,7 @@ func Transfer(c *gin.Context) {
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.Logger.Errorf("转账参数错误: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
@@ -120,14 +120,14 @@ func Transfer(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -137,13 +137,13 @@ func Transfer(c *gin.Context) {
 	// 验证参数
 	if req.Amount <= 0 {
 		utils.Logger.Errorf("转账金额必须大于0: %f", req.Amount)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "转账金额必须大于0"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "wallet.transfer_amount_invalid")})
 		return
 	}
 
 	if userID == req.ReceiverID {
 		utils.Logger.Errorf("不能给自己转账: 发送者ID=%d, 接收者ID=%d", userID, req.ReceiverID)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "不能给自己转账"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "wallet.transfer_to_self")})
 		return
 	}
 
@@ -161,7 +161,7 @@ func Transfer(c *gin.Context) {
 		// 检查余额是否足够
 		if senderWallet.Balance < req.Amount {
 			utils.Logger.Errorf("余额不足: 当前余额=%f, 转账金额=%f", senderWallet.Balance, req.Amount)
-			return &utils.AppError{Code: 400, Message: "余额不足"}
+			return &utils.AppError{Code: 400, Message: "余额不足", Key: "wallet.insufficient_balance"}
 		}
 
 		// 查询或创建接收者钱包
@@ -287,17 +287,17 @@ func Transfer(c *gin.Context) {
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			utils.Logger.Errorf("转账失败(应用错误): %s", appErr.Message)
-			c.JSON(appErr.Code, gin.H{"success": false, "msg": appErr.Message})
+			c.JSON(appErr.Code, gin.H{"success": false, "msg": appErrorMessage(c, appErr)})
 		} else {
 			utils.Logger.Errorf("转账失败(系统错误): %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "转账失败: " + err.Error()})
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.transfer_failed_detail", err.Error())})
 		}
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "转账成功",
+		"msg":     tr(c, "wallet.transferred"),
 	})
 }
 
@@ -310,21 +310,21 @@ func Recharge(c *gin.Context) {
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.Logger.Errorf("充值参数错误: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 验证参数
 	if req.Amount <= 0 {
 		utils.Logger.Errorf("充值金额必须大于0: %f", req.Amount)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "充值金额必须大于0"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "wallet.deposit_amount_invalid")})
 		return
 	}
 
 	// 验证支付方式
 	if req.PaymentMethod != "bank_card" && req.PaymentMethod != "crypto" {
 		utils.Logger.Errorf("支付方式无效: %s", req.PaymentMethod)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "支付方式无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "wallet.invalid_payment_method")})
 		return
 	}
 
@@ -333,7 +333,7 @@ func Recharge(c *gin.Context) {
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -346,7 +346,7 @@ func Recharge(c *gin.Context) {
 	if req.PaymentMethod == "bank_card" {
 		if err := db.Where("id = ? AND user_id = ?", req.BankCardID, userID).First(&bankCard).Error; err != nil {
 			utils.Logger.Errorf("银行卡不存在或不属于当前用户: 银行卡ID=%d, 用户ID=%d, 错误=%v", req.BankCardID, userID, err)
-			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "银行卡不存在或不属于当前用户"})
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "bank_card.not_found")})
 			return
 		}
 	}
@@ -434,7 +434,7 @@ func Recharge(c *gin.Context) {
 
 	if err != nil {
 		utils.Logger.Errorf("充值失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "充值失败: " + err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.deposit_failed_detail", err.Error())})
 		return
 	}
 
@@ -444,7 +444,7 @@ func Recharge(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "充值成功",
+		"msg":     tr(c, "wallet.deposited"),
 		"data": gin.H{
 			"new_balance": wallet.Balance,
 		},
@@ -459,14 +459,14 @@ func Withdraw(c *gin.Context) {
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.Logger.Errorf("提现参数错误: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 验证参数
 	if req.Amount <= 0 {
 		utils.Logger.Errorf("提现金额必须大于0: %f", req.Amount)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "提现金额必须大于0"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "wallet.withdraw_amount_invalid")})
 		return
 	}
 
@@ -475,7 +475,7 @@ func Withdraw(c *gin.Context) {
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -487,7 +487,7 @@ func Withdraw(c *gin.Context) {
 	var bankCard models.BankCard
 	if err := db.Where("id = ? AND user_id = ?", req.BankCardID, userID).First(&bankCard).Error; err != nil {
 		utils.Logger.Errorf("银行卡不存在或不属于当前用户: 银行卡ID=%d, 用户ID=%d, 错误=%v", req.BankCardID, userID, err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "银行卡不存在或不属于当前用户"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "bank_card.not_found")})
 		return
 	}
 
@@ -503,7 +503,7 @@ func Withdraw(c *gin.Context) {
 		// 检查余额是否足够
 		if wallet.Balance < req.Amount {
 			utils.Logger.Errorf("余额不足: 当前余额=%f, 提现金额=%f", wallet.Balance, req.Amount)
-			return &utils.AppError{Code: 400, Message: "余额不足"}
+			return &utils.AppError{Code: 400, Message: "余额不足", Key: "wallet.insufficient_balance"}
 		}
 
 		now := time.Now().Unix()
@@ -563,10 +563,10 @@ func Withdraw(c *gin.Context) {
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			utils.Logger.Errorf("提现失败(应用错误): %s", appErr.Message)
-			c.JSON(appErr.Code, gin.H{"success": false, "msg": appErr.Message})
+			c.JSON(appErr.Code, gin.H{"success": false, "msg": appErrorMessage(c, appErr)})
 		} else {
 			utils.Logger.Errorf("提现失败(系统错误): %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "提现失败: " + err.Error()})
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.withdraw_failed_detail", err.Error())})
 		}
 		return
 	}
@@ -577,7 +577,7 @@ func Withdraw(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "提现成功",
+		"msg":     tr(c, "wallet.withdrawn"),
 		"data": gin.H{
 			"new_balance": wallet.Balance,
 		},
@@ -21,7 +21,7 @@ func CreateDeposit(c *gin.Context) {
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.Logger.Errorf("创建定期存款参数错误: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
@@ -29,14 +29,14 @@ func CreateDeposit(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -49,13 +49,13 @@ func CreateDeposit(c *gin.Context) {
 	var wallet models.Wallet
 	if err := db.Where("user_id = ?", userID).First(&wallet).Error; err != nil {
 		utils.Logger.Errorf("查询钱包失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "查询钱包失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.query_failed")})
 		return
 	}
 
 	if wallet.Balance < req.Amount {
 		utils.Logger.Errorf("余额不足: 当前余额=%f, 存款金额=%f", wallet.Balance, req.Amount)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "余额不足"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "wallet.insufficient_balance")})
 		return
 	}
 
@@ -126,7 +126,7 @@ func CreateDeposit(c *gin.Context) {
 
 	if err != nil {
 		utils.Logger.Errorf("创建定期存款失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "创建定期存款失败: " + err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "deposit.create_failed_detail", err.Error())})
 		return
 	}
 
@@ -136,7 +136,7 @@ func CreateDeposit(c *gin.Context) {
 	utils.Logger.Infof("创建定期存款成功: userID=%d, amount=%f, term=%d", userID, req.Amount, req.Term)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "创建定期存款成功",
+		"msg":     tr(c, "deposit.created"),
 		"data": gin.H{
 			"new_balance": wallet.Balance,
 		},
@@ -149,14 +149,14 @@ func GetDeposits(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -179,7 +179,7 @@ func GetDeposits(c *gin.Context) {
 	var deposits []models.Deposit
 	if err := query.Order("created_at DESC").Find(&deposits).Error; err != nil {
 		utils.Logger.Errorf("查询定期存款失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "查询定期存款失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "deposit.query_failed")})
 		return
 	}
 
@@ -193,7 +193,7 @@ func GetDeposits(c *gin.Context) {
 	utils.Logger.Infof("获取到定期存款列表: userID=%d, 数量=%d", userID, len(deposits))
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取定期存款列表成功",
+		"msg":     tr(c, "deposit.list_loaded"),
 		"data": gin.H{
 			"deposits":       deposits,
 			"total_amount":   totalAmount,
@@ -208,14 +208,14 @@ func GetDepositDetail(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -223,7 +223,7 @@ func GetDepositDetail(c *gin.Context) {
 	depositID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		utils.Logger.Errorf("定期存款ID无效: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "定期存款ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "deposit.invalid_id")})
 		return
 	}
 
@@ -235,7 +235,7 @@ func GetDepositDetail(c *gin.Context) {
 	var deposit models.Deposit
 	if err := db.Where("id = ? AND user_id = ?", depositID, userID).First(&deposit).Error; err != nil {
 		utils.Logger.Errorf("查询定期存款失败: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "定期存款不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "deposit.not_found")})
 		return
 	}
 
@@ -276,7 +276,7 @@ func GetDepositDetail(c *gin.Context) {
 		userID, depositID, progress, currentInterest)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取定期存款详情成功",
+		"msg":     tr(c, "deposit.detail_loaded"),
 		"data": gin.H{
 			"deposit":          deposit,
 			"progress":         progress,
@@ -293,14 +293,14 @@ func WithdrawDeposit(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -308,7 +308,7 @@ func WithdrawDeposit(c *gin.Context) {
 	depositID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		utils.Logger.Errorf("定期存款ID无效: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "定期存款ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "deposit.invalid_id")})
 		return
 	}
 
@@ -322,7 +322,7 @@ func WithdrawDeposit(c *gin.Context) {
 		var deposit models.Deposit
 		if err := tx.Where("id = ? AND user_id = ? AND status = 'active'", depositID, userID).First(&deposit).Error; err != nil {
 			utils.Logger.Errorf("查询定期存款失败: %v", err)
-			return &utils.AppError{Code: 404, Message: "定期存款不存在或已结束"}
+			return &utils.AppError{Code: 404, Message: "定期存款不存在或已结束", Key: "deposit.not_active"}
 		}
 
 		// 计算当前已经过的时间和进度
@@ -397,10 +397,10 @@ func WithdrawDeposit(c *gin.Context) {
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			utils.Logger.Errorf("提前支取定期存款失败(应用错误): %s", appErr.Message)
-			c.JSON(appErr.Code, gin.H{"success": false, "msg": appErr.Message})
+			c.JSON(appErr.Code, gin.H{"success": false, "msg": appErrorMessage(c, appErr)})
 		} else {
 			utils.Logger.Errorf("提前支取定期存款失败(系统错误): %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "提前支取定期存款失败: " + err.Error()})
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "deposit.withdraw_failed_detail", err.Error())})
 		}
 		return
 	}
@@ -412,7 +412,7 @@ func WithdrawDeposit(c *gin.Context) {
 	utils.Logger.Infof("提前支取定期存款成功: userID=%d, depositID=%d", userID, depositID)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "提前支取定期存款成功",
+		"msg":     tr(c, "deposit.withdrawn"),
 		"data": gin.H{
 			"new_balance": wallet.Balance,
 		},
@@ -22,14 +22,14 @@ func ExportTransactionsCSV(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -69,7 +69,7 @@ func ExportTransactionsCSV(c *gin.Context) {
 	var transactions []models.Transaction
 	if err := query.Order("created_at DESC").Find(&transactions).Error; err != nil {
 		utils.Logger.Errorf("查询交易记录失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "查询交易记录失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.transactions_query_failed")})
 		return
 	}
 
@@ -81,7 +81,7 @@ func ExportTransactionsCSV(c *gin.Context) {
 	file, err := os.Create(filePath)
 	if err != nil {
 		utils.Logger.Errorf("创建CSV文件失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "创建CSV文件失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "export.csv_create_failed")})
 		return
 	}
 	defer file.Close()
@@ -97,7 +97,7 @@ func ExportTransactionsCSV(c *gin.Context) {
 	headers := []string{"交易ID", "交易类型", "金额", "余额", "描述", "状态", "交易时间"}
 	if err := writer.Write(headers); err != nil {
 		utils.Logger.Errorf("写入CSV头失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "写入CSV头失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "export.csv_header_failed")})
 		return
 	}
 
@@ -149,7 +149,7 @@ func ExportTransactionsCSV(c *gin.Context) {
 
 		if err := writer.Write(record); err != nil {
 			utils.Logger.Errorf("写入CSV记录失败: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "写入CSV记录失败"})
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "export.csv_row_failed")})
 			return
 		}
 	}
@@ -177,14 +177,14 @@ func ExportTransactionsJSON(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -224,7 +224,7 @@ func ExportTransactionsJSON(c *gin.Context) {
 	var transactions []models.Transaction
 	if err := query.Order("created_at DESC").Find(&transactions).Error; err != nil {
 		utils.Logger.Errorf("查询交易记录失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "查询交易记录失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.transactions_query_failed")})
 		return
 	}
 
@@ -236,7 +236,7 @@ func ExportTransactionsJSON(c *gin.Context) {
 	file, err := os.Create(filePath)
 	if err != nil {
 		utils.Logger.Errorf("创建JSON文件失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "创建JSON文件失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "export.json_create_failed")})
 		return
 	}
 	defer file.Close()
@@ -245,14 +245,14 @@ func ExportTransactionsJSON(c *gin.Context) {
 	jsonData, err := json.MarshalIndent(transactions, "", "  ")
 	if err != nil {
 		utils.Logger.Errorf("转换JSON失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "转换JSON失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "export.json_encode_failed")})
 		return
 	}
 
 	// 写入JSON文件
 	if _, err := file.Write(jsonData); err != nil {
 		utils.Logger.Errorf("写入JSON文件失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "写入JSON文件失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "export.json_write_failed")})
 		return
 	}
 
@@ -279,14 +279,14 @@ func ExportMonthlyStatement(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -297,14 +297,14 @@ func ExportMonthlyStatement(c *gin.Context) {
 	year, err := strconv.Atoi(yearStr)
 	if err != nil {
 		utils.Logger.Errorf("年份参数无效: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "年份参数无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "export.invalid_year")})
 		return
 	}
 
 	month, err := strconv.Atoi(monthStr)
 	if err != nil || month < 1 || month > 12 {
 		utils.Logger.Errorf("月份参数无效: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "月份参数无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "export.invalid_month")})
 		return
 	}
 
@@ -320,7 +320,7 @@ func ExportMonthlyStatement(c *gin.Context) {
 	var user models.User
 	if err := db.Select("id, account, nickname").Where("id = ?", userID).First(&user).Error; err != nil {
 		utils.Logger.Errorf("查询用户信息失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "查询用户信息失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "user.query_failed")})
 		return
 	}
 
@@ -328,7 +328,7 @@ func ExportMonthlyStatement(c *gin.Context) {
 	var wallet models.Wallet
 	if err := db.Where("user_id = ?", userID).First(&wallet).Error; err != nil {
 		utils.Logger.Errorf("查询钱包信息失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "查询钱包信息失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.query_failed")})
 		return
 	}
 
@@ -353,7 +353,7 @@ func ExportMonthlyStatement(c *gin.Context) {
 	if err := db.Where("user_id = ? AND created_at >= ? AND created_at <= ?", userID, startTime, endTime).
 		Order("created_at").Find(&transactions).Error; err != nil {
 		utils.Logger.Errorf("查询交易记录失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "查询交易记录失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.transactions_query_failed")})
 		return
 	}
 
@@ -382,7 +382,7 @@ func ExportMonthlyStatement(c *gin.Context) {
 	file, err := os.Create(filePath)
 	if err != nil {
 		utils.Logger.Errorf("创建CSV文件失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "创建CSV文件失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "export.csv_create_failed")})
 		return
 	}
 	defer file.Close()
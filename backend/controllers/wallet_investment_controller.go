@@ -48,14 +48,14 @@ func GetInvestments(c *gin.Context) {
 	var investments []models.Investment
 	if err := query.Order("created_at DESC").Find(&investments).Error; err != nil {
 		utils.Logger.Errorf("查询理财产品失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "查询理财产品失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "investment.product_query_failed")})
 		return
 	}
 
 	utils.Logger.Infof("获取到理财产品列表: 数量=%d", len(investments))
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取理财产品列表成功",
+		"msg":     tr(c, "investment.products_loaded"),
 		"data": gin.H{
 			"investments": investments,
 		},
@@ -68,7 +68,7 @@ func GetInvestmentDetail(c *gin.Context) {
 	investmentID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		utils.Logger.Errorf("理财产品ID无效: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "理财产品ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "investment.invalid_product_id")})
 		return
 	}
 
@@ -80,7 +80,7 @@ func GetInvestmentDetail(c *gin.Context) {
 	var investment models.Investment
 	if err := db.Where("id = ?", investmentID).First(&investment).Error; err != nil {
 		utils.Logger.Errorf("查询理财产品失败: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "理财产品不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "investment.product_not_found")})
 		return
 	}
 
@@ -103,7 +103,7 @@ func GetInvestmentDetail(c *gin.Context) {
 		investmentID, investorCount, totalInvestment)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取理财产品详情成功",
+		"msg":     tr(c, "investment.product_loaded"),
 		"data": gin.H{
 			"investment":         investment,
 			"investor_count":     investorCount,
@@ -121,7 +121,7 @@ func PurchaseInvestment(c *gin.Context) {
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.Logger.Errorf("购买理财产品参数错误: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
@@ -129,14 +129,14 @@ func PurchaseInvestment(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -151,21 +151,21 @@ func PurchaseInvestment(c *gin.Context) {
 		var investment models.Investment
 		if err := tx.Where("id = ? AND status = 'available'", req.InvestmentID).First(&investment).Error; err != nil {
 			utils.Logger.Errorf("查询理财产品失败: %v", err)
-			return &utils.AppError{Code: 404, Message: "理财产品不存在或已售罄"}
+			return &utils.AppError{Code: 404, Message: "理财产品不存在或已售罄", Key: "investment.product_unavailable"}
 		}
 
 		// 检查投资金额是否满足最低要求
 		if req.Amount < investment.MinInvestment {
 			utils.Logger.Errorf("投资金额不满足最低要求: 最低要求=%f, 实际投资=%f", 
 				investment.MinInvestment, req.Amount)
-			return &utils.AppError{Code: 400, Message: "投资金额不满足最低要求"}
+			return &utils.AppError{Code: 400, Message: "投资金额不满足最低要求", Key: "investment.below_minimum"}
 		}
 
 		// 检查投资金额是否超过可投资金额
 		if req.Amount > investment.AvailableAmount {
 			utils.Logger.Errorf("投资金额超过可投资金额: 可投资金额=%f, 实际投资=%f", 
 				investment.AvailableAmount, req.Amount)
-			return &utils.AppError{Code: 400, Message: "投资金额超过可投资金额"}
+			return &utils.AppError{Code: 400, Message: "投资金额超过可投资金额", Key: "investment.above_available"}
 		}
 
 		// 查询钱包
@@ -178,7 +178,7 @@ func PurchaseInvestment(c *gin.Context) {
 		// 检查钱包余额是否足够
 		if wallet.Balance < req.Amount {
 			utils.Logger.Errorf("余额不足: 当前余额=%f, 投资金额=%f", wallet.Balance, req.Amount)
-			return &utils.AppError{Code: 400, Message: "余额不足"}
+			return &utils.AppError{Code: 400, Message: "余额不足", Key: "wallet.insufficient_balance"}
 		}
 
 		now := time.Now().Unix()
@@ -253,10 +253,10 @@ func PurchaseInvestment(c *gin.Context) {
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			utils.Logger.Errorf("购买理财产品失败(应用错误): %s", appErr.Message)
-			c.JSON(appErr.Code, gin.H{"success": false, "msg": appErr.Message})
+			c.JSON(appErr.Code, gin.H{"success": false, "msg": appErrorMessage(c, appErr)})
 		} else {
 			utils.Logger.Errorf("购买理财产品失败(系统错误): %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "购买理财产品失败: " + err.Error()})
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "investment.purchase_failed_detail", err.Error())})
 		}
 		return
 	}
@@ -269,7 +269,7 @@ func PurchaseInvestment(c *gin.Context) {
 		userID, req.InvestmentID, req.Amount)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "购买理财产品成功",
+		"msg":     tr(c, "investment.purchased"),
 		"data": gin.H{
 			"new_balance": wallet.Balance,
 		},
@@ -282,14 +282,14 @@ func GetUserInvestments(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -312,7 +312,7 @@ func GetUserInvestments(c *gin.Context) {
 	var userInvestments []models.UserInvestment
 	if err := query.Order("created_at DESC").Find(&userInvestments).Error; err != nil {
 		utils.Logger.Errorf("查询用户投资失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "查询用户投资失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "investment.query_failed")})
 		return
 	}
 
@@ -357,7 +357,7 @@ func GetUserInvestments(c *gin.Context) {
 	utils.Logger.Infof("获取到用户投资列表: userID=%d, 数量=%d", userID, len(userInvestments))
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取用户投资列表成功",
+		"msg":     tr(c, "investment.list_loaded"),
 		"data": gin.H{
 			"investments":      investmentsWithDetails,
 			"total_investment": totalInvestment,
@@ -372,14 +372,14 @@ func GetUserInvestmentDetail(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -387,7 +387,7 @@ func GetUserInvestmentDetail(c *gin.Context) {
 	userInvestmentID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		utils.Logger.Errorf("用户投资ID无效: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户投资ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "investment.invalid_id")})
 		return
 	}
 
@@ -399,7 +399,7 @@ func GetUserInvestmentDetail(c *gin.Context) {
 	var userInvestment models.UserInvestment
 	if err := db.Where("id = ? AND user_id = ?", userInvestmentID, userID).First(&userInvestment).Error; err != nil {
 		utils.Logger.Errorf("查询用户投资失败: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "用户投资不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "investment.not_found")})
 		return
 	}
 
@@ -407,7 +407,7 @@ func GetUserInvestmentDetail(c *gin.Context) {
 	var investment models.Investment
 	if err := db.Where("id = ?", userInvestment.InvestmentID).First(&investment).Error; err != nil {
 		utils.Logger.Errorf("查询理财产品失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "查询理财产品失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "investment.product_query_failed")})
 		return
 	}
 
@@ -450,7 +450,7 @@ func GetUserInvestmentDetail(c *gin.Context) {
 		userID, userInvestmentID, progress, expectedProfit)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取用户投资详情成功",
+		"msg":     tr(c, "investment.detail_loaded"),
 		"data": gin.H{
 			"user_investment": userInvestment,
 			"investment":      investment,
@@ -467,14 +467,14 @@ func RedeemInvestment(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -482,7 +482,7 @@ func RedeemInvestment(c *gin.Context) {
 	userInvestmentID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		utils.Logger.Errorf("用户投资ID无效: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户投资ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "investment.invalid_id")})
 		return
 	}
 
@@ -496,7 +496,7 @@ func RedeemInvestment(c *gin.Context) {
 		var userInvestment models.UserInvestment
 		if err := tx.Where("id = ? AND user_id = ? AND status = 'active'", userInvestmentID, userID).First(&userInvestment).Error; err != nil {
 			utils.Logger.Errorf("查询用户投资失败: %v", err)
-			return &utils.AppError{Code: 404, Message: "用户投资不存在或已赎回"}
+			return &utils.AppError{Code: 404, Message: "用户投资不存在或已赎回", Key: "investment.not_active"}
 		}
 
 		// 查询理财产品
@@ -599,10 +599,10 @@ func RedeemInvestment(c *gin.Context) {
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			utils.Logger.Errorf("赎回投资失败(应用错误): %s", appErr.Message)
-			c.JSON(appErr.Code, gin.H{"success": false, "msg": appErr.Message})
+			c.JSON(appErr.Code, gin.H{"success": false, "msg": appErrorMessage(c, appErr)})
 		} else {
 			utils.Logger.Errorf("赎回投资失败(系统错误): %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "赎回投资失败: " + err.Error()})
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "investment.redeem_failed_detail", err.Error())})
 		}
 		return
 	}
@@ -614,7 +614,7 @@ func RedeemInvestment(c *gin.Context) {
 	utils.Logger.Infof("赎回投资成功: userID=%d, userInvestmentID=%d", userID, userInvestmentID)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "赎回投资成功",
+		"msg":     tr(c, "investment.redeemed"),
 		"data": gin.H{
 			"new_balance": wallet.Balance,
 		},
@@ -635,7 +635,7 @@ func GetInvestmentTypes(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取投资类型成功",
+		"msg":     tr(c, "investment.types_loaded"),
 		"data": gin.H{
 			"types": types,
 		},
@@ -63,14 +63,14 @@ func GetTransactionNotifications(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -115,7 +115,7 @@ func GetTransactionNotifications(c *gin.Context) {
 	utils.Logger.Infof("获取到交易通知: userID=%d, 总数=%d, 本页数量=%d", userID, total, len(notifications))
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取交易通知成功",
+		"msg":     tr(c, "wallet_notification.list_loaded"),
 		"data": gin.H{
 			"total":         total,
 			"page":          page,
@@ -131,14 +131,14 @@ func MarkNotificationRead(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -146,7 +146,7 @@ func MarkNotificationRead(c *gin.Context) {
 	notificationID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		utils.Logger.Errorf("通知ID无效: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "通知ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "wallet_notification.invalid_id")})
 		return
 	}
 
@@ -158,7 +158,7 @@ func MarkNotificationRead(c *gin.Context) {
 	var notification models.Notification
 	if err := db.Where("id = ? AND user_id = ?", notificationID, userID).First(&notification).Error; err != nil {
 		utils.Logger.Errorf("查询通知失败: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "通知不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "wallet_notification.not_found")})
 		return
 	}
 
@@ -167,14 +167,14 @@ func MarkNotificationRead(c *gin.Context) {
 	notification.UpdatedAt = time.Now().Unix()
 	if err := db.Save(&notification).Error; err != nil {
 		utils.Logger.Errorf("更新通知状态失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "更新通知状态失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet_notification.update_failed")})
 		return
 	}
 
 	utils.Logger.Infof("标记通知为已读成功: userID=%d, notificationID=%d", userID, notificationID)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "标记通知为已读成功",
+		"msg":     tr(c, "wallet_notification.marked_read"),
 	})
 }
 
@@ -184,14 +184,14 @@ func MarkAllNotificationsRead(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -210,14 +210,14 @@ func MarkAllNotificationsRead(c *gin.Context) {
 
 	if result.Error != nil {
 		utils.Logger.Errorf("更新通知状态失败: %v", result.Error)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "更新通知状态失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet_notification.update_failed")})
 		return
 	}
 
 	utils.Logger.Infof("标记所有通知为已读成功: userID=%d, 更新数量=%d", userID, result.RowsAffected)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "标记所有通知为已读成功",
+		"msg":     tr(c, "wallet_notification.all_marked_read"),
 		"data": gin.H{
 			"updated_count": result.RowsAffected,
 		},
@@ -230,14 +230,14 @@ func GetUnreadNotificationCount(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -254,7 +254,7 @@ func GetUnreadNotificationCount(c *gin.Context) {
 	utils.Logger.Infof("获取到未读通知数量: userID=%d, count=%d", userID, count)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取未读通知数量成功",
+		"msg":     tr(c, "wallet_notification.unread_count_loaded"),
 		"data": gin.H{
 			"unread_count": count,
 		},
@@ -14,18 +14,19 @@ import (
 // 设置支付密码
 func SetPayPassword(c *gin.Context) {
 	var req struct {
-		Password string `json:"password"`
+		Password      string `json:"password"`
+		TwoFactorCode string `json:"two_factor_code"` // 开启两步验证后必填
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.Logger.Errorf("设置支付密码参数错误: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 验证密码强度
 	if len(req.Password) < 6 {
 		utils.Logger.Errorf("支付密码长度不足: %d", len(req.Password))
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "支付密码长度不能少于6位"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "wallet.pay_password_too_short")})
 		return
 	}
 
@@ -33,14 +34,14 @@ func SetPayPassword(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -48,12 +49,17 @@ func SetPayPassword(c *gin.Context) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		utils.Logger.Errorf("密码加密失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "密码加密失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "password.hash_failed")})
 		return
 	}
 
 	db := c.MustGet("db").(*gorm.DB)
 
+	// 开启了两步验证的用户需要验证码
+	if !checkTwoFactor(c, db, userID, req.TwoFactorCode) {
+		return
+	}
+
 	// 查询或创建钱包
 	var wallet models.Wallet
 	result := db.Where("user_id = ?", userID).First(&wallet)
@@ -72,7 +78,7 @@ func SetPayPassword(c *gin.Context) {
 		}
 		if err := db.Create(&wallet).Error; err != nil {
 			utils.Logger.Errorf("创建钱包失败: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "创建钱包失败"})
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.create_failed")})
 			return
 		}
 	} else {
@@ -82,14 +88,14 @@ func SetPayPassword(c *gin.Context) {
 		wallet.UpdatedAt = time.Now().Unix()
 		if err := db.Save(&wallet).Error; err != nil {
 			utils.Logger.Errorf("更新支付密码失败: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "更新支付密码失败"})
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.pay_password_update_failed")})
 			return
 		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "支付密码设置成功",
+		"msg":     tr(c, "wallet.pay_password_set"),
 	})
 }
 
@@ -100,7 +106,7 @@ func VerifyPayPassword(c *gin.Context) {
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.Logger.Errorf("验证支付密码参数错误: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
@@ -108,14 +114,14 @@ func VerifyPayPassword(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -125,14 +131,14 @@ func VerifyPayPassword(c *gin.Context) {
 	var wallet models.Wallet
 	if err := db.Where("user_id = ?", userID).First(&wallet).Error; err != nil {
 		utils.Logger.Errorf("查询钱包失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "查询钱包失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.query_failed")})
 		return
 	}
 
 	// 检查是否已设置支付密码
 	if !wallet.PayPasswordSet {
 		utils.Logger.Errorf("用户未设置支付密码: 用户ID=%d", userID)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "请先设置支付密码"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "wallet.pay_password_not_set")})
 		return
 	}
 
@@ -140,32 +146,33 @@ func VerifyPayPassword(c *gin.Context) {
 	err := bcrypt.CompareHashAndPassword([]byte(wallet.PayPassword), []byte(req.Password))
 	if err != nil {
 		utils.Logger.Errorf("支付密码验证失败: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "支付密码错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "wallet.pay_password_invalid")})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "支付密码验证成功",
+		"msg":     tr(c, "wallet.pay_password_verified"),
 	})
 }
 
 // 修改支付密码
 func UpdatePayPassword(c *gin.Context) {
 	var req struct {
-		OldPassword string `json:"old_password"`
-		NewPassword string `json:"new_password"`
+		OldPassword   string `json:"old_password"`
+		NewPassword   string `json:"new_password"`
+		TwoFactorCode string `json:"two_factor_code"` // 开启两步验证后必填
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.Logger.Errorf("修改支付密码参数错误: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 验证新密码强度
 	if len(req.NewPassword) < 6 {
 		utils.Logger.Errorf("新支付密码长度不足: %d", len(req.NewPassword))
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "支付密码长度不能少于6位"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "wallet.pay_password_too_short")})
 		return
 	}
 
@@ -173,14 +180,14 @@ func UpdatePayPassword(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -190,14 +197,14 @@ func UpdatePayPassword(c *gin.Context) {
 	var wallet models.Wallet
 	if err := db.Where("user_id = ?", userID).First(&wallet).Error; err != nil {
 		utils.Logger.Errorf("查询钱包失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "查询钱包失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.query_failed")})
 		return
 	}
 
 	// 检查是否已设置支付密码
 	if !wallet.PayPasswordSet {
 		utils.Logger.Errorf("用户未设置支付密码: 用户ID=%d", userID)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "请先设置支付密码"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "wallet.pay_password_not_set")})
 		return
 	}
 
@@ -205,7 +212,12 @@ func UpdatePayPassword(c *gin.Context) {
 	err := bcrypt.CompareHashAndPassword([]byte(wallet.PayPassword), []byte(req.OldPassword))
 	if err != nil {
 		utils.Logger.Errorf("旧支付密码验证失败: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "旧支付密码错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "wallet.old_pay_password_invalid")})
+		return
+	}
+
+	// 开启了两步验证的用户需要验证码
+	if !checkTwoFactor(c, db, userID, req.TwoFactorCode) {
 		return
 	}
 
@@ -213,7 +225,7 @@ func UpdatePayPassword(c *gin.Context) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
 	if err != nil {
 		utils.Logger.Errorf("新密码加密失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "密码加密失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "password.hash_failed")})
 		return
 	}
 
@@ -222,13 +234,13 @@ func UpdatePayPassword(c *gin.Context) {
 	wallet.UpdatedAt = time.Now().Unix()
 	if err := db.Save(&wallet).Error; err != nil {
 		utils.Logger.Errorf("更新支付密码失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "更新支付密码失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.pay_password_update_failed")})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "支付密码修改成功",
+		"msg":     tr(c, "wallet.pay_password_changed"),
 	})
 }
 
@@ -239,14 +251,14 @@ func SetSecurityLevel(c *gin.Context) {
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.Logger.Errorf("设置安全等级参数错误: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 验证安全等级
 	if req.SecurityLevel < 1 || req.SecurityLevel > 3 {
 		utils.Logger.Errorf("安全等级无效: %d", req.SecurityLevel)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "安全等级无效，应为1-3"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "wallet.invalid_security_level")})
 		return
 	}
 
@@ -254,14 +266,14 @@ func SetSecurityLevel(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -272,7 +284,7 @@ func SetSecurityLevel(c *gin.Context) {
 	result := db.Where("user_id = ?", userID).First(&wallet)
 	if result.Error != nil {
 		utils.Logger.Errorf("查询钱包失败: %v", result.Error)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "查询钱包失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.query_failed")})
 		return
 	}
 
@@ -281,13 +293,13 @@ func SetSecurityLevel(c *gin.Context) {
 	wallet.UpdatedAt = time.Now().Unix()
 	if err := db.Save(&wallet).Error; err != nil {
 		utils.Logger.Errorf("更新安全等级失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "更新安全等级失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.security_level_update_failed")})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "安全等级设置成功",
+		"msg":     tr(c, "wallet.security_level_set"),
 	})
 }
 
@@ -298,14 +310,14 @@ func SetDailyLimit(c *gin.Context) {
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.Logger.Errorf("设置每日交易限额参数错误: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 验证限额
 	if req.DailyLimit <= 0 {
 		utils.Logger.Errorf("每日交易限额无效: %f", req.DailyLimit)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "每日交易限额必须大于0"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "wallet.invalid_daily_limit")})
 		return
 	}
 
@@ -313,14 +325,14 @@ func SetDailyLimit(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -331,7 +343,7 @@ func SetDailyLimit(c *gin.Context) {
 	result := db.Where("user_id = ?", userID).First(&wallet)
 	if result.Error != nil {
 		utils.Logger.Errorf("查询钱包失败: %v", result.Error)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "查询钱包失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.query_failed")})
 		return
 	}
 
@@ -340,13 +352,13 @@ func SetDailyLimit(c *gin.Context) {
 	wallet.UpdatedAt = time.Now().Unix()
 	if err := db.Save(&wallet).Error; err != nil {
 		utils.Logger.Errorf("更新每日交易限额失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "更新每日交易限额失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.daily_limit_update_failed")})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "每日交易限额设置成功",
+		"msg":     tr(c, "wallet.daily_limit_set"),
 	})
 }
 
@@ -356,14 +368,14 @@ func GetWalletSecurity(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -374,13 +386,13 @@ func GetWalletSecurity(c *gin.Context) {
 	result := db.Where("user_id = ?", userID).First(&wallet)
 	if result.Error != nil {
 		utils.Logger.Errorf("查询钱包失败: %v", result.Error)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "查询钱包失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.query_failed")})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取钱包安全设置成功",
+		"msg":     tr(c, "wallet.security_loaded"),
 		"data": gin.H{
 			"pay_password_set": wallet.PayPasswordSet,
 			"security_level":   wallet.SecurityLevel,
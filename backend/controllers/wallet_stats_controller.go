@@ -16,14 +16,14 @@ func GetWalletOverview(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -35,7 +35,7 @@ func GetWalletOverview(c *gin.Context) {
 	var wallet models.Wallet
 	if err := db.Where("user_id = ?", userID).First(&wallet).Error; err != nil {
 		utils.Logger.Errorf("查询钱包失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "查询钱包失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.query_failed")})
 		return
 	}
 
@@ -99,7 +99,7 @@ func GetWalletOverview(c *gin.Context) {
 		userID, wallet.Balance, todayIncome, todayExpense, monthIncome, monthExpense)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取钱包概览成功",
+		"msg":     tr(c, "wallet_stats.overview_loaded"),
 		"data": gin.H{
 			"balance":             wallet.Balance,
 			"today_income":        todayIncome,
@@ -121,14 +121,14 @@ func GetIncomeTrend(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -193,7 +193,7 @@ func GetIncomeTrend(c *gin.Context) {
 	utils.Logger.Infof("获取到收支趋势: userID=%d, 数据点数量=%d", userID, len(trends))
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取收支趋势成功",
+		"msg":     tr(c, "wallet_stats.trend_loaded"),
 		"data": gin.H{
 			"period":     period,
 			"group_by":   groupBy,
@@ -210,14 +210,14 @@ func GetCategoryStats(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -281,7 +281,7 @@ func GetCategoryStats(c *gin.Context) {
 	utils.Logger.Infof("获取到收支分类统计: userID=%d, 分类数量=%d", userID, len(typeStats))
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取收支分类统计成功",
+		"msg":     tr(c, "wallet_stats.categories_loaded"),
 		"data": gin.H{
 			"period":     period,
 			"direction":  direction,
@@ -298,14 +298,14 @@ func GetWalletHealth(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -317,7 +317,7 @@ func GetWalletHealth(c *gin.Context) {
 	var wallet models.Wallet
 	if err := db.Where("user_id = ?", userID).First(&wallet).Error; err != nil {
 		utils.Logger.Errorf("查询钱包失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "查询钱包失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "wallet.query_failed")})
 		return
 	}
 
@@ -449,7 +449,7 @@ func GetWalletHealth(c *gin.Context) {
 	utils.Logger.Infof("获取到钱包健康度: userID=%d, 安全得分=%d, 消费健康得分=%d", userID, securityScore, consumptionScore)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取钱包健康度成功",
+		"msg":     tr(c, "wallet_stats.health_loaded"),
 		"data": gin.H{
 			"security_score":       securityScore,
 			"consumption_score":    consumptionScore,
@@ -17,14 +17,14 @@ func GetTransactionsAdvanced(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -106,7 +106,7 @@ func GetTransactionsAdvanced(c *gin.Context) {
 	utils.Logger.Infof("获取到交易记录(高级筛选): userID=%d, 总数=%d, 本页数量=%d", userID, total, len(transactions))
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取交易记录成功",
+		"msg":     tr(c, "wallet.transactions_loaded"),
 		"data": gin.H{
 			"total":        total,
 			"page":         page,
@@ -122,14 +122,14 @@ func GetTransactionStats(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -207,7 +207,7 @@ func GetTransactionStats(c *gin.Context) {
 	utils.Logger.Infof("获取到交易统计: userID=%d, 总收入=%f, 总支出=%f", userID, totalIncome, totalExpense)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取交易统计成功",
+		"msg":     tr(c, "wallet_stats.transactions_loaded"),
 		"data": gin.H{
 			"period":        period,
 			"start_time":    startTime,
@@ -226,14 +226,14 @@ func GetTransactionDetail(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
 	if !exists {
 		utils.Logger.Errorf("用户ID不存在")
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "请先登录"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.login_required")})
 		return
 	}
 	
 	userID, ok := userIDStr.(uint)
 	if !ok {
 		utils.Logger.Errorf("用户ID类型转换失败: %v, 类型=%T", userIDStr, userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "用户ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id")})
 		return
 	}
 
@@ -241,7 +241,7 @@ func GetTransactionDetail(c *gin.Context) {
 	transactionID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		utils.Logger.Errorf("交易ID无效: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "交易ID无效"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "wallet.invalid_transaction_id")})
 		return
 	}
 
@@ -253,7 +253,7 @@ func GetTransactionDetail(c *gin.Context) {
 	var transaction models.Transaction
 	if err := db.Where("id = ? AND user_id = ?", transactionID, userID).First(&transaction).Error; err != nil {
 		utils.Logger.Errorf("查询交易记录失败: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "交易记录不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "wallet.transaction_not_found")})
 		return
 	}
 
@@ -330,7 +330,7 @@ func GetTransactionDetail(c *gin.Context) {
 	utils.Logger.Infof("获取到交易详情: userID=%d, transactionID=%d, type=%s", userID, transactionID, transaction.Type)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "获取交易详情成功",
+		"msg":     tr(c, "wallet.transaction_loaded"),
 		"data": gin.H{
 			"transaction": transaction,
 			"related":     relatedData,
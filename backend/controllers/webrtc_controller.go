@@ -16,7 +16,7 @@ func WebRTCSignal(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -28,14 +28,14 @@ func WebRTCSignal(c *gin.Context) {
 		CallType string `json:"call_type"` // video/voice
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 检查接收者是否存在
 	var receiver models.User
 	if err := utils.DB.First(&receiver, req.To).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "接收者不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "chat.receiver_not_found")})
 		return
 	}
 
@@ -43,7 +43,7 @@ func WebRTCSignal(c *gin.Context) {
 	var friendship models.Friend
 	if err := utils.DB.Where("(user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)",
 		userID, req.To, req.To, userID).First(&friendship).Error; err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": "对方不是您的好友"})
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": tr(c, "friend.not_friend")})
 		return
 	}
 
@@ -60,7 +60,7 @@ func WebRTCSignal(c *gin.Context) {
 	// 将信令消息转换为JSON
 	signalJSON, err := json.Marshal(signalMessage)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "信令处理失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "webrtc.signal_failed")})
 		return
 	}
 
@@ -87,7 +87,7 @@ func WebRTCSignal(c *gin.Context) {
 				// 记录失败不影响信令发送
 				c.JSON(http.StatusOK, gin.H{
 					"success": true,
-					"msg":     "信令已发送，但通话记录创建失败",
+					"msg":     tr(c, "webrtc.signal_sent_record_failed"),
 					"signal":  string(signalJSON),
 				})
 				return
@@ -104,7 +104,7 @@ func WebRTCSignal(c *gin.Context) {
 				// 记录失败不影响信令发送
 				c.JSON(http.StatusOK, gin.H{
 					"success": true,
-					"msg":     "信令已发送，但通话记录创建失败",
+					"msg":     tr(c, "webrtc.signal_sent_record_failed"),
 					"signal":  string(signalJSON),
 				})
 				return
@@ -114,7 +114,7 @@ func WebRTCSignal(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "信令已发送",
+		"msg":     tr(c, "webrtc.signal_sent"),
 		"signal":  string(signalJSON),
 	})
 }
@@ -124,7 +124,7 @@ func StartVideoCallWebRTC(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -133,14 +133,14 @@ func StartVideoCallWebRTC(c *gin.Context) {
 		ReceiverID uint `json:"receiver_id" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 检查接收者是否存在
 	var receiver models.User
 	if err := utils.DB.First(&receiver, req.ReceiverID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "接收者不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "chat.receiver_not_found")})
 		return
 	}
 
@@ -148,7 +148,7 @@ func StartVideoCallWebRTC(c *gin.Context) {
 	var friendship models.Friend
 	if err := utils.DB.Where("(user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)",
 		userID, req.ReceiverID, req.ReceiverID, userID).First(&friendship).Error; err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": "对方不是您的好友"})
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": tr(c, "friend.not_friend")})
 		return
 	}
 
@@ -161,7 +161,7 @@ func StartVideoCallWebRTC(c *gin.Context) {
 	}
 
 	if err := utils.DB.Create(&videoCall).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "创建通话记录失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "call.create_failed")})
 		return
 	}
 
@@ -174,7 +174,7 @@ func StartVideoCallWebRTC(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "视频通话已发起",
+		"msg":     tr(c, "webrtc.video_started"),
 		"data": gin.H{
 			"call_id":     videoCall.ID,
 			"caller_id":   videoCall.CallerID,
@@ -190,7 +190,7 @@ func EndVideoCallWebRTC(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -199,20 +199,20 @@ func EndVideoCallWebRTC(c *gin.Context) {
 		CallID uint `json:"call_id" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 查询通话记录
 	var videoCall models.VideoCallRecord
 	if err := utils.DB.First(&videoCall, req.CallID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "通话记录不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "call.not_found")})
 		return
 	}
 
 	// 检查是否是通话参与者
 	if videoCall.CallerID != userID.(uint) && videoCall.ReceiverID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": "您不是该通话的参与者"})
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": tr(c, "webrtc.not_participant")})
 		return
 	}
 
@@ -223,7 +223,7 @@ func EndVideoCallWebRTC(c *gin.Context) {
 	videoCall.Status = 1 // 已接通并结束
 
 	if err := utils.DB.Save(&videoCall).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "更新通话记录失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "call.update_failed")})
 		return
 	}
 
@@ -243,7 +243,7 @@ func EndVideoCallWebRTC(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "视频通话已结束",
+		"msg":     tr(c, "webrtc.video_ended"),
 		"data": gin.H{
 			"call_id":     videoCall.ID,
 			"caller_id":   videoCall.CallerID,
@@ -261,7 +261,7 @@ func RejectVideoCallWebRTC(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -270,20 +270,20 @@ func RejectVideoCallWebRTC(c *gin.Context) {
 		CallID uint `json:"call_id" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 查询通话记录
 	var videoCall models.VideoCallRecord
 	if err := utils.DB.First(&videoCall, req.CallID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "通话记录不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "call.not_found")})
 		return
 	}
 
 	// 检查是否是接收者
 	if videoCall.ReceiverID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": "只有接收者可以拒绝通话"})
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": tr(c, "webrtc.reject_receiver_only")})
 		return
 	}
 
@@ -292,7 +292,7 @@ func RejectVideoCallWebRTC(c *gin.Context) {
 	videoCall.Status = 2 // 已拒绝
 
 	if err := utils.DB.Save(&videoCall).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "更新通话记录失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "call.update_failed")})
 		return
 	}
 
@@ -305,7 +305,7 @@ func RejectVideoCallWebRTC(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "已拒绝视频通话",
+		"msg":     tr(c, "webrtc.video_rejected"),
 		"data": gin.H{
 			"call_id":     videoCall.ID,
 			"caller_id":   videoCall.CallerID,
@@ -320,7 +320,7 @@ func StartVoiceCallWebRTC(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -329,14 +329,14 @@ func StartVoiceCallWebRTC(c *gin.Context) {
 		ReceiverID uint `json:"receiver_id" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 检查接收者是否存在
 	var receiver models.User
 	if err := utils.DB.First(&receiver, req.ReceiverID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "接收者不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "chat.receiver_not_found")})
 		return
 	}
 
@@ -344,7 +344,7 @@ func StartVoiceCallWebRTC(c *gin.Context) {
 	var friendship models.Friend
 	if err := utils.DB.Where("(user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)",
 		userID, req.ReceiverID, req.ReceiverID, userID).First(&friendship).Error; err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": "对方不是您的好友"})
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": tr(c, "friend.not_friend")})
 		return
 	}
 
@@ -357,7 +357,7 @@ func StartVoiceCallWebRTC(c *gin.Context) {
 	}
 
 	if err := utils.DB.Create(&voiceCall).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "创建通话记录失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "call.create_failed")})
 		return
 	}
 
@@ -370,7 +370,7 @@ func StartVoiceCallWebRTC(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "语音通话已发起",
+		"msg":     tr(c, "webrtc.voice_started"),
 		"data": gin.H{
 			"call_id":     voiceCall.ID,
 			"caller_id":   voiceCall.CallerID,
@@ -386,7 +386,7 @@ func EndVoiceCallWebRTC(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -395,20 +395,20 @@ func EndVoiceCallWebRTC(c *gin.Context) {
 		CallID uint `json:"call_id" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 查询通话记录
 	var voiceCall models.VoiceCallRecord
 	if err := utils.DB.First(&voiceCall, req.CallID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "通话记录不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "call.not_found")})
 		return
 	}
 
 	// 检查是否是通话参与者
 	if voiceCall.CallerID != userID.(uint) && voiceCall.ReceiverID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": "您不是该通话的参与者"})
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": tr(c, "webrtc.not_participant")})
 		return
 	}
 
@@ -419,7 +419,7 @@ func EndVoiceCallWebRTC(c *gin.Context) {
 	voiceCall.Status = 1 // 已接通并结束
 
 	if err := utils.DB.Save(&voiceCall).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "更新通话记录失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "call.update_failed")})
 		return
 	}
 
@@ -439,7 +439,7 @@ func EndVoiceCallWebRTC(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "语音通话已结束",
+		"msg":     tr(c, "webrtc.voice_ended"),
 		"data": gin.H{
 			"call_id":     voiceCall.ID,
 			"caller_id":   voiceCall.CallerID,
@@ -457,7 +457,7 @@ func RejectVoiceCallWebRTC(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -466,20 +466,20 @@ func RejectVoiceCallWebRTC(c *gin.Context) {
 		CallID uint `json:"call_id" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_params")})
 		return
 	}
 
 	// 查询通话记录
 	var voiceCall models.VoiceCallRecord
 	if err := utils.DB.First(&voiceCall, req.CallID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": "通话记录不存在"})
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "msg": tr(c, "call.not_found")})
 		return
 	}
 
 	// 检查是否是接收者
 	if voiceCall.ReceiverID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": "只有接收者可以拒绝通话"})
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "msg": tr(c, "webrtc.reject_receiver_only")})
 		return
 	}
 
@@ -488,7 +488,7 @@ func RejectVoiceCallWebRTC(c *gin.Context) {
 	voiceCall.Status = 2 // 已拒绝
 
 	if err := utils.DB.Save(&voiceCall).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "更新通话记录失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "call.update_failed")})
 		return
 	}
 
@@ -501,7 +501,7 @@ func RejectVoiceCallWebRTC(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "已拒绝语音通话",
+		"msg":     tr(c, "webrtc.voice_rejected"),
 		"data": gin.H{
 			"call_id":     voiceCall.ID,
 			"caller_id":   voiceCall.CallerID,
@@ -516,7 +516,7 @@ func GetCallHistoryWebRTC(c *gin.Context) {
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "未授权"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.unauthorized")})
 		return
 	}
 
@@ -547,7 +547,7 @@ func GetCallHistoryWebRTC(c *gin.Context) {
 		var voiceCalls []models.VoiceCallRecord
 		if err := utils.DB.Where("caller_id = ? OR receiver_id = ?", userID, userID).
 			Order("start_time DESC").Limit(limit).Offset(offset).Find(&voiceCalls).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "获取通话历史失败"})
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "webrtc.history_query_failed")})
 			return
 		}
 
@@ -618,7 +618,7 @@ func GetCallHistoryWebRTC(c *gin.Context) {
 		var videoCalls []models.VideoCallRecord
 		if err := utils.DB.Where("caller_id = ? OR receiver_id = ?", userID, userID).
 			Order("start_time DESC").Limit(limit).Offset(offset).Find(&videoCalls).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "获取通话历史失败"})
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": tr(c, "webrtc.history_query_failed")})
 			return
 		}
 
@@ -686,6 +686,6 @@ func GetCallHistoryWebRTC(c *gin.Context) {
 			"data":    result,
 		})
 	} else {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "无效的通话类型，请指定 voice 或 video"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "webrtc.invalid_call_type")})
 	}
 }
@@ -1,8 +1,11 @@
 package controllers
 
 import (
+	"allinone_backend/middleware"
 	"allinone_backend/models"
+	"allinone_backend/services"
 	"allinone_backend/utils"
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
@@ -28,34 +31,34 @@ func HandleWebSocket(c *gin.Context) {
 	// 获取当前用户ID
 	userIDStr, exists := c.GetQuery("user_id")
 	if !exists {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "缺少user_id参数"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.user_id_missing")})
 		return
 	}
 
 	// 获取token
 	token, exists := c.GetQuery("token")
 	if !exists {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "缺少token参数"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "websocket.token_missing")})
 		return
 	}
 
 	// 验证token
 	claims, err := utils.ParseToken(token)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "无效的token"})
+	if err != nil || services.IsTokenRevoked(claims) {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "auth.token_invalid")})
 		return
 	}
 
 	// 验证用户ID
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "无效的user_id"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": tr(c, "common.invalid_user_id_param")})
 		return
 	}
 
 	// 检查token中的用户ID是否与请求中的用户ID一致
 	if claims.UserID != uint(userID) {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": "用户ID与token不匹配"})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "msg": tr(c, "websocket.user_mismatch")})
 		return
 	}
 
@@ -66,29 +69,38 @@ func HandleWebSocket(c *gin.Context) {
 		return
 	}
 
-	// 注册WebSocket连接
-	utils.WebRTCServer.RegisterClient(uint(userID), conn)
+	// 注册WebSocket连接，信令和推送消息使用同一个连接对象，所有写操作共用一个写锁
+	wsConn := utils.GetWebSocketManager().AddConnection(uint(userID), conn)
+	utils.WebRTCServer.RegisterClient(uint(userID), wsConn)
 
 	// 发送欢迎消息
 	welcomeMsg := map[string]interface{}{
 		"type":    "welcome",
-		"message": "已连接到WebRTC信令服务器",
+		"message": tr(c, "websocket.connected"),
 		"user_id": userID,
 	}
-	conn.WriteJSON(welcomeMsg)
+	wsConn.WriteJSON(welcomeMsg)
 
 	// 处理WebSocket连接
-	go handleConnection(conn, uint(userID))
+	go handleConnection(wsConn, uint(userID), middleware.Localizer(c))
 }
 
 // 处理WebSocket连接
-func handleConnection(conn *websocket.Conn, userID uint) {
+func handleConnection(wsConn *utils.WebSocketConnection, userID uint, localizer *utils.Localizer) {
+	conn := wsConn.Conn
+	// 连接关闭时取消该连接上进行中的AI流式对话
+	ctx, cancel := context.WithCancel(context.Background())
 	defer func() {
+		cancel()
 		// 注销WebSocket连接
-		utils.WebRTCServer.UnregisterClient(userID)
+		utils.WebRTCServer.UnregisterClient(userID, wsConn)
+		utils.GetWebSocketManager().RemoveConnection(userID, wsConn)
 		conn.Close()
 	}()
 
+	// AI流式输出在独立的goroutine中写入，通过连接的写锁与其他写操作串行
+	aiStreams := newAIStreamSession(ctx, userID, localizer, wsConn.WriteJSON)
+
 	// 设置读取超时
 	conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 	conn.SetPongHandler(func(string) error {
@@ -103,9 +115,11 @@ func handleConnection(conn *websocket.Conn, userID uint) {
 		for {
 			select {
 			case <-ticker.C:
-				if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				if err := wsConn.WriteMessage(websocket.PingMessage, nil); err != nil {
 					return
 				}
+			case <-ctx.Done():
+				return
 			}
 		}
 	}()
@@ -151,9 +165,15 @@ func handleConnection(conn *websocket.Conn, userID uint) {
 		case "call_ended":
 			// 处理通话结束
 			handleCallEnded(data, userID)
+		case "ai_chat":
+			// AI流式对话
+			aiStreams.start(data)
+		case "ai_chat_cancel":
+			// 取消AI流式对话
+			aiStreams.cancel(data)
 		case "ping":
 			// 处理ping消息
-			conn.WriteJSON(map[string]interface{}{
+			wsConn.WriteJSON(map[string]interface{}{
 				"type": "pong",
 				"time": time.Now().Unix(),
 			})
@@ -1,6 +1,7 @@
 package captcha
 
 import (
+	"allinone_backend/middleware"
 	"allinone_backend/utils"
 	"fmt"
 	"math/rand"
@@ -61,7 +62,13 @@ func GetCaptcha(c *gin.Context) {
 		}
 
 		// 将验证码保存到自定义存储中
-		utils.SaveCaptcha(id, captchaValue)
+		if err := utils.SaveCaptcha(id, captchaValue); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"msg":     middleware.T(c, "verification.generate_failed"),
+			})
+			return
+		}
 
 		// 生成一个简单的验证码图片（使用一个1x1像素的透明图片）
 		b64s := "data:image/gif;base64,R0lGODlhAQABAIAAAAAAAP///yH5BAEAAAAALAAAAAABAAEAAAIBRAA7"
@@ -87,13 +94,19 @@ func GetCaptcha(c *gin.Context) {
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"msg":     "生成验证码失败",
+			"msg":     middleware.T(c, "verification.generate_failed"),
 		})
 		return
 	}
 
 	// 将验证码保存到自定义存储中
-	utils.SaveCaptcha(id, store.Get(id, true))
+	if err := utils.SaveCaptcha(id, store.Get(id, true)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"msg":     middleware.T(c, "verification.generate_failed"),
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -114,7 +127,7 @@ func VerifyCaptchaHandler(c *gin.Context) {
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "请求参数错误",
+			"msg":     middleware.T(c, "common.invalid_request"),
 		})
 		return
 	}
@@ -123,24 +136,12 @@ func VerifyCaptchaHandler(c *gin.Context) {
 	if utils.VerifyCaptcha(req.CaptchaID, req.CaptchaValue) {
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
-			"msg":     "验证码正确",
+			"msg":     middleware.T(c, "captcha.valid"),
 		})
 	} else {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "验证码错误或已过期",
+			"msg":     middleware.T(c, "verification.code_invalid_or_expired"),
 		})
 	}
 }
-
-// 定期清理过期验证码
-func init() {
-	go func() {
-		ticker := time.NewTicker(10 * time.Minute)
-		defer ticker.Stop()
-
-		for range ticker.C {
-			utils.CleanExpiredCaptcha()
-		}
-	}()
-}
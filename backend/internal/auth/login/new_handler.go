@@ -1,25 +1,27 @@
 package login
 
 import (
+	"allinone_backend/middleware"
 	"allinone_backend/models"
+	"allinone_backend/services"
 	"allinone_backend/utils"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type NewLoginRequest struct {
 	Account   string `json:"account"`
 	Password  string `json:"password"`
 	LoginType string `json:"login_type"` // "account", "phone", "email"
+	services.DeviceInfo
 }
 
 // 新的登录处理函数，支持账号、手机号和邮箱登录
 func NewLoginHandler(c *gin.Context) {
 	var req NewLoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": middleware.T(c, "common.invalid_params")})
 		return
 	}
 
@@ -42,25 +44,54 @@ func NewLoginHandler(c *gin.Context) {
 		err = utils.DB.Where("account = ?", req.Account).First(&user).Error
 	}
 
-	// 用户不存在或密码错误
-	if err != nil || bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)) != nil {
-		c.JSON(http.StatusOK, gin.H{"success": false, "msg": "账号或密码错误"})
+	// 验证密码，用户不存在时同样校验；错误次数过多时锁定账号和IP
+	var found *models.User
+	if err == nil {
+		found = &user
+	}
+	if err := services.AuthenticatePassword(utils.DB, found, req.Account, req.Password, c.ClientIP()); err != nil {
+		if appErr, ok := err.(*utils.AppError); ok && appErr != services.ErrInvalidCredentials {
+			c.JSON(appErr.Code, gin.H{"success": false, "msg": middleware.AppErrorMessage(c, appErr)})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": false, "msg": middleware.T(c, "auth.invalid_credentials")})
 		return
 	}
 
-	// 生成JWT
-	token, err := utils.GenerateToken(user.ID, user.Account)
+	// 在登录设备上创建会话，签发访问令牌和刷新令牌
+	req.DeviceInfo.IPAddress = c.ClientIP()
+	req.DeviceInfo.UserAgent = c.Request.UserAgent()
+	tokens, challenge, err := services.BeginLogin(utils.DB, user.ID, user.Account, req.DeviceInfo)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "生成token失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": middleware.T(c, "auth.token_generate_failed")})
+		return
+	}
+
+	// 开启两步验证且在新设备登录，返回临时令牌，提交验证码到 /api/login/2fa 完成登录
+	if challenge != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"msg":     middleware.T(c, "two_factor.code_required"),
+			"data": gin.H{
+				"two_factor_required": true,
+				"two_factor_token":    challenge.Token,
+				"expires_in":          challenge.ExpiresIn,
+			},
+		})
 		return
 	}
 
 	// 返回用户信息
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "登录成功",
+		"msg":     middleware.T(c, "auth.login_success"),
 		"data": gin.H{
-			"token": token,
+			"token":              tokens.AccessToken,
+			"refresh_token":      tokens.RefreshToken,
+			"expires_in":         tokens.ExpiresIn,
+			"refresh_expires_in": tokens.RefreshExpiresIn,
+			"session_id":         tokens.SessionID,
+			"device_id":          tokens.DeviceID,
 			"user": gin.H{
 				"id":              user.ID,
 				"account":         user.Account,
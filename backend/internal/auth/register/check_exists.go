@@ -1,6 +1,7 @@
 package register
 
 import (
+	"allinone_backend/middleware"
 	"allinone_backend/models"
 	"allinone_backend/utils"
 	"net/http"
@@ -51,7 +52,7 @@ func CheckExistsHandler(c *gin.Context) {
 	if req.Type == "" || req.Target == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "参数错误，需要type和target",
+			"msg":     middleware.T(c, "verification.type_target_required"),
 		})
 		return
 	}
@@ -60,7 +61,7 @@ func CheckExistsHandler(c *gin.Context) {
 	if req.Type != "email" && req.Type != "phone" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "类型错误，必须是 email 或 phone",
+			"msg":     middleware.T(c, "verification.invalid_type"),
 		})
 		return
 	}
@@ -69,7 +70,7 @@ func CheckExistsHandler(c *gin.Context) {
 	if req.Type == "email" && !utils.ValidateEmail(req.Target) {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "邮箱格式不正确",
+			"msg":     middleware.T(c, "verification.invalid_email"),
 		})
 		return
 	}
@@ -77,7 +78,7 @@ func CheckExistsHandler(c *gin.Context) {
 	if req.Type == "phone" && !utils.ValidatePhone(req.Target) {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"msg":     "手机号格式不正确",
+			"msg":     middleware.T(c, "verification.invalid_phone"),
 		})
 		return
 	}
@@ -95,7 +96,7 @@ func CheckExistsHandler(c *gin.Context) {
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"msg":     "数据库查询错误",
+			"msg":     middleware.T(c, "common.database_error"),
 		})
 		return
 	}
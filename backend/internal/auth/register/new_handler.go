@@ -1,8 +1,10 @@
 package register
 
 import (
+	"allinone_backend/middleware"
 	"allinone_backend/models"
 	"allinone_backend/utils"
+	"errors"
 	"fmt"
 	"math/rand"
 	"net/http"
@@ -11,7 +13,6 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // 新的注册请求结构
@@ -36,14 +37,14 @@ func NewRegisterHandler(c *gin.Context) {
 	var req NewRegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		// 参数解析失败，返回错误信息
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "参数错误，请检查输入格式"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": middleware.T(c, "common.invalid_input")})
 		return
 	}
 
 	// 验证图形验证码（如果提供了验证码ID和值）
 	if req.CaptchaID != "" && req.CaptchaValue != "" {
 		if !utils.VerifyCaptcha(req.CaptchaID, req.CaptchaValue) {
-			c.JSON(http.StatusOK, gin.H{"success": false, "msg": "图形验证码错误"})
+			c.JSON(http.StatusOK, gin.H{"success": false, "msg": middleware.T(c, "captcha.invalid")})
 			return
 		}
 	}
@@ -73,13 +74,9 @@ func NewRegisterHandler(c *gin.Context) {
 		// 如果是邮箱或手机号注册，验证验证码
 		if (registerType == "email" || registerType == "phone") && target != "" {
 			codeKey := fmt.Sprintf("%s:%s", registerType, target)
-			if !utils.VerifyCode(codeKey, req.VerificationCode) {
-				// 在开发环境中，如果验证码是123456，直接通过
-				if req.VerificationCode != "123456" {
-					c.JSON(http.StatusOK, gin.H{"success": false, "msg": "验证码错误或已过期"})
-					return
-				}
-				fmt.Println("开发环境 - 使用默认验证码123456")
+			if !utils.VerifyCode(utils.CodePurposeRegister, codeKey, req.VerificationCode) {
+				c.JSON(http.StatusOK, gin.H{"success": false, "msg": middleware.T(c, "verification.code_invalid_or_expired")})
+				return
 			}
 		}
 	}
@@ -90,19 +87,19 @@ func NewRegisterHandler(c *gin.Context) {
 		if req.RegisterType == "" {
 			req.RegisterType = "account"
 		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "注册类型错误，必须是email、phone或account"})
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": middleware.T(c, "user.invalid_register_type")})
 			return
 		}
 	}
 
 	// 根据注册类型验证参数
 	if req.RegisterType == "email" && req.Email == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "邮箱不能为空"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": middleware.T(c, "verification.email_required")})
 		return
 	}
 
 	if req.RegisterType == "phone" && req.Phone == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "手机号不能为空"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": middleware.T(c, "verification.phone_required")})
 		return
 	}
 
@@ -111,35 +108,45 @@ func NewRegisterHandler(c *gin.Context) {
 	if req.RegisterType == "email" {
 		err := utils.DB.Model(&models.User{}).Where("email = ?", req.Email).Count(&count).Error
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "数据库错误"})
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": middleware.T(c, "common.database_error")})
 			return
 		}
 		if count > 0 {
-			c.JSON(http.StatusOK, gin.H{"success": false, "msg": "邮箱已被注册"})
+			c.JSON(http.StatusOK, gin.H{"success": false, "msg": middleware.T(c, "user.email_taken")})
 			return
 		}
 	} else {
 		err := utils.DB.Model(&models.User{}).Where("phone = ?", req.Phone).Count(&count).Error
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "数据库错误"})
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": middleware.T(c, "common.database_error")})
 			return
 		}
 		if count > 0 {
-			c.JSON(http.StatusOK, gin.H{"success": false, "msg": "手机号已被注册"})
+			c.JSON(http.StatusOK, gin.H{"success": false, "msg": middleware.T(c, "user.phone_taken")})
 			return
 		}
 	}
 
+	// 检查密码强度
+	if err := utils.ValidatePasswordStrength(req.Password, ""); err != nil {
+		msg := err.Error()
+		if appErr, ok := err.(*utils.AppError); ok {
+			msg = middleware.AppErrorMessage(c, appErr)
+		}
+		c.JSON(http.StatusOK, gin.H{"success": false, "msg": msg})
+		return
+	}
+
 	// 密码加密
-	hashedPwd, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPwd, err := utils.HashPassword(req.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "密码加密失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": middleware.T(c, "password.hash_failed")})
 		return
 	}
 
 	// 创建用户
 	user := models.User{
-		Password:      string(hashedPwd),
+		Password:      hashedPwd,
 		CreatedAt:     time.Now().Unix(),
 		FriendAddMode: 1, // 默认设置为需要验证
 	}
@@ -148,7 +155,7 @@ func NewRegisterHandler(c *gin.Context) {
 	if req.RegisterType == "email" {
 		// 检查是否使用公司邮箱注册
 		if strings.HasSuffix(req.Email, "@allinone.com") {
-			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "不能使用公司邮箱注册"})
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": middleware.T(c, "user.company_email_forbidden")})
 			return
 		}
 		user.Email = req.Email
@@ -166,7 +173,7 @@ func NewRegisterHandler(c *gin.Context) {
 
 	// 保存用户
 	if err := utils.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "注册失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": middleware.T(c, "user.register_failed")})
 		return
 	}
 
@@ -197,14 +204,14 @@ func NewRegisterHandler(c *gin.Context) {
 		"GeneratedEmail": generatedEmail, // 系统生成的邮箱
 		"Nickname":       nickname,       // 用户昵称
 	}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "更新账号信息失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": middleware.T(c, "user.account_update_failed")})
 		return
 	}
 
 	// 返回成功信息
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"msg":     "注册成功",
+		"msg":     middleware.T(c, "user.registered"),
 		"data": gin.H{
 			"account":         account,
 			"generated_email": generatedEmail,
@@ -281,25 +288,25 @@ func GenerateVerificationCode(c *gin.Context) {
 		if codeType == "register" {
 			codeType = "email"
 		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "验证码类型错误"})
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": middleware.T(c, "verification.invalid_code_type")})
 			return
 		}
 	}
 
 	if target == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "目标不能为空"})
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": middleware.T(c, "verification.target_required")})
 		return
 	}
 
 	// 验证格式
 	if codeType == "email" {
 		if !utils.ValidateEmail(target) {
-			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "邮箱格式不正确"})
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": middleware.T(c, "verification.invalid_email")})
 			return
 		}
 	} else {
 		if !utils.ValidatePhone(target) {
-			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": "手机号格式不正确"})
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "msg": middleware.T(c, "verification.invalid_phone")})
 			return
 		}
 	}
@@ -315,79 +322,64 @@ func GenerateVerificationCode(c *gin.Context) {
 	}
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": "数据库查询错误"})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": middleware.T(c, "common.database_error")})
 		return
 	}
 
 	if count > 0 {
 		// 已注册，返回错误
 		if codeType == "email" {
-			c.JSON(http.StatusOK, gin.H{"success": false, "msg": "该邮箱已被注册"})
+			c.JSON(http.StatusOK, gin.H{"success": false, "msg": middleware.T(c, "user.email_taken")})
 		} else {
-			c.JSON(http.StatusOK, gin.H{"success": false, "msg": "该手机号已被注册"})
+			c.JSON(http.StatusOK, gin.H{"success": false, "msg": middleware.T(c, "user.phone_taken")})
 		}
 		return
 	}
 
-	// 生成6位随机验证码
-	code := utils.GenerateRandomCode(6)
-
-	// 保存验证码（实际应用中应该有过期时间）
+	// 同一目标一分钟内只能获取一次，避免替换掉刚发送的验证码
 	codeKey := fmt.Sprintf("%s:%s", codeType, target)
-	utils.SaveVerificationCode(codeKey, code)
+	if utils.VerificationCodeSentWithin(utils.CodePurposeRegister, codeKey, time.Minute) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"success": false, "msg": middleware.T(c, "verification.too_frequent")})
+		return
+	}
+
+	// 生成6位随机验证码，先保存再发送，保存失败时不会发出无法使用的验证码
+	code, err := utils.GenerateRandomCode(6)
+	if err != nil {
+		utils.Logger.Errorf("生成验证码失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": middleware.T(c, "verification.generate_failed")})
+		return
+	}
+	if err := utils.SaveVerificationCode(utils.CodePurposeRegister, codeKey, code); err != nil {
+		utils.Logger.Errorf("保存验证码失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": middleware.T(c, "verification.send_failed_retry")})
+		return
+	}
 
-	// 根据类型发送验证码
+	// 按请求的语言发送验证码，发送失败时作废刚保存的验证码
+	lang := middleware.Localizer(c).Lang
 	if codeType == "email" {
-		// 发送邮件验证码
-		err = utils.SendVerificationEmail(target, code)
-		if err != nil {
-			fmt.Printf("发送邮件验证码失败: %v\n", err)
-			// 在macOS测试环境中，如果发送失败，仍然返回成功，并在日志中打印验证码
-			fmt.Printf("macOS测试环境 - 向邮箱 %s 发送验证码: %s\n", target, code)
-
-			// 尝试使用本地邮件发送功能
-			localEmailConfig := utils.EmailConfig{
-				Host:     "localhost",
-				Port:     25,
-				Username: "",
-				Password: "",
-				From:     "noreply@localhost",
-			}
-			utils.SetEmailConfig(localEmailConfig)
-
-			// 再次尝试发送
-			err = utils.SendVerificationEmail(target, code)
-			if err != nil {
-				fmt.Printf("使用本地邮件服务发送失败: %v\n", err)
-				// 返回验证码，方便测试
-				c.JSON(http.StatusOK, gin.H{
-					"success": true,
-					"msg":     "验证码已发送（模拟）",
-					"code":    code, // 在测试环境中返回验证码，方便测试
-				})
-				return
-			}
-		}
+		err = utils.SendVerificationEmail(target, code, lang)
 	} else {
-		// 发送短信验证码
-		err = utils.SendSMSVerificationCode(target, code)
-		if err != nil {
-			fmt.Printf("发送短信验证码失败: %v\n", err)
-			// 在开发环境中，如果发送失败，仍然返回成功，并在日志中打印验证码
-			fmt.Printf("开发环境 - 向手机 %s 发送验证码: %s\n", target, code)
-			c.JSON(http.StatusOK, gin.H{
-				"success": true,
-				"msg":     "验证码已发送",
-				"code":    code, // 在开发环境中返回验证码，方便测试
-			})
+		err = utils.SendSMSVerificationCode(target, code, lang)
+	}
+	if err != nil {
+		if delErr := utils.DeleteVerificationCode(utils.CodePurposeRegister, codeKey, code); delErr != nil {
+			utils.Logger.Errorf("作废未发送的验证码失败: %v", delErr)
+		}
+		if errors.Is(err, utils.ErrSendThrottled) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"success": false, "msg": middleware.T(c, "verification.too_frequent")})
 			return
 		}
+		utils.Logger.Errorf("发送验证码失败: %s %s, %v", codeType, target, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "msg": middleware.T(c, "verification.send_failed_retry")})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"msg":     "验证码已发送",
-		// 在开发环境下返回验证码，方便测试
-		"code": code,
-	})
+	response := gin.H{"success": true, "msg": middleware.T(c, "verification.code_sent")}
+	// 在开发环境下返回验证码，方便测试；未配置发送服务时验证码写入文件收件箱
+	if gin.Mode() == gin.DebugMode {
+		response["code"] = code
+	}
+	c.JSON(http.StatusOK, response)
 }
@@ -0,0 +1,134 @@
+package register
+
+import (
+	"allinone_backend/utils"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeEmailSender 记录发送的验证码，以及发送时验证码是否已保存
+type fakeEmailSender struct {
+	err       error
+	code      string
+	savedSent bool
+}
+
+func (s *fakeEmailSender) Name() string {
+	return "fake"
+}
+
+func (s *fakeEmailSender) SendEmail(ctx context.Context, msg *utils.EmailMessage) error {
+	s.code = regexp.MustCompile(`\d{6}`).FindString(msg.Text)
+	s.savedSent = utils.VerifyCode(utils.CodePurposeRegister, "email:"+msg.To, s.code)
+	return s.err
+}
+
+// setupRegisterTest 使用临时数据库、内存验证码存储和指定的邮件发送方
+func setupRegisterTest(t *testing.T, sender *fakeEmailSender) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	if err := utils.MigrateDB(db); err != nil {
+		t.Fatalf("迁移测试数据库失败: %v", err)
+	}
+	previousDB, previousNotify, previousStore := utils.DB, utils.GetNotifyConfig(), utils.GetCodeStoreConfig()
+	utils.DB = db
+	utils.SetCodeStore(utils.NewMemoryCodeStore())
+	utils.SetNotifyConfig(utils.NotifyConfig{SinkDir: t.TempDir(), AllowSink: true})
+	utils.SetEmailSender(sender)
+	t.Cleanup(func() {
+		utils.DB = previousDB
+		utils.SetNotifyConfig(previousNotify)
+		utils.SetCodeStoreConfig(previousStore, previousDB)
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+}
+
+func requestCode(target string) int {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/register/code?type=email&target="+target, nil)
+	GenerateVerificationCode(c)
+	return w.Code
+}
+
+func TestGenerateVerificationCodeSavesBeforeSending(t *testing.T) {
+	sender := &fakeEmailSender{}
+	setupRegisterTest(t, sender)
+
+	if status := requestCode("alice@example.com"); status != http.StatusOK {
+		t.Fatalf("获取验证码返回 %d", status)
+	}
+	if sender.code == "" || !sender.savedSent {
+		t.Error("发送时验证码应已保存")
+	}
+	if !utils.VerifyCode(utils.CodePurposeRegister, "email:alice@example.com", sender.code) {
+		t.Error("发送成功后验证码应有效")
+	}
+	// 一分钟内重复获取被拒绝，已发送的验证码不受影响
+	first := sender.code
+	if status := requestCode("alice@example.com"); status != http.StatusTooManyRequests {
+		t.Errorf("重复获取应返回429，得到 %d", status)
+	}
+	if !utils.VerifyCode(utils.CodePurposeRegister, "email:alice@example.com", first) {
+		t.Error("重复获取不应替换已发送的验证码")
+	}
+}
+
+func TestGenerateVerificationCodeDeletedWhenSendFails(t *testing.T) {
+	sender := &fakeEmailSender{err: &utils.SendError{Sender: "fake", Kind: utils.ErrSendRejected}}
+	setupRegisterTest(t, sender)
+
+	if status := requestCode("bob@example.com"); status != http.StatusInternalServerError {
+		t.Fatalf("发送失败应返回500，得到 %d", status)
+	}
+	if !sender.savedSent {
+		t.Error("发送时验证码应已保存")
+	}
+	if utils.VerifyCode(utils.CodePurposeRegister, "email:bob@example.com", sender.code) {
+		t.Error("发送失败后验证码应作废")
+	}
+
+	// 作废后可以立即重新获取
+	sender.err = nil
+	if status := requestCode("bob@example.com"); status != http.StatusOK {
+		t.Errorf("重新获取返回 %d", status)
+	}
+}
+
+func TestNewRegisterRejectsFixedCode(t *testing.T) {
+	setupRegisterTest(t, &fakeEmailSender{})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	body := `{"register_type":"email","email":"carol@example.com","password":"Blue-Sky42","verification_code":"123456"}`
+	c.Request = httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	NewRegisterHandler(c)
+
+	if !strings.Contains(w.Body.String(), `"success":false`) {
+		t.Errorf("固定的测试验证码不应通过: %s", w.Body.String())
+	}
+	var count int64
+	utils.DB.Table("users").Where("email = ?", "carol@example.com").Count(&count)
+	if count != 0 {
+		t.Error("不应创建用户")
+	}
+}
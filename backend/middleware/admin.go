@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminOnly 管理员权限中间件，需在JWTAuth之后使用
+func AdminOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+
+		var user models.User
+		if err := utils.DB.Select("id, role").First(&user, userID).Error; err != nil || user.Role != "admin" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"msg":     T(c, "auth.admin_required"),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
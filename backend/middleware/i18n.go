@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"allinone_backend/services"
+	"allinone_backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Localizer 获取当前请求的本地化器，首次调用时确定语言并缓存在上下文中
+// 登录用户优先使用其语言设置，未登录时按 Accept-Language
+func Localizer(c *gin.Context) *utils.Localizer {
+	if v, ok := c.Get("localizer"); ok {
+		return v.(*utils.Localizer)
+	}
+	var userID uint
+	if v, ok := c.Get("user_id"); ok {
+		userID, _ = v.(uint)
+	}
+	localizer := services.RequestLocalizer(utils.DB, userID, c.GetHeader("Accept-Language"))
+	c.Set("localizer", localizer)
+	c.Header("Content-Language", localizer.Lang)
+	return localizer
+}
+
+// T 按当前请求的语言获取消息文本
+func T(c *gin.Context, key string, args ...interface{}) string {
+	return Localizer(c).T(key, args...)
+}
+
+// AppErrorMessage 应用错误的提示文本，设置了消息键时按当前请求的语言返回
+func AppErrorMessage(c *gin.Context, err *utils.AppError) string {
+	if err.Key != "" {
+		return T(c, err.Key, err.Args...)
+	}
+	return err.Message
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"allinone_backend/services"
 	"allinone_backend/utils"
 	"net/http"
 	"strings"
@@ -16,7 +17,7 @@ func JWTAuth() gin.HandlerFunc {
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"success": false,
-				"msg":     "请求未携带token，无权限访问",
+				"msg":     T(c, "auth.token_missing"),
 			})
 			c.Abort()
 			return
@@ -27,7 +28,7 @@ func JWTAuth() gin.HandlerFunc {
 		if !(len(parts) == 2 && parts[0] == "Bearer") {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"success": false,
-				"msg":     "token格式错误",
+				"msg":     T(c, "auth.token_malformed"),
 			})
 			c.Abort()
 			return
@@ -38,7 +39,17 @@ func JWTAuth() gin.HandlerFunc {
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"success": false,
-				"msg":     "token无效或已过期",
+				"msg":     T(c, "auth.token_invalid"),
+			})
+			c.Abort()
+			return
+		}
+
+		// 检查令牌或其登录会话是否已被吊销（退出登录、远程下线）
+		if services.IsTokenRevoked(claims) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"msg":     T(c, "auth.token_revoked"),
 			})
 			c.Abort()
 			return
@@ -47,9 +58,11 @@ func JWTAuth() gin.HandlerFunc {
 		// 将用户信息保存到上下文
 		c.Set("user_id", claims.UserID)
 		c.Set("account", claims.Account)
+		c.Set("session_id", claims.SessionID)
+		c.Set("claims", claims)
 
 		// 打印调试信息
-		utils.Logger.Infof("JWT认证成功: user_id=%d, account=%s", claims.UserID, claims.Account)
+		utils.Logger.Debugf("JWT认证成功: user_id=%d, account=%s", claims.UserID, claims.Account)
 
 		// 设置数据库连接
 		c.Set("db", utils.DB)
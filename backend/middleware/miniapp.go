@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MiniAppAuth 小程序令牌认证中间件，要求令牌包含指定的授权范围
+func MiniAppAuth(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		parts := strings.SplitN(authHeader, " ", 2)
+		if !(len(parts) == 2 && parts[0] == "Bearer") {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"msg":     T(c, "miniapp.token_missing"),
+			})
+			c.Abort()
+			return
+		}
+
+		claims, err := utils.ParseMiniAppToken(parts[1])
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"msg":     T(c, "miniapp.token_invalid"),
+			})
+			c.Abort()
+			return
+		}
+
+		if !claims.HasScope(scope) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"msg":     T(c, "miniapp.scope_not_granted"),
+			})
+			c.Abort()
+			return
+		}
+
+		// 用户撤销授权或小程序被停用后，已签发的令牌立即失效
+		var grant models.MiniAppGrant
+		if err := utils.DB.Where("user_id = ? AND app_id = ?", claims.UserID, claims.AppID).First(&grant).Error; err != nil ||
+			!strings.Contains(","+grant.Scopes+",", ","+scope+",") {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"msg":     T(c, "miniapp.authorization_revoked"),
+			})
+			c.Abort()
+			return
+		}
+		var app models.MiniApp
+		if err := utils.DB.Where("app_id = ? AND status = ?", claims.AppID, "active").First(&app).Error; err != nil {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"msg":     T(c, "miniapp.not_found"),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set("user_id", claims.UserID)
+		c.Set("miniapp_id", claims.AppID)
+		c.Set("db", utils.DB)
+
+		c.Next()
+	}
+}
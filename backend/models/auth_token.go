@@ -0,0 +1,28 @@
+package models
+
+// 登录会话的令牌数据模型
+
+// 刷新令牌，只保存哈希，每次使用后轮换为新令牌
+// 同一次登录中轮换产生的令牌 SessionID 相同；已轮换的令牌再次使用时视为泄露，整个会话失效
+type RefreshToken struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	UserID    uint   `json:"user_id" gorm:"index"`
+	DeviceID  uint   `json:"device_id" gorm:"index"` // UserDevice.ID
+	SessionID string `json:"session_id" gorm:"index;size:64"`
+	TokenHash string `json:"-" gorm:"uniqueIndex;size:64"`
+	Status    string `json:"status" gorm:"default:'active'"` // active, rotated, revoked
+	ExpiresAt int64  `json:"expires_at"`
+	CreatedAt int64  `json:"created_at"`
+	UsedAt    int64  `json:"used_at"`
+}
+
+// 已吊销的访问令牌或会话，记录保留到对应的访问令牌全部过期
+type RevokedToken struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	TokenType string `json:"token_type" gorm:"uniqueIndex:idx_revoked_token;size:16"` // access（按 jti）, session（按 sid）
+	TokenID   string `json:"token_id" gorm:"uniqueIndex:idx_revoked_token;size:64"`
+	UserID    uint   `json:"user_id"`
+	Reason    string `json:"reason"`
+	ExpiresAt int64  `json:"expires_at" gorm:"index"`
+	CreatedAt int64  `json:"created_at"`
+}
@@ -19,6 +19,19 @@ type ChatMessage struct {
 	TargetLanguage string `json:"target_language"` // 目标语言
 }
 
+// 消息的按接收者翻译，开启自动翻译的用户收到文本消息时生成
+// 群成员的语言各不相同，译文按接收者分别保存
+type MessageTranslation struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	MessageID  uint   `json:"message_id" gorm:"uniqueIndex:idx_message_translation"`
+	UserID     uint   `json:"user_id" gorm:"uniqueIndex:idx_message_translation"`
+	SourceLang string `json:"source_lang"`
+	TargetLang string `json:"target_lang"`
+	Text       string `json:"text" gorm:"type:text"`
+	Provider   string `json:"provider"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
 // 红包相关模型已移至 red_packet.go
 // 通话记录相关模型已移至 call.go
 
@@ -40,6 +53,49 @@ type AIChatMessage struct {
 	CreatedAt int64  `json:"created_at"`
 	Type      string `json:"type"` // personal, group, game
 	GroupID   uint   `json:"group_id"`
+	GameID    uint   `json:"game_id"`
+	Provider  string `json:"provider"` // 实际响应的AI提供方
+	Model     string `json:"model"`
+	Status    string `json:"status" gorm:"default:'completed'"` // completed, awaiting_permission
+	ToolTrace string `json:"tool_trace" gorm:"type:text"`       // 工具调用记录，JSON数组
+	SessionID uint   `json:"session_id" gorm:"index"`
+	// 本条对话消耗的token数
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	// 游戏对话中扮演的AI角色，0表示通用陪玩助手
+	CharacterID uint `json:"character_id"`
+}
+
+// AI对话会话
+// 超出上下文预算的早期消息会被压缩进 Summary，SummarizedUntil 之前的消息不再原样发送
+type AIChatSession struct {
+	ID              uint   `json:"id" gorm:"primaryKey"`
+	UserID          uint   `json:"user_id" gorm:"index"`
+	Type            string `json:"type"` // personal, group, game
+	GroupID         uint   `json:"group_id"`
+	GameID          uint   `json:"game_id"`
+	CharacterID     uint   `json:"character_id"` // 游戏角色会话，每个角色单独记忆
+	Title           string `json:"title"`
+	Summary         string `json:"summary" gorm:"type:text"`
+	SummarizedUntil uint   `json:"summarized_until"` // 已压缩进摘要的最后一条消息ID
+	MessageCount    int    `json:"message_count"`
+	TokensUsed      int    `json:"tokens_used"`
+	LastMessageAt   int64  `json:"last_message_at" gorm:"index"`
+	CreatedAt       int64  `json:"created_at"`
+	UpdatedAt       int64  `json:"updated_at"`
+}
+
+// 用户每日AI用量，按提供方统计
+type AIUsageDaily struct {
+	ID               uint   `json:"id" gorm:"primaryKey"`
+	UserID           uint   `json:"user_id" gorm:"uniqueIndex:idx_ai_usage_daily"`
+	Date             string `json:"date" gorm:"uniqueIndex:idx_ai_usage_daily"` // 2006-01-02
+	Provider         string `json:"provider" gorm:"uniqueIndex:idx_ai_usage_daily"`
+	Requests         int    `json:"requests"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+	TotalTokens      int    `json:"total_tokens"`
+	UpdatedAt        int64  `json:"updated_at"`
 }
 
 // 虚拟货币账户
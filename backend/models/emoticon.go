@@ -0,0 +1,62 @@
+package models
+
+// 表情包数据模型
+
+// 表情包，价格为0时免费添加，付费表情包从钱包扣款购买
+type EmoticonPackage struct {
+	ID          uint    `json:"id" gorm:"primaryKey"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Cover       string  `json:"cover"` // 封面地址，未设置时使用第一个表情
+	Author      string  `json:"author"`
+	Price       float64 `json:"price" gorm:"default:0"`
+	Count       int     `json:"count" gorm:"default:0"`            // 表情数量
+	SortOrder   int     `json:"sort_order" gorm:"default:0"`       // 商店中的排序，越小越靠前
+	Status      string  `json:"status" gorm:"default:'published'"` // published, unpublished
+	CreatedAt   int64   `json:"created_at"`
+	UpdatedAt   int64   `json:"updated_at"`
+}
+
+// 表情，图片保存在媒体存储，公开访问
+// 表情包中的表情 PackageID 不为0；用户上传的收藏表情 PackageID 为0，OwnerID 为上传者
+type Emoticon struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	PackageID uint   `json:"package_id" gorm:"index"`
+	OwnerID   uint   `json:"owner_id" gorm:"index"`
+	Name      string `json:"name"`
+	MediaID   uint   `json:"media_id"`
+	URL       string `json:"url"`
+	ThumbURL  string `json:"thumb_url"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	SortOrder int    `json:"sort_order" gorm:"default:0"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// 用户添加到表情面板的表情包，按 SortOrder 排序
+type UserEmoticonPackage struct {
+	ID        uint  `json:"id" gorm:"primaryKey"`
+	UserID    uint  `json:"user_id" gorm:"uniqueIndex:idx_user_emoticon_package"`
+	PackageID uint  `json:"package_id" gorm:"uniqueIndex:idx_user_emoticon_package"`
+	SortOrder int   `json:"sort_order" gorm:"default:0"`
+	CreatedAt int64 `json:"created_at"`
+}
+
+// 付费表情包的购买记录，移出面板后重新添加无需再次付费
+type EmoticonOrder struct {
+	ID        uint    `json:"id" gorm:"primaryKey"`
+	UserID    uint    `json:"user_id" gorm:"uniqueIndex:idx_emoticon_order"`
+	PackageID uint    `json:"package_id" gorm:"uniqueIndex:idx_emoticon_order"`
+	Amount    float64 `json:"amount"`
+	Status    string  `json:"status"` // paid
+	CreatedAt int64   `json:"created_at"`
+}
+
+// 用户收藏的表情，按 SortOrder 排序
+type UserFavoriteEmoticon struct {
+	ID         uint  `json:"id" gorm:"primaryKey"`
+	UserID     uint  `json:"user_id" gorm:"uniqueIndex:idx_user_favorite_emoticon"`
+	EmoticonID uint  `json:"emoticon_id" gorm:"uniqueIndex:idx_user_favorite_emoticon"`
+	SortOrder  int   `json:"sort_order" gorm:"default:0"`
+	CreatedAt  int64 `json:"created_at"`
+}
@@ -14,6 +14,7 @@ type Game struct {
 	Platforms   string `json:"platforms"` // PC, Mobile, Console, 逗号分隔
 	Rating      float64 `json:"rating" gorm:"default:0"`
 	Downloads   int    `json:"downloads" gorm:"default:0"`
+	Status      string `json:"status" gorm:"default:'published'"` // published, unpublished
 	CreatedAt   int64  `json:"created_at"`
 	UpdatedAt   int64  `json:"updated_at"`
 }
@@ -21,21 +22,23 @@ type Game struct {
 // 游戏开发者
 type GameDeveloper struct {
 	ID          uint   `json:"id" gorm:"primaryKey"`
-	UserID      uint   `json:"user_id"`
+	UserID      uint   `json:"user_id" gorm:"index"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Logo        string `json:"logo"`
 	Website     string `json:"website"`
 	Email       string `json:"email"`
-	CreatedAt   int64  `json:"created_at"`
-	UpdatedAt   int64  `json:"updated_at"`
+	// 游戏服务器签名成就解锁请求的密钥，只在生成时返回给开发者
+	AchievementSecret string `json:"-"`
+	CreatedAt         int64  `json:"created_at"`
+	UpdatedAt         int64  `json:"updated_at"`
 }
 
 // 用户游戏
 type UserGame struct {
 	ID        uint   `json:"id" gorm:"primaryKey"`
-	UserID    uint   `json:"user_id"`
-	GameID    uint   `json:"game_id"`
+	UserID    uint   `json:"user_id" gorm:"uniqueIndex:idx_user_game"`
+	GameID    uint   `json:"game_id" gorm:"uniqueIndex:idx_user_game"`
 	Status    string `json:"status"` // purchased, downloaded, installed, playing
 	PlayTime  int    `json:"play_time" gorm:"default:0"`
 	LastPlayed int64  `json:"last_played" gorm:"default:0"`
@@ -86,7 +89,7 @@ type GameUpdate struct {
 // AI游戏角色
 type AIGameCharacter struct {
 	ID          uint   `json:"id" gorm:"primaryKey"`
-	GameID      uint   `json:"game_id"`
+	GameID      uint   `json:"game_id" gorm:"index"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Avatar      string `json:"avatar"`
@@ -94,3 +97,31 @@ type AIGameCharacter struct {
 	CreatedAt   int64  `json:"created_at"`
 	UpdatedAt   int64  `json:"updated_at"`
 }
+
+// 游戏订单，记录每次购买的实付金额，供开发者收入结算使用
+type GameOrder struct {
+	ID           uint    `json:"id" gorm:"primaryKey"`
+	UserID       uint    `json:"user_id" gorm:"index"`
+	GameID       uint    `json:"game_id" gorm:"index"`
+	DeveloperID  uint    `json:"developer_id" gorm:"index"`
+	Amount       float64 `json:"amount"`
+	Status       string  `json:"status" gorm:"default:'paid'"`        // paid, refunded
+	SettlementID uint    `json:"settlement_id" gorm:"index;default:0"` // 所属结算单，0表示未结算
+	CreatedAt    int64   `json:"created_at" gorm:"index"`
+}
+
+// 开发者收入结算单
+type GameSettlement struct {
+	ID            uint    `json:"id" gorm:"primaryKey"`
+	DeveloperID   uint    `json:"developer_id" gorm:"index"`
+	UserID        uint    `json:"user_id"` // 收款用户，即开发者账号
+	PeriodStart   int64   `json:"period_start"`
+	PeriodEnd     int64   `json:"period_end"`
+	OrderCount    int     `json:"order_count"`
+	GrossAmount   float64 `json:"gross_amount"` // 销售总额
+	FeeRate       float64 `json:"fee_rate"`     // 平台抽成比例
+	PlatformFee   float64 `json:"platform_fee"`
+	NetAmount     float64 `json:"net_amount"` // 开发者实际到账
+	TransactionID uint    `json:"transaction_id"`
+	CreatedAt     int64   `json:"created_at" gorm:"index"`
+}
@@ -51,3 +51,12 @@ type GroupAnnouncement struct {
 	UpdatedAt int64  `json:"updated_at"`
 	PinnedAt  int64  `json:"pinned_at" gorm:"default:0"` // 置顶时间，0表示未置顶
 }
+
+// 群成员的已读位置，用于未读统计和AI消息摘要
+type GroupReadState struct {
+	ID                uint  `gorm:"primaryKey" json:"id"`
+	GroupID           uint  `json:"group_id" gorm:"uniqueIndex:idx_group_read_state"`
+	UserID            uint  `json:"user_id" gorm:"uniqueIndex:idx_group_read_state"`
+	LastReadMessageID uint  `json:"last_read_message_id"`
+	UpdatedAt         int64 `json:"updated_at"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// 登录尝试记录，用于按账号和IP限制密码错误次数
+// 账号不存在时 UserID 为0，按提交的登录名计数
+type LoginAttempt struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	UserID     uint   `json:"user_id" gorm:"index"`
+	Identifier string `json:"identifier" gorm:"index;size:128"` // 提交的账号、手机号或邮箱
+	IPAddress  string `json:"ip_address" gorm:"index;size:64"`
+	Success    bool   `json:"success"`
+	CreatedAt  int64  `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,37 @@
+package models
+
+// 媒体文件数据模型
+
+// 按内容哈希去重的文件实体，多条媒体记录可以引用同一份文件
+type MediaBlob struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Hash        string `json:"hash" gorm:"uniqueIndex"` // 内容的SHA-256
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+	Storage     string `json:"storage"`     // 保存所在的存储后端：local, s3
+	StorageKey  string `json:"storage_key"` // 存储后端中的路径
+	RefCount    int    `json:"ref_count"`   // 引用该文件的媒体记录数，为0时删除文件
+	CreatedAt   int64  `json:"created_at"`
+}
+
+// 用户上传的媒体，记录上传者和所属会话，用于下载时校验权限
+type Media struct {
+	ID               uint   `json:"id" gorm:"primaryKey"`
+	OwnerID          uint   `json:"owner_id" gorm:"index"`
+	BlobID           uint   `json:"-" gorm:"index"`
+	Hash             string `json:"hash"`
+	FileName         string `json:"file_name"` // 上传时的原始文件名
+	FileType         string `json:"file_type"` // image, voice, video, file
+	ContentType      string `json:"content_type"`
+	Size             int64  `json:"size"`
+	Width            int    `json:"width,omitempty"`                                       // 图片宽度
+	Height           int    `json:"height,omitempty"`                                      // 图片高度
+	ParentID         uint   `json:"parent_id,omitempty" gorm:"index"`                      // 缩略图对应的原图
+	Rendition        string `json:"rendition,omitempty"`                                   // 缩略图规格：thumb, medium，原图为空
+	Scope            string `json:"scope"`                                                 // private: 仅上传者, conversation: 会话成员, moment: 动态的可见用户, square: 广场动态的查看者, public: 所有人
+	ConversationType string `json:"conversation_type" gorm:"index:idx_media_conversation"` // private: 单聊, group: 群聊
+	ConversationID   uint   `json:"conversation_id" gorm:"index:idx_media_conversation"`   // 单聊为对方用户ID，群聊为群ID
+	MomentID         uint   `json:"moment_id,omitempty" gorm:"index"`                      // 所属动态，发布前为0
+	SquarePostID     uint   `json:"square_post_id,omitempty" gorm:"index"`                 // 所属广场动态，发布前为0
+	CreatedAt        int64  `json:"created_at"`
+}
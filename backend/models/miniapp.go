@@ -0,0 +1,62 @@
+package models
+
+// 小程序授权范围
+const (
+	MiniAppScopeUserInfo  = "userinfo"   // 读取用户基本资料
+	MiniAppScopeWalletPay = "wallet.pay" // 发起钱包支付
+)
+
+// MiniAppScopes 所有可申请的授权范围及其说明
+var MiniAppScopes = map[string]string{
+	MiniAppScopeUserInfo:  "获取你的昵称、头像等公开信息",
+	MiniAppScopeWalletPay: "向你发起钱包支付请求",
+}
+
+// 小程序
+type MiniApp struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	AppID       string `json:"app_id" gorm:"uniqueIndex"` // 对外公开的小程序ID
+	DeveloperID uint   `json:"developer_id" gorm:"index"` // 开发者用户ID，支付款项结算到该用户钱包
+	Name        string `json:"name"`
+	Icon        string `json:"icon"`
+	EntryURL    string `json:"entry_url"` // 小程序入口地址
+	Description string `json:"description"`
+	Scopes      string `json:"scopes"`                         // 申请的授权范围，逗号分隔
+	Status      string `json:"status" gorm:"default:'active'"` // active, suspended
+	CreatedAt   int64  `json:"created_at"`
+	UpdatedAt   int64  `json:"updated_at"`
+}
+
+// 用户对小程序的授权
+type MiniAppGrant struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	UserID    uint   `json:"user_id" gorm:"uniqueIndex:idx_miniapp_grant"`
+	AppID     string `json:"app_id" gorm:"uniqueIndex:idx_miniapp_grant"`
+	Scopes    string `json:"scopes"` // 用户同意的授权范围，逗号分隔
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// 用户最近使用的小程序
+type MiniAppUsage struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	UserID     uint   `json:"user_id" gorm:"uniqueIndex:idx_miniapp_usage"`
+	AppID      string `json:"app_id" gorm:"uniqueIndex:idx_miniapp_usage"`
+	UseCount   int    `json:"use_count" gorm:"default:0"`
+	LastUsedAt int64  `json:"last_used_at" gorm:"index"`
+}
+
+// 小程序发起的钱包支付
+type MiniAppPayment struct {
+	ID          uint    `json:"id" gorm:"primaryKey"`
+	AppID       string  `json:"app_id" gorm:"index"`
+	UserID      uint    `json:"user_id" gorm:"index"`
+	Amount      float64 `json:"amount"`
+	Description string  `json:"description"`
+	OutTradeNo  string  `json:"out_trade_no"`                    // 小程序侧订单号
+	Status      string  `json:"status" gorm:"default:'pending'"` // pending, paid, cancelled, expired
+	ExpiresAt   int64   `json:"expires_at"`
+	PaidAt      int64   `json:"paid_at" gorm:"default:0"`
+	CreatedAt   int64   `json:"created_at"`
+	UpdatedAt   int64   `json:"updated_at"`
+}
@@ -0,0 +1,43 @@
+package models
+
+// 朋友圈可见范围
+const (
+	MomentVisibilityFriends = "friends" // 好友可见
+	MomentVisibilityPrivate = "private" // 仅自己可见
+	MomentVisibilityInclude = "include" // 部分可见（仅VisibleUsers可见）
+	MomentVisibilityExclude = "exclude" // 不给谁看（VisibleUsers不可见）
+)
+
+// 朋友圈动态
+type Moment struct {
+	ID           uint   `json:"id" gorm:"primaryKey"`
+	UserID       uint   `json:"user_id" gorm:"index"`
+	Content      string `json:"content"`
+	Media        string `json:"media"`                               // JSON数组，元素为 {type, url, media_id}，url 为 MediaRef 地址
+	Location     string `json:"location"`                            // 位置信息
+	Visibility   string `json:"visibility" gorm:"default:'friends'"` // friends, private, include, exclude
+	VisibleUsers string `json:"visible_users"`                       // include/exclude 对应的用户ID，逗号分隔
+	LikeCount    int    `json:"like_count" gorm:"default:0"`
+	CommentCount int    `json:"comment_count" gorm:"default:0"`
+	CreatedAt    int64  `json:"created_at" gorm:"index"`
+	UpdatedAt    int64  `json:"updated_at"`
+}
+
+// 朋友圈点赞
+type MomentLike struct {
+	ID        uint  `json:"id" gorm:"primaryKey"`
+	MomentID  uint  `json:"moment_id" gorm:"uniqueIndex:idx_moment_like"`
+	UserID    uint  `json:"user_id" gorm:"uniqueIndex:idx_moment_like"`
+	CreatedAt int64 `json:"created_at"`
+}
+
+// 朋友圈评论
+type MomentComment struct {
+	ID            uint   `json:"id" gorm:"primaryKey"`
+	MomentID      uint   `json:"moment_id" gorm:"index"`
+	UserID        uint   `json:"user_id"`
+	ParentID      uint   `json:"parent_id" gorm:"default:0"`        // 回复的评论ID，0表示直接评论动态
+	ReplyToUserID uint   `json:"reply_to_user_id" gorm:"default:0"` // 被回复的用户ID
+	Content       string `json:"content"`
+	CreatedAt     int64  `json:"created_at"`
+}
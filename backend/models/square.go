@@ -0,0 +1,73 @@
+package models
+
+// 广场动态状态
+const (
+	SquarePostNormal  = "normal"  // 正常展示
+	SquarePostHidden  = "hidden"  // 被举报过多，自动隐藏待审核
+	SquarePostRemoved = "removed" // 已被管理员删除
+)
+
+// 广场动态
+type SquarePost struct {
+	ID           uint    `json:"id" gorm:"primaryKey"`
+	UserID       uint    `json:"user_id" gorm:"index"`
+	Content      string  `json:"content"`
+	Media        string  `json:"media"`  // JSON数组，元素为 {type, url, media_id}，url 为 MediaRef 地址
+	Topics       string  `json:"topics"` // 话题名称，逗号分隔
+	LikeCount    int     `json:"like_count" gorm:"default:0"`
+	CommentCount int     `json:"comment_count" gorm:"default:0"`
+	ReportCount  int     `json:"report_count" gorm:"default:0"`
+	HotScore     float64 `json:"hot_score" gorm:"index;default:0"`     // 热度分，随时间衰减
+	Status       string  `json:"status" gorm:"index;default:'normal'"` // normal, hidden, removed
+	CreatedAt    int64   `json:"created_at" gorm:"index"`
+	UpdatedAt    int64   `json:"updated_at"`
+}
+
+// 广场话题
+type SquareTopic struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	Name      string `json:"name" gorm:"uniqueIndex"`
+	PostCount int    `json:"post_count" gorm:"default:0"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// 广场动态与话题的关联
+type SquarePostTopic struct {
+	ID        uint  `json:"id" gorm:"primaryKey"`
+	PostID    uint  `json:"post_id" gorm:"index"`
+	TopicID   uint  `json:"topic_id" gorm:"index"`
+	CreatedAt int64 `json:"created_at" gorm:"index"`
+}
+
+// 广场点赞
+type SquareLike struct {
+	ID        uint  `json:"id" gorm:"primaryKey"`
+	PostID    uint  `json:"post_id" gorm:"uniqueIndex:idx_square_like"`
+	UserID    uint  `json:"user_id" gorm:"uniqueIndex:idx_square_like"`
+	CreatedAt int64 `json:"created_at"`
+}
+
+// 广场评论
+type SquareComment struct {
+	ID            uint   `json:"id" gorm:"primaryKey"`
+	PostID        uint   `json:"post_id" gorm:"index"`
+	UserID        uint   `json:"user_id"`
+	ParentID      uint   `json:"parent_id" gorm:"default:0"`        // 回复的评论ID，0表示直接评论动态
+	ReplyToUserID uint   `json:"reply_to_user_id" gorm:"default:0"` // 被回复的用户ID
+	Content       string `json:"content"`
+	CreatedAt     int64  `json:"created_at"`
+}
+
+// 广场举报
+type SquareReport struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	PostID     uint   `json:"post_id" gorm:"uniqueIndex:idx_square_report"`
+	ReporterID uint   `json:"reporter_id" gorm:"uniqueIndex:idx_square_report"`
+	Reason     string `json:"reason"`                                // spam, abuse, porn, illegal, other
+	Detail     string `json:"detail"`                                // 补充说明
+	Status     string `json:"status" gorm:"index;default:'pending'"` // pending, accepted, rejected
+	HandledBy  uint   `json:"handled_by" gorm:"default:0"`
+	HandledAt  int64  `json:"handled_at" gorm:"default:0"`
+	CreatedAt  int64  `json:"created_at"`
+}
@@ -0,0 +1,37 @@
+package models
+
+// 两步验证数据模型
+
+// 用户的TOTP密钥，设置后验证一次验证码才启用
+type UserTwoFactor struct {
+	ID             uint   `json:"id" gorm:"primaryKey"`
+	UserID         uint   `json:"user_id" gorm:"uniqueIndex"`
+	Secret         string `json:"-"`
+	Enabled        bool   `json:"enabled" gorm:"default:false"`
+	LastUsedStep   int64  `json:"-"` // 最近一次使用的时间步，防止验证码重放
+	FailedAttempts int    `json:"-" gorm:"default:0"`
+	LockedUntil    int64  `json:"locked_until"`
+	EnabledAt      int64  `json:"enabled_at"`
+	CreatedAt      int64  `json:"created_at"`
+	UpdatedAt      int64  `json:"updated_at"`
+}
+
+// 一次性恢复码，只保存哈希，无法使用验证器时代替验证码
+type TwoFactorRecoveryCode struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	UserID    uint   `json:"user_id" gorm:"index"`
+	CodeHash  string `json:"-" gorm:"size:64"`
+	UsedAt    int64  `json:"used_at"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// 密码验证通过、等待两步验证的登录，令牌只保存哈希
+type TwoFactorChallenge struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	TokenHash string `json:"-" gorm:"uniqueIndex;size:64"`
+	UserID    uint   `json:"user_id" gorm:"index"`
+	Device    string `json:"device" gorm:"type:text"` // 登录设备信息（JSON）
+	Attempts  int    `json:"attempts" gorm:"default:0"`
+	ExpiresAt int64  `json:"expires_at"`
+	CreatedAt int64  `json:"created_at"`
+}
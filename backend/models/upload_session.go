@@ -0,0 +1,23 @@
+package models
+
+// 断点续传会话数据模型
+
+// 分片上传会话，客户端按偏移量分多次上传，全部上传后校验哈希并保存为媒体
+type UploadSession struct {
+	ID               uint   `json:"-" gorm:"primaryKey"`
+	UploadID         string `json:"upload_id" gorm:"uniqueIndex;size:64"`
+	UserID           uint   `json:"user_id" gorm:"index"`
+	FileName         string `json:"file_name"`
+	FileType         string `json:"file_type"` // image, voice, video, file
+	Size             int64  `json:"size"`      // 文件总大小
+	Offset           int64  `json:"offset"`    // 已接收的字节数
+	Checksum         string `json:"checksum"`  // 客户端声明的SHA-256，可在完成时再提供
+	Scope            string `json:"scope"`
+	ConversationType string `json:"conversation_type"`
+	ConversationID   uint   `json:"conversation_id"`
+	Status           string `json:"status" gorm:"index"` // uploading: 上传中, finalizing: 保存中, completed: 已完成
+	MediaID          uint   `json:"media_id"`            // 完成后的媒体ID
+	ExpiresAt        int64  `json:"expires_at" gorm:"index"`
+	CreatedAt        int64  `json:"created_at"`
+	UpdatedAt        int64  `json:"updated_at"`
+}
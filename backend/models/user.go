@@ -3,7 +3,7 @@ package models
 type User struct {
 	ID             uint   `json:"id" gorm:"primaryKey"`
 	Account        string `json:"account" gorm:"uniqueIndex"`
-	Password       string `json:"password"`
+	Password       string `json:"-"` // 密码哈希，见 utils.HashPassword
 	Email          string `json:"email" gorm:"index"`
 	Phone          string `json:"phone" gorm:"index"`
 	GeneratedEmail string `json:"generated_email"`
@@ -11,8 +11,10 @@ type User struct {
 	PhoneVerified  bool   `json:"phone_verified" gorm:"default:false"`
 	Nickname       string `json:"nickname" gorm:"default:''"`
 	Avatar         string `json:"avatar" gorm:"default:''"`
+	AvatarThumb    string `json:"avatar_thumb" gorm:"default:''"` // 头像缩略图
 	Bio            string `json:"bio" gorm:"default:''"`
 	Gender         string `json:"gender" gorm:"default:'未知'"`
 	CreatedAt      int64  `json:"created_at"`
 	FriendAddMode  int    `json:"friend_add_mode" gorm:"default:1"` // 0=自动同意，1=需验证，2=拒绝所有
+	Role           string `json:"role" gorm:"default:'user'"`       // user, admin, bot
 }
@@ -20,7 +20,7 @@ type UserSettings struct {
 // 语言包
 type LanguagePack struct {
 	ID        uint   `json:"id" gorm:"primaryKey"`
-	LangCode  string `json:"lang_code" gorm:"uniqueIndex"` // zh_CN, en_US, etc.
+	LangCode  string `json:"lang_code" gorm:"uniqueIndex"` // 统一后的语言代码，如 zh-CN, en
 	Name      string `json:"name"`                         // 中文, English, etc.
 	Content   string `json:"content"`                      // JSON格式，存储所有翻译文本
 	CreatedAt int64  `json:"created_at"`
@@ -30,18 +30,42 @@ type LanguagePack struct {
 // 用户自定义语言包
 type UserLanguagePack struct {
 	ID        uint   `json:"id" gorm:"primaryKey"`
-	UserID    uint   `json:"user_id"`
-	LangCode  string `json:"lang_code"`
+	UserID    uint   `json:"user_id" gorm:"uniqueIndex:idx_user_language_pack"`
+	LangCode  string `json:"lang_code" gorm:"uniqueIndex:idx_user_language_pack"`
 	Content   string `json:"content"` // JSON格式，存储用户自定义的翻译文本
 	CreatedAt int64  `json:"created_at"`
 	UpdatedAt int64  `json:"updated_at"`
 }
 
+// 翻译缓存，按原文哈希、源语言和目标语言唯一
+type TranslationCache struct {
+	ID           uint   `json:"id" gorm:"primaryKey"`
+	TextHash     string `json:"text_hash" gorm:"size:64;uniqueIndex:idx_translation_cache"` // 原文的SHA-256
+	SourceLang   string `json:"source_lang" gorm:"size:16;uniqueIndex:idx_translation_cache"`
+	TargetLang   string `json:"target_lang" gorm:"size:16;uniqueIndex:idx_translation_cache"`
+	DetectedLang string `json:"detected_lang" gorm:"size:16"` // 源语言为 auto 时检测到的语言
+	Text         string `json:"text" gorm:"type:text"`
+	Translation  string `json:"translation" gorm:"type:text"`
+	Provider     string `json:"provider"`
+	Hits         int    `json:"hits"`
+	CreatedAt    int64  `json:"created_at"`
+	LastUsedAt   int64  `json:"last_used_at" gorm:"index"`
+}
+
+// 语言检测缓存，按原文哈希唯一
+type LanguageDetection struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	TextHash  string `json:"text_hash" gorm:"size:64;uniqueIndex"`
+	Lang      string `json:"lang" gorm:"size:16"`
+	CreatedAt int64  `json:"created_at" gorm:"index"`
+}
+
 // AI设置
 type AISettings struct {
 	ID              uint   `json:"id" gorm:"primaryKey"`
 	UserID          uint   `json:"user_id" gorm:"uniqueIndex"`
-	AIModel         string `json:"ai_model" gorm:"default:'gpt-3.5-turbo'"` // 使用的AI模型
+	AIProvider      string `json:"ai_provider" gorm:"column:ai_provider"`   // 优先使用的AI提供方，为空时使用系统默认回退链
+	AIModel         string `json:"ai_model"`                                // 使用的AI模型，只对 AIProvider 指定的提供方生效
 	Temperature     float64 `json:"temperature" gorm:"default:0.7"`         // 创造性参数
 	MaxTokens       int    `json:"max_tokens" gorm:"default:2000"`          // 最大token数
 	PersonalPrompt  string `json:"personal_prompt"`                         // 个人AI助手的提示词
@@ -51,20 +75,32 @@ type AISettings struct {
 	UpdatedAt       int64  `json:"updated_at"`
 }
 
+// AI工具调用授权，没有记录的工具在调用前需要用户确认
+type AIToolPermission struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	UserID    uint   `json:"user_id" gorm:"uniqueIndex:idx_ai_tool_permission"`
+	Tool      string `json:"tool" gorm:"uniqueIndex:idx_ai_tool_permission"`
+	Mode      string `json:"mode"` // allow, deny
+	UpdatedAt int64  `json:"updated_at"`
+}
+
 // 设备信息
 type UserDevice struct {
-	ID           uint   `json:"id" gorm:"primaryKey"`
-	UserID       uint   `json:"user_id"`
-	DeviceID     string `json:"device_id" gorm:"uniqueIndex"`
-	DeviceType   string `json:"device_type"` // ios, android, windows, macos, linux, web
-	DeviceName   string `json:"device_name"`
-	DeviceModel  string `json:"device_model"`
-	OSVersion    string `json:"os_version"`
-	AppVersion   string `json:"app_version"`
-	LastLoginAt  int64  `json:"last_login_at"`
-	LastActiveAt int64  `json:"last_active_at"`
-	IPAddress    string `json:"ip_address"`
-	UserAgent    string `json:"user_agent"`
-	IsActive     bool   `json:"is_active" gorm:"default:true"`
-	CreatedAt    int64  `json:"created_at"`
+	ID             uint   `json:"id" gorm:"primaryKey"`
+	UserID         uint   `json:"user_id" gorm:"uniqueIndex:idx_user_device"`
+	DeviceID       string `json:"device_id" gorm:"uniqueIndex:idx_user_device"` // 客户端生成的设备标识，不同用户可以相同
+	DeviceType     string `json:"device_type"`                                  // ios, android, windows, macos, linux, web
+	DeviceName     string `json:"device_name"`
+	DeviceModel    string `json:"device_model"`
+	OSVersion      string `json:"os_version"`
+	AppVersion     string `json:"app_version"`
+	LastLoginAt    int64  `json:"last_login_at"`
+	LastActiveAt   int64  `json:"last_active_at"`
+	IPAddress      string `json:"ip_address"`
+	UserAgent      string `json:"user_agent"`
+	IsActive       bool   `json:"is_active" gorm:"default:true"`
+	SessionID      string `json:"-" gorm:"index"` // 当前登录会话，每次登录重新生成，访问令牌的 sid
+	Trusted        bool   `json:"trusted"`        // 用户在该设备通过两步验证时选择了记住设备，开启两步验证后在受信任设备登录无需验证码
+	TrustTokenHash string `json:"-"`              // 记住设备时签发给客户端的信任令牌的哈希，登录时令牌匹配才视为受信任
+	CreatedAt      int64  `json:"created_at"`
 }
@@ -0,0 +1,11 @@
+package models
+
+// 验证码，使用数据库存储时每个用途和目标一条记录，重新发送时覆盖
+// CodeKey 由用途和目标组成，例如 register:email:a@b.com
+type VerificationCode struct {
+	CodeKey   string `json:"code_key" gorm:"primaryKey;size:191"`
+	Code      string `json:"-" gorm:"size:64"`
+	Attempts  int    `json:"attempts"` // 输错次数
+	SentAt    int64  `json:"sent_at"`
+	ExpiresAt int64  `json:"expires_at" gorm:"index"`
+}
@@ -3,15 +3,49 @@ package models
 // 语音消息数据模型
 
 type VoiceMessage struct {
-	ID         uint   `json:"id" gorm:"primaryKey"`
-	SenderID   uint   `json:"sender_id"`
-	ReceiverID uint   `json:"receiver_id"`
-	GroupID    uint   `json:"group_id"`
-	FilePath   string `json:"file_path"` // 服务器上的文件路径
-	URL        string `json:"url"`       // 可访问的URL
-	Duration   int    `json:"duration"`  // 语音时长（秒）
-	Status     int    `json:"status"`    // 0: 发送中, 1: 已发送, 2: 已送达, 3: 已读, 4: 发送失败
-	CreatedAt  int64  `json:"created_at"`
+	ID               uint   `json:"id" gorm:"primaryKey"`
+	SenderID         uint   `json:"sender_id"`
+	ReceiverID       uint   `json:"receiver_id"`
+	GroupID          uint   `json:"group_id"`
+	ChatMessageID    uint   `json:"chat_message_id" gorm:"index"` // 对应的聊天消息
+	MediaID          uint   `json:"media_id"`                     // 媒体存储中的文件，为0时是旧数据，文件在 FilePath
+	FilePath         string `json:"file_path"`                    // 服务器上的文件路径
+	URL              string `json:"url"`                          // 可访问的URL
+	Duration         int    `json:"duration"`                     // 语音时长（秒），按音频内容计算
+	DurationMs       int64  `json:"duration_ms"`                  // 语音时长（毫秒）
+	Waveform         string `json:"waveform" gorm:"type:text"`    // 波形峰值，JSON数组，取值0-100
+	Status           int    `json:"status"`                       // 0: 发送中, 1: 已发送, 2: 已送达, 3: 已读, 4: 发送失败
+	Transcript       string `json:"transcript" gorm:"type:text"`  // 语音识别文本
+	TranscriptLang   string `json:"transcript_lang"`              // 识别出的语言
+	TranscriptStatus string `json:"transcript_status"`            // 空: 未识别, pending, processing, done, failed
+	CreatedAt        int64  `json:"created_at"`
+}
+
+// 语音消息的收听记录，每个接收者一条
+type VoiceListen struct {
+	ID             uint  `json:"id" gorm:"primaryKey"`
+	VoiceMessageID uint  `json:"voice_message_id" gorm:"uniqueIndex:idx_voice_listen"`
+	UserID         uint  `json:"user_id" gorm:"uniqueIndex:idx_voice_listen"`
+	ListenedAt     int64 `json:"listened_at"`
+}
+
+// 语音识别任务，识别完成后结果写入语音消息和聊天消息
+type TranscriptionJob struct {
+	ID             uint   `json:"id" gorm:"primaryKey"`
+	UserID         uint   `json:"user_id" gorm:"index"` // 发起识别的用户
+	VoiceMessageID uint   `json:"voice_message_id" gorm:"index"`
+	ChatMessageID  uint   `json:"chat_message_id" gorm:"index"`
+	FilePath       string `json:"-"`
+	Language       string `json:"language"`              // 语言提示，空为自动判断
+	Status         string `json:"status" gorm:"index"`   // pending, processing, done, failed
+	Text           string `json:"text" gorm:"type:text"` // 识别文本
+	DetectedLang   string `json:"detected_lang"`         // 识别出的语言
+	Provider       string `json:"provider"`              // 完成识别的提供方
+	Error          string `json:"error"`                 // 失败原因
+	Attempts       int    `json:"attempts"`              // 已尝试次数
+	CreatedAt      int64  `json:"created_at"`
+	UpdatedAt      int64  `json:"updated_at"`
+	FinishedAt     int64  `json:"finished_at"`
 }
 
 // WebRTC信令服务器配置
@@ -13,20 +13,38 @@ func RegisterAIRoutes(r *gin.RouterGroup) {
 		// AI工具列表
 		ai.GET("/tools", controllers.ListAiTools)
 
+		// AI工具调用授权
+		ai.GET("/tools/permissions", controllers.GetAIToolPermissions)
+		ai.PUT("/tools/permissions", controllers.UpdateAIToolPermission)
+		ai.POST("/tools/confirm", controllers.ConfirmAIToolCalls)
+
 		// AI聊天历史
 		ai.GET("/history", controllers.GetAIChatHistory)
 
+		// AI对话会话
+		ai.GET("/sessions", controllers.ListAISessions)
+		ai.POST("/sessions", controllers.CreateAISession)
+		ai.PUT("/sessions/:id", controllers.RenameAISession)
+		ai.GET("/sessions/:id/export", controllers.ExportAISession)
+		ai.DELETE("/sessions/:id", controllers.DeleteAISession)
+
+		// AI用量统计
+		ai.GET("/usage", controllers.GetAIUsage)
+
 		// AI设置
 		ai.GET("/settings", controllers.GetAISettings)
 		ai.PUT("/settings", controllers.UpdateAISettings)
 
 		// 个人AI助手
 		ai.POST("/personal/chat", controllers.ChatWithPersonalAI)
+		ai.POST("/personal/chat/stream", controllers.StreamPersonalAIChat)
 
 		// 群组AI管理
 		ai.POST("/group/chat", controllers.ChatWithGroupAI)
+		ai.POST("/group/chat/stream", controllers.StreamGroupAIChat)
 
 		// 游戏AI陪玩
 		ai.POST("/game/chat", controllers.ChatWithGameAI)
+		ai.POST("/game/chat/stream", controllers.StreamGameAIChat)
 	}
 }
@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"allinone_backend/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterTokenRoutes 注册刷新令牌路由，使用刷新令牌认证，无需访问令牌
+func RegisterTokenRoutes(r *gin.RouterGroup) {
+	r.POST("/auth/refresh", controllers.RefreshToken)
+}
+
+// RegisterSessionRoutes 注册退出登录和设备管理路由
+func RegisterSessionRoutes(r *gin.RouterGroup) {
+	auth := r.Group("/auth")
+	{
+		// 退出当前登录
+		auth.POST("/logout", controllers.Logout)
+
+		// 已登录的设备
+		auth.GET("/sessions", controllers.GetSessions)
+
+		// 远程下线指定设备
+		auth.DELETE("/sessions/:id", controllers.RevokeSession)
+
+		// 下线其他所有设备
+		auth.DELETE("/sessions", controllers.RevokeOtherSessions)
+
+		// 两步验证
+		auth.GET("/2fa", controllers.GetTwoFactorStatus)
+		auth.POST("/2fa/setup", controllers.SetupTwoFactor)
+		auth.POST("/2fa/enable", controllers.EnableTwoFactor)
+		auth.POST("/2fa/disable", controllers.DisableTwoFactor)
+		auth.POST("/2fa/recovery-codes", controllers.RegenerateRecoveryCodes)
+	}
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"allinone_backend/controllers"
+	"allinone_backend/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -32,7 +33,7 @@ func RegisterChatRoutes(r *gin.RouterGroup) {
 		chat.GET("/list", func(c *gin.Context) {
 			c.JSON(200, gin.H{
 				"success": true,
-				"msg":     "获取聊天列表成功",
+				"msg":     middleware.T(c, "chat.list_loaded"),
 				"data": []gin.H{
 					{
 						"id":            1,
@@ -52,7 +53,7 @@ func RegisterChatRoutes(r *gin.RouterGroup) {
 		chat.GET("/messages/:id", func(c *gin.Context) {
 			c.JSON(200, gin.H{
 				"success": true,
-				"msg":     "获取聊天消息成功",
+				"msg":     middleware.T(c, "chat.messages_loaded"),
 				"data": []gin.H{
 					{
 						"id":         1,
@@ -109,6 +110,30 @@ func RegisterChatRoutes(r *gin.RouterGroup) {
 		{
 			emoticon.GET("/packages", controllers.GetEmoticonPackages)
 			emoticon.GET("/list", controllers.GetEmoticons)
+
+			// 表情面板
+			emoticon.GET("/packages/mine", controllers.GetMyEmoticonPackages)
+			emoticon.PUT("/packages/mine/order", controllers.SortEmoticonPackages)
+			emoticon.GET("/packages/:id", controllers.GetEmoticonPackage)
+			emoticon.POST("/packages/:id/add", controllers.AddEmoticonPackage)
+			emoticon.DELETE("/packages/:id", controllers.RemoveEmoticonPackage)
+
+			// 收藏表情
+			emoticon.GET("/favorites", controllers.GetFavoriteEmoticons)
+			emoticon.POST("/favorites", controllers.AddFavoriteEmoticon)
+			emoticon.POST("/favorites/upload", controllers.UploadFavoriteEmoticon)
+			emoticon.PUT("/favorites/order", controllers.SortFavoriteEmoticons)
+			emoticon.DELETE("/favorites/:id", controllers.RemoveFavoriteEmoticon)
+
+			// 管理员维护表情包
+			admin := emoticon.Group("/admin")
+			admin.Use(middleware.AdminOnly())
+			{
+				admin.POST("/packages", controllers.AdminCreateEmoticonPackage)
+				admin.PUT("/packages/:id", controllers.AdminUpdateEmoticonPackage)
+				admin.POST("/packages/:id/emoticons", controllers.AdminAddEmoticon)
+				admin.DELETE("/emoticons/:id", controllers.AdminDeleteEmoticon)
+			}
 		}
 	}
 }
@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"allinone_backend/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterGameRoutes 注册游戏商店相关路由
+func RegisterGameRoutes(r *gin.RouterGroup) {
+	games := r.Group("/games")
+	{
+		// 游戏目录和用户游戏库
+		games.GET("", controllers.GetGames)
+		games.GET("/library", controllers.GetGameLibrary)
+		games.GET("/updates/feed", controllers.GetGameUpdateFeed)
+
+		// 开发者平台
+		developer := games.Group("/developer")
+		{
+			developer.POST("/register", controllers.RegisterGameDeveloper)
+			developer.GET("/profile", controllers.GetGameDeveloperProfile)
+			developer.PUT("/profile", controllers.UpdateGameDeveloperProfile)
+			developer.POST("/achievement-secret", controllers.RotateAchievementSecret)
+			developer.GET("/games", controllers.GetDeveloperGames)
+			developer.POST("/games", controllers.PublishGame)
+			developer.PUT("/games/:id", controllers.UpdateDeveloperGame)
+			developer.POST("/games/:id/achievements", controllers.CreateGameAchievement)
+			developer.PUT("/games/:id/achievements/:achievement_id", controllers.UpdateGameAchievement)
+			developer.POST("/games/:id/updates", controllers.PublishGameUpdate)
+			developer.GET("/games/:id/characters", controllers.GetDeveloperGameCharacters)
+			developer.POST("/games/:id/characters", controllers.CreateGameCharacter)
+			developer.PUT("/games/:id/characters/:character_id", controllers.UpdateGameCharacter)
+			developer.DELETE("/games/:id/characters/:character_id", controllers.DeleteGameCharacter)
+			developer.POST("/games/:id/characters/:character_id/avatar", controllers.UploadGameCharacterAvatar)
+			developer.GET("/stats", controllers.GetDeveloperGameStats)
+			developer.GET("/settlements", controllers.GetDeveloperSettlements)
+		}
+
+		// 游戏详情、购买和游玩状态
+		games.GET("/:id", controllers.GetGameDetail)
+		games.POST("/:id/purchase", controllers.PurchaseGame)
+		games.PUT("/:id/play", controllers.UpdateGamePlayState)
+
+		// 评价
+		games.GET("/:id/reviews", controllers.GetGameReviews)
+		games.POST("/:id/reviews", controllers.ReviewGame)
+		games.DELETE("/:id/reviews", controllers.DeleteGameReview)
+
+		// 成就
+		games.GET("/:id/achievements", controllers.GetGameAchievements)
+		games.POST("/:id/achievements/:achievement_id/unlock", controllers.UnlockGameAchievement)
+
+		// 版本更新
+		games.GET("/:id/updates", controllers.GetGameUpdates)
+
+		// AI角色
+		games.GET("/:id/characters", controllers.GetGameCharacters)
+	}
+}
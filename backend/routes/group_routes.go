@@ -35,5 +35,13 @@ func RegisterGroupRoutes(r *gin.RouterGroup) {
 
 		// 移除群成员
 		group.POST("/remove_member", groupController.RemoveGroupMember)
+
+		// 标记群消息已读
+		group.POST("/read", groupController.MarkGroupRead)
+
+		// 群聊AI助手
+		group.POST("/ai/enable", groupController.EnableGroupAI)
+		group.POST("/ai/disable", groupController.DisableGroupAI)
+		group.POST("/ai/catchup", groupController.CatchUpGroup)
 	}
 }
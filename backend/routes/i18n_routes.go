@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"allinone_backend/controllers"
+	"allinone_backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterI18nRoutes 注册多语言相关路由
+func RegisterI18nRoutes(r *gin.RouterGroup) {
+	i18n := r.Group("/i18n")
+	{
+		// 获取当前语言的提示文本
+		i18n.GET("/messages", controllers.GetI18nMessages)
+
+		// 用户自定义提示文本
+		i18n.GET("/overrides", controllers.GetUserLanguageOverrides)
+		i18n.PUT("/overrides", controllers.UpdateUserLanguageOverrides)
+
+		// 管理员管理语言包
+		admin := i18n.Group("/admin")
+		admin.Use(middleware.AdminOnly())
+		{
+			admin.GET("/packs", controllers.GetLanguagePacks)
+			admin.GET("/packs/:lang", controllers.ExportLanguagePack)
+			admin.POST("/packs/:lang", controllers.ImportLanguagePack)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"allinone_backend/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterMediaRoutes 注册媒体文件相关路由
+func RegisterMediaRoutes(r *gin.RouterGroup) {
+	media := r.Group("/media")
+	{
+		// 获取媒体信息和下载地址
+		media.GET("/:id", controllers.GetMediaInfo)
+
+		// 删除自己上传的媒体
+		media.DELETE("/:id", controllers.DeleteMediaFile)
+	}
+}
+
+// RegisterMediaContentRoutes 注册媒体下载路由，使用签名校验，无需登录
+func RegisterMediaContentRoutes(r *gin.RouterGroup) {
+	r.GET("/media/:id/content", controllers.DownloadMedia)
+}
@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"allinone_backend/controllers"
+	"allinone_backend/middleware"
+	"allinone_backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterMiniAppRoutes 注册小程序相关路由（用户令牌）
+func RegisterMiniAppRoutes(r *gin.RouterGroup) {
+	miniapp := r.Group("/miniapp")
+	{
+		// 小程序列表和最近使用
+		miniapp.GET("/list", controllers.ListMiniApps)
+		miniapp.GET("/recent", controllers.GetRecentMiniApps)
+
+		// 开发者管理自己的小程序
+		developer := miniapp.Group("/developer")
+		{
+			developer.GET("/apps", controllers.GetDeveloperMiniApps)
+			developer.POST("/apps", controllers.RegisterMiniApp)
+			developer.PUT("/apps/:app_id", controllers.UpdateMiniApp)
+		}
+
+		// 小程序支付确认
+		payments := miniapp.Group("/payments")
+		{
+			payments.GET("/:id", controllers.GetMiniAppPayment)
+			payments.POST("/:id/confirm", controllers.ConfirmMiniAppPayment)
+			payments.POST("/:id/cancel", controllers.CancelMiniAppPayment)
+		}
+
+		// 小程序详情、启动和授权
+		miniapp.GET("/app/:app_id", controllers.GetMiniApp)
+		miniapp.POST("/app/:app_id/launch", controllers.LaunchMiniApp)
+		miniapp.POST("/app/:app_id/authorize", controllers.AuthorizeMiniApp)
+		miniapp.DELETE("/app/:app_id/authorize", controllers.RevokeMiniApp)
+	}
+}
+
+// RegisterMiniAppOpenRoutes 注册小程序开放接口（小程序令牌）
+func RegisterMiniAppOpenRoutes(r *gin.RouterGroup) {
+	open := r.Group("/open/miniapp")
+	{
+		open.GET("/profile", middleware.MiniAppAuth(models.MiniAppScopeUserInfo), controllers.MiniAppGetProfile)
+		open.POST("/payments", middleware.MiniAppAuth(models.MiniAppScopeWalletPay), controllers.MiniAppCreatePayment)
+		open.GET("/payments/:id", middleware.MiniAppAuth(models.MiniAppScopeWalletPay), controllers.MiniAppGetPayment)
+	}
+}
@@ -1,6 +1,8 @@
 package routes
 
 import (
+	"allinone_backend/controllers"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -8,47 +10,27 @@ import (
 func RegisterMomentsRoutes(r *gin.RouterGroup) {
 	moments := r.Group("/moments")
 	{
-		// 获取朋友圈动态列表
-		moments.GET("", func(c *gin.Context) {
-			c.JSON(200, gin.H{
-				"success": true,
-				"msg":     "获取朋友圈动态列表成功",
-				"data": []gin.H{
-					{
-						"id":        1,
-						"user_id":   1,
-						"content":   "这是一条朋友圈动态",
-						"images":    []string{"https://picsum.photos/200/300"},
-						"created_at": 1625123456,
-						"likes":     10,
-						"comments":  5,
-					},
-				},
-			})
-		})
+		// 获取朋友圈动态列表（好友时间线）
+		moments.GET("", controllers.GetMoments)
+
+		// 获取某个用户的朋友圈
+		moments.GET("/user/:id", controllers.GetUserMoments)
+
+		// 获取动态详情
+		moments.GET("/detail/:id", controllers.GetMomentDetail)
 
 		// 发布朋友圈动态
-		moments.POST("/post", func(c *gin.Context) {
-			c.JSON(200, gin.H{
-				"success": true,
-				"msg":     "发布朋友圈动态成功",
-			})
-		})
-
-		// 点赞朋友圈动态
-		moments.POST("/like/:id", func(c *gin.Context) {
-			c.JSON(200, gin.H{
-				"success": true,
-				"msg":     "点赞成功",
-			})
-		})
+		moments.POST("/post", controllers.PostMoment)
+
+		// 删除朋友圈动态
+		moments.DELETE("/:id", controllers.DeleteMoment)
+
+		// 点赞/取消点赞朋友圈动态
+		moments.POST("/like/:id", controllers.LikeMoment)
+		moments.DELETE("/like/:id", controllers.UnlikeMoment)
 
 		// 评论朋友圈动态
-		moments.POST("/comment/:id", func(c *gin.Context) {
-			c.JSON(200, gin.H{
-				"success": true,
-				"msg":     "评论成功",
-			})
-		})
+		moments.POST("/comment/:id", controllers.CommentMoment)
+		moments.DELETE("/comment/:id", controllers.DeleteMomentComment)
 	}
 }
@@ -10,8 +10,11 @@ import (
 func RegisterSpeechRoutes(r *gin.RouterGroup) {
 	speech := r.Group("/speech")
 	{
-		// 语音转文字 - 这个路由已经在其他地方注册了，所以这里注释掉
-		// speech.POST("/recognize", controllers.RecognizeSpeech)
+		// 语音转文字，同步返回识别结果
+		speech.POST("/recognize", controllers.RecognizeSpeech)
+
+		// 查询语音识别任务
+		speech.GET("/transcriptions/:id", controllers.GetTranscriptionJob)
 
 		// 发送语音消息（带转录）
 		speech.POST("/send", controllers.SendVoiceMessage)
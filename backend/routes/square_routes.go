@@ -1,6 +1,9 @@
 package routes
 
 import (
+	"allinone_backend/controllers"
+	"allinone_backend/middleware"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -8,51 +11,38 @@ import (
 func RegisterSquareRoutes(r *gin.RouterGroup) {
 	square := r.Group("/square")
 	{
-		// 获取广场动态列表
-		square.GET("/posts", func(c *gin.Context) {
-			c.JSON(200, gin.H{
-				"success": true,
-				"msg":     "获取广场动态列表成功",
-				"data": []gin.H{
-					{
-						"id":         1,
-						"user_id":    1,
-						"user_name":  "用户1",
-						"user_avatar": "https://picsum.photos/200/300",
-						"content":    "这是一条广场动态",
-						"images":     []string{"https://picsum.photos/200/300"},
-						"video_url":  "",
-						"tags":       []string{"标签1", "标签2"},
-						"created_at": 1625123456,
-						"likes":      10,
-						"comments":   5,
-					},
-				},
-			})
-		})
+		// 获取广场动态列表（sort=latest|hot，可按topic筛选）
+		square.GET("/posts", controllers.GetPosts)
+
+		// 获取广场动态详情
+		square.GET("/posts/:id", controllers.GetSquarePostDetail)
 
 		// 发布广场动态
-		square.POST("/posts/create", func(c *gin.Context) {
-			c.JSON(200, gin.H{
-				"success": true,
-				"msg":     "发布广场动态成功",
-			})
-		})
-
-		// 点赞广场动态
-		square.POST("/posts/like/:id", func(c *gin.Context) {
-			c.JSON(200, gin.H{
-				"success": true,
-				"msg":     "点赞成功",
-			})
-		})
+		square.POST("/posts/create", controllers.PostSquare)
+
+		// 删除广场动态
+		square.DELETE("/posts/:id", controllers.DeleteSquarePost)
+
+		// 点赞/取消点赞广场动态
+		square.POST("/posts/like/:id", controllers.LikeSquarePost)
+		square.DELETE("/posts/like/:id", controllers.UnlikeSquarePost)
 
 		// 评论广场动态
-		square.POST("/posts/comment/:id", func(c *gin.Context) {
-			c.JSON(200, gin.H{
-				"success": true,
-				"msg":     "评论成功",
-			})
-		})
+		square.POST("/posts/comment/:id", controllers.CommentSquarePost)
+		square.DELETE("/comments/:id", controllers.DeleteSquareComment)
+
+		// 举报广场动态
+		square.POST("/posts/report/:id", controllers.ReportSquarePost)
+
+		// 热门话题
+		square.GET("/topics/hot", controllers.GetHotTopics)
+
+		// 管理员审核
+		admin := square.Group("/admin")
+		admin.Use(middleware.AdminOnly())
+		{
+			admin.GET("/reports", controllers.GetSquareReports)
+			admin.POST("/posts/:id/review", controllers.ReviewSquarePost)
+		}
 	}
 }
@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"allinone_backend/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterUploadRoutes 注册断点续传相关路由
+func RegisterUploadRoutes(r *gin.RouterGroup) {
+	uploads := r.Group("/uploads")
+	{
+		// 存储空间使用情况
+		uploads.GET("/quota", controllers.GetUploadQuota)
+
+		// 创建上传会话
+		uploads.POST("", controllers.CreateUpload)
+
+		// 查询上传进度
+		uploads.HEAD("/:id", controllers.GetUploadStatus)
+		uploads.GET("/:id", controllers.GetUploadStatus)
+
+		// 上传分片
+		uploads.PATCH("/:id", controllers.PatchUpload)
+
+		// 完成上传
+		uploads.POST("/:id/finalize", controllers.FinalizeUpload)
+
+		// 取消上传
+		uploads.DELETE("/:id", controllers.AbortUpload)
+	}
+}
@@ -21,8 +21,13 @@ func RegisterUserRoutes(r *gin.Engine) {
 		api.POST("/register/check", register.CheckExistsHandler)      // 检查邮箱/手机号是否已注册
 		api.POST("/login", controllers.Login)                         // 旧的登录接口，保留兼容
 		api.POST("/login/new", login.NewLoginHandler)                 // 新的登录接口，支持账号、手机号和邮箱
+		api.POST("/login/2fa", controllers.TwoFactorLogin)            // 提交两步验证码完成登录
 		api.POST("/validate-token", controllers.ValidateToken)        // 验证token接口
 
+		// 忘记密码，通过绑定的邮箱或手机号重置
+		api.POST("/password/reset/code", controllers.SendPasswordResetCode) // 获取重置密码的验证码
+		api.POST("/password/reset", controllers.ResetPassword)              // 使用验证码重置密码
+
 		// 用户相关API
 		user := api.Group("/user")
 		{
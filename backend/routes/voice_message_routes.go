@@ -16,6 +16,12 @@ func RegisterVoiceMessageRoutes(r *gin.RouterGroup) {
 		// 获取语音消息
 		voice.GET("/:id", controllers.GetVoiceMessage)
 		
+		// 重新识别语音消息
+		voice.POST("/:id/transcribe", controllers.TranscribeVoiceMessage)
+
+		// 标记语音消息已收听
+		voice.POST("/:id/listened", controllers.MarkVoiceMessageListened)
+		
 		// 下载语音文件
 		voice.GET("/download/:filename", controllers.DownloadVoiceFile)
 	}
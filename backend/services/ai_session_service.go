@@ -0,0 +1,319 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"context"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AI对话会话、上下文预算和每日用量
+
+const (
+	// 压缩摘要时保留的最近对话条数
+	aiSessionKeepRecent = 4
+	// 默认的上下文token预算
+	aiDefaultContextTokens = 2000
+	// 会话标题的最大长度
+	aiSessionTitleLength = 20
+)
+
+// AIQuotaConfig 每日AI用量限制和单次回复的最大token数，0表示不限制
+type AIQuotaConfig struct {
+	DailyTokens   int
+	DailyRequests int
+	MaxTokens     int // 用户设置的最大token数超出时按此上限
+}
+
+var aiQuotaConfig = AIQuotaConfig{
+	DailyTokens:   100000,
+	DailyRequests: 200,
+	MaxTokens:     4000,
+}
+
+// 初始化函数，从环境变量加载用量限制
+func init() {
+	if v, err := strconv.Atoi(os.Getenv("AI_DAILY_TOKEN_QUOTA")); err == nil && v >= 0 {
+		aiQuotaConfig.DailyTokens = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("AI_DAILY_REQUEST_QUOTA")); err == nil && v >= 0 {
+		aiQuotaConfig.DailyRequests = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("AI_MAX_TOKENS")); err == nil && v >= 0 {
+		aiQuotaConfig.MaxTokens = v
+	}
+}
+
+// SetAIQuotaConfig 设置每日用量限制
+func SetAIQuotaConfig(config AIQuotaConfig) {
+	aiQuotaConfig = config
+}
+
+// GetAIQuotaConfig 获取每日用量限制
+func GetAIQuotaConfig() AIQuotaConfig {
+	return aiQuotaConfig
+}
+
+// ClampAIMaxTokens 将用户设置的最大token数限制在服务端上限内
+func ClampAIMaxTokens(maxTokens int) int {
+	if limit := GetAIQuotaConfig().MaxTokens; limit > 0 && maxTokens > limit {
+		return limit
+	}
+	return maxTokens
+}
+
+// aiUsageDate 用量统计使用的日期
+func aiUsageDate(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// AIUsageTotal 一段时间内的用量合计
+type AIUsageTotal struct {
+	Requests         int `json:"requests"`
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// GetAIUsageTotal 统计用户某天所有提供方的用量
+func GetAIUsageTotal(db *gorm.DB, userID uint, date string) AIUsageTotal {
+	var total AIUsageTotal
+	db.Model(&models.AIUsageDaily{}).
+		Select("COALESCE(SUM(requests), 0) AS requests, COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens, COALESCE(SUM(completion_tokens), 0) AS completion_tokens, COALESCE(SUM(total_tokens), 0) AS total_tokens").
+		Where("user_id = ? AND date = ?", userID, date).
+		Scan(&total)
+	return total
+}
+
+// CheckAIQuota 检查用户今日用量是否超限
+func CheckAIQuota(db *gorm.DB, userID uint) error {
+	config := GetAIQuotaConfig()
+	if config.DailyTokens == 0 && config.DailyRequests == 0 {
+		return nil
+	}
+	today := GetAIUsageTotal(db, userID, aiUsageDate(time.Now()))
+	if (config.DailyTokens > 0 && today.TotalTokens >= config.DailyTokens) ||
+		(config.DailyRequests > 0 && today.Requests >= config.DailyRequests) {
+		return &utils.AppError{Code: http.StatusTooManyRequests, Message: "今日AI使用额度已用完，请明天再试", Key: "ai.daily_quota_exceeded"}
+	}
+	return nil
+}
+
+// RecordAIUsage 累加用户今日在某提供方的用量
+func RecordAIUsage(db *gorm.DB, userID uint, provider string, requests int, usage utils.AIUsage) error {
+	if requests == 0 && usage.TotalTokens == 0 {
+		return nil
+	}
+	now := time.Now()
+	row := models.AIUsageDaily{
+		UserID:           userID,
+		Date:             aiUsageDate(now),
+		Provider:         provider,
+		Requests:         requests,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+		UpdatedAt:        now.Unix(),
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "date"}, {Name: "provider"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"requests":          gorm.Expr("ai_usage_dailies.requests + ?", requests),
+			"prompt_tokens":     gorm.Expr("ai_usage_dailies.prompt_tokens + ?", usage.PromptTokens),
+			"completion_tokens": gorm.Expr("ai_usage_dailies.completion_tokens + ?", usage.CompletionTokens),
+			"total_tokens":      gorm.Expr("ai_usage_dailies.total_tokens + ?", usage.TotalTokens),
+			"updated_at":        now.Unix(),
+		}),
+	}).Create(&row).Error
+}
+
+// AISessionScope 会话所属的对话类型和对象
+type AISessionScope struct {
+	Type        string // personal, group, game
+	GroupID     uint
+	GameID      uint
+	CharacterID uint // 游戏角色，每个角色的会话单独记忆
+}
+
+// CreateAISession 创建新会话，title 为空时使用首条消息作为标题
+func CreateAISession(db *gorm.DB, userID uint, scope AISessionScope, title string) (*models.AIChatSession, error) {
+	now := time.Now().Unix()
+	session := models.AIChatSession{
+		UserID:      userID,
+		Type:        scope.Type,
+		GroupID:     scope.GroupID,
+		GameID:      scope.GameID,
+		CharacterID: scope.CharacterID,
+		Title:       AISessionTitle(title),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := db.Create(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// AISessionTitle 截取会话标题
+func AISessionTitle(text string) string {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\n", " "))
+	runes := []rune(text)
+	if len(runes) > aiSessionTitleLength {
+		return string(runes[:aiSessionTitleLength]) + "…"
+	}
+	return text
+}
+
+// ResolveAISession 获取本次对话使用的会话
+// 指定 sessionID 时校验归属和类型；未指定时沿用该对话最近的会话，没有则新建，
+// 并将引入会话之前的历史记录归入新会话
+func ResolveAISession(db *gorm.DB, userID, sessionID uint, scope AISessionScope, firstMessage string) (*models.AIChatSession, error) {
+	var session models.AIChatSession
+	if sessionID != 0 {
+		if err := db.Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
+			return nil, &utils.AppError{Code: http.StatusNotFound, Message: "会话不存在", Key: "ai_session.not_found"}
+		}
+		if session.Type != scope.Type || session.GroupID != scope.GroupID || session.GameID != scope.GameID || session.CharacterID != scope.CharacterID {
+			return nil, &utils.AppError{Code: http.StatusBadRequest, Message: "会话与当前对话不匹配", Key: "ai_session.type_mismatch"}
+		}
+		if session.Title == "" {
+			db.Model(&session).Update("title", AISessionTitle(firstMessage))
+		}
+		return &session, nil
+	}
+
+	err := db.Where("user_id = ? AND type = ? AND group_id = ? AND game_id = ? AND character_id = ?", userID, scope.Type, scope.GroupID, scope.GameID, scope.CharacterID).
+		Order("last_message_at DESC, id DESC").First(&session).Error
+	if err == nil {
+		return &session, nil
+	}
+
+	created, err := CreateAISession(db, userID, scope, firstMessage)
+	if err != nil {
+		return nil, err
+	}
+	legacy := db.Model(&models.AIChatMessage{}).
+		Where("user_id = ? AND type = ? AND group_id = ? AND game_id = ? AND character_id = ? AND session_id = 0", userID, scope.Type, scope.GroupID, scope.GameID, scope.CharacterID).
+		Update("session_id", created.ID)
+	if legacy.RowsAffected > 0 {
+		created.MessageCount = int(legacy.RowsAffected)
+		db.Model(created).Update("message_count", created.MessageCount)
+	}
+	return created, nil
+}
+
+// RecordAISessionMessage 更新会话的消息数和用量
+func RecordAISessionMessage(db *gorm.DB, sessionID uint, tokens int) {
+	now := time.Now().Unix()
+	db.Model(&models.AIChatSession{}).Where("id = ?", sessionID).Updates(map[string]interface{}{
+		"message_count":   gorm.Expr("message_count + 1"),
+		"tokens_used":     gorm.Expr("tokens_used + ?", tokens),
+		"last_message_at": now,
+		"updated_at":      now,
+	})
+}
+
+// BuildAISessionContext 组装系统提示、会话摘要和最近的对话
+// 超出 budget 时将较早的对话压缩进会话摘要；压缩失败时仅在本次请求中丢弃较早的对话
+func BuildAISessionContext(ctx context.Context, db *gorm.DB, session *models.AIChatSession, provider, systemPrompt, message string, budget int) ([]utils.AIMessage, error) {
+	if budget <= 0 {
+		budget = aiDefaultContextTokens
+	}
+
+	var records []models.AIChatMessage
+	if err := db.Where("session_id = ? AND status = ? AND id > ?", session.ID, "completed", session.SummarizedUntil).
+		Order("id ASC").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	build := func(summary string, records []models.AIChatMessage) []utils.AIMessage {
+		messages := []utils.AIMessage{{Role: "system", Content: systemPrompt}}
+		if summary != "" {
+			messages = append(messages, utils.AIMessage{Role: "system", Content: "之前对话的摘要：" + summary})
+		}
+		for _, r := range records {
+			messages = append(messages, utils.AIMessage{Role: "user", Content: r.Content})
+			messages = append(messages, utils.AIMessage{Role: "assistant", Content: r.Response})
+		}
+		return append(messages, utils.AIMessage{Role: "user", Content: message})
+	}
+
+	messages := build(session.Summary, records)
+	if utils.CountAITokens(provider, messages) <= budget {
+		return messages, nil
+	}
+
+	if len(records) > aiSessionKeepRecent {
+		folded := records[:len(records)-aiSessionKeepRecent]
+		summary, err := summarizeAIConversation(ctx, db, session.UserID, provider, session.Summary, folded)
+		if err == nil {
+			session.Summary = summary
+			session.SummarizedUntil = folded[len(folded)-1].ID
+			db.Model(session).Updates(map[string]interface{}{
+				"summary":          session.Summary,
+				"summarized_until": session.SummarizedUntil,
+			})
+			records = records[len(folded):]
+			messages = build(session.Summary, records)
+		} else if ctx.Err() != nil {
+			return nil, ctx.Err()
+		} else {
+			utils.Logger.Errorf("压缩AI会话失败: session=%d, error=%v", session.ID, err)
+		}
+	}
+
+	// 仍然超出预算时从最早的对话开始丢弃
+	for len(records) > 0 && utils.CountAITokens(provider, messages) > budget {
+		records = records[1:]
+		messages = build(session.Summary, records)
+	}
+	return messages, nil
+}
+
+// summarizeAIConversation 将已有摘要和一段对话合并为新的摘要
+// 摘要消耗的用量计入会话所属用户
+func summarizeAIConversation(ctx context.Context, db *gorm.DB, userID uint, provider, summary string, records []models.AIChatMessage) (string, error) {
+	var transcript strings.Builder
+	if summary != "" {
+		transcript.WriteString("已有摘要：" + summary + "\n\n")
+	}
+	for _, r := range records {
+		transcript.WriteString("用户: " + r.Content + "\n")
+		transcript.WriteString("助手: " + r.Response + "\n")
+	}
+
+	result, err := utils.ChatWithProviders(ctx, provider, utils.AIChatRequest{
+		Messages: []utils.AIMessage{
+			{Role: "system", Content: "请将以下对话压缩为简洁的摘要，保留用户的偏好、关键事实和未完成的事项，不超过300字。"},
+			{Role: "user", Content: transcript.String()},
+		},
+		Temperature: 0.3,
+		MaxTokens:   500,
+	})
+	if err != nil {
+		return "", err
+	}
+	RecordAIUsage(db, userID, result.Provider, 1, result.Usage)
+	return strings.TrimSpace(result.Content), nil
+}
+
+// DeleteAISession 删除会话及其全部聊天记录
+func DeleteAISession(db *gorm.DB, userID, sessionID uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", sessionID, userID).Delete(&models.AIChatSession{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &utils.AppError{Code: http.StatusNotFound, Message: "会话不存在", Key: "ai_session.not_found"}
+		}
+		return tx.Where("session_id = ? AND user_id = ?", sessionID, userID).Delete(&models.AIChatMessage{}).Error
+	})
+}
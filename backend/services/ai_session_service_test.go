@@ -0,0 +1,219 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// summaryAIProvider 返回固定摘要的提供方，err 不为空时调用失败
+type summaryAIProvider struct {
+	summary string
+	err     error
+	calls   int
+}
+
+func (p *summaryAIProvider) Name() string {
+	return "summary"
+}
+
+func (p *summaryAIProvider) Chat(ctx context.Context, req utils.AIChatRequest) (*utils.AIChatResult, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &utils.AIChatResult{
+		Provider: p.Name(),
+		Content:  p.summary,
+		Usage:    utils.AIUsage{PromptTokens: 30, CompletionTokens: 10, TotalTokens: 40},
+	}, nil
+}
+
+// useSummaryAIProvider 只使用 provider 作为AI提供方，测试结束后恢复默认配置
+func useSummaryAIProvider(t *testing.T, provider *summaryAIProvider) {
+	t.Helper()
+	utils.SetAIConfig(utils.AIConfig{})
+	utils.RegisterAIProvider(provider)
+	utils.SetAIProviderChain([]string{provider.Name()})
+	t.Cleanup(func() { utils.SetAIConfig(utils.AIConfig{}) })
+}
+
+// useAIQuotaConfig 使用指定的用量限制，测试结束后恢复
+func useAIQuotaConfig(t *testing.T, config AIQuotaConfig) {
+	t.Helper()
+	previous := GetAIQuotaConfig()
+	SetAIQuotaConfig(config)
+	t.Cleanup(func() { SetAIQuotaConfig(previous) })
+}
+
+// createTestAIChat 在会话中写入 n 条已完成的对话，返回写入的记录
+func createTestAIChat(t *testing.T, db *gorm.DB, session *models.AIChatSession, n int) []models.AIChatMessage {
+	t.Helper()
+	records := make([]models.AIChatMessage, 0, n)
+	for i := 0; i < n; i++ {
+		record := models.AIChatMessage{
+			UserID:    session.UserID,
+			SessionID: session.ID,
+			Type:      session.Type,
+			Content:   fmt.Sprintf("question %02d about the weather today", i),
+			Response:  fmt.Sprintf("answer %02d it will be sunny all day", i),
+			Status:    "completed",
+		}
+		if err := db.Create(&record).Error; err != nil {
+			t.Fatalf("写入对话失败: %v", err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestCheckAIQuota(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	useAIQuotaConfig(t, AIQuotaConfig{DailyTokens: 100, DailyRequests: 3})
+
+	// 昨天的用量不计入今天的额度
+	yesterday := aiUsageDate(time.Now().AddDate(0, 0, -1))
+	db.Create(&models.AIUsageDaily{UserID: alice.ID, Date: yesterday, Provider: "openai", Requests: 10, TotalTokens: 1000})
+	if err := CheckAIQuota(db, alice.ID); err != nil {
+		t.Fatalf("昨天的用量不应影响今天: %v", err)
+	}
+
+	// 不同提供方的用量合并计算
+	RecordAIUsage(db, alice.ID, "openai", 1, utils.AIUsage{PromptTokens: 20, CompletionTokens: 10, TotalTokens: 30})
+	RecordAIUsage(db, alice.ID, "local", 1, utils.AIUsage{TotalTokens: 30})
+	RecordAIUsage(db, alice.ID, "openai", 0, utils.AIUsage{})
+	total := GetAIUsageTotal(db, alice.ID, aiUsageDate(time.Now()))
+	if total.Requests != 2 || total.TotalTokens != 60 || total.PromptTokens != 20 {
+		t.Errorf("今日用量合计不正确: %+v", total)
+	}
+	if err := CheckAIQuota(db, alice.ID); err != nil {
+		t.Errorf("未超出额度时不应报错: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		usage  utils.AIUsage
+		reqs   int
+		config AIQuotaConfig
+		key    string
+	}{
+		{"token用完", utils.AIUsage{TotalTokens: 40}, 0, AIQuotaConfig{DailyTokens: 100, DailyRequests: 10}, "ai.daily_quota_exceeded"},
+		{"请求次数用完", utils.AIUsage{}, 1, AIQuotaConfig{DailyTokens: 1000, DailyRequests: 3}, "ai.daily_quota_exceeded"},
+		{"不限制", utils.AIUsage{}, 0, AIQuotaConfig{}, ""},
+	}
+	for _, tt := range tests {
+		SetAIQuotaConfig(tt.config)
+		RecordAIUsage(db, alice.ID, "openai", tt.reqs, tt.usage)
+		if err := CheckAIQuota(db, alice.ID); appErrorKey(err) != tt.key {
+			t.Errorf("%s: 得到 %v，应为 %q", tt.name, err, tt.key)
+		}
+	}
+
+	SetAIQuotaConfig(AIQuotaConfig{DailyTokens: 100, DailyRequests: 3})
+	if err := CheckAIQuota(db, bob.ID); err != nil {
+		t.Errorf("其他用户的用量不应影响 bob: %v", err)
+	}
+}
+
+func TestClampAIMaxTokens(t *testing.T) {
+	useAIQuotaConfig(t, AIQuotaConfig{MaxTokens: 4000})
+	if got := ClampAIMaxTokens(8000); got != 4000 {
+		t.Errorf("超过上限时应按上限，得到 %d", got)
+	}
+	if got := ClampAIMaxTokens(1000); got != 1000 {
+		t.Errorf("未超过上限时不变，得到 %d", got)
+	}
+	SetAIQuotaConfig(AIQuotaConfig{})
+	if got := ClampAIMaxTokens(8000); got != 8000 {
+		t.Errorf("未设置上限时不变，得到 %d", got)
+	}
+}
+
+func TestBuildAISessionContextSummaryRollover(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+	provider := &summaryAIProvider{summary: "用户关心天气"}
+	useSummaryAIProvider(t, provider)
+
+	session, err := CreateAISession(db, alice.ID, AISessionScope{Type: "personal"}, "天气")
+	if err != nil {
+		t.Fatalf("创建会话失败: %v", err)
+	}
+	records := createTestAIChat(t, db, session, 8)
+
+	// 预算恰好容纳摘要和最近的对话，全部对话放不下
+	recent := records[len(records)-aiSessionKeepRecent:]
+	expected := []utils.AIMessage{
+		{Role: "system", Content: "你是助手"},
+		{Role: "system", Content: "之前对话的摘要：" + provider.summary},
+	}
+	for _, r := range recent {
+		expected = append(expected, utils.AIMessage{Role: "user", Content: r.Content}, utils.AIMessage{Role: "assistant", Content: r.Response})
+	}
+	expected = append(expected, utils.AIMessage{Role: "user", Content: "明天呢"})
+	budget := utils.EstimateAITokens(expected)
+
+	messages, err := BuildAISessionContext(context.Background(), db, session, "", "你是助手", "明天呢", budget)
+	if err != nil {
+		t.Fatalf("组装上下文失败: %v", err)
+	}
+	if fmt.Sprint(messages) != fmt.Sprint(expected) {
+		t.Errorf("应保留摘要和最近 %d 条对话，得到 %v", aiSessionKeepRecent, messages)
+	}
+
+	var saved models.AIChatSession
+	db.First(&saved, session.ID)
+	folded := records[len(records)-aiSessionKeepRecent-1]
+	if saved.Summary != provider.summary || saved.SummarizedUntil != folded.ID {
+		t.Errorf("摘要应保存到会话，得到 summary=%q until=%d", saved.Summary, saved.SummarizedUntil)
+	}
+	if usage := GetAIUsageTotal(db, alice.ID, aiUsageDate(time.Now())); usage.Requests != 1 || usage.TotalTokens != 40 {
+		t.Errorf("压缩摘要的用量应计入会话用户: %+v", usage)
+	}
+
+	// 已压缩的对话不再重复压缩
+	if _, err := BuildAISessionContext(context.Background(), db, &saved, "", "你是助手", "明天呢", budget); err != nil {
+		t.Fatalf("再次组装上下文失败: %v", err)
+	}
+	if provider.calls != 1 {
+		t.Errorf("上下文未超出预算时不应再次压缩，调用了 %d 次", provider.calls)
+	}
+}
+
+func TestBuildAISessionContextSummaryFailure(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+	provider := &summaryAIProvider{err: &utils.AIError{Provider: "summary", Kind: utils.ErrAIUnavailable}}
+	useSummaryAIProvider(t, provider)
+
+	session, _ := CreateAISession(db, alice.ID, AISessionScope{Type: "personal"}, "天气")
+	records := createTestAIChat(t, db, session, 8)
+
+	last := records[len(records)-1]
+	budget := utils.EstimateAITokens([]utils.AIMessage{
+		{Role: "system", Content: "你是助手"},
+		{Role: "user", Content: last.Content},
+		{Role: "assistant", Content: last.Response},
+		{Role: "user", Content: "明天呢"},
+	})
+	messages, err := BuildAISessionContext(context.Background(), db, session, "", "你是助手", "明天呢", budget)
+	if err != nil {
+		t.Fatalf("压缩失败时不应报错: %v", err)
+	}
+	if len(messages) != 4 || messages[1].Content != last.Content {
+		t.Errorf("压缩失败时应从最早的对话开始丢弃，得到 %v", messages)
+	}
+
+	var saved models.AIChatSession
+	db.First(&saved, session.ID)
+	if saved.Summary != "" || saved.SummarizedUntil != 0 {
+		t.Errorf("压缩失败时不应修改会话，得到 summary=%q until=%d", saved.Summary, saved.SummarizedUntil)
+	}
+}
@@ -0,0 +1,552 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AI工具调用
+// 模型通过 function calling 请求调用服务端注册的工具，工具以当前用户身份执行；
+// 涉及隐私或会产生数据的工具需要用户授权，未授权时暂停对话等待用户确认
+
+const (
+	// 单次对话最多的工具调用轮数，超过后要求模型直接回答
+	aiToolMaxRounds = 5
+	// 单个工具的执行超时
+	aiToolTimeout = 10 * time.Second
+)
+
+// 工具调用步骤的状态
+const (
+	AIToolStatusSuccess = "success"
+	AIToolStatusError   = "error"
+	AIToolStatusInvalid = "invalid" // 参数校验失败
+	AIToolStatusUnknown = "unknown" // 工具不存在
+	AIToolStatusDenied  = "denied"  // 用户拒绝
+	AIToolStatusPending = "pending" // 等待用户授权
+)
+
+// AITool 服务端注册的工具
+type AITool struct {
+	Name        string
+	Title       string // 展示给用户的名称
+	Description string
+	Parameters  utils.AIToolSchema
+	// 调用前是否需要用户授权
+	RequiresPermission bool
+	Run                func(ctx context.Context, db *gorm.DB, userID uint, args map[string]interface{}) (interface{}, error)
+}
+
+// Spec 转换为提供给模型的工具声明
+func (t *AITool) Spec() utils.AIToolSpec {
+	return utils.AIToolSpec{
+		Type: "function",
+		Function: utils.AIFunctionSpec{
+			Name:        t.Name,
+			Description: t.Description,
+			Parameters:  t.Parameters,
+		},
+	}
+}
+
+// AIToolStep 一次工具调用的执行记录，按顺序保存在 AIChatMessage.ToolTrace 中
+type AIToolStep struct {
+	Round      int    `json:"round"`
+	CallID     string `json:"call_id"`
+	Tool       string `json:"tool"`
+	Arguments  string `json:"arguments"`
+	Status     string `json:"status"`
+	Result     string `json:"result,omitempty"` // 工具返回值，JSON格式
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+var aiTools = map[string]*AITool{}
+
+// RegisterAITool 注册工具，同名工具会被替换
+func RegisterAITool(tool *AITool) {
+	aiTools[tool.Name] = tool
+}
+
+// GetAITool 按名称获取工具
+func GetAITool(name string) (*AITool, bool) {
+	tool, ok := aiTools[name]
+	return tool, ok
+}
+
+// ListAITools 按名称排序返回全部工具
+func ListAITools() []*AITool {
+	tools := make([]*AITool, 0, len(aiTools))
+	for _, tool := range aiTools {
+		tools = append(tools, tool)
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	return tools
+}
+
+// GetAIToolPermissions 获取用户对各工具的授权，未设置的工具不在结果中
+func GetAIToolPermissions(db *gorm.DB, userID uint) map[string]string {
+	var permissions []models.AIToolPermission
+	db.Where("user_id = ?", userID).Find(&permissions)
+	modes := make(map[string]string, len(permissions))
+	for _, p := range permissions {
+		modes[p.Tool] = p.Mode
+	}
+	return modes
+}
+
+// SetAIToolPermission 设置工具授权，mode 为 allow、deny 或 ask（删除授权，每次询问）
+func SetAIToolPermission(db *gorm.DB, userID uint, tool, mode string) error {
+	if _, ok := GetAITool(tool); !ok {
+		return &utils.AppError{Code: http.StatusNotFound, Message: "工具不存在", Key: "ai_tool.not_found"}
+	}
+	switch mode {
+	case "ask":
+		return db.Where("user_id = ? AND tool = ?", userID, tool).Delete(&models.AIToolPermission{}).Error
+	case "allow", "deny":
+		permission := models.AIToolPermission{UserID: userID, Tool: tool, Mode: mode, UpdatedAt: time.Now().Unix()}
+		return db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "tool"}},
+			DoUpdates: clause.AssignmentColumns([]string{"mode", "updated_at"}),
+		}).Create(&permission).Error
+	default:
+		return &utils.AppError{Code: http.StatusBadRequest, Message: "授权方式只能是 allow、deny 或 ask", Key: "ai_tool.invalid_mode"}
+	}
+}
+
+// AIToolRun 一次带工具调用的对话
+// Trace 为已执行的步骤，可从保存的记录恢复后继续执行
+type AIToolRun struct {
+	UserID   uint
+	Provider string
+	Request  utils.AIChatRequest // 不含工具调用的初始消息
+	Trace    []AIToolStep
+	// 每次调用模型后回调，用于统计用量
+	OnResult func(result *utils.AIChatResult)
+}
+
+// Pending 返回等待用户授权的步骤
+func (r *AIToolRun) Pending() []AIToolStep {
+	var pending []AIToolStep
+	for _, step := range r.Trace {
+		if step.Status == AIToolStatusPending {
+			pending = append(pending, step)
+		}
+	}
+	return pending
+}
+
+// TraceJSON 序列化执行记录，没有调用工具时为空
+func (r *AIToolRun) TraceJSON() string {
+	if len(r.Trace) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(r.Trace)
+	return string(data)
+}
+
+// LoadTrace 从保存的记录恢复执行步骤
+func (r *AIToolRun) LoadTrace(trace string) error {
+	if trace == "" {
+		r.Trace = nil
+		return nil
+	}
+	return json.Unmarshal([]byte(trace), &r.Trace)
+}
+
+// Resolve 处理等待授权的步骤：approve 为 true 时执行，否则标记为拒绝
+func (r *AIToolRun) Resolve(ctx context.Context, db *gorm.DB, approve bool) {
+	for i := range r.Trace {
+		step := &r.Trace[i]
+		if step.Status != AIToolStatusPending {
+			continue
+		}
+		if !approve {
+			step.Status = AIToolStatusDenied
+			step.Error = "用户拒绝了该工具调用"
+			continue
+		}
+		// 保存记录后工具可能已被移除或参数定义已变更
+		tool, ok := GetAITool(step.Tool)
+		if !ok {
+			step.Status = AIToolStatusUnknown
+			step.Error = "工具不存在"
+			continue
+		}
+		args, err := utils.ValidateToolArguments(tool.Parameters, step.Arguments)
+		if err != nil {
+			step.Status = AIToolStatusInvalid
+			step.Error = err.Error()
+			continue
+		}
+		r.runStep(ctx, db, tool, args, step)
+	}
+}
+
+// Continue 执行对话直到模型给出最终回答或需要用户授权
+// 需要授权时返回 nil 结果，通过 Pending 获取待授权的步骤
+func (r *AIToolRun) Continue(ctx context.Context, db *gorm.DB) (*utils.AIChatResult, error) {
+	if len(r.Pending()) > 0 {
+		return nil, nil
+	}
+
+	specs := make([]utils.AIToolSpec, 0, len(aiTools))
+	for _, tool := range ListAITools() {
+		specs = append(specs, tool.Spec())
+	}
+
+	messages := r.replay()
+	round := 0
+	if len(r.Trace) > 0 {
+		round = r.Trace[len(r.Trace)-1].Round + 1
+	}
+
+	for ; ; round++ {
+		req := r.Request
+		req.Messages = messages
+		if round < aiToolMaxRounds {
+			req.Tools = specs
+		}
+
+		result, err := utils.ChatWithProviders(ctx, r.Provider, req)
+		if err != nil {
+			return nil, err
+		}
+		if r.OnResult != nil {
+			r.OnResult(result)
+		}
+		if len(result.ToolCalls) == 0 || round >= aiToolMaxRounds {
+			return result, nil
+		}
+
+		messages = append(messages, utils.AIMessage{Role: "assistant", Content: result.Content, ToolCalls: result.ToolCalls})
+		waiting := false
+		for _, call := range result.ToolCalls {
+			step := r.execute(ctx, db, round, call)
+			r.Trace = append(r.Trace, step)
+			if step.Status == AIToolStatusPending {
+				waiting = true
+				continue
+			}
+			messages = append(messages, step.message())
+		}
+		if waiting {
+			return nil, nil
+		}
+	}
+}
+
+// replay 根据执行记录重建包含工具调用和结果的消息
+func (r *AIToolRun) replay() []utils.AIMessage {
+	messages := append([]utils.AIMessage{}, r.Request.Messages...)
+	for i := 0; i < len(r.Trace); {
+		round := r.Trace[i].Round
+		j := i
+		assistant := utils.AIMessage{Role: "assistant"}
+		for ; j < len(r.Trace) && r.Trace[j].Round == round; j++ {
+			assistant.ToolCalls = append(assistant.ToolCalls, utils.AIToolCall{
+				ID:       r.Trace[j].CallID,
+				Type:     "function",
+				Function: utils.AIFunctionCall{Name: r.Trace[j].Tool, Arguments: r.Trace[j].Arguments},
+			})
+		}
+		messages = append(messages, assistant)
+		for _, step := range r.Trace[i:j] {
+			messages = append(messages, step.message())
+		}
+		i = j
+	}
+	return messages
+}
+
+// execute 校验并执行一次工具调用
+func (r *AIToolRun) execute(ctx context.Context, db *gorm.DB, round int, call utils.AIToolCall) AIToolStep {
+	step := AIToolStep{
+		Round:     round,
+		CallID:    call.ID,
+		Tool:      call.Function.Name,
+		Arguments: call.Function.Arguments,
+	}
+
+	tool, ok := GetAITool(call.Function.Name)
+	if !ok {
+		step.Status = AIToolStatusUnknown
+		step.Error = "工具不存在"
+		return step
+	}
+	args, err := utils.ValidateToolArguments(tool.Parameters, call.Function.Arguments)
+	if err != nil {
+		step.Status = AIToolStatusInvalid
+		step.Error = err.Error()
+		return step
+	}
+
+	if tool.RequiresPermission {
+		switch GetAIToolPermissions(db, r.UserID)[tool.Name] {
+		case "allow":
+		case "deny":
+			step.Status = AIToolStatusDenied
+			step.Error = "用户拒绝了该工具调用"
+			return step
+		default:
+			step.Status = AIToolStatusPending
+			return step
+		}
+	}
+
+	r.runStep(ctx, db, tool, args, &step)
+	return step
+}
+
+// runStep 执行工具并记录结果和耗时
+func (r *AIToolRun) runStep(ctx context.Context, db *gorm.DB, tool *AITool, args map[string]interface{}, step *AIToolStep) {
+	ctx, cancel := context.WithTimeout(ctx, aiToolTimeout)
+	defer cancel()
+
+	start := time.Now()
+	output, err := tool.Run(ctx, db, r.UserID, args)
+	step.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		step.Status = AIToolStatusError
+		var appErr *utils.AppError
+		if errors.As(err, &appErr) {
+			step.Error = appErr.Message
+		} else {
+			utils.Logger.Errorf("AI工具执行失败: tool=%s, error=%v", tool.Name, err)
+			step.Error = "工具执行失败"
+		}
+		return
+	}
+
+	data, err := json.Marshal(output)
+	if err != nil {
+		step.Status = AIToolStatusError
+		step.Error = "工具结果无法序列化"
+		return
+	}
+	step.Status = AIToolStatusSuccess
+	step.Result = string(data)
+}
+
+// message 转换为返回给模型的 tool 消息
+func (s *AIToolStep) message() utils.AIMessage {
+	content := s.Result
+	if s.Status != AIToolStatusSuccess {
+		data, _ := json.Marshal(map[string]string{"error": s.Error})
+		content = string(data)
+	}
+	return utils.AIMessage{Role: "tool", Content: content, ToolCallID: s.CallID}
+}
+
+// 参数读取辅助函数，参数已通过结构校验
+
+func toolArgString(args map[string]interface{}, name, fallback string) string {
+	if v, ok := args[name].(string); ok && v != "" {
+		return v
+	}
+	return fallback
+}
+
+func toolArgInt(args map[string]interface{}, name string, fallback int64) int64 {
+	if v, ok := args[name].(int64); ok {
+		return v
+	}
+	return fallback
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+// 内置工具
+func init() {
+	RegisterAITool(&AITool{
+		Name:        "translate_text",
+		Title:       "文字翻译",
+		Description: "将文本翻译为指定语言",
+		Parameters: utils.AIToolSchema{
+			Type: "object",
+			Properties: map[string]utils.AIToolProperty{
+				"text":        {Type: "string", Description: "要翻译的文本", MaxLength: 5000},
+				"target_lang": {Type: "string", Description: "目标语言代码，如 en、zh、ja"},
+				"source_lang": {Type: "string", Description: "源语言代码，默认自动检测"},
+			},
+			Required: []string{"text", "target_lang"},
+		},
+		Run: func(ctx context.Context, db *gorm.DB, userID uint, args map[string]interface{}) (interface{}, error) {
+			source := toolArgString(args, "source_lang", "auto")
+			target := toolArgString(args, "target_lang", "")
+			result, err := TranslateText(ctx, db, toolArgString(args, "text", ""), source, target)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]string{"translated_text": result.TranslatedText, "source_lang": result.SourceLang, "target_lang": result.TargetLang}, nil
+		},
+	})
+
+	RegisterAITool(&AITool{
+		Name:               "get_wallet_balance",
+		Title:              "查询钱包余额",
+		Description:        "查询当前用户的钱包余额和每日交易限额",
+		Parameters:         utils.AIToolSchema{Type: "object", Properties: map[string]utils.AIToolProperty{}},
+		RequiresPermission: true,
+		Run: func(ctx context.Context, db *gorm.DB, userID uint, args map[string]interface{}) (interface{}, error) {
+			var wallet models.Wallet
+			if err := db.WithContext(ctx).Where("user_id = ?", userID).First(&wallet).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return map[string]interface{}{"balance": 0, "has_wallet": false}, nil
+				}
+				return nil, err
+			}
+			return map[string]interface{}{"balance": wallet.Balance, "daily_limit": wallet.DailyLimit, "has_wallet": true}, nil
+		},
+	})
+
+	RegisterAITool(&AITool{
+		Name:        "list_recent_transactions",
+		Title:       "查询最近交易",
+		Description: "查询当前用户最近的钱包交易记录",
+		Parameters: utils.AIToolSchema{
+			Type: "object",
+			Properties: map[string]utils.AIToolProperty{
+				"limit": {Type: "integer", Description: "返回条数，默认10", Minimum: floatPtr(1), Maximum: floatPtr(20)},
+				"type":  {Type: "string", Description: "交易类型，如 recharge、withdraw、transfer_in、transfer_out"},
+			},
+		},
+		RequiresPermission: true,
+		Run: func(ctx context.Context, db *gorm.DB, userID uint, args map[string]interface{}) (interface{}, error) {
+			query := db.WithContext(ctx).Where("user_id = ?", userID)
+			if txType := toolArgString(args, "type", ""); txType != "" {
+				query = query.Where("type = ?", txType)
+			}
+			var transactions []models.Transaction
+			if err := query.Order("created_at DESC").Limit(int(toolArgInt(args, "limit", 10))).Find(&transactions).Error; err != nil {
+				return nil, err
+			}
+			result := make([]map[string]interface{}, 0, len(transactions))
+			for _, t := range transactions {
+				result = append(result, map[string]interface{}{
+					"amount":      t.Amount,
+					"balance":     t.Balance,
+					"type":        t.Type,
+					"description": t.Description,
+					"status":      t.Status,
+					"time":        time.Unix(t.CreatedAt, 0).Format("2006-01-02 15:04"),
+				})
+			}
+			return map[string]interface{}{"transactions": result}, nil
+		},
+	})
+
+	RegisterAITool(&AITool{
+		Name:        "search_chat_history",
+		Title:       "搜索聊天记录",
+		Description: "按关键词搜索当前用户的私聊和群聊记录，可限定好友或群组",
+		Parameters: utils.AIToolSchema{
+			Type: "object",
+			Properties: map[string]utils.AIToolProperty{
+				"keyword":   {Type: "string", Description: "搜索关键词", MaxLength: 100},
+				"friend_id": {Type: "integer", Description: "只搜索与该用户的私聊", Minimum: floatPtr(1)},
+				"group_id":  {Type: "integer", Description: "只搜索该群组", Minimum: floatPtr(1)},
+				"limit":     {Type: "integer", Description: "返回条数，默认10", Minimum: floatPtr(1), Maximum: floatPtr(20)},
+			},
+			Required: []string{"keyword"},
+		},
+		RequiresPermission: true,
+		Run: func(ctx context.Context, db *gorm.DB, userID uint, args map[string]interface{}) (interface{}, error) {
+			keyword := toolArgString(args, "keyword", "")
+			if keyword == "" {
+				return nil, &utils.AppError{Code: http.StatusBadRequest, Message: "关键词不能为空", Key: "common.keyword_required"}
+			}
+			query := db.WithContext(ctx).Model(&models.ChatMessage{}).
+				Where("type = ? AND content LIKE ?", "text", "%"+keyword+"%")
+
+			groupID := toolArgInt(args, "group_id", 0)
+			friendID := toolArgInt(args, "friend_id", 0)
+			switch {
+			case groupID > 0:
+				var count int64
+				db.Model(&models.GroupMember{}).Where("group_id = ? AND user_id = ?", groupID, userID).Count(&count)
+				if count == 0 {
+					return nil, &utils.AppError{Code: http.StatusForbidden, Message: "不是该群组成员", Key: "group.not_member"}
+				}
+				query = query.Where("group_id = ?", groupID)
+			case friendID > 0:
+				query = query.Where("group_id = 0 AND ((sender_id = ? AND receiver_id = ?) OR (sender_id = ? AND receiver_id = ?))",
+					userID, friendID, friendID, userID)
+			default:
+				groups := db.Model(&models.GroupMember{}).Select("group_id").Where("user_id = ?", userID)
+				query = query.Where("(group_id = 0 AND (sender_id = ? OR receiver_id = ?)) OR group_id IN (?)", userID, userID, groups)
+			}
+
+			var messages []models.ChatMessage
+			if err := query.Order("created_at DESC").Limit(int(toolArgInt(args, "limit", 10))).Find(&messages).Error; err != nil {
+				return nil, err
+			}
+			result := make([]map[string]interface{}, 0, len(messages))
+			for _, m := range messages {
+				result = append(result, map[string]interface{}{
+					"id":          m.ID,
+					"sender_id":   m.SenderID,
+					"receiver_id": m.ReceiverID,
+					"group_id":    m.GroupID,
+					"content":     m.Content,
+					"time":        time.Unix(m.CreatedAt, 0).Format("2006-01-02 15:04"),
+				})
+			}
+			return map[string]interface{}{"messages": result}, nil
+		},
+	})
+
+	RegisterAITool(&AITool{
+		Name:        "create_budget",
+		Title:       "创建预算",
+		Description: "为当前用户创建预算，未指定起止时间时使用当前自然月或自然年",
+		Parameters: utils.AIToolSchema{
+			Type: "object",
+			Properties: map[string]utils.AIToolProperty{
+				"category":    {Type: "string", Description: "预算类别，如 food、shopping、entertainment", MaxLength: 50},
+				"amount":      {Type: "number", Description: "预算金额", Minimum: floatPtr(0.01)},
+				"period":      {Type: "string", Description: "预算周期", Enum: []string{"month", "year"}},
+				"start_date":  {Type: "integer", Description: "开始时间戳（秒）"},
+				"end_date":    {Type: "integer", Description: "结束时间戳（秒）"},
+				"description": {Type: "string", Description: "预算描述", MaxLength: 200},
+			},
+			Required: []string{"category", "amount", "period"},
+		},
+		RequiresPermission: true,
+		Run: func(ctx context.Context, db *gorm.DB, userID uint, args map[string]interface{}) (interface{}, error) {
+			period := toolArgString(args, "period", "month")
+			start, end := CurrentBudgetPeriod(period, time.Now())
+			budget := models.Budget{
+				UserID:      userID,
+				Category:    toolArgString(args, "category", ""),
+				Amount:      args["amount"].(float64),
+				Period:      period,
+				StartDate:   toolArgInt(args, "start_date", start),
+				EndDate:     toolArgInt(args, "end_date", end),
+				Description: toolArgString(args, "description", ""),
+			}
+			if err := CreateBudget(db.WithContext(ctx), &budget); err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{
+				"budget_id":  budget.ID,
+				"category":   budget.Category,
+				"amount":     budget.Amount,
+				"period":     budget.Period,
+				"start_date": time.Unix(budget.StartDate, 0).Format("2006-01-02"),
+				"end_date":   time.Unix(budget.EndDate, 0).Format("2006-01-02"),
+			}, nil
+		},
+	})
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"allinone_backend/utils"
+	"context"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// 授权时工具已被移除或参数不再合法，步骤标记为失败而不是执行
+func TestAIToolRunResolve(t *testing.T) {
+	db := newTestDB(t)
+	ran := 0
+	RegisterAITool(&AITool{
+		Name: "test_echo",
+		Parameters: utils.AIToolSchema{
+			Type:       "object",
+			Properties: map[string]utils.AIToolProperty{"text": {Type: "string"}},
+			Required:   []string{"text"},
+		},
+		RequiresPermission: true,
+		Run: func(ctx context.Context, db *gorm.DB, userID uint, args map[string]interface{}) (interface{}, error) {
+			ran++
+			return args["text"], nil
+		},
+	})
+	defer delete(aiTools, "test_echo")
+
+	run := &AIToolRun{UserID: 1, Trace: []AIToolStep{
+		{CallID: "1", Tool: "test_echo", Arguments: `{"text":"hi"}`, Status: AIToolStatusPending},
+		{CallID: "2", Tool: "removed_tool", Arguments: `{}`, Status: AIToolStatusPending},
+		{CallID: "3", Tool: "test_echo", Arguments: `{"text":1}`, Status: AIToolStatusPending},
+		{CallID: "4", Tool: "test_echo", Arguments: `{"text":"done"}`, Status: AIToolStatusSuccess},
+	}}
+	run.Resolve(context.Background(), db, true)
+
+	want := []string{AIToolStatusSuccess, AIToolStatusUnknown, AIToolStatusInvalid, AIToolStatusSuccess}
+	for i, step := range run.Trace {
+		if step.Status != want[i] {
+			t.Errorf("步骤 %s 的状态为 %q，应为 %q", step.CallID, step.Status, want[i])
+		}
+	}
+	if ran != 1 {
+		t.Errorf("工具执行了 %d 次，应为 1 次", ran)
+	}
+	if len(run.Pending()) != 0 {
+		t.Error("处理后仍有等待授权的步骤")
+	}
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CreateBudget 校验并创建预算，同一类别的预算时间段不能重叠
+func CreateBudget(db *gorm.DB, budget *models.Budget) error {
+	if budget.Amount <= 0 {
+		return &utils.AppError{Code: http.StatusBadRequest, Message: "预算金额必须大于0", Key: "budget.invalid_amount"}
+	}
+	if budget.StartDate >= budget.EndDate {
+		return &utils.AppError{Code: http.StatusBadRequest, Message: "开始日期必须早于结束日期", Key: "budget.invalid_date_range"}
+	}
+	if budget.Period != "month" && budget.Period != "year" {
+		return &utils.AppError{Code: http.StatusBadRequest, Message: "预算周期无效，只支持 month 或 year", Key: "budget.invalid_period"}
+	}
+
+	// 检查是否已存在相同类别和时间段的预算
+	var existingBudget models.Budget
+	result := db.Where("user_id = ? AND category = ? AND ((start_date <= ? AND end_date >= ?) OR (start_date <= ? AND end_date >= ?) OR (start_date >= ? AND end_date <= ?))",
+		budget.UserID, budget.Category, budget.StartDate, budget.StartDate, budget.EndDate, budget.EndDate, budget.StartDate, budget.EndDate).
+		First(&existingBudget)
+	if result.Error == nil {
+		return &utils.AppError{Code: http.StatusBadRequest, Message: "已存在相同类别和时间段的预算", Key: "budget.duplicate"}
+	}
+
+	now := time.Now().Unix()
+	budget.CreatedAt = now
+	budget.UpdatedAt = now
+	return db.Create(budget).Error
+}
+
+// CurrentBudgetPeriod 返回当前自然月或自然年的起止时间戳
+func CurrentBudgetPeriod(period string, now time.Time) (int64, int64) {
+	if period == "year" {
+		start := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
+		return start.Unix(), start.AddDate(1, 0, 0).Unix() - 1
+	}
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	return start.Unix(), start.AddDate(0, 1, 0).Unix() - 1
+}
@@ -27,23 +27,17 @@ func SendSingleMessage(db *gorm.DB, msg *models.ChatMessage) error {
 		return err
 	}
 
-	// 通过WebSocket推送消息
+	// 通过WebSocket推送消息，接收者开启自动翻译时附带译文
 	go func() {
-		// 构造WebSocket消息
-		wsMessage := map[string]any{
-			"type": "new_message",
-			"data": map[string]any{
-				"id":         msg.ID,
-				"from_id":    msg.SenderID,
-				"to_id":      msg.ReceiverID,
-				"content":    msg.Content,
-				"type":       msg.Type,
-				"created_at": msg.CreatedAt,
-			},
+		data := map[string]any{
+			"id":         msg.ID,
+			"from_id":    msg.SenderID,
+			"to_id":      msg.ReceiverID,
+			"content":    msg.Content,
+			"type":       msg.Type,
+			"created_at": msg.CreatedAt,
 		}
-
-		// 推送消息给接收者
-		utils.PushMessageToUser(msg.ReceiverID, wsMessage)
+		PushChatMessage(db, msg, []uint{msg.ReceiverID}, "new_message", data)
 	}()
 
 	return nil
@@ -67,33 +61,23 @@ func SendGroupMessage(db *gorm.DB, msg *models.ChatMessage) error {
 
 	// 通过WebSocket推送消息
 	go func() {
-		// 查询群组所有成员
-		var groupMembers []models.GroupMember
-		db.Where("group_id = ?", msg.GroupID).Find(&groupMembers)
-
-		// 构造WebSocket消息
-		wsMessage := map[string]any{
-			"type": "new_group_message",
-			"data": map[string]any{
-				"id":              msg.ID,
-				"sender_id":       msg.SenderID,
-				"group_id":        msg.GroupID,
-				"content":         msg.Content,
-				"type":            msg.Type,
-				"extra":           msg.Extra,
-				"mentioned_users": msg.MentionedUsers,
-				"status":          msg.Status,
-				"created_at":      msg.CreatedAt,
-			},
-		}
-
-		// 向每个群成员推送消息
-		for _, member := range groupMembers {
-			// 不向发送者推送消息
-			if member.UserID != msg.SenderID {
-				utils.PushMessageToUser(member.UserID, wsMessage)
-			}
+		// 查询群组所有成员，不向发送者推送消息
+		var recipients []uint
+		db.Model(&models.GroupMember{}).Where("group_id = ? AND user_id <> ?", msg.GroupID, msg.SenderID).Pluck("user_id", &recipients)
+
+		// 开启自动翻译的成员按各自的语言附带译文
+		data := map[string]any{
+			"id":              msg.ID,
+			"sender_id":       msg.SenderID,
+			"group_id":        msg.GroupID,
+			"content":         msg.Content,
+			"type":            msg.Type,
+			"extra":           msg.Extra,
+			"mentioned_users": msg.MentionedUsers,
+			"status":          msg.Status,
+			"created_at":      msg.CreatedAt,
 		}
+		PushChatMessage(db, msg, recipients, "new_group_message", data)
 	}()
 
 	return nil
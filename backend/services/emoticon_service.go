@@ -0,0 +1,402 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 表情包和收藏表情
+// 表情图片经过图片处理后保存为公开媒体，GIF 动图原样保留，缩略图用于表情面板
+// 免费表情包直接添加到面板，付费表情包从钱包扣款并记录订单；收藏表情可以上传或收藏已有的表情
+
+// 用户最多收藏的表情数
+const maxFavoriteEmoticons = 300
+
+// EmoticonPackageView 表情包及当前用户的购买、添加状态
+type EmoticonPackageView struct {
+	models.EmoticonPackage
+	Owned bool `json:"owned"` // 免费或已购买
+	Added bool `json:"added"` // 已添加到表情面板
+}
+
+// ListEmoticonPackages 商店中已上架的表情包
+func ListEmoticonPackages(db *gorm.DB, userID uint) ([]EmoticonPackageView, error) {
+	var packages []models.EmoticonPackage
+	if err := db.Where("status = ?", "published").Order("sort_order ASC, id DESC").Find(&packages).Error; err != nil {
+		return nil, err
+	}
+	var added, ordered []uint
+	db.Model(&models.UserEmoticonPackage{}).Where("user_id = ?", userID).Pluck("package_id", &added)
+	db.Model(&models.EmoticonOrder{}).Where("user_id = ? AND status = ?", userID, "paid").Pluck("package_id", &ordered)
+	addedSet := make(map[uint]bool, len(added))
+	for _, id := range added {
+		addedSet[id] = true
+	}
+	orderedSet := make(map[uint]bool, len(ordered))
+	for _, id := range ordered {
+		orderedSet[id] = true
+	}
+
+	views := make([]EmoticonPackageView, 0, len(packages))
+	for _, pkg := range packages {
+		views = append(views, EmoticonPackageView{
+			EmoticonPackage: pkg,
+			Owned:           pkg.Price <= 0 || orderedSet[pkg.ID],
+			Added:           addedSet[pkg.ID],
+		})
+	}
+	return views, nil
+}
+
+// GetEmoticonPackage 获取表情包，未上架的表情包只有已购买或已添加的用户可以查看
+func GetEmoticonPackage(db *gorm.DB, userID, packageID uint) (*models.EmoticonPackage, error) {
+	var pkg models.EmoticonPackage
+	if err := db.First(&pkg, packageID).Error; err != nil {
+		return nil, &utils.AppError{Code: http.StatusNotFound, Message: "表情包不存在", Key: "emoticon.pack_not_found"}
+	}
+	if pkg.Status != "published" && !OwnsEmoticonPackage(db, userID, &pkg) {
+		return nil, &utils.AppError{Code: http.StatusNotFound, Message: "表情包不存在", Key: "emoticon.pack_not_found"}
+	}
+	return &pkg, nil
+}
+
+// ListPackageEmoticons 表情包中的表情
+func ListPackageEmoticons(db *gorm.DB, packageIDs ...uint) []models.Emoticon {
+	emoticons := []models.Emoticon{}
+	if len(packageIDs) == 0 {
+		return emoticons
+	}
+	db.Where("package_id IN ?", packageIDs).Order("package_id ASC, sort_order ASC, id ASC").Find(&emoticons)
+	return emoticons
+}
+
+// GetUserEmoticonPackages 用户表情面板中的表情包，按用户设置的顺序排列
+func GetUserEmoticonPackages(db *gorm.DB, userID uint) ([]models.EmoticonPackage, error) {
+	var packages []models.EmoticonPackage
+	err := db.Table("emoticon_packages").
+		Joins("JOIN user_emoticon_packages ON user_emoticon_packages.package_id = emoticon_packages.id").
+		Where("user_emoticon_packages.user_id = ?", userID).
+		Order("user_emoticon_packages.sort_order ASC, user_emoticon_packages.id ASC").
+		Select("emoticon_packages.*").
+		Find(&packages).Error
+	return packages, err
+}
+
+// AddEmoticonPackage 将表情包添加到用户的表情面板，付费表情包首次添加时从钱包扣款
+// 返回本次扣款的金额，已购买过或免费时为0
+func AddEmoticonPackage(db *gorm.DB, userID uint, pkg *models.EmoticonPackage) (float64, error) {
+	var charged float64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		tx.Model(&models.UserEmoticonPackage{}).Where("user_id = ? AND package_id = ?", userID, pkg.ID).Count(&count)
+		if count > 0 {
+			return &utils.AppError{Code: http.StatusBadRequest, Message: "表情包已添加", Key: "emoticon.pack_already_added"}
+		}
+
+		now := time.Now().Unix()
+		if !OwnsEmoticonPackage(tx, userID, pkg) {
+			if pkg.Status != "published" {
+				return &utils.AppError{Code: http.StatusBadRequest, Message: "表情包已下架", Key: "emoticon.pack_unpublished"}
+			}
+			if pkg.Price > 0 {
+				order := models.EmoticonOrder{
+					UserID:    userID,
+					PackageID: pkg.ID,
+					Amount:    pkg.Price,
+					Status:    "paid",
+					CreatedAt: now,
+				}
+				if err := tx.Create(&order).Error; err != nil {
+					return err
+				}
+				description := fmt.Sprintf("购买表情包「%s」", pkg.Name)
+				if _, err := DebitWallet(tx, userID, pkg.Price, "emoticon_purchase", order.ID, description); err != nil {
+					return err
+				}
+				charged = pkg.Price
+			}
+		}
+
+		// 新添加的表情包排在面板最后
+		var maxOrder struct{ Value int }
+		tx.Model(&models.UserEmoticonPackage{}).Where("user_id = ?", userID).
+			Select("COALESCE(MAX(sort_order), -1) AS value").Scan(&maxOrder)
+		return tx.Create(&models.UserEmoticonPackage{
+			UserID:    userID,
+			PackageID: pkg.ID,
+			SortOrder: maxOrder.Value + 1,
+			CreatedAt: now,
+		}).Error
+	})
+	return charged, err
+}
+
+// RemoveEmoticonPackage 将表情包移出表情面板，购买记录保留
+func RemoveEmoticonPackage(db *gorm.DB, userID, packageID uint) error {
+	result := db.Where("user_id = ? AND package_id = ?", userID, packageID).Delete(&models.UserEmoticonPackage{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return &utils.AppError{Code: http.StatusNotFound, Message: "表情包未添加", Key: "emoticon.pack_not_added"}
+	}
+	return nil
+}
+
+// SortUserEmoticonPackages 调整表情面板中表情包的顺序，未列出的表情包保持原顺序排在后面
+func SortUserEmoticonPackages(db *gorm.DB, userID uint, packageIDs []uint) error {
+	return applyUserSortOrder(db, &models.UserEmoticonPackage{}, "package_id", userID, packageIDs)
+}
+
+// OwnsEmoticonPackage 用户是否可以使用表情包：免费、已购买或已添加到面板
+func OwnsEmoticonPackage(db *gorm.DB, userID uint, pkg *models.EmoticonPackage) bool {
+	if pkg.Price <= 0 && pkg.Status == "published" {
+		return true
+	}
+	var count int64
+	db.Model(&models.EmoticonOrder{}).Where("user_id = ? AND package_id = ? AND status = ?", userID, pkg.ID, "paid").Count(&count)
+	if count > 0 {
+		return true
+	}
+	db.Model(&models.UserEmoticonPackage{}).Where("user_id = ? AND package_id = ?", userID, pkg.ID).Count(&count)
+	return count > 0
+}
+
+// CreateEmoticon 上传表情图片并加入表情包，packageID 为0时为用户的收藏表情
+func CreateEmoticon(ctx context.Context, db *gorm.DB, ownerID, packageID uint, body io.Reader, fileName, name string) (*models.Emoticon, error) {
+	media, err := StoreImage(ctx, db, ownerID, body, fileName, utils.ImageKindSticker, MediaTarget{Scope: MediaScopePublic})
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+	}
+	emoticon := models.Emoticon{
+		PackageID: packageID,
+		OwnerID:   ownerID,
+		Name:      name,
+		MediaID:   media.ID,
+		URL:       MediaRef(media),
+		ThumbURL:  MediaRef(media),
+		Width:     media.Width,
+		Height:    media.Height,
+		CreatedAt: time.Now().Unix(),
+	}
+	if thumb, ok := MediaRenditions(db, media.ID)["thumb"]; ok {
+		emoticon.ThumbURL = MediaRef(&thumb)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if packageID != 0 {
+			var pkg models.EmoticonPackage
+			if err := tx.First(&pkg, packageID).Error; err != nil {
+				return &utils.AppError{Code: http.StatusNotFound, Message: "表情包不存在", Key: "emoticon.pack_not_found"}
+			}
+			emoticon.SortOrder = pkg.Count
+			updates := map[string]interface{}{"count": gorm.Expr("count + 1"), "updated_at": time.Now().Unix()}
+			if pkg.Cover == "" {
+				updates["cover"] = emoticon.ThumbURL
+			}
+			if err := tx.Model(&pkg).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&emoticon).Error
+	})
+	if err != nil {
+		DeleteMedia(ctx, db, ownerID, media.ID)
+		return nil, err
+	}
+	return &emoticon, nil
+}
+
+// DeleteEmoticon 从表情包中删除表情，已发送的消息仍可显示，图片不删除
+func DeleteEmoticon(db *gorm.DB, emoticonID uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var emoticon models.Emoticon
+		if err := tx.Where("id = ? AND package_id <> 0", emoticonID).First(&emoticon).Error; err != nil {
+			return &utils.AppError{Code: http.StatusNotFound, Message: "表情不存在", Key: "emoticon.not_found"}
+		}
+		if err := tx.Delete(&emoticon).Error; err != nil {
+			return err
+		}
+		tx.Where("emoticon_id = ?", emoticon.ID).Delete(&models.UserFavoriteEmoticon{})
+		return tx.Model(&models.EmoticonPackage{}).Where("id = ?", emoticon.PackageID).
+			Updates(map[string]interface{}{"count": gorm.Expr("count - 1"), "updated_at": time.Now().Unix()}).Error
+	})
+}
+
+// ListFavoriteEmoticons 用户收藏的表情，按用户设置的顺序排列
+func ListFavoriteEmoticons(db *gorm.DB, userID uint) ([]models.Emoticon, error) {
+	emoticons := []models.Emoticon{}
+	err := db.Table("emoticons").
+		Joins("JOIN user_favorite_emoticons ON user_favorite_emoticons.emoticon_id = emoticons.id").
+		Where("user_favorite_emoticons.user_id = ?", userID).
+		Order("user_favorite_emoticons.sort_order ASC, user_favorite_emoticons.id DESC").
+		Select("emoticons.*").
+		Find(&emoticons).Error
+	return emoticons, err
+}
+
+// UploadFavoriteEmoticon 上传图片作为收藏表情，重复上传同一张图片时收藏已有的表情
+func UploadFavoriteEmoticon(ctx context.Context, db *gorm.DB, userID uint, body io.Reader, fileName string) (*models.Emoticon, error) {
+	if err := checkFavoriteLimit(db, userID); err != nil {
+		return nil, err
+	}
+	emoticon, err := CreateEmoticon(ctx, db, userID, 0, body, fileName, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// 同一张图片保存的媒体内容哈希相同
+	var hash string
+	db.Model(&models.Media{}).Where("id = ?", emoticon.MediaID).Pluck("hash", &hash)
+	sameMedia := db.Model(&models.Media{}).Select("id").Where("owner_id = ? AND hash = ?", userID, hash)
+	var existing models.Emoticon
+	err = db.Where("owner_id = ? AND package_id = 0 AND id <> ? AND media_id IN (?)", userID, emoticon.ID, sameMedia).
+		First(&existing).Error
+	if err == nil {
+		db.Delete(emoticon)
+		DeleteMedia(ctx, db, userID, emoticon.MediaID)
+		emoticon = &existing
+	}
+
+	if err := favoriteEmoticon(db, userID, emoticon.ID); err != nil {
+		return nil, err
+	}
+	return emoticon, nil
+}
+
+// AddFavoriteEmoticon 收藏已有的表情，如聊天中收到的表情
+func AddFavoriteEmoticon(db *gorm.DB, userID, emoticonID uint) (*models.Emoticon, error) {
+	var emoticon models.Emoticon
+	if err := db.First(&emoticon, emoticonID).Error; err != nil {
+		return nil, &utils.AppError{Code: http.StatusNotFound, Message: "表情不存在", Key: "emoticon.not_found"}
+	}
+	if err := checkFavoriteLimit(db, userID); err != nil {
+		return nil, err
+	}
+	if err := favoriteEmoticon(db, userID, emoticon.ID); err != nil {
+		return nil, err
+	}
+	return &emoticon, nil
+}
+
+// RemoveFavoriteEmoticon 取消收藏
+func RemoveFavoriteEmoticon(db *gorm.DB, userID, emoticonID uint) error {
+	result := db.Where("user_id = ? AND emoticon_id = ?", userID, emoticonID).Delete(&models.UserFavoriteEmoticon{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return &utils.AppError{Code: http.StatusNotFound, Message: "表情未收藏", Key: "emoticon.not_favorited"}
+	}
+	return nil
+}
+
+// SortFavoriteEmoticons 调整收藏表情的顺序
+func SortFavoriteEmoticons(db *gorm.DB, userID uint, emoticonIDs []uint) error {
+	return applyUserSortOrder(db, &models.UserFavoriteEmoticon{}, "emoticon_id", userID, emoticonIDs)
+}
+
+func checkFavoriteLimit(db *gorm.DB, userID uint) error {
+	var count int64
+	db.Model(&models.UserFavoriteEmoticon{}).Where("user_id = ?", userID).Count(&count)
+	if count >= maxFavoriteEmoticons {
+		return &utils.AppError{Code: http.StatusBadRequest, Message: fmt.Sprintf("最多收藏%d个表情", maxFavoriteEmoticons), Key: "emoticon.too_many_favorites", Args: []interface{}{maxFavoriteEmoticons}}
+	}
+	return nil
+}
+
+// favoriteEmoticon 收藏表情，新收藏的排在最前
+func favoriteEmoticon(db *gorm.DB, userID, emoticonID uint) error {
+	var count int64
+	db.Model(&models.UserFavoriteEmoticon{}).Where("user_id = ? AND emoticon_id = ?", userID, emoticonID).Count(&count)
+	if count > 0 {
+		return &utils.AppError{Code: http.StatusBadRequest, Message: "表情已收藏", Key: "emoticon.already_favorited"}
+	}
+	var minOrder struct{ Value int }
+	db.Model(&models.UserFavoriteEmoticon{}).Where("user_id = ?", userID).
+		Select("COALESCE(MIN(sort_order), 1) AS value").Scan(&minOrder)
+	return db.Create(&models.UserFavoriteEmoticon{
+		UserID:     userID,
+		EmoticonID: emoticonID,
+		SortOrder:  minOrder.Value - 1,
+		CreatedAt:  time.Now().Unix(),
+	}).Error
+}
+
+// applyUserSortOrder 按 ids 的顺序重排用户的记录，未列出的记录保持原顺序排在后面
+func applyUserSortOrder(db *gorm.DB, model interface{}, column string, userID uint, ids []uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var current []uint
+		if err := tx.Model(model).Where("user_id = ?", userID).Order("sort_order ASC, id ASC").Pluck(column, &current).Error; err != nil {
+			return err
+		}
+		exists := make(map[uint]bool, len(current))
+		for _, id := range current {
+			exists[id] = true
+		}
+		ordered := make([]uint, 0, len(current))
+		seen := make(map[uint]bool, len(current))
+		for _, id := range append(ids, current...) {
+			if exists[id] && !seen[id] {
+				seen[id] = true
+				ordered = append(ordered, id)
+			}
+		}
+		for i, id := range ordered {
+			if err := tx.Model(model).Where("user_id = ? AND "+column+" = ?", userID, id).Update("sort_order", i).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CanUseEmoticon 用户是否可以发送表情
+// 表情包中的表情需要拥有表情包；收藏表情需要是上传者或已收藏
+func CanUseEmoticon(db *gorm.DB, userID uint, emoticon *models.Emoticon) bool {
+	if emoticon.PackageID == 0 {
+		if emoticon.OwnerID == userID {
+			return true
+		}
+		var count int64
+		db.Model(&models.UserFavoriteEmoticon{}).Where("user_id = ? AND emoticon_id = ?", userID, emoticon.ID).Count(&count)
+		return count > 0
+	}
+	var pkg models.EmoticonPackage
+	if err := db.First(&pkg, emoticon.PackageID).Error; err != nil {
+		return false
+	}
+	return OwnsEmoticonPackage(db, userID, &pkg)
+}
+
+// AttachMessageEmoticon 校验表情消息引用的表情，返回写入消息 Extra 的表情信息
+func AttachMessageEmoticon(db *gorm.DB, senderID, emoticonID uint) (*models.Emoticon, map[string]interface{}, error) {
+	var emoticon models.Emoticon
+	if err := db.First(&emoticon, emoticonID).Error; err != nil {
+		return nil, nil, &utils.AppError{Code: http.StatusNotFound, Message: "表情不存在", Key: "emoticon.not_found"}
+	}
+	if !CanUseEmoticon(db, senderID, &emoticon) {
+		return nil, nil, &utils.AppError{Code: http.StatusForbidden, Message: "未拥有该表情", Key: "emoticon.not_owned"}
+	}
+	return &emoticon, map[string]interface{}{
+		"emoticon_id": emoticon.ID,
+		"package_id":  emoticon.PackageID,
+		"name":        emoticon.Name,
+		"url":         emoticon.URL,
+		"thumb_url":   emoticon.ThumbURL,
+		"width":       emoticon.Width,
+		"height":      emoticon.Height,
+	}, nil
+}
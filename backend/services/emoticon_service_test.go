@@ -0,0 +1,178 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"fmt"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// createTestEmoticonPackage 创建表情包，并加入一个表情
+func createTestEmoticonPackage(t *testing.T, db *gorm.DB, name string, price float64, status string) (*models.EmoticonPackage, *models.Emoticon) {
+	t.Helper()
+	pkg := &models.EmoticonPackage{Name: name, Price: price, Status: status, Count: 1}
+	if err := db.Create(pkg).Error; err != nil {
+		t.Fatalf("创建表情包失败: %v", err)
+	}
+	emoticon := &models.Emoticon{PackageID: pkg.ID, Name: name + "1", URL: "/api/media/1"}
+	if err := db.Create(emoticon).Error; err != nil {
+		t.Fatalf("创建表情失败: %v", err)
+	}
+	return pkg, emoticon
+}
+
+// userPackageIDs 用户表情面板中表情包的顺序
+func userPackageIDs(t *testing.T, db *gorm.DB, userID uint) []uint {
+	t.Helper()
+	packages, err := GetUserEmoticonPackages(db, userID)
+	if err != nil {
+		t.Fatalf("获取表情面板失败: %v", err)
+	}
+	ids := make([]uint, 0, len(packages))
+	for _, pkg := range packages {
+		ids = append(ids, pkg.ID)
+	}
+	return ids
+}
+
+func TestAddEmoticonPackagePurchase(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	fundTestWallet(t, db, alice.ID, 10, "")
+	fundTestWallet(t, db, bob.ID, 1, "")
+	pkg, emoticon := createTestEmoticonPackage(t, db, "猫", 6, "published")
+
+	if _, _, err := AttachMessageEmoticon(db, alice.ID, emoticon.ID); appErrorKey(err) != "emoticon.not_owned" {
+		t.Errorf("未购买时不能发送付费表情，得到 %v", err)
+	}
+
+	charged, err := AddEmoticonPackage(db, alice.ID, pkg)
+	if err != nil {
+		t.Fatalf("购买表情包失败: %v", err)
+	}
+	if charged != 6 || walletBalance(db, alice.ID) != 4 {
+		t.Errorf("应扣款6元，得到 charged=%v balance=%v", charged, walletBalance(db, alice.ID))
+	}
+	if _, err := AddEmoticonPackage(db, alice.ID, pkg); appErrorKey(err) != "emoticon.pack_already_added" {
+		t.Errorf("重复添加应报错，得到 %v", err)
+	}
+	if _, extra, err := AttachMessageEmoticon(db, alice.ID, emoticon.ID); err != nil || extra["package_id"] != pkg.ID {
+		t.Errorf("购买后应可以发送表情，得到 %v %v", extra, err)
+	}
+
+	// 移出面板后重新添加不再扣款，表情包下架后已购买的用户仍可添加
+	if err := RemoveEmoticonPackage(db, alice.ID, pkg.ID); err != nil {
+		t.Fatalf("移出表情包失败: %v", err)
+	}
+	if err := RemoveEmoticonPackage(db, alice.ID, pkg.ID); appErrorKey(err) != "emoticon.pack_not_added" {
+		t.Errorf("未添加的表情包移出应报错，得到 %v", err)
+	}
+	db.Model(pkg).Update("status", "unpublished")
+	if charged, err := AddEmoticonPackage(db, alice.ID, pkg); err != nil || charged != 0 || walletBalance(db, alice.ID) != 4 {
+		t.Errorf("已购买的表情包重新添加不应扣款，得到 charged=%v %v", charged, err)
+	}
+	if _, err := GetEmoticonPackage(db, alice.ID, pkg.ID); err != nil {
+		t.Errorf("已购买的用户可以查看下架的表情包，得到 %v", err)
+	}
+	if _, err := GetEmoticonPackage(db, bob.ID, pkg.ID); appErrorKey(err) != "emoticon.pack_not_found" {
+		t.Errorf("未购买的用户不能查看下架的表情包，得到 %v", err)
+	}
+	if _, err := AddEmoticonPackage(db, bob.ID, pkg); appErrorKey(err) != "emoticon.pack_unpublished" {
+		t.Errorf("下架的表情包不能购买，得到 %v", err)
+	}
+
+	// 余额不足时不记录订单，也不添加到面板
+	db.Model(pkg).Update("status", "published")
+	if _, err := AddEmoticonPackage(db, bob.ID, pkg); appErrorKey(err) != "wallet.insufficient_balance" {
+		t.Errorf("余额不足应报错，得到 %v", err)
+	}
+	var orders int64
+	db.Model(&models.EmoticonOrder{}).Where("user_id = ?", bob.ID).Count(&orders)
+	if orders != 0 || len(userPackageIDs(t, db, bob.ID)) != 0 || walletBalance(db, bob.ID) != 1 {
+		t.Errorf("扣款失败时应回滚，orders=%d", orders)
+	}
+
+	views, _ := ListEmoticonPackages(db, bob.ID)
+	if len(views) != 1 || views[0].Owned || views[0].Added {
+		t.Errorf("未购买的用户看到的状态不正确: %+v", views)
+	}
+	views, _ = ListEmoticonPackages(db, alice.ID)
+	if len(views) != 1 || !views[0].Owned || !views[0].Added {
+		t.Errorf("已购买的用户看到的状态不正确: %+v", views)
+	}
+}
+
+func TestSortUserEmoticonPackages(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+	var ids []uint
+	for i := 0; i < 3; i++ {
+		pkg, _ := createTestEmoticonPackage(t, db, fmt.Sprintf("免费%d", i), 0, "published")
+		if charged, err := AddEmoticonPackage(db, alice.ID, pkg); err != nil || charged != 0 {
+			t.Fatalf("添加免费表情包失败: %v %v", charged, err)
+		}
+		ids = append(ids, pkg.ID)
+	}
+	if got := userPackageIDs(t, db, alice.ID); fmt.Sprint(got) != fmt.Sprint(ids) {
+		t.Errorf("新添加的表情包应排在最后，得到 %v", got)
+	}
+
+	// 未列出的保持原顺序排在后面，重复和未添加的ID忽略
+	if err := SortUserEmoticonPackages(db, alice.ID, []uint{ids[2], 9999, ids[2]}); err != nil {
+		t.Fatalf("调整顺序失败: %v", err)
+	}
+	want := []uint{ids[2], ids[0], ids[1]}
+	if got := userPackageIDs(t, db, alice.ID); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("顺序应为 %v，得到 %v", want, got)
+	}
+}
+
+func TestFavoriteEmoticonOrder(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+
+	var ids []uint
+	for i := 0; i < 3; i++ {
+		emoticon := models.Emoticon{OwnerID: bob.ID, Name: fmt.Sprintf("收藏%d", i)}
+		db.Create(&emoticon)
+		if _, err := AddFavoriteEmoticon(db, alice.ID, emoticon.ID); err != nil {
+			t.Fatalf("收藏表情失败: %v", err)
+		}
+		ids = append(ids, emoticon.ID)
+	}
+	favoriteIDs := func() string {
+		emoticons, _ := ListFavoriteEmoticons(db, alice.ID)
+		got := make([]uint, 0, len(emoticons))
+		for _, emoticon := range emoticons {
+			got = append(got, emoticon.ID)
+		}
+		return fmt.Sprint(got)
+	}
+	if got, want := favoriteIDs(), fmt.Sprint([]uint{ids[2], ids[1], ids[0]}); got != want {
+		t.Errorf("新收藏的表情应排在最前，得到 %s", got)
+	}
+	if _, err := AddFavoriteEmoticon(db, alice.ID, ids[0]); appErrorKey(err) != "emoticon.already_favorited" {
+		t.Errorf("重复收藏应报错，得到 %v", err)
+	}
+
+	if err := SortFavoriteEmoticons(db, alice.ID, []uint{ids[0]}); err != nil {
+		t.Fatalf("调整顺序失败: %v", err)
+	}
+	if got, want := favoriteIDs(), fmt.Sprint([]uint{ids[0], ids[2], ids[1]}); got != want {
+		t.Errorf("顺序应为 %s，得到 %s", want, got)
+	}
+
+	// 收藏后可以发送他人上传的表情，取消收藏后不能发送
+	if _, _, err := AttachMessageEmoticon(db, alice.ID, ids[1]); err != nil {
+		t.Errorf("收藏的表情应可以发送，得到 %v", err)
+	}
+	if err := RemoveFavoriteEmoticon(db, alice.ID, ids[1]); err != nil {
+		t.Fatalf("取消收藏失败: %v", err)
+	}
+	if _, _, err := AttachMessageEmoticon(db, alice.ID, ids[1]); appErrorKey(err) != "emoticon.not_owned" {
+		t.Errorf("取消收藏后不能发送，得到 %v", err)
+	}
+}
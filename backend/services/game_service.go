@@ -0,0 +1,257 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 游戏商店相关逻辑
+
+// OwnsGame 判断用户是否已拥有游戏
+func OwnsGame(db *gorm.DB, userID, gameID uint) bool {
+	var count int64
+	db.Model(&models.UserGame{}).Where("user_id = ? AND game_id = ?", userID, gameID).Count(&count)
+	return count > 0
+}
+
+// AcquireGame 将游戏加入用户游戏库，付费游戏从钱包扣款并生成订单
+// 在事务中重新读取游戏并先写入游戏库，同一游戏并发购买时只有一次成功，其余按已拥有处理且不扣款
+func AcquireGame(db *gorm.DB, userID uint, game *models.Game) (*models.UserGame, error) {
+	var userGame models.UserGame
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var current models.Game
+		if err := tx.First(&current, game.ID).Error; err != nil {
+			return &utils.AppError{Code: http.StatusNotFound, Message: "游戏不存在", Key: "game.not_found"}
+		}
+		if current.Status == "unpublished" {
+			return &utils.AppError{Code: http.StatusBadRequest, Message: "游戏已下架", Key: "game.unavailable"}
+		}
+
+		now := time.Now().Unix()
+		userGame = models.UserGame{
+			UserID:    userID,
+			GameID:    current.ID,
+			Status:    "purchased",
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&userGame)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &utils.AppError{Code: http.StatusBadRequest, Message: "您已拥有该游戏", Key: "game.already_owned"}
+		}
+
+		if !current.IsFree && current.Price > 0 {
+			order := models.GameOrder{
+				UserID:      userID,
+				GameID:      current.ID,
+				DeveloperID: current.DeveloperID,
+				Amount:      current.Price,
+				Status:      "paid",
+				CreatedAt:   now,
+			}
+			if err := tx.Create(&order).Error; err != nil {
+				return err
+			}
+			description := fmt.Sprintf("购买游戏「%s」", current.Name)
+			if _, err := DebitWallet(tx, userID, current.Price, "game_purchase", order.ID, description); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.Game{}).Where("id = ?", current.ID).
+			UpdateColumn("downloads", gorm.Expr("downloads + 1")).Error
+	})
+	return &userGame, err
+}
+
+// AchievementSignatureWindow 成就解锁签名中时间戳允许的偏差
+const AchievementSignatureWindow = 5 * time.Minute
+
+// RotateAchievementSecret 为开发者生成新的成就签名密钥，旧密钥立即失效
+func RotateAchievementSecret(db *gorm.DB, developer *models.GameDeveloper) (string, error) {
+	secret := utils.GenerateRandomHash()
+	err := db.Model(developer).Updates(map[string]any{
+		"achievement_secret": secret,
+		"updated_at":         time.Now().Unix(),
+	}).Error
+	if err != nil {
+		return "", err
+	}
+	developer.AchievementSecret = secret
+	return secret, nil
+}
+
+// AchievementSignature 计算游戏服务器为用户解锁成就的签名
+// 签名内容为 "用户ID:成就ID:时间戳"，使用开发者密钥做 HMAC-SHA256 后十六进制编码
+func AchievementSignature(secret string, userID, achievementID uint, timestamp int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d:%d:%d", userID, achievementID, timestamp)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyAchievementUnlock 校验解锁请求由游戏开发者的服务器签发，客户端不能自行解锁成就
+func VerifyAchievementUnlock(db *gorm.DB, achievement *models.GameAchievement, userID uint, timestamp int64, signature string) error {
+	invalid := &utils.AppError{Code: http.StatusForbidden, Message: "成就解锁签名无效", Key: "game.achievement_signature_invalid"}
+
+	var developer models.GameDeveloper
+	err := db.Joins("JOIN games ON games.developer_id = game_developers.id").
+		Where("games.id = ?", achievement.GameID).First(&developer).Error
+	if err != nil || developer.AchievementSecret == "" {
+		return invalid
+	}
+	skew := time.Since(time.Unix(timestamp, 0))
+	if skew > AchievementSignatureWindow || skew < -AchievementSignatureWindow {
+		return invalid
+	}
+	want := AchievementSignature(developer.AchievementSecret, userID, achievement.ID, timestamp)
+	if !hmac.Equal([]byte(want), []byte(strings.ToLower(signature))) {
+		return invalid
+	}
+	return nil
+}
+
+// RecomputeGameRating 根据全部评价重新计算游戏评分
+func RecomputeGameRating(tx *gorm.DB, gameID uint) error {
+	var avg struct{ Rating float64 }
+	if err := tx.Model(&models.GameReview{}).Select("COALESCE(AVG(rating), 0) AS rating").
+		Where("game_id = ?", gameID).Scan(&avg).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Game{}).Where("id = ?", gameID).
+		Updates(map[string]any{"rating": avg.Rating, "updated_at": time.Now().Unix()}).Error
+}
+
+// 游戏收入结算配置
+type GameSettlementConfig struct {
+	PlatformFeeRate float64       // 平台抽成比例，0-1
+	Interval        time.Duration // 结算周期
+}
+
+// 全局结算配置
+var gameSettlementConfig = GameSettlementConfig{
+	PlatformFeeRate: 0.3,
+	Interval:        24 * time.Hour,
+}
+
+// 初始化时从环境变量加载结算配置
+func init() {
+	if rate, err := strconv.ParseFloat(os.Getenv("GAME_PLATFORM_FEE_RATE"), 64); err == nil && rate >= 0 && rate < 1 {
+		gameSettlementConfig.PlatformFeeRate = rate
+	}
+	if interval, err := time.ParseDuration(os.Getenv("GAME_SETTLEMENT_INTERVAL")); err == nil && interval > 0 {
+		gameSettlementConfig.Interval = interval
+	}
+}
+
+// SetGameSettlementConfig 设置结算配置
+func SetGameSettlementConfig(config GameSettlementConfig) {
+	gameSettlementConfig = config
+}
+
+// GetGameSettlementConfig 获取当前结算配置
+func GetGameSettlementConfig() GameSettlementConfig {
+	return gameSettlementConfig
+}
+
+// SettleGameRevenue 将截止时间前未结算的订单按开发者汇总，扣除平台抽成后入账到开发者钱包
+func SettleGameRevenue(db *gorm.DB, cutoff int64) {
+	var developerIDs []uint
+	if err := db.Model(&models.GameOrder{}).
+		Where("status = ? AND settlement_id = 0 AND created_at <= ?", "paid", cutoff).
+		Distinct().Pluck("developer_id", &developerIDs).Error; err != nil {
+		utils.Logger.Errorf("查询待结算游戏订单失败: %v", err)
+		return
+	}
+
+	settled := 0
+	for _, developerID := range developerIDs {
+		if err := settleDeveloperRevenue(db, developerID, cutoff); err != nil {
+			utils.Logger.Errorf("结算开发者收入失败: developerID=%d, error=%v", developerID, err)
+			continue
+		}
+		settled++
+	}
+	utils.Logger.Infof("已完成 %d 个开发者的游戏收入结算", settled)
+}
+
+// settleDeveloperRevenue 结算单个开发者的收入
+func settleDeveloperRevenue(db *gorm.DB, developerID uint, cutoff int64) error {
+	var developer models.GameDeveloper
+	if err := db.First(&developer, developerID).Error; err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var orders []models.GameOrder
+		if err := tx.Where("developer_id = ? AND status = ? AND settlement_id = 0 AND created_at <= ?", developerID, "paid", cutoff).
+			Order("created_at ASC").Find(&orders).Error; err != nil {
+			return err
+		}
+		if len(orders) == 0 {
+			return nil
+		}
+
+		orderIDs := make([]uint, 0, len(orders))
+		gross := 0.0
+		for _, o := range orders {
+			orderIDs = append(orderIDs, o.ID)
+			gross += o.Amount
+		}
+		gross = math.Round(gross*100) / 100
+		rate := gameSettlementConfig.PlatformFeeRate
+		fee := math.Round(gross*rate*100) / 100
+		net := math.Round((gross-fee)*100) / 100
+
+		settlement := models.GameSettlement{
+			DeveloperID: developerID,
+			UserID:      developer.UserID,
+			PeriodStart: orders[0].CreatedAt,
+			PeriodEnd:   cutoff,
+			OrderCount:  len(orders),
+			GrossAmount: gross,
+			FeeRate:     rate,
+			PlatformFee: fee,
+			NetAmount:   net,
+			CreatedAt:   time.Now().Unix(),
+		}
+		if err := tx.Create(&settlement).Error; err != nil {
+			return err
+		}
+
+		// 仅标记仍未结算的订单，防止并发结算重复入账
+		result := tx.Model(&models.GameOrder{}).Where("id IN ? AND settlement_id = 0", orderIDs).
+			UpdateColumn("settlement_id", settlement.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != int64(len(orderIDs)) {
+			return fmt.Errorf("订单已被其他结算处理")
+		}
+
+		if net > 0 {
+			description := fmt.Sprintf("游戏收入结算：%d笔订单，销售额%.2f，平台服务费%.2f", len(orders), gross, fee)
+			transaction, err := CreditWallet(tx, developer.UserID, net, "game_income", settlement.ID, description)
+			if err != nil {
+				return err
+			}
+			return tx.Model(&settlement).UpdateColumn("transaction_id", transaction.ID).Error
+		}
+		return nil
+	})
+}
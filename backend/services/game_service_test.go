@@ -0,0 +1,211 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// createTestGame 创建开发者和游戏，price 为0时为免费游戏
+func createTestGame(t *testing.T, db *gorm.DB, developer *models.GameDeveloper, name string, price float64) *models.Game {
+	t.Helper()
+	game := &models.Game{Name: name, DeveloperID: developer.ID, Price: price, IsFree: price == 0, Status: "published"}
+	if err := db.Create(game).Error; err != nil {
+		t.Fatalf("创建游戏失败: %v", err)
+	}
+	return game
+}
+
+// createTestDeveloper 将用户注册为开发者
+func createTestDeveloper(t *testing.T, db *gorm.DB, user *models.User) *models.GameDeveloper {
+	t.Helper()
+	developer := &models.GameDeveloper{UserID: user.ID, Name: user.Account + " studio"}
+	if err := db.Create(developer).Error; err != nil {
+		t.Fatalf("创建开发者失败: %v", err)
+	}
+	return developer
+}
+
+// useGameSettlementConfig 使用指定的结算配置，测试结束后恢复
+func useGameSettlementConfig(t *testing.T, config GameSettlementConfig) {
+	t.Helper()
+	previous := GetGameSettlementConfig()
+	SetGameSettlementConfig(config)
+	t.Cleanup(func() { SetGameSettlementConfig(previous) })
+}
+
+func TestAcquireGame(t *testing.T) {
+	db := newTestDB(t)
+	studio := createTestDeveloper(t, db, createTestUser(t, db, "studio"))
+	alice := createTestUser(t, db, "alice")
+	fundTestWallet(t, db, alice.ID, 50, "")
+
+	free := createTestGame(t, db, studio, "免费游戏", 0)
+	paid := createTestGame(t, db, studio, "付费游戏", 30)
+	expensive := createTestGame(t, db, studio, "昂贵游戏", 30)
+	hidden := createTestGame(t, db, studio, "下架游戏", 10)
+	db.Model(hidden).Update("status", "unpublished")
+	hidden.Status = "unpublished"
+
+	tests := []struct {
+		name    string
+		game    *models.Game
+		key     string
+		balance float64
+		owned   bool
+	}{
+		{"免费游戏不扣款", free, "", 50, true},
+		{"付费游戏扣款", paid, "", 20, true},
+		{"重复购买", paid, "game.already_owned", 20, true},
+		{"余额不足", expensive, "wallet.insufficient_balance", 20, false},
+		{"已下架", hidden, "game.unavailable", 20, false},
+	}
+	for _, tt := range tests {
+		_, err := AcquireGame(db, alice.ID, tt.game)
+		if appErrorKey(err) != tt.key {
+			t.Errorf("%s: 得到 %v，应为 %q", tt.name, err, tt.key)
+		}
+		if got := walletBalance(db, alice.ID); got != tt.balance {
+			t.Errorf("%s: 余额为 %.2f，应为 %.2f", tt.name, got, tt.balance)
+		}
+		if OwnsGame(db, alice.ID, tt.game.ID) != tt.owned {
+			t.Errorf("%s: 是否拥有游戏应为 %v", tt.name, tt.owned)
+		}
+	}
+
+	// 扣款失败时整个购买回滚，不留下订单和下载数
+	var orders []models.GameOrder
+	db.Where("user_id = ?", alice.ID).Find(&orders)
+	if len(orders) != 1 || orders[0].GameID != paid.ID || orders[0].Amount != 30 || orders[0].DeveloperID != studio.ID {
+		t.Errorf("应只有付费游戏的1个订单: %+v", orders)
+	}
+	var transaction models.Transaction
+	db.Where("user_id = ? AND type = ?", alice.ID, "game_purchase").First(&transaction)
+	if transaction.RelatedID != orders[0].ID || transaction.Amount != -30 {
+		t.Errorf("扣款记录应关联订单: %+v", transaction)
+	}
+	var reloaded models.Game
+	db.First(&reloaded, expensive.ID)
+	if reloaded.Downloads != 0 {
+		t.Errorf("购买失败不应增加下载数，得到 %d", reloaded.Downloads)
+	}
+
+	// 传入的游戏信息已过期时按数据库中的当前价格扣款
+	raised := createTestGame(t, db, studio, "涨价游戏", 5)
+	db.Model(&models.Game{}).Where("id = ?", raised.ID).Update("price", 15)
+	if _, err := AcquireGame(db, alice.ID, raised); err != nil {
+		t.Fatalf("购买涨价游戏失败: %v", err)
+	}
+	if got := walletBalance(db, alice.ID); got != 5 {
+		t.Errorf("应按当前价格扣款，余额为 %.2f", got)
+	}
+
+	// 同一游戏只能在游戏库中出现一次
+	duplicate := models.UserGame{UserID: alice.ID, GameID: paid.ID, Status: "purchased"}
+	if err := db.Create(&duplicate).Error; err == nil {
+		t.Error("重复写入游戏库应被唯一索引拒绝")
+	}
+}
+
+func TestVerifyAchievementUnlock(t *testing.T) {
+	db := newTestDB(t)
+	studio := createTestDeveloper(t, db, createTestUser(t, db, "studio"))
+	alice := createTestUser(t, db, "alice")
+	game := createTestGame(t, db, studio, "成就游戏", 0)
+	achievement := models.GameAchievement{GameID: game.ID, Name: "首胜"}
+	db.Create(&achievement)
+
+	now := time.Now().Unix()
+	if err := VerifyAchievementUnlock(db, &achievement, alice.ID, now, "anything"); appErrorKey(err) != "game.achievement_signature_invalid" {
+		t.Errorf("开发者未生成密钥时应拒绝解锁，得到 %v", err)
+	}
+
+	secret, err := RotateAchievementSecret(db, studio)
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	tests := []struct {
+		name      string
+		secret    string
+		userID    uint
+		timestamp int64
+		ok        bool
+	}{
+		{"正确签名", secret, alice.ID, now, true},
+		{"密钥错误", "wrong", alice.ID, now, false},
+		{"签名属于其他用户", secret, alice.ID + 1, now, false},
+		{"时间戳过期", secret, alice.ID, now - 600, false},
+	}
+	for _, tt := range tests {
+		signature := AchievementSignature(tt.secret, tt.userID, achievement.ID, tt.timestamp)
+		err := VerifyAchievementUnlock(db, &achievement, alice.ID, tt.timestamp, signature)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: 得到 %v", tt.name, err)
+		}
+	}
+
+	// 重新生成后旧密钥签发的请求失效
+	if _, err := RotateAchievementSecret(db, studio); err != nil {
+		t.Fatalf("重新生成密钥失败: %v", err)
+	}
+	signature := AchievementSignature(secret, alice.ID, achievement.ID, now)
+	if err := VerifyAchievementUnlock(db, &achievement, alice.ID, now, signature); err == nil {
+		t.Error("旧密钥的签名应失效")
+	}
+}
+
+func TestSettleGameRevenue(t *testing.T) {
+	db := newTestDB(t)
+	useGameSettlementConfig(t, GameSettlementConfig{PlatformFeeRate: 0.3, Interval: time.Hour})
+	owner := createTestUser(t, db, "studio")
+	studio := createTestDeveloper(t, db, owner)
+	game := createTestGame(t, db, studio, "付费游戏", 9.99)
+
+	now := time.Now().Unix()
+	for i, createdAt := range []int64{now - 7200, now - 3600, now - 60} {
+		buyer := createTestUser(t, db, "buyer"+string(rune('a'+i)))
+		fundTestWallet(t, db, buyer.ID, 20, "")
+		if _, err := AcquireGame(db, buyer.ID, game); err != nil {
+			t.Fatalf("购买失败: %v", err)
+		}
+		db.Model(&models.GameOrder{}).Where("user_id = ?", buyer.ID).Update("created_at", createdAt)
+	}
+
+	// 只结算截止时间之前的订单，扣除平台抽成后入账
+	cutoff := now - 1800
+	SettleGameRevenue(db, cutoff)
+	var settlements []models.GameSettlement
+	db.Find(&settlements)
+	if len(settlements) != 1 {
+		t.Fatalf("应生成1个结算单，得到 %d 个", len(settlements))
+	}
+	s := settlements[0]
+	if s.OrderCount != 2 || s.GrossAmount != 19.98 || s.PlatformFee != 5.99 || s.NetAmount != 13.99 || s.UserID != owner.ID {
+		t.Errorf("结算金额错误: %+v", s)
+	}
+	if got := walletBalance(db, owner.ID); got != 13.99 {
+		t.Errorf("开发者余额为 %.2f，应为 13.99", got)
+	}
+	var transaction models.Transaction
+	db.First(&transaction, s.TransactionID)
+	if transaction.UserID != owner.ID || transaction.Type != "game_income" || transaction.RelatedID != s.ID {
+		t.Errorf("入账记录错误: %+v", transaction)
+	}
+
+	// 重复结算不会重复入账，之后的订单在下一次结算
+	SettleGameRevenue(db, cutoff)
+	if got := walletBalance(db, owner.ID); got != 13.99 {
+		t.Errorf("重复结算后余额为 %.2f", got)
+	}
+	SettleGameRevenue(db, now)
+	var count int64
+	db.Model(&models.GameOrder{}).Where("settlement_id = 0").Count(&count)
+	if count != 0 {
+		t.Errorf("仍有 %d 个订单未结算", count)
+	}
+	if got := walletBalance(db, owner.ID); got != 20.98 {
+		t.Errorf("两次结算后余额为 %.2f，应为 20.98", got)
+	}
+}
@@ -0,0 +1,383 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 群聊AI助手相关逻辑
+// AI助手是一个 role 为 bot 的系统用户，以普通群成员身份加入群组，
+// 在被@或 ai 类型群组中回复，回复以普通群消息的形式发送
+
+const (
+	// AI助手的系统账号
+	GroupAIBotAccount = "ai_assistant"
+	// AI助手的默认昵称
+	groupAIBotNickname = "AI助手"
+	// 作为上下文的最近群消息条数
+	groupAIContextWindow = 20
+	// 上下文总字符数上限，超出时丢弃较早的消息
+	groupAIContextChars = 4000
+	// 摘要最多覆盖的未读消息条数
+	groupAICatchUpLimit = 200
+	// 单次AI调用超时
+	groupAITimeout = 60 * time.Second
+)
+
+// 正在生成回复的群组，同一群组同时只生成一条回复
+var groupAIBusy sync.Map
+
+// GetGroupAIBot 获取AI助手账号，不存在时创建
+// 按 role 查找，不按账号名查找：账号名可能在保留之前已被普通用户注册，此时改用带后缀的账号名
+func GetGroupAIBot(db *gorm.DB) (*models.User, error) {
+	var bot models.User
+	if err := db.Where("role = ?", UserRoleBot).Order("id").First(&bot).Error; err == nil {
+		return &bot, nil
+	}
+
+	for _, account := range []string{GroupAIBotAccount, GroupAIBotAccount + "_" + uuid.NewString()[:8]} {
+		bot = models.User{
+			Account:  account,
+			Password: utils.UnusablePassword, // 系统账号不可登录
+			Nickname: groupAIBotNickname,
+			Role:     UserRoleBot,
+			// 拒绝所有好友申请
+			FriendAddMode: 2,
+			CreatedAt:     time.Now().Unix(),
+		}
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&bot).Error; err != nil {
+			return nil, err
+		}
+		// 并发创建时以先创建的为准
+		if err := db.Where("role = ?", UserRoleBot).Order("id").First(&bot).Error; err == nil {
+			return &bot, nil
+		}
+	}
+	return nil, fmt.Errorf("创建AI助手账号失败")
+}
+
+// IsGroupAIEnabled 判断AI助手是否在群中
+func IsGroupAIEnabled(db *gorm.DB, groupID, botID uint) bool {
+	var count int64
+	db.Model(&models.GroupMember{}).Where("group_id = ? AND user_id = ?", groupID, botID).Count(&count)
+	return count > 0
+}
+
+// EnableGroupAI 将AI助手加入群组
+func EnableGroupAI(db *gorm.DB, groupID, inviterID uint) error {
+	bot, err := GetGroupAIBot(db)
+	if err != nil {
+		return err
+	}
+	if IsGroupAIEnabled(db, groupID, bot.ID) {
+		return nil
+	}
+	return db.Create(&models.GroupMember{
+		GroupID:   groupID,
+		UserID:    bot.ID,
+		Role:      "member",
+		Nickname:  bot.Nickname,
+		JoinedAt:  time.Now().Unix(),
+		InvitedBy: inviterID,
+	}).Error
+}
+
+// DisableGroupAI 将AI助手移出群组
+func DisableGroupAI(db *gorm.DB, groupID uint) error {
+	bot, err := GetGroupAIBot(db)
+	if err != nil {
+		return err
+	}
+	return db.Where("group_id = ? AND user_id = ?", groupID, bot.ID).Delete(&models.GroupMember{}).Error
+}
+
+// TriggerGroupAIBot 在群消息发送后调用，判断是否需要AI助手回复并异步生成
+func TriggerGroupAIBot(db *gorm.DB, msg *models.ChatMessage) {
+	if msg.GroupID == 0 || msg.Type != "text" {
+		return
+	}
+
+	bot, err := GetGroupAIBot(db)
+	if err != nil {
+		utils.Logger.Errorf("获取AI助手账号失败: %v", err)
+		return
+	}
+	if msg.SenderID == bot.ID {
+		return
+	}
+
+	var group models.Group
+	if err := db.First(&group, msg.GroupID).Error; err != nil {
+		return
+	}
+
+	if group.Type == "ai" {
+		// ai 类型群组默认包含AI助手
+		if err := EnableGroupAI(db, group.ID, group.OwnerID); err != nil {
+			utils.Logger.Errorf("AI助手加入群组失败: %v", err)
+			return
+		}
+	} else if !IsGroupAIEnabled(db, group.ID, bot.ID) || !mentionsGroupAIBot(msg, bot) {
+		return
+	}
+
+	go replyInGroup(db, &group, bot, msg)
+}
+
+// mentionsGroupAIBot 判断消息是否@了AI助手
+func mentionsGroupAIBot(msg *models.ChatMessage, bot *models.User) bool {
+	for _, id := range ParseUserIDList(msg.MentionedUsers) {
+		if id == bot.ID {
+			return true
+		}
+	}
+	return strings.Contains(msg.Content, "@"+bot.Nickname)
+}
+
+// replyInGroup 以最近的群消息为上下文生成回复，并作为群消息发送
+func replyInGroup(db *gorm.DB, group *models.Group, bot *models.User, trigger *models.ChatMessage) {
+	if _, busy := groupAIBusy.LoadOrStore(group.ID, true); busy {
+		return
+	}
+	defer groupAIBusy.Delete(group.ID)
+
+	var recent []models.ChatMessage
+	if err := db.Where("group_id = ? AND id <= ?", group.ID, trigger.ID).
+		Order("id DESC").Limit(groupAIContextWindow).Find(&recent).Error; err != nil {
+		utils.Logger.Errorf("查询群消息失败: %v", err)
+		return
+	}
+
+	systemPrompt := fmt.Sprintf("你是群聊「%s」中的AI助手%s。群成员的发言格式为「昵称: 内容」，请直接给出你的回复，简洁友好，不要加昵称前缀。", group.Name, bot.Nickname)
+	if group.Notice != "" {
+		systemPrompt += "\n群公告：" + group.Notice
+	}
+	messages := []utils.AIMessage{{Role: "system", Content: systemPrompt}}
+	messages = append(messages, groupMessagesAsContext(db, group.ID, bot.ID, recent)...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), groupAITimeout)
+	defer cancel()
+	result, err := utils.ChatWithProviders(ctx, "", utils.AIChatRequest{
+		Messages:    messages,
+		Temperature: 0.7,
+		MaxTokens:   1000,
+	})
+	if err != nil {
+		utils.Logger.Errorf("AI助手生成群回复失败: group=%d, error=%v", group.ID, err)
+		return
+	}
+
+	reply := models.ChatMessage{
+		SenderID:       bot.ID,
+		GroupID:        group.ID,
+		Content:        strings.TrimSpace(result.Content),
+		Type:           "text",
+		Extra:          fmt.Sprintf(`{"ai":true,"reply_to":%d}`, trigger.ID),
+		MentionedUsers: strconv.FormatUint(uint64(trigger.SenderID), 10),
+		Status:         1,
+	}
+	if err := SendGroupMessage(db, &reply); err != nil {
+		utils.Logger.Errorf("发送AI助手群回复失败: %v", err)
+	}
+}
+
+// groupMessagesAsContext 将群消息（按ID倒序）转换为时间正序的对话上下文
+// AI助手自己的消息作为 assistant，其他成员的消息带上昵称作为 user
+func groupMessagesAsContext(db *gorm.DB, groupID, botID uint, desc []models.ChatMessage) []utils.AIMessage {
+	names := groupMemberNames(db, groupID, desc)
+
+	context := []utils.AIMessage{}
+	total := 0
+	for _, msg := range desc {
+		content := describeGroupMessage(&msg)
+		if msg.SenderID != botID {
+			content = names[msg.SenderID] + ": " + content
+		}
+		total += utf8.RuneCountInString(content)
+		if total > groupAIContextChars && len(context) > 0 {
+			break
+		}
+
+		role := "user"
+		if msg.SenderID == botID {
+			role = "assistant"
+		}
+		context = append(context, utils.AIMessage{Role: role, Content: content})
+	}
+
+	// 倒序收集，翻转为时间正序
+	for i, j := 0, len(context)-1; i < j; i, j = i+1, j-1 {
+		context[i], context[j] = context[j], context[i]
+	}
+	return context
+}
+
+// groupMemberNames 获取发送者在群内的显示名称，优先使用群昵称
+func groupMemberNames(db *gorm.DB, groupID uint, messages []models.ChatMessage) map[uint]string {
+	ids := []uint{}
+	seen := map[uint]bool{}
+	for _, msg := range messages {
+		if !seen[msg.SenderID] {
+			seen[msg.SenderID] = true
+			ids = append(ids, msg.SenderID)
+		}
+	}
+
+	names := map[uint]string{}
+	if len(ids) == 0 {
+		return names
+	}
+	var users []models.User
+	db.Select("id, account, nickname").Where("id IN ?", ids).Find(&users)
+	for _, u := range users {
+		names[u.ID] = u.Nickname
+		if names[u.ID] == "" {
+			names[u.ID] = u.Account
+		}
+	}
+	var members []models.GroupMember
+	db.Where("group_id = ? AND user_id IN ? AND nickname <> ''", groupID, ids).Find(&members)
+	for _, m := range members {
+		names[m.UserID] = m.Nickname
+	}
+	for _, id := range ids {
+		if names[id] == "" {
+			names[id] = "用户" + strconv.FormatUint(uint64(id), 10)
+		}
+	}
+	return names
+}
+
+// describeGroupMessage 将非文本消息转换为简短描述
+func describeGroupMessage(msg *models.ChatMessage) string {
+	switch msg.Type {
+	case "", "text":
+		return msg.Content
+	case "image":
+		return "[图片]"
+	case "voice":
+		return "[语音]"
+	case "video":
+		return "[视频]"
+	case "file":
+		return "[文件]"
+	case "location":
+		return "[位置]"
+	case "redpacket":
+		return "[红包]"
+	case "emoticon":
+		return "[表情]"
+	default:
+		return "[" + msg.Type + "]"
+	}
+}
+
+// MarkGroupRead 更新用户在群内的已读位置，只前进不后退
+func MarkGroupRead(db *gorm.DB, groupID, userID, messageID uint) error {
+	if messageID == 0 {
+		return nil
+	}
+	now := time.Now().Unix()
+	state := models.GroupReadState{GroupID: groupID, UserID: userID, LastReadMessageID: messageID, UpdatedAt: now}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&state).Error; err != nil {
+		return err
+	}
+	return db.Model(&models.GroupReadState{}).
+		Where("group_id = ? AND user_id = ? AND last_read_message_id < ?", groupID, userID, messageID).
+		Updates(map[string]any{"last_read_message_id": messageID, "updated_at": now}).Error
+}
+
+// GetGroupLastRead 获取用户在群内的已读位置
+func GetGroupLastRead(db *gorm.DB, groupID, userID uint) uint {
+	var state models.GroupReadState
+	if err := db.Where("group_id = ? AND user_id = ?", groupID, userID).First(&state).Error; err != nil {
+		return 0
+	}
+	return state.LastReadMessageID
+}
+
+// GroupCatchUp 未读消息摘要
+type GroupCatchUp struct {
+	Summary       string `json:"summary"`
+	MessageCount  int    `json:"message_count"`
+	FromMessageID uint   `json:"from_message_id"`
+	ToMessageID   uint   `json:"to_message_id"`
+	Truncated     bool   `json:"truncated"` // 未读过多时只摘要最近的部分
+}
+
+// SummarizeGroupSinceLastRead 使用AI总结用户上次已读之后的群消息
+func SummarizeGroupSinceLastRead(ctx context.Context, db *gorm.DB, groupID, userID uint) (*GroupCatchUp, error) {
+	lastRead := GetGroupLastRead(db, groupID, userID)
+
+	var unread int64
+	db.Model(&models.ChatMessage{}).Where("group_id = ? AND id > ?", groupID, lastRead).Count(&unread)
+	if unread == 0 {
+		return &GroupCatchUp{Summary: "没有未读消息", FromMessageID: lastRead, ToMessageID: lastRead}, nil
+	}
+
+	var desc []models.ChatMessage
+	if err := db.Where("group_id = ? AND id > ?", groupID, lastRead).
+		Order("id DESC").Limit(groupAICatchUpLimit).Find(&desc).Error; err != nil {
+		return nil, err
+	}
+
+	bot, err := GetGroupAIBot(db)
+	if err != nil {
+		return nil, err
+	}
+
+	// 摘要时所有消息都作为材料，统一带上发送者名称
+	names := groupMemberNames(db, groupID, desc)
+	var transcript strings.Builder
+	for i := len(desc) - 1; i >= 0; i-- {
+		name := names[desc[i].SenderID]
+		if desc[i].SenderID == bot.ID {
+			name = bot.Nickname
+		}
+		transcript.WriteString(name + ": " + describeGroupMessage(&desc[i]) + "\n")
+	}
+
+	result, err := utils.ChatWithProviders(ctx, "", utils.AIChatRequest{
+		Messages: []utils.AIMessage{
+			{Role: "system", Content: "你是群聊消息摘要助手。请用简洁的中文列出以下群聊记录的要点，包括讨论的话题、做出的决定和与读者相关的待办事项。"},
+			{Role: "user", Content: transcript.String()},
+		},
+		Temperature: 0.3,
+		MaxTokens:   800,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &GroupCatchUp{
+		Summary:       strings.TrimSpace(result.Content),
+		MessageCount:  int(unread),
+		FromMessageID: lastRead,
+		ToMessageID:   desc[0].ID,
+		Truncated:     unread > int64(len(desc)),
+	}, nil
+}
+
+// CheckGroupManager 检查用户是否为群主或管理员
+func CheckGroupManager(db *gorm.DB, groupID, userID uint) error {
+	var member models.GroupMember
+	if err := db.Where("group_id = ? AND user_id = ?", groupID, userID).First(&member).Error; err != nil {
+		return &utils.AppError{Code: http.StatusForbidden, Message: "您不是该群组成员", Key: "group.not_member"}
+	}
+	if member.Role != "owner" && member.Role != "admin" {
+		return &utils.AppError{Code: http.StatusForbidden, Message: "只有群主或管理员可以操作", Key: "group.admin_required"}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"testing"
+)
+
+// 普通用户抢先注册了AI助手的账号名时，不能被当作AI助手；AI助手账号不能登录
+func TestGetGroupAIBotIgnoresSquattedAccount(t *testing.T) {
+	db := newTestDB(t)
+	squatter := createTestUser(t, db, GroupAIBotAccount)
+
+	bot, err := GetGroupAIBot(db)
+	if err != nil {
+		t.Fatalf("获取AI助手失败: %v", err)
+	}
+	if bot.ID == squatter.ID {
+		t.Fatal("普通用户注册的同名账号被当作AI助手")
+	}
+	if bot.Role != UserRoleBot {
+		t.Errorf("AI助手的 role 为 %q，应为 %q", bot.Role, UserRoleBot)
+	}
+
+	again, err := GetGroupAIBot(db)
+	if err != nil || again.ID != bot.ID {
+		t.Errorf("再次获取AI助手得到 %v, %v，应为同一账号 %d", again, err, bot.ID)
+	}
+
+	for _, password := range []string{"", bot.Password, utils.UnusablePassword} {
+		if err := AuthenticatePassword(db, bot, bot.Account, password, ""); err == nil {
+			t.Errorf("AI助手账号使用密码 %q 登录成功", password)
+		}
+	}
+}
+
+// 旧版本创建的AI助手保存了明文密码，迁移后不可登录
+func TestMigrateBotPassword(t *testing.T) {
+	db := newTestDB(t)
+	bot := &models.User{Account: GroupAIBotAccount, Password: "legacy-plain-uuid", Role: UserRoleBot}
+	if err := db.Create(bot).Error; err != nil {
+		t.Fatalf("创建AI助手失败: %v", err)
+	}
+	if err := utils.MigrateDB(db); err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+	db.First(bot, bot.ID)
+	if err := AuthenticatePassword(db, bot, bot.Account, "legacy-plain-uuid", ""); err == nil {
+		t.Error("迁移后仍可使用旧的明文密码登录AI助手账号")
+	}
+}
+
+func TestIsReservedAccount(t *testing.T) {
+	tests := []struct {
+		account  string
+		reserved bool
+	}{
+		{"ai_assistant", true},
+		{" AI_Assistant ", true},
+		{"ai_assistant_1a2b3c4d", true},
+		{"system", true},
+		{"ai_assistant2", false},
+		{"alice", false},
+	}
+	for _, tt := range tests {
+		if got := IsReservedAccount(tt.account); got != tt.reserved {
+			t.Errorf("IsReservedAccount(%q) = %v，应为 %v", tt.account, got, tt.reserved)
+		}
+	}
+
+	db := newTestDB(t)
+	if err := RegisterUser(db, "ai_assistant", "Str0ng-Passw0rd!"); err != ErrReservedAccount {
+		t.Errorf("注册保留账号名返回 %v，应为 ErrReservedAccount", err)
+	}
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 语言包管理：管理员导入的语言包保存在 LanguagePack，用户自定义文本保存在 UserLanguagePack
+// 导入的语言包在启动和定时任务中加载到内存，覆盖内置语言包
+
+// LanguagePackInfo 语言包概况，Missing 为相对默认语言缺少的消息键数量
+type LanguagePackInfo struct {
+	LangCode  string `json:"lang_code"`
+	Name      string `json:"name"`
+	Shipped   bool   `json:"shipped"`
+	Imported  bool   `json:"imported"`
+	Keys      int    `json:"keys"`
+	Missing   int    `json:"missing"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// LanguagePackExport 语言包的导入导出格式
+type LanguagePackExport struct {
+	LangCode string            `json:"lang_code"`
+	Name     string            `json:"name"`
+	Messages map[string]string `json:"messages"`
+}
+
+// LoadLanguagePacks 将数据库中导入的语言包加载到内存
+func LoadLanguagePacks(db *gorm.DB) error {
+	var packs []models.LanguagePack
+	if err := db.Find(&packs).Error; err != nil {
+		return err
+	}
+	for _, pack := range packs {
+		var messages map[string]string
+		if err := json.Unmarshal([]byte(pack.Content), &messages); err != nil {
+			utils.Logger.Errorf("语言包 %s 格式错误: %v", pack.LangCode, err)
+			continue
+		}
+		utils.SetImportedLanguagePack(pack.LangCode, messages)
+	}
+	return nil
+}
+
+// ListLanguagePacks 列出内置和导入的语言包
+func ListLanguagePacks(db *gorm.DB) ([]LanguagePackInfo, error) {
+	var packs []models.LanguagePack
+	if err := db.Find(&packs).Error; err != nil {
+		return nil, err
+	}
+	infos := map[string]*LanguagePackInfo{}
+	for _, lang := range utils.ShippedLanguages() {
+		infos[lang] = &LanguagePackInfo{LangCode: lang, Name: utils.SupportedLanguages[lang], Shipped: true}
+	}
+	for _, pack := range packs {
+		info, ok := infos[pack.LangCode]
+		if !ok {
+			info = &LanguagePackInfo{LangCode: pack.LangCode}
+			infos[pack.LangCode] = info
+		}
+		info.Imported = true
+		info.UpdatedAt = pack.UpdatedAt
+		if pack.Name != "" {
+			info.Name = pack.Name
+		}
+	}
+
+	defaults := utils.ShippedLanguagePack(utils.DefaultLanguage)
+	result := make([]LanguagePackInfo, 0, len(infos))
+	for lang, info := range infos {
+		messages := utils.LanguagePackMessages(lang)
+		info.Keys = len(messages)
+		for key := range defaults {
+			if _, ok := messages[key]; !ok {
+				info.Missing++
+			}
+		}
+		result = append(result, *info)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].LangCode < result[j].LangCode })
+	return result, nil
+}
+
+// ExportLanguagePack 导出合并内置和导入文本后的语言包
+func ExportLanguagePack(db *gorm.DB, lang string) (*LanguagePackExport, error) {
+	lang = utils.NormalizeLanguage(lang)
+	if !utils.HasLanguagePack(lang) {
+		return nil, &utils.AppError{Code: http.StatusNotFound, Message: "语言包不存在", Key: "i18n.pack_not_found"}
+	}
+	export := &LanguagePackExport{
+		LangCode: lang,
+		Name:     utils.SupportedLanguages[lang],
+		Messages: utils.LanguagePackMessages(lang),
+	}
+	var pack models.LanguagePack
+	if err := db.Where("lang_code = ?", lang).First(&pack).Error; err == nil && pack.Name != "" {
+		export.Name = pack.Name
+	}
+	return export, nil
+}
+
+// ImportLanguagePack 导入语言包，已导入的同语言语言包整体替换
+// 消息键必须是默认语言中已有的键，避免拼写错误的键静默失效
+func ImportLanguagePack(db *gorm.DB, lang, name string, messages map[string]string) (*models.LanguagePack, error) {
+	lang, err := validateLanguagePack(lang, messages)
+	if err != nil {
+		return nil, err
+	}
+	content, err := json.Marshal(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	pack := models.LanguagePack{
+		LangCode:  lang,
+		Name:      name,
+		Content:   string(content),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err = db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "lang_code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "content", "updated_at"}),
+	}).Create(&pack).Error
+	if err != nil {
+		return nil, err
+	}
+	utils.SetImportedLanguagePack(lang, messages)
+	return &pack, nil
+}
+
+// GetUserLanguageOverrides 获取用户在某个语言下自定义的文本
+func GetUserLanguageOverrides(db *gorm.DB, userID uint, lang string) map[string]string {
+	var pack models.UserLanguagePack
+	if err := db.Where("user_id = ? AND lang_code = ?", userID, lang).First(&pack).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.Logger.Errorf("查询用户语言包失败: user=%d, error=%v", userID, err)
+		}
+		return nil
+	}
+	var messages map[string]string
+	if err := json.Unmarshal([]byte(pack.Content), &messages); err != nil {
+		utils.Logger.Errorf("用户语言包格式错误: user=%d, lang=%s, error=%v", userID, lang, err)
+		return nil
+	}
+	return messages
+}
+
+// SetUserLanguageOverrides 保存用户自定义的文本，messages 为空时清除
+func SetUserLanguageOverrides(db *gorm.DB, userID uint, lang string, messages map[string]string) (string, error) {
+	lang, err := validateLanguagePack(lang, messages)
+	if err != nil {
+		return "", err
+	}
+	if len(messages) == 0 {
+		return lang, db.Where("user_id = ? AND lang_code = ?", userID, lang).Delete(&models.UserLanguagePack{}).Error
+	}
+	content, err := json.Marshal(messages)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().Unix()
+	return lang, db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "lang_code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"content", "updated_at"}),
+	}).Create(&models.UserLanguagePack{
+		UserID:    userID,
+		LangCode:  lang,
+		Content:   string(content),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}).Error
+}
+
+// RequestLocalizer 确定请求使用的语言
+// 登录用户优先使用语言设置，其次是 Accept-Language，都没有语言包时使用默认语言
+func RequestLocalizer(db *gorm.DB, userID uint, acceptLanguage string) *utils.Localizer {
+	lang := ""
+	if userID != 0 {
+		var settings models.UserSettings
+		if err := db.Select("language").Where("user_id = ?", userID).First(&settings).Error; err == nil {
+			if normalized := utils.NormalizeLanguage(settings.Language); utils.HasLanguagePack(normalized) {
+				lang = normalized
+			}
+		}
+	}
+	if lang == "" {
+		lang = utils.MatchLanguage(acceptLanguage)
+	}
+	if lang == "" {
+		lang = utils.DefaultLanguage
+	}
+
+	var overrides map[string]string
+	if userID != 0 {
+		overrides = GetUserLanguageOverrides(db, userID, lang)
+	}
+	return utils.NewLocalizer(lang, overrides)
+}
+
+// validateLanguagePack 校验语言代码、消息键和格式化参数，返回统一后的语言代码
+func validateLanguagePack(lang string, messages map[string]string) (string, error) {
+	lang = utils.NormalizeLanguage(lang)
+	if _, ok := utils.SupportedLanguages[lang]; !ok {
+		return "", &utils.AppError{Code: http.StatusBadRequest, Message: "不支持的语言", Key: "i18n.unsupported_language"}
+	}
+	var unknown []string
+	for key := range messages {
+		if !utils.IsMessageKey(key) {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return "", &utils.AppError{
+			Code:    http.StatusBadRequest,
+			Message: "语言包包含未知的消息键: " + strings.Join(unknown, ", "),
+			Key:     "i18n.unknown_keys",
+			Args:    []interface{}{strings.Join(unknown, ", ")},
+		}
+	}
+	var mismatched []string
+	for key, text := range messages {
+		if !utils.MessageVerbsMatch(key, text) {
+			mismatched = append(mismatched, key)
+		}
+	}
+	if len(mismatched) > 0 {
+		sort.Strings(mismatched)
+		return "", &utils.AppError{
+			Code:    http.StatusBadRequest,
+			Message: "语言包中以下消息的格式化参数与默认语言不一致: " + strings.Join(mismatched, ", "),
+			Key:     "i18n.format_mismatch",
+			Args:    []interface{}{strings.Join(mismatched, ", ")},
+		}
+	}
+	return lang, nil
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"allinone_backend/utils"
+	"testing"
+)
+
+func TestValidateLanguagePackFormatVerbs(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+	t.Cleanup(func() { utils.SetImportedLanguagePack("en", nil) })
+
+	tests := []struct {
+		name     string
+		messages map[string]string
+		key      string
+	}{
+		{"参数一致", map[string]string{"media.quota_exceeded": "Quota is %d MB", "common.invalid_params": "Bad params"}, ""},
+		{"缺少参数", map[string]string{"media.quota_exceeded": "Quota exceeded"}, "i18n.format_mismatch"},
+		{"参数类型不同", map[string]string{"media.quota_exceeded": "Quota is %s MB"}, "i18n.format_mismatch"},
+		{"多出参数", map[string]string{"common.invalid_params": "Bad %s"}, "i18n.format_mismatch"},
+		{"未知的消息键", map[string]string{"no.such_key": "x"}, "i18n.unknown_keys"},
+	}
+	for _, tt := range tests {
+		if _, err := ImportLanguagePack(db, "en_US", "English", tt.messages); appErrorKey(err) != tt.key {
+			t.Errorf("导入语言包 %s: 得到 %v，应为 %q", tt.name, err, tt.key)
+		}
+		if _, err := SetUserLanguageOverrides(db, alice.ID, "en", tt.messages); appErrorKey(err) != tt.key {
+			t.Errorf("自定义文本 %s: 得到 %v，应为 %q", tt.name, err, tt.key)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 图片上传：校验格式和尺寸、去除元数据后保存原图，并保存各规格的缩略图
+// 缩略图作为原图的子媒体记录，可见范围与原图相同
+
+// StoreImage 处理并保存图片，kind 为 utils.ImageKindImage 或 utils.ImageKindAvatar
+func StoreImage(ctx context.Context, db *gorm.DB, ownerID uint, body io.Reader, fileName, kind string, target MediaTarget) (*models.Media, error) {
+	limit, ok := utils.ImageLimits[kind]
+	if !ok {
+		limit = utils.ImageLimits[utils.ImageKindImage]
+	}
+	data, err := io.ReadAll(io.LimitReader(body, limit.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	processed, err := utils.ProcessImage(data, kind)
+	if err != nil {
+		return nil, imageAppError(err)
+	}
+
+	// 文件名的扩展名以实际格式为准
+	baseName := strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+	if baseName == "" || baseName == "." {
+		baseName = "image"
+	}
+	template := models.Media{
+		OwnerID:          ownerID,
+		FileType:         "image",
+		Scope:            target.Scope,
+		ConversationType: target.ConversationType,
+		ConversationID:   target.ConversationID,
+	}
+
+	original := template
+	original.FileName = baseName + processed.Original.Ext
+	original.Width = processed.Original.Width
+	original.Height = processed.Original.Height
+	media, err := storeMedia(ctx, db, bytes.NewReader(processed.Original.Data), original)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, encoded := range processed.Renditions {
+		rendition := template
+		rendition.FileName = baseName + "_" + encoded.Name + encoded.Ext
+		rendition.Width = encoded.Width
+		rendition.Height = encoded.Height
+		rendition.ParentID = media.ID
+		rendition.Rendition = encoded.Name
+		if _, err := storeMedia(ctx, db, bytes.NewReader(encoded.Data), rendition); err != nil {
+			DeleteMedia(ctx, db, ownerID, media.ID)
+			return nil, err
+		}
+	}
+	return media, nil
+}
+
+// MediaRenditions 获取原图的各规格缩略图，按规格名称索引
+func MediaRenditions(db *gorm.DB, mediaID uint) map[string]models.Media {
+	var items []models.Media
+	db.Where("parent_id = ?", mediaID).Find(&items)
+	renditions := make(map[string]models.Media, len(items))
+	for _, item := range items {
+		renditions[item.Rendition] = item
+	}
+	return renditions
+}
+
+// MediaExtra 消息 Extra 中的媒体信息，图片附带宽高和各规格缩略图地址
+// 没有某个规格时（原图已经足够小）使用原图地址
+func MediaExtra(db *gorm.DB, media *models.Media) map[string]interface{} {
+	extra := map[string]interface{}{
+		"media_id":     media.ID,
+		"url":          MediaRef(media),
+		"file_name":    media.FileName,
+		"file_size":    media.Size,
+		"content_type": media.ContentType,
+	}
+	if media.FileType != "image" {
+		return extra
+	}
+	extra["width"] = media.Width
+	extra["height"] = media.Height
+	renditions := MediaRenditions(db, media.ID)
+	for _, spec := range utils.ImageRenditions {
+		if item, ok := renditions[spec.Name]; ok {
+			extra[spec.Name+"_url"] = MediaRef(&item)
+		} else {
+			extra[spec.Name+"_url"] = MediaRef(media)
+		}
+	}
+	return extra
+}
+
+// AttachMessageMedia 将媒体关联到消息所在的会话，返回写入消息 Extra 的媒体信息
+// 媒体属于其他会话时（如转发）复制一条媒体记录，文件本身不重复保存
+func AttachMessageMedia(ctx context.Context, db *gorm.DB, senderID, mediaID uint, target MediaTarget) (*models.Media, map[string]interface{}, error) {
+	media, err := GetAccessibleMedia(db, senderID, mediaID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if media.ParentID != 0 {
+		return nil, nil, &utils.AppError{Code: http.StatusBadRequest, Message: "不能发送缩略图", Key: "media.thumbnail_not_sendable"}
+	}
+	if !mediaVisibleInConversation(media, senderID, target) {
+		media, err = copyMedia(db, media, senderID, target)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return media, MediaExtra(db, media), nil
+}
+
+// mediaVisibleInConversation 会话成员是否都能访问该媒体
+func mediaVisibleInConversation(media *models.Media, senderID uint, target MediaTarget) bool {
+	if media.Scope == MediaScopePublic {
+		return true
+	}
+	if media.Scope != MediaScopeConversation || media.ConversationType != target.ConversationType {
+		return false
+	}
+	if target.ConversationType == ConversationGroup {
+		return media.ConversationID == target.ConversationID
+	}
+	// 单聊媒体记录的是上传者和对方，对方回发同一文件时也属于该会话
+	return (media.OwnerID == senderID && media.ConversationID == target.ConversationID) ||
+		(media.OwnerID == target.ConversationID && media.ConversationID == senderID)
+}
+
+// copyMedia 为新的会话复制媒体记录和缩略图，引用同一份文件
+func copyMedia(db *gorm.DB, media *models.Media, ownerID uint, target MediaTarget) (*models.Media, error) {
+	var copied models.Media
+	err := db.Transaction(func(tx *gorm.DB) error {
+		items := []models.Media{*media}
+		var renditions []models.Media
+		tx.Where("parent_id = ?", media.ID).Find(&renditions)
+		items = append(items, renditions...)
+
+		for i, item := range items {
+			item.ID = 0
+			item.OwnerID = ownerID
+			item.Scope = target.Scope
+			item.ConversationType = target.ConversationType
+			item.ConversationID = target.ConversationID
+			item.MomentID = target.MomentID
+			item.SquarePostID = target.SquarePostID
+			if i > 0 {
+				item.ParentID = copied.ID
+			}
+			if err := tx.Create(&item).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.MediaBlob{}).Where("id = ?", item.BlobID).
+				UpdateColumn("ref_count", gorm.Expr("ref_count + 1")).Error; err != nil {
+				return err
+			}
+			if i == 0 {
+				copied = item
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &copied, nil
+}
+
+// imageAppError 将图片处理错误转换为接口错误
+func imageAppError(err error) error {
+	switch {
+	case errors.Is(err, utils.ErrImageTooLarge):
+		return &utils.AppError{Code: http.StatusRequestEntityTooLarge, Message: err.Error(), Key: "media.image_exceeds_limit"}
+	case errors.Is(err, utils.ErrImageInvalid):
+		return &utils.AppError{Code: http.StatusBadRequest, Message: "不支持的图片格式或文件已损坏", Key: "media.invalid_image"}
+	default:
+		return err
+	}
+}
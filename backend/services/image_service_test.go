@@ -0,0 +1,120 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// encodeTestImage 编码 w x h 的纯色图片，format 为 png 或 jpeg
+func encodeTestImage(t *testing.T, format string, w, h int) []byte {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	var buf bytes.Buffer
+	var err error
+	if format == "png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatalf("编码图片失败: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestStoreImage(t *testing.T) {
+	db := newTestDB(t)
+	useTestMediaConfig(t, nil)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	ctx := context.Background()
+
+	// 扩展名和内容不符时按实际格式保存，并去除注释等元数据
+	secret := []byte("camera serial 12345")
+	data := encodeTestImage(t, "jpeg", 1500, 300)
+	comment := append([]byte{0xFF, 0xFE, 0, byte(len(secret) + 2)}, secret...)
+	data = append(append(append([]byte{}, data[:2]...), comment...), data[2:]...)
+	media, err := StoreImage(ctx, db, alice.ID, bytes.NewReader(data), "photo.png", utils.ImageKindImage, MediaTarget{Scope: MediaScopePrivate})
+	if err != nil {
+		t.Fatalf("保存图片失败: %v", err)
+	}
+	if media.FileName != "photo.jpg" || media.ContentType != "image/jpeg" || media.Width != 1500 || media.Height != 300 {
+		t.Errorf("图片信息不正确: %+v", media)
+	}
+	reader, err := OpenMedia(ctx, db, media)
+	if err != nil {
+		t.Fatalf("读取图片失败: %v", err)
+	}
+	stored, _ := io.ReadAll(reader)
+	reader.Close()
+	if bytes.Contains(stored, secret) {
+		t.Error("保存的图片不应包含元数据")
+	}
+
+	renditions := MediaRenditions(db, media.ID)
+	if len(renditions) != len(utils.ImageRenditions) {
+		t.Fatalf("应保存 %d 个缩略图，得到 %d", len(utils.ImageRenditions), len(renditions))
+	}
+	for _, spec := range utils.ImageRenditions {
+		item := renditions[spec.Name]
+		if item.Width != spec.MaxSide || item.FileName != "photo_"+spec.Name+".jpg" || item.Scope != media.Scope {
+			t.Errorf("缩略图 %s 不正确: %+v", spec.Name, item)
+		}
+	}
+
+	// 发送到会话时复制原图和缩略图，文件引用数增加
+	target := MediaTarget{Scope: MediaScopeConversation, ConversationType: ConversationPrivate, ConversationID: bob.ID}
+	copied, extra, err := AttachMessageMedia(ctx, db, alice.ID, media.ID, target)
+	if err != nil {
+		t.Fatalf("关联消息媒体失败: %v", err)
+	}
+	if copied.ID == media.ID || copied.Scope != MediaScopeConversation || len(MediaRenditions(db, copied.ID)) != len(utils.ImageRenditions) {
+		t.Errorf("私有图片应复制到会话，得到 %+v", copied)
+	}
+	if extra["thumb_url"] == extra["url"] || extra["width"] != 1500 {
+		t.Errorf("消息中的图片信息不正确: %v", extra)
+	}
+	var blob models.MediaBlob
+	db.First(&blob, media.BlobID)
+	if blob.RefCount != 2 {
+		t.Errorf("复制后文件引用数应为2，得到 %d", blob.RefCount)
+	}
+	thumb := renditions["thumb"]
+	if _, _, err := AttachMessageMedia(ctx, db, alice.ID, thumb.ID, target); appErrorKey(err) != "media.thumbnail_not_sendable" {
+		t.Errorf("不能直接发送缩略图，得到 %v", err)
+	}
+
+	// 小图不生成缩略图，消息中使用原图地址
+	small, err := StoreImage(ctx, db, alice.ID, bytes.NewReader(encodeTestImage(t, "png", 100, 100)), "small.png", utils.ImageKindImage, MediaTarget{Scope: MediaScopePrivate})
+	if err != nil {
+		t.Fatalf("保存小图失败: %v", err)
+	}
+	if extra := MediaExtra(db, small); len(MediaRenditions(db, small.ID)) != 0 || extra["thumb_url"] != extra["url"] {
+		t.Errorf("小图应使用原图地址，得到 %v", extra)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		kind string
+		key  string
+		code int
+	}{
+		{"伪装成图片的文本", []byte("#!/bin/sh\necho hi\n"), utils.ImageKindImage, "media.invalid_image", http.StatusBadRequest},
+		{"表情尺寸超限", encodeTestImage(t, "png", 2000, 10), utils.ImageKindSticker, "media.image_exceeds_limit", http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		_, err := StoreImage(ctx, db, alice.ID, bytes.NewReader(tt.data), "fake.png", tt.kind, MediaTarget{Scope: MediaScopePrivate})
+		if appErrorKey(err) != tt.key || appErrorCode(err) != tt.code {
+			t.Errorf("%s: 得到 %v，应为 %s", tt.name, err, tt.key)
+		}
+	}
+}
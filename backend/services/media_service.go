@@ -0,0 +1,484 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 媒体文件：按内容哈希去重保存到存储后端，媒体记录保存上传者和所属会话
+// 非公开媒体只能通过带签名的短时效地址下载，下载时校验签名用户是否仍是会话成员或仍能查看所属动态、广场动态
+
+// 媒体可见范围
+const (
+	MediaScopePrivate      = "private"      // 仅上传者
+	MediaScopeConversation = "conversation" // 所属会话的成员
+	MediaScopeMoment       = "moment"       // 所属动态的可见用户，发布前仅上传者
+	MediaScopeSquare       = "square"       // 所属广场动态正常展示时的所有用户，发布前仅上传者
+	MediaScopePublic       = "public"       // 所有人，用于头像、表情等公开内容
+)
+
+// 会话类型
+const (
+	ConversationPrivate = "private"
+	ConversationGroup   = "group"
+)
+
+// MediaFileTypes 允许的媒体类型
+var MediaFileTypes = map[string]bool{
+	"image": true,
+	"voice": true,
+	"video": true,
+	"file":  true,
+}
+
+// MediaTarget 媒体的可见范围和所属会话、动态或广场动态
+type MediaTarget struct {
+	Scope            string
+	ConversationType string
+	ConversationID   uint
+	MomentID         uint
+	SquarePostID     uint
+}
+
+// ResolveMediaTarget 根据上传参数确定媒体的可见范围，指定群时校验上传者是群成员
+// 指定了接收者或群时为会话媒体；scope 为 moment 时为动态媒体，发布后动态的可见用户才能访问
+// scope 为 square 时为广场媒体，发布后所有用户可以访问，其余仅上传者可见
+// 上传接口不能创建公开媒体，旧客户端为动态上传时传的 public 按 moment 处理
+func ResolveMediaTarget(db *gorm.DB, userID uint, scope string, receiverID, groupID uint) (MediaTarget, error) {
+	switch {
+	case groupID != 0:
+		var count int64
+		db.Model(&models.GroupMember{}).Where("group_id = ? AND user_id = ?", groupID, userID).Count(&count)
+		if count == 0 {
+			return MediaTarget{}, &utils.AppError{Code: http.StatusForbidden, Message: "不是群成员", Key: "group.not_member"}
+		}
+		return MediaTarget{Scope: MediaScopeConversation, ConversationType: ConversationGroup, ConversationID: groupID}, nil
+	case receiverID != 0:
+		var count int64
+		db.Model(&models.User{}).Where("id = ?", receiverID).Count(&count)
+		if count == 0 {
+			return MediaTarget{}, &utils.AppError{Code: http.StatusNotFound, Message: "接收者不存在", Key: "chat.receiver_not_found"}
+		}
+		return MediaTarget{Scope: MediaScopeConversation, ConversationType: ConversationPrivate, ConversationID: receiverID}, nil
+	case scope == MediaScopeMoment, scope == MediaScopePublic:
+		return MediaTarget{Scope: MediaScopeMoment}, nil
+	case scope == MediaScopeSquare:
+		return MediaTarget{Scope: MediaScopeSquare}, nil
+	default:
+		return MediaTarget{Scope: MediaScopePrivate}, nil
+	}
+}
+
+// StoreMedia 保存上传的文件并创建媒体记录，内容相同的文件只保存一份
+func StoreMedia(ctx context.Context, db *gorm.DB, ownerID uint, body io.Reader, fileName, fileType string, target MediaTarget) (*models.Media, error) {
+	if !MediaFileTypes[fileType] {
+		return nil, &utils.AppError{Code: http.StatusBadRequest, Message: "不支持的文件类型", Key: "file.unsupported_type"}
+	}
+	return storeMedia(ctx, db, body, models.Media{
+		OwnerID:          ownerID,
+		FileName:         filepath.Base(fileName),
+		FileType:         fileType,
+		Scope:            target.Scope,
+		ConversationType: target.ConversationType,
+		ConversationID:   target.ConversationID,
+		MomentID:         target.MomentID,
+		SquarePostID:     target.SquarePostID,
+	})
+}
+
+// storeMedia 保存文件内容，按 media 中的上传者、会话等信息创建媒体记录
+func storeMedia(ctx context.Context, db *gorm.DB, body io.Reader, media models.Media) (*models.Media, error) {
+	config := utils.GetMediaConfig()
+
+	// 先写入临时文件，同时计算哈希
+	tempFile, err := os.CreateTemp("", "media-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tempFile, hasher), io.LimitReader(body, config.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if size == 0 {
+		return nil, &utils.AppError{Code: http.StatusBadRequest, Message: "文件内容为空", Key: "file.empty"}
+	}
+	if size > config.MaxSize {
+		return nil, &utils.AppError{Code: http.StatusRequestEntityTooLarge, Message: fmt.Sprintf("文件大小不能超过%dMB", config.MaxSize>>20), Key: "file.too_large", Args: []interface{}{config.MaxSize >> 20}}
+	}
+	if media.ParentID == 0 {
+		if err := CheckMediaQuota(db, media.OwnerID, size); err != nil {
+			return nil, err
+		}
+	}
+	return saveMediaFile(ctx, db, tempFile, hex.EncodeToString(hasher.Sum(nil)), size, media)
+}
+
+// saveMediaFile 保存已写入本地的文件，hash 和 size 由调用方计算
+// media 中已指定 ContentType 时（如转码后的音频）直接使用，否则按文件内容判断
+func saveMediaFile(ctx context.Context, db *gorm.DB, file *os.File, hash string, size int64, media models.Media) (*models.Media, error) {
+	contentType := media.ContentType
+	if contentType == "" {
+		contentType = detectMediaContentType(file, media.FileName)
+	}
+
+	blob, err := acquireMediaBlob(ctx, db, file, hash, size, contentType)
+	if err != nil {
+		return nil, mediaStorageAppError(err)
+	}
+
+	media.BlobID = blob.ID
+	media.Hash = hash
+	media.ContentType = contentType
+	media.Size = size
+	media.CreatedAt = time.Now().Unix()
+	if err := db.Create(&media).Error; err != nil {
+		releaseMediaBlob(ctx, db, blob.ID)
+		return nil, err
+	}
+	return &media, nil
+}
+
+// MediaUsage 用户已使用的存储空间，包括进行中的断点续传预占的大小，缩略图不计入
+func MediaUsage(db *gorm.DB, userID uint) int64 {
+	var used, reserved int64
+	db.Model(&models.Media{}).Where("owner_id = ? AND parent_id = 0", userID).Select("COALESCE(SUM(size), 0)").Scan(&used)
+	db.Model(&models.UploadSession{}).Where("user_id = ? AND status = ? AND expires_at > ?", userID, UploadStatusUploading, time.Now().Unix()).
+		Select("COALESCE(SUM(size), 0)").Scan(&reserved)
+	return used + reserved
+}
+
+// CheckMediaQuota 检查保存 size 字节后是否超过用户的存储配额
+func CheckMediaQuota(db *gorm.DB, userID uint, size int64) error {
+	quota := utils.GetMediaConfig().UserQuota
+	if quota <= 0 {
+		return nil
+	}
+	if MediaUsage(db, userID)+size > quota {
+		return &utils.AppError{Code: http.StatusRequestEntityTooLarge, Message: fmt.Sprintf("存储空间不足，配额为%dMB", quota>>20), Key: "media.quota_exceeded", Args: []interface{}{quota >> 20}}
+	}
+	return nil
+}
+
+// acquireMediaBlob 查找内容相同的文件并增加引用，不存在时上传到存储后端
+func acquireMediaBlob(ctx context.Context, db *gorm.DB, file *os.File, hash string, size int64, contentType string) (*models.MediaBlob, error) {
+	var blob models.MediaBlob
+	if err := db.Where("hash = ?", hash).First(&blob).Error; err == nil {
+		db.Model(&blob).UpdateColumn("ref_count", gorm.Expr("ref_count + 1"))
+		return &blob, nil
+	}
+
+	storage, err := utils.GetMediaStorage("")
+	if err != nil {
+		return nil, err
+	}
+	key := utils.MediaStorageKey(hash)
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if err := storage.Put(ctx, key, file, size, contentType); err != nil {
+		return nil, err
+	}
+
+	blob = models.MediaBlob{
+		Hash:        hash,
+		Size:        size,
+		ContentType: contentType,
+		Storage:     storage.Name(),
+		StorageKey:  key,
+		RefCount:    1,
+		CreatedAt:   time.Now().Unix(),
+	}
+	if err := db.Create(&blob).Error; err != nil {
+		// 并发上传了相同内容，改为引用已有的文件
+		if db.Where("hash = ?", hash).First(&blob).Error != nil {
+			return nil, err
+		}
+		db.Model(&blob).UpdateColumn("ref_count", gorm.Expr("ref_count + 1"))
+	}
+	return &blob, nil
+}
+
+// releaseMediaBlob 减少文件引用，没有引用时从存储后端删除
+func releaseMediaBlob(ctx context.Context, db *gorm.DB, blobID uint) {
+	db.Model(&models.MediaBlob{}).Where("id = ?", blobID).UpdateColumn("ref_count", gorm.Expr("ref_count - 1"))
+
+	var blob models.MediaBlob
+	if err := db.First(&blob, blobID).Error; err != nil || blob.RefCount > 0 {
+		return
+	}
+	if db.Where("id = ? AND ref_count <= 0", blobID).Delete(&models.MediaBlob{}).RowsAffected == 0 {
+		return
+	}
+	storage, err := utils.GetMediaStorage(blob.Storage)
+	if err == nil {
+		err = storage.Delete(ctx, blob.StorageKey)
+	}
+	if err != nil {
+		utils.Logger.Errorf("删除媒体文件失败: blob=%d, key=%s, error=%v", blob.ID, blob.StorageKey, err)
+	}
+}
+
+// detectMediaContentType 按文件头判断类型，无法识别时按扩展名判断
+func detectMediaContentType(file *os.File, fileName string) string {
+	head := make([]byte, 512)
+	n, _ := file.ReadAt(head, 0)
+	if format := utils.SniffImageFormat(head[:n]); format != "" {
+		return utils.ImageContentType(format)
+	}
+	contentType := http.DetectContentType(head[:n])
+	if contentType == "application/octet-stream" {
+		if byExt := mime.TypeByExtension(strings.ToLower(filepath.Ext(fileName))); byExt != "" {
+			return byExt
+		}
+	}
+	return contentType
+}
+
+// CanAccessMedia 用户是否可以下载媒体
+func CanAccessMedia(db *gorm.DB, media *models.Media, userID uint) bool {
+	switch {
+	case media.Scope == MediaScopePublic:
+		return true
+	case userID == 0:
+		return false
+	case media.OwnerID == userID:
+		return true
+	case media.Scope == MediaScopeMoment:
+		if media.MomentID == 0 {
+			return false
+		}
+		var moment models.Moment
+		if err := db.First(&moment, media.MomentID).Error; err != nil {
+			return false
+		}
+		return CanViewMoment(db, &moment, userID)
+	case media.Scope == MediaScopeSquare:
+		if media.SquarePostID == 0 {
+			return false
+		}
+		var post models.SquarePost
+		if err := db.First(&post, media.SquarePostID).Error; err != nil {
+			return false
+		}
+		return post.Status == models.SquarePostNormal
+	case media.Scope != MediaScopeConversation:
+		return false
+	case media.ConversationType == ConversationPrivate:
+		return media.ConversationID == userID
+	case media.ConversationType == ConversationGroup:
+		var count int64
+		db.Model(&models.GroupMember{}).Where("group_id = ? AND user_id = ?", media.ConversationID, userID).Count(&count)
+		return count > 0
+	}
+	return false
+}
+
+// GetAccessibleMedia 获取用户有权访问的媒体，无权访问时同样返回不存在
+func GetAccessibleMedia(db *gorm.DB, userID, mediaID uint) (*models.Media, error) {
+	var media models.Media
+	if err := db.First(&media, mediaID).Error; err != nil || !CanAccessMedia(db, &media, userID) {
+		return nil, &utils.AppError{Code: http.StatusNotFound, Message: "文件不存在", Key: "media.not_found"}
+	}
+	return &media, nil
+}
+
+// MediaRef 保存在消息等数据中的媒体地址，长期有效
+// 公开媒体直接指向内容；其余指向媒体信息接口，由客户端换取带签名的下载地址
+func MediaRef(media *models.Media) string {
+	if media.Scope == MediaScopePublic {
+		return fmt.Sprintf("/api/media/%d/content", media.ID)
+	}
+	return fmt.Sprintf("/api/media/%d", media.ID)
+}
+
+// ParseMediaRef 从 MediaRef 或下载地址中解析媒体ID
+func ParseMediaRef(ref string) (uint, bool) {
+	rest, ok := strings.CutPrefix(ref, "/api/media/")
+	if !ok {
+		return 0, false
+	}
+	if i := strings.IndexAny(rest, "/?"); i >= 0 {
+		rest = rest[:i]
+	}
+	id, err := strconv.ParseUint(rest, 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// MediaURL 返回用户下载媒体的地址，公开媒体的地址长期有效，其余为带签名的短时效地址
+func MediaURL(media *models.Media, userID uint) (string, int64) {
+	if media.Scope == MediaScopePublic {
+		return MediaRef(media), 0
+	}
+	return utils.SignMediaURL(media.ID, userID)
+}
+
+// OpenMedia 从存储后端读取媒体内容
+func OpenMedia(ctx context.Context, db *gorm.DB, media *models.Media) (io.ReadCloser, error) {
+	var blob models.MediaBlob
+	if err := db.First(&blob, media.BlobID).Error; err != nil {
+		return nil, &utils.AppError{Code: http.StatusNotFound, Message: "文件不存在", Key: "media.not_found"}
+	}
+	storage, err := utils.GetMediaStorage(blob.Storage)
+	if err != nil {
+		return nil, mediaStorageAppError(err)
+	}
+	reader, err := storage.Get(ctx, blob.StorageKey)
+	if err != nil {
+		return nil, mediaStorageAppError(err)
+	}
+	return reader, nil
+}
+
+// MediaLocalFile 返回媒体在本地磁盘上的路径，供 ffmpeg 等外部程序读取
+// 本地存储直接返回文件路径，其他存储下载到临时文件，调用方处理完后调用 cleanup
+func MediaLocalFile(ctx context.Context, db *gorm.DB, media *models.Media) (string, func(), error) {
+	noop := func() {}
+	var blob models.MediaBlob
+	if err := db.First(&blob, media.BlobID).Error; err != nil {
+		return "", noop, &utils.AppError{Code: http.StatusNotFound, Message: "文件不存在", Key: "media.not_found"}
+	}
+	storage, err := utils.GetMediaStorage(blob.Storage)
+	if err != nil {
+		return "", noop, mediaStorageAppError(err)
+	}
+	if local, ok := storage.(utils.LocalPathStorage); ok {
+		return local.LocalPath(blob.StorageKey), noop, nil
+	}
+
+	reader, err := storage.Get(ctx, blob.StorageKey)
+	if err != nil {
+		return "", noop, mediaStorageAppError(err)
+	}
+	defer reader.Close()
+	tempFile, err := os.CreateTemp("", "media-*"+filepath.Ext(media.FileName))
+	if err != nil {
+		return "", noop, err
+	}
+	cleanup := func() { os.Remove(tempFile.Name()) }
+	_, err = io.Copy(tempFile, reader)
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", noop, err
+	}
+	return tempFile.Name(), cleanup, nil
+}
+
+// DeleteMedia 上传者删除媒体及其缩略图，文件没有其他引用时一并删除
+// 仍被消息、动态或表情引用的媒体不能删除，需要先删除引用它的内容
+func DeleteMedia(ctx context.Context, db *gorm.DB, userID, mediaID uint) error {
+	var media models.Media
+	if err := db.Where("id = ? AND owner_id = ?", mediaID, userID).First(&media).Error; err != nil {
+		return &utils.AppError{Code: http.StatusNotFound, Message: "文件不存在", Key: "media.not_found"}
+	}
+	var renditions []models.Media
+	db.Where("parent_id = ?", media.ID).Find(&renditions)
+	items := append(renditions, media)
+	if mediaInUse(db, items) {
+		return &utils.AppError{Code: http.StatusConflict, Message: "文件正在被消息、动态或表情使用，不能删除", Key: "media.in_use"}
+	}
+	for _, item := range items {
+		if err := db.Delete(&item).Error; err != nil {
+			return err
+		}
+		releaseMediaBlob(ctx, db, item.BlobID)
+	}
+	return nil
+}
+
+// mediaInUse 媒体或其缩略图是否仍被消息、语音消息、动态或表情引用
+// 消息和动态中保存的是 MediaRef 地址，按地址匹配
+func mediaInUse(db *gorm.DB, items []models.Media) bool {
+	ids := make([]uint, 0, len(items))
+	refs := make([]string, 0, len(items)*2)
+	patterns := make([]string, 0, len(items)*2)
+	for _, item := range items {
+		ids = append(ids, item.ID)
+		refs = append(refs, fmt.Sprintf("/api/media/%d", item.ID), fmt.Sprintf("/api/media/%d/content", item.ID))
+		// 内容地址后面可能带有签名参数
+		patterns = append(patterns, fmt.Sprintf(`%%"url":"/api/media/%d"%%`, item.ID), fmt.Sprintf(`%%"url":"/api/media/%d/content%%`, item.ID))
+	}
+
+	var count int64
+	db.Model(&models.Emoticon{}).Where("media_id IN ?", ids).Count(&count)
+	if count > 0 {
+		return true
+	}
+	db.Model(&models.VoiceMessage{}).Where("media_id IN ?", ids).Count(&count)
+	if count > 0 {
+		return true
+	}
+	db.Model(&models.ChatMessage{}).Where("content IN ?", refs).Count(&count)
+	if count > 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		db.Model(&models.ChatMessage{}).Where("extra LIKE ?", pattern).Count(&count)
+		if count > 0 {
+			return true
+		}
+		db.Model(&models.Moment{}).Where("media LIKE ?", pattern).Count(&count)
+		if count > 0 {
+			return true
+		}
+		db.Model(&models.SquarePost{}).Where("media LIKE ?", pattern).Count(&count)
+		if count > 0 {
+			return true
+		}
+	}
+	for _, item := range items {
+		if item.MomentID == 0 {
+			continue
+		}
+		db.Model(&models.Moment{}).Where("id = ?", item.MomentID).Count(&count)
+		if count > 0 {
+			return true
+		}
+	}
+	for _, item := range items {
+		if item.SquarePostID == 0 {
+			continue
+		}
+		db.Model(&models.SquarePost{}).Where("id = ?", item.SquarePostID).Count(&count)
+		if count > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// mediaStorageAppError 将存储错误转换为接口错误
+func mediaStorageAppError(err error) error {
+	switch {
+	case errors.Is(err, utils.ErrMediaNotFound):
+		return &utils.AppError{Code: http.StatusNotFound, Message: "文件不存在", Key: "media.not_found"}
+	case errors.Is(err, utils.ErrMediaUnavailable), errors.Is(err, utils.ErrMediaNoStorage):
+		return &utils.AppError{Code: http.StatusServiceUnavailable, Message: "文件存储服务暂不可用", Key: "file.storage_unavailable"}
+	default:
+		return err
+	}
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// useTestMediaConfig 媒体文件和上传分片保存到临时目录，测试结束后恢复原配置
+func useTestMediaConfig(t *testing.T, modify func(*utils.MediaConfig)) {
+	t.Helper()
+	previous := utils.GetMediaConfig()
+	dir := t.TempDir()
+	config := previous
+	config.Storage = "local"
+	config.LocalDir = filepath.Join(dir, "media")
+	config.UploadDir = filepath.Join(dir, "uploads")
+	config.URLSecret = []byte("test-secret")
+	if modify != nil {
+		modify(&config)
+	}
+	utils.SetMediaConfig(config)
+	t.Cleanup(func() { utils.SetMediaConfig(previous) })
+}
+
+// storeTestMedia 保存一个测试文件
+func storeTestMedia(t *testing.T, db *gorm.DB, ownerID uint, content, fileType string, target MediaTarget) *models.Media {
+	t.Helper()
+	media, err := StoreMedia(context.Background(), db, ownerID, strings.NewReader(content), "test.bin", fileType, target)
+	if err != nil {
+		t.Fatalf("保存媒体失败: %v", err)
+	}
+	return media
+}
+
+// appErrorKey 返回业务错误的消息键，不是业务错误时返回空
+func appErrorKey(err error) string {
+	var appErr *utils.AppError
+	if errors.As(err, &appErr) {
+		return appErr.Key
+	}
+	return ""
+}
+
+// appErrorCode 返回业务错误的状态码，不是业务错误时返回0
+func appErrorCode(err error) int {
+	var appErr *utils.AppError
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return 0
+}
+
+func TestResolveMediaTargetNeverPublic(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+
+	tests := []struct {
+		scope string
+		want  string
+	}{
+		{"", MediaScopePrivate},
+		{"private", MediaScopePrivate},
+		{"moment", MediaScopeMoment},
+		{"square", MediaScopeSquare},
+		// 旧客户端为动态上传时传 public
+		{"public", MediaScopeMoment},
+	}
+	for _, tt := range tests {
+		target, err := ResolveMediaTarget(db, alice.ID, tt.scope, 0, 0)
+		if err != nil {
+			t.Fatalf("scope=%q: %v", tt.scope, err)
+		}
+		if target.Scope != tt.want {
+			t.Errorf("scope=%q 得到 %s，应为 %s", tt.scope, target.Scope, tt.want)
+		}
+	}
+}
+
+func TestMomentMediaAccess(t *testing.T) {
+	db := newTestDB(t)
+	useTestMediaConfig(t, nil)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	carol := createTestUser(t, db, "carol")
+	makeFriends(t, db, alice.ID, bob.ID)
+
+	media := storeTestMedia(t, db, alice.ID, "photo", "image", MediaTarget{Scope: MediaScopeMoment})
+	if CanAccessMedia(db, media, bob.ID) {
+		t.Error("动态发布前好友不应能访问媒体")
+	}
+
+	moment := models.Moment{UserID: alice.ID, Visibility: models.MomentVisibilityFriends}
+	db.Create(&moment)
+	attached, err := AttachMomentMedia(db, alice.ID, moment.ID, media.ID)
+	if err != nil {
+		t.Fatalf("关联动态媒体失败: %v", err)
+	}
+	if attached.ID != media.ID || attached.MomentID != moment.ID {
+		t.Fatalf("未使用过的媒体应直接关联，得到 id=%d moment=%d", attached.ID, attached.MomentID)
+	}
+	if ref := MediaRef(attached); ref != fmt.Sprintf("/api/media/%d", attached.ID) {
+		t.Errorf("动态媒体不应是公开地址: %s", ref)
+	}
+
+	tests := []struct {
+		name   string
+		userID uint
+		want   bool
+	}{
+		{"发布者", alice.ID, true},
+		{"好友", bob.ID, true},
+		{"非好友", carol.ID, false},
+		{"未登录", 0, false},
+	}
+	for _, tt := range tests {
+		if got := CanAccessMedia(db, attached, tt.userID); got != tt.want {
+			t.Errorf("%s: CanAccessMedia = %v，应为 %v", tt.name, got, tt.want)
+		}
+	}
+
+	db.Model(&moment).Update("visibility", models.MomentVisibilityPrivate)
+	if CanAccessMedia(db, attached, bob.ID) {
+		t.Error("动态改为仅自己可见后好友不应能访问媒体")
+	}
+	db.Model(&moment).Update("visibility", models.MomentVisibilityFriends)
+	if err := DeleteMoment(db, moment.ID); err != nil {
+		t.Fatalf("删除动态失败: %v", err)
+	}
+	var released models.Media
+	db.First(&released, attached.ID)
+	if released.Scope != MediaScopePrivate || released.MomentID != 0 {
+		t.Errorf("动态删除后媒体应退回为私有，得到 scope=%s moment=%d", released.Scope, released.MomentID)
+	}
+	if CanAccessMedia(db, &released, bob.ID) || !CanAccessMedia(db, &released, alice.ID) {
+		t.Error("动态删除后只有上传者可以访问媒体")
+	}
+	if err := DeleteMedia(context.Background(), db, alice.ID, released.ID); err != nil {
+		t.Errorf("动态删除后上传者应能删除媒体: %v", err)
+	}
+}
+
+func TestAttachMomentMediaCopiesUsedMedia(t *testing.T) {
+	db := newTestDB(t)
+	useTestMediaConfig(t, nil)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+
+	avatar := storeTestMedia(t, db, alice.ID, "avatar", "image", MediaTarget{Scope: MediaScopePublic})
+	chat := storeTestMedia(t, db, alice.ID, "chat", "image", MediaTarget{Scope: MediaScopeConversation, ConversationType: ConversationPrivate, ConversationID: bob.ID})
+	moment := models.Moment{UserID: alice.ID, Visibility: models.MomentVisibilityFriends}
+	db.Create(&moment)
+
+	for _, original := range []*models.Media{avatar, chat} {
+		attached, err := AttachMomentMedia(db, alice.ID, moment.ID, original.ID)
+		if err != nil {
+			t.Fatalf("关联动态媒体失败: %v", err)
+		}
+		if attached.ID == original.ID || attached.Scope != MediaScopeMoment || attached.MomentID != moment.ID {
+			t.Errorf("已使用的媒体应复制一条动态媒体，得到 %+v", attached)
+		}
+		var reloaded models.Media
+		db.First(&reloaded, original.ID)
+		if reloaded.Scope != original.Scope || reloaded.MomentID != 0 {
+			t.Errorf("原媒体的可见范围不应改变，得到 %s", reloaded.Scope)
+		}
+	}
+
+	voice := storeTestMedia(t, db, alice.ID, "voice", "voice", MediaTarget{Scope: MediaScopePrivate})
+	if _, err := AttachMomentMedia(db, alice.ID, moment.ID, voice.ID); appErrorKey(err) != "moment.invalid_media" {
+		t.Errorf("动态不能关联语音，得到 %v", err)
+	}
+	other := storeTestMedia(t, db, bob.ID, "other", "image", MediaTarget{Scope: MediaScopePrivate})
+	if _, err := AttachMomentMedia(db, alice.ID, moment.ID, other.ID); appErrorKey(err) != "media.not_found" {
+		t.Errorf("不能关联他人的私有媒体，得到 %v", err)
+	}
+}
+
+func TestDeleteMediaInUse(t *testing.T) {
+	db := newTestDB(t)
+	useTestMediaConfig(t, nil)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+
+	tests := []struct {
+		name      string
+		reference func(media *models.Media)
+	}{
+		{"消息内容", func(media *models.Media) {
+			db.Create(&models.ChatMessage{SenderID: alice.ID, ReceiverID: bob.ID, Type: "file", Content: MediaRef(media)})
+		}},
+		{"消息附加信息", func(media *models.Media) {
+			db.Create(&models.ChatMessage{SenderID: alice.ID, ReceiverID: bob.ID, Type: "image", Content: "图片",
+				Extra: fmt.Sprintf(`{"media_id":%d,"url":"%s"}`, media.ID, MediaRef(media))})
+		}},
+		{"旧动态的公开地址", func(media *models.Media) {
+			db.Create(&models.Moment{UserID: alice.ID, Media: fmt.Sprintf(`[{"type":"image","url":"/api/media/%d/content"}]`, media.ID)})
+		}},
+		{"动态", func(media *models.Media) {
+			moment := models.Moment{UserID: alice.ID, Media: "[]"}
+			db.Create(&moment)
+			db.Model(media).Update("moment_id", moment.ID)
+		}},
+		{"表情", func(media *models.Media) {
+			db.Create(&models.Emoticon{OwnerID: alice.ID, MediaID: media.ID, URL: MediaRef(media)})
+		}},
+		{"语音消息", func(media *models.Media) {
+			db.Create(&models.VoiceMessage{SenderID: alice.ID, ReceiverID: bob.ID, MediaID: media.ID, URL: MediaRef(media)})
+		}},
+	}
+	for i, tt := range tests {
+		media := storeTestMedia(t, db, alice.ID, fmt.Sprintf("content-%d", i), "file", MediaTarget{Scope: MediaScopePrivate})
+		tt.reference(media)
+		if err := DeleteMedia(context.Background(), db, alice.ID, media.ID); appErrorKey(err) != "media.in_use" {
+			t.Errorf("%s: 仍被引用的媒体不应删除，得到 %v", tt.name, err)
+		}
+	}
+
+	// 其他媒体的地址前缀相同时不算引用
+	media := storeTestMedia(t, db, alice.ID, "unused", "file", MediaTarget{Scope: MediaScopePrivate})
+	db.Create(&models.ChatMessage{SenderID: alice.ID, ReceiverID: bob.ID, Type: "file", Content: fmt.Sprintf("/api/media/%d0", media.ID)})
+	if err := DeleteMedia(context.Background(), db, bob.ID, media.ID); appErrorKey(err) != "media.not_found" {
+		t.Errorf("不能删除他人的媒体，得到 %v", err)
+	}
+	if err := DeleteMedia(context.Background(), db, alice.ID, media.ID); err != nil {
+		t.Fatalf("删除未引用的媒体失败: %v", err)
+	}
+	var count int64
+	db.Model(&models.MediaBlob{}).Where("hash = ?", media.Hash).Count(&count)
+	if count != 0 {
+		t.Error("没有引用的文件应一并删除")
+	}
+}
+
+func TestParseMediaRef(t *testing.T) {
+	tests := []struct {
+		ref  string
+		id   uint
+		isOK bool
+	}{
+		{"/api/media/12", 12, true},
+		{"/api/media/12/content", 12, true},
+		{"/api/media/12/content?uid=1&expires=2&sig=ab", 12, true},
+		{"/api/media/abc", 0, false},
+		{"/api/media/0", 0, false},
+		{"/uploads/image/a.png", 0, false},
+	}
+	for _, tt := range tests {
+		id, ok := ParseMediaRef(tt.ref)
+		if id != tt.id || ok != tt.isOK {
+			t.Errorf("ParseMediaRef(%q) = %d, %v，应为 %d, %v", tt.ref, id, ok, tt.id, tt.isOK)
+		}
+	}
+}
+
+func TestMediaURLSignedForNonPublic(t *testing.T) {
+	db := newTestDB(t)
+	useTestMediaConfig(t, nil)
+	alice := createTestUser(t, db, "alice")
+
+	private := storeTestMedia(t, db, alice.ID, "private", "file", MediaTarget{Scope: MediaScopePrivate})
+	url, expires := MediaURL(private, alice.ID)
+	if !strings.Contains(url, "sig=") || expires <= time.Now().Unix() {
+		t.Errorf("非公开媒体应返回带签名的短时效地址，得到 %s, %d", url, expires)
+	}
+	public := storeTestMedia(t, db, alice.ID, "public", "image", MediaTarget{Scope: MediaScopePublic})
+	if url, expires := MediaURL(public, alice.ID); url != MediaRef(public) || expires != 0 {
+		t.Errorf("公开媒体应返回长期地址，得到 %s, %d", url, expires)
+	}
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"context"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 消息自动翻译：开启 UserSettings.AutoTranslate 的用户收到文本消息时，按各自的语言生成译文
+// 译文按接收者保存，推送、历史消息和同步接口中以 translation 字段返回
+
+// 推送时自动翻译的超时时间
+const autoTranslateTimeout = 15 * time.Second
+
+// PushChatMessage 推送消息给接收者
+// 未开启自动翻译的接收者立即推送；开启的接收者在翻译完成后推送，data 中附带 translation
+func PushChatMessage(db *gorm.DB, msg *models.ChatMessage, recipients []uint, eventType string, data map[string]any) {
+	targets := autoTranslateTargets(db, recipients)
+	delete(targets, msg.SenderID)
+	for _, userID := range recipients {
+		if _, ok := targets[userID]; !ok {
+			utils.PushMessageToUser(userID, map[string]any{"type": eventType, "data": data})
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), autoTranslateTimeout)
+	defer cancel()
+	translations := translateMessageFor(ctx, db, msg, targets)
+	for userID := range targets {
+		payload := data
+		if translation, ok := translations[userID]; ok {
+			payload = make(map[string]any, len(data)+1)
+			for k, v := range data {
+				payload[k] = v
+			}
+			payload["translation"] = translation
+		}
+		utils.PushMessageToUser(userID, map[string]any{"type": eventType, "data": payload})
+	}
+}
+
+// LoadMessageTranslations 获取用户在这些消息上的译文，按消息ID索引
+// 用户开启自动翻译时，为还没有译文的文本消息补充翻译，每次最多 MaxTranslationBatch 条
+func LoadMessageTranslations(ctx context.Context, db *gorm.DB, userID uint, messages []models.ChatMessage) map[uint]*models.MessageTranslation {
+	translations := map[uint]*models.MessageTranslation{}
+	if len(messages) == 0 {
+		return translations
+	}
+	ids := make([]uint, len(messages))
+	for i, msg := range messages {
+		ids[i] = msg.ID
+	}
+	var rows []models.MessageTranslation
+	db.Where("user_id = ? AND message_id IN ?", userID, ids).Find(&rows)
+	for i := range rows {
+		translations[rows[i].MessageID] = &rows[i]
+	}
+
+	target := autoTranslateTargets(db, []uint{userID})[userID]
+	if target == "" {
+		return translations
+	}
+
+	// 按已知的源语言分组，未检测过的消息自动检测
+	groups := map[string][]*models.ChatMessage{}
+	pending := 0
+	for i := range messages {
+		msg := &messages[i]
+		if _, ok := translations[msg.ID]; ok || !needsTranslation(msg, userID) {
+			continue
+		}
+		source := "auto"
+		if msg.SourceLanguage != "" {
+			source = utils.NormalizeLanguage(msg.SourceLanguage)
+		}
+		if source == target {
+			continue
+		}
+		if pending >= MaxTranslationBatch {
+			break
+		}
+		groups[source] = append(groups[source], msg)
+		pending++
+	}
+
+	now := time.Now().Unix()
+	for source, group := range groups {
+		texts := make([]string, len(group))
+		for i, msg := range group {
+			texts[i] = msg.Content
+		}
+		results, err := TranslateTexts(ctx, db, texts, source, target)
+		if err != nil {
+			utils.Logger.Errorf("补充消息译文失败: user=%d, error=%v", userID, err)
+			continue
+		}
+		for i, msg := range group {
+			result := results[i]
+			if msg.SourceLanguage == "" {
+				saveMessageSourceLanguage(db, msg, result.SourceLang)
+			}
+			if translation := newMessageTranslation(msg, userID, &result, now); translation != nil {
+				saveMessageTranslation(db, translation)
+				translations[msg.ID] = translation
+			}
+		}
+	}
+	return translations
+}
+
+// translateMessageFor 按接收者的语言翻译消息并保存，相同语言的接收者共用一次翻译
+func translateMessageFor(ctx context.Context, db *gorm.DB, msg *models.ChatMessage, targets map[uint]string) map[uint]*models.MessageTranslation {
+	translations := map[uint]*models.MessageTranslation{}
+	if !needsTranslation(msg, 0) {
+		return translations
+	}
+
+	source := utils.NormalizeLanguage(msg.SourceLanguage)
+	if msg.SourceLanguage == "" {
+		detected, err := DetectLanguage(ctx, db, msg.Content)
+		if err != nil {
+			utils.Logger.Errorf("检测消息语言失败: message=%d, error=%v", msg.ID, err)
+			return translations
+		}
+		saveMessageSourceLanguage(db, msg, detected)
+		source = detected
+	}
+
+	results := map[string]*TranslationResult{}
+	now := time.Now().Unix()
+	for userID, target := range targets {
+		if target == source {
+			continue
+		}
+		result, done := results[target]
+		if !done {
+			var err error
+			result, err = TranslateText(ctx, db, msg.Content, source, target)
+			if err != nil {
+				utils.Logger.Errorf("自动翻译消息失败: message=%d, target=%s, error=%v", msg.ID, target, err)
+			}
+			results[target] = result
+		}
+		if result == nil {
+			continue
+		}
+		if translation := newMessageTranslation(msg, userID, result, now); translation != nil {
+			saveMessageTranslation(db, translation)
+			translations[userID] = translation
+		}
+	}
+	return translations
+}
+
+// autoTranslateTargets 开启自动翻译的用户及其目标语言
+func autoTranslateTargets(db *gorm.DB, userIDs []uint) map[uint]string {
+	targets := map[uint]string{}
+	if len(userIDs) == 0 {
+		return targets
+	}
+	var settings []models.UserSettings
+	db.Where("user_id IN ? AND auto_translate = ?", userIDs, true).Find(&settings)
+	for _, s := range settings {
+		lang := utils.NormalizeLanguage(s.Language)
+		if _, ok := utils.SupportedLanguages[lang]; ok {
+			targets[s.UserID] = lang
+		}
+	}
+	return targets
+}
+
+// needsTranslation 只翻译别人发送的非空文本消息
+func needsTranslation(msg *models.ChatMessage, viewerID uint) bool {
+	return msg.Type == "text" && msg.SenderID != viewerID && strings.TrimSpace(msg.Content) != ""
+}
+
+// newMessageTranslation 根据翻译结果生成译文记录，译文与原文相同时返回 nil
+func newMessageTranslation(msg *models.ChatMessage, userID uint, result *TranslationResult, now int64) *models.MessageTranslation {
+	if result.SourceLang == result.TargetLang || result.TranslatedText == msg.Content {
+		return nil
+	}
+	return &models.MessageTranslation{
+		MessageID:  msg.ID,
+		UserID:     userID,
+		SourceLang: result.SourceLang,
+		TargetLang: result.TargetLang,
+		Text:       result.TranslatedText,
+		Provider:   result.Provider,
+		CreatedAt:  now,
+	}
+}
+
+func saveMessageTranslation(db *gorm.DB, translation *models.MessageTranslation) {
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "message_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"source_lang", "target_lang", "text", "provider", "created_at"}),
+	}).Create(translation).Error
+	if err != nil {
+		utils.Logger.Errorf("保存消息译文失败: message=%d, user=%d, error=%v", translation.MessageID, translation.UserID, err)
+	}
+}
+
+// saveMessageSourceLanguage 保存检测到的消息语言，之后的接收者不再重复检测
+func saveMessageSourceLanguage(db *gorm.DB, msg *models.ChatMessage, lang string) {
+	msg.SourceLanguage = lang
+	db.Model(&models.ChatMessage{}).Where("id = ? AND (source_language = '' OR source_language IS NULL)", msg.ID).Update("source_language", lang)
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"context"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// enableAutoTranslate 为用户开启自动翻译，译文使用 lang
+func enableAutoTranslate(t *testing.T, db *gorm.DB, userID uint, lang string) {
+	t.Helper()
+	var settings models.UserSettings
+	err := db.Where(models.UserSettings{UserID: userID}).
+		Assign(map[string]interface{}{"language": lang, "auto_translate": true}).
+		FirstOrCreate(&settings).Error
+	if err != nil {
+		t.Fatalf("开启自动翻译失败: %v", err)
+	}
+}
+
+func TestTranslateMessageForRecipients(t *testing.T) {
+	db := newTestDB(t)
+	translator := &fakeTranslator{}
+	useFakeTranslator(t, translator)
+
+	sender := createTestUser(t, db, "sender")
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	carol := createTestUser(t, db, "carol")
+	dave := createTestUser(t, db, "dave")
+	eve := createTestUser(t, db, "eve")
+	enableAutoTranslate(t, db, alice.ID, "zh_CN")
+	enableAutoTranslate(t, db, bob.ID, "zh-CN")
+	enableAutoTranslate(t, db, dave.ID, "ja")
+	enableAutoTranslate(t, db, eve.ID, "en")
+
+	msg := models.ChatMessage{SenderID: sender.ID, GroupID: 1, Type: "text", Content: "hello"}
+	db.Create(&msg)
+
+	targets := autoTranslateTargets(db, []uint{alice.ID, bob.ID, carol.ID, dave.ID, eve.ID})
+	if _, ok := targets[carol.ID]; ok || len(targets) != 4 || targets[alice.ID] != "zh-CN" {
+		t.Fatalf("自动翻译的接收者不正确: %v", targets)
+	}
+	translations := translateMessageFor(context.Background(), db, &msg, targets)
+
+	tests := []struct {
+		name   string
+		userID uint
+		want   string
+	}{
+		{"简体中文", alice.ID, "zh-CN:hello"},
+		{"相同语言的另一位接收者", bob.ID, "zh-CN:hello"},
+		{"日语", dave.ID, "ja:hello"},
+		{"与原文语言相同", eve.ID, ""},
+	}
+	for _, tt := range tests {
+		var saved models.MessageTranslation
+		db.Where("message_id = ? AND user_id = ?", msg.ID, tt.userID).Limit(1).Find(&saved)
+		got := ""
+		if translation, ok := translations[tt.userID]; ok {
+			got = translation.Text
+		}
+		if got != tt.want || saved.Text != tt.want {
+			t.Errorf("%s: 推送的译文为 %q，保存的为 %q，应为 %q", tt.name, got, saved.Text, tt.want)
+		}
+	}
+
+	// 相同目标语言只翻译一次，检测到的语言保存到消息
+	if len(translator.batches) != 2 {
+		t.Errorf("应按目标语言各翻译一次，得到 %v", translator.batches)
+	}
+	var reloaded models.ChatMessage
+	db.First(&reloaded, msg.ID)
+	if reloaded.SourceLanguage != "en" {
+		t.Errorf("应保存检测到的源语言，得到 %q", reloaded.SourceLanguage)
+	}
+}
+
+func TestLoadMessageTranslations(t *testing.T) {
+	db := newTestDB(t)
+	translator := &fakeTranslator{}
+	useFakeTranslator(t, translator)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	enableAutoTranslate(t, db, alice.ID, "zh-CN")
+
+	messages := []models.ChatMessage{
+		{SenderID: bob.ID, ReceiverID: alice.ID, Type: "text", Content: "hello"},
+		{SenderID: alice.ID, ReceiverID: bob.ID, Type: "text", Content: "my own message"},
+		{SenderID: bob.ID, ReceiverID: alice.ID, Type: "image", Content: "/api/media/1"},
+		{SenderID: bob.ID, ReceiverID: alice.ID, Type: "text", Content: "你好", SourceLanguage: "zh-CN"},
+		{SenderID: bob.ID, ReceiverID: alice.ID, Type: "text", Content: "translated before"},
+	}
+	for i := range messages {
+		db.Create(&messages[i])
+	}
+	db.Create(&models.MessageTranslation{MessageID: messages[4].ID, UserID: alice.ID, SourceLang: "en", TargetLang: "zh-CN", Text: "之前的译文"})
+
+	translations := LoadMessageTranslations(context.Background(), db, alice.ID, messages)
+	if len(translations) != 2 {
+		t.Fatalf("应返回2条译文，得到 %d 条", len(translations))
+	}
+	if translation := translations[messages[0].ID]; translation == nil || translation.Text != "zh-CN:hello" {
+		t.Errorf("别人发送的文本消息应补充译文，得到 %+v", translation)
+	}
+	if translation := translations[messages[4].ID]; translation == nil || translation.Text != "之前的译文" {
+		t.Errorf("已有的译文应直接返回，得到 %+v", translation)
+	}
+	if len(translator.batches) != 1 || len(translator.batches[0]) != 1 {
+		t.Errorf("只应翻译缺少译文的一条消息，得到 %v", translator.batches)
+	}
+
+	// 未开启自动翻译的用户只返回已保存的译文，不补充翻译
+	db.Create(&models.MessageTranslation{MessageID: messages[1].ID, UserID: bob.ID, SourceLang: "en", TargetLang: "ja", Text: "訳文"})
+	translations = LoadMessageTranslations(context.Background(), db, bob.ID, messages)
+	if len(translations) != 1 || translations[messages[1].ID].Text != "訳文" {
+		t.Errorf("未开启自动翻译时只返回已保存的译文，得到 %v", translations)
+	}
+	if len(translator.batches) != 1 {
+		t.Errorf("未开启自动翻译时不应请求翻译，得到 %v", translator.batches)
+	}
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 朋友圈相关逻辑
+// 时间线采用读扩散：读取时根据好友关系实时拼装，不预先写入每个好友的收件箱
+
+// GetMutualFriendIDs 获取互为好友且双方均未屏蔽的用户ID
+func GetMutualFriendIDs(db *gorm.DB, userID uint) ([]uint, error) {
+	var ids []uint
+	err := db.Model(&models.Friend{}).
+		Where("user_id = ? AND blocked = 0", userID).
+		Where("friend_id IN (?)", db.Model(&models.Friend{}).
+			Select("user_id").
+			Where("friend_id = ? AND blocked = 0", userID)).
+		Distinct().Pluck("friend_id", &ids).Error
+	return ids, err
+}
+
+// IsMutualFriend 判断两个用户是否互为好友，两个方向各有一条未屏蔽的记录即可，重复记录不影响结果
+func IsMutualFriend(db *gorm.DB, userID, otherID uint) bool {
+	if userID == otherID {
+		return false
+	}
+	var count int64
+	db.Model(&models.Friend{}).
+		Where("((user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)) AND blocked = 0",
+			userID, otherID, otherID, userID).
+		Distinct("user_id").
+		Count(&count)
+	return count == 2
+}
+
+// ParseUserIDList 解析逗号分隔的用户ID列表
+func ParseUserIDList(s string) []uint {
+	ids := []uint{}
+	for _, part := range strings.Split(s, ",") {
+		if id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 32); err == nil && id > 0 {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+// JoinUserIDList 将用户ID列表拼接为逗号分隔的字符串
+func JoinUserIDList(ids []uint) string {
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.FormatUint(uint64(id), 10))
+	}
+	return strings.Join(parts, ",")
+}
+
+// CanViewMoment 判断用户是否可以查看某条动态
+func CanViewMoment(db *gorm.DB, moment *models.Moment, viewerID uint) bool {
+	if moment.UserID == viewerID {
+		return true
+	}
+	if moment.Visibility == models.MomentVisibilityPrivate {
+		return false
+	}
+	if !IsMutualFriend(db, moment.UserID, viewerID) {
+		return false
+	}
+
+	listed := false
+	for _, id := range ParseUserIDList(moment.VisibleUsers) {
+		if id == viewerID {
+			listed = true
+			break
+		}
+	}
+
+	switch moment.Visibility {
+	case models.MomentVisibilityInclude:
+		return listed
+	case models.MomentVisibilityExclude:
+		return !listed
+	default:
+		return true
+	}
+}
+
+// visibleMomentsScope 限定查看者可见的动态
+// authorIDs 必须已经是查看者本人或其互为好友的用户
+func visibleMomentsScope(db *gorm.DB, viewerID uint, authorIDs []uint) *gorm.DB {
+	pattern := "%," + strconv.FormatUint(uint64(viewerID), 10) + ",%"
+	return db.Where("user_id IN ?", authorIDs).
+		Where(db.Where("user_id = ?", viewerID).
+			Or("visibility = ?", models.MomentVisibilityFriends).
+			Or("visibility = ? AND (',' || visible_users || ',') LIKE ?", models.MomentVisibilityInclude, pattern).
+			Or("visibility = ? AND (',' || visible_users || ',') NOT LIKE ?", models.MomentVisibilityExclude, pattern))
+}
+
+// GetMomentTimeline 获取查看者的朋友圈时间线
+// before 为上一页最后一条动态的位置，为空时从最新开始
+func GetMomentTimeline(db *gorm.DB, viewerID uint, before MomentCursor, limit int) ([]models.Moment, error) {
+	friendIDs, err := GetMutualFriendIDs(db, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	authorIDs := append(friendIDs, viewerID)
+
+	query := before.apply(visibleMomentsScope(db, viewerID, authorIDs))
+
+	var moments []models.Moment
+	err = query.Order("created_at DESC, id DESC").Limit(ClampMomentLimit(limit)).Find(&moments).Error
+	return moments, err
+}
+
+// GetUserMoments 获取某个用户对查看者可见的动态
+func GetUserMoments(db *gorm.DB, viewerID, authorID uint, before MomentCursor, limit int) ([]models.Moment, error) {
+	if authorID != viewerID && !IsMutualFriend(db, authorID, viewerID) {
+		return []models.Moment{}, nil
+	}
+
+	query := before.apply(visibleMomentsScope(db, viewerID, []uint{authorID}))
+
+	var moments []models.Moment
+	err := query.Order("created_at DESC, id DESC").Limit(ClampMomentLimit(limit)).Find(&moments).Error
+	return moments, err
+}
+
+// 动态列表每页默认和最多返回的条数
+const (
+	defaultMomentPageSize = 20
+	maxMomentPageSize     = 50
+)
+
+// ClampMomentLimit 未指定每页条数时使用默认值，超过上限时按上限返回
+func ClampMomentLimit(limit int) int {
+	if limit < 1 {
+		return defaultMomentPageSize
+	}
+	if limit > maxMomentPageSize {
+		return maxMomentPageSize
+	}
+	return limit
+}
+
+// MomentCursor 动态列表的翻页位置，取上一页最后一条动态的发布时间和ID
+// 同一秒内发布的多条动态按ID区分，翻页时不会遗漏或重复；只有时间时按时间翻页
+type MomentCursor struct {
+	CreatedAt int64
+	ID        uint
+}
+
+// apply 只查询排在翻页位置之后的动态
+func (cursor MomentCursor) apply(query *gorm.DB) *gorm.DB {
+	switch {
+	case cursor.CreatedAt <= 0:
+		return query
+	case cursor.ID == 0:
+		return query.Where("created_at < ?", cursor.CreatedAt)
+	default:
+		return query.Where("(created_at < ? OR (created_at = ? AND id < ?))", cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+}
+
+// MomentInteractionVisibleUsers 获取查看者可以看到其点赞和评论的用户集合
+// 只展示查看者本人及其互为好友的用户的互动
+func MomentInteractionVisibleUsers(db *gorm.DB, viewerID uint) map[uint]bool {
+	visible := map[uint]bool{viewerID: true}
+	if ids, err := GetMutualFriendIDs(db, viewerID); err == nil {
+		for _, id := range ids {
+			visible[id] = true
+		}
+	}
+	return visible
+}
+
+// AttachMomentMedia 将媒体关联到动态，之后动态的可见用户可以通过签名地址访问
+func AttachMomentMedia(db *gorm.DB, userID, momentID, mediaID uint) (*models.Media, error) {
+	invalid := &utils.AppError{Code: http.StatusBadRequest, Message: "无效的媒体文件", Key: "moment.invalid_media"}
+	return attachPostMedia(db, userID, mediaID, MediaTarget{Scope: MediaScopeMoment, MomentID: momentID}, invalid)
+}
+
+// attachPostMedia 将图片或视频关联到动态或广场动态，不是图片、视频时返回 invalid
+// 上传者尚未使用的媒体直接关联；其他媒体（公开、会话中或其他动态的）复制一条记录，不影响原来的用途
+func attachPostMedia(db *gorm.DB, userID, mediaID uint, target MediaTarget, invalid *utils.AppError) (*models.Media, error) {
+	media, err := GetAccessibleMedia(db, userID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	if media.ParentID != 0 || (media.FileType != "image" && media.FileType != "video") {
+		return nil, invalid
+	}
+	unused := media.OwnerID == userID && media.MomentID == 0 && media.SquarePostID == 0 &&
+		(media.Scope == MediaScopePrivate || media.Scope == target.Scope)
+	if !unused {
+		return copyMedia(db, media, userID, target)
+	}
+
+	updates := map[string]interface{}{
+		"scope":             target.Scope,
+		"conversation_type": "",
+		"conversation_id":   0,
+		"moment_id":         target.MomentID,
+		"square_post_id":    target.SquarePostID,
+	}
+	if err := db.Model(&models.Media{}).Where("id = ? OR parent_id = ?", media.ID, media.ID).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	media.Scope = target.Scope
+	media.ConversationType = ""
+	media.ConversationID = 0
+	media.MomentID = target.MomentID
+	media.SquarePostID = target.SquarePostID
+	return media, nil
+}
+
+// DeleteMoment 删除动态及其点赞和评论，并在同一事务中释放动态关联的媒体
+func DeleteMoment(db *gorm.DB, momentID uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("moment_id = ?", momentID).Delete(&models.MomentLike{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("moment_id = ?", momentID).Delete(&models.MomentComment{}).Error; err != nil {
+			return err
+		}
+		// 动态中的图片和视频退回为上传者的私有媒体，其他人不能再访问，上传者可以重新使用或删除
+		err := tx.Model(&models.Media{}).Where("moment_id = ?", momentID).
+			Updates(map[string]interface{}{"scope": MediaScopePrivate, "moment_id": 0}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&models.Moment{}, momentID).Error
+	})
+}
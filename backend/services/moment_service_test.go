@@ -0,0 +1,141 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"fmt"
+	"testing"
+)
+
+func TestIsMutualFriend(t *testing.T) {
+	db := newTestDB(t)
+	a := createTestUser(t, db, "alice")
+	b := createTestUser(t, db, "bob")
+	c := createTestUser(t, db, "carol")
+	d := createTestUser(t, db, "dave")
+
+	makeFriends(t, db, a.ID, b.ID)
+	// 重复的好友记录不影响判断
+	db.Create(&models.Friend{UserID: a.ID, FriendID: b.ID})
+	// 单向好友
+	db.Create(&models.Friend{UserID: a.ID, FriendID: c.ID})
+	// 一方屏蔽
+	makeFriends(t, db, a.ID, d.ID)
+	db.Model(&models.Friend{}).Where("user_id = ? AND friend_id = ?", d.ID, a.ID).Update("blocked", 1)
+
+	tests := []struct {
+		name   string
+		x, y   uint
+		mutual bool
+	}{
+		{"重复记录", a.ID, b.ID, true},
+		{"反向查询", b.ID, a.ID, true},
+		{"单向好友", a.ID, c.ID, false},
+		{"已屏蔽", a.ID, d.ID, false},
+		{"自己", a.ID, a.ID, false},
+	}
+	for _, tt := range tests {
+		if got := IsMutualFriend(db, tt.x, tt.y); got != tt.mutual {
+			t.Errorf("%s: IsMutualFriend(%d, %d) = %v，应为 %v", tt.name, tt.x, tt.y, got, tt.mutual)
+		}
+	}
+
+	ids, err := GetMutualFriendIDs(db, a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != b.ID {
+		t.Errorf("GetMutualFriendIDs = %v，应为 [%d]", ids, b.ID)
+	}
+}
+
+func TestCanViewMoment(t *testing.T) {
+	db := newTestDB(t)
+	author := createTestUser(t, db, "author")
+	friend := createTestUser(t, db, "friend")
+	other := createTestUser(t, db, "other")
+	stranger := createTestUser(t, db, "stranger")
+	makeFriends(t, db, author.ID, friend.ID)
+	makeFriends(t, db, author.ID, other.ID)
+	// 重复记录的好友仍然可以看到仅好友可见的动态
+	db.Create(&models.Friend{UserID: friend.ID, FriendID: author.ID})
+
+	tests := []struct {
+		name       string
+		visibility string
+		listed     string
+		viewer     uint
+		visible    bool
+	}{
+		{"作者本人", models.MomentVisibilityPrivate, "", author.ID, true},
+		{"私密", models.MomentVisibilityPrivate, "", friend.ID, false},
+		{"好友可见", models.MomentVisibilityFriends, "", friend.ID, true},
+		{"陌生人", models.MomentVisibilityFriends, "", stranger.ID, false},
+		{"部分可见-在列表中", models.MomentVisibilityInclude, JoinUserIDList([]uint{friend.ID}), friend.ID, true},
+		{"部分可见-不在列表中", models.MomentVisibilityInclude, JoinUserIDList([]uint{friend.ID}), other.ID, false},
+		{"不给谁看-在列表中", models.MomentVisibilityExclude, JoinUserIDList([]uint{friend.ID}), friend.ID, false},
+		{"不给谁看-不在列表中", models.MomentVisibilityExclude, JoinUserIDList([]uint{friend.ID}), other.ID, true},
+	}
+	for _, tt := range tests {
+		moment := &models.Moment{UserID: author.ID, Visibility: tt.visibility, VisibleUsers: tt.listed}
+		if got := CanViewMoment(db, moment, tt.viewer); got != tt.visible {
+			t.Errorf("%s: CanViewMoment = %v，应为 %v", tt.name, got, tt.visible)
+		}
+	}
+}
+
+func TestMomentTimelinePaging(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	makeFriends(t, db, alice.ID, bob.ID)
+
+	// 同一秒内发布的多条动态
+	var want []uint
+	for _, createdAt := range []int64{1000, 1000, 1000, 999, 999} {
+		moment := models.Moment{UserID: bob.ID, Content: "moment", Visibility: models.MomentVisibilityFriends, CreatedAt: createdAt}
+		db.Create(&moment)
+		want = append(want, moment.ID)
+	}
+	// 按时间倒序，同一秒内按ID倒序
+	want = []uint{want[2], want[1], want[0], want[4], want[3]}
+
+	var got []uint
+	var cursor MomentCursor
+	for page := 0; page < 5; page++ {
+		moments, err := GetMomentTimeline(db, alice.ID, cursor, 2)
+		if err != nil {
+			t.Fatalf("获取时间线失败: %v", err)
+		}
+		if len(moments) == 0 {
+			break
+		}
+		for _, moment := range moments {
+			got = append(got, moment.ID)
+		}
+		last := moments[len(moments)-1]
+		cursor = MomentCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("翻页结果为 %v，应为 %v", got, want)
+	}
+
+	moments, _ := GetUserMoments(db, alice.ID, bob.ID, MomentCursor{CreatedAt: 1000}, 20)
+	if len(moments) != 2 {
+		t.Errorf("只按时间翻页时应返回更早的2条，得到 %d 条", len(moments))
+	}
+}
+
+func TestClampMomentLimit(t *testing.T) {
+	tests := []struct{ limit, want int }{
+		{0, 20},
+		{-1, 20},
+		{10, 10},
+		{50, 50},
+		{100, 50},
+	}
+	for _, tt := range tests {
+		if got := ClampMomentLimit(tt.limit); got != tt.want {
+			t.Errorf("ClampMomentLimit(%d) = %d，应为 %d", tt.limit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 登录密码：校验时限制错误次数，同一账号或同一IP在一段时间内错误过多会被锁定；
+// 旧格式的密码哈希（bcrypt、明文）登录成功后转换为当前算法
+// 忘记密码时通过绑定的邮箱或手机号接收验证码重置，重置后所有设备下线
+
+const (
+	loginFailureWindow      = 15 * time.Minute
+	loginLockDuration       = 15 * time.Minute
+	maxAccountLoginFailures = 5
+	maxIPLoginFailures      = 20
+
+	passwordResetCodeLength     = 6
+	passwordResetResendInterval = time.Minute
+)
+
+// ErrInvalidCredentials 账号不存在或密码错误，两种情况返回相同的错误
+var ErrInvalidCredentials = &utils.AppError{Code: http.StatusBadRequest, Message: "账号或密码错误", Key: "auth.invalid_credentials"}
+
+var (
+	dummyPasswordOnce sync.Once
+	dummyPasswordHash string
+)
+
+// AuthenticatePassword 校验登录密码，user 为按登录名查到的用户，不存在时传 nil
+// 账号或IP已被锁定时返回429；密码正确且哈希需要升级时重新计算并保存
+func AuthenticatePassword(db *gorm.DB, user *models.User, identifier, password, ipAddress string) error {
+	identifier = strings.ToLower(strings.TrimSpace(identifier))
+	var userID uint
+	if user != nil {
+		userID = user.ID
+	}
+
+	if lockedUntil := loginLockedUntil(db, userID, identifier, ipAddress); lockedUntil > 0 {
+		minutes := (lockedUntil - time.Now().Unix() + 59) / 60
+		return &utils.AppError{Code: http.StatusTooManyRequests, Message: fmt.Sprintf("登录失败次数过多，请%d分钟后再试", minutes), Key: "auth.too_many_failures", Args: []interface{}{minutes}}
+	}
+
+	ok, needsRehash := false, false
+	if user != nil {
+		ok, needsRehash = utils.VerifyPassword(user.Password, password)
+	} else {
+		// 账号不存在时同样计算一次哈希，避免通过响应时间判断账号是否存在
+		dummyPasswordOnce.Do(func() {
+			dummyPasswordHash, _ = utils.HashPassword("dummy-password")
+		})
+		utils.VerifyPassword(dummyPasswordHash, password)
+	}
+
+	db.Create(&models.LoginAttempt{
+		UserID:     userID,
+		Identifier: identifier,
+		IPAddress:  ipAddress,
+		Success:    ok,
+		CreatedAt:  time.Now().Unix(),
+	})
+	if !ok {
+		return ErrInvalidCredentials
+	}
+
+	if needsRehash {
+		if hash, err := utils.HashPassword(password); err == nil {
+			if err := db.Model(&models.User{}).Where("id = ?", user.ID).Update("password", hash).Error; err != nil {
+				utils.Logger.Errorf("升级密码哈希失败: 用户ID=%d, %v", user.ID, err)
+			} else {
+				user.Password = hash
+			}
+		}
+	}
+	return nil
+}
+
+// loginLockedUntil 账号或IP被锁定时返回解锁时间，未锁定返回0
+// 账号按上次登录成功之后的错误次数计算，IP只按时间窗口计算
+func loginLockedUntil(db *gorm.DB, userID uint, identifier, ipAddress string) int64 {
+	now := time.Now()
+	since := now.Add(-loginFailureWindow).Unix()
+
+	account := db.Model(&models.LoginAttempt{})
+	if userID > 0 {
+		account = account.Where("user_id = ?", userID)
+	} else {
+		account = account.Where("user_id = 0 AND identifier = ?", identifier)
+	}
+	account = account.Session(&gorm.Session{})
+	var lastSuccess int64
+	account.Where("success = ?", true).Select("COALESCE(MAX(created_at), 0)").Scan(&lastSuccess)
+	if lastSuccess > since {
+		since = lastSuccess
+	}
+	if until := lockedUntilAfter(account.Where("success = ? AND created_at >= ?", false, since), maxAccountLoginFailures); until > now.Unix() {
+		return until
+	}
+
+	if ipAddress != "" {
+		ip := db.Model(&models.LoginAttempt{}).Where("ip_address = ? AND success = ? AND created_at >= ?", ipAddress, false, now.Add(-loginFailureWindow).Unix())
+		if until := lockedUntilAfter(ip, maxIPLoginFailures); until > now.Unix() {
+			return until
+		}
+	}
+	return 0
+}
+
+// lockedUntilAfter 失败次数达到上限时，从最后一次失败开始锁定
+func lockedUntilAfter(failures *gorm.DB, limit int64) int64 {
+	var result struct {
+		Count int64
+		Last  int64
+	}
+	failures.Select("COUNT(*) AS count, COALESCE(MAX(created_at), 0) AS last").Scan(&result)
+	if result.Count < limit {
+		return 0
+	}
+	return result.Last + int64(loginLockDuration/time.Second)
+}
+
+// CleanupLoginAttempts 删除超过一天的登录尝试记录
+func CleanupLoginAttempts(db *gorm.DB) {
+	result := db.Where("created_at < ?", time.Now().Add(-24*time.Hour).Unix()).Delete(&models.LoginAttempt{})
+	if result.Error != nil {
+		utils.Logger.Errorf("清理登录尝试记录失败: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		utils.Logger.Infof("清理登录尝试记录 %d 条", result.RowsAffected)
+	}
+}
+
+// SendPasswordResetCode 向绑定的邮箱或手机号发送重置密码的验证码，acceptLanguage 用于用户没有语言设置时选择语言
+// 未绑定任何账号时不发送，但返回相同的结果，避免泄露邮箱或手机号是否已注册
+func SendPasswordResetCode(db *gorm.DB, channel, target, acceptLanguage string) error {
+	user, err := findUserForReset(db, channel, target)
+	if err != nil {
+		return err
+	}
+	key := passwordResetCodeKey(channel, target)
+	if utils.VerificationCodeSentWithin(utils.CodePurposeResetPassword, key, passwordResetResendInterval) {
+		return &utils.AppError{Code: http.StatusTooManyRequests, Message: "验证码发送过于频繁，请稍后再试", Key: "verification.too_frequent"}
+	}
+
+	// 未绑定账号时也保存验证码，使重复发送的限制一致；ResetPassword 不会接受这样的验证码
+	code, err := utils.GenerateRandomCode(passwordResetCodeLength)
+	if err != nil {
+		return err
+	}
+	if err := utils.SaveVerificationCode(utils.CodePurposeResetPassword, key, code); err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+
+	// 按用户的语言设置发送，发送给同一收件人过于频繁或发送失败时结果与正常发送相同
+	lang := RequestLocalizer(db, user.ID, acceptLanguage).Lang
+	data := map[string]interface{}{"Code": code, "Minutes": 10, "Account": user.Account}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if channel == "email" {
+		err = utils.SendTemplateEmail(ctx, target, "password_reset", lang, data)
+	} else {
+		err = utils.SendTemplateSMS(ctx, target, "password_reset", lang, data)
+	}
+	if err != nil {
+		// 发送失败时作废验证码，不影响重新获取
+		utils.Logger.Errorf("发送重置密码验证码失败: %s %s, %v", channel, target, err)
+		if err := utils.DeleteVerificationCode(utils.CodePurposeResetPassword, key, code); err != nil {
+			utils.Logger.Errorf("作废未发送的验证码失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// ResetPassword 校验验证码并设置新密码，所有设备下线，并清除账号的登录锁定
+func ResetPassword(db *gorm.DB, channel, target, code, newPassword string) error {
+	user, err := findUserForReset(db, channel, target)
+	if err != nil {
+		return err
+	}
+	invalidCode := &utils.AppError{Code: http.StatusBadRequest, Message: "验证码错误或已过期", Key: "verification.code_invalid_or_expired"}
+	if user == nil {
+		return invalidCode
+	}
+	// 先检查密码强度，避免密码不合格时验证码被作废
+	if err := utils.ValidatePasswordStrength(newPassword, user.Account); err != nil {
+		return err
+	}
+	if !utils.ConsumeVerificationCode(utils.CodePurposeResetPassword, passwordResetCodeKey(channel, target), code) {
+		return invalidCode
+	}
+
+	hash, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Update("password", hash).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND success = ?", user.ID, false).Delete(&models.LoginAttempt{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := RevokeOtherSessions(db, user.ID, ""); err != nil {
+		utils.Logger.Errorf("重置密码后下线设备失败: 用户ID=%d, %v", user.ID, err)
+	}
+	return nil
+}
+
+// findUserForReset 按邮箱或手机号查找用户，格式错误返回400，未绑定账号返回 nil
+func findUserForReset(db *gorm.DB, channel, target string) (*models.User, error) {
+	var column string
+	switch channel {
+	case "email":
+		if !utils.ValidateEmail(target) {
+			return nil, &utils.AppError{Code: http.StatusBadRequest, Message: "邮箱格式不正确", Key: "verification.invalid_email"}
+		}
+		column = "email"
+	case "phone":
+		if !utils.ValidatePhone(target) {
+			return nil, &utils.AppError{Code: http.StatusBadRequest, Message: "手机号格式不正确", Key: "verification.invalid_phone"}
+		}
+		column = "phone"
+	default:
+		return nil, &utils.AppError{Code: http.StatusBadRequest, Message: "验证方式错误，必须是email或phone", Key: "password.invalid_channel"}
+	}
+
+	var user models.User
+	if err := db.Where(column+" = ?", target).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func passwordResetCodeKey(channel, target string) string {
+	return channel + ":" + target
+}
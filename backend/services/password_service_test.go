@@ -0,0 +1,205 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// useTestPasswordConfig 使用较小的 argon2id 参数，测试结束后恢复原配置
+func useTestPasswordConfig(t *testing.T) {
+	t.Helper()
+	previous := utils.GetPasswordConfig()
+	utils.SetPasswordConfig(utils.PasswordConfig{Algorithm: utils.PasswordArgon2id, ArgonMemory: 8 * 1024, ArgonTime: 1, ArgonThreads: 1})
+	t.Cleanup(func() { utils.SetPasswordConfig(previous) })
+}
+
+// useTestCodeStore 使用独立的内存验证码存储，测试结束后恢复原配置
+func useTestCodeStore(t *testing.T) *utils.MemoryCodeStore {
+	t.Helper()
+	previous := utils.GetCodeStoreConfig()
+	store := utils.NewMemoryCodeStore()
+	utils.SetCodeStore(store)
+	t.Cleanup(func() { utils.SetCodeStoreConfig(previous, utils.DB) })
+	return store
+}
+
+// setTestPassword 直接保存用户的密码字段
+func setTestPassword(t *testing.T, db *gorm.DB, user *models.User, stored string) {
+	t.Helper()
+	if err := db.Model(user).Update("password", stored).Error; err != nil {
+		t.Fatalf("保存密码失败: %v", err)
+	}
+}
+
+// backdateLoginAttempts 把已有的登录尝试记录提前，模拟之前发生的登录
+func backdateLoginAttempts(db *gorm.DB, d time.Duration) {
+	db.Model(&models.LoginAttempt{}).Where("1 = 1").Update("created_at", gorm.Expr("created_at - ?", int64(d/time.Second)))
+}
+
+func TestAuthenticatePasswordMigratesLegacyHash(t *testing.T) {
+	db := newTestDB(t)
+	useTestPasswordConfig(t)
+	bcryptHash, _ := bcrypt.GenerateFromPassword([]byte("Secret#123"), bcrypt.MinCost)
+
+	tests := []struct {
+		name   string
+		stored string
+	}{
+		{"明文", "Secret#123"},
+		{"bcrypt", string(bcryptHash)},
+	}
+	for i, tt := range tests {
+		user := createTestUser(t, db, "legacy"+string(rune('a'+i)))
+		setTestPassword(t, db, user, tt.stored)
+
+		// 密码错误时不转换
+		if err := AuthenticatePassword(db, user, user.Account, "wrong", ""); err != ErrInvalidCredentials {
+			t.Errorf("%s: 错误密码应返回账号或密码错误，得到 %v", tt.name, err)
+		}
+		var reloaded models.User
+		db.First(&reloaded, user.ID)
+		if reloaded.Password != tt.stored {
+			t.Errorf("%s: 密码错误时不应修改保存的密码", tt.name)
+		}
+
+		if err := AuthenticatePassword(db, user, user.Account, "Secret#123", ""); err != nil {
+			t.Fatalf("%s: 正确密码应通过: %v", tt.name, err)
+		}
+		db.First(&reloaded, user.ID)
+		if !strings.HasPrefix(reloaded.Password, "$argon2id$") || reloaded.Password != user.Password {
+			t.Errorf("%s: 登录后应转换为 argon2id，得到 %s", tt.name, reloaded.Password)
+		}
+		if ok, needsRehash := utils.VerifyPassword(reloaded.Password, "Secret#123"); !ok || needsRehash {
+			t.Errorf("%s: 转换后的哈希应有效且不需要再转换", tt.name)
+		}
+		// 转换后仍可用原密码登录
+		if err := AuthenticatePassword(db, user, user.Account, "Secret#123", ""); err != nil {
+			t.Errorf("%s: 转换后登录失败: %v", tt.name, err)
+		}
+	}
+}
+
+func TestAuthenticatePasswordLockout(t *testing.T) {
+	db := newTestDB(t)
+	useTestPasswordConfig(t)
+	alice := createTestUser(t, db, "alice")
+	hash, _ := utils.HashPassword("Secret#123")
+	setTestPassword(t, db, alice, hash)
+	alice.Password = hash
+
+	// 达到上限前错误返回账号或密码错误，之后正确密码也被拒绝
+	for i := 0; i < maxAccountLoginFailures; i++ {
+		if err := AuthenticatePassword(db, alice, "alice", "wrong", "10.0.0.1"); err != ErrInvalidCredentials {
+			t.Fatalf("第%d次错误: %v", i+1, err)
+		}
+	}
+	if err := AuthenticatePassword(db, alice, "alice", "Secret#123", "10.0.0.2"); appErrorKey(err) != "auth.too_many_failures" {
+		t.Fatalf("账号锁定后应拒绝登录，得到 %v", err)
+	}
+
+	// 超过时间窗口后解锁；登录成功后重新计数
+	backdateLoginAttempts(db, loginFailureWindow+time.Minute)
+	if err := AuthenticatePassword(db, alice, "alice", "Secret#123", "10.0.0.2"); err != nil {
+		t.Fatalf("锁定结束后应可以登录: %v", err)
+	}
+	backdateLoginAttempts(db, time.Second)
+	for i := 0; i < maxAccountLoginFailures-1; i++ {
+		AuthenticatePassword(db, alice, "alice", "wrong", "10.0.0.3")
+	}
+	if err := AuthenticatePassword(db, alice, "alice", "Secret#123", "10.0.0.3"); err != nil {
+		t.Errorf("登录成功后错误次数应重新计算: %v", err)
+	}
+
+	// 不存在的账号按登录名计数，返回与密码错误相同的结果
+	for i := 0; i < maxAccountLoginFailures; i++ {
+		if err := AuthenticatePassword(db, nil, " Nobody ", "wrong", "10.0.0.4"); err != ErrInvalidCredentials {
+			t.Fatalf("不存在的账号第%d次: %v", i+1, err)
+		}
+	}
+	if err := AuthenticatePassword(db, nil, "nobody", "wrong", "10.0.0.5"); appErrorKey(err) != "auth.too_many_failures" {
+		t.Errorf("不存在的账号也应锁定，得到 %v", err)
+	}
+
+	// 同一IP对不同账号的错误次数过多时锁定该IP
+	db.Where("1 = 1").Delete(&models.LoginAttempt{})
+	for i := 0; i < maxIPLoginFailures; i++ {
+		AuthenticatePassword(db, nil, "user"+string(rune('a'+i)), "wrong", "10.0.0.9")
+	}
+	tests := []struct {
+		name string
+		ip   string
+		key  string
+	}{
+		{"被锁定的IP", "10.0.0.9", "auth.too_many_failures"},
+		{"其他IP", "10.0.0.10", ""},
+	}
+	for _, tt := range tests {
+		if err := AuthenticatePassword(db, alice, "alice", "Secret#123", tt.ip); appErrorKey(err) != tt.key {
+			t.Errorf("%s: 得到 %v，应为 %q", tt.name, err, tt.key)
+		}
+	}
+}
+
+func TestResetPasswordCode(t *testing.T) {
+	db := newTestDB(t)
+	useTestJWTConfig(t)
+	useTestPasswordConfig(t)
+	store := useTestCodeStore(t)
+	alice := createTestUser(t, db, "alice")
+	db.Model(alice).Update("email", "alice@example.com")
+	session, _ := CreateSession(db, alice.ID, alice.Account, DeviceInfo{DeviceType: "ios"})
+	key := passwordResetCodeKey("email", "alice@example.com")
+	storeKey := string(utils.CodePurposeResetPassword) + ":" + key
+
+	// 过期的验证码
+	store.Save(context.Background(), storeKey, utils.CodeEntry{Code: "111111", SentAt: time.Now().Add(-11 * time.Minute), ExpiresAt: time.Now().Add(-time.Minute)})
+	if err := ResetPassword(db, "email", "alice@example.com", "111111", "Blue-Sky42"); appErrorKey(err) != "verification.code_invalid_or_expired" {
+		t.Errorf("过期的验证码应被拒绝，得到 %v", err)
+	}
+
+	utils.SaveVerificationCode(utils.CodePurposeResetPassword, key, "222222")
+	// 其他用途的验证码不能用于重置密码
+	utils.SaveVerificationCode(utils.CodePurposeRegister, key, "333333")
+	tests := []struct {
+		name     string
+		channel  string
+		target   string
+		code     string
+		password string
+		key      string
+	}{
+		{"错误的验证码", "email", "alice@example.com", "000000", "Blue-Sky42", "verification.code_invalid_or_expired"},
+		{"其他用途的验证码", "email", "alice@example.com", "333333", "Blue-Sky42", "verification.code_invalid_or_expired"},
+		{"未绑定的邮箱", "email", "bob@example.com", "222222", "Blue-Sky42", "verification.code_invalid_or_expired"},
+		{"错误的验证方式", "wechat", "alice@example.com", "222222", "Blue-Sky42", "password.invalid_channel"},
+		{"密码强度不够", "email", "alice@example.com", "222222", "short", "password.too_short"},
+	}
+	for _, tt := range tests {
+		if err := ResetPassword(db, tt.channel, tt.target, tt.code, tt.password); appErrorKey(err) != tt.key {
+			t.Errorf("%s: 得到 %v，应为 %s", tt.name, err, tt.key)
+		}
+	}
+
+	// 密码强度不够时验证码仍然有效；重置后验证码作废，其他设备下线
+	if err := ResetPassword(db, "email", "alice@example.com", "222222", "Blue-Sky42"); err != nil {
+		t.Fatalf("重置密码失败: %v", err)
+	}
+	if err := ResetPassword(db, "email", "alice@example.com", "222222", "Blue-Sky43"); appErrorKey(err) != "verification.code_invalid_or_expired" {
+		t.Errorf("验证码不能重复使用，得到 %v", err)
+	}
+	var reloaded models.User
+	db.First(&reloaded, alice.ID)
+	if ok, _ := utils.VerifyPassword(reloaded.Password, "Blue-Sky42"); !ok {
+		t.Error("应可以使用新密码")
+	}
+	if !IsTokenRevoked(accessClaims(t, session.AccessToken)) {
+		t.Error("重置密码后原会话应失效")
+	}
+}
@@ -0,0 +1,437 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 登录会话：每次登录在设备上创建一个会话，签发短期访问令牌和刷新令牌
+// 刷新令牌每次使用后轮换，已轮换的刷新令牌再次出现时视为泄露，吊销整个会话
+// 退出登录、远程下线时把会话（sid）或单个访问令牌（jti）加入吊销列表，吊销列表缓存在内存中供认证中间件检查，定时从数据库同步
+
+const (
+	RefreshTokenActive  = "active"
+	RefreshTokenRotated = "rotated"
+	RefreshTokenRevoked = "revoked"
+
+	RevokedTypeAccess  = "access"
+	RevokedTypeSession = "session"
+)
+
+// DeviceInfo 登录设备信息，客户端首次登录时可不传 device_id，由服务端生成后返回
+// TrustToken 为记住设备时返回的信任令牌，在受信任的设备上登录时携带，可免两步验证
+type DeviceInfo struct {
+	DeviceID    string `json:"device_id"`
+	TrustToken  string `json:"trust_token"`
+	DeviceType  string `json:"device_type"` // ios, android, windows, macos, linux, web
+	DeviceName  string `json:"device_name"`
+	DeviceModel string `json:"device_model"`
+	OSVersion   string `json:"os_version"`
+	AppVersion  string `json:"app_version"`
+	IPAddress   string `json:"-"`
+	UserAgent   string `json:"-"`
+}
+
+// TokenPair 登录或刷新后返回给客户端的令牌
+type TokenPair struct {
+	AccessToken      string `json:"token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int64  `json:"expires_in"`         // 访问令牌有效期（秒）
+	RefreshExpiresIn int64  `json:"refresh_expires_in"` // 刷新令牌有效期（秒）
+	SessionID        string `json:"session_id"`
+	DeviceID         string `json:"device_id"`
+	TrustToken       string `json:"trust_token,omitempty"` // 通过两步验证并选择记住设备时返回，客户端保存后在该设备登录时携带
+}
+
+var (
+	revocationsMu sync.RWMutex
+	revocations   = map[string]int64{} // token_type:token_id -> 过期时间
+)
+
+// CreateSession 用户登录成功后在设备上创建会话，设备上原有的会话失效
+// 设备按用户区分，不同用户使用相同的设备标识互不影响；设备的信任状态不变，只在两步验证时记住设备才受信任
+func CreateSession(db *gorm.DB, userID uint, account string, info DeviceInfo) (*TokenPair, error) {
+	now := time.Now().Unix()
+	sessionID := uuid.NewString()
+	if info.DeviceID == "" {
+		info.DeviceID = uuid.NewString()
+	}
+
+	var pair *TokenPair
+	var previous models.UserDevice
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var device models.UserDevice
+		err := tx.Where("user_id = ? AND device_id = ?", userID, info.DeviceID).First(&device).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		previous = device
+		if err == nil && device.SessionID != "" {
+			if err := tx.Model(&models.RefreshToken{}).Where("session_id = ? AND status = ?", device.SessionID, RefreshTokenActive).
+				Update("status", RefreshTokenRevoked).Error; err != nil {
+				return err
+			}
+		}
+
+		device.UserID = userID
+		device.DeviceID = info.DeviceID
+		device.DeviceType = info.DeviceType
+		device.DeviceName = info.DeviceName
+		device.DeviceModel = info.DeviceModel
+		device.OSVersion = info.OSVersion
+		device.AppVersion = info.AppVersion
+		device.IPAddress = info.IPAddress
+		device.UserAgent = info.UserAgent
+		device.LastLoginAt = now
+		device.LastActiveAt = now
+		device.IsActive = true
+		device.SessionID = sessionID
+		if device.CreatedAt == 0 {
+			device.CreatedAt = now
+		}
+		if err := tx.Save(&device).Error; err != nil {
+			return err
+		}
+
+		pair, err = issueSessionTokens(tx, userID, account, &device, sessionID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 同一设备重新登录，原会话的访问令牌立即失效
+	if previous.SessionID != "" {
+		revokeToken(db, RevokedTypeSession, previous.SessionID, previous.UserID, "relogin")
+	}
+	return pair, nil
+}
+
+// RefreshSession 使用刷新令牌换取新的访问令牌和刷新令牌
+func RefreshSession(db *gorm.DB, refreshToken, ipAddress string) (*TokenPair, error) {
+	if refreshToken == "" {
+		return nil, &utils.AppError{Code: http.StatusUnauthorized, Message: "刷新令牌无效", Key: "auth.refresh_token_invalid"}
+	}
+	now := time.Now().Unix()
+
+	var pair *TokenPair
+	var reused *models.RefreshToken
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var token models.RefreshToken
+		if err := tx.Where("token_hash = ?", hashToken(refreshToken)).First(&token).Error; err != nil {
+			return &utils.AppError{Code: http.StatusUnauthorized, Message: "刷新令牌无效", Key: "auth.refresh_token_invalid"}
+		}
+		switch {
+		case token.Status == RefreshTokenRotated:
+			reused = &token
+			return nil
+		case token.Status != RefreshTokenActive:
+			return &utils.AppError{Code: http.StatusUnauthorized, Message: "登录已失效，请重新登录", Key: "auth.token_revoked"}
+		case token.ExpiresAt <= now:
+			return &utils.AppError{Code: http.StatusUnauthorized, Message: "登录已过期，请重新登录", Key: "auth.session_expired"}
+		}
+
+		// 条件更新保证同一个刷新令牌只能成功使用一次
+		result := tx.Model(&models.RefreshToken{}).Where("id = ? AND status = ?", token.ID, RefreshTokenActive).
+			Updates(map[string]interface{}{"status": RefreshTokenRotated, "used_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			reused = &token
+			return nil
+		}
+
+		var device models.UserDevice
+		if err := tx.First(&device, token.DeviceID).Error; err != nil || !device.IsActive || device.SessionID != token.SessionID {
+			return &utils.AppError{Code: http.StatusUnauthorized, Message: "登录已失效，请重新登录", Key: "auth.token_revoked"}
+		}
+		var user models.User
+		if err := tx.Select("id, account").First(&user, token.UserID).Error; err != nil {
+			return &utils.AppError{Code: http.StatusUnauthorized, Message: "用户不存在", Key: "user.not_found"}
+		}
+
+		updates := map[string]interface{}{"last_active_at": now}
+		if ipAddress != "" {
+			updates["ip_address"] = ipAddress
+		}
+		if err := tx.Model(&device).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		var err error
+		pair, err = issueSessionTokens(tx, user.ID, user.Account, &device, token.SessionID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if reused != nil {
+		utils.Logger.Errorf("刷新令牌被重复使用，吊销会话: user_id=%d, session_id=%s", reused.UserID, reused.SessionID)
+		var device models.UserDevice
+		if db.Where("id = ? AND session_id = ?", reused.DeviceID, reused.SessionID).First(&device).Error == nil {
+			revokeDeviceSession(db, &device, "refresh_token_reused")
+		} else {
+			revokeToken(db, RevokedTypeSession, reused.SessionID, reused.UserID, "refresh_token_reused")
+		}
+		return nil, &utils.AppError{Code: http.StatusUnauthorized, Message: "刷新令牌已被使用，为保证账号安全已退出该设备的登录", Key: "auth.refresh_token_reused"}
+	}
+	return pair, nil
+}
+
+// issueSessionTokens 为会话签发访问令牌和新的刷新令牌
+func issueSessionTokens(tx *gorm.DB, userID uint, account string, device *models.UserDevice, sessionID string) (*TokenPair, error) {
+	config := utils.GetJWTConfig()
+	accessToken, _, err := utils.GenerateAccessToken(userID, account, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	if err := tx.Create(&models.RefreshToken{
+		UserID:    userID,
+		DeviceID:  device.ID,
+		SessionID: sessionID,
+		TokenHash: hashToken(refreshToken),
+		Status:    RefreshTokenActive,
+		ExpiresAt: now + int64(config.RefreshTTL/time.Second),
+		CreatedAt: now,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		ExpiresIn:        int64(config.AccessTTL / time.Second),
+		RefreshExpiresIn: int64(config.RefreshTTL / time.Second),
+		SessionID:        sessionID,
+		DeviceID:         device.DeviceID,
+	}, nil
+}
+
+// TrustDevice 将用户的设备标记为受信任，返回新的信任令牌，原有的信任令牌失效
+func TrustDevice(db *gorm.DB, userID uint, deviceID string) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	result := db.Model(&models.UserDevice{}).Where("user_id = ? AND device_id = ?", userID, deviceID).
+		Updates(map[string]interface{}{"trusted": true, "trust_token_hash": hashToken(token)})
+	if result.Error != nil {
+		return "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return "", &utils.AppError{Code: http.StatusNotFound, Message: "设备不存在或已退出登录", Key: "auth.device_not_found"}
+	}
+	return token, nil
+}
+
+// IsTrustedDevice 设备是否为用户受信任的设备，需要携带记住设备时签发的信任令牌
+func IsTrustedDevice(db *gorm.DB, userID uint, deviceID, trustToken string) bool {
+	if deviceID == "" || trustToken == "" {
+		return false
+	}
+	var count int64
+	db.Model(&models.UserDevice{}).
+		Where("user_id = ? AND device_id = ? AND trusted = ? AND trust_token_hash = ?", userID, deviceID, true, hashToken(trustToken)).
+		Count(&count)
+	return count > 0
+}
+
+// randomToken 生成256位随机令牌
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken 令牌只保存SHA-256哈希
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ListSessions 用户已登录的设备
+func ListSessions(db *gorm.DB, userID uint) ([]models.UserDevice, error) {
+	devices := []models.UserDevice{}
+	err := db.Where("user_id = ? AND is_active = ? AND session_id <> ''", userID, true).
+		Order("last_active_at DESC").Find(&devices).Error
+	return devices, err
+}
+
+// RevokeSession 远程下线用户的某台设备
+func RevokeSession(db *gorm.DB, userID, deviceID uint) error {
+	var device models.UserDevice
+	if err := db.Where("id = ? AND user_id = ? AND is_active = ? AND session_id <> ''", deviceID, userID, true).First(&device).Error; err != nil {
+		return &utils.AppError{Code: http.StatusNotFound, Message: "设备不存在或已退出登录", Key: "auth.device_not_found"}
+	}
+	return revokeDeviceSession(db, &device, "remote_logout")
+}
+
+// RevokeOtherSessions 下线当前会话以外的所有设备，返回下线的设备数
+func RevokeOtherSessions(db *gorm.DB, userID uint, currentSessionID string) (int, error) {
+	devices, err := ListSessions(db, userID)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for i := range devices {
+		if devices[i].SessionID == currentSessionID {
+			continue
+		}
+		if err := revokeDeviceSession(db, &devices[i], "remote_logout"); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Logout 退出当前登录，会话中签发的所有令牌失效；不属于任何会话的旧令牌只吊销该令牌本身
+func Logout(db *gorm.DB, claims *utils.Claims) error {
+	if claims.SessionID != "" {
+		var device models.UserDevice
+		if err := db.Where("user_id = ? AND session_id = ?", claims.UserID, claims.SessionID).First(&device).Error; err == nil {
+			return revokeDeviceSession(db, &device, "logout")
+		}
+		return revokeToken(db, RevokedTypeSession, claims.SessionID, claims.UserID, "logout")
+	}
+	if claims.ID != "" {
+		return revokeToken(db, RevokedTypeAccess, claims.ID, claims.UserID, "logout")
+	}
+	return nil
+}
+
+// revokeDeviceSession 吊销设备当前的会话，并通知该设备下线
+// 用户主动退出登录时设备仍受信任；远程下线或令牌泄露时取消信任，再次登录需要两步验证
+func revokeDeviceSession(db *gorm.DB, device *models.UserDevice, reason string) error {
+	sessionID := device.SessionID
+	updates := map[string]interface{}{"is_active": false, "session_id": ""}
+	if reason != "logout" {
+		updates["trusted"] = false
+		updates["trust_token_hash"] = ""
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.RefreshToken{}).Where("session_id = ? AND status = ?", sessionID, RefreshTokenActive).
+			Update("status", RefreshTokenRevoked).Error; err != nil {
+			return err
+		}
+		return tx.Model(device).Updates(updates).Error
+	})
+	if err != nil {
+		return err
+	}
+	if err := revokeToken(db, RevokedTypeSession, sessionID, device.UserID, reason); err != nil {
+		return err
+	}
+
+	utils.PushMessageToUser(device.UserID, map[string]interface{}{
+		"type": "session_revoked",
+		"data": map[string]interface{}{
+			"device_id":  device.DeviceID,
+			"session_id": sessionID,
+			"reason":     reason,
+		},
+	})
+	return nil
+}
+
+// revokeToken 将会话或访问令牌加入吊销列表，保留到此前签发的访问令牌全部过期
+func revokeToken(db *gorm.DB, tokenType, tokenID string, userID uint, reason string) error {
+	now := time.Now().Unix()
+	expiresAt := now + int64(utils.GetJWTConfig().AccessTTL/time.Second)
+
+	revocationsMu.Lock()
+	revocations[tokenType+":"+tokenID] = expiresAt
+	revocationsMu.Unlock()
+
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token_type"}, {Name: "token_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"reason", "expires_at"}),
+	}).Create(&models.RevokedToken{
+		TokenType: tokenType,
+		TokenID:   tokenID,
+		UserID:    userID,
+		Reason:    reason,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}).Error
+}
+
+// IsTokenRevoked 访问令牌或其所属会话是否已被吊销
+func IsTokenRevoked(claims *utils.Claims) bool {
+	now := time.Now().Unix()
+	revocationsMu.RLock()
+	defer revocationsMu.RUnlock()
+	if claims.ID != "" {
+		if expiresAt, ok := revocations[RevokedTypeAccess+":"+claims.ID]; ok && expiresAt > now {
+			return true
+		}
+	}
+	if claims.SessionID != "" {
+		if expiresAt, ok := revocations[RevokedTypeSession+":"+claims.SessionID]; ok && expiresAt > now {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadTokenRevocations 从数据库加载吊销列表，同时清理已过期的记录
+// 启动时调用，并定时执行以同步其他实例吊销的令牌
+func LoadTokenRevocations(db *gorm.DB) error {
+	now := time.Now().Unix()
+	db.Where("expires_at <= ?", now).Delete(&models.RevokedToken{})
+
+	var items []models.RevokedToken
+	if err := db.Where("expires_at > ?", now).Find(&items).Error; err != nil {
+		return err
+	}
+	loaded := make(map[string]int64, len(items))
+	for _, item := range items {
+		loaded[item.TokenType+":"+item.TokenID] = item.ExpiresAt
+	}
+
+	revocationsMu.Lock()
+	// 保留本实例刚吊销但尚未查询到的记录
+	for key, expiresAt := range revocations {
+		if _, ok := loaded[key]; !ok && expiresAt > now {
+			loaded[key] = expiresAt
+		}
+	}
+	revocations = loaded
+	revocationsMu.Unlock()
+	return nil
+}
+
+// CleanupExpiredRefreshTokens 删除过期的刷新令牌记录
+// 已轮换的令牌保留到过期，以便发现重复使用
+func CleanupExpiredRefreshTokens(db *gorm.DB) {
+	result := db.Where("expires_at <= ?", time.Now().Unix()).Delete(&models.RefreshToken{})
+	if result.Error != nil {
+		utils.Logger.Errorf("清理过期刷新令牌失败: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		utils.Logger.Infof("清理过期刷新令牌 %d 个", result.RowsAffected)
+	}
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"testing"
+)
+
+// useTestJWTConfig 使用测试签名密钥，测试结束后恢复原配置
+func useTestJWTConfig(t *testing.T) {
+	t.Helper()
+	previous := utils.GetJWTConfig()
+	utils.SetJWTConfig(utils.JWTConfig{Keys: map[string][]byte{"test": []byte("test-secret")}, ActiveKeyID: "test"})
+	t.Cleanup(func() { utils.SetJWTConfig(previous) })
+}
+
+// accessClaims 解析访问令牌
+func accessClaims(t *testing.T, token string) *utils.Claims {
+	t.Helper()
+	claims, err := utils.ParseToken(token)
+	if err != nil {
+		t.Fatalf("解析访问令牌失败: %v", err)
+	}
+	return claims
+}
+
+func TestRefreshSessionRotation(t *testing.T) {
+	db := newTestDB(t)
+	useTestJWTConfig(t)
+	alice := createTestUser(t, db, "alice")
+
+	pair, err := CreateSession(db, alice.ID, alice.Account, DeviceInfo{DeviceType: "ios"})
+	if err != nil {
+		t.Fatalf("创建会话失败: %v", err)
+	}
+	claims := accessClaims(t, pair.AccessToken)
+	if claims.UserID != alice.ID || claims.SessionID != pair.SessionID {
+		t.Fatalf("访问令牌内容错误: %+v", claims)
+	}
+
+	refreshed, err := RefreshSession(db, pair.RefreshToken, "127.0.0.1")
+	if err != nil {
+		t.Fatalf("刷新失败: %v", err)
+	}
+	if refreshed.RefreshToken == pair.RefreshToken || refreshed.SessionID != pair.SessionID {
+		t.Errorf("刷新后应签发新的刷新令牌并保持会话不变")
+	}
+	var stored models.RefreshToken
+	db.Where("token_hash = ?", hashToken(pair.RefreshToken)).First(&stored)
+	if stored.Status != RefreshTokenRotated {
+		t.Errorf("使用过的刷新令牌状态为 %s，应为已轮换", stored.Status)
+	}
+	var count int64
+	db.Model(&models.RefreshToken{}).Where("token_hash IN ?", []string{pair.RefreshToken, refreshed.RefreshToken}).Count(&count)
+	if count != 0 {
+		t.Error("刷新令牌不应明文保存")
+	}
+
+	// 新的刷新令牌可以继续使用
+	next, err := RefreshSession(db, refreshed.RefreshToken, "")
+	if err != nil {
+		t.Fatalf("再次刷新失败: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		key   string
+	}{
+		{"空令牌", "", "auth.refresh_token_invalid"},
+		{"未知令牌", "not-a-token", "auth.refresh_token_invalid"},
+	}
+	for _, tt := range tests {
+		if _, err := RefreshSession(db, tt.token, ""); appErrorKey(err) != tt.key {
+			t.Errorf("%s: 得到 %v，应为 %s", tt.name, err, tt.key)
+		}
+	}
+	if next.AccessToken == "" {
+		t.Error("刷新后应返回访问令牌")
+	}
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	db := newTestDB(t)
+	useTestJWTConfig(t)
+	alice := createTestUser(t, db, "alice")
+
+	pair, err := CreateSession(db, alice.ID, alice.Account, DeviceInfo{DeviceType: "web"})
+	if err != nil {
+		t.Fatalf("创建会话失败: %v", err)
+	}
+	refreshed, err := RefreshSession(db, pair.RefreshToken, "")
+	if err != nil {
+		t.Fatalf("刷新失败: %v", err)
+	}
+
+	// 已轮换的刷新令牌再次出现，视为泄露
+	if _, err := RefreshSession(db, pair.RefreshToken, ""); appErrorKey(err) != "auth.refresh_token_reused" {
+		t.Fatalf("重复使用刷新令牌应报错，得到 %v", err)
+	}
+	if !IsTokenRevoked(accessClaims(t, refreshed.AccessToken)) {
+		t.Error("会话中签发的访问令牌应被吊销")
+	}
+	if _, err := RefreshSession(db, refreshed.RefreshToken, ""); err == nil {
+		t.Error("会话吊销后最新的刷新令牌也应失效")
+	}
+	var device models.UserDevice
+	db.Where("device_id = ?", pair.DeviceID).First(&device)
+	if device.IsActive || device.SessionID != "" || device.Trusted {
+		t.Errorf("设备应退出登录并取消信任: %+v", device)
+	}
+}
+
+func TestSessionRevocation(t *testing.T) {
+	db := newTestDB(t)
+	useTestJWTConfig(t)
+	alice := createTestUser(t, db, "alice")
+
+	phone, _ := CreateSession(db, alice.ID, alice.Account, DeviceInfo{DeviceType: "ios"})
+	laptop, _ := CreateSession(db, alice.ID, alice.Account, DeviceInfo{DeviceType: "macos"})
+	web, _ := CreateSession(db, alice.ID, alice.Account, DeviceInfo{DeviceType: "web"})
+
+	// 退出登录
+	if err := Logout(db, accessClaims(t, phone.AccessToken)); err != nil {
+		t.Fatalf("退出登录失败: %v", err)
+	}
+	if !IsTokenRevoked(accessClaims(t, phone.AccessToken)) {
+		t.Error("退出登录后访问令牌应失效")
+	}
+	if _, err := RefreshSession(db, phone.RefreshToken, ""); appErrorKey(err) != "auth.token_revoked" {
+		t.Errorf("退出登录后刷新令牌应失效，得到 %v", err)
+	}
+
+	// 下线其他设备
+	count, err := RevokeOtherSessions(db, alice.ID, web.SessionID)
+	if err != nil || count != 1 {
+		t.Fatalf("下线其他设备: count=%d, err=%v", count, err)
+	}
+	if !IsTokenRevoked(accessClaims(t, laptop.AccessToken)) || IsTokenRevoked(accessClaims(t, web.AccessToken)) {
+		t.Error("应只吊销其他设备的令牌")
+	}
+	sessions, _ := ListSessions(db, alice.ID)
+	if len(sessions) != 1 || sessions[0].SessionID != web.SessionID {
+		t.Errorf("只应剩下当前设备，得到 %d 个", len(sessions))
+	}
+
+	// 远程下线他人的设备
+	bob := createTestUser(t, db, "bob")
+	if err := RevokeSession(db, bob.ID, sessions[0].ID); appErrorKey(err) != "auth.device_not_found" {
+		t.Errorf("不能下线他人的设备，得到 %v", err)
+	}
+
+	// 吊销列表从数据库加载，其他实例吊销的令牌同样生效
+	revocationsMu.Lock()
+	revocations = map[string]int64{}
+	revocationsMu.Unlock()
+	if err := LoadTokenRevocations(db); err != nil {
+		t.Fatalf("加载吊销列表失败: %v", err)
+	}
+	if !IsTokenRevoked(accessClaims(t, laptop.AccessToken)) {
+		t.Error("从数据库加载后吊销仍应生效")
+	}
+}
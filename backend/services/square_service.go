@@ -0,0 +1,248 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 广场相关逻辑
+
+const (
+	// 单条动态最多关联的话题数
+	MaxSquareTopics = 5
+	// 话题名称最大长度（字符）
+	maxSquareTopicLength = 30
+	// 被不同用户举报达到该次数后自动隐藏，等待管理员审核
+	SquareReportHideThreshold = 5
+	// 热度重算的时间窗口，超出窗口的动态热度不再变化
+	squareHotWindow = 7 * 24 * time.Hour
+	// 热度衰减指数，越大衰减越快
+	squareHotGravity = 1.8
+)
+
+// 话题格式：#话题# 或 #tag
+var squareTopicPattern = regexp.MustCompile(`#([\p{L}\p{N}_]+)`)
+
+// ExtractSquareTopics 从正文和显式指定的话题中提取去重后的话题名称
+func ExtractSquareTopics(content string, extra []string) []string {
+	candidates := append([]string{}, extra...)
+	for _, m := range squareTopicPattern.FindAllStringSubmatch(content, -1) {
+		candidates = append(candidates, m[1])
+	}
+
+	seen := map[string]bool{}
+	topics := []string{}
+	for _, name := range candidates {
+		name = strings.Trim(strings.TrimSpace(name), "#")
+		if name == "" || utf8.RuneCountInString(name) > maxSquareTopicLength {
+			continue
+		}
+		key := strings.ToLower(name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		topics = append(topics, name)
+		if len(topics) == MaxSquareTopics {
+			break
+		}
+	}
+	return topics
+}
+
+// SquareHotScore 计算时间衰减的热度分
+// 评论权重高于点赞，分数随发布时间按幂函数衰减
+func SquareHotScore(likes, comments int, createdAt, now int64) float64 {
+	hours := float64(now-createdAt) / 3600
+	if hours < 0 {
+		hours = 0
+	}
+	points := float64(likes) + 2*float64(comments) + 1
+	return points / math.Pow(hours+2, squareHotGravity)
+}
+
+// UpdateSquareHotScore 重新计算单条动态的热度分
+func UpdateSquareHotScore(db *gorm.DB, postID uint) error {
+	var post models.SquarePost
+	if err := db.Select("id, like_count, comment_count, created_at").First(&post, postID).Error; err != nil {
+		return err
+	}
+	score := SquareHotScore(post.LikeCount, post.CommentCount, post.CreatedAt, time.Now().Unix())
+	return db.Model(&models.SquarePost{}).Where("id = ?", postID).UpdateColumn("hot_score", score).Error
+}
+
+// RefreshSquareHotScores 定时重算时间窗口内动态的热度分
+func RefreshSquareHotScores(db *gorm.DB) {
+	now := time.Now()
+	var posts []models.SquarePost
+	if err := db.Select("id, like_count, comment_count, created_at").
+		Where("status = ? AND created_at >= ?", models.SquarePostNormal, now.Add(-squareHotWindow).Unix()).
+		Find(&posts).Error; err != nil {
+		utils.Logger.Errorf("查询广场动态失败: %v", err)
+		return
+	}
+
+	for _, post := range posts {
+		score := SquareHotScore(post.LikeCount, post.CommentCount, post.CreatedAt, now.Unix())
+		db.Model(&models.SquarePost{}).Where("id = ?", post.ID).UpdateColumn("hot_score", score)
+	}
+
+	// 窗口外的动态热度归零，避免旧动态长期占据热榜
+	db.Model(&models.SquarePost{}).
+		Where("created_at < ? AND hot_score > 0", now.Add(-squareHotWindow).Unix()).
+		UpdateColumn("hot_score", 0)
+
+	utils.Logger.Infof("已刷新 %d 条广场动态的热度", len(posts))
+}
+
+// AttachSquareTopics 创建话题（如不存在）并关联到动态
+func AttachSquareTopics(tx *gorm.DB, postID uint, names []string, now int64) error {
+	for _, name := range names {
+		topic := models.SquareTopic{Name: name, CreatedAt: now, UpdatedAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&topic).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("name = ?", name).First(&topic).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&models.SquarePostTopic{PostID: postID, TopicID: topic.ID, CreatedAt: now}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.SquareTopic{}).Where("id = ?", topic.ID).
+			Updates(map[string]any{"post_count": gorm.Expr("post_count + 1"), "updated_at": now}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AttachSquareMedia 将媒体关联到广场动态，动态正常展示时所有用户都可以通过签名地址访问
+func AttachSquareMedia(db *gorm.DB, userID, postID, mediaID uint) (*models.Media, error) {
+	invalid := &utils.AppError{Code: http.StatusBadRequest, Message: "无效的媒体文件", Key: "square.invalid_media"}
+	return attachPostMedia(db, userID, mediaID, MediaTarget{Scope: MediaScopeSquare, SquarePostID: postID}, invalid)
+}
+
+// DetachSquareTopics 解除动态与话题的关联
+func DetachSquareTopics(tx *gorm.DB, postID uint) error {
+	var topicIDs []uint
+	if err := tx.Model(&models.SquarePostTopic{}).Where("post_id = ?", postID).Pluck("topic_id", &topicIDs).Error; err != nil {
+		return err
+	}
+	if len(topicIDs) > 0 {
+		if err := tx.Model(&models.SquareTopic{}).Where("id IN ? AND post_count > 0", topicIDs).
+			UpdateColumn("post_count", gorm.Expr("post_count - 1")).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Where("post_id = ?", postID).Delete(&models.SquarePostTopic{}).Error
+}
+
+// GetHotSquareTopics 获取近期热门话题
+func GetHotSquareTopics(db *gorm.DB, since int64, limit int) ([]map[string]any, error) {
+	var rows []struct {
+		ID        uint
+		Name      string
+		PostCount int
+		Recent    int
+	}
+	err := db.Table("square_post_topics AS pt").
+		Select("t.id, t.name, t.post_count, COUNT(*) AS recent").
+		Joins("JOIN square_topics t ON t.id = pt.topic_id").
+		Joins("JOIN square_posts p ON p.id = pt.post_id").
+		Where("pt.created_at >= ? AND p.status = ?", since, models.SquarePostNormal).
+		Group("t.id, t.name, t.post_count").
+		Order("recent DESC, t.post_count DESC").
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]any, 0, len(rows))
+	for _, r := range rows {
+		result = append(result, map[string]any{
+			"id":           r.ID,
+			"name":         r.Name,
+			"post_count":   r.PostCount,
+			"recent_count": r.Recent,
+		})
+	}
+	return result, nil
+}
+
+// ReportSquarePost 举报广场动态，同一用户只能举报一次
+// 不同用户举报次数达到阈值后动态自动隐藏
+func ReportSquarePost(db *gorm.DB, postID, reporterID uint, reason, detail string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		tx.Model(&models.SquareReport{}).Where("post_id = ? AND reporter_id = ?", postID, reporterID).Count(&count)
+		if count > 0 {
+			return &utils.AppError{Code: 400, Message: "您已举报过该动态", Key: "square.already_reported"}
+		}
+
+		report := models.SquareReport{
+			PostID:     postID,
+			ReporterID: reporterID,
+			Reason:     reason,
+			Detail:     detail,
+			Status:     "pending",
+			CreatedAt:  time.Now().Unix(),
+		}
+		if err := tx.Create(&report).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.SquarePost{}).Where("id = ?", postID).
+			UpdateColumn("report_count", gorm.Expr("report_count + 1")).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.SquarePost{}).
+			Where("id = ? AND status = ? AND report_count >= ?", postID, models.SquarePostNormal, SquareReportHideThreshold).
+			Update("status", models.SquarePostHidden).Error
+	})
+}
+
+// ReviewSquarePost 管理员审核被举报的动态
+// action 为 remove 时删除动态并认定举报成立，为 restore 时恢复展示并驳回举报
+func ReviewSquarePost(db *gorm.DB, postID, adminID uint, action string) error {
+	now := time.Now().Unix()
+	return db.Transaction(func(tx *gorm.DB) error {
+		var post models.SquarePost
+		if err := tx.First(&post, postID).Error; err != nil {
+			return &utils.AppError{Code: 404, Message: "动态不存在", Key: "square.not_found"}
+		}
+
+		var postUpdates map[string]any
+		var reportStatus string
+		switch action {
+		case "remove":
+			postUpdates = map[string]any{"status": models.SquarePostRemoved, "hot_score": 0, "updated_at": now}
+			reportStatus = "accepted"
+			if err := DetachSquareTopics(tx, postID); err != nil {
+				return err
+			}
+		case "restore":
+			postUpdates = map[string]any{"status": models.SquarePostNormal, "report_count": 0, "updated_at": now}
+			reportStatus = "rejected"
+		default:
+			return &utils.AppError{Code: 400, Message: "无效的审核操作", Key: "square.invalid_review_action"}
+		}
+
+		if err := tx.Model(&post).Updates(postUpdates).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.SquareReport{}).
+			Where("post_id = ? AND status = ?", postID, "pending").
+			Updates(map[string]any{"status": reportStatus, "handled_by": adminID, "handled_at": now}).Error
+	})
+}
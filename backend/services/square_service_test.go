@@ -0,0 +1,185 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// createTestSquarePost 创建一条正常展示的广场动态
+func createTestSquarePost(t *testing.T, db *gorm.DB, userID uint, createdAt int64) *models.SquarePost {
+	t.Helper()
+	post := &models.SquarePost{UserID: userID, Content: "广场", Status: models.SquarePostNormal, CreatedAt: createdAt}
+	if err := db.Create(post).Error; err != nil {
+		t.Fatalf("创建广场动态失败: %v", err)
+	}
+	return post
+}
+
+func TestExtractSquareTopics(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		extra   []string
+		want    []string
+	}{
+		{"正文话题", "今天 #旅行 和 #美食", nil, []string{"旅行", "美食"}},
+		{"忽略大小写去重", "#Go #go", []string{"GO"}, []string{"GO"}},
+		{"最多5个", "#a #b #c #d #e #f", nil, []string{"a", "b", "c", "d", "e"}},
+		{"过长的话题", "", []string{"#" + fmt.Sprintf("%031d", 0)}, []string{}},
+	}
+	for _, tt := range tests {
+		if got := ExtractSquareTopics(tt.content, tt.extra); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: 得到 %v，应为 %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSquareHotScore(t *testing.T) {
+	now := time.Now().Unix()
+	hour := int64(3600)
+
+	if SquareHotScore(0, 1, now, now) <= SquareHotScore(1, 0, now, now) {
+		t.Error("评论的权重应高于点赞")
+	}
+	if SquareHotScore(10, 0, now-24*hour, now) >= SquareHotScore(10, 0, now, now) {
+		t.Error("热度应随时间衰减")
+	}
+	if SquareHotScore(10, 0, now+hour, now) != SquareHotScore(10, 0, now, now) {
+		t.Error("发布时间晚于当前时间时按刚发布计算")
+	}
+	// 新动态的少量互动可以超过一天前的较多互动
+	if SquareHotScore(3, 0, now, now) <= SquareHotScore(20, 0, now-24*hour, now) {
+		t.Error("较新的动态应排在热榜前面")
+	}
+}
+
+func TestRefreshSquareHotScores(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+	now := time.Now().Unix()
+
+	recent := createTestSquarePost(t, db, alice.ID, now-3600)
+	db.Model(recent).UpdateColumn("like_count", 5)
+	old := createTestSquarePost(t, db, alice.ID, now-8*24*3600)
+	db.Model(old).UpdateColumn("hot_score", 3)
+	hidden := createTestSquarePost(t, db, alice.ID, now)
+	db.Model(hidden).UpdateColumns(map[string]any{"status": models.SquarePostHidden, "hot_score": 2})
+
+	RefreshSquareHotScores(db)
+
+	scores := map[uint]float64{}
+	var posts []models.SquarePost
+	db.Find(&posts)
+	for _, post := range posts {
+		scores[post.ID] = post.HotScore
+	}
+	if want := SquareHotScore(5, 0, recent.CreatedAt, now); scores[recent.ID] < want*0.99 || scores[recent.ID] > want*1.01 {
+		t.Errorf("窗口内的动态热度为 %f，应约为 %f", scores[recent.ID], want)
+	}
+	if scores[old.ID] != 0 {
+		t.Errorf("窗口外的动态热度应归零，得到 %f", scores[old.ID])
+	}
+	if scores[hidden.ID] != 2 {
+		t.Errorf("隐藏的动态不参与重算，得到 %f", scores[hidden.ID])
+	}
+}
+
+func TestReportSquarePostAutoHide(t *testing.T) {
+	db := newTestDB(t)
+	author := createTestUser(t, db, "author")
+	post := createTestSquarePost(t, db, author.ID, time.Now().Unix())
+
+	for i := 0; i < SquareReportHideThreshold; i++ {
+		reporter := createTestUser(t, db, fmt.Sprintf("reporter%d", i))
+		if err := ReportSquarePost(db, post.ID, reporter.ID, "spam", ""); err != nil {
+			t.Fatalf("举报失败: %v", err)
+		}
+		var reloaded models.SquarePost
+		db.First(&reloaded, post.ID)
+		hidden := i+1 >= SquareReportHideThreshold
+		if (reloaded.Status == models.SquarePostHidden) != hidden {
+			t.Errorf("第%d次举报后状态为 %s", i+1, reloaded.Status)
+		}
+		if i == 0 {
+			if err := ReportSquarePost(db, post.ID, reporter.ID, "abuse", ""); appErrorKey(err) != "square.already_reported" {
+				t.Errorf("重复举报应报错，得到 %v", err)
+			}
+		}
+	}
+
+	var reloaded models.SquarePost
+	db.First(&reloaded, post.ID)
+	if reloaded.ReportCount != SquareReportHideThreshold {
+		t.Errorf("重复举报不应计数，举报次数为 %d", reloaded.ReportCount)
+	}
+}
+
+func TestReviewSquarePost(t *testing.T) {
+	db := newTestDB(t)
+	author := createTestUser(t, db, "author")
+	reporter := createTestUser(t, db, "reporter")
+	admin := createTestUser(t, db, "admin")
+	now := time.Now().Unix()
+
+	restored := createTestSquarePost(t, db, author.ID, now)
+	removed := createTestSquarePost(t, db, author.ID, now)
+	if err := AttachSquareTopics(db, removed.ID, []string{"话题"}, now); err != nil {
+		t.Fatalf("关联话题失败: %v", err)
+	}
+	for _, post := range []*models.SquarePost{restored, removed} {
+		if err := ReportSquarePost(db, post.ID, reporter.ID, "spam", ""); err != nil {
+			t.Fatalf("举报失败: %v", err)
+		}
+		db.Model(post).UpdateColumns(map[string]any{"status": models.SquarePostHidden, "hot_score": 1})
+	}
+
+	if err := ReviewSquarePost(db, restored.ID, admin.ID, "restore"); err != nil {
+		t.Fatalf("恢复动态失败: %v", err)
+	}
+	if err := ReviewSquarePost(db, removed.ID, admin.ID, "remove"); err != nil {
+		t.Fatalf("删除动态失败: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		post         *models.SquarePost
+		status       string
+		reportCount  int
+		hotScore     float64
+		reportStatus string
+	}{
+		{"恢复展示", restored, models.SquarePostNormal, 0, 1, "rejected"},
+		{"删除", removed, models.SquarePostRemoved, 1, 0, "accepted"},
+	}
+	for _, tt := range tests {
+		var post models.SquarePost
+		db.First(&post, tt.post.ID)
+		if post.Status != tt.status || post.ReportCount != tt.reportCount || post.HotScore != tt.hotScore {
+			t.Errorf("%s: 动态为 status=%s report_count=%d hot_score=%f", tt.name, post.Status, post.ReportCount, post.HotScore)
+		}
+		var report models.SquareReport
+		db.Where("post_id = ?", tt.post.ID).First(&report)
+		if report.Status != tt.reportStatus || report.HandledBy != admin.ID || report.HandledAt == 0 {
+			t.Errorf("%s: 举报处理结果为 %+v", tt.name, report)
+		}
+	}
+
+	var topic models.SquareTopic
+	db.Where("name = ?", "话题").First(&topic)
+	var links int64
+	db.Model(&models.SquarePostTopic{}).Where("post_id = ?", removed.ID).Count(&links)
+	if topic.PostCount != 0 || links != 0 {
+		t.Errorf("删除的动态应解除话题关联，post_count=%d links=%d", topic.PostCount, links)
+	}
+
+	if err := ReviewSquarePost(db, restored.ID, admin.ID, "ignore"); appErrorKey(err) != "square.invalid_review_action" {
+		t.Errorf("无效的审核操作应报错，得到 %v", err)
+	}
+	if err := ReviewSquarePost(db, 9999, admin.ID, "remove"); appErrorKey(err) != "square.not_found" {
+		t.Errorf("不存在的动态应报错，得到 %v", err)
+	}
+}
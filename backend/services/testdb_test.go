@@ -0,0 +1,55 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB 创建迁移好全部表的临时数据库，同时设置为 utils.DB
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	if err := utils.MigrateDB(db); err != nil {
+		t.Fatalf("迁移测试数据库失败: %v", err)
+	}
+	previous := utils.DB
+	utils.DB = db
+	t.Cleanup(func() {
+		utils.DB = previous
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// createTestUser 创建测试用户
+func createTestUser(t *testing.T, db *gorm.DB, account string) *models.User {
+	t.Helper()
+	user := &models.User{Account: account, Nickname: account, Password: "-"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("创建用户 %s 失败: %v", account, err)
+	}
+	return user
+}
+
+// makeFriends 建立双向好友关系
+func makeFriends(t *testing.T, db *gorm.DB, a, b uint) {
+	t.Helper()
+	for _, pair := range [][2]uint{{a, b}, {b, a}} {
+		if err := db.Create(&models.Friend{UserID: pair[0], FriendID: pair[1]}).Error; err != nil {
+			t.Fatalf("创建好友关系失败: %v", err)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 语音识别：临时音频同步识别，语音消息通过异步任务识别
+// 任务完成后识别文本写入 VoiceMessage 和对应 ChatMessage 的 Extra，并推送给会话双方
+
+const (
+	// 单次识别的超时时间
+	transcriptionTimeout = 2 * time.Minute
+	// 识别服务不可用时的最大尝试次数
+	maxTranscriptionAttempts = 3
+	// 同时执行的识别任务数，本地引擎较占CPU
+	transcriptionWorkers = 2
+)
+
+// 识别任务状态
+const (
+	TranscriptionPending    = "pending"
+	TranscriptionProcessing = "processing"
+	TranscriptionDone       = "done"
+	TranscriptionFailed     = "failed"
+)
+
+var transcriptionSlots = make(chan struct{}, transcriptionWorkers)
+
+// TranscribeAudio 同步识别音频文件，language 为空时自动判断
+func TranscribeAudio(ctx context.Context, audioPath, language string) (*utils.Transcript, error) {
+	ctx, cancel := context.WithTimeout(ctx, transcriptionTimeout)
+	defer cancel()
+
+	result, err := utils.TranscribeWithProviders(ctx, audioPath, language)
+	if err != nil {
+		return nil, transcriptionAppError(err)
+	}
+	return result, nil
+}
+
+// ValidateSpeechLanguage 校验语言提示，返回统一后的语言代码，空字符串表示自动判断
+func ValidateSpeechLanguage(language string) (string, error) {
+	language = utils.NormalizeLanguage(language)
+	if language == "auto" {
+		return "", nil
+	}
+	if _, ok := utils.SupportedLanguages[language]; !ok {
+		return "", &utils.AppError{Code: http.StatusBadRequest, Message: "不支持的语言", Key: "i18n.unsupported_language"}
+	}
+	return language, nil
+}
+
+// EnqueueVoiceTranscription 为语音消息创建识别任务并在后台执行
+// 已有未完成的任务时直接返回该任务
+func EnqueueVoiceTranscription(db *gorm.DB, voice *models.VoiceMessage, userID uint, language string) (*models.TranscriptionJob, error) {
+	language, err := ValidateSpeechLanguage(language)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing models.TranscriptionJob
+	if err := db.Where("voice_message_id = ? AND status IN ?", voice.ID, []string{TranscriptionPending, TranscriptionProcessing}).
+		First(&existing).Error; err == nil {
+		return &existing, nil
+	}
+
+	now := time.Now().Unix()
+	job := models.TranscriptionJob{
+		UserID:         userID,
+		VoiceMessageID: voice.ID,
+		ChatMessageID:  voice.ChatMessageID,
+		FilePath:       voice.FilePath,
+		Language:       language,
+		Status:         TranscriptionPending,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := db.Create(&job).Error; err != nil {
+		return nil, err
+	}
+	attachTranscript(db, &job)
+
+	go RunTranscriptionJob(db, job.ID)
+	return &job, nil
+}
+
+// RunTranscriptionJob 执行识别任务，同一任务只会被一个协程领取
+func RunTranscriptionJob(db *gorm.DB, jobID uint) {
+	transcriptionSlots <- struct{}{}
+	defer func() { <-transcriptionSlots }()
+
+	claim := db.Model(&models.TranscriptionJob{}).
+		Where("id = ? AND status = ?", jobID, TranscriptionPending).
+		Updates(map[string]interface{}{
+			"status":     TranscriptionProcessing,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"updated_at": time.Now().Unix(),
+		})
+	if claim.Error != nil || claim.RowsAffected == 0 {
+		return
+	}
+	var job models.TranscriptionJob
+	if err := db.First(&job, jobID).Error; err != nil {
+		return
+	}
+	attachTranscript(db, &job)
+
+	ctx, cancel := context.WithTimeout(context.Background(), transcriptionTimeout)
+	defer cancel()
+	var result *utils.Transcript
+	audioPath, cleanup, err := transcriptionAudioPath(ctx, db, &job)
+	if err == nil {
+		result, err = utils.TranscribeWithProviders(ctx, audioPath, job.Language)
+		cleanup()
+	}
+
+	now := time.Now().Unix()
+	if err != nil {
+		utils.Logger.Errorf("语音识别任务失败: job=%d, attempt=%d, error=%v", job.ID, job.Attempts, err)
+		job.Error = err.Error()
+		job.Status = TranscriptionFailed
+		// 服务暂时不可用时退回等待状态，由定时任务稍后重试
+		if isRetryableTranscriptionError(err) && job.Attempts < maxTranscriptionAttempts {
+			job.Status = TranscriptionPending
+		} else {
+			job.FinishedAt = now
+		}
+	} else {
+		job.Status = TranscriptionDone
+		job.Text = result.Text
+		job.DetectedLang = result.Language
+		job.Provider = result.Provider
+		job.Error = ""
+		job.FinishedAt = now
+	}
+	job.UpdatedAt = now
+	db.Model(&job).Select("status", "text", "detected_lang", "provider", "error", "updated_at", "finished_at").Updates(&job)
+	attachTranscript(db, &job)
+}
+
+// transcriptionAudioPath 任务对应的本地音频文件，媒体存储中的语音按需下载到临时文件
+func transcriptionAudioPath(ctx context.Context, db *gorm.DB, job *models.TranscriptionJob) (string, func(), error) {
+	if job.FilePath != "" {
+		return job.FilePath, func() {}, nil
+	}
+	var voice models.VoiceMessage
+	if err := db.First(&voice, job.VoiceMessageID).Error; err != nil {
+		return "", func() {}, err
+	}
+	var media models.Media
+	if err := db.First(&media, voice.MediaID).Error; err != nil {
+		return "", func() {}, err
+	}
+	return MediaLocalFile(ctx, db, &media)
+}
+
+// RetryTranscriptionJobs 重新执行等待中的任务，并回收执行中断的任务
+// 用于服务重启后恢复任务，以及识别服务不可用时的延迟重试
+func RetryTranscriptionJobs(db *gorm.DB) {
+	now := time.Now()
+	db.Model(&models.TranscriptionJob{}).
+		Where("status = ? AND updated_at < ?", TranscriptionProcessing, now.Add(-2*transcriptionTimeout).Unix()).
+		Updates(map[string]interface{}{"status": TranscriptionPending, "updated_at": now.Unix()})
+
+	var ids []uint
+	db.Model(&models.TranscriptionJob{}).
+		Where("status = ? AND updated_at < ?", TranscriptionPending, now.Add(-time.Minute).Unix()).
+		Order("id asc").Limit(50).Pluck("id", &ids)
+	for _, id := range ids {
+		go RunTranscriptionJob(db, id)
+	}
+}
+
+// GetTranscriptionJob 获取识别任务，只有会话参与者可以查看
+func GetTranscriptionJob(db *gorm.DB, userID, jobID uint) (*models.TranscriptionJob, error) {
+	var job models.TranscriptionJob
+	if err := db.First(&job, jobID).Error; err != nil {
+		return nil, &utils.AppError{Code: http.StatusNotFound, Message: "识别任务不存在", Key: "speech.job_not_found"}
+	}
+	if job.UserID != userID {
+		var voice models.VoiceMessage
+		if err := db.First(&voice, job.VoiceMessageID).Error; err != nil || !IsVoiceMessageParticipant(db, &voice, userID) {
+			return nil, &utils.AppError{Code: http.StatusNotFound, Message: "识别任务不存在", Key: "speech.job_not_found"}
+		}
+	}
+	return &job, nil
+}
+
+// IsVoiceMessageParticipant 用户是否为语音消息所在会话的参与者
+func IsVoiceMessageParticipant(db *gorm.DB, voice *models.VoiceMessage, userID uint) bool {
+	if voice.SenderID == userID || voice.ReceiverID == userID {
+		return true
+	}
+	if voice.GroupID == 0 {
+		return false
+	}
+	var count int64
+	db.Model(&models.GroupMember{}).Where("group_id = ? AND user_id = ?", voice.GroupID, userID).Count(&count)
+	return count > 0
+}
+
+// attachTranscript 将任务状态和结果写入语音消息和聊天消息，结束时推送给会话参与者
+func attachTranscript(db *gorm.DB, job *models.TranscriptionJob) {
+	var voice models.VoiceMessage
+	if err := db.First(&voice, job.VoiceMessageID).Error; err != nil {
+		return
+	}
+	updates := map[string]interface{}{"transcript_status": job.Status}
+	if job.Status == TranscriptionDone {
+		updates["transcript"] = job.Text
+		updates["transcript_lang"] = job.DetectedLang
+	}
+	db.Model(&voice).Updates(updates)
+
+	if job.ChatMessageID != 0 {
+		var message models.ChatMessage
+		if err := db.First(&message, job.ChatMessageID).Error; err == nil {
+			extra := map[string]interface{}{}
+			if message.Extra != "" {
+				json.Unmarshal([]byte(message.Extra), &extra)
+			}
+			extra["transcript_status"] = job.Status
+			if job.Status == TranscriptionDone {
+				extra["text"] = job.Text
+				extra["transcript_lang"] = job.DetectedLang
+			}
+			if data, err := json.Marshal(extra); err == nil {
+				db.Model(&message).Update("extra", string(data))
+			}
+		}
+	}
+
+	if job.Status != TranscriptionDone && job.Status != TranscriptionFailed {
+		return
+	}
+	recipients := []uint{voice.SenderID}
+	if voice.GroupID != 0 {
+		var members []uint
+		db.Model(&models.GroupMember{}).Where("group_id = ? AND user_id <> ?", voice.GroupID, voice.SenderID).Pluck("user_id", &members)
+		recipients = append(recipients, members...)
+	} else if voice.ReceiverID != 0 {
+		recipients = append(recipients, voice.ReceiverID)
+	}
+	event := map[string]interface{}{
+		"type": "voice_transcribed",
+		"data": map[string]interface{}{
+			"job_id":           job.ID,
+			"message_id":       job.ChatMessageID,
+			"voice_message_id": job.VoiceMessageID,
+			"status":           job.Status,
+			"text":             job.Text,
+			"language":         job.DetectedLang,
+		},
+	}
+	for _, userID := range recipients {
+		utils.PushMessageToUser(userID, event)
+	}
+}
+
+// isRetryableTranscriptionError 音频本身的问题重试也不会成功
+func isRetryableTranscriptionError(err error) bool {
+	return !errors.Is(err, utils.ErrAudioInvalid) &&
+		!errors.Is(err, utils.ErrAudioTooLong) &&
+		!errors.Is(err, utils.ErrTranscribeEmpty)
+}
+
+// transcriptionAppError 将识别错误转换为接口错误
+func transcriptionAppError(err error) error {
+	switch {
+	case errors.Is(err, utils.ErrAudioInvalid):
+		return &utils.AppError{Code: http.StatusBadRequest, Message: "音频格式不支持或文件已损坏", Key: "speech.invalid_audio"}
+	case errors.Is(err, utils.ErrAudioTooLong):
+		return &utils.AppError{Code: http.StatusBadRequest, Message: "语音时长超过限制", Key: "speech.too_long"}
+	case errors.Is(err, utils.ErrTranscribeEmpty):
+		return &utils.AppError{Code: http.StatusUnprocessableEntity, Message: "没有识别到语音内容", Key: "speech.no_speech"}
+	case errors.Is(err, context.Canceled):
+		return err
+	default:
+		return &utils.AppError{Code: http.StatusServiceUnavailable, Message: "语音识别服务暂不可用", Key: "speech.unavailable"}
+	}
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// writeTestWav 写入指定时长的16kHz单声道PCM静音WAV文件
+func writeTestWav(t *testing.T, seconds int) string {
+	t.Helper()
+	dataSize := uint32(seconds * 16000 * 2)
+	header := []interface{}{
+		[4]byte{'R', 'I', 'F', 'F'}, 36 + dataSize, [4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '}, uint32(16), uint16(1), uint16(1), uint32(16000), uint32(32000), uint16(2), uint16(16),
+		[4]byte{'d', 'a', 't', 'a'}, dataSize,
+	}
+	path := filepath.Join(t.TempDir(), "voice.wav")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("创建音频文件失败: %v", err)
+	}
+	defer file.Close()
+	for _, field := range header {
+		binary.Write(file, binary.LittleEndian, field)
+	}
+	file.Write(make([]byte, dataSize))
+	return path
+}
+
+// scriptedTranscriber 依次返回 errs 中的错误，用完后返回 text
+type scriptedTranscriber struct {
+	mu    sync.Mutex
+	errs  []error
+	text  string
+	calls int
+}
+
+// useScriptedTranscriber 只使用 transcriber 识别语音，测试结束后恢复原配置
+func useScriptedTranscriber(t *testing.T, transcriber *scriptedTranscriber) {
+	t.Helper()
+	previous := utils.GetSpeechConfig()
+	utils.SetSpeechConfig(utils.SpeechConfig{Chain: []string{"mock"}})
+	utils.RegisterTranscriber(&utils.MockTranscriber{Reply: func(wavPath, language string) (*utils.Transcript, error) {
+		transcriber.mu.Lock()
+		defer transcriber.mu.Unlock()
+		transcriber.calls++
+		if len(transcriber.errs) > 0 {
+			err := transcriber.errs[0]
+			transcriber.errs = transcriber.errs[1:]
+			return nil, err
+		}
+		return &utils.Transcript{Text: transcriber.text, Language: "en"}, nil
+	}})
+	t.Cleanup(func() { utils.SetSpeechConfig(previous) })
+}
+
+// createTestVoiceMessage 创建一条私聊语音消息及其聊天消息
+func createTestVoiceMessage(t *testing.T, db *gorm.DB, senderID, receiverID uint) *models.VoiceMessage {
+	t.Helper()
+	message := models.ChatMessage{SenderID: senderID, ReceiverID: receiverID, Type: "voice", Extra: `{"duration":2}`}
+	db.Create(&message)
+	voice := &models.VoiceMessage{SenderID: senderID, ReceiverID: receiverID, ChatMessageID: message.ID, FilePath: writeTestWav(t, 2), Duration: 2}
+	if err := db.Create(voice).Error; err != nil {
+		t.Fatalf("创建语音消息失败: %v", err)
+	}
+	return voice
+}
+
+// waitTranscriptionJob 等待后台任务结束，并且结果已写入聊天消息
+func waitTranscriptionJob(t *testing.T, db *gorm.DB, jobID uint) models.TranscriptionJob {
+	t.Helper()
+	var job models.TranscriptionJob
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		db.First(&job, jobID)
+		if job.Status != TranscriptionDone && job.Status != TranscriptionFailed {
+			continue
+		}
+		var message models.ChatMessage
+		db.First(&message, job.ChatMessageID)
+		var extra map[string]interface{}
+		json.Unmarshal([]byte(message.Extra), &extra)
+		if extra["transcript_status"] == job.Status {
+			return job
+		}
+	}
+	t.Fatalf("识别任务没有结束，状态为 %s", job.Status)
+	return job
+}
+
+// createPendingJob 直接创建等待中的任务，不启动后台执行
+func createPendingJob(t *testing.T, db *gorm.DB, voice *models.VoiceMessage) *models.TranscriptionJob {
+	t.Helper()
+	job := &models.TranscriptionJob{
+		UserID:         voice.ReceiverID,
+		VoiceMessageID: voice.ID,
+		ChatMessageID:  voice.ChatMessageID,
+		FilePath:       voice.FilePath,
+		Status:         TranscriptionPending,
+	}
+	if err := db.Create(job).Error; err != nil {
+		t.Fatalf("创建识别任务失败: %v", err)
+	}
+	return job
+}
+
+func TestVoiceTranscriptionJobDone(t *testing.T) {
+	db := newTestDB(t)
+	useScriptedTranscriber(t, &scriptedTranscriber{text: " hello there "})
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	voice := createTestVoiceMessage(t, db, alice.ID, bob.ID)
+
+	if _, err := EnqueueVoiceTranscription(db, voice, bob.ID, "xx"); appErrorKey(err) != "i18n.unsupported_language" {
+		t.Errorf("不支持的语言提示应报错，得到 %v", err)
+	}
+	job, err := EnqueueVoiceTranscription(db, voice, bob.ID, "")
+	if err != nil {
+		t.Fatalf("创建识别任务失败: %v", err)
+	}
+	done := waitTranscriptionJob(t, db, job.ID)
+	if done.Status != TranscriptionDone || done.Text != "hello there" || done.DetectedLang != "en" ||
+		done.Provider != "mock" || done.Attempts != 1 || done.FinishedAt == 0 {
+		t.Errorf("任务结果不正确: %+v", done)
+	}
+
+	var saved models.VoiceMessage
+	db.First(&saved, voice.ID)
+	if saved.Transcript != "hello there" || saved.TranscriptLang != "en" || saved.TranscriptStatus != TranscriptionDone {
+		t.Errorf("识别结果应写入语音消息: %+v", saved)
+	}
+	var message models.ChatMessage
+	db.First(&message, voice.ChatMessageID)
+	var extra map[string]interface{}
+	json.Unmarshal([]byte(message.Extra), &extra)
+	if extra["text"] != "hello there" || extra["transcript_status"] != TranscriptionDone || extra["duration"] != float64(2) {
+		t.Errorf("识别结果应合并到聊天消息的 extra: %s", message.Extra)
+	}
+
+	// 会话参与者可以查看任务，其他人不能
+	carol := createTestUser(t, db, "carol")
+	if _, err := GetTranscriptionJob(db, alice.ID, job.ID); err != nil {
+		t.Errorf("发送者应能查看任务: %v", err)
+	}
+	if _, err := GetTranscriptionJob(db, carol.ID, job.ID); appErrorKey(err) != "speech.job_not_found" {
+		t.Errorf("非参与者不应能查看任务，得到 %v", err)
+	}
+}
+
+func TestVoiceTranscriptionJobReusesPending(t *testing.T) {
+	db := newTestDB(t)
+	transcriber := &scriptedTranscriber{text: "hello"}
+	useScriptedTranscriber(t, transcriber)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	voice := createTestVoiceMessage(t, db, alice.ID, bob.ID)
+	pending := createPendingJob(t, db, voice)
+
+	job, err := EnqueueVoiceTranscription(db, voice, alice.ID, "")
+	if err != nil || job.ID != pending.ID {
+		t.Fatalf("已有未完成的任务时应直接返回，得到 %+v %v", job, err)
+	}
+
+	// 已结束的任务不会被再次领取
+	RunTranscriptionJob(db, pending.ID)
+	RunTranscriptionJob(db, pending.ID)
+	if transcriber.calls != 1 {
+		t.Errorf("同一任务只应执行一次，执行了 %d 次", transcriber.calls)
+	}
+}
+
+func TestVoiceTranscriptionJobRetry(t *testing.T) {
+	db := newTestDB(t)
+	unavailable := &utils.TranscribeError{Provider: "mock", Kind: utils.ErrTranscribeUnavailable}
+	empty := &utils.TranscribeError{Provider: "mock", Kind: utils.ErrTranscribeEmpty}
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+
+	tests := []struct {
+		name     string
+		errs     []error
+		runs     int
+		status   string
+		attempts int
+	}{
+		{"服务不可用时退回等待", []error{unavailable}, 1, TranscriptionPending, 1},
+		{"重试后成功", []error{unavailable, unavailable}, 3, TranscriptionDone, 3},
+		{"超过最大尝试次数", []error{unavailable, unavailable, unavailable}, 3, TranscriptionFailed, 3},
+		{"没有语音内容不重试", []error{empty}, 1, TranscriptionFailed, 1},
+	}
+	for _, tt := range tests {
+		useScriptedTranscriber(t, &scriptedTranscriber{errs: tt.errs, text: "hello"})
+		voice := createTestVoiceMessage(t, db, alice.ID, bob.ID)
+		job := createPendingJob(t, db, voice)
+		for i := 0; i < tt.runs; i++ {
+			RunTranscriptionJob(db, job.ID)
+		}
+
+		var saved models.TranscriptionJob
+		db.First(&saved, job.ID)
+		if saved.Status != tt.status || saved.Attempts != tt.attempts {
+			t.Errorf("%s: 状态为 %s，尝试 %d 次", tt.name, saved.Status, saved.Attempts)
+		}
+		if finished := saved.FinishedAt != 0; finished != (tt.status != TranscriptionPending) {
+			t.Errorf("%s: finished_at 为 %d", tt.name, saved.FinishedAt)
+		}
+		var reloaded models.VoiceMessage
+		db.First(&reloaded, voice.ID)
+		if reloaded.TranscriptStatus != tt.status {
+			t.Errorf("%s: 语音消息的识别状态为 %s", tt.name, reloaded.TranscriptStatus)
+		}
+	}
+}
+
+func TestRetryTranscriptionJobsRecoversStuckJobs(t *testing.T) {
+	db := newTestDB(t)
+	useScriptedTranscriber(t, &scriptedTranscriber{text: "hello"})
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+
+	// 服务重启前执行中断的任务
+	stale := time.Now().Add(-time.Hour).Unix()
+	stuck := createPendingJob(t, db, createTestVoiceMessage(t, db, alice.ID, bob.ID))
+	db.Model(stuck).Updates(map[string]interface{}{"status": TranscriptionProcessing, "updated_at": stale})
+	// 刚刚退回等待的任务暂不重试
+	recent := createPendingJob(t, db, createTestVoiceMessage(t, db, alice.ID, bob.ID))
+	db.Model(recent).Update("updated_at", time.Now().Unix())
+
+	RetryTranscriptionJobs(db)
+	// 回收后的任务需等下一轮才重试
+	var saved models.TranscriptionJob
+	db.First(&saved, stuck.ID)
+	if saved.Status != TranscriptionPending {
+		t.Fatalf("中断的任务应退回等待，得到 %s", saved.Status)
+	}
+	db.Model(stuck).Update("updated_at", stale)
+
+	RetryTranscriptionJobs(db)
+	if done := waitTranscriptionJob(t, db, stuck.ID); done.Text != "hello" {
+		t.Errorf("中断的任务应重新执行，得到 %+v", done)
+	}
+	db.First(&saved, recent.ID)
+	if saved.Status != TranscriptionPending || saved.Attempts != 0 {
+		t.Errorf("刚退回等待的任务不应立即重试，得到 %+v", saved)
+	}
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 文本翻译：先查持久化缓存，未命中的文本按源语言分组后批量交给翻译提供方
+
+const (
+	// 单次批量翻译的最大条数
+	MaxTranslationBatch = 50
+	// 缓存超过该时间未被使用时清理
+	translationCacheTTL = 30 * 24 * time.Hour
+)
+
+// TranslationResult 一条文本的翻译结果
+type TranslationResult struct {
+	OriginalText   string `json:"original_text"`
+	TranslatedText string `json:"translated_text"`
+	SourceLang     string `json:"source_lang"`
+	TargetLang     string `json:"target_lang"`
+	Provider       string `json:"provider"`
+	Cached         bool   `json:"cached"`
+}
+
+// TranslateText 翻译单条文本，sourceLang 为空或 auto 时自动检测
+func TranslateText(ctx context.Context, db *gorm.DB, text, sourceLang, targetLang string) (*TranslationResult, error) {
+	results, err := TranslateTexts(ctx, db, []string{text}, sourceLang, targetLang)
+	if err != nil {
+		return nil, err
+	}
+	return &results[0], nil
+}
+
+// TranslateTexts 批量翻译，结果与 texts 一一对应
+// 离线词典的结果不写入缓存，避免在线服务恢复后仍返回原文
+func TranslateTexts(ctx context.Context, db *gorm.DB, texts []string, sourceLang, targetLang string) ([]TranslationResult, error) {
+	if len(texts) == 0 {
+		return []TranslationResult{}, nil
+	}
+	if len(texts) > MaxTranslationBatch {
+		return nil, &utils.AppError{Code: http.StatusBadRequest, Message: "单次最多翻译50条文本", Key: "translation.too_many_texts"}
+	}
+	sourceLang = utils.NormalizeLanguage(sourceLang)
+	targetLang = utils.NormalizeLanguage(targetLang)
+	if _, ok := utils.SupportedLanguages[targetLang]; !ok {
+		return nil, &utils.AppError{Code: http.StatusBadRequest, Message: "不支持的目标语言", Key: "translation.unsupported_target"}
+	}
+
+	results := make([]TranslationResult, len(texts))
+	hashes := make([]string, len(texts))
+	for i, text := range texts {
+		sum := sha256.Sum256([]byte(text))
+		hashes[i] = hex.EncodeToString(sum[:])
+		results[i] = TranslationResult{OriginalText: text, SourceLang: sourceLang, TargetLang: targetLang}
+	}
+
+	// 查缓存
+	var cached []models.TranslationCache
+	db.Where("source_lang = ? AND target_lang = ? AND text_hash IN ?", sourceLang, targetLang, hashes).Find(&cached)
+	byHash := map[string]models.TranslationCache{}
+	for _, row := range cached {
+		byHash[row.TextHash] = row
+	}
+	now := time.Now().Unix()
+	var hitIDs []uint
+	// 未命中的文本，相同文本只翻译一次
+	missing := map[string][]int{}
+	var missingOrder []string
+	for i, hash := range hashes {
+		if row, ok := byHash[hash]; ok {
+			results[i].TranslatedText = row.Translation
+			results[i].Provider = row.Provider
+			results[i].Cached = true
+			if row.DetectedLang != "" {
+				results[i].SourceLang = row.DetectedLang
+			}
+			hitIDs = append(hitIDs, row.ID)
+			continue
+		}
+		if _, ok := missing[hash]; !ok {
+			missingOrder = append(missingOrder, hash)
+		}
+		missing[hash] = append(missing[hash], i)
+	}
+	if len(hitIDs) > 0 {
+		db.Model(&models.TranslationCache{}).Where("id IN ?", hitIDs).Updates(map[string]interface{}{
+			"hits":         gorm.Expr("hits + 1"),
+			"last_used_at": now,
+		})
+	}
+	if len(missingOrder) == 0 {
+		return results, nil
+	}
+
+	// 按源语言分组，自动检测时逐条检测
+	groups := map[string][]string{}
+	var groupOrder []string
+	for _, hash := range missingOrder {
+		text := texts[missing[hash][0]]
+		lang := sourceLang
+		if lang == "auto" {
+			detected, err := DetectLanguage(ctx, db, text)
+			if err != nil {
+				return nil, err
+			}
+			lang = detected
+		}
+		if _, ok := groups[lang]; !ok {
+			groupOrder = append(groupOrder, lang)
+		}
+		groups[lang] = append(groups[lang], hash)
+	}
+
+	for _, lang := range groupOrder {
+		hashesInGroup := groups[lang]
+		batch := make([]string, len(hashesInGroup))
+		for i, hash := range hashesInGroup {
+			batch[i] = texts[missing[hash][0]]
+		}
+
+		translated, provider := batch, "none"
+		if lang != targetLang {
+			var err error
+			translated, provider, err = utils.TranslateWithProviders(ctx, batch, lang, targetLang)
+			if err != nil {
+				return nil, translationAppError(err)
+			}
+		}
+
+		for i, hash := range hashesInGroup {
+			for _, idx := range missing[hash] {
+				results[idx].TranslatedText = translated[i]
+				results[idx].SourceLang = lang
+				results[idx].Provider = provider
+			}
+			if provider == "dictionary" || provider == "none" {
+				continue
+			}
+			row := models.TranslationCache{
+				TextHash:    hash,
+				SourceLang:  sourceLang,
+				TargetLang:  targetLang,
+				Text:        batch[i],
+				Translation: translated[i],
+				Provider:    provider,
+				CreatedAt:   now,
+				LastUsedAt:  now,
+			}
+			if sourceLang == "auto" {
+				row.DetectedLang = lang
+			}
+			db.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "text_hash"}, {Name: "source_lang"}, {Name: "target_lang"}},
+				DoUpdates: clause.AssignmentColumns([]string{"translation", "detected_lang", "provider", "last_used_at"}),
+			}).Create(&row)
+		}
+	}
+	return results, nil
+}
+
+// DetectLanguage 检测文本语言，结果按原文哈希缓存
+// 离线词典只按书写系统粗略判断，其结果不写入缓存
+func DetectLanguage(ctx context.Context, db *gorm.DB, text string) (string, error) {
+	sum := sha256.Sum256([]byte(text))
+	hash := hex.EncodeToString(sum[:])
+
+	var cached models.LanguageDetection
+	if err := db.Where("text_hash = ?", hash).First(&cached).Error; err == nil {
+		return cached.Lang, nil
+	}
+
+	lang, provider, err := utils.DetectWithProviders(ctx, text)
+	if err != nil {
+		return "", translationAppError(err)
+	}
+	lang = utils.NormalizeLanguage(lang)
+	if provider != "dictionary" {
+		db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.LanguageDetection{
+			TextHash:  hash,
+			Lang:      lang,
+			CreatedAt: time.Now().Unix(),
+		})
+	}
+	return lang, nil
+}
+
+// PurgeTranslationCache 清理长时间未使用的翻译缓存和过期的语言检测缓存
+func PurgeTranslationCache(db *gorm.DB) {
+	cutoff := time.Now().Add(-translationCacheTTL).Unix()
+	db.Where("created_at < ?", cutoff).Delete(&models.LanguageDetection{})
+	result := db.Where("last_used_at < ?", cutoff).Delete(&models.TranslationCache{})
+	if result.Error != nil {
+		utils.Logger.Errorf("清理翻译缓存失败: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		utils.Logger.Infof("清理翻译缓存 %d 条", result.RowsAffected)
+	}
+}
+
+// translationAppError 将翻译错误转换为接口错误
+func translationAppError(err error) error {
+	switch {
+	case errors.Is(err, utils.ErrTranslateUnsupported):
+		return &utils.AppError{Code: http.StatusBadRequest, Message: "不支持的翻译语言", Key: "translation.unsupported_language"}
+	case errors.Is(err, utils.ErrTranslateRateLimited), errors.Is(err, utils.ErrTranslateCircuitOpen):
+		return &utils.AppError{Code: http.StatusServiceUnavailable, Message: "翻译服务繁忙，请稍后再试", Key: "translation.busy"}
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err
+	default:
+		return &utils.AppError{Code: http.StatusServiceUnavailable, Message: "翻译服务暂不可用", Key: "translation.unavailable"}
+	}
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+)
+
+// fakeTranslator 支持批量的翻译提供方，译文为 "目标语言:原文"，记录每次请求的文本
+type fakeTranslator struct {
+	err     error
+	batches [][]string
+}
+
+func (f *fakeTranslator) Name() string { return "fake" }
+
+func (f *fakeTranslator) SupportsBatch() bool { return true }
+
+func (f *fakeTranslator) Translate(ctx context.Context, texts []string, sourceLang, targetLang string) ([]string, error) {
+	f.batches = append(f.batches, texts)
+	if f.err != nil {
+		return nil, f.err
+	}
+	translated := make([]string, len(texts))
+	for i, text := range texts {
+		translated[i] = targetLang + ":" + text
+	}
+	return translated, nil
+}
+
+// Detect 含有汉字时为中文，否则为英文
+func (f *fakeTranslator) Detect(ctx context.Context, text string) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) {
+			return "zh-CN", nil
+		}
+	}
+	return "en", nil
+}
+
+// useFakeTranslator 只使用 translator 和离线词典翻译，测试结束后恢复为只有离线词典
+func useFakeTranslator(t *testing.T, translator *fakeTranslator) {
+	t.Helper()
+	utils.SetTranslationConfig(utils.TranslationConfig{Chain: []string{translator.Name()}})
+	utils.RegisterTranslator(translator)
+	t.Cleanup(func() { utils.SetTranslationConfig(utils.TranslationConfig{}) })
+}
+
+func TestTranslateTextsBatchAndCache(t *testing.T) {
+	db := newTestDB(t)
+	translator := &fakeTranslator{}
+	useFakeTranslator(t, translator)
+
+	texts := []string{"hello", "world", "hello"}
+	results, err := TranslateTexts(context.Background(), db, texts, "en", "zh-CN")
+	if err != nil {
+		t.Fatalf("翻译失败: %v", err)
+	}
+	// 相同文本只翻译一次，未命中缓存的文本一次批量请求
+	if fmt.Sprint(translator.batches) != "[[hello world]]" {
+		t.Errorf("应批量请求一次去重后的文本，得到 %v", translator.batches)
+	}
+	for i, result := range results {
+		if result.TranslatedText != "zh-CN:"+texts[i] || result.Provider != "fake" || result.Cached {
+			t.Errorf("第%d条结果不正确: %+v", i, result)
+		}
+	}
+
+	results, err = TranslateTexts(context.Background(), db, []string{"world", "new"}, "en", "zh-CN")
+	if err != nil {
+		t.Fatalf("再次翻译失败: %v", err)
+	}
+	if len(translator.batches) != 2 || fmt.Sprint(translator.batches[1]) != "[new]" {
+		t.Errorf("命中缓存的文本不应再请求提供方，得到 %v", translator.batches)
+	}
+	if !results[0].Cached || results[0].TranslatedText != "zh-CN:world" || results[1].Cached {
+		t.Errorf("缓存命中结果不正确: %+v", results)
+	}
+	var row models.TranslationCache
+	db.Where("text = ?", "world").First(&row)
+	if row.Hits != 1 || row.Provider != "fake" {
+		t.Errorf("缓存命中次数应为1，得到 %+v", row)
+	}
+}
+
+func TestTranslateTextsAutoDetect(t *testing.T) {
+	db := newTestDB(t)
+	translator := &fakeTranslator{}
+	useFakeTranslator(t, translator)
+
+	results, err := TranslateTexts(context.Background(), db, []string{"hello", "你好", "早上好"}, "auto", "en")
+	if err != nil {
+		t.Fatalf("翻译失败: %v", err)
+	}
+	// 与目标语言相同的文本原样返回，其余按检测到的语言分组批量翻译
+	if results[0].TranslatedText != "hello" || results[0].Provider != "none" || results[0].SourceLang != "en" {
+		t.Errorf("与目标语言相同的文本应原样返回: %+v", results[0])
+	}
+	if fmt.Sprint(translator.batches) != "[[你好 早上好]]" {
+		t.Errorf("中文文本应在同一批中翻译，得到 %v", translator.batches)
+	}
+	if results[1].TranslatedText != "en:你好" || results[1].SourceLang != "zh-CN" {
+		t.Errorf("检测到的源语言不正确: %+v", results[1])
+	}
+
+	// 自动检测的结果随缓存返回，不再检测和翻译
+	var detections int64
+	db.Model(&models.LanguageDetection{}).Count(&detections)
+	if detections != 3 {
+		t.Errorf("应缓存3条语言检测结果，得到 %d", detections)
+	}
+	results, _ = TranslateTexts(context.Background(), db, []string{"你好"}, "auto", "en")
+	if !results[0].Cached || results[0].SourceLang != "zh-CN" || len(translator.batches) != 1 {
+		t.Errorf("再次翻译应命中缓存: %+v", results[0])
+	}
+}
+
+func TestTranslateTextsDictionaryFallbackNotCached(t *testing.T) {
+	db := newTestDB(t)
+	translator := &fakeTranslator{err: &utils.TranslateError{Provider: "fake", Kind: utils.ErrTranslateUnavailable}}
+	useFakeTranslator(t, translator)
+
+	results, err := TranslateTexts(context.Background(), db, []string{"hello"}, "en", "zh-CN")
+	if err != nil {
+		t.Fatalf("在线服务失败时应回退到离线词典: %v", err)
+	}
+	if results[0].Provider != "dictionary" {
+		t.Errorf("应由离线词典翻译，得到 %+v", results[0])
+	}
+	var count int64
+	db.Model(&models.TranslationCache{}).Count(&count)
+	if count != 0 {
+		t.Errorf("离线词典的结果不应写入缓存，得到 %d 条", count)
+	}
+}
+
+func TestTranslateTextsValidation(t *testing.T) {
+	db := newTestDB(t)
+	useFakeTranslator(t, &fakeTranslator{})
+
+	tests := []struct {
+		name   string
+		texts  []string
+		target string
+		key    string
+	}{
+		{"超过批量上限", strings.Split(strings.Repeat("a,", MaxTranslationBatch+1), ",")[:MaxTranslationBatch+1], "en", "translation.too_many_texts"},
+		{"不支持的目标语言", []string{"hello"}, "xx", "translation.unsupported_target"},
+	}
+	for _, tt := range tests {
+		if _, err := TranslateTexts(context.Background(), db, tt.texts, "en", tt.target); appErrorKey(err) != tt.key {
+			t.Errorf("%s: 得到 %v，应为 %q", tt.name, err, tt.key)
+		}
+	}
+}
+
+func TestPurgeTranslationCache(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now().Unix()
+	old := now - int64(translationCacheTTL/time.Second) - 60
+	db.Create(&models.TranslationCache{TextHash: "old", SourceLang: "en", TargetLang: "zh-CN", LastUsedAt: old})
+	db.Create(&models.TranslationCache{TextHash: "recent", SourceLang: "en", TargetLang: "zh-CN", LastUsedAt: now})
+	db.Create(&models.LanguageDetection{TextHash: "old", Lang: "en", CreatedAt: old})
+
+	PurgeTranslationCache(db)
+
+	var hashes []string
+	db.Model(&models.TranslationCache{}).Pluck("text_hash", &hashes)
+	if fmt.Sprint(hashes) != "[recent]" {
+		t.Errorf("应只保留最近使用的缓存，得到 %v", hashes)
+	}
+	var detections int64
+	db.Model(&models.LanguageDetection{}).Count(&detections)
+	if detections != 0 {
+		t.Errorf("过期的语言检测缓存应被清理，剩余 %d 条", detections)
+	}
+}
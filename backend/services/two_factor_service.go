@@ -0,0 +1,314 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 两步验证：TOTP验证码或一次性恢复码
+// 开启后，在未受信任的设备上登录需要先验证密码，再用返回的临时令牌提交验证码才能完成登录；修改支付密码也需要验证码
+// 提交验证码时可以选择记住设备，服务端签发信任令牌，之后在该设备登录时携带信任令牌可免验证码
+// 连续验证失败会锁定一段时间，每个临时令牌也只能尝试有限次数
+
+const (
+	maxTwoFactorFailures  = 5
+	twoFactorLockDuration = 15 * time.Minute
+	twoFactorChallengeTTL = 5 * time.Minute
+	maxChallengeAttempts  = 5
+	recoveryCodeCount     = 10
+)
+
+// 恢复码字符集，去掉了容易混淆的 0/o、1/l/i
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// LoginChallenge 需要两步验证时返回的临时令牌
+type LoginChallenge struct {
+	Token     string `json:"two_factor_token"`
+	ExpiresIn int64  `json:"expires_in"`
+}
+
+// TwoFactorEnabled 用户是否已开启两步验证
+func TwoFactorEnabled(db *gorm.DB, userID uint) bool {
+	var count int64
+	db.Model(&models.UserTwoFactor{}).Where("user_id = ? AND enabled = ?", userID, true).Count(&count)
+	return count > 0
+}
+
+// GetTwoFactorStatus 两步验证状态和剩余可用的恢复码数量
+func GetTwoFactorStatus(db *gorm.DB, userID uint) (bool, int64) {
+	if !TwoFactorEnabled(db, userID) {
+		return false, 0
+	}
+	var remaining int64
+	db.Model(&models.TwoFactorRecoveryCode{}).Where("user_id = ? AND used_at = 0", userID).Count(&remaining)
+	return true, remaining
+}
+
+// SetupTwoFactor 生成新的TOTP密钥，返回密钥和用于生成二维码的 otpauth 地址，验证一次验证码后才启用
+func SetupTwoFactor(db *gorm.DB, userID uint, account string) (string, string, error) {
+	var record models.UserTwoFactor
+	exists := db.Where("user_id = ?", userID).First(&record).Error == nil
+	if exists && record.Enabled {
+		return "", "", &utils.AppError{Code: http.StatusBadRequest, Message: "两步验证已开启", Key: "two_factor.already_enabled"}
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	now := time.Now().Unix()
+	record.UserID = userID
+	record.Secret = secret
+	record.UpdatedAt = now
+	if !exists {
+		record.CreatedAt = now
+	}
+	if err := db.Save(&record).Error; err != nil {
+		return "", "", err
+	}
+	return secret, utils.TOTPProvisioningURI(account, secret), nil
+}
+
+// EnableTwoFactor 验证验证器生成的验证码后开启两步验证，返回恢复码（只在此时明文返回）
+func EnableTwoFactor(db *gorm.DB, userID uint, code string) ([]string, error) {
+	var record models.UserTwoFactor
+	if err := db.Where("user_id = ?", userID).First(&record).Error; err != nil {
+		return nil, &utils.AppError{Code: http.StatusBadRequest, Message: "请先获取两步验证密钥", Key: "two_factor.setup_required"}
+	}
+	if record.Enabled {
+		return nil, &utils.AppError{Code: http.StatusBadRequest, Message: "两步验证已开启", Key: "two_factor.already_enabled"}
+	}
+	step, ok := utils.VerifyTOTP(record.Secret, code, time.Now(), 1)
+	if !ok {
+		return nil, &utils.AppError{Code: http.StatusBadRequest, Message: "验证码错误", Key: "verification.code_invalid"}
+	}
+
+	var codes []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now().Unix()
+		if err := tx.Model(&record).Updates(map[string]interface{}{
+			"enabled":         true,
+			"enabled_at":      now,
+			"last_used_step":  step,
+			"failed_attempts": 0,
+			"locked_until":    0,
+			"updated_at":      now,
+		}).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	return codes, err
+}
+
+// DisableTwoFactor 验证验证码后关闭两步验证
+func DisableTwoFactor(db *gorm.DB, userID uint, code string) error {
+	if err := VerifyTwoFactorCode(db, userID, code); err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.TwoFactorRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.UserTwoFactor{}).Error
+	})
+}
+
+// RegenerateRecoveryCodes 验证验证码后重新生成恢复码，原有的恢复码全部失效
+func RegenerateRecoveryCodes(db *gorm.DB, userID uint, code string) ([]string, error) {
+	if err := VerifyTwoFactorCode(db, userID, code); err != nil {
+		return nil, err
+	}
+	var codes []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	return codes, err
+}
+
+// VerifyTwoFactorCode 校验TOTP验证码或恢复码，连续失败后锁定一段时间
+func VerifyTwoFactorCode(db *gorm.DB, userID uint, code string) error {
+	var record models.UserTwoFactor
+	if err := db.Where("user_id = ? AND enabled = ?", userID, true).First(&record).Error; err != nil {
+		return &utils.AppError{Code: http.StatusBadRequest, Message: "未开启两步验证", Key: "two_factor.not_enabled"}
+	}
+	now := time.Now()
+	if record.LockedUntil > now.Unix() {
+		minutes := (record.LockedUntil - now.Unix() + 59) / 60
+		return &utils.AppError{Code: http.StatusTooManyRequests, Message: fmt.Sprintf("验证失败次数过多，请%d分钟后再试", minutes), Key: "two_factor.too_many_failures", Args: []interface{}{minutes}}
+	}
+
+	code = strings.TrimSpace(code)
+	if step, ok := utils.VerifyTOTP(record.Secret, code, now, 1); ok {
+		// 条件更新保证同一个验证码只能使用一次
+		result := db.Model(&models.UserTwoFactor{}).Where("id = ? AND last_used_step < ?", record.ID, step).Updates(map[string]interface{}{
+			"last_used_step":  step,
+			"failed_attempts": 0,
+			"updated_at":      now.Unix(),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &utils.AppError{Code: http.StatusBadRequest, Message: "验证码已使用，请等待验证器刷新后重试", Key: "two_factor.code_reused"}
+		}
+		return nil
+	}
+	if useRecoveryCode(db, userID, code) {
+		return db.Model(&record).Updates(map[string]interface{}{"failed_attempts": 0, "updated_at": now.Unix()}).Error
+	}
+
+	updates := map[string]interface{}{"failed_attempts": record.FailedAttempts + 1, "updated_at": now.Unix()}
+	if record.FailedAttempts+1 >= maxTwoFactorFailures {
+		updates["failed_attempts"] = 0
+		updates["locked_until"] = now.Add(twoFactorLockDuration).Unix()
+	}
+	db.Model(&record).Updates(updates)
+	return &utils.AppError{Code: http.StatusBadRequest, Message: "验证码错误", Key: "verification.code_invalid"}
+}
+
+// RequiresTwoFactor 登录是否需要两步验证：已开启且没有携带该用户受信任设备的信任令牌
+func RequiresTwoFactor(db *gorm.DB, userID uint, info DeviceInfo) bool {
+	return TwoFactorEnabled(db, userID) && !IsTrustedDevice(db, userID, info.DeviceID, info.TrustToken)
+}
+
+// BeginLogin 密码验证通过后调用，需要两步验证时返回临时令牌，否则直接创建会话
+func BeginLogin(db *gorm.DB, userID uint, account string, info DeviceInfo) (*TokenPair, *LoginChallenge, error) {
+	if !RequiresTwoFactor(db, userID, info) {
+		pair, err := CreateSession(db, userID, account, info)
+		return pair, nil, err
+	}
+
+	now := time.Now()
+	db.Where("expires_at <= ?", now.Unix()).Delete(&models.TwoFactorChallenge{})
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, nil, err
+	}
+	// 信任令牌无效，不保存
+	info.TrustToken = ""
+	device, _ := json.Marshal(info)
+	if err := db.Create(&models.TwoFactorChallenge{
+		TokenHash: hashToken(token),
+		UserID:    userID,
+		Device:    string(device),
+		ExpiresAt: now.Add(twoFactorChallengeTTL).Unix(),
+		CreatedAt: now.Unix(),
+	}).Error; err != nil {
+		return nil, nil, err
+	}
+	return nil, &LoginChallenge{Token: token, ExpiresIn: int64(twoFactorChallengeTTL / time.Second)}, nil
+}
+
+// CompleteTwoFactorLogin 提交临时令牌和验证码完成登录，remember 为 true 时记住设备并返回信任令牌
+func CompleteTwoFactorLogin(db *gorm.DB, token, code string, remember bool, ipAddress, userAgent string) (*TokenPair, *models.User, error) {
+	var challenge models.TwoFactorChallenge
+	if err := db.Where("token_hash = ?", hashToken(token)).First(&challenge).Error; err != nil ||
+		challenge.ExpiresAt <= time.Now().Unix() || challenge.Attempts >= maxChallengeAttempts {
+		if challenge.ID != 0 {
+			db.Delete(&challenge)
+		}
+		return nil, nil, &utils.AppError{Code: http.StatusUnauthorized, Message: "登录验证已过期，请重新登录", Key: "two_factor.challenge_expired"}
+	}
+
+	if err := VerifyTwoFactorCode(db, challenge.UserID, code); err != nil {
+		db.Model(&challenge).Update("attempts", gorm.Expr("attempts + 1"))
+		return nil, nil, err
+	}
+	db.Delete(&challenge)
+
+	var user models.User
+	if err := db.First(&user, challenge.UserID).Error; err != nil {
+		return nil, nil, &utils.AppError{Code: http.StatusUnauthorized, Message: "用户不存在", Key: "user.not_found"}
+	}
+	var info DeviceInfo
+	json.Unmarshal([]byte(challenge.Device), &info)
+	info.IPAddress = ipAddress
+	info.UserAgent = userAgent
+	pair, err := CreateSession(db, user.ID, user.Account, info)
+	if err != nil {
+		return nil, nil, err
+	}
+	if remember {
+		pair.TrustToken, err = TrustDevice(db, user.ID, pair.DeviceID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return pair, &user, nil
+}
+
+// replaceRecoveryCodes 生成新的恢复码并替换原有的恢复码
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.TwoFactorRecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.Create(&models.TwoFactorRecoveryCode{
+			UserID:    userID,
+			CodeHash:  hashRecoveryCode(code),
+			CreatedAt: now,
+		}).Error; err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode 生成 xxxxx-xxxxx 格式的恢复码
+func generateRecoveryCode() (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	for i := 0; i < 10; i++ {
+		if i == 5 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(recoveryCodeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// hashRecoveryCode 恢复码忽略大小写、空格和连字符
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// useRecoveryCode 使用一个未使用过的恢复码
+func useRecoveryCode(db *gorm.DB, userID uint, code string) bool {
+	if len(code) < 10 {
+		return false
+	}
+	result := db.Model(&models.TwoFactorRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at = 0", userID, hashRecoveryCode(code)).
+		Update("used_at", time.Now().Unix())
+	return result.Error == nil && result.RowsAffected > 0
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// enableTestTwoFactor 为用户开启两步验证，返回密钥和恢复码
+func enableTestTwoFactor(t *testing.T, db *gorm.DB, user *models.User) (string, []string) {
+	t.Helper()
+	secret, _, err := SetupTwoFactor(db, user.ID, user.Account)
+	if err != nil {
+		t.Fatalf("获取两步验证密钥失败: %v", err)
+	}
+	codes, err := EnableTwoFactor(db, user.ID, totpCodeAt(t, secret, 0))
+	if err != nil {
+		t.Fatalf("开启两步验证失败: %v", err)
+	}
+	return secret, codes
+}
+
+// totpCodeAt 当前时间步偏移 offset 后的验证码
+func totpCodeAt(t *testing.T, secret string, offset int64) string {
+	t.Helper()
+	code, err := utils.TOTPCode(secret, utils.TOTPStep(time.Now())+offset)
+	if err != nil {
+		t.Fatalf("计算验证码失败: %v", err)
+	}
+	return code
+}
+
+// resetUsedStep 清除已使用的时间步，使同一时间步的验证码可以再次使用
+func resetUsedStep(db *gorm.DB, userID uint) {
+	db.Model(&models.UserTwoFactor{}).Where("user_id = ?", userID).Update("last_used_step", 0)
+}
+
+func TestVerifyTwoFactorCode(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+
+	if err := VerifyTwoFactorCode(db, alice.ID, "123456"); appErrorKey(err) != "two_factor.not_enabled" {
+		t.Errorf("未开启时应报错，得到 %v", err)
+	}
+	secret, recovery := enableTestTwoFactor(t, db, alice)
+	if len(recovery) != recoveryCodeCount {
+		t.Fatalf("恢复码数量为 %d", len(recovery))
+	}
+	if _, _, err := SetupTwoFactor(db, alice.ID, alice.Account); appErrorKey(err) != "two_factor.already_enabled" {
+		t.Errorf("已开启时不能重新获取密钥，得到 %v", err)
+	}
+
+	// 开启时使用的验证码不能再次使用，下一个时间步的可以
+	if err := VerifyTwoFactorCode(db, alice.ID, totpCodeAt(t, secret, 0)); appErrorKey(err) != "two_factor.code_reused" {
+		t.Errorf("同一验证码不能重复使用，得到 %v", err)
+	}
+	if err := VerifyTwoFactorCode(db, alice.ID, totpCodeAt(t, secret, 1)); err != nil {
+		t.Errorf("下一个时间步的验证码应有效: %v", err)
+	}
+
+	// 恢复码只能使用一次，忽略大小写和连字符
+	code := recovery[0]
+	if err := VerifyTwoFactorCode(db, alice.ID, strings.ToUpper(strings.ReplaceAll(code, "-", " "))); err != nil {
+		t.Errorf("恢复码应有效: %v", err)
+	}
+	if err := VerifyTwoFactorCode(db, alice.ID, code); appErrorKey(err) != "verification.code_invalid" {
+		t.Errorf("恢复码不能重复使用，得到 %v", err)
+	}
+	_, remaining := GetTwoFactorStatus(db, alice.ID)
+	if remaining != recoveryCodeCount-1 {
+		t.Errorf("剩余恢复码 %d 个", remaining)
+	}
+
+	// 连续失败后锁定（上面重复使用恢复码已算一次失败），锁定期间正确的验证码也被拒绝
+	for i := 1; i < maxTwoFactorFailures; i++ {
+		if err := VerifyTwoFactorCode(db, alice.ID, "abcdef"); appErrorKey(err) != "verification.code_invalid" {
+			t.Fatalf("第%d次错误: %v", i+1, err)
+		}
+	}
+	resetUsedStep(db, alice.ID)
+	if err := VerifyTwoFactorCode(db, alice.ID, totpCodeAt(t, secret, 0)); appErrorKey(err) != "two_factor.too_many_failures" {
+		t.Errorf("锁定期间应拒绝验证，得到 %v", err)
+	}
+	db.Model(&models.UserTwoFactor{}).Where("user_id = ?", alice.ID).Update("locked_until", 0)
+	if err := VerifyTwoFactorCode(db, alice.ID, totpCodeAt(t, secret, 0)); err != nil {
+		t.Errorf("锁定结束后应可以验证: %v", err)
+	}
+
+	if err := DisableTwoFactor(db, alice.ID, recovery[1]); err != nil {
+		t.Fatalf("关闭两步验证失败: %v", err)
+	}
+	if TwoFactorEnabled(db, alice.ID) {
+		t.Error("两步验证应已关闭")
+	}
+}
+
+func TestTwoFactorLoginRememberDevice(t *testing.T) {
+	db := newTestDB(t)
+	useTestJWTConfig(t)
+	alice := createTestUser(t, db, "alice")
+	secret, _ := enableTestTwoFactor(t, db, alice)
+	device := DeviceInfo{DeviceID: "phone-1", DeviceType: "ios"}
+
+	// 新设备需要两步验证，不记住设备时下次仍需验证
+	pair, challenge, err := BeginLogin(db, alice.ID, alice.Account, device)
+	if err != nil || pair != nil || challenge == nil {
+		t.Fatalf("新设备应要求两步验证: %v", err)
+	}
+	resetUsedStep(db, alice.ID)
+	pair, _, err = CompleteTwoFactorLogin(db, challenge.Token, totpCodeAt(t, secret, 0), false, "", "")
+	if err != nil {
+		t.Fatalf("完成两步验证失败: %v", err)
+	}
+	if pair.TrustToken != "" {
+		t.Error("未选择记住设备时不应返回信任令牌")
+	}
+	if !RequiresTwoFactor(db, alice.ID, device) {
+		t.Error("未记住的设备再次登录仍需两步验证")
+	}
+
+	// 临时令牌只能使用一次
+	if _, _, err := CompleteTwoFactorLogin(db, challenge.Token, totpCodeAt(t, secret, 0), false, "", ""); appErrorKey(err) != "two_factor.challenge_expired" {
+		t.Errorf("临时令牌不能重复使用，得到 %v", err)
+	}
+
+	// 记住设备后携带信任令牌免验证
+	_, challenge, _ = BeginLogin(db, alice.ID, alice.Account, device)
+	resetUsedStep(db, alice.ID)
+	pair, _, err = CompleteTwoFactorLogin(db, challenge.Token, totpCodeAt(t, secret, 0), true, "", "")
+	if err != nil || pair.TrustToken == "" {
+		t.Fatalf("记住设备应返回信任令牌: %v", err)
+	}
+	var stored models.UserDevice
+	db.Where("user_id = ? AND device_id = ?", alice.ID, device.DeviceID).First(&stored)
+	if !stored.Trusted || stored.TrustTokenHash == pair.TrustToken || stored.TrustTokenHash == "" {
+		t.Errorf("应只保存信任令牌的哈希: %+v", stored)
+	}
+
+	trusted := device
+	trusted.TrustToken = pair.TrustToken
+	tests := []struct {
+		name     string
+		info     DeviceInfo
+		requires bool
+	}{
+		{"携带信任令牌", trusted, false},
+		{"只有设备标识", device, true},
+		{"错误的信任令牌", DeviceInfo{DeviceID: device.DeviceID, TrustToken: "guess"}, true},
+		{"信任令牌用于其他设备", DeviceInfo{DeviceID: "phone-2", TrustToken: pair.TrustToken}, true},
+	}
+	for _, tt := range tests {
+		if got := RequiresTwoFactor(db, alice.ID, tt.info); got != tt.requires {
+			t.Errorf("%s: RequiresTwoFactor = %v，应为 %v", tt.name, got, tt.requires)
+		}
+	}
+	pair, challenge, err = BeginLogin(db, alice.ID, alice.Account, trusted)
+	if err != nil || pair == nil || challenge != nil {
+		t.Fatalf("受信任的设备应直接登录: %v", err)
+	}
+
+	// 远程下线后取消信任
+	if err := RevokeSession(db, alice.ID, stored.ID); err != nil {
+		t.Fatalf("远程下线失败: %v", err)
+	}
+	if !RequiresTwoFactor(db, alice.ID, trusted) {
+		t.Error("远程下线后设备不应再受信任")
+	}
+}
+
+func TestDeviceIDScopedToUser(t *testing.T) {
+	db := newTestDB(t)
+	useTestJWTConfig(t)
+	alice := createTestUser(t, db, "alice")
+	mallory := createTestUser(t, db, "mallory")
+	secret, _ := enableTestTwoFactor(t, db, alice)
+
+	device := DeviceInfo{DeviceID: "shared-device"}
+	_, challenge, _ := BeginLogin(db, alice.ID, alice.Account, device)
+	resetUsedStep(db, alice.ID)
+	alicePair, _, err := CompleteTwoFactorLogin(db, challenge.Token, totpCodeAt(t, secret, 0), true, "", "")
+	if err != nil {
+		t.Fatalf("完成两步验证失败: %v", err)
+	}
+
+	// 其他用户使用相同的设备标识登录，不影响原用户的设备记录和会话
+	malloryPair, err := CreateSession(db, mallory.ID, mallory.Account, device)
+	if err != nil {
+		t.Fatalf("创建会话失败: %v", err)
+	}
+	var devices []models.UserDevice
+	db.Where("device_id = ?", device.DeviceID).Order("user_id").Find(&devices)
+	if len(devices) != 2 || devices[0].UserID != alice.ID || devices[1].UserID != mallory.ID {
+		t.Fatalf("每个用户应有自己的设备记录，得到 %d 条", len(devices))
+	}
+	if devices[1].Trusted {
+		t.Error("新用户的设备不应继承信任")
+	}
+	if IsTokenRevoked(accessClaims(t, alicePair.AccessToken)) {
+		t.Error("其他用户登录不应使原用户的会话失效")
+	}
+	if _, err := RefreshSession(db, alicePair.RefreshToken, ""); err != nil {
+		t.Errorf("原用户的刷新令牌应仍然有效: %v", err)
+	}
+
+	// 同一用户在同一设备重新登录，原会话失效，信任状态保留
+	trusted := device
+	trusted.TrustToken = alicePair.TrustToken
+	again, _, err := BeginLogin(db, alice.ID, alice.Account, trusted)
+	if err != nil || again == nil {
+		t.Fatalf("受信任的设备应直接登录: %v", err)
+	}
+	if !IsTokenRevoked(accessClaims(t, alicePair.AccessToken)) {
+		t.Error("重新登录后原会话应失效")
+	}
+	if !IsTrustedDevice(db, alice.ID, device.DeviceID, alicePair.TrustToken) {
+		t.Error("重新登录不应取消信任")
+	}
+	if IsTokenRevoked(accessClaims(t, malloryPair.AccessToken)) {
+		t.Error("原用户重新登录不应影响其他用户")
+	}
+}
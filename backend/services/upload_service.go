@@ -0,0 +1,311 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 断点续传：先创建上传会话，再按偏移量分多次上传分片，全部上传后校验SHA-256并保存到媒体存储
+// 分片追加写入本地临时文件，中断后客户端查询已接收的偏移量继续上传
+// 未完成的会话预占用户的存储配额，过期后由定时任务清理
+
+// 上传会话状态
+const (
+	UploadStatusUploading  = "uploading"
+	UploadStatusFinalizing = "finalizing"
+	UploadStatusCompleted  = "completed"
+)
+
+// 同一会话的分片和完成请求串行处理
+var uploadLocks sync.Map
+
+func lockUpload(uploadID string) func() {
+	value, _ := uploadLocks.LoadOrStore(uploadID, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// UploadSessionRequest 创建上传会话的参数
+type UploadSessionRequest struct {
+	FileName string
+	FileType string
+	Size     int64
+	Checksum string
+	Target   MediaTarget
+}
+
+// CreateUploadSession 创建上传会话，校验文件大小和用户配额
+func CreateUploadSession(db *gorm.DB, userID uint, req UploadSessionRequest) (*models.UploadSession, error) {
+	config := utils.GetMediaConfig()
+	if !MediaFileTypes[req.FileType] {
+		return nil, &utils.AppError{Code: http.StatusBadRequest, Message: "不支持的文件类型", Key: "file.unsupported_type"}
+	}
+	if req.Size <= 0 {
+		return nil, &utils.AppError{Code: http.StatusBadRequest, Message: "文件大小无效", Key: "upload.invalid_size"}
+	}
+	if req.Size > config.UploadMaxSize {
+		return nil, &utils.AppError{Code: http.StatusRequestEntityTooLarge, Message: fmt.Sprintf("文件大小不能超过%dMB", config.UploadMaxSize>>20), Key: "file.too_large", Args: []interface{}{config.UploadMaxSize >> 20}}
+	}
+	if limit, ok := utils.ImageLimits[utils.ImageKindImage]; ok && req.FileType == "image" && req.Size > limit.MaxSize {
+		return nil, &utils.AppError{Code: http.StatusRequestEntityTooLarge, Message: fmt.Sprintf("图片大小不能超过%dMB", limit.MaxSize>>20), Key: "media.image_too_large", Args: []interface{}{limit.MaxSize >> 20}}
+	}
+	checksum, ok := normalizeChecksum(req.Checksum)
+	if !ok {
+		return nil, &utils.AppError{Code: http.StatusBadRequest, Message: "校验值格式错误，应为SHA-256十六进制", Key: "upload.invalid_checksum"}
+	}
+	if err := CheckMediaQuota(db, userID, req.Size); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := models.UploadSession{
+		UploadID:         strings.ReplaceAll(uuid.New().String(), "-", ""),
+		UserID:           userID,
+		FileName:         filepath.Base(req.FileName),
+		FileType:         req.FileType,
+		Size:             req.Size,
+		Checksum:         checksum,
+		Scope:            req.Target.Scope,
+		ConversationType: req.Target.ConversationType,
+		ConversationID:   req.Target.ConversationID,
+		Status:           UploadStatusUploading,
+		ExpiresAt:        now.Add(config.UploadTTL).Unix(),
+		CreatedAt:        now.Unix(),
+		UpdatedAt:        now.Unix(),
+	}
+	if err := os.MkdirAll(config.UploadDir, 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(uploadPartPath(session.UploadID), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	file.Close()
+	if err := db.Create(&session).Error; err != nil {
+		os.Remove(uploadPartPath(session.UploadID))
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetUploadSession 获取用户自己的上传会话
+func GetUploadSession(db *gorm.DB, userID uint, uploadID string) (*models.UploadSession, error) {
+	var session models.UploadSession
+	if err := db.Where("upload_id = ? AND user_id = ?", uploadID, userID).First(&session).Error; err != nil {
+		return nil, &utils.AppError{Code: http.StatusNotFound, Message: "上传会话不存在", Key: "upload.not_found"}
+	}
+	if session.Status != UploadStatusCompleted && session.ExpiresAt < time.Now().Unix() {
+		return nil, &utils.AppError{Code: http.StatusGone, Message: "上传会话已过期，请重新上传", Key: "upload.expired"}
+	}
+	return &session, nil
+}
+
+// AppendUploadChunk 从 offset 处追加分片，offset 必须等于已接收的字节数
+// 请求中断时已写入的部分同样保留，客户端查询偏移量后从断点继续
+func AppendUploadChunk(db *gorm.DB, userID uint, uploadID string, offset int64, body io.Reader) (*models.UploadSession, error) {
+	unlock := lockUpload(uploadID)
+	defer unlock()
+
+	session, err := GetUploadSession(db, userID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != UploadStatusUploading {
+		return session, &utils.AppError{Code: http.StatusConflict, Message: "上传已完成", Key: "upload.completed"}
+	}
+	if offset != session.Offset {
+		return session, &utils.AppError{Code: http.StatusConflict, Message: fmt.Sprintf("偏移量不匹配，已接收%d字节", session.Offset), Key: "upload.offset_mismatch", Args: []interface{}{session.Offset}}
+	}
+
+	file, err := os.OpenFile(uploadPartPath(uploadID), os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, &utils.AppError{Code: http.StatusGone, Message: "上传会话已过期，请重新上传", Key: "upload.expired"}
+	}
+	defer file.Close()
+	// 丢弃之前请求失败时写入但未记录的数据
+	if err := file.Truncate(session.Offset); err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(session.Offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	remaining := session.Size - session.Offset
+	limit := utils.GetMediaConfig().UploadChunkSize
+	if remaining < limit {
+		limit = remaining
+	}
+	// 分片超过单次上限时只接收上限内的部分，客户端按返回的偏移量继续
+	written, copyErr := io.Copy(file, io.LimitReader(body, limit))
+	if copyErr == nil && written == remaining {
+		var extra [1]byte
+		if n, _ := body.Read(extra[:]); n > 0 {
+			copyErr = &utils.AppError{Code: http.StatusRequestEntityTooLarge, Message: "上传内容超过声明的文件大小", Key: "upload.exceeds_size"}
+		}
+	}
+
+	now := time.Now()
+	session.Offset += written
+	session.UpdatedAt = now.Unix()
+	session.ExpiresAt = now.Add(utils.GetMediaConfig().UploadTTL).Unix()
+	if err := db.Model(session).Updates(map[string]interface{}{
+		"offset":     session.Offset,
+		"updated_at": session.UpdatedAt,
+		"expires_at": session.ExpiresAt,
+	}).Error; err != nil {
+		return nil, err
+	}
+	if copyErr != nil {
+		return session, copyErr
+	}
+	return session, nil
+}
+
+// FinalizeUpload 校验文件完整性并保存为媒体，重复调用返回已保存的媒体
+// checksum 为空时使用创建会话时声明的校验值，两者都未提供时拒绝
+func FinalizeUpload(ctx context.Context, db *gorm.DB, userID uint, uploadID, checksum string) (*models.Media, error) {
+	unlock := lockUpload(uploadID)
+	defer unlock()
+
+	session, err := GetUploadSession(db, userID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status == UploadStatusCompleted {
+		return GetAccessibleMedia(db, userID, session.MediaID)
+	}
+	if session.Offset != session.Size {
+		return nil, &utils.AppError{Code: http.StatusConflict, Message: fmt.Sprintf("文件未上传完成，已接收%d/%d字节", session.Offset, session.Size), Key: "upload.incomplete", Args: []interface{}{session.Offset, session.Size}}
+	}
+	expected, ok := normalizeChecksum(checksum)
+	if !ok {
+		return nil, &utils.AppError{Code: http.StatusBadRequest, Message: "校验值格式错误，应为SHA-256十六进制", Key: "upload.invalid_checksum"}
+	}
+	if expected == "" {
+		expected = session.Checksum
+	}
+	if expected == "" {
+		return nil, &utils.AppError{Code: http.StatusBadRequest, Message: "缺少文件校验值", Key: "upload.checksum_required"}
+	}
+
+	file, err := os.Open(uploadPartPath(uploadID))
+	if err != nil {
+		return nil, &utils.AppError{Code: http.StatusGone, Message: "上传会话已过期，请重新上传", Key: "upload.expired"}
+	}
+	defer file.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return nil, err
+	}
+	hash := hex.EncodeToString(hasher.Sum(nil))
+	if hash != expected {
+		// 内容已损坏，从头重新上传
+		os.Truncate(uploadPartPath(uploadID), 0)
+		db.Model(session).Updates(map[string]interface{}{"offset": 0, "updated_at": time.Now().Unix()})
+		return nil, &utils.AppError{Code: http.StatusUnprocessableEntity, Message: "文件校验失败，请重新上传", Key: "upload.checksum_mismatch"}
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	// 保存期间不再计入预占配额，由媒体记录计入
+	db.Model(session).Update("status", UploadStatusFinalizing)
+	target := MediaTarget{Scope: session.Scope, ConversationType: session.ConversationType, ConversationID: session.ConversationID}
+	var media *models.Media
+	if session.FileType == "image" {
+		media, err = StoreImage(ctx, db, userID, file, session.FileName, utils.ImageKindImage, target)
+	} else {
+		media, err = saveMediaFile(ctx, db, file, hash, session.Size, models.Media{
+			OwnerID:          userID,
+			FileName:         session.FileName,
+			FileType:         session.FileType,
+			Scope:            target.Scope,
+			ConversationType: target.ConversationType,
+			ConversationID:   target.ConversationID,
+		})
+	}
+	if err != nil {
+		db.Model(session).Update("status", UploadStatusUploading)
+		return nil, err
+	}
+
+	db.Model(session).Updates(map[string]interface{}{
+		"status":     UploadStatusCompleted,
+		"media_id":   media.ID,
+		"updated_at": time.Now().Unix(),
+	})
+	os.Remove(uploadPartPath(uploadID))
+	return media, nil
+}
+
+// AbortUpload 取消上传并删除已接收的分片
+func AbortUpload(db *gorm.DB, userID uint, uploadID string) error {
+	unlock := lockUpload(uploadID)
+	defer unlock()
+
+	var session models.UploadSession
+	if err := db.Where("upload_id = ? AND user_id = ?", uploadID, userID).First(&session).Error; err != nil {
+		return &utils.AppError{Code: http.StatusNotFound, Message: "上传会话不存在", Key: "upload.not_found"}
+	}
+	if session.Status == UploadStatusFinalizing {
+		return &utils.AppError{Code: http.StatusConflict, Message: "文件正在保存", Key: "upload.saving"}
+	}
+	os.Remove(uploadPartPath(uploadID))
+	uploadLocks.Delete(uploadID)
+	return db.Delete(&session).Error
+}
+
+// CleanupExpiredUploads 删除过期未完成的上传会话和分片文件，已完成的会话记录保留到过期后删除
+func CleanupExpiredUploads(db *gorm.DB) {
+	var sessions []models.UploadSession
+	if err := db.Where("expires_at < ? AND status <> ?", time.Now().Unix(), UploadStatusFinalizing).Find(&sessions).Error; err != nil {
+		utils.Logger.Errorf("查询过期上传会话失败: %v", err)
+		return
+	}
+	for _, session := range sessions {
+		if err := os.Remove(uploadPartPath(session.UploadID)); err != nil && !os.IsNotExist(err) {
+			utils.Logger.Errorf("删除上传分片失败: upload=%s, error=%v", session.UploadID, err)
+			continue
+		}
+		db.Delete(&session)
+		uploadLocks.Delete(session.UploadID)
+	}
+	if len(sessions) > 0 {
+		utils.Logger.Infof("清理过期上传会话%d个", len(sessions))
+	}
+}
+
+func uploadPartPath(uploadID string) string {
+	return filepath.Join(utils.GetMediaConfig().UploadDir, uploadID+".part")
+}
+
+// normalizeChecksum 校验值统一为小写十六进制，允许 sha256: 前缀，空值返回空字符串
+func normalizeChecksum(checksum string) (string, bool) {
+	checksum = strings.ToLower(strings.TrimSpace(checksum))
+	checksum = strings.TrimPrefix(checksum, "sha256:")
+	if checksum == "" {
+		return "", true
+	}
+	if len(checksum) != sha256.Size*2 {
+		return "", false
+	}
+	if _, err := hex.DecodeString(checksum); err != nil {
+		return "", false
+	}
+	return checksum, true
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"allinone_backend/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAppendUploadChunkOffsets(t *testing.T) {
+	db := newTestDB(t)
+	useTestMediaConfig(t, func(config *utils.MediaConfig) {
+		config.UploadChunkSize = 4
+	})
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+
+	content := "0123456789"
+	sum := sha256.Sum256([]byte(content))
+	session, err := CreateUploadSession(db, alice.ID, UploadSessionRequest{
+		FileName: "a.txt",
+		FileType: "file",
+		Size:     int64(len(content)),
+		Checksum: hex.EncodeToString(sum[:]),
+		Target:   MediaTarget{Scope: MediaScopePrivate},
+	})
+	if err != nil {
+		t.Fatalf("创建上传会话失败: %v", err)
+	}
+
+	steps := []struct {
+		name     string
+		userID   uint
+		offset   int64
+		body     string
+		wantCode int
+		want     int64 // 之后已接收的字节数
+	}{
+		{"他人的会话", bob.ID, 0, content, http.StatusNotFound, 0},
+		{"偏移量超前", alice.ID, 3, content[3:], http.StatusConflict, 0},
+		// 超过单次上限的部分不接收
+		{"首个分片", alice.ID, 0, content, 0, 4},
+		{"重复发送已接收的分片", alice.ID, 0, content[:4], http.StatusConflict, 4},
+		{"续传", alice.ID, 4, content[4:8], 0, 8},
+		{"超过声明的大小", alice.ID, 8, content[8:] + "x", http.StatusRequestEntityTooLarge, 10},
+	}
+	for _, step := range steps {
+		_, err := AppendUploadChunk(db, step.userID, session.UploadID, step.offset, strings.NewReader(step.body))
+		if got := appErrorCode(err); got != step.wantCode || (step.wantCode == 0 && err != nil) {
+			t.Fatalf("%s: 错误为 %v，应为 %d", step.name, err, step.wantCode)
+		}
+		current, err := GetUploadSession(db, alice.ID, session.UploadID)
+		if err != nil {
+			t.Fatalf("%s: 查询上传会话失败: %v", step.name, err)
+		}
+		if current.Offset != step.want {
+			t.Fatalf("%s: 已接收 %d 字节，应为 %d", step.name, current.Offset, step.want)
+		}
+	}
+
+	if _, err := AppendUploadChunk(db, alice.ID, session.UploadID, 10, strings.NewReader("x")); appErrorCode(err) != http.StatusRequestEntityTooLarge {
+		t.Errorf("接收完成后继续上传应报错，得到 %v", err)
+	}
+	media, err := FinalizeUpload(context.Background(), db, alice.ID, session.UploadID, "")
+	if err != nil {
+		t.Fatalf("完成上传失败: %v", err)
+	}
+	if media.Size != int64(len(content)) || media.Hash != hex.EncodeToString(sum[:]) {
+		t.Errorf("保存的文件不正确: size=%d hash=%s", media.Size, media.Hash)
+	}
+	if _, err := AppendUploadChunk(db, alice.ID, session.UploadID, 10, strings.NewReader("x")); appErrorCode(err) != http.StatusConflict {
+		t.Errorf("上传完成后不能再追加，得到 %v", err)
+	}
+}
+
+func TestFinalizeUploadChecksumMismatchRestarts(t *testing.T) {
+	db := newTestDB(t)
+	useTestMediaConfig(t, nil)
+	alice := createTestUser(t, db, "alice")
+
+	session, err := CreateUploadSession(db, alice.ID, UploadSessionRequest{
+		FileName: "a.txt",
+		FileType: "file",
+		Size:     3,
+		Target:   MediaTarget{Scope: MediaScopePrivate},
+	})
+	if err != nil {
+		t.Fatalf("创建上传会话失败: %v", err)
+	}
+	if _, err := FinalizeUpload(context.Background(), db, alice.ID, session.UploadID, ""); appErrorCode(err) != http.StatusConflict {
+		t.Errorf("未上传完成时应报错，得到 %v", err)
+	}
+	if _, err := AppendUploadChunk(db, alice.ID, session.UploadID, 0, strings.NewReader("abc")); err != nil {
+		t.Fatalf("上传失败: %v", err)
+	}
+	if _, err := FinalizeUpload(context.Background(), db, alice.ID, session.UploadID, ""); appErrorCode(err) != http.StatusBadRequest {
+		t.Errorf("缺少校验值时应报错，得到 %v", err)
+	}
+	wrong := strings.Repeat("0", 64)
+	if _, err := FinalizeUpload(context.Background(), db, alice.ID, session.UploadID, wrong); appErrorCode(err) != http.StatusUnprocessableEntity {
+		t.Errorf("校验值不符时应报错，得到 %v", err)
+	}
+	current, _ := GetUploadSession(db, alice.ID, session.UploadID)
+	if current.Offset != 0 {
+		t.Errorf("校验失败后应从头上传，已接收 %d 字节", current.Offset)
+	}
+}
@@ -4,20 +4,54 @@ import (
 	"gorm.io/gorm"
 	"allinone_backend/models"
 	"allinone_backend/repositories"
+	"allinone_backend/utils"
+	"net/http"
+	"strings"
 )
 
+// UserRoleBot 系统账号（如AI助手）的 role，这类账号不能登录
+const UserRoleBot = "bot"
+
+// 系统保留的账号名，不能注册
+var reservedAccounts = map[string]bool{
+	GroupAIBotAccount: true,
+	"system":          true,
+}
+
+// IsReservedAccount 判断账号名是否为系统保留，不区分大小写
+func IsReservedAccount(account string) bool {
+	account = strings.ToLower(strings.TrimSpace(account))
+	if reservedAccounts[account] {
+		return true
+	}
+	return strings.HasPrefix(account, GroupAIBotAccount+"_")
+}
+
+// ErrReservedAccount 注册的账号名为系统保留
+var ErrReservedAccount = &utils.AppError{Code: http.StatusBadRequest, Message: "该账号名为系统保留，请更换", Key: "user.account_reserved"}
+
 func RegisterUser(db *gorm.DB, account, password string) error {
-	user := &models.User{Account: account, Password: password}
+	if IsReservedAccount(account) {
+		return ErrReservedAccount
+	}
+	if err := utils.ValidatePasswordStrength(password, account); err != nil {
+		return err
+	}
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	user := &models.User{Account: account, Password: hash}
 	return repositories.CreateUser(db, user)
 }
 
 func LoginUser(db *gorm.DB, account, password string) (*models.User, error) {
 	user, err := repositories.GetUserByAccount(db, account)
-	if err != nil {
+	if err != nil && err != gorm.ErrRecordNotFound {
 		return nil, err
 	}
-	if user.Password != password {
-		return nil, gorm.ErrRecordNotFound
+	if err := AuthenticatePassword(db, user, account, password, ""); err != nil {
+		return nil, err
 	}
 	return user, nil
 }
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 语音消息：按实际音频内容计算时长和波形，转码后保存到媒体存储
+// 客户端上传的时长不再使用；每个接收者的收听状态单独记录，首次收听时通知发送者
+
+// StoreVoice 处理并保存语音文件，返回媒体记录和时长、波形等信息
+func StoreVoice(ctx context.Context, db *gorm.DB, ownerID uint, body io.Reader, fileName string, target MediaTarget) (*models.Media, *utils.ProcessedVoice, error) {
+	config := utils.GetMediaConfig()
+	tempFile, err := os.CreateTemp("", "voice-upload-*"+strings.ToLower(filepath.Ext(fileName)))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.Remove(tempFile.Name())
+	size, err := io.Copy(tempFile, io.LimitReader(body, config.MaxSize+1))
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if size == 0 {
+		return nil, nil, &utils.AppError{Code: http.StatusBadRequest, Message: "文件内容为空", Key: "file.empty"}
+	}
+	if size > config.MaxSize {
+		return nil, nil, &utils.AppError{Code: http.StatusRequestEntityTooLarge, Message: fmt.Sprintf("文件大小不能超过%dMB", config.MaxSize>>20), Key: "file.too_large", Args: []interface{}{config.MaxSize >> 20}}
+	}
+
+	voice, cleanup, err := utils.ProcessVoice(ctx, tempFile.Name())
+	if err != nil {
+		return nil, nil, voiceAppError(err)
+	}
+	defer cleanup()
+
+	file, err := os.Open(voice.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	baseName := strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+	if baseName == "" || baseName == "." {
+		baseName = "voice"
+	}
+	media, err := storeMedia(ctx, db, file, models.Media{
+		OwnerID:          ownerID,
+		FileName:         baseName + voice.Ext,
+		FileType:         "voice",
+		ContentType:      voice.ContentType,
+		Scope:            target.Scope,
+		ConversationType: target.ConversationType,
+		ConversationID:   target.ConversationID,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return media, voice, nil
+}
+
+// ApplyVoiceInfo 将时长和波形写入语音消息
+func ApplyVoiceInfo(message *models.VoiceMessage, voice *utils.ProcessedVoice) {
+	message.Duration = voiceSeconds(voice.Duration)
+	message.DurationMs = voice.Duration.Milliseconds()
+	data, _ := json.Marshal(voice.Waveform)
+	message.Waveform = string(data)
+}
+
+// VoiceExtra 语音聊天消息的 Extra
+func VoiceExtra(mediaID uint, voice *utils.ProcessedVoice) map[string]interface{} {
+	return map[string]interface{}{
+		"duration":    voiceSeconds(voice.Duration),
+		"duration_ms": voice.Duration.Milliseconds(),
+		"media_id":    mediaID,
+		"waveform":    voice.Waveform,
+	}
+}
+
+// VoiceWaveform 解析语音消息保存的波形，旧数据返回空数组
+func VoiceWaveform(message *models.VoiceMessage) []int {
+	waveform := []int{}
+	if message.Waveform != "" {
+		json.Unmarshal([]byte(message.Waveform), &waveform)
+	}
+	return waveform
+}
+
+// voiceSeconds 时长按秒向上取整，不足1秒按1秒
+func voiceSeconds(duration time.Duration) int {
+	seconds := int((duration + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// MarkVoiceListened 标记语音消息已被用户收听，首次收听时通知发送者
+func MarkVoiceListened(db *gorm.DB, message *models.VoiceMessage, userID uint) (*models.VoiceListen, error) {
+	if message.SenderID == userID {
+		return nil, &utils.AppError{Code: http.StatusBadRequest, Message: "不能标记自己发送的语音", Key: "voice.cannot_mark_own"}
+	}
+	listen := models.VoiceListen{
+		VoiceMessageID: message.ID,
+		UserID:         userID,
+		ListenedAt:     time.Now().Unix(),
+	}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&listen)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		// 已收听过，返回首次收听的记录
+		db.Where("voice_message_id = ? AND user_id = ?", message.ID, userID).First(&listen)
+		return &listen, nil
+	}
+
+	utils.PushMessageToUser(message.SenderID, map[string]interface{}{
+		"type": "voice_listened",
+		"data": map[string]interface{}{
+			"message_id":       message.ChatMessageID,
+			"voice_message_id": message.ID,
+			"user_id":          userID,
+			"listened_at":      listen.ListenedAt,
+		},
+	})
+	return &listen, nil
+}
+
+// VoiceListenState 用户视角的收听状态
+// 接收者返回自己是否已收听；发送者返回收听人数，单聊时同时返回对方是否已收听
+func VoiceListenState(db *gorm.DB, message *models.VoiceMessage, userID uint) map[string]interface{} {
+	if message.SenderID != userID {
+		var listen models.VoiceListen
+		if err := db.Where("voice_message_id = ? AND user_id = ?", message.ID, userID).First(&listen).Error; err != nil {
+			return map[string]interface{}{"listened": false}
+		}
+		return map[string]interface{}{"listened": true, "listened_at": listen.ListenedAt}
+	}
+
+	var count int64
+	db.Model(&models.VoiceListen{}).Where("voice_message_id = ?", message.ID).Count(&count)
+	state := map[string]interface{}{"listened_count": count}
+	if message.GroupID == 0 {
+		state["listened"] = count > 0
+	}
+	return state
+}
+
+// voiceAppError 将语音处理错误转换为接口错误
+func voiceAppError(err error) error {
+	switch {
+	case errors.Is(err, utils.ErrAudioTooLong):
+		maxSeconds := int(utils.GetVoiceConfig().MaxDuration / time.Second)
+		return &utils.AppError{Code: http.StatusBadRequest, Message: fmt.Sprintf("语音不能超过%d秒", maxSeconds), Key: "voice.too_long", Args: []interface{}{maxSeconds}}
+	case errors.Is(err, utils.ErrAudioInvalid):
+		return &utils.AppError{Code: http.StatusBadRequest, Message: "音频格式不支持或文件已损坏", Key: "speech.invalid_audio"}
+	case errors.Is(err, utils.ErrTranscribeUnavailable):
+		return &utils.AppError{Code: http.StatusServiceUnavailable, Message: "服务器暂不支持该音频格式，请上传WAV格式", Key: "voice.wav_required"}
+	default:
+		return err
+	}
+}
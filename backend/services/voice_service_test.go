@@ -0,0 +1,133 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useTestVoiceConfig 使用不存在的ffmpeg，语音按原格式保存，测试结束后恢复原配置
+func useTestVoiceConfig(t *testing.T, maxDuration time.Duration) {
+	t.Helper()
+	previous := utils.GetVoiceConfig()
+	utils.SetVoiceConfig(utils.VoiceConfig{FFmpegPath: "ffmpeg-not-installed", MaxDuration: maxDuration, WaveformPeaks: 16})
+	t.Cleanup(func() { utils.SetVoiceConfig(previous) })
+}
+
+func TestStoreVoice(t *testing.T) {
+	db := newTestDB(t)
+	useTestMediaConfig(t, nil)
+	useTestVoiceConfig(t, 5*time.Second)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	target := MediaTarget{Scope: MediaScopeConversation, ConversationType: ConversationPrivate, ConversationID: bob.ID}
+
+	file, err := os.Open(writeTestWav(t, 3))
+	if err != nil {
+		t.Fatalf("打开音频文件失败: %v", err)
+	}
+	defer file.Close()
+	media, voice, err := StoreVoice(context.Background(), db, alice.ID, file, "record.WAV", target)
+	if err != nil {
+		t.Fatalf("保存语音失败: %v", err)
+	}
+	if media.FileType != "voice" || media.FileName != "record.wav" || media.ConversationID != bob.ID {
+		t.Errorf("媒体记录不正确: %+v", media)
+	}
+	if voice.Duration != 3*time.Second || len(voice.Waveform) != 16 {
+		t.Errorf("时长或波形不正确: %v %v", voice.Duration, voice.Waveform)
+	}
+
+	message := models.VoiceMessage{Duration: 60}
+	ApplyVoiceInfo(&message, voice)
+	if message.Duration != 3 || message.DurationMs != 3000 || len(VoiceWaveform(&message)) != 16 {
+		t.Errorf("应使用服务器计算的时长和波形，得到 %+v", message)
+	}
+	if extra := VoiceExtra(media.ID, voice); extra["duration"] != 3 || extra["media_id"] != media.ID {
+		t.Errorf("消息 Extra 不正确: %v", extra)
+	}
+	if waveform := VoiceWaveform(&models.VoiceMessage{}); waveform == nil || len(waveform) != 0 {
+		t.Errorf("旧数据的波形应为空数组，得到 %v", waveform)
+	}
+
+	long, err := os.ReadFile(writeTestWav(t, 6))
+	if err != nil {
+		t.Fatalf("读取音频文件失败: %v", err)
+	}
+	tests := []struct {
+		name     string
+		body     string
+		fileName string
+		key      string
+	}{
+		{"空文件", "", "voice.wav", "file.empty"},
+		{"超过最大时长", string(long), "long.wav", "voice.too_long"},
+		{"其他格式需要ffmpeg", "not really audio", "voice.m4a", "voice.wav_required"},
+	}
+	for _, tt := range tests {
+		_, _, err := StoreVoice(context.Background(), db, alice.ID, strings.NewReader(tt.body), tt.fileName, target)
+		if appErrorKey(err) != tt.key {
+			t.Errorf("%s: 得到 %v，应为 %s", tt.name, err, tt.key)
+		}
+	}
+}
+
+func TestVoiceSeconds(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		want     int
+	}{
+		{0, 1},
+		{300 * time.Millisecond, 1},
+		{time.Second, 1},
+		{1001 * time.Millisecond, 2},
+		{59500 * time.Millisecond, 60},
+	}
+	for _, tt := range tests {
+		if got := voiceSeconds(tt.duration); got != tt.want {
+			t.Errorf("%v: 得到 %d 秒，应为 %d", tt.duration, got, tt.want)
+		}
+	}
+}
+
+func TestMarkVoiceListened(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	message := createTestVoiceMessage(t, db, alice.ID, bob.ID)
+
+	if _, err := MarkVoiceListened(db, message, alice.ID); appErrorKey(err) != "voice.cannot_mark_own" {
+		t.Errorf("不能标记自己发送的语音，得到 %v", err)
+	}
+	if state := VoiceListenState(db, message, alice.ID); state["listened"] != false || state["listened_count"] != int64(0) {
+		t.Errorf("未收听时发送者看到的状态不正确: %v", state)
+	}
+
+	first, err := MarkVoiceListened(db, message, bob.ID)
+	if err != nil {
+		t.Fatalf("标记收听失败: %v", err)
+	}
+	listenedAt := first.ListenedAt - 60
+	db.Model(first).UpdateColumn("listened_at", listenedAt)
+	again, err := MarkVoiceListened(db, message, bob.ID)
+	if err != nil || again.ID != first.ID || again.ListenedAt != listenedAt {
+		t.Errorf("重复标记应返回首次收听的记录，得到 %+v %v", again, err)
+	}
+
+	if state := VoiceListenState(db, message, bob.ID); state["listened"] != true || state["listened_at"] != again.ListenedAt {
+		t.Errorf("接收者看到的状态不正确: %v", state)
+	}
+	if state := VoiceListenState(db, message, alice.ID); state["listened"] != true || state["listened_count"] != int64(1) {
+		t.Errorf("发送者看到的状态不正确: %v", state)
+	}
+
+	// 群聊只返回收听人数
+	message.GroupID = 1
+	if state := VoiceListenState(db, message, alice.ID); state["listened"] != nil || state["listened_count"] != int64(1) {
+		t.Errorf("群聊发送者看到的状态不正确: %v", state)
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"allinone_backend/utils"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// 钱包内部记账逻辑
+// 供购买、结算等业务在事务中调用，统一生成交易记录
+
+// getOrCreateWallet 查询用户钱包，不存在时创建
+func getOrCreateWallet(tx *gorm.DB, userID uint) (*models.Wallet, error) {
+	var wallet models.Wallet
+	if err := tx.Where("user_id = ?", userID).First(&wallet).Error; err == nil {
+		return &wallet, nil
+	}
+
+	now := time.Now().Unix()
+	wallet = models.Wallet{
+		UserID:    userID,
+		Balance:   0,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := tx.Create(&wallet).Error; err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// DebitWallet 从用户钱包扣款并记录交易
+func DebitWallet(tx *gorm.DB, userID uint, amount float64, txType string, relatedID uint, description string) (*models.Transaction, error) {
+	if amount <= 0 {
+		return nil, &utils.AppError{Code: http.StatusBadRequest, Message: "金额必须大于0", Key: "wallet.invalid_amount"}
+	}
+
+	wallet, err := getOrCreateWallet(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet.Balance < amount {
+		return nil, &utils.AppError{Code: http.StatusBadRequest, Message: "余额不足", Key: "wallet.insufficient_balance"}
+	}
+
+	now := time.Now().Unix()
+	// 以余额条件更新，防止并发扣款导致余额为负
+	result := tx.Model(&models.Wallet{}).
+		Where("id = ? AND balance >= ?", wallet.ID, amount).
+		Updates(map[string]any{"balance": gorm.Expr("balance - ?", amount), "updated_at": now})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, &utils.AppError{Code: http.StatusBadRequest, Message: "余额不足", Key: "wallet.insufficient_balance"}
+	}
+
+	transaction := models.Transaction{
+		UserID:      userID,
+		Amount:      -amount,
+		Balance:     wallet.Balance - amount,
+		Type:        txType,
+		RelatedID:   relatedID,
+		Description: description,
+		Status:      "success",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := tx.Create(&transaction).Error; err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// CreditWallet 向用户钱包入账并记录交易
+func CreditWallet(tx *gorm.DB, userID uint, amount float64, txType string, relatedID uint, description string) (*models.Transaction, error) {
+	if amount <= 0 {
+		return nil, &utils.AppError{Code: http.StatusBadRequest, Message: "金额必须大于0", Key: "wallet.invalid_amount"}
+	}
+
+	wallet, err := getOrCreateWallet(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	if err := tx.Model(&models.Wallet{}).Where("id = ?", wallet.ID).
+		Updates(map[string]any{"balance": gorm.Expr("balance + ?", amount), "updated_at": now}).Error; err != nil {
+		return nil, err
+	}
+
+	transaction := models.Transaction{
+		UserID:      userID,
+		Amount:      amount,
+		Balance:     wallet.Balance + amount,
+		Type:        txType,
+		RelatedID:   relatedID,
+		Description: description,
+		Status:      "success",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := tx.Create(&transaction).Error; err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// CheckPayPassword 校验用户的支付密码
+func CheckPayPassword(db *gorm.DB, userID uint, password string) error {
+	var wallet models.Wallet
+	if err := db.Where("user_id = ?", userID).First(&wallet).Error; err != nil || !wallet.PayPasswordSet {
+		return &utils.AppError{Code: http.StatusBadRequest, Message: "请先设置支付密码", Key: "wallet.pay_password_not_set"}
+	}
+	if bcrypt.CompareHashAndPassword([]byte(wallet.PayPassword), []byte(password)) != nil {
+		return &utils.AppError{Code: http.StatusBadRequest, Message: "支付密码错误", Key: "wallet.pay_password_invalid"}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"allinone_backend/models"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// fundTestWallet 创建余额为 balance 的钱包，payPassword 不为空时设置支付密码
+func fundTestWallet(t *testing.T, db *gorm.DB, userID uint, balance float64, payPassword string) {
+	t.Helper()
+	wallet := models.Wallet{UserID: userID, Balance: balance}
+	if payPassword != "" {
+		hash, _ := bcrypt.GenerateFromPassword([]byte(payPassword), bcrypt.MinCost)
+		wallet.PayPassword, wallet.PayPasswordSet = string(hash), true
+	}
+	if err := db.Create(&wallet).Error; err != nil {
+		t.Fatalf("创建钱包失败: %v", err)
+	}
+}
+
+// walletBalance 查询钱包余额，没有钱包时为0
+func walletBalance(db *gorm.DB, userID uint) float64 {
+	var wallet models.Wallet
+	db.Where("user_id = ?", userID).First(&wallet)
+	return wallet.Balance
+}
+
+func TestDebitWallet(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	fundTestWallet(t, db, alice.ID, 100, "")
+
+	tests := []struct {
+		name    string
+		userID  uint
+		amount  float64
+		key     string
+		balance float64
+	}{
+		{"金额为0", alice.ID, 0, "wallet.invalid_amount", 100},
+		{"金额为负", alice.ID, -10, "wallet.invalid_amount", 100},
+		{"余额不足", alice.ID, 100.01, "wallet.insufficient_balance", 100},
+		{"正常扣款", alice.ID, 30, "", 70},
+		{"扣完余额", alice.ID, 70, "", 0},
+		{"没有钱包", bob.ID, 1, "wallet.insufficient_balance", 0},
+	}
+	for _, tt := range tests {
+		transaction, err := DebitWallet(db, tt.userID, tt.amount, "game_purchase", 7, "测试扣款")
+		if appErrorKey(err) != tt.key {
+			t.Errorf("%s: 得到 %v，应为 %q", tt.name, err, tt.key)
+		}
+		if got := walletBalance(db, tt.userID); got != tt.balance {
+			t.Errorf("%s: 余额为 %.2f，应为 %.2f", tt.name, got, tt.balance)
+		}
+		if err == nil && (transaction.Amount != -tt.amount || transaction.Balance != tt.balance || transaction.RelatedID != 7 || transaction.Type != "game_purchase") {
+			t.Errorf("%s: 交易记录错误: %+v", tt.name, transaction)
+		}
+	}
+
+	var count int64
+	db.Model(&models.Transaction{}).Where("user_id = ?", alice.ID).Count(&count)
+	if count != 2 {
+		t.Errorf("应只记录成功的2笔交易，得到 %d 笔", count)
+	}
+}
+
+func TestCreditWalletAndPayPassword(t *testing.T) {
+	db := newTestDB(t)
+	alice := createTestUser(t, db, "alice")
+
+	// 没有钱包时自动创建
+	transaction, err := CreditWallet(db, alice.ID, 12.5, "game_income", 3, "测试入账")
+	if err != nil || transaction.Balance != 12.5 || transaction.Amount != 12.5 {
+		t.Fatalf("入账失败: %+v, %v", transaction, err)
+	}
+	if _, err := CreditWallet(db, alice.ID, 0, "game_income", 3, ""); appErrorKey(err) != "wallet.invalid_amount" {
+		t.Errorf("入账金额为0应报错，得到 %v", err)
+	}
+	if err := CheckPayPassword(db, alice.ID, "123456"); appErrorKey(err) != "wallet.pay_password_not_set" {
+		t.Errorf("未设置支付密码时应报错，得到 %v", err)
+	}
+
+	bob := createTestUser(t, db, "bob")
+	fundTestWallet(t, db, bob.ID, 0, "246810")
+	tests := []struct {
+		password string
+		key      string
+	}{
+		{"246810", ""},
+		{"246811", "wallet.pay_password_invalid"},
+		{"", "wallet.pay_password_invalid"},
+	}
+	for _, tt := range tests {
+		if err := CheckPayPassword(db, bob.ID, tt.password); appErrorKey(err) != tt.key {
+			t.Errorf("支付密码 %q: 得到 %v，应为 %q", tt.password, err, tt.key)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HuggingFaceProvider Hugging Face Inference API
+// 该接口只接受拼接后的对话文本，不支持多轮消息结构
+type HuggingFaceProvider struct {
+	token        string
+	defaultModel string
+	baseURL      string
+	client       *http.Client
+}
+
+// NewHuggingFaceProvider 创建Hugging Face提供方
+func NewHuggingFaceProvider(token, defaultModel string) *HuggingFaceProvider {
+	return &HuggingFaceProvider{
+		token:        token,
+		defaultModel: defaultModel,
+		baseURL:      "https://api-inference.huggingface.co/models/",
+		client:       &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (p *HuggingFaceProvider) Name() string {
+	return "huggingface"
+}
+
+func (p *HuggingFaceProvider) Chat(ctx context.Context, req AIChatRequest) (*AIChatResult, error) {
+	// Hugging Face模型名称格式为 组织/模型，其他格式（如 gpt-3.5-turbo）使用默认模型
+	model := req.Model
+	if !strings.Contains(model, "/") {
+		model = p.defaultModel
+	}
+
+	// 构建对话文本
+	var conversation string
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case "user":
+			conversation += "User: " + msg.Content + "\n"
+		case "assistant":
+			conversation += "Assistant: " + msg.Content + "\n"
+		case "system":
+			// 系统消息作为指令
+			conversation = "Instructions: " + msg.Content + "\n" + conversation
+		}
+	}
+	conversation += "Assistant: "
+
+	maxLength := req.MaxTokens
+	if maxLength <= 0 {
+		maxLength = 1000
+	}
+	requestData := map[string]interface{}{
+		"inputs": conversation,
+		"parameters": map[string]interface{}{
+			"temperature":      req.Temperature,
+			"max_length":       maxLength,
+			"return_full_text": false,
+		},
+	}
+	jsonData, err := json.Marshal(requestData)
+	if err != nil {
+		return nil, &AIError{Provider: p.Name(), Kind: ErrAIBadRequest, Message: err.Error()}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+model, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, &AIError{Provider: p.Name(), Kind: ErrAIBadRequest, Message: err.Error()}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.token)
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, &AIError{Provider: p.Name(), Kind: ErrAIUnavailable, Message: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &AIError{Provider: p.Name(), Kind: ErrAIUnavailable, Message: err.Error()}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAIHTTPError(p.Name(), resp.StatusCode, string(body))
+	}
+
+	// 响应可能是数组或单个对象
+	var generatedText string
+	var list []map[string]interface{}
+	if err := json.Unmarshal(body, &list); err == nil {
+		if len(list) > 0 {
+			generatedText, _ = list[0]["generated_text"].(string)
+		}
+	} else {
+		var single map[string]interface{}
+		if err := json.Unmarshal(body, &single); err == nil {
+			generatedText, _ = single["generated_text"].(string)
+		}
+	}
+
+	// 部分模型会返回完整对话，只保留最后一段回复
+	parts := strings.Split(generatedText, "Assistant: ")
+	generatedText = strings.TrimSpace(parts[len(parts)-1])
+	if generatedText == "" {
+		return nil, &AIError{Provider: p.Name(), Kind: ErrAIEmptyResponse}
+	}
+
+	return &AIChatResult{Provider: p.Name(), Model: model, Content: generatedText}, nil
+}
@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// 模拟流式输出时每段的字符数
+const mockStreamChunk = 4
+
+// MockAIProvider 确定性的模拟提供方，用于本地开发和测试
+// 默认回复为最后一条用户消息的复述，可通过 Reply 自定义；
+// 请求带有工具时，形如 "/tool 工具名 {参数JSON}" 的用户消息会转换为工具调用，
+// 收到工具结果后回复结果内容
+type MockAIProvider struct {
+	Reply func(req AIChatRequest) (string, error)
+}
+
+// NewMockAIProvider 创建模拟提供方
+func NewMockAIProvider() *MockAIProvider {
+	return &MockAIProvider{}
+}
+
+func (p *MockAIProvider) Name() string {
+	return "mock"
+}
+
+func (p *MockAIProvider) Chat(ctx context.Context, req AIChatRequest) (*AIChatResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if p.Reply == nil && len(req.Tools) > 0 {
+		if result := p.mockToolTurn(req); result != nil {
+			return result, nil
+		}
+	}
+
+	var content string
+	if p.Reply != nil {
+		reply, err := p.Reply(req)
+		if err != nil {
+			return nil, err
+		}
+		content = reply
+	} else {
+		last := ""
+		for i := len(req.Messages) - 1; i >= 0; i-- {
+			if req.Messages[i].Role == "user" {
+				last = req.Messages[i].Content
+				break
+			}
+		}
+		content = "模拟回复：" + last
+	}
+
+	model := req.Model
+	if model == "" {
+		model = "mock"
+	}
+
+	// 以字符数近似token数
+	prompt := 0
+	for _, msg := range req.Messages {
+		prompt += utf8.RuneCountInString(msg.Content)
+	}
+	completion := utf8.RuneCountInString(content)
+
+	return &AIChatResult{
+		Provider: p.Name(),
+		Model:    model,
+		Content:  content,
+		Usage: AIUsage{
+			PromptTokens:     prompt,
+			CompletionTokens: completion,
+			TotalTokens:      prompt + completion,
+		},
+	}, nil
+}
+
+// CountTokens 模拟提供方按字符数计数，与其返回的用量一致
+func (p *MockAIProvider) CountTokens(messages []AIMessage) int {
+	total := 0
+	for _, msg := range messages {
+		total += utf8.RuneCountInString(msg.Content)
+	}
+	return total
+}
+
+// ChatStream 将模拟回复按固定长度分段输出
+func (p *MockAIProvider) ChatStream(ctx context.Context, req AIChatRequest, onDelta func(delta string) error) (*AIChatResult, error) {
+	result, err := p.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	runes := []rune(result.Content)
+	for i := 0; i < len(runes); i += mockStreamChunk {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		end := i + mockStreamChunk
+		if end > len(runes) {
+			end = len(runes)
+		}
+		if err := onDelta(string(runes[i:end])); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// mockToolTurn 处理工具调用相关的模拟回复，不涉及工具时返回 nil
+func (p *MockAIProvider) mockToolTurn(req AIChatRequest) *AIChatResult {
+	if len(req.Messages) == 0 {
+		return nil
+	}
+	last := req.Messages[len(req.Messages)-1]
+
+	switch {
+	case last.Role == "user" && strings.HasPrefix(last.Content, "/tool "):
+		fields := strings.SplitN(strings.TrimSpace(strings.TrimPrefix(last.Content, "/tool ")), " ", 2)
+		arguments := "{}"
+		if len(fields) == 2 {
+			arguments = fields[1]
+		}
+		return &AIChatResult{
+			Provider: p.Name(),
+			Model:    "mock",
+			ToolCalls: []AIToolCall{{
+				ID:       fmt.Sprintf("call_%d", len(req.Messages)),
+				Type:     "function",
+				Function: AIFunctionCall{Name: fields[0], Arguments: arguments},
+			}},
+		}
+	case last.Role == "tool":
+		// 汇总最后一轮的全部工具结果
+		var results []string
+		for i := len(req.Messages) - 1; i >= 0 && req.Messages[i].Role == "tool"; i-- {
+			results = append([]string{req.Messages[i].Content}, results...)
+		}
+		content := "工具结果：" + strings.Join(results, "\n")
+		return &AIChatResult{
+			Provider: p.Name(),
+			Model:    "mock",
+			Content:  content,
+			Usage:    AIUsage{CompletionTokens: utf8.RuneCountInString(content), TotalTokens: utf8.RuneCountInString(content)},
+		}
+	}
+	return nil
+}
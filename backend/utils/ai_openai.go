@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIProvider OpenAI兼容的 chat/completions 接口
+// 同样适用于 llama.cpp server、Ollama 等自建服务
+type OpenAIProvider struct {
+	name         string
+	baseURL      string
+	apiKey       string
+	defaultModel string
+	client       *http.Client
+	// 流式请求的耗时取决于生成长度，只依赖 context 取消
+	streamClient *http.Client
+}
+
+// NewOpenAIProvider 创建OpenAI兼容提供方，apiKey 为空时不发送认证头
+func NewOpenAIProvider(name, baseURL, apiKey, defaultModel string) *OpenAIProvider {
+	return &OpenAIProvider{
+		name:         name,
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		apiKey:       apiKey,
+		defaultModel: defaultModel,
+		client:       &http.Client{Timeout: 60 * time.Second},
+		streamClient: &http.Client{},
+	}
+}
+
+func (p *OpenAIProvider) Name() string {
+	return p.name
+}
+
+func (p *OpenAIProvider) Chat(ctx context.Context, req AIChatRequest) (*AIChatResult, error) {
+	if req.Model == "" {
+		req.Model = p.defaultModel
+	}
+	req.Stream = false
+
+	resp, err := p.post(ctx, p.client, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &AIError{Provider: p.name, Kind: ErrAIUnavailable, Message: err.Error()}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAIHTTPError(p.name, resp.StatusCode, string(body))
+	}
+
+	var response AIChatResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, &AIError{Provider: p.name, Kind: ErrAIEmptyResponse, Message: "解析响应失败"}
+	}
+	if len(response.Choices) == 0 {
+		return nil, &AIError{Provider: p.name, Kind: ErrAIEmptyResponse}
+	}
+	message := response.Choices[0].Message
+	if strings.TrimSpace(message.Content) == "" && len(message.ToolCalls) == 0 {
+		return nil, &AIError{Provider: p.name, Kind: ErrAIEmptyResponse}
+	}
+
+	return &AIChatResult{
+		Provider:  p.name,
+		Model:     req.Model,
+		Content:   message.Content,
+		Usage:     response.Usage,
+		ToolCalls: message.ToolCalls,
+	}, nil
+}
+
+// ChatStream 使用 stream 模式请求，按SSE数据块回调增量文本
+func (p *OpenAIProvider) ChatStream(ctx context.Context, req AIChatRequest, onDelta func(delta string) error) (*AIChatResult, error) {
+	if req.Model == "" {
+		req.Model = p.defaultModel
+	}
+	req.Stream = true
+
+	resp, err := p.post(ctx, p.streamClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newAIHTTPError(p.name, resp.StatusCode, string(body))
+	}
+
+	result := &AIChatResult{Provider: p.name, Model: req.Model}
+	var content strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta AIMessage `json:"delta"`
+			} `json:"choices"`
+			Usage *AIUsage `json:"usage"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+		if chunk.Usage != nil {
+			result.Usage = *chunk.Usage
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		delta := chunk.Choices[0].Delta.Content
+		content.WriteString(delta)
+		if err := onDelta(delta); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &AIError{Provider: p.name, Kind: ErrAIUnavailable, Message: err.Error()}
+	}
+	if strings.TrimSpace(content.String()) == "" {
+		return nil, &AIError{Provider: p.name, Kind: ErrAIEmptyResponse}
+	}
+
+	result.Content = content.String()
+	return result, nil
+}
+
+// post 发送 chat/completions 请求
+func (p *OpenAIProvider) post(ctx context.Context, client *http.Client, req AIChatRequest) (*http.Response, error) {
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return nil, &AIError{Provider: p.name, Kind: ErrAIBadRequest, Message: err.Error()}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, &AIError{Provider: p.name, Kind: ErrAIBadRequest, Message: err.Error()}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &AIError{Provider: p.name, Kind: ErrAIUnavailable, Message: err.Error()}
+	}
+	return resp, nil
+}
@@ -0,0 +1,320 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// AI服务提供方抽象
+// 所有AI调用通过 AIProvider 完成，按配置的顺序组成回退链，
+// 用户可在 AISettings 中指定优先使用的提供方
+
+// AIProvider AI聊天服务提供方
+type AIProvider interface {
+	// Name 提供方名称，用于配置和用户设置
+	Name() string
+	// Chat 发送一次对话请求，req.Model 为空时使用提供方默认模型
+	Chat(ctx context.Context, req AIChatRequest) (*AIChatResult, error)
+}
+
+// AIChatResult 一次AI对话的结果
+type AIChatResult struct {
+	Provider string  `json:"provider"`
+	Model    string  `json:"model"`
+	Content  string  `json:"content"`
+	Usage    AIUsage `json:"usage"`
+	// 模型请求的工具调用，非空时 Content 可能为空
+	ToolCalls []AIToolCall `json:"tool_calls,omitempty"`
+}
+
+// AI调用错误类型，可通过 errors.Is 判断
+var (
+	ErrAIUnavailable   = errors.New("AI服务不可用")
+	ErrAIRateLimited   = errors.New("AI服务请求过于频繁")
+	ErrAIUnauthorized  = errors.New("AI服务认证失败")
+	ErrAIBadRequest    = errors.New("AI请求参数错误")
+	ErrAIModelLoading  = errors.New("AI模型加载中")
+	ErrAIEmptyResponse = errors.New("AI没有返回有效内容")
+	ErrAINoProvider    = errors.New("没有可用的AI服务")
+)
+
+// AIError 带提供方信息的AI调用错误
+type AIError struct {
+	Provider   string
+	Kind       error // 上面定义的错误类型之一
+	StatusCode int   // 上游HTTP状态码，非HTTP错误为0
+	Message    string
+}
+
+func (e *AIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s: %v", e.Provider, e.Kind)
+	}
+	return fmt.Sprintf("%s: %v: %s", e.Provider, e.Kind, e.Message)
+}
+
+func (e *AIError) Unwrap() error {
+	return e.Kind
+}
+
+// newAIHTTPError 根据上游HTTP状态码构造错误
+func newAIHTTPError(provider string, statusCode int, body string) *AIError {
+	kind := ErrAIUnavailable
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		kind = ErrAIRateLimited
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		kind = ErrAIUnauthorized
+	case statusCode == http.StatusServiceUnavailable && strings.Contains(body, "loading"):
+		kind = ErrAIModelLoading
+	case statusCode >= 400 && statusCode < 500:
+		kind = ErrAIBadRequest
+	}
+	if len(body) > 200 {
+		body = body[:200]
+	}
+	return &AIError{Provider: provider, Kind: kind, StatusCode: statusCode, Message: body}
+}
+
+// AI提供方配置
+type AIConfig struct {
+	// 回退链，按顺序尝试
+	Chain []string
+
+	OpenAIBaseURL string
+	OpenAIAPIKey  string
+	OpenAIModel   string
+
+	// 自建的OpenAI兼容服务，如 llama.cpp server、Ollama
+	LocalBaseURL string
+	LocalModel   string
+
+	HuggingFaceToken string
+	HuggingFaceModel string
+}
+
+var (
+	aiProvidersMu sync.RWMutex
+	aiProviders   = map[string]AIProvider{}
+	aiChain       []string
+)
+
+// 初始化函数，从环境变量加载AI配置
+func init() {
+	config := AIConfig{
+		OpenAIBaseURL:    envOrDefault("AI_OPENAI_BASE_URL", "https://api.openai.com/v1"),
+		OpenAIAPIKey:     os.Getenv("AI_OPENAI_API_KEY"),
+		OpenAIModel:      envOrDefault("AI_OPENAI_MODEL", "gpt-3.5-turbo"),
+		LocalBaseURL:     os.Getenv("AI_LOCAL_BASE_URL"),
+		LocalModel:       envOrDefault("AI_LOCAL_MODEL", "llama3"),
+		HuggingFaceToken: os.Getenv("AI_HF_TOKEN"),
+		HuggingFaceModel: envOrDefault("AI_HF_MODEL", "facebook/blenderbot-400M-distill"),
+	}
+	if chain := os.Getenv("AI_PROVIDERS"); chain != "" {
+		for _, name := range strings.Split(chain, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				config.Chain = append(config.Chain, name)
+			}
+		}
+	}
+	SetAIConfig(config)
+}
+
+// SetAIConfig 根据配置重新注册提供方和回退链
+// 未显式配置回退链时，按已配置凭据的提供方依次回退；都未配置时回退链为空，调用返回 ErrAINoProvider
+// 模拟提供方只在回退链中显式指定 mock 时注册，用于本地开发和测试
+func SetAIConfig(config AIConfig) {
+	providers := map[string]AIProvider{}
+	var chain []string
+	if config.OpenAIAPIKey != "" {
+		providers["openai"] = NewOpenAIProvider("openai", config.OpenAIBaseURL, config.OpenAIAPIKey, config.OpenAIModel)
+		chain = append(chain, "openai")
+	}
+	if config.LocalBaseURL != "" {
+		providers["local"] = NewOpenAIProvider("local", config.LocalBaseURL, "", config.LocalModel)
+		chain = append(chain, "local")
+	}
+	if config.HuggingFaceToken != "" {
+		providers["huggingface"] = NewHuggingFaceProvider(config.HuggingFaceToken, config.HuggingFaceModel)
+		chain = append(chain, "huggingface")
+	}
+	if len(config.Chain) > 0 {
+		chain = config.Chain
+	}
+	for _, name := range chain {
+		if name == "mock" {
+			providers["mock"] = NewMockAIProvider()
+		}
+	}
+
+	aiProvidersMu.Lock()
+	aiProviders = providers
+	aiChain = chain
+	aiProvidersMu.Unlock()
+}
+
+// RegisterAIProvider 注册或替换提供方，可用于接入其他实现
+func RegisterAIProvider(provider AIProvider) {
+	aiProvidersMu.Lock()
+	defer aiProvidersMu.Unlock()
+	aiProviders[provider.Name()] = provider
+}
+
+// SetAIProviderChain 设置默认回退链
+func SetAIProviderChain(names []string) {
+	aiProvidersMu.Lock()
+	defer aiProvidersMu.Unlock()
+	aiChain = append([]string{}, names...)
+}
+
+// ListAIProviders 返回可用提供方名称，默认回退链中的排在前面
+func ListAIProviders() []string {
+	aiProvidersMu.RLock()
+	defer aiProvidersMu.RUnlock()
+
+	names := []string{}
+	seen := map[string]bool{}
+	for _, name := range aiChain {
+		if _, ok := aiProviders[name]; ok && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for name := range aiProviders {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// HasAIProvider 判断提供方是否已注册
+func HasAIProvider(name string) bool {
+	aiProvidersMu.RLock()
+	defer aiProvidersMu.RUnlock()
+	_, ok := aiProviders[name]
+	return ok
+}
+
+// resolveAIChain 组合用户偏好和默认回退链
+func resolveAIChain(preferred string) []AIProvider {
+	aiProvidersMu.RLock()
+	defer aiProvidersMu.RUnlock()
+
+	names := aiChain
+	if preferred != "" {
+		names = append([]string{preferred}, aiChain...)
+	}
+
+	providers := []AIProvider{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		if p, ok := aiProviders[name]; ok && !seen[name] {
+			seen[name] = true
+			providers = append(providers, p)
+		}
+	}
+	return providers
+}
+
+// ChatWithProviders 按回退链依次调用提供方，直到成功
+// req.Model 只对用户指定的提供方生效，未指定提供方或回退时使用各提供方的默认模型
+func ChatWithProviders(ctx context.Context, preferred string, req AIChatRequest) (*AIChatResult, error) {
+	providers := resolveAIChain(preferred)
+	if len(providers) == 0 {
+		return nil, &AIError{Provider: preferred, Kind: ErrAINoProvider}
+	}
+
+	var lastErr error
+	for i, provider := range providers {
+		attempt := req
+		if i > 0 || provider.Name() != preferred {
+			attempt.Model = ""
+		}
+		result, err := provider.Chat(ctx, attempt)
+		if err == nil {
+			fillAIUsage(provider, attempt, result)
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		Logger.Errorf("AI提供方调用失败，尝试下一个: %v", err)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// envOrDefault 读取环境变量，未设置时返回默认值
+func envOrDefault(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// AIStreamProvider 支持流式输出的提供方
+type AIStreamProvider interface {
+	AIProvider
+	// ChatStream 逐段回调生成的文本，返回完整结果
+	ChatStream(ctx context.Context, req AIChatRequest, onDelta func(delta string) error) (*AIChatResult, error)
+}
+
+// ChatStreamWithProviders 按回退链进行流式对话
+// 不支持流式的提供方在完成后一次性回调全部文本；已输出内容后出错不再回退
+// 已输出内容后出错或被取消时，同时返回已输出部分的结果，用于记录已消耗的用量
+func ChatStreamWithProviders(ctx context.Context, preferred string, req AIChatRequest, onDelta func(delta string) error) (*AIChatResult, error) {
+	providers := resolveAIChain(preferred)
+	if len(providers) == 0 {
+		return nil, &AIError{Provider: preferred, Kind: ErrAINoProvider}
+	}
+
+	var lastErr error
+	for i, provider := range providers {
+		attempt := req
+		if i > 0 || provider.Name() != preferred {
+			attempt.Model = ""
+		}
+
+		var emitted strings.Builder
+		emit := func(delta string) error {
+			if delta == "" {
+				return nil
+			}
+			emitted.WriteString(delta)
+			return onDelta(delta)
+		}
+		partial := func() *AIChatResult {
+			result := &AIChatResult{Provider: provider.Name(), Model: attempt.Model, Content: emitted.String()}
+			fillAIUsage(provider, attempt, result)
+			return result
+		}
+
+		var result *AIChatResult
+		var err error
+		if sp, ok := provider.(AIStreamProvider); ok {
+			result, err = sp.ChatStream(ctx, attempt, emit)
+		} else if result, err = provider.Chat(ctx, attempt); err == nil {
+			err = emit(result.Content)
+		}
+		if err == nil {
+			fillAIUsage(provider, attempt, result)
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			// 请求已发给提供方，取消前的提示词和已输出的内容同样计入用量
+			return partial(), ctx.Err()
+		}
+		if emitted.Len() > 0 {
+			return partial(), err
+		}
+		Logger.Errorf("AI提供方调用失败，尝试下一个: %v", err)
+		lastErr = err
+	}
+	return nil, lastErr
+}
@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// fakeAIProvider 记录收到的模型名，按设置返回错误或固定回复
+type fakeAIProvider struct {
+	name   string
+	err    error
+	models []string
+}
+
+func (p *fakeAIProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeAIProvider) Chat(ctx context.Context, req AIChatRequest) (*AIChatResult, error) {
+	p.models = append(p.models, req.Model)
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &AIChatResult{Provider: p.name, Model: req.Model, Content: "reply from " + p.name}, nil
+}
+
+// useFakeAIProviders 注册测试用的提供方和回退链，测试结束后恢复
+func useFakeAIProviders(t *testing.T, chain []string, providers ...*fakeAIProvider) {
+	t.Helper()
+	aiProvidersMu.Lock()
+	previousProviders, previousChain := aiProviders, aiChain
+	aiProviders = map[string]AIProvider{}
+	aiChain = chain
+	for _, p := range providers {
+		aiProviders[p.name] = p
+	}
+	aiProvidersMu.Unlock()
+	t.Cleanup(func() {
+		aiProvidersMu.Lock()
+		aiProviders, aiChain = previousProviders, previousChain
+		aiProvidersMu.Unlock()
+	})
+}
+
+func TestChatWithProvidersModelOnlyForPreferred(t *testing.T) {
+	tests := []struct {
+		name      string
+		preferred string
+		firstErr  error
+		wantFirst []string // first 收到的模型名
+		wantNext  []string // next 收到的模型名
+	}{
+		{"未指定提供方时不传模型", "", nil, []string{""}, nil},
+		{"指定的提供方使用模型", "first", nil, []string{"custom-model"}, nil},
+		{"指定其他提供方时不传给回退链", "next", nil, nil, []string{"custom-model"}},
+		{"回退时使用默认模型", "first", &AIError{Provider: "first", Kind: ErrAIUnavailable}, []string{"custom-model"}, []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := &fakeAIProvider{name: "first", err: tt.firstErr}
+			next := &fakeAIProvider{name: "next"}
+			useFakeAIProviders(t, []string{"first", "next"}, first, next)
+
+			result, err := ChatWithProviders(context.Background(), tt.preferred, AIChatRequest{
+				Model:    "custom-model",
+				Messages: []AIMessage{{Role: "user", Content: "hi"}},
+			})
+			if err != nil {
+				t.Fatalf("ChatWithProviders 返回错误: %v", err)
+			}
+			if result.Usage.TotalTokens == 0 {
+				t.Error("没有估算token用量")
+			}
+			if !equalStrings(first.models, tt.wantFirst) || !equalStrings(next.models, tt.wantNext) {
+				t.Errorf("收到的模型 first=%q next=%q，应为 %q %q", first.models, next.models, tt.wantFirst, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestChatWithProvidersAllFail(t *testing.T) {
+	useFakeAIProviders(t, []string{"a", "b"},
+		&fakeAIProvider{name: "a", err: &AIError{Provider: "a", Kind: ErrAIRateLimited}},
+		&fakeAIProvider{name: "b", err: &AIError{Provider: "b", Kind: ErrAIUnauthorized}})
+
+	_, err := ChatWithProviders(context.Background(), "", AIChatRequest{})
+	if !errors.Is(err, ErrAIUnauthorized) {
+		t.Errorf("应返回最后一个提供方的错误，实际为 %v", err)
+	}
+
+	useFakeAIProviders(t, nil)
+	if _, err := ChatWithProviders(context.Background(), "", AIChatRequest{}); !errors.Is(err, ErrAINoProvider) {
+		t.Errorf("没有提供方时应返回 ErrAINoProvider，实际为 %v", err)
+	}
+}
+
+func TestSetAIConfigNoFallbackToMock(t *testing.T) {
+	useFakeAIProviders(t, nil)
+
+	// 未配置任何提供方时不回退到模拟回复
+	SetAIConfig(AIConfig{})
+	if HasAIProvider("mock") {
+		t.Error("未配置时不应注册模拟提供方")
+	}
+	if _, err := ChatWithProviders(context.Background(), "mock", AIChatRequest{}); !errors.Is(err, ErrAINoProvider) {
+		t.Errorf("未配置提供方时应返回 ErrAINoProvider，实际为 %v", err)
+	}
+
+	SetAIConfig(AIConfig{Chain: []string{"mock"}})
+	result, err := ChatWithProviders(context.Background(), "", AIChatRequest{Messages: []AIMessage{{Role: "user", Content: "hi"}}})
+	if err != nil || result.Provider != "mock" {
+		t.Errorf("显式配置 mock 时应使用模拟提供方，得到 %+v, %v", result, err)
+	}
+}
+
+// failingStreamProvider 输出一段文本后返回错误
+type failingStreamProvider struct {
+	fakeAIProvider
+}
+
+func (p *failingStreamProvider) ChatStream(ctx context.Context, req AIChatRequest, onDelta func(delta string) error) (*AIChatResult, error) {
+	if err := onDelta("partial"); err != nil {
+		return nil, err
+	}
+	return nil, p.err
+}
+
+func TestChatStreamWithProvidersPartialUsage(t *testing.T) {
+	failing := &failingStreamProvider{fakeAIProvider{name: "failing", err: &AIError{Provider: "failing", Kind: ErrAIUnavailable}}}
+	aiProvidersMu.Lock()
+	previousProviders, previousChain := aiProviders, aiChain
+	aiProviders, aiChain = map[string]AIProvider{"failing": failing}, []string{"failing"}
+	aiProvidersMu.Unlock()
+	t.Cleanup(func() {
+		aiProvidersMu.Lock()
+		aiProviders, aiChain = previousProviders, previousChain
+		aiProvidersMu.Unlock()
+	})
+	req := AIChatRequest{Messages: []AIMessage{{Role: "user", Content: "hi"}}}
+
+	// 输出后出错：返回错误和已输出部分的用量
+	result, err := ChatStreamWithProviders(context.Background(), "", req, func(string) error { return nil })
+	if !errors.Is(err, ErrAIUnavailable) {
+		t.Fatalf("应返回提供方的错误，实际为 %v", err)
+	}
+	if result == nil || result.Content != "partial" || result.Usage.PromptTokens == 0 || result.Usage.CompletionTokens == 0 {
+		t.Errorf("出错时应返回已输出部分的用量，得到 %+v", result)
+	}
+
+	// 输出时被取消
+	ctx, cancel := context.WithCancel(context.Background())
+	result, err = ChatStreamWithProviders(ctx, "", req, func(string) error {
+		cancel()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) || result == nil || result.Usage.TotalTokens == 0 {
+		t.Errorf("取消时应返回已消耗的用量，得到 %+v, %v", result, err)
+	}
+}
+
+func TestNewAIHTTPError(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		kind   error
+	}{
+		{http.StatusTooManyRequests, "", ErrAIRateLimited},
+		{http.StatusUnauthorized, "", ErrAIUnauthorized},
+		{http.StatusForbidden, "", ErrAIUnauthorized},
+		{http.StatusServiceUnavailable, "model is loading", ErrAIModelLoading},
+		{http.StatusServiceUnavailable, "", ErrAIUnavailable},
+		{http.StatusBadRequest, "", ErrAIBadRequest},
+		{http.StatusInternalServerError, "", ErrAIUnavailable},
+	}
+	for _, tt := range tests {
+		if err := newAIHTTPError("p", tt.status, tt.body); !errors.Is(err, tt.kind) {
+			t.Errorf("状态码 %d 的错误类型为 %v，应为 %v", tt.status, err.Kind, tt.kind)
+		}
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"unicode"
+)
+
+// AITokenCounter 提供方自带的token计数方式
+// 未实现的提供方使用 EstimateAITokens 估算
+type AITokenCounter interface {
+	CountTokens(messages []AIMessage) int
+}
+
+// 每条消息的格式开销，与OpenAI聊天格式的计数方式一致
+const aiTokensPerMessage = 4
+
+// CountAITokens 按提供方计算消息的token数，provider 为空时使用回退链中的第一个
+func CountAITokens(provider string, messages []AIMessage) int {
+	providers := resolveAIChain(provider)
+	if len(providers) > 0 {
+		if counter, ok := providers[0].(AITokenCounter); ok {
+			return counter.CountTokens(messages)
+		}
+	}
+	return EstimateAITokens(messages)
+}
+
+// EstimateAITokens 估算token数：中日韩文字每字约1个token，其他文字约4个字符1个token
+func EstimateAITokens(messages []AIMessage) int {
+	total := 0
+	for _, msg := range messages {
+		total += aiTokensPerMessage + estimateTextTokens(msg.Content)
+		for _, call := range msg.ToolCalls {
+			total += estimateTextTokens(call.Function.Name) + estimateTextTokens(call.Function.Arguments)
+		}
+	}
+	return total
+}
+
+func estimateTextTokens(text string) int {
+	tokens, others := 0, 0
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r) {
+			tokens++
+		} else {
+			others++
+		}
+	}
+	return tokens + (others+3)/4
+}
+
+// fillAIUsage 提供方未返回用量时（如部分流式接口）按请求和回复估算
+func fillAIUsage(provider AIProvider, req AIChatRequest, result *AIChatResult) {
+	if result.Usage.TotalTokens > 0 {
+		return
+	}
+	count := EstimateAITokens
+	if counter, ok := provider.(AITokenCounter); ok {
+		count = counter.CountTokens
+	}
+	result.Usage.PromptTokens = count(req.Messages)
+	reply := AIMessage{Role: "assistant", Content: result.Content, ToolCalls: result.ToolCalls}
+	result.Usage.CompletionTokens = count([]AIMessage{reply}) - count([]AIMessage{{Role: "assistant"}})
+	result.Usage.TotalTokens = result.Usage.PromptTokens + result.Usage.CompletionTokens
+}
@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// AI工具调用相关类型，格式与OpenAI function calling一致
+
+// AIToolSpec 提供给模型的工具声明
+type AIToolSpec struct {
+	Type     string         `json:"type"` // 固定为 function
+	Function AIFunctionSpec `json:"function"`
+}
+
+// AIFunctionSpec 工具的名称、说明和参数结构
+type AIFunctionSpec struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Parameters  AIToolSchema `json:"parameters"`
+}
+
+// AIToolCall 模型发起的一次工具调用
+type AIToolCall struct {
+	ID       string         `json:"id"`
+	Type     string         `json:"type"`
+	Function AIFunctionCall `json:"function"`
+}
+
+// AIFunctionCall 调用的工具名称和JSON编码的参数
+type AIFunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// AIToolSchema 工具参数结构，支持JSON Schema的一个子集：
+// 顶层为 object，属性为 string/integer/number/boolean，不允许未声明的属性
+type AIToolSchema struct {
+	Type                 string                    `json:"type"`
+	Properties           map[string]AIToolProperty `json:"properties"`
+	Required             []string                  `json:"required,omitempty"`
+	AdditionalProperties bool                      `json:"additionalProperties"`
+}
+
+// AIToolProperty 单个参数的约束
+type AIToolProperty struct {
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+	Minimum     *float64 `json:"minimum,omitempty"`
+	Maximum     *float64 `json:"maximum,omitempty"`
+	MaxLength   int      `json:"maxLength,omitempty"`
+}
+
+// ValidateToolArguments 按参数结构解析并校验模型给出的参数
+// integer 类型的参数转换为 int64，number 为 float64
+func ValidateToolArguments(schema AIToolSchema, raw string) (map[string]interface{}, error) {
+	args := map[string]interface{}{}
+	if strings.TrimSpace(raw) != "" {
+		if err := json.Unmarshal([]byte(raw), &args); err != nil {
+			return nil, fmt.Errorf("参数不是有效的JSON对象")
+		}
+	}
+
+	for _, name := range schema.Required {
+		if _, ok := args[name]; !ok {
+			return nil, fmt.Errorf("缺少参数 %s", name)
+		}
+	}
+
+	// 按名称排序，保证错误信息稳定
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prop, ok := schema.Properties[name]
+		if !ok {
+			if schema.AdditionalProperties {
+				continue
+			}
+			return nil, fmt.Errorf("不支持的参数 %s", name)
+		}
+		value, err := validateToolProperty(name, prop, args[name])
+		if err != nil {
+			return nil, err
+		}
+		args[name] = value
+	}
+	return args, nil
+}
+
+// validateToolProperty 校验单个参数并转换为对应的Go类型
+func validateToolProperty(name string, prop AIToolProperty, value interface{}) (interface{}, error) {
+	switch prop.Type {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("参数 %s 应为字符串", name)
+		}
+		if prop.MaxLength > 0 && utf8.RuneCountInString(s) > prop.MaxLength {
+			return nil, fmt.Errorf("参数 %s 长度不能超过 %d", name, prop.MaxLength)
+		}
+		if len(prop.Enum) > 0 {
+			for _, option := range prop.Enum {
+				if s == option {
+					return s, nil
+				}
+			}
+			return nil, fmt.Errorf("参数 %s 只能是 %s", name, strings.Join(prop.Enum, "、"))
+		}
+		return s, nil
+	case "integer", "number":
+		f, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("参数 %s 应为数字", name)
+		}
+		if prop.Type == "integer" && f != math.Trunc(f) {
+			return nil, fmt.Errorf("参数 %s 应为整数", name)
+		}
+		if prop.Minimum != nil && f < *prop.Minimum {
+			return nil, fmt.Errorf("参数 %s 不能小于 %v", name, *prop.Minimum)
+		}
+		if prop.Maximum != nil && f > *prop.Maximum {
+			return nil, fmt.Errorf("参数 %s 不能大于 %v", name, *prop.Maximum)
+		}
+		if prop.Type == "integer" {
+			return int64(f), nil
+		}
+		return f, nil
+	case "boolean":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("参数 %s 应为布尔值", name)
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("参数 %s 的类型 %s 不受支持", name, prop.Type)
+	}
+}
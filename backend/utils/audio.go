@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 音频格式处理：语音识别前统一转换为16kHz单声道16位PCM的WAV
+
+// 语音识别使用的采样率
+const speechSampleRate = 16000
+
+// SupportedAudioFormats 支持上传和识别的音频格式，除WAV外都需要ffmpeg转换
+var SupportedAudioFormats = map[string]bool{
+	"wav":  true,
+	"mp3":  true,
+	"m4a":  true,
+	"aac":  true,
+	"amr":  true,
+	"webm": true,
+	"ogg":  true,
+	"opus": true,
+}
+
+// IsSupportedAudioFormat 根据文件扩展名或格式名判断是否支持
+func IsSupportedAudioFormat(format string) bool {
+	format = strings.ToLower(strings.TrimPrefix(filepath.Ext("."+format), "."))
+	return SupportedAudioFormats[format]
+}
+
+// wavInfo WAV文件头中的格式信息
+type wavInfo struct {
+	AudioFormat   uint16 // 1 为PCM
+	Channels      uint16
+	SampleRate    uint32
+	ByteRate      uint32
+	BitsPerSample uint16
+	DataSize      uint32
+}
+
+// NormalizeAudio 将音频转换为16kHz单声道PCM的WAV文件
+// 已经是目标格式时直接返回原文件；否则通过ffmpeg转换到临时文件，调用方处理完后调用 cleanup 删除
+func NormalizeAudio(ctx context.Context, ffmpegPath, audioPath string) (string, func(), error) {
+	noop := func() {}
+	if info, err := readWavInfo(audioPath); err == nil && isSpeechWav(info) {
+		return audioPath, noop, nil
+	}
+
+	if _, err := exec.LookPath(ffmpegPath); err != nil {
+		return "", noop, &TranscribeError{Provider: "ffmpeg", Kind: ErrTranscribeUnavailable, Message: "未安装ffmpeg，无法转换音频格式"}
+	}
+
+	tempFile, err := os.CreateTemp("", "speech-*.wav")
+	if err != nil {
+		return "", noop, err
+	}
+	tempFile.Close()
+	cleanup := func() { os.Remove(tempFile.Name()) }
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpegPath,
+		"-nostdin", "-y", "-v", "error",
+		"-i", audioPath,
+		"-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le", "-f", "wav",
+		tempFile.Name(),
+	)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		cleanup()
+		if ctx.Err() != nil {
+			return "", noop, ctx.Err()
+		}
+		return "", noop, &TranscribeError{Provider: "ffmpeg", Kind: ErrAudioInvalid, Message: strings.TrimSpace(stderr.String())}
+	}
+	return tempFile.Name(), cleanup, nil
+}
+
+// WavDuration 根据WAV文件头计算时长
+func WavDuration(wavPath string) (time.Duration, error) {
+	info, err := readWavInfo(wavPath)
+	if err != nil {
+		return 0, err
+	}
+	if info.ByteRate == 0 {
+		return 0, &TranscribeError{Kind: ErrAudioInvalid, Message: "WAV文件头错误"}
+	}
+	return time.Duration(float64(info.DataSize) / float64(info.ByteRate) * float64(time.Second)), nil
+}
+
+func isSpeechWav(info *wavInfo) bool {
+	return info.AudioFormat == 1 && info.Channels == 1 && info.SampleRate == speechSampleRate && info.BitsPerSample == 16
+}
+
+// readWavInfo 读取WAV文件的 fmt 和 data 块信息
+func readWavInfo(path string) (*wavInfo, error) {
+	file, info, err := openWav(path)
+	if err != nil {
+		return nil, err
+	}
+	file.Close()
+	return info, nil
+}
+
+// openWav 打开WAV文件并定位到 data 块的开头
+func openWav(path string) (*os.File, *wavInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := seekWavData(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return file, info, nil
+}
+
+func seekWavData(file *os.File) (*wavInfo, error) {
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(file, header); err != nil || string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, &TranscribeError{Kind: ErrAudioInvalid, Message: "不是WAV文件"}
+	}
+
+	info := &wavInfo{}
+	hasFormat := false
+	chunk := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(file, chunk); err != nil {
+			break
+		}
+		id := string(chunk[0:4])
+		size := binary.LittleEndian.Uint32(chunk[4:8])
+		switch id {
+		case "fmt ":
+			if size < 16 || size > 64 {
+				return nil, &TranscribeError{Kind: ErrAudioInvalid, Message: "WAV文件头错误"}
+			}
+			data := make([]byte, size)
+			if _, err := io.ReadFull(file, data); err != nil {
+				return nil, &TranscribeError{Kind: ErrAudioInvalid, Message: "WAV文件头错误"}
+			}
+			info.AudioFormat = binary.LittleEndian.Uint16(data[0:2])
+			info.Channels = binary.LittleEndian.Uint16(data[2:4])
+			info.SampleRate = binary.LittleEndian.Uint32(data[4:8])
+			info.ByteRate = binary.LittleEndian.Uint32(data[8:12])
+			info.BitsPerSample = binary.LittleEndian.Uint16(data[14:16])
+			hasFormat = true
+			if size%2 == 1 {
+				file.Seek(1, io.SeekCurrent)
+			}
+		case "data":
+			if !hasFormat {
+				return nil, &TranscribeError{Kind: ErrAudioInvalid, Message: "WAV文件头错误"}
+			}
+			// 按文件实际长度修正，避免文件头中的长度被伪造或录音中断未回写
+			info.DataSize = size
+			if pos, err := file.Seek(0, io.SeekCurrent); err == nil {
+				if stat, err := file.Stat(); err == nil && stat.Size()-pos < int64(size) {
+					info.DataSize = uint32(stat.Size() - pos)
+				}
+			}
+			return info, nil
+		default:
+			// 跳过 LIST 等其他块，块大小为奇数时有一个填充字节
+			if _, err := file.Seek(int64(size+size%2), io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, &TranscribeError{Kind: ErrAudioInvalid, Message: "WAV文件缺少数据块"}
+}
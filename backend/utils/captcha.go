@@ -1,67 +1,16 @@
 package utils
 
-import (
-	"sync"
-	"time"
-)
+import "time"
 
-// 简单的内存存储验证码
-var captchaStore = struct {
-	sync.RWMutex
-	data map[string]captchaInfo
-}{
-	data: make(map[string]captchaInfo),
-}
-
-type captchaInfo struct {
-	Value     string
-	ExpiredAt time.Time
-}
+// 图形验证码有效期
+const captchaTTL = 5 * time.Minute
 
-// SaveCaptcha 保存验证码
-func SaveCaptcha(id, value string) {
-	captchaStore.Lock()
-	defer captchaStore.Unlock()
-
-	// 验证码有效期5分钟
-	captchaStore.data[id] = captchaInfo{
-		Value:     value,
-		ExpiredAt: time.Now().Add(5 * time.Minute),
-	}
+// SaveCaptcha 保存图形验证码，与短信/邮箱验证码使用同一个存储
+func SaveCaptcha(id, value string) error {
+	return saveCode(CodePurposeCaptcha, id, value, captchaTTL)
 }
 
-// VerifyCaptcha 验证验证码
+// VerifyCaptcha 验证图形验证码，输错次数过多时作废
 func VerifyCaptcha(id, value string) bool {
-	// 在测试环境下，如果验证码是"1234"，始终返回true
-	if value == "1234" {
-		return true
-	}
-
-	captchaStore.RLock()
-	defer captchaStore.RUnlock()
-
-	info, ok := captchaStore.data[id]
-	if !ok {
-		return false
-	}
-
-	// 验证码过期
-	if time.Now().After(info.ExpiredAt) {
-		return false
-	}
-
-	return info.Value == value
-}
-
-// CleanExpiredCaptcha 清理过期验证码
-func CleanExpiredCaptcha() {
-	captchaStore.Lock()
-	defer captchaStore.Unlock()
-
-	now := time.Now()
-	for id, info := range captchaStore.data {
-		if now.After(info.ExpiredAt) {
-			delete(captchaStore.data, id)
-		}
-	}
+	return checkCode(CodePurposeCaptcha, id, value, false)
 }
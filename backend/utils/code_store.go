@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 验证码存储抽象
+// 短信/邮箱验证码和图形验证码保存在 CodeStore 中，可使用内存、数据库或 Redis；
+// 使用数据库或 Redis 时重启不会丢失验证码，多个实例也可以共享
+// 存储只负责保存和原子操作，过期、错误次数和比对规则在 verification.go 中统一处理
+
+// CodeEntry 一条验证码记录
+type CodeEntry struct {
+	Code      string
+	Attempts  int // 输错次数
+	SentAt    time.Time
+	ExpiresAt time.Time
+}
+
+// CodeStore 验证码存储后端，每个操作都需要是原子的
+type CodeStore interface {
+	// Name 后端名称，用于配置
+	Name() string
+	// Save 保存验证码，替换同一 key 下的旧记录，错误次数清零
+	Save(ctx context.Context, key string, entry CodeEntry) error
+	// Get 读取验证码，不存在或已过期时返回 nil
+	Get(ctx context.Context, key string) (*CodeEntry, error)
+	// IncrAttempts 错误次数加一并返回新的次数，记录不存在时返回0
+	IncrAttempts(ctx context.Context, key string) (int, error)
+	// Delete 删除记录；code 不为空时只有当前验证码等于 code 才删除，返回是否删除
+	Delete(ctx context.Context, key, code string) (bool, error)
+	// DeleteExpired 删除过期记录，返回删除的条数；自动过期的后端可以不处理
+	DeleteExpired(ctx context.Context) (int64, error)
+	// IncrCounter 计数加一并返回新的值，key 不存在或已过期时从1开始，ttl 后过期；用于限制发送频率
+	IncrCounter(ctx context.Context, key string, ttl time.Duration) (int, error)
+}
+
+// 验证码存储配置
+type CodeStoreConfig struct {
+	// 存储后端：memory、db 或 redis
+	Backend string
+
+	// Redis（或兼容协议的服务），DB 为库编号，Prefix 为 key 前缀
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+	RedisPrefix   string
+
+	// 一个验证码最多允许输错的次数，超过后作废
+	MaxAttempts int
+}
+
+var (
+	codeStoreMu     sync.RWMutex
+	codeStoreConfig CodeStoreConfig
+	codeStore       CodeStore = NewMemoryCodeStore()
+)
+
+// 初始化函数，从环境变量加载验证码存储配置
+// 数据库存储在 InitDB 完成后才能使用，之前使用内存存储
+func init() {
+	config := CodeStoreConfig{
+		Backend:       envOrDefault("CODE_STORE", "db"),
+		RedisAddr:     envOrDefault("CODE_STORE_REDIS_ADDR", "127.0.0.1:6379"),
+		RedisPassword: os.Getenv("CODE_STORE_REDIS_PASSWORD"),
+		RedisPrefix:   envOrDefault("CODE_STORE_REDIS_PREFIX", "allinone:code:"),
+		MaxAttempts:   5,
+	}
+	if v, err := strconv.Atoi(os.Getenv("CODE_STORE_REDIS_DB")); err == nil && v >= 0 {
+		config.RedisDB = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("VERIFICATION_MAX_ATTEMPTS")); err == nil && v > 0 {
+		config.MaxAttempts = v
+	}
+	codeStoreConfig = config
+	if config.Backend == "redis" {
+		codeStore = NewRedisCodeStore(config.RedisAddr, config.RedisPassword, config.RedisDB, config.RedisPrefix)
+	}
+}
+
+// InitCodeStore 按配置创建验证码存储，db 为数据库存储使用的连接
+func InitCodeStore(db *gorm.DB) {
+	SetCodeStoreConfig(GetCodeStoreConfig(), db)
+}
+
+// SetCodeStoreConfig 根据配置重新创建验证码存储，db 为 nil 时数据库存储退回内存存储
+// 切换存储后已保存的验证码不会迁移
+func SetCodeStoreConfig(config CodeStoreConfig, db *gorm.DB) {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 1
+	}
+	var store CodeStore
+	switch {
+	case config.Backend == "redis":
+		store = NewRedisCodeStore(config.RedisAddr, config.RedisPassword, config.RedisDB, config.RedisPrefix)
+	case config.Backend == "db" && db != nil:
+		store = NewDBCodeStore(db)
+	default:
+		store = NewMemoryCodeStore()
+	}
+
+	codeStoreMu.Lock()
+	codeStoreConfig = config
+	codeStore = store
+	codeStoreMu.Unlock()
+}
+
+// GetCodeStoreConfig 获取当前验证码存储配置
+func GetCodeStoreConfig() CodeStoreConfig {
+	codeStoreMu.RLock()
+	defer codeStoreMu.RUnlock()
+	return codeStoreConfig
+}
+
+// SetCodeStore 替换验证码存储，用于接入其他实现
+func SetCodeStore(store CodeStore) {
+	codeStoreMu.Lock()
+	defer codeStoreMu.Unlock()
+	codeStore = store
+	codeStoreConfig.Backend = store.Name()
+}
+
+func currentCodeStore() (CodeStoreConfig, CodeStore) {
+	codeStoreMu.RLock()
+	defer codeStoreMu.RUnlock()
+	return codeStoreConfig, codeStore
+}
+
+// MemoryCodeStore 进程内存储，重启后丢失，只适合单实例和本地开发
+type MemoryCodeStore struct {
+	mu   sync.Mutex
+	data map[string]CodeEntry
+}
+
+func NewMemoryCodeStore() *MemoryCodeStore {
+	return &MemoryCodeStore{data: make(map[string]CodeEntry)}
+}
+
+func (s *MemoryCodeStore) Name() string {
+	return "memory"
+}
+
+func (s *MemoryCodeStore) Save(ctx context.Context, key string, entry CodeEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry.Attempts = 0
+	s.data[key] = entry
+	return nil
+}
+
+func (s *MemoryCodeStore) Get(ctx context.Context, key string) (*CodeEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.data[key]
+	if !ok || time.Now().After(entry.ExpiresAt) {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+func (s *MemoryCodeStore) IncrAttempts(ctx context.Context, key string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.data[key]
+	if !ok || time.Now().After(entry.ExpiresAt) {
+		return 0, nil
+	}
+	entry.Attempts++
+	s.data[key] = entry
+	return entry.Attempts, nil
+}
+
+func (s *MemoryCodeStore) Delete(ctx context.Context, key, code string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.data[key]
+	if !ok || (code != "" && entry.Code != code) {
+		return false, nil
+	}
+	delete(s.data, key)
+	return true, nil
+}
+
+func (s *MemoryCodeStore) IncrCounter(ctx context.Context, key string, ttl time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	entry, ok := s.data[key]
+	if !ok || now.After(entry.ExpiresAt) {
+		entry = CodeEntry{SentAt: now, ExpiresAt: now.Add(ttl)}
+	}
+	entry.Attempts++
+	s.data[key] = entry
+	return entry.Attempts, nil
+}
+
+func (s *MemoryCodeStore) DeleteExpired(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	var count int64
+	for key, entry := range s.data {
+		if now.After(entry.ExpiresAt) {
+			delete(s.data, key)
+			count++
+		}
+	}
+	return count, nil
+}
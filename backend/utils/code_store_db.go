@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"allinone_backend/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DBCodeStore 数据库存储，验证码保存在 verification_codes 表中，过期记录由定时任务清理
+type DBCodeStore struct {
+	db *gorm.DB
+}
+
+func NewDBCodeStore(db *gorm.DB) *DBCodeStore {
+	return &DBCodeStore{db: db}
+}
+
+func (s *DBCodeStore) Name() string {
+	return "db"
+}
+
+func (s *DBCodeStore) Save(ctx context.Context, key string, entry CodeEntry) error {
+	record := models.VerificationCode{
+		CodeKey:   key,
+		Code:      entry.Code,
+		SentAt:    entry.SentAt.Unix(),
+		ExpiresAt: entry.ExpiresAt.Unix(),
+	}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&record).Error
+}
+
+func (s *DBCodeStore) Get(ctx context.Context, key string) (*CodeEntry, error) {
+	var records []models.VerificationCode
+	err := s.db.WithContext(ctx).Where("code_key = ? AND expires_at > ?", key, time.Now().Unix()).Limit(1).Find(&records).Error
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+	return &CodeEntry{
+		Code:      records[0].Code,
+		Attempts:  records[0].Attempts,
+		SentAt:    time.Unix(records[0].SentAt, 0),
+		ExpiresAt: time.Unix(records[0].ExpiresAt, 0),
+	}, nil
+}
+
+func (s *DBCodeStore) IncrAttempts(ctx context.Context, key string) (int, error) {
+	var attempts int
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.VerificationCode{}).
+			Where("code_key = ? AND expires_at > ?", key, time.Now().Unix()).
+			Update("attempts", gorm.Expr("attempts + 1"))
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.VerificationCode{}).Where("code_key = ?", key).Select("attempts").Scan(&attempts).Error
+	})
+	return attempts, err
+}
+
+// IncrCounter 计数保存在 attempts 字段，过期的记录在同一条语句中重新从1开始
+func (s *DBCodeStore) IncrCounter(ctx context.Context, key string, ttl time.Duration) (int, error) {
+	now := time.Now()
+	record := models.VerificationCode{
+		CodeKey:   key,
+		Attempts:  1,
+		SentAt:    now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}
+	var count int
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "code_key"}},
+			DoUpdates: clause.Set{
+				{Column: clause.Column{Name: "attempts"}, Value: gorm.Expr("CASE WHEN verification_codes.expires_at > ? THEN verification_codes.attempts + 1 ELSE 1 END", now.Unix())},
+				{Column: clause.Column{Name: "sent_at"}, Value: gorm.Expr("CASE WHEN verification_codes.expires_at > ? THEN verification_codes.sent_at ELSE ? END", now.Unix(), record.SentAt)},
+				{Column: clause.Column{Name: "expires_at"}, Value: gorm.Expr("CASE WHEN verification_codes.expires_at > ? THEN verification_codes.expires_at ELSE ? END", now.Unix(), record.ExpiresAt)},
+			},
+		}).Create(&record).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.VerificationCode{}).Where("code_key = ?", key).Select("attempts").Scan(&count).Error
+	})
+	return count, err
+}
+
+func (s *DBCodeStore) Delete(ctx context.Context, key, code string) (bool, error) {
+	query := s.db.WithContext(ctx).Where("code_key = ?", key)
+	if code != "" {
+		query = query.Where("code = ?", code)
+	}
+	result := query.Delete(&models.VerificationCode{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (s *DBCodeStore) DeleteExpired(ctx context.Context) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at <= ?", time.Now().Unix()).Delete(&models.VerificationCode{})
+	return result.RowsAffected, result.Error
+}
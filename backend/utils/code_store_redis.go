@@ -0,0 +1,274 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RedisCodeStore Redis 存储，也可对接兼容 Redis 协议的服务（KeyDB、Valkey 等）
+// 每条验证码保存为一个 hash，依靠 key 过期自动清理；多步操作使用 Lua 脚本保证原子性
+type RedisCodeStore struct {
+	addr     string
+	password string
+	db       int
+	prefix   string
+
+	mu   sync.Mutex
+	idle []*redisConn
+}
+
+// 最多保留的空闲连接数
+const redisMaxIdleConns = 4
+
+const (
+	redisSaveScript = `redis.call('DEL', KEYS[1])
+redis.call('HSET', KEYS[1], 'code', ARGV[1], 'sent_at', ARGV[2], 'expires_at', ARGV[3], 'attempts', 0)
+redis.call('PEXPIREAT', KEYS[1], ARGV[3])
+return 1`
+	redisIncrScript = `if redis.call('EXISTS', KEYS[1]) == 0 then return 0 end
+return redis.call('HINCRBY', KEYS[1], 'attempts', 1)`
+	redisCounterScript = `local n = redis.call('HINCRBY', KEYS[1], 'attempts', 1)
+if n == 1 then
+  redis.call('HSET', KEYS[1], 'sent_at', ARGV[1], 'expires_at', ARGV[2])
+  redis.call('PEXPIREAT', KEYS[1], ARGV[2])
+end
+return n`
+	redisDeleteScript = `if ARGV[1] ~= '' and redis.call('HGET', KEYS[1], 'code') ~= ARGV[1] then return 0 end
+return redis.call('DEL', KEYS[1])`
+)
+
+func NewRedisCodeStore(addr, password string, db int, prefix string) *RedisCodeStore {
+	return &RedisCodeStore{addr: addr, password: password, db: db, prefix: prefix}
+}
+
+func (s *RedisCodeStore) Name() string {
+	return "redis"
+}
+
+func (s *RedisCodeStore) Save(ctx context.Context, key string, entry CodeEntry) error {
+	_, err := s.do(ctx, "EVAL", redisSaveScript, "1", s.prefix+key, entry.Code,
+		strconv.FormatInt(entry.SentAt.UnixMilli(), 10), strconv.FormatInt(entry.ExpiresAt.UnixMilli(), 10))
+	return err
+}
+
+func (s *RedisCodeStore) Get(ctx context.Context, key string) (*CodeEntry, error) {
+	reply, err := s.do(ctx, "HGETALL", s.prefix+key)
+	if err != nil {
+		return nil, err
+	}
+	fields, _ := reply.([]interface{})
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	values := make(map[string]string, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		name, _ := fields[i].(string)
+		value, _ := fields[i+1].(string)
+		values[name] = value
+	}
+	sentAt, _ := strconv.ParseInt(values["sent_at"], 10, 64)
+	expiresAt, _ := strconv.ParseInt(values["expires_at"], 10, 64)
+	attempts, _ := strconv.Atoi(values["attempts"])
+	entry := &CodeEntry{
+		Code:      values["code"],
+		Attempts:  attempts,
+		SentAt:    time.UnixMilli(sentAt),
+		ExpiresAt: time.UnixMilli(expiresAt),
+	}
+	// key 过期有一定延迟，这里再检查一次
+	if time.Now().After(entry.ExpiresAt) {
+		return nil, nil
+	}
+	return entry, nil
+}
+
+func (s *RedisCodeStore) IncrAttempts(ctx context.Context, key string) (int, error) {
+	reply, err := s.do(ctx, "EVAL", redisIncrScript, "1", s.prefix+key)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := reply.(int64)
+	return int(n), nil
+}
+
+func (s *RedisCodeStore) IncrCounter(ctx context.Context, key string, ttl time.Duration) (int, error) {
+	now := time.Now()
+	reply, err := s.do(ctx, "EVAL", redisCounterScript, "1", s.prefix+key,
+		strconv.FormatInt(now.UnixMilli(), 10), strconv.FormatInt(now.Add(ttl).UnixMilli(), 10))
+	if err != nil {
+		return 0, err
+	}
+	n, _ := reply.(int64)
+	return int(n), nil
+}
+
+func (s *RedisCodeStore) Delete(ctx context.Context, key, code string) (bool, error) {
+	reply, err := s.do(ctx, "EVAL", redisDeleteScript, "1", s.prefix+key, code)
+	if err != nil {
+		return false, err
+	}
+	n, _ := reply.(int64)
+	return n > 0, nil
+}
+
+// DeleteExpired Redis 自动删除过期的 key，不需要清理
+func (s *RedisCodeStore) DeleteExpired(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+// redisConn 一个 Redis 连接，按 RESP 协议收发命令
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// redisServerError 服务端返回的错误，连接仍可继续使用
+type redisServerError string
+
+func (e redisServerError) Error() string {
+	return "redis: " + string(e)
+}
+
+// do 执行一条命令，网络错误时关闭连接，成功或服务端错误时放回连接池
+func (s *RedisCodeStore) do(ctx context.Context, args ...string) (interface{}, error) {
+	conn, err := s.getConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.command(ctx, args...)
+	var serverErr redisServerError
+	if err != nil && !errors.As(err, &serverErr) {
+		conn.conn.Close()
+		return nil, err
+	}
+	s.putConn(conn)
+	return reply, err
+}
+
+func (s *RedisCodeStore) getConn(ctx context.Context) (*redisConn, error) {
+	s.mu.Lock()
+	if n := len(s.idle); n > 0 {
+		conn := s.idle[n-1]
+		s.idle = s.idle[:n-1]
+		s.mu.Unlock()
+		return conn, nil
+	}
+	s.mu.Unlock()
+
+	netConn, err := (&net.Dialer{Timeout: 5 * time.Second}).DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	conn := &redisConn{conn: netConn, r: bufio.NewReader(netConn)}
+	if s.password != "" {
+		if _, err := conn.command(ctx, "AUTH", s.password); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	if s.db > 0 {
+		if _, err := conn.command(ctx, "SELECT", strconv.Itoa(s.db)); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (s *RedisCodeStore) putConn(conn *redisConn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.idle) >= redisMaxIdleConns {
+		conn.conn.Close()
+		return
+	}
+	s.idle = append(s.idle, conn)
+}
+
+func (c *redisConn) command(ctx context.Context, args ...string) (interface{}, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(5 * time.Second)
+	}
+	c.conn.SetDeadline(deadline)
+
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	if _, err := c.conn.Write(buf); err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	return c.readReply()
+}
+
+// readReply 读取一个回复：简单字符串和批量字符串返回 string，整数返回 int64，数组返回 []interface{}，空值返回 nil
+func (c *redisConn) readReply() (interface{}, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.New("redis: 无效的回复")
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisServerError(body)
+	case ':':
+		n, err := strconv.ParseInt(body, 10, 64)
+		if err != nil {
+			return nil, errors.New("redis: 无效的整数回复")
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, errors.New("redis: 无效的字符串长度")
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, data); err != nil {
+			return nil, fmt.Errorf("redis: %w", err)
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, errors.New("redis: 无效的数组长度")
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			// 数组中的错误不影响读取后续元素
+			item, err := c.readReply()
+			var serverErr redisServerError
+			if err != nil && !errors.As(err, &serverErr) {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: 未知的回复类型 %q", kind)
+}
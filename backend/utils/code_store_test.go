@@ -0,0 +1,377 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis 按 RESP 协议应答的内存服务，只实现 RedisCodeStore 用到的命令和脚本
+type fakeRedis struct {
+	password string
+
+	mu       sync.Mutex
+	data     map[string]map[string]string
+	expireAt map[string]int64 // 过期时间，毫秒
+	selected []int            // 每个连接 SELECT 的库编号
+}
+
+// startFakeRedis 启动服务，测试结束后关闭，返回监听地址
+func startFakeRedis(t *testing.T, password string) (*fakeRedis, string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	s := &fakeRedis{password: password, data: map[string]map[string]string{}, expireAt: map[string]int64{}}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s, ln.Addr().String()
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authed := s.password == ""
+	for {
+		args, err := readFakeCommand(r)
+		if err != nil {
+			return
+		}
+		var reply string
+		switch strings.ToUpper(args[0]) {
+		case "AUTH":
+			if args[1] != s.password {
+				reply = "-WRONGPASS invalid password\r\n"
+			} else {
+				authed = true
+				reply = "+OK\r\n"
+			}
+		case "SELECT":
+			db, _ := strconv.Atoi(args[1])
+			s.mu.Lock()
+			s.selected = append(s.selected, db)
+			s.mu.Unlock()
+			reply = "+OK\r\n"
+		default:
+			if !authed {
+				reply = "-NOAUTH Authentication required.\r\n"
+			} else {
+				reply = s.exec(args)
+			}
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func readFakeCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil || line[0] != '*' {
+		return nil, fmt.Errorf("无效的命令: %q", line)
+	}
+	args := make([]string, n)
+	for i := range args {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
+
+// hash 返回未过期的 hash，create 为 true 时不存在则创建
+func (s *fakeRedis) hash(key string, create bool) map[string]string {
+	if at, ok := s.expireAt[key]; ok && at <= time.Now().UnixMilli() {
+		delete(s.data, key)
+		delete(s.expireAt, key)
+	}
+	h := s.data[key]
+	if h == nil && create {
+		h = map[string]string{}
+		s.data[key] = h
+	}
+	return h
+}
+
+func (s *fakeRedis) exec(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "HGETALL":
+		h := s.hash(args[1], false)
+		var b strings.Builder
+		fmt.Fprintf(&b, "*%d\r\n", len(h)*2)
+		for name, value := range h {
+			fmt.Fprintf(&b, "$%d\r\n%s\r\n$%d\r\n%s\r\n", len(name), name, len(value), value)
+		}
+		return b.String()
+	case "EVAL":
+		script, key, argv := args[1], args[3], args[4:]
+		switch script {
+		case redisSaveScript:
+			delete(s.data, key)
+			h := s.hash(key, true)
+			h["code"], h["sent_at"], h["expires_at"], h["attempts"] = argv[0], argv[1], argv[2], "0"
+			s.expireAt[key], _ = strconv.ParseInt(argv[2], 10, 64)
+			return ":1\r\n"
+		case redisIncrScript:
+			h := s.hash(key, false)
+			if h == nil {
+				return ":0\r\n"
+			}
+			n, _ := strconv.Atoi(h["attempts"])
+			h["attempts"] = strconv.Itoa(n + 1)
+			return fmt.Sprintf(":%d\r\n", n+1)
+		case redisDeleteScript:
+			h := s.hash(key, false)
+			if h == nil || (argv[0] != "" && h["code"] != argv[0]) {
+				return ":0\r\n"
+			}
+			delete(s.data, key)
+			delete(s.expireAt, key)
+			return ":1\r\n"
+		case redisCounterScript:
+			h := s.hash(key, true)
+			n, _ := strconv.Atoi(h["attempts"])
+			h["attempts"] = strconv.Itoa(n + 1)
+			if n == 0 {
+				h["sent_at"], h["expires_at"] = argv[0], argv[1]
+				s.expireAt[key], _ = strconv.ParseInt(argv[1], 10, 64)
+			}
+			return fmt.Sprintf(":%d\r\n", n+1)
+		}
+		return "-ERR unknown script\r\n"
+	}
+	return "-ERR unknown command '" + args[0] + "'\r\n"
+}
+
+// testCodeStores 三种存储后端
+func testCodeStores(t *testing.T) map[string]CodeStore {
+	t.Helper()
+	_, addr := startFakeRedis(t, "")
+	return map[string]CodeStore{
+		"memory": NewMemoryCodeStore(),
+		"db":     NewDBCodeStore(openTestDB(t)),
+		"redis":  NewRedisCodeStore(addr, "", 0, "test:"),
+	}
+}
+
+func TestCodeStoreOperations(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range testCodeStores(t) {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			entry := CodeEntry{Code: "123456", SentAt: now, ExpiresAt: now.Add(time.Minute)}
+			if err := store.Save(ctx, "k", entry); err != nil {
+				t.Fatalf("保存失败: %v", err)
+			}
+			got, err := store.Get(ctx, "k")
+			if err != nil || got == nil || got.Code != "123456" || got.Attempts != 0 || got.SentAt.Unix() != now.Unix() {
+				t.Fatalf("读取结果错误: %+v, %v", got, err)
+			}
+			if missing, err := store.Get(ctx, "missing"); missing != nil || err != nil {
+				t.Errorf("不存在的记录应返回 nil: %+v, %v", missing, err)
+			}
+
+			// 错误次数
+			for want := 1; want <= 2; want++ {
+				if n, err := store.IncrAttempts(ctx, "k"); n != want || err != nil {
+					t.Errorf("第%d次错误计数为 %d, %v", want, n, err)
+				}
+			}
+			if n, _ := store.IncrAttempts(ctx, "missing"); n != 0 {
+				t.Errorf("不存在的记录错误计数应为0，得到 %d", n)
+			}
+			// 重新保存后错误次数清零
+			store.Save(ctx, "k", CodeEntry{Code: "654321", SentAt: now, ExpiresAt: now.Add(time.Minute)})
+			if got, _ := store.Get(ctx, "k"); got == nil || got.Code != "654321" || got.Attempts != 0 {
+				t.Errorf("重新保存后应替换验证码并清零: %+v", got)
+			}
+
+			// 只有验证码一致时才删除
+			if ok, _ := store.Delete(ctx, "k", "123456"); ok {
+				t.Error("验证码不一致时不应删除")
+			}
+			if ok, _ := store.Delete(ctx, "k", "654321"); !ok {
+				t.Error("验证码一致时应删除")
+			}
+			if got, _ := store.Get(ctx, "k"); got != nil {
+				t.Error("删除后应读取不到")
+			}
+			store.Save(ctx, "k", entry)
+			if ok, _ := store.Delete(ctx, "k", ""); !ok {
+				t.Error("不指定验证码时应直接删除")
+			}
+		})
+	}
+}
+
+func TestCodeStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range testCodeStores(t) {
+		t.Run(name, func(t *testing.T) {
+			past := time.Now().Add(-time.Minute)
+			store.Save(ctx, "expired", CodeEntry{Code: "111111", SentAt: past, ExpiresAt: past.Add(time.Second)})
+			store.Save(ctx, "valid", CodeEntry{Code: "222222", SentAt: time.Now(), ExpiresAt: time.Now().Add(time.Minute)})
+			if got, _ := store.Get(ctx, "expired"); got != nil {
+				t.Errorf("过期的验证码应读取不到: %+v", got)
+			}
+			if n, _ := store.IncrAttempts(ctx, "expired"); n != 0 {
+				t.Errorf("过期的验证码不应计数，得到 %d", n)
+			}
+
+			if _, err := store.DeleteExpired(ctx); err != nil {
+				t.Errorf("清理过期记录失败: %v", err)
+			}
+			if got, _ := store.Get(ctx, "valid"); got == nil {
+				t.Error("清理不应删除未过期的记录")
+			}
+
+			// 计数在有效期内累加，过期后从1开始
+			for want := 1; want <= 2; want++ {
+				if n, err := store.IncrCounter(ctx, "counter", time.Minute); n != want || err != nil {
+					t.Errorf("计数应为 %d，得到 %d, %v", want, n, err)
+				}
+			}
+			for i := 0; i < 2; i++ {
+				if n, _ := store.IncrCounter(ctx, "short", -time.Second); n != 1 {
+					t.Errorf("过期的计数应从1开始，得到 %d", n)
+				}
+			}
+		})
+	}
+}
+
+func TestVerificationCodeRules(t *testing.T) {
+	for name, store := range testCodeStores(t) {
+		t.Run(name, func(t *testing.T) {
+			useCodeStore(t, store)
+			maxAttempts := GetCodeStoreConfig().MaxAttempts
+
+			// 不同用途的验证码分开保存
+			SaveVerificationCode(CodePurposeRegister, "email:a@example.com", "111111")
+			SaveVerificationCode(CodePurposeResetPassword, "email:a@example.com", "222222")
+			tests := []struct {
+				name    string
+				purpose CodePurpose
+				code    string
+				ok      bool
+			}{
+				{"注册验证码用于注册", CodePurposeRegister, "111111", true},
+				{"注册验证码用于重置密码", CodePurposeResetPassword, "111111", false},
+				{"重置密码验证码用于注册", CodePurposeRegister, "222222", false},
+				{"测试验证码", CodePurposeRegister, "123456", false},
+			}
+			for _, tt := range tests {
+				if got := VerifyCode(tt.purpose, "email:a@example.com", tt.code); got != tt.ok {
+					t.Errorf("%s: VerifyCode = %v，应为 %v", tt.name, got, tt.ok)
+				}
+			}
+			// 上面输错一次，仍然有效；消费后作废
+			if !ConsumeVerificationCode(CodePurposeResetPassword, "email:a@example.com", "222222") {
+				t.Error("验证码应可以消费")
+			}
+			if ConsumeVerificationCode(CodePurposeResetPassword, "email:a@example.com", "222222") {
+				t.Error("验证码不能重复消费")
+			}
+			if !VerifyCode(CodePurposeRegister, "email:a@example.com", "111111") {
+				t.Error("消费其他用途的验证码不应影响注册验证码")
+			}
+
+			// 输错次数达到上限后作废，正确的验证码也不再有效
+			SaveVerificationCode(CodePurposeRegister, "phone:13800000000", "333333")
+			for i := 0; i < maxAttempts; i++ {
+				VerifyCode(CodePurposeRegister, "phone:13800000000", "000000")
+			}
+			if VerifyCode(CodePurposeRegister, "phone:13800000000", "333333") {
+				t.Error("输错次数过多后验证码应作废")
+			}
+
+			// 发送失败时只作废刚保存的验证码
+			SaveVerificationCode(CodePurposeRegister, "phone:13900000000", "444444")
+			DeleteVerificationCode(CodePurposeRegister, "phone:13900000000", "555555")
+			if !VerifyCode(CodePurposeRegister, "phone:13900000000", "444444") {
+				t.Error("验证码不一致时不应作废")
+			}
+			DeleteVerificationCode(CodePurposeRegister, "phone:13900000000", "444444")
+			if VerifyCode(CodePurposeRegister, "phone:13900000000", "444444") {
+				t.Error("作废后验证码应无效")
+			}
+
+			// 图形验证码不接受固定的测试值
+			SaveCaptcha("captcha-1", "ABCD")
+			if VerifyCaptcha("captcha-1", "1234") || !VerifyCaptcha("captcha-1", "ABCD") {
+				t.Error("图形验证码只接受保存的值")
+			}
+		})
+	}
+}
+
+func TestRedisCodeStoreAuthAndDB(t *testing.T) {
+	server, addr := startFakeRedis(t, "secret")
+	ctx := context.Background()
+
+	if err := NewRedisCodeStore(addr, "wrong", 0, "").Save(ctx, "k", CodeEntry{Code: "1", ExpiresAt: time.Now().Add(time.Minute)}); err == nil {
+		t.Error("密码错误时应报错")
+	}
+	store := NewRedisCodeStore(addr, "secret", 3, "app:")
+	if err := store.Save(ctx, "k", CodeEntry{Code: "1", ExpiresAt: time.Now().Add(time.Minute)}); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	if got, _ := store.Get(ctx, "k"); got == nil || got.Code != "1" {
+		t.Errorf("读取结果错误: %+v", got)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.data["app:k"] == nil {
+		t.Error("key 应带有前缀")
+	}
+	if len(server.selected) != 1 || server.selected[0] != 3 {
+		t.Errorf("应选择配置的库，连接复用时不重复选择: %v", server.selected)
+	}
+}
+
+func TestGenerateRandomCode(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		code, err := GenerateRandomCode(6)
+		if err != nil {
+			t.Fatalf("生成验证码失败: %v", err)
+		}
+		if len(code) != 6 || strings.Trim(code, "0123456789") != "" {
+			t.Fatalf("验证码应为6位数字: %q", code)
+		}
+		seen[code] = true
+	}
+	if len(seen) < 15 {
+		t.Errorf("验证码重复过多: %d 个不同", len(seen))
+	}
+}
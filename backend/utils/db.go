@@ -32,29 +32,84 @@ func InitDB() error {
 		return err
 	}
 
-	// 自动迁移表结构
-	err = db.AutoMigrate(
+	if err := MigrateDB(db); err != nil {
+		return err
+	}
+
+	DB = db
+	// 验证码存储使用数据库时需要在迁移之后创建
+	InitCodeStore(db)
+	return nil
+}
+
+// MigrateDB 自动迁移全部表结构
+func MigrateDB(db *gorm.DB) error {
+	// 旧版本并发购买可能重复添加同一游戏，建立唯一索引前只保留最早的一条
+	if db.Migrator().HasTable(&models.UserGame{}) && !db.Migrator().HasIndex(&models.UserGame{}, "idx_user_game") {
+		err := db.Exec("DELETE FROM user_games WHERE id NOT IN (SELECT MIN(id) FROM user_games GROUP BY user_id, game_id)").Error
+		if err != nil {
+			return err
+		}
+	}
+
+	err := db.AutoMigrate(
 		// 用户相关
 		&models.User{},
 		&models.UserSettings{},
 		&models.UserDevice{},
 		&models.AISettings{},
+		&models.AIToolPermission{},
 
 		// 聊天相关
 		&models.ChatMessage{},
+		&models.MessageTranslation{},
+		&models.VoiceMessage{},
+		&models.VoiceListen{},
+		&models.TranscriptionJob{},
+		&models.MediaBlob{},
+		&models.Media{},
+		&models.UploadSession{},
+		&models.EmoticonPackage{},
+		&models.Emoticon{},
+		&models.UserEmoticonPackage{},
+		&models.EmoticonOrder{},
+		&models.UserFavoriteEmoticon{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.UserTwoFactor{},
+		&models.TwoFactorRecoveryCode{},
+		&models.TwoFactorChallenge{},
+		&models.LoginAttempt{},
+		&models.VerificationCode{},
 		&models.VoiceCallRecord{},
 		&models.VideoCallRecord{},
 		&models.AIChatMessage{},
+		&models.AIChatSession{},
+		&models.AIUsageDaily{},
 
 		// 好友相关
 		&models.Friend{},
 		&models.FriendRequest{},
 
+		// 朋友圈相关
+		&models.Moment{},
+		&models.MomentLike{},
+		&models.MomentComment{},
+
+		// 广场相关
+		&models.SquarePost{},
+		&models.SquareTopic{},
+		&models.SquarePostTopic{},
+		&models.SquareLike{},
+		&models.SquareComment{},
+		&models.SquareReport{},
+
 		// 群组相关
 		&models.Group{},
 		&models.GroupMember{},
 		&models.GroupInvitation{},
 		&models.GroupAnnouncement{},
+		&models.GroupReadState{},
 		&models.ChatGroupExt{},
 
 		// 钱包相关
@@ -76,9 +131,17 @@ func InitDB() error {
 		&models.Investment{},
 		&models.UserInvestment{},
 
+		// 小程序相关
+		&models.MiniApp{},
+		&models.MiniAppGrant{},
+		&models.MiniAppUsage{},
+		&models.MiniAppPayment{},
+
 		// 多语言支持
 		&models.LanguagePack{},
 		&models.UserLanguagePack{},
+		&models.TranslationCache{},
+		&models.LanguageDetection{},
 
 		// 游戏相关
 		&models.Game{},
@@ -88,14 +151,40 @@ func InitDB() error {
 		&models.GameAchievement{},
 		&models.UserGameAchievement{},
 		&models.GameUpdate{},
+		&models.GameOrder{},
+		&models.GameSettlement{},
 		&models.AIGameCharacter{},
 	)
 	if err != nil {
 		return err
 	}
 
-	DB = db
-	return nil
+	// 旧版本AI设置的默认模型为 gpt-3.5-turbo，未指定提供方的清空，使用各提供方的默认模型
+	err = db.Model(&models.AISettings{}).
+		Where("ai_model = ? AND (ai_provider = '' OR ai_provider IS NULL)", "gpt-3.5-turbo").
+		Update("ai_model", "").Error
+	if err != nil {
+		return err
+	}
+
+	// 旧版本的系统账号保存了明文随机密码，会被当作旧格式密码登录，改为不可登录
+	err = db.Model(&models.User{}).
+		Where("role = ? AND password <> ?", "bot", UnusablePassword).
+		Update("password", UnusablePassword).Error
+	if err != nil {
+		return err
+	}
+
+	// 旧版本的设备标识全局唯一，其他用户使用相同的设备标识登录时会占用该设备记录，改为按用户唯一
+	if db.Migrator().HasIndex(&models.UserDevice{}, "idx_user_devices_device_id") {
+		if err := db.Migrator().DropIndex(&models.UserDevice{}, "idx_user_devices_device_id"); err != nil {
+			return err
+		}
+	}
+	// 旧版本每次登录都把设备标记为受信任，没有信任令牌的设备取消信任
+	return db.Model(&models.UserDevice{}).
+		Where("trusted = ? AND (trust_token_hash = '' OR trust_token_hash IS NULL)", true).
+		Update("trusted", false).Error
 }
 
 // 事务处理
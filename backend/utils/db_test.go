@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"allinone_backend/models"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestMigrateUserDevicesPerUser(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := MigrateDB(db); err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+
+	// 模拟旧版本：设备标识全局唯一，登录即受信任
+	if err := db.Exec("CREATE UNIQUE INDEX idx_user_devices_device_id ON user_devices(device_id)").Error; err != nil {
+		t.Fatalf("创建旧索引失败: %v", err)
+	}
+	db.Create(&models.UserDevice{UserID: 1, DeviceID: "d1", Trusted: true})
+	db.Create(&models.UserDevice{UserID: 1, DeviceID: "d2", Trusted: true, TrustTokenHash: "hash"})
+
+	if err := MigrateDB(db); err != nil {
+		t.Fatalf("再次迁移失败: %v", err)
+	}
+	if db.Migrator().HasIndex(&models.UserDevice{}, "idx_user_devices_device_id") {
+		t.Error("旧的全局唯一索引应被删除")
+	}
+	if err := db.Create(&models.UserDevice{UserID: 2, DeviceID: "d1"}).Error; err != nil {
+		t.Errorf("不同用户应可以使用相同的设备标识: %v", err)
+	}
+	if err := db.Create(&models.UserDevice{UserID: 2, DeviceID: "d1"}).Error; err == nil {
+		t.Error("同一用户的设备标识应唯一")
+	}
+
+	var devices []models.UserDevice
+	db.Where("user_id = ?", 1).Order("device_id").Find(&devices)
+	if len(devices) != 2 || devices[0].Trusted || !devices[1].Trusted {
+		t.Errorf("没有信任令牌的设备应取消信任，有信任令牌的保留: %+v", devices)
+	}
+}
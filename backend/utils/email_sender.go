@@ -1,124 +1,201 @@
 package utils
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
 	"net/smtp"
+	"net/textproto"
+	"strconv"
 	"strings"
-
-	"github.com/gin-gonic/gin"
+	"time"
 )
 
-// 邮件配置
-type EmailConfig struct {
-	Host     string
-	Port     int
-	Username string
-	Password string
-	From     string
+// SMTPEmailSender 通过SMTP服务器发送邮件
+type SMTPEmailSender struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
 }
 
-// 全局邮件配置
-var emailConfig = EmailConfig{
-	Host:     "smtp.qq.com",
-	Port:     587,
-	Username: "your_email@qq.com", // 替换为您的QQ邮箱
-	Password: "your_password",     // 替换为您的邮箱授权码
-	From:     "your_email@qq.com", // 替换为您的QQ邮箱
+// NewSMTPEmailSender 创建SMTP发送方，username 为空时不进行认证
+func NewSMTPEmailSender(host string, port int, username, password, from string) *SMTPEmailSender {
+	return &SMTPEmailSender{host: host, port: port, username: username, password: password, from: from}
 }
 
-// 初始化函数，从环境变量或配置文件加载邮箱配置
-func init() {
-	// 这里可以从环境变量或配置文件加载邮箱配置
-	// 为了简单起见，我们直接在代码中设置
+func (s *SMTPEmailSender) Name() string {
+	return "smtp"
+}
 
-	// 使用Outlook邮箱的SMTP服务
-	// 注意：在实际使用时，请使用环境变量或配置文件存储这些敏感信息
-	emailConfig = EmailConfig{
-		Host:     "smtp.office365.com",    // Outlook SMTP服务器
-		Port:     587,                     // Outlook SMTP端口
-		Username: "126970540@outlook.com", // 发送邮箱
-		Password: "your_password",         // 这里需要替换为邮箱的密码
-		From:     "126970540@outlook.com", // 发送邮箱
+// SendEmail 连接服务器发送一封邮件；连接失败和4xx响应可重试，5xx响应视为被拒绝
+func (s *SMTPEmailSender) SendEmail(ctx context.Context, msg *EmailMessage) error {
+	if strings.ContainsAny(msg.To, "\r\n") {
+		return &SendError{Sender: s.Name(), Kind: ErrSendRejected, Message: "收件人地址无效"}
+	}
+	addr := net.JoinHostPort(s.host, strconv.Itoa(s.port))
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(30 * time.Second)
 	}
 
-	// 初始化邮件服务
-	fmt.Println("邮件服务初始化完成，发送邮箱：126970540@outlook.com")
-}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	var err error
+	if s.port == 465 {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: s.host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return &SendError{Sender: s.Name(), Kind: ErrSendUnavailable, Message: err.Error()}
+	}
+	conn.SetDeadline(deadline)
 
-// 设置邮件配置
-func SetEmailConfig(config EmailConfig) {
-	emailConfig = config
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return s.wrapError(err)
+	}
+	defer client.Close()
+
+	if s.port != 465 {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+				return s.wrapError(err)
+			}
+		}
+	}
+	if s.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return s.wrapError(err)
+		}
+	}
+	if err := client.Mail(s.from); err != nil {
+		return s.wrapError(err)
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return s.wrapError(err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return s.wrapError(err)
+	}
+	data, err := buildEmailMIME(s.from, msg)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return s.wrapError(err)
+	}
+	if err := w.Close(); err != nil {
+		return s.wrapError(err)
+	}
+	return client.Quit()
 }
 
-// 发送邮件
-func SendEmail(to, subject, body string) error {
-	// 构建邮件头
-	headers := make(map[string]string)
-	headers["From"] = emailConfig.From
-	headers["To"] = to
-	headers["Subject"] = subject
-	headers["MIME-Version"] = "1.0"
-	headers["Content-Type"] = "text/html; charset=UTF-8"
-
-	// 构建邮件内容
-	message := ""
-	for key, value := range headers {
-		message += fmt.Sprintf("%s: %s\r\n", key, value)
-	}
-	message += "\r\n" + body
-
-	// 连接到SMTP服务器
-	auth := smtp.PlainAuth("", emailConfig.Username, emailConfig.Password, emailConfig.Host)
-	addr := fmt.Sprintf("%s:%d", emailConfig.Host, emailConfig.Port)
-
-	// 发送邮件
-	return smtp.SendMail(
-		addr,
-		auth,
-		emailConfig.From,
-		[]string{to},
-		[]byte(message),
-	)
+// wrapError 按SMTP响应码区分可重试的错误
+func (s *SMTPEmailSender) wrapError(err error) error {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		kind := ErrSendUnavailable
+		if protoErr.Code >= 500 {
+			kind = ErrSendRejected
+		}
+		return &SendError{Sender: s.Name(), Kind: kind, StatusCode: protoErr.Code, Message: protoErr.Msg}
+	}
+	return &SendError{Sender: s.Name(), Kind: ErrSendUnavailable, Message: err.Error()}
 }
 
-// 发送验证码邮件
-func SendVerificationEmail(to, code string) error {
-	// 在测试环境中，我们总是保存验证码到内存中，以便后续验证
-	codeKey := fmt.Sprintf("email:%s", to)
-	SaveVerificationCode(codeKey, code)
-
-	// 在开发环境中打印验证码，方便调试
-	if gin.Mode() == gin.DebugMode {
-		fmt.Printf("验证码已生成 - 目标邮箱: %s, 验证码: %s\n", to, code)
-	}
-
-	// 构建邮件内容
-	subject := "验证码 - 您的账号注册"
-	body := fmt.Sprintf(`
-		<html>
-		<body>
-			<h2>验证码</h2>
-			<p>您的验证码是: <strong>%s</strong></p>
-			<p>验证码有效期为10分钟，请勿泄露给他人。</p>
-			<p>如果这不是您的操作，请忽略此邮件。</p>
-		</body>
-		</html>
-	`, code)
-
-	// 尝试发送邮件
-	err := SendEmail(to, subject, body)
-	if err != nil {
-		fmt.Printf("发送邮件失败: %v\n", err)
+// buildEmailMIME 生成邮件内容，同时有纯文本和HTML时使用 multipart/alternative
+func buildEmailMIME(from string, msg *EmailMessage) ([]byte, error) {
+	id := make([]byte, 16)
+	rand.Read(id)
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), domain)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if msg.Text == "" || msg.HTML == "" {
+		contentType, body := "text/html", msg.HTML
+		if msg.HTML == "" {
+			contentType, body = "text/plain", msg.Text
+		}
+		fmt.Fprintf(&buf, "Content-Type: %s; charset=UTF-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n", contentType)
+		if err := writeQuotedPrintable(&buf, body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
 
-		// 即使发送失败，我们也返回nil，表示验证码已生成
-		return nil
+	parts := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=UTF-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
 	}
+	return buf.Bytes(), nil
+}
 
-	// 在开发环境中打印成功信息
-	if gin.Mode() == gin.DebugMode {
-		fmt.Printf("邮件发送成功，验证码: %s\n", code)
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
 	}
-	return nil
+	return qp.Close()
+}
+
+// SendEmail 直接发送一封HTML邮件，不套用模板，也不限制发送频率
+func SendEmail(to, subject, body string) error {
+	config, sender, _ := currentSenders()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	msg := &EmailMessage{To: to, Subject: subject, HTML: body}
+	return sendWithRetry(ctx, config, func() error {
+		return sender.SendEmail(ctx, msg)
+	})
+}
+
+// SendVerificationEmail 发送注册验证码邮件，lang 为邮件使用的语言
+func SendVerificationEmail(to, code, lang string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	return SendTemplateEmail(ctx, to, "verification_code", lang, map[string]interface{}{
+		"Code":    code,
+		"Minutes": 10,
+	})
 }
 
 // 验证邮箱格式
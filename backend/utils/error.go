@@ -4,6 +4,8 @@ package utils
 type AppError struct {
 	Code    int
 	Message string
+	Key     string        // 消息键，设置时接口按请求语言返回对应文本
+	Args    []interface{} // 消息文本的格式化参数
 }
 
 func (e *AppError) Error() string {
@@ -11,17 +11,6 @@ func FormatMoney(amount float64) string {
 	return fmt.Sprintf("%.2f 元", amount)
 }
 
-// 生成随机验证码（已在verification.go中定义，此处为兼容保留）
-func GenerateRandomCodeCompat(length int) string {
-	rand.Seed(time.Now().UnixNano())
-	const digits = "0123456789"
-	code := make([]byte, length)
-	for i := 0; i < length; i++ {
-		code[i] = digits[rand.Intn(len(digits))]
-	}
-	return string(code)
-}
-
 // 生成随机字符串
 func GenerateRandomString(length int) string {
 	rand.Seed(time.Now().UnixNano())
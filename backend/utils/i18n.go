@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 服务端消息的多语言支持：接口返回的提示文本通过消息键从语言包中获取
+// 查找顺序：用户自定义文本 > 管理员导入的语言包 > 内置语言包 > 默认语言(zh-CN) > 消息键本身
+
+// 默认语言，其内置语言包包含全部消息键
+const DefaultLanguage = "zh-CN"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var (
+	i18nMutex sync.RWMutex
+	// 内置语言包，随程序发布，按语言代码索引
+	shippedLanguagePacks = map[string]map[string]string{}
+	// 管理员导入的语言包，覆盖内置语言包中的同名消息键
+	importedLanguagePacks = map[string]map[string]string{}
+)
+
+// 初始化函数，加载内置语言包
+func init() {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("读取内置语言包失败: %v", err))
+	}
+	for _, entry := range entries {
+		data, err := localeFiles.ReadFile("locales/" + entry.Name())
+		if err != nil {
+			panic(fmt.Sprintf("读取内置语言包 %s 失败: %v", entry.Name(), err))
+		}
+		var pack map[string]string
+		if err := json.Unmarshal(data, &pack); err != nil {
+			panic(fmt.Sprintf("内置语言包 %s 格式错误: %v", entry.Name(), err))
+		}
+		shippedLanguagePacks[strings.TrimSuffix(entry.Name(), ".json")] = pack
+	}
+}
+
+// ShippedLanguages 内置语言包的语言代码
+func ShippedLanguages() []string {
+	langs := make([]string, 0, len(shippedLanguagePacks))
+	for lang := range shippedLanguagePacks {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// ShippedLanguagePack 内置语言包的副本，不存在时返回 nil
+func ShippedLanguagePack(lang string) map[string]string {
+	pack, ok := shippedLanguagePacks[lang]
+	if !ok {
+		return nil
+	}
+	return copyMessages(pack)
+}
+
+// IsMessageKey 消息键是否存在于默认语言的内置语言包中
+func IsMessageKey(key string) bool {
+	_, ok := shippedLanguagePacks[DefaultLanguage][key]
+	return ok
+}
+
+// 消息中的格式化参数，如 %d、%s
+var messageVerbPattern = regexp.MustCompile(`%[a-z]`)
+
+// MessageVerbsMatch 文本的格式化参数是否与默认语言中该消息键的一致
+// 不一致时按默认语言的参数格式化会输出 %!d(MISSING) 之类的文本
+func MessageVerbsMatch(key, text string) bool {
+	want := messageVerbPattern.FindAllString(shippedLanguagePacks[DefaultLanguage][key], -1)
+	got := messageVerbPattern.FindAllString(text, -1)
+	return strings.Join(want, "") == strings.Join(got, "")
+}
+
+// SetImportedLanguagePack 设置管理员导入的语言包，pack 为 nil 时移除
+func SetImportedLanguagePack(lang string, pack map[string]string) {
+	i18nMutex.Lock()
+	defer i18nMutex.Unlock()
+	if pack == nil {
+		delete(importedLanguagePacks, lang)
+		return
+	}
+	importedLanguagePacks[lang] = copyMessages(pack)
+}
+
+// HasLanguagePack 是否有该语言的内置或导入语言包
+func HasLanguagePack(lang string) bool {
+	if _, ok := shippedLanguagePacks[lang]; ok {
+		return true
+	}
+	i18nMutex.RLock()
+	defer i18nMutex.RUnlock()
+	_, ok := importedLanguagePacks[lang]
+	return ok
+}
+
+// LanguagePackMessages 合并内置和导入文本后的语言包，用于导出
+func LanguagePackMessages(lang string) map[string]string {
+	messages := copyMessages(shippedLanguagePacks[lang])
+	i18nMutex.RLock()
+	defer i18nMutex.RUnlock()
+	for key, text := range importedLanguagePacks[lang] {
+		messages[key] = text
+	}
+	return messages
+}
+
+// MatchLanguage 按 Accept-Language 的权重选出第一个有语言包的语言，没有时返回空字符串
+// 如 "en-US,en;q=0.9,zh;q=0.8" 返回 en
+func MatchLanguage(acceptLanguage string) string {
+	type candidate struct {
+		lang   string
+		weight float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		weight := 1.0
+		for _, param := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if q, err := strconv.ParseFloat(v, 64); err == nil {
+					weight = q
+				}
+			}
+		}
+		if weight > 0 {
+			candidates = append(candidates, candidate{lang: NormalizeLanguage(tag), weight: weight})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].weight > candidates[j].weight
+	})
+	for _, c := range candidates {
+		if HasLanguagePack(c.lang) {
+			return c.lang
+		}
+	}
+	return ""
+}
+
+// Localizer 按某个语言取消息文本，Overrides 为用户自定义的文本
+type Localizer struct {
+	Lang      string
+	Overrides map[string]string
+}
+
+// NewLocalizer 创建本地化器，语言没有语言包时使用默认语言
+func NewLocalizer(lang string, overrides map[string]string) *Localizer {
+	if !HasLanguagePack(lang) {
+		lang = DefaultLanguage
+	}
+	return &Localizer{Lang: lang, Overrides: overrides}
+}
+
+// T 获取消息键对应的文本，有参数时按 fmt 格式化
+// 所有语言包都缺少该消息键时返回消息键本身
+func (l *Localizer) T(key string, args ...interface{}) string {
+	lang := DefaultLanguage
+	var text string
+	var ok bool
+	if l != nil {
+		lang = l.Lang
+		text, ok = l.Overrides[key]
+	}
+	if !ok {
+		text, ok = lookupMessage(lang, key)
+	}
+	if !ok && lang != DefaultLanguage {
+		text, ok = lookupMessage(DefaultLanguage, key)
+	}
+	if !ok {
+		Logger.Errorf("缺少消息键: %s", key)
+		return key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+func lookupMessage(lang, key string) (string, bool) {
+	i18nMutex.RLock()
+	text, ok := importedLanguagePacks[lang][key]
+	i18nMutex.RUnlock()
+	if ok {
+		return text, true
+	}
+	text, ok = shippedLanguagePacks[lang][key]
+	return text, ok
+}
+
+func copyMessages(messages map[string]string) map[string]string {
+	result := make(map[string]string, len(messages))
+	for key, text := range messages {
+		result[key] = text
+	}
+	return result
+}
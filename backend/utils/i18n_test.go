@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// 每个内置语言包都必须包含默认语言的全部消息键，且格式化参数一致
+func TestShippedLanguagePacksComplete(t *testing.T) {
+	defaults := ShippedLanguagePack(DefaultLanguage)
+	if len(defaults) == 0 {
+		t.Fatalf("缺少默认语言 %s 的内置语言包", DefaultLanguage)
+	}
+	verbs := regexp.MustCompile(`%[a-z]`)
+	for _, lang := range ShippedLanguages() {
+		pack := ShippedLanguagePack(lang)
+		for key, text := range defaults {
+			translated, ok := pack[key]
+			if !ok || translated == "" {
+				t.Errorf("语言包 %s 缺少消息键 %s", lang, key)
+				continue
+			}
+			if want, got := verbs.FindAllString(text, -1), verbs.FindAllString(translated, -1); strings.Join(want, "") != strings.Join(got, "") {
+				t.Errorf("语言包 %s 的消息键 %s 格式化参数为 %v，应为 %v", lang, key, got, want)
+			}
+		}
+		for key := range pack {
+			if _, ok := defaults[key]; !ok {
+				t.Errorf("语言包 %s 包含默认语言没有的消息键 %s", lang, key)
+			}
+		}
+	}
+}
+
+// 代码中使用的消息键必须存在于默认语言的内置语言包中，测试文件不检查
+func TestMessageKeysDefined(t *testing.T) {
+	usage := regexp.MustCompile(`(?:\btr\(c, |\bT\(c, |sendError\(|AppError\{[^{}]*\bKey:\s+)"([a-z0-9_.]+)"`)
+	dirs := []string{".", "../controllers", "../middleware", "../services", "../routes",
+		"../internal/auth/captcha", "../internal/auth/login", "../internal/auth/register", "../internal/auth/sms"}
+	for _, dir := range dirs {
+		files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, file := range files {
+			if strings.HasSuffix(file, "_test.go") {
+				continue
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, match := range usage.FindAllStringSubmatch(string(data), -1) {
+				if !IsMessageKey(match[1]) {
+					t.Errorf("%s 使用了未定义的消息键 %s", file, match[1])
+				}
+			}
+		}
+	}
+}
+
+func TestMatchLanguage(t *testing.T) {
+	cases := map[string]string{
+		"en-US,en;q=0.9,zh;q=0.8": "en",
+		"fr;q=0.9,zh-CN;q=0.5":    "zh-CN",
+		"zh_CN":                   "zh-CN",
+		"fr,de":                   "",
+		"":                        "",
+	}
+	for header, want := range cases {
+		if got := MatchLanguage(header); got != want {
+			t.Errorf("MatchLanguage(%q) = %q，应为 %q", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,473 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// 图片处理：按文件头识别格式，校验尺寸，去除EXIF/GPS等元数据并生成缩略图
+// 元数据尽量无损去除；JPEG 带有旋转信息时按方向旋转后重新编码
+
+// 图片用途，不同用途的限制不同
+const (
+	ImageKindImage   = "image"
+	ImageKindAvatar  = "avatar"
+	ImageKindSticker = "sticker" // 表情，GIF 动图原样保留
+)
+
+// ImageLimit 图片的大小和尺寸限制
+type ImageLimit struct {
+	MaxSize      int64 // 文件大小（字节）
+	MaxDimension int   // 最长边（像素）
+	MaxPixels    int   // 总像素数，防止解码时占用过多内存
+}
+
+// ImageLimits 各用途的图片限制
+var ImageLimits = map[string]ImageLimit{
+	ImageKindImage:   {MaxSize: 20 << 20, MaxDimension: 8192, MaxPixels: 40000000},
+	ImageKindAvatar:  {MaxSize: 5 << 20, MaxDimension: 4096, MaxPixels: 16000000},
+	ImageKindSticker: {MaxSize: 2 << 20, MaxDimension: 1024, MaxPixels: 1 << 20},
+}
+
+// ImageRendition 缩略图规格，长边缩放到不超过 MaxSide
+type ImageRendition struct {
+	Name    string
+	MaxSide int
+}
+
+// ImageRenditions 上传时生成的缩略图规格
+var ImageRenditions = []ImageRendition{
+	{Name: "thumb", MaxSide: 240},
+	{Name: "medium", MaxSide: 1080},
+}
+
+// 图片处理错误类型，可通过 errors.Is 判断
+var (
+	ErrImageInvalid  = errors.New("不支持的图片格式或文件已损坏")
+	ErrImageTooLarge = errors.New("图片大小或尺寸超过限制")
+)
+
+// imageFormats 支持的图片格式和对应的 Content-Type、扩展名
+var imageFormats = map[string][2]string{
+	"jpeg": {"image/jpeg", ".jpg"},
+	"png":  {"image/png", ".png"},
+	"gif":  {"image/gif", ".gif"},
+	"webp": {"image/webp", ".webp"},
+}
+
+// EncodedImage 编码后的图片
+type EncodedImage struct {
+	Name        string // 缩略图规格名称，原图为空
+	Data        []byte
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+}
+
+// ProcessedImage 处理后的图片，原图已去除元数据
+type ProcessedImage struct {
+	Format     string
+	Original   EncodedImage
+	Renditions []EncodedImage // 只包含比原图小的规格
+}
+
+// SniffImageFormat 根据文件头识别图片格式，不是支持的图片时返回空字符串
+func SniffImageFormat(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return "jpeg"
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "png"
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return "gif"
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return "webp"
+	}
+	return ""
+}
+
+// ImageContentType 图片格式对应的 Content-Type
+func ImageContentType(format string) string {
+	return imageFormats[format][0]
+}
+
+// ProcessImage 校验图片并去除元数据，生成缩略图
+func ProcessImage(data []byte, kind string) (*ProcessedImage, error) {
+	limit, ok := ImageLimits[kind]
+	if !ok {
+		limit = ImageLimits[ImageKindImage]
+	}
+	if int64(len(data)) > limit.MaxSize {
+		return nil, fmt.Errorf("%w: 文件不能超过%dMB", ErrImageTooLarge, limit.MaxSize>>20)
+	}
+	format := SniffImageFormat(data)
+	if format == "" {
+		return nil, ErrImageInvalid
+	}
+
+	// 解码前先检查尺寸
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrImageInvalid
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, ErrImageInvalid
+	}
+	if config.Width > limit.MaxDimension || config.Height > limit.MaxDimension || config.Width*config.Height > limit.MaxPixels {
+		return nil, fmt.Errorf("%w: 尺寸不能超过%dx%d", ErrImageTooLarge, limit.MaxDimension, limit.MaxDimension)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrImageInvalid
+	}
+
+	var stripped []byte
+	switch format {
+	case "jpeg":
+		if orientation := jpegOrientation(data); orientation > 1 && orientation <= 8 {
+			// 去除EXIF会丢失方向信息，按方向旋转后重新编码
+			img = applyOrientation(img, orientation)
+			stripped, err = encodeImage(img, "jpeg", 90)
+		} else {
+			stripped, err = stripJPEGMetadata(data)
+		}
+	case "png":
+		stripped, err = stripPNGMetadata(data)
+	case "gif":
+		stripped, err = stripGIFMetadata(data)
+	case "webp":
+		stripped, err = stripWebPMetadata(data)
+	}
+	if err != nil {
+		return nil, ErrImageInvalid
+	}
+
+	bounds := img.Bounds()
+	result := &ProcessedImage{
+		Format: format,
+		Original: EncodedImage{
+			Data:        stripped,
+			ContentType: imageFormats[format][0],
+			Ext:         imageFormats[format][1],
+			Width:       bounds.Dx(),
+			Height:      bounds.Dy(),
+		},
+	}
+
+	// 有透明通道的图片缩略图使用PNG，其余使用JPEG
+	thumbFormat := "jpeg"
+	if opaque, ok := img.(interface{ Opaque() bool }); !ok || !opaque.Opaque() {
+		if format != "jpeg" {
+			thumbFormat = "png"
+		}
+	}
+	for _, rendition := range ImageRenditions {
+		if bounds.Dx() <= rendition.MaxSide && bounds.Dy() <= rendition.MaxSide {
+			continue
+		}
+		resized := resizeImage(img, rendition.MaxSide, thumbFormat == "jpeg")
+		encoded, err := encodeImage(resized, thumbFormat, 80)
+		if err != nil {
+			return nil, err
+		}
+		result.Renditions = append(result.Renditions, EncodedImage{
+			Name:        rendition.Name,
+			Data:        encoded,
+			ContentType: imageFormats[thumbFormat][0],
+			Ext:         imageFormats[thumbFormat][1],
+			Width:       resized.Bounds().Dx(),
+			Height:      resized.Bounds().Dy(),
+		})
+	}
+	return result, nil
+}
+
+func encodeImage(img image.Image, format string, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if format == "png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	}
+	return buf.Bytes(), err
+}
+
+// resizeImage 等比缩放到长边不超过 maxSide，输出JPEG时透明部分填充白色
+func resizeImage(img image.Image, maxSide int, flatten bool) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width >= height {
+		height = max(1, height*maxSide/width)
+		width = maxSide
+	} else {
+		width = max(1, width*maxSide/height)
+		height = maxSide
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	if flatten {
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	}
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+	return dst
+}
+
+// applyOrientation 按EXIF方向值（1-8）旋转或翻转图片
+func applyOrientation(img image.Image, orientation int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			default:
+				sx, sy = x, y
+			}
+			dst.Set(x, y, img.At(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// jpegOrientation 读取JPEG中EXIF的方向值，没有时返回0
+func jpegOrientation(data []byte) int {
+	pos := 2
+	for pos+4 <= len(data) && data[pos] == 0xFF {
+		marker := data[pos+1]
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+		size := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if size < 2 || pos+2+size > len(data) {
+			break
+		}
+		segment := data[pos+4 : pos+2+size]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		pos += 2 + size
+	}
+	return 0
+}
+
+// exifOrientation 在TIFF格式的EXIF数据的 IFD0 中查找方向标签 0x0112
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[0:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[offset : offset+2]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8 : entry+10]))
+		}
+	}
+	return 0
+}
+
+// stripJPEGMetadata 去除 APP1-APP15 和注释段，保留 JFIF 和 ICC 颜色配置
+func stripJPEGMetadata(data []byte) ([]byte, error) {
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[0:2])
+	pos := 2
+	for {
+		if pos+4 > len(data) || data[pos] != 0xFF {
+			return nil, ErrImageInvalid
+		}
+		marker := data[pos+1]
+		if marker == 0xFF {
+			// 段之间的填充字节
+			pos++
+			continue
+		}
+		if marker == 0xDA {
+			// 图像数据开始，之后原样保留
+			out.Write(data[pos:])
+			return out.Bytes(), nil
+		}
+		size := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if size < 2 || pos+2+size > len(data) {
+			return nil, ErrImageInvalid
+		}
+		segment := data[pos : pos+2+size]
+		isICC := marker == 0xE2 && bytes.HasPrefix(segment[4:], []byte("ICC_PROFILE"))
+		if !(marker >= 0xE1 && marker <= 0xEF && !isICC) && marker != 0xFE {
+			out.Write(segment)
+		}
+		pos += 2 + size
+	}
+}
+
+// stripPNGMetadata 去除文本、EXIF和时间等辅助块
+func stripPNGMetadata(data []byte) ([]byte, error) {
+	drop := map[string]bool{"tEXt": true, "zTXt": true, "iTXt": true, "eXIf": true, "tIME": true}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[0:8])
+	pos := 8
+	for pos+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		end := pos + 12 + length
+		if end > len(data) {
+			return nil, ErrImageInvalid
+		}
+		chunkType := string(data[pos+4 : pos+8])
+		if !drop[chunkType] {
+			out.Write(data[pos:end])
+		}
+		pos = end
+		if chunkType == "IEND" {
+			return out.Bytes(), nil
+		}
+	}
+	return nil, ErrImageInvalid
+}
+
+// stripGIFMetadata 去除注释扩展和除循环播放外的应用扩展（如XMP）
+func stripGIFMetadata(data []byte) ([]byte, error) {
+	if len(data) < 13 {
+		return nil, ErrImageInvalid
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	pos := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		pos += 3 << ((flags & 0x07) + 1)
+	}
+	if pos > len(data) {
+		return nil, ErrImageInvalid
+	}
+	out.Write(data[0:pos])
+
+	// skipSubBlocks 返回数据子块序列结束后的位置
+	skipSubBlocks := func(p int) (int, error) {
+		for p < len(data) {
+			size := int(data[p])
+			p += 1 + size
+			if size == 0 {
+				return p, nil
+			}
+		}
+		return 0, ErrImageInvalid
+	}
+
+	for pos < len(data) {
+		start := pos
+		switch data[pos] {
+		case 0x3B:
+			out.WriteByte(0x3B)
+			return out.Bytes(), nil
+		case 0x2C:
+			// 图像描述符，可能带局部颜色表，之后是LZW最小码长和数据子块
+			if pos+10 > len(data) {
+				return nil, ErrImageInvalid
+			}
+			pos += 10
+			if flags := data[start+9]; flags&0x80 != 0 {
+				pos += 3 << ((flags & 0x07) + 1)
+			}
+			next, err := skipSubBlocks(pos + 1)
+			if err != nil {
+				return nil, err
+			}
+			out.Write(data[start:next])
+			pos = next
+		case 0x21:
+			if pos+2 > len(data) {
+				return nil, ErrImageInvalid
+			}
+			label := data[pos+1]
+			next, err := skipSubBlocks(pos + 2)
+			if err != nil {
+				return nil, err
+			}
+			keep := label != 0xFE
+			if label == 0xFF {
+				appID := data[pos+2 : min(next, pos+14)]
+				keep = bytes.Contains(appID, []byte("NETSCAPE2.0")) || bytes.Contains(appID, []byte("ANIMEXTS1.0"))
+			}
+			if keep {
+				out.Write(data[start:next])
+			}
+			pos = next
+		default:
+			return nil, ErrImageInvalid
+		}
+	}
+	return nil, ErrImageInvalid
+}
+
+// stripWebPMetadata 去除EXIF和XMP块，并清除 VP8X 中对应的标志位
+func stripWebPMetadata(data []byte) ([]byte, error) {
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[0:12])
+	pos := 12
+	for pos+8 <= len(data) {
+		chunkType := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		end := pos + 8 + size + size%2
+		if end > len(data) {
+			return nil, ErrImageInvalid
+		}
+		switch chunkType {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte{}, data[pos:end]...)
+			if len(chunk) > 8 {
+				chunk[8] &^= 0x08 | 0x04
+			}
+			out.Write(chunk)
+		default:
+			out.Write(data[pos:end])
+		}
+		pos = end
+	}
+	result := out.Bytes()
+	binary.LittleEndian.PutUint32(result[4:8], uint32(len(result)-8))
+	return result, nil
+}
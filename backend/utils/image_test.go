@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// testImage 生成 w x h 的不透明图片，左上角为红色，便于检查旋转方向
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{0, 0, 255, 255})
+		}
+	}
+	for y := 0; y < h/2; y++ {
+		for x := 0; x < w/2; x++ {
+			img.Set(x, y, color.RGBA{255, 0, 0, 255})
+		}
+	}
+	return img
+}
+
+// exifSegment 生成只包含方向标签的 APP1 EXIF 段
+func exifSegment(orientation uint16) []byte {
+	tiff := []byte("II*\x00\x08\x00\x00\x00\x01\x00")
+	entry := make([]byte, 12)
+	binary.LittleEndian.PutUint16(entry[0:2], 0x0112)
+	binary.LittleEndian.PutUint16(entry[2:4], 3)
+	binary.LittleEndian.PutUint32(entry[4:8], 1)
+	binary.LittleEndian.PutUint16(entry[8:10], orientation)
+	tiff = append(tiff, entry...)
+	tiff = append(tiff, 0, 0, 0, 0)
+	return jpegSegment(0xE1, append([]byte("Exif\x00\x00"), tiff...))
+}
+
+func jpegSegment(marker byte, payload []byte) []byte {
+	segment := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:4], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// testJPEG 编码 JPEG，并在文件头之后插入 segments
+func testJPEG(t *testing.T, w, h int, segments ...[]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(w, h), &jpeg.Options{Quality: 90}); err != nil {
+		t.Fatalf("编码JPEG失败: %v", err)
+	}
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	for _, segment := range segments {
+		out = append(out, segment...)
+	}
+	return append(out, data[2:]...)
+}
+
+// testPNG 编码 PNG，并在 IEND 之前插入 chunk 块
+func testPNG(t *testing.T, w, h int, chunks ...[]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(w, h)); err != nil {
+		t.Fatalf("编码PNG失败: %v", err)
+	}
+	data := buf.Bytes()
+	out := append([]byte{}, data[:len(data)-12]...)
+	for _, chunk := range chunks {
+		out = append(out, chunk...)
+	}
+	return append(out, data[len(data)-12:]...)
+}
+
+func pngChunk(chunkType string, payload []byte) []byte {
+	chunk := make([]byte, 8, 12+len(payload))
+	binary.BigEndian.PutUint32(chunk[0:4], uint32(len(payload)))
+	copy(chunk[4:8], chunkType)
+	chunk = append(chunk, payload...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+func TestSniffImageFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE0}, "jpeg"},
+		{"png", []byte("\x89PNG\r\n\x1a\n...."), "png"},
+		{"gif", []byte("GIF89a...."), "gif"},
+		{"webp", []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), "webp"},
+		{"其他RIFF格式", []byte("RIFF\x00\x00\x00\x00WAVEfmt "), ""},
+		{"文本", []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"/>"), ""},
+		{"空文件", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := SniffImageFormat(tt.data); got != tt.want {
+			t.Errorf("%s: 得到 %q，应为 %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestProcessImageStripsMetadata(t *testing.T) {
+	secret := []byte("GPS 31.2304N 121.4737E")
+
+	jpegData := testJPEG(t, 64, 48, exifSegment(1), jpegSegment(0xE1, append([]byte("http://ns.adobe.com/xap/1.0/\x00"), secret...)), jpegSegment(0xFE, secret))
+	pngData := testPNG(t, 64, 48, pngChunk("tEXt", append([]byte("Comment\x00"), secret...)), pngChunk("tIME", make([]byte, 7)))
+
+	var gifBuf bytes.Buffer
+	gif.Encode(&gifBuf, testImage(64, 48), nil)
+	gifData := gifBuf.Bytes()
+	comment := append([]byte{0x21, 0xFE, byte(len(secret))}, secret...)
+	gifData = append(append(append([]byte{}, gifData[:len(gifData)-1]...), append(comment, 0x00)...), 0x3B)
+
+	tests := []struct {
+		name   string
+		data   []byte
+		format string
+	}{
+		{"jpeg", jpegData, "jpeg"},
+		{"png", pngData, "png"},
+		{"gif", gifData, "gif"},
+	}
+	for _, tt := range tests {
+		if !bytes.Contains(tt.data, secret) {
+			t.Fatalf("%s: 测试图片应包含元数据", tt.name)
+		}
+		processed, err := ProcessImage(tt.data, ImageKindImage)
+		if err != nil {
+			t.Fatalf("%s: 处理图片失败: %v", tt.name, err)
+		}
+		original := processed.Original
+		if processed.Format != tt.format || original.ContentType != ImageContentType(tt.format) {
+			t.Errorf("%s: 格式为 %s %s", tt.name, processed.Format, original.ContentType)
+		}
+		if bytes.Contains(original.Data, secret) || bytes.Contains(original.Data, []byte("Exif")) {
+			t.Errorf("%s: 处理后仍包含元数据", tt.name)
+		}
+		config, format, err := image.DecodeConfig(bytes.NewReader(original.Data))
+		if err != nil || format != tt.format || config.Width != 64 || config.Height != 48 {
+			t.Errorf("%s: 处理后的图片无法解码或尺寸改变: %s %dx%d %v", tt.name, format, config.Width, config.Height, err)
+		}
+		if original.Width != 64 || original.Height != 48 || len(processed.Renditions) != 0 {
+			t.Errorf("%s: 小图不应生成缩略图，得到 %dx%d %d", tt.name, original.Width, original.Height, len(processed.Renditions))
+		}
+	}
+
+	// 不需要旋转时JPEG无损去除元数据，图像数据不变
+	stripped, _ := ProcessImage(jpegData, ImageKindImage)
+	plain := testJPEG(t, 64, 48)
+	if !bytes.Equal(stripped.Original.Data, plain) {
+		t.Error("没有旋转信息的JPEG应只去除元数据段")
+	}
+}
+
+func TestProcessImageOrientation(t *testing.T) {
+	// 方向6表示需要顺时针旋转90度，左上角的红色旋转到右上角
+	processed, err := ProcessImage(testJPEG(t, 40, 20, exifSegment(6)), ImageKindImage)
+	if err != nil {
+		t.Fatalf("处理图片失败: %v", err)
+	}
+	if processed.Original.Width != 20 || processed.Original.Height != 40 {
+		t.Errorf("旋转后尺寸应为20x40，得到 %dx%d", processed.Original.Width, processed.Original.Height)
+	}
+	if jpegOrientation(processed.Original.Data) != 0 {
+		t.Error("旋转后不应保留方向信息")
+	}
+	img, err := jpeg.Decode(bytes.NewReader(processed.Original.Data))
+	if err != nil {
+		t.Fatalf("解码旋转后的图片失败: %v", err)
+	}
+	if r, _, b, _ := img.At(15, 5).RGBA(); r < b {
+		t.Error("右上角应为原图左上角的红色")
+	}
+	if r, _, b, _ := img.At(5, 5).RGBA(); r > b {
+		t.Error("左上角应为原图左下角的蓝色")
+	}
+}
+
+func TestProcessImageLimits(t *testing.T) {
+	processed, err := ProcessImage(testPNG(t, 2000, 100), ImageKindImage)
+	if err != nil {
+		t.Fatalf("处理图片失败: %v", err)
+	}
+	if len(processed.Renditions) != len(ImageRenditions) {
+		t.Fatalf("应生成 %d 个缩略图，得到 %d", len(ImageRenditions), len(processed.Renditions))
+	}
+	for i, rendition := range processed.Renditions {
+		spec := ImageRenditions[i]
+		if rendition.Name != spec.Name || rendition.Width != spec.MaxSide || rendition.ContentType != "image/jpeg" {
+			t.Errorf("缩略图 %s 为 %dx%d %s", rendition.Name, rendition.Width, rendition.Height, rendition.ContentType)
+		}
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		kind string
+		err  error
+	}{
+		{"伪装成图片的文本", []byte("<html>not an image</html>"), ImageKindImage, ErrImageInvalid},
+		{"文件头正确但已损坏", testPNG(t, 10, 10)[:40], ImageKindImage, ErrImageInvalid},
+		{"表情尺寸超限", testPNG(t, 1100, 10), ImageKindSticker, ErrImageTooLarge},
+		{"头像文件过大", append([]byte{0xFF, 0xD8, 0xFF}, make([]byte, 5<<20)...), ImageKindAvatar, ErrImageTooLarge},
+	}
+	for _, tt := range tests {
+		if _, err := ProcessImage(tt.data, tt.kind); !errors.Is(err, tt.err) {
+			t.Errorf("%s: 得到 %v，应为 %v", tt.name, err, tt.err)
+		}
+	}
+}
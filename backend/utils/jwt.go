@@ -2,45 +2,213 @@ package utils
 
 import (
 	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-// 定义JWT密钥
-var jwtSecret = []byte("allinone_secret_key")
+// JWT签名密钥支持轮换：令牌头的 kid 标明签名密钥，新令牌使用当前密钥签名，
+// 旧密钥保留在配置中用于校验轮换前签发的令牌，待其全部过期后再移除
+//
+// 环境变量：
+//   JWT_KEYS        多个密钥，格式为 kid1:secret1,kid2:secret2
+//   JWT_ACTIVE_KID  签发新令牌使用的密钥，默认为 JWT_KEYS 中的最后一个
+//   JWT_SECRET      只配置一个密钥时使用，kid 为 default
+//   JWT_ACCESS_TTL  访问令牌有效期（秒），默认15分钟
+//   JWT_REFRESH_TTL 刷新令牌有效期（秒），默认30天
+//   MINIAPP_OPENID_SECRET 派生小程序 open_id 的密钥，不随JWT密钥轮换
+//
+// 必须配置至少一个密钥，否则服务无法启动；没有 kid 的旧令牌使用 kid 为 legacy 的密钥校验，未配置时拒绝
+
+// 没有 kid 的旧令牌对应的密钥ID
+const legacyJWTKeyID = "legacy"
+
+// 缺少密钥配置时的错误
+var (
+	ErrJWTNoKey           = errors.New("未配置JWT签名密钥 JWT_SECRET 或 JWT_KEYS")
+	ErrMiniAppOpenIDNoKey = errors.New("未配置小程序用户标识密钥 MINIAPP_OPENID_SECRET")
+)
+
+// JWT配置
+type JWTConfig struct {
+	Keys        map[string][]byte
+	ActiveKeyID string
+
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+	Issuer     string
+
+	// 派生小程序 open_id 的密钥，open_id 需要长期不变，单独配置
+	MiniAppOpenIDSecret []byte
+}
+
+var (
+	jwtConfigMu sync.RWMutex
+	jwtConfig   JWTConfig
+	// 从环境变量加载配置失败的原因，由 CheckJWTConfig 在启动时返回
+	jwtConfigErr error
+)
+
+// 初始化函数，从环境变量加载JWT配置
+func init() {
+	loadJWTConfig()
+}
+
+// loadJWTConfig 从环境变量加载JWT配置，配置有误时记录原因，不在初始化时直接退出
+func loadJWTConfig() {
+	config := JWTConfig{
+		Keys:       map[string][]byte{},
+		AccessTTL:  15 * time.Minute,
+		RefreshTTL: 30 * 24 * time.Hour,
+		Issuer:     "allinone",
+
+		MiniAppOpenIDSecret: []byte(os.Getenv("MINIAPP_OPENID_SECRET")),
+	}
+	for _, item := range strings.Split(os.Getenv("JWT_KEYS"), ",") {
+		kid, secret, ok := strings.Cut(strings.TrimSpace(item), ":")
+		if ok && kid != "" && secret != "" {
+			config.Keys[kid] = []byte(secret)
+			config.ActiveKeyID = kid
+		}
+	}
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		config.Keys["default"] = []byte(secret)
+		if config.ActiveKeyID == "" {
+			config.ActiveKeyID = "default"
+		}
+	}
+	if kid := os.Getenv("JWT_ACTIVE_KID"); kid != "" {
+		config.ActiveKeyID = kid
+	}
+	if v, err := strconv.Atoi(os.Getenv("JWT_ACCESS_TTL")); err == nil && v > 0 {
+		config.AccessTTL = time.Duration(v) * time.Second
+	}
+	if v, err := strconv.Atoi(os.Getenv("JWT_REFRESH_TTL")); err == nil && v > 0 {
+		config.RefreshTTL = time.Duration(v) * time.Second
+	}
+	if err := SetJWTConfig(config); err != nil {
+		jwtConfigMu.Lock()
+		jwtConfigErr = err
+		jwtConfigMu.Unlock()
+	}
+}
+
+// SetJWTConfig 设置JWT配置，未配置密钥时无法签发和校验令牌，启动时由 CheckJWTConfig 拒绝
+func SetJWTConfig(config JWTConfig) error {
+	if _, ok := config.Keys[config.ActiveKeyID]; len(config.Keys) > 0 && !ok {
+		return fmt.Errorf("JWT签名密钥 %q 不存在", config.ActiveKeyID)
+	}
+	if config.AccessTTL <= 0 {
+		config.AccessTTL = 15 * time.Minute
+	}
+	if config.RefreshTTL <= 0 {
+		config.RefreshTTL = 30 * 24 * time.Hour
+	}
+	if config.Issuer == "" {
+		config.Issuer = "allinone"
+	}
+	jwtConfigMu.Lock()
+	jwtConfig = config
+	jwtConfigErr = nil
+	jwtConfigMu.Unlock()
+	return nil
+}
+
+// GetJWTConfig 获取当前JWT配置
+func GetJWTConfig() JWTConfig {
+	jwtConfigMu.RLock()
+	defer jwtConfigMu.RUnlock()
+	return jwtConfig
+}
+
+// CheckJWTConfig 检查JWT配置能否加载，以及是否配置了签名密钥和小程序用户标识密钥，启动时调用
+func CheckJWTConfig() error {
+	jwtConfigMu.RLock()
+	err := jwtConfigErr
+	jwtConfigMu.RUnlock()
+	if err != nil {
+		return err
+	}
+	config := GetJWTConfig()
+	if len(config.Keys) == 0 {
+		return ErrJWTNoKey
+	}
+	if len(config.MiniAppOpenIDSecret) == 0 {
+		return ErrMiniAppOpenIDNoKey
+	}
+	return nil
+}
+
+// activeSigningKey 签发新令牌使用的密钥
+func activeSigningKey() (string, []byte) {
+	config := GetJWTConfig()
+	return config.ActiveKeyID, config.Keys[config.ActiveKeyID]
+}
+
+// signingKey 按 kid 查找校验密钥，没有 kid 的令牌为轮换前签发的旧令牌，按 legacy 查找
+func signingKey(token *jwt.Token) ([]byte, error) {
+	config := GetJWTConfig()
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = legacyJWTKeyID
+	}
+	key, ok := config.Keys[kid]
+	if !ok || len(key) == 0 {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
 
 // Claims 自定义JWT声明结构
+// SessionID 为登录会话（设备）的ID，退出登录或远程下线时整个会话的令牌失效；ID（jti）标识单个令牌
 type Claims struct {
-	UserID  uint   `json:"user_id"`
-	Account string `json:"account"`
+	UserID    uint   `json:"user_id"`
+	Account   string `json:"account"`
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken 生成JWT
-func GenerateToken(userID uint, account string) (string, error) {
-	// 设置token有效期为7天
-	expirationTime := time.Now().Add(7 * 24 * time.Hour)
+// GenerateAccessToken 为登录会话生成短期访问令牌
+func GenerateAccessToken(userID uint, account, sessionID string) (string, *Claims, error) {
+	config := GetJWTConfig()
+	now := time.Now()
 
 	claims := &Claims{
-		UserID:  userID,
-		Account: account,
+		UserID:    userID,
+		Account:   account,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "allinone",
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(config.AccessTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    config.Issuer,
 		},
 	}
 
+	kid, key := activeSigningKey()
+	if len(key) == 0 {
+		return "", nil, ErrJWTNoKey
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
 }
 
-// ParseToken 解析JWT
+// ParseToken 解析JWT，只校验签名和有效期，是否已吊销由调用方检查
 func ParseToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+		return signingKey(token)
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// useJWTConfig 使用指定的JWT配置，测试结束后恢复原配置
+func useJWTConfig(t *testing.T, config JWTConfig) {
+	t.Helper()
+	previous := GetJWTConfig()
+	if err := SetJWTConfig(config); err != nil {
+		t.Fatalf("设置JWT配置失败: %v", err)
+	}
+	t.Cleanup(func() { SetJWTConfig(previous) })
+}
+
+func TestJWTRequiresKey(t *testing.T) {
+	useJWTConfig(t, JWTConfig{})
+	if err := CheckJWTConfig(); !errors.Is(err, ErrJWTNoKey) {
+		t.Errorf("未配置密钥时应拒绝启动，得到 %v", err)
+	}
+	if _, _, err := GenerateAccessToken(1, "alice", "sid"); !errors.Is(err, ErrJWTNoKey) {
+		t.Errorf("未配置密钥时不应签发令牌，得到 %v", err)
+	}
+	if _, _, err := GenerateMiniAppToken(1, "app", nil, time.Minute); !errors.Is(err, ErrJWTNoKey) {
+		t.Errorf("未配置密钥时不应签发小程序令牌，得到 %v", err)
+	}
+
+	// 使用曾经的默认密钥签名、没有 kid 的旧令牌不再被接受
+	old := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{UserID: 1, Account: "alice",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}})
+	signed, _ := old.SignedString([]byte("allinone_secret_key"))
+	if _, err := ParseToken(signed); err == nil {
+		t.Error("不应接受默认密钥签名的令牌")
+	}
+
+	if err := SetJWTConfig(JWTConfig{Keys: map[string][]byte{"a": []byte("secret")}, ActiveKeyID: "b"}); err == nil {
+		t.Error("当前密钥不存在时应报错")
+	}
+	useJWTConfig(t, JWTConfig{Keys: map[string][]byte{"a": []byte("secret")}, ActiveKeyID: "a"})
+	if err := CheckJWTConfig(); !errors.Is(err, ErrMiniAppOpenIDNoKey) {
+		t.Errorf("未配置小程序用户标识密钥时应拒绝启动，得到 %v", err)
+	}
+	useJWTConfig(t, JWTConfig{Keys: map[string][]byte{"a": []byte("secret")}, ActiveKeyID: "a", MiniAppOpenIDSecret: []byte("openid")})
+	if err := CheckJWTConfig(); err != nil {
+		t.Errorf("配置完整时不应报错: %v", err)
+	}
+}
+
+func TestJWTActiveKeyMissing(t *testing.T) {
+	useJWTConfig(t, GetJWTConfig())
+	t.Setenv("JWT_KEYS", "k1:one")
+	t.Setenv("JWT_ACTIVE_KID", "k2")
+	t.Setenv("MINIAPP_OPENID_SECRET", "openid")
+	loadJWTConfig()
+	if err := CheckJWTConfig(); err == nil {
+		t.Error("JWT_ACTIVE_KID 指向不存在的密钥时应拒绝启动")
+	}
+
+	t.Setenv("JWT_ACTIVE_KID", "k1")
+	loadJWTConfig()
+	if err := CheckJWTConfig(); err != nil {
+		t.Errorf("修正配置后不应报错: %v", err)
+	}
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	useJWTConfig(t, JWTConfig{Keys: map[string][]byte{"k1": []byte("one")}, ActiveKeyID: "k1"})
+	before, _, err := GenerateAccessToken(1, "alice", "sid-1")
+	if err != nil {
+		t.Fatalf("签发令牌失败: %v", err)
+	}
+
+	// 轮换后旧令牌仍可校验，新令牌使用新密钥
+	useJWTConfig(t, JWTConfig{Keys: map[string][]byte{"k1": []byte("one"), "k2": []byte("two")}, ActiveKeyID: "k2"})
+	claims, err := ParseToken(before)
+	if err != nil || claims.UserID != 1 || claims.SessionID != "sid-1" {
+		t.Fatalf("轮换前的令牌应仍然有效，得到 %+v, %v", claims, err)
+	}
+	after, _, _ := GenerateAccessToken(2, "bob", "sid-2")
+	token, _, _ := jwt.NewParser().ParseUnverified(after, &Claims{})
+	if token.Header["kid"] != "k2" {
+		t.Errorf("新令牌应使用当前密钥，kid=%v", token.Header["kid"])
+	}
+
+	// 移除旧密钥后旧令牌失效
+	useJWTConfig(t, JWTConfig{Keys: map[string][]byte{"k2": []byte("two")}, ActiveKeyID: "k2"})
+	if _, err := ParseToken(before); err == nil {
+		t.Error("移除密钥后旧令牌应失效")
+	}
+	if _, err := ParseToken(after); err != nil {
+		t.Errorf("新令牌应有效: %v", err)
+	}
+
+	// 小程序令牌不能当作用户令牌使用
+	miniToken, _, _ := GenerateMiniAppToken(1, "app", []string{"userinfo"}, time.Minute)
+	if _, err := ParseToken(miniToken); err == nil {
+		t.Error("小程序令牌不应通过用户令牌校验")
+	}
+	miniClaims, err := ParseMiniAppToken(miniToken)
+	if err != nil || !miniClaims.HasScope("userinfo") {
+		t.Errorf("小程序令牌应有效，得到 %+v, %v", miniClaims, err)
+	}
+}
+
+func TestMiniAppOpenID(t *testing.T) {
+	useJWTConfig(t, JWTConfig{Keys: map[string][]byte{"k1": []byte("one")}, ActiveKeyID: "k1"})
+	if _, err := MiniAppOpenID("app", 1); !errors.Is(err, ErrMiniAppOpenIDNoKey) {
+		t.Errorf("未配置密钥时不应生成用户标识，得到 %v", err)
+	}
+
+	useJWTConfig(t, JWTConfig{Keys: map[string][]byte{"k1": []byte("one")}, ActiveKeyID: "k1", MiniAppOpenIDSecret: []byte("openid")})
+	first, _ := MiniAppOpenID("app", 1)
+	// 轮换JWT密钥不影响用户标识
+	useJWTConfig(t, JWTConfig{Keys: map[string][]byte{"k2": []byte("two")}, ActiveKeyID: "k2", MiniAppOpenIDSecret: []byte("openid")})
+	again, _ := MiniAppOpenID("app", 1)
+	otherApp, _ := MiniAppOpenID("app2", 1)
+	otherUser, _ := MiniAppOpenID("app", 2)
+	if first != again {
+		t.Error("轮换JWT密钥后用户标识不应改变")
+	}
+	if first == otherApp || first == otherUser {
+		t.Error("不同小程序或不同用户的标识应不同")
+	}
+}
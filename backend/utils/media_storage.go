@@ -0,0 +1,242 @@
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 媒体文件存储抽象
+// 文件按内容的SHA-256寻址保存，相同内容只存一份；
+// 下载地址带有时效签名，签名绑定请求用户，下载时再按会话成员关系校验
+
+// MediaStorage 媒体文件存储后端
+type MediaStorage interface {
+	// Name 后端名称，记录在文件元数据中，用于读取旧文件
+	Name() string
+	// Put 保存文件，key 已存在时覆盖
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get 读取文件，不存在时返回 ErrMediaNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除文件，不存在时不报错
+	Delete(ctx context.Context, key string) error
+	// Exists 文件是否存在
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// LocalPathStorage 可以直接访问本地文件路径的存储后端
+type LocalPathStorage interface {
+	LocalPath(key string) string
+}
+
+// 媒体存储错误类型，可通过 errors.Is 判断
+var (
+	ErrMediaNotFound       = errors.New("媒体文件不存在")
+	ErrMediaUnavailable    = errors.New("媒体存储服务不可用")
+	ErrMediaNoStorage      = errors.New("没有可用的媒体存储")
+	ErrMediaSignatureWrong = errors.New("下载链接无效或已过期")
+	ErrMediaNoURLSecret    = errors.New("未配置下载链接签名密钥 MEDIA_URL_SECRET")
+)
+
+// MediaStorageError 带存储后端信息的错误
+type MediaStorageError struct {
+	Storage    string
+	Kind       error // 上面定义的错误类型之一
+	StatusCode int   // 上游HTTP状态码，非HTTP错误为0
+	Message    string
+}
+
+func (e *MediaStorageError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s: %v", e.Storage, e.Kind)
+	}
+	return fmt.Sprintf("%s: %v: %s", e.Storage, e.Kind, e.Message)
+}
+
+func (e *MediaStorageError) Unwrap() error {
+	return e.Kind
+}
+
+// 媒体存储配置
+type MediaConfig struct {
+	// 新文件写入的后端，local 或 s3
+	Storage string
+
+	// 本地存储目录，该目录不会作为静态文件对外提供
+	LocalDir string
+
+	// S3 兼容存储（AWS S3、MinIO 等）
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	// 使用 endpoint/bucket/key 形式的地址，MinIO 需要开启
+	S3PathStyle bool
+
+	// 下载链接签名密钥，必须配置，多个实例使用同一密钥
+	URLSecret []byte
+	// 下载链接有效期
+	URLTTL time.Duration
+
+	// 单个文件大小上限（字节）
+	MaxSize int64
+
+	// 断点续传：分片临时目录、文件大小上限、单次上传的分片上限和未完成会话的保留时间
+	UploadDir       string
+	UploadMaxSize   int64
+	UploadChunkSize int64
+	UploadTTL       time.Duration
+
+	// 每个用户的存储空间配额（字节），0 表示不限制
+	UserQuota int64
+}
+
+var (
+	mediaStoragesMu sync.RWMutex
+	mediaStorages   = map[string]MediaStorage{}
+	mediaConfig     MediaConfig
+)
+
+// 初始化函数，从环境变量加载媒体存储配置
+func init() {
+	config := MediaConfig{
+		Storage:     envOrDefault("MEDIA_STORAGE", "local"),
+		LocalDir:    envOrDefault("MEDIA_LOCAL_DIR", "uploads/media"),
+		S3Endpoint:  os.Getenv("MEDIA_S3_ENDPOINT"),
+		S3Region:    envOrDefault("MEDIA_S3_REGION", "us-east-1"),
+		S3Bucket:    os.Getenv("MEDIA_S3_BUCKET"),
+		S3AccessKey: os.Getenv("MEDIA_S3_ACCESS_KEY"),
+		S3SecretKey: os.Getenv("MEDIA_S3_SECRET_KEY"),
+		S3PathStyle: os.Getenv("MEDIA_S3_VIRTUAL_HOST") == "",
+		URLSecret:   []byte(os.Getenv("MEDIA_URL_SECRET")),
+		URLTTL:      10 * time.Minute,
+		MaxSize:     50 << 20,
+
+		UploadDir:       envOrDefault("MEDIA_UPLOAD_DIR", "uploads/resumable"),
+		UploadMaxSize:   2 << 30,
+		UploadChunkSize: 8 << 20,
+		UploadTTL:       24 * time.Hour,
+		UserQuota:       5 << 30,
+	}
+	if v, err := strconv.Atoi(os.Getenv("MEDIA_URL_TTL")); err == nil && v > 0 {
+		config.URLTTL = time.Duration(v) * time.Second
+	}
+	if v, err := strconv.ParseInt(os.Getenv("MEDIA_MAX_SIZE"), 10, 64); err == nil && v > 0 {
+		config.MaxSize = v
+	}
+	if v, err := strconv.ParseInt(os.Getenv("MEDIA_UPLOAD_MAX_SIZE"), 10, 64); err == nil && v > 0 {
+		config.UploadMaxSize = v
+	}
+	if v, err := strconv.ParseInt(os.Getenv("MEDIA_UPLOAD_CHUNK_SIZE"), 10, 64); err == nil && v > 0 {
+		config.UploadChunkSize = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("MEDIA_UPLOAD_TTL")); err == nil && v > 0 {
+		config.UploadTTL = time.Duration(v) * time.Second
+	}
+	if v, err := strconv.ParseInt(os.Getenv("MEDIA_USER_QUOTA"), 10, 64); err == nil && v >= 0 {
+		config.UserQuota = v
+	}
+	SetMediaConfig(config)
+}
+
+// SetMediaConfig 根据配置重新注册存储后端
+// 本地存储始终注册，切换到S3后仍可读取之前保存在本地的文件
+func SetMediaConfig(config MediaConfig) {
+	storages := map[string]MediaStorage{
+		"local": NewLocalMediaStorage(config.LocalDir),
+	}
+	if config.S3Endpoint != "" && config.S3Bucket != "" {
+		storages["s3"] = NewS3MediaStorage(config.S3Endpoint, config.S3Region, config.S3Bucket,
+			config.S3AccessKey, config.S3SecretKey, config.S3PathStyle)
+	}
+
+	mediaStoragesMu.Lock()
+	mediaStorages = storages
+	mediaConfig = config
+	mediaStoragesMu.Unlock()
+}
+
+// GetMediaConfig 获取当前媒体存储配置
+func GetMediaConfig() MediaConfig {
+	mediaStoragesMu.RLock()
+	defer mediaStoragesMu.RUnlock()
+	return mediaConfig
+}
+
+// CheckMediaConfig 检查媒体存储配置，未配置签名密钥时返回错误，启动时调用
+func CheckMediaConfig() error {
+	if len(GetMediaConfig().URLSecret) == 0 {
+		return ErrMediaNoURLSecret
+	}
+	return nil
+}
+
+// RegisterMediaStorage 注册或替换存储后端，可用于接入其他实现
+func RegisterMediaStorage(storage MediaStorage) {
+	mediaStoragesMu.Lock()
+	defer mediaStoragesMu.Unlock()
+	mediaStorages[storage.Name()] = storage
+}
+
+// GetMediaStorage 按名称获取存储后端，名称为空时返回写入新文件使用的后端
+func GetMediaStorage(name string) (MediaStorage, error) {
+	mediaStoragesMu.RLock()
+	defer mediaStoragesMu.RUnlock()
+	if name == "" {
+		name = mediaConfig.Storage
+	}
+	storage, ok := mediaStorages[name]
+	if !ok {
+		return nil, &MediaStorageError{Storage: name, Kind: ErrMediaNoStorage}
+	}
+	return storage, nil
+}
+
+// MediaStorageKey 根据内容哈希生成存储路径，按前缀分目录避免单目录文件过多
+func MediaStorageKey(hash string) string {
+	return hash[0:2] + "/" + hash[2:4] + "/" + hash
+}
+
+// SignMediaURL 生成带时效签名的下载地址，签名绑定媒体和请求用户
+func SignMediaURL(mediaID, userID uint) (string, int64) {
+	expires := time.Now().Add(GetMediaConfig().URLTTL).Unix()
+	sig := mediaSignature(mediaID, userID, expires)
+	return fmt.Sprintf("/api/media/%d/content?uid=%d&expires=%d&sig=%s", mediaID, userID, expires, sig), expires
+}
+
+// VerifyMediaSignature 校验下载地址的签名和有效期，返回签名绑定的用户ID
+func VerifyMediaSignature(mediaID uint, uid, expires, sig string) (uint, error) {
+	userID, err := strconv.ParseUint(uid, 10, 64)
+	if err != nil {
+		return 0, ErrMediaSignatureWrong
+	}
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return 0, ErrMediaSignatureWrong
+	}
+	// 未配置密钥时任何人都能伪造签名，一律拒绝
+	if len(GetMediaConfig().URLSecret) == 0 {
+		return 0, ErrMediaSignatureWrong
+	}
+	want := mediaSignature(mediaID, uint(userID), exp)
+	if !hmac.Equal([]byte(want), []byte(strings.ToLower(sig))) {
+		return 0, ErrMediaSignatureWrong
+	}
+	return uint(userID), nil
+}
+
+func mediaSignature(mediaID, userID uint, expires int64) string {
+	mac := hmac.New(sha256.New, GetMediaConfig().URLSecret)
+	fmt.Fprintf(mac, "%d:%d:%d", mediaID, userID, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
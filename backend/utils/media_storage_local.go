@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalMediaStorage 保存在本地磁盘的存储后端
+type LocalMediaStorage struct {
+	Dir string
+}
+
+func NewLocalMediaStorage(dir string) *LocalMediaStorage {
+	return &LocalMediaStorage{Dir: dir}
+}
+
+func (s *LocalMediaStorage) Name() string { return "local" }
+
+// LocalPath 返回文件在本地的路径，key 中的 .. 会被清理掉
+func (s *LocalMediaStorage) LocalPath(key string) string {
+	key = strings.TrimPrefix(filepath.Clean("/"+key), "/")
+	return filepath.Join(s.Dir, filepath.FromSlash(key))
+}
+
+func (s *LocalMediaStorage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	path := s.LocalPath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// 先写临时文件再改名，避免并发读取到写了一半的文件
+	tempFile, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	if _, err := io.Copy(tempFile, body); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempFile.Name(), path)
+}
+
+func (s *LocalMediaStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	file, err := os.Open(s.LocalPath(key))
+	if os.IsNotExist(err) {
+		return nil, &MediaStorageError{Storage: s.Name(), Kind: ErrMediaNotFound, Message: key}
+	}
+	return file, err
+}
+
+func (s *LocalMediaStorage) Delete(ctx context.Context, key string) error {
+	if err := os.Remove(s.LocalPath(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalMediaStorage) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := os.Stat(s.LocalPath(key))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useMediaSecret 使用指定的签名密钥，测试结束后恢复原配置
+func useMediaSecret(t *testing.T, secret string) {
+	t.Helper()
+	previous := GetMediaConfig()
+	config := previous
+	config.URLSecret = []byte(secret)
+	SetMediaConfig(config)
+	t.Cleanup(func() { SetMediaConfig(previous) })
+}
+
+func TestMediaSignature(t *testing.T) {
+	useMediaSecret(t, "test-secret")
+
+	link, expires := SignMediaURL(7, 3)
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("解析下载地址失败: %v", err)
+	}
+	if parsed.Path != "/api/media/7/content" {
+		t.Errorf("下载地址错误: %s", link)
+	}
+	query := parsed.Query()
+	uid, exp, sig := query.Get("uid"), query.Get("expires"), query.Get("sig")
+	if exp != strconv.FormatInt(expires, 10) {
+		t.Errorf("过期时间不一致: %s != %d", exp, expires)
+	}
+
+	tampered := []byte(sig)
+	tampered[len(tampered)-1] ^= 1
+	past := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	tests := []struct {
+		name    string
+		mediaID uint
+		uid     string
+		expires string
+		sig     string
+		ok      bool
+	}{
+		{"有效签名", 7, uid, exp, sig, true},
+		{"签名大小写不敏感", 7, uid, exp, strings.ToUpper(sig), true},
+		{"其他媒体", 8, uid, exp, sig, false},
+		{"其他用户", 7, "4", exp, sig, false},
+		{"延长有效期", 7, uid, strconv.FormatInt(expires+3600, 10), sig, false},
+		{"篡改签名", 7, uid, exp, string(tampered), false},
+		{"已过期", 7, uid, past, mediaSignature(7, 3, mustInt(t, past)), false},
+		{"参数无效", 7, "x", exp, sig, false},
+	}
+	for _, tt := range tests {
+		userID, err := VerifyMediaSignature(tt.mediaID, tt.uid, tt.expires, tt.sig)
+		if tt.ok {
+			if err != nil || userID != 3 {
+				t.Errorf("%s: 应校验通过，得到 %d, %v", tt.name, userID, err)
+			}
+		} else if !errors.Is(err, ErrMediaSignatureWrong) {
+			t.Errorf("%s: 应校验失败，得到 %v", tt.name, err)
+		}
+	}
+
+	// 更换密钥后旧链接失效
+	useMediaSecret(t, "other-secret")
+	if _, err := VerifyMediaSignature(7, uid, exp, sig); !errors.Is(err, ErrMediaSignatureWrong) {
+		t.Errorf("更换密钥后旧链接应失效，得到 %v", err)
+	}
+}
+
+func TestMediaSignatureRequiresSecret(t *testing.T) {
+	useMediaSecret(t, "")
+	if err := CheckMediaConfig(); !errors.Is(err, ErrMediaNoURLSecret) {
+		t.Errorf("未配置签名密钥时应报错，得到 %v", err)
+	}
+	exp := strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10)
+	sig := mediaSignature(7, 3, mustInt(t, exp))
+	if _, err := VerifyMediaSignature(7, "3", exp, sig); !errors.Is(err, ErrMediaSignatureWrong) {
+		t.Errorf("未配置签名密钥时不应接受任何签名，得到 %v", err)
+	}
+
+	useMediaSecret(t, "test-secret")
+	if err := CheckMediaConfig(); err != nil {
+		t.Errorf("已配置签名密钥: %v", err)
+	}
+}
+
+func mustInt(t *testing.T, s string) int64 {
+	t.Helper()
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
@@ -35,3 +35,48 @@ func FilterSensitiveWords(content string) string {
 
 	return filteredContent
 }
+
+// ContainsSensitiveWords 检查内容是否包含敏感词
+func ContainsSensitiveWords(content string) bool {
+	for _, word := range sensitiveWords {
+		if strings.Contains(content, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// SensitiveWordStream 流式文本的敏感词过滤
+// 敏感词可能被拆在相邻的两个片段中，末尾可能是敏感词开头的部分先缓存，等后续片段到达再输出
+type SensitiveWordStream struct {
+	pending string
+}
+
+// Write 写入一个片段，返回可以输出的已过滤文本
+func (s *SensitiveWordStream) Write(delta string) string {
+	text := FilterSensitiveWords(s.pending + delta)
+	hold := sensitiveWordPrefixSuffix(text)
+	s.pending = text[len(text)-hold:]
+	return text[:len(text)-hold]
+}
+
+// Flush 输出缓存的剩余文本
+func (s *SensitiveWordStream) Flush() string {
+	rest := s.pending
+	s.pending = ""
+	return rest
+}
+
+// sensitiveWordPrefixSuffix 文本末尾与敏感词开头重合的最大长度
+func sensitiveWordPrefixSuffix(text string) int {
+	longest := 0
+	for _, word := range sensitiveWords {
+		for n := len(word) - 1; n > longest; n-- {
+			if strings.HasSuffix(text, word[:n]) {
+				longest = n
+				break
+			}
+		}
+	}
+	return longest
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilterSensitiveWords(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"", ""},
+		{"你好", "你好"},
+		{"what the fuck", "what the ****"},
+		{"shit and shit", "**** and ****"},
+	}
+	for _, tt := range tests {
+		if got := FilterSensitiveWords(tt.content); got != tt.want {
+			t.Errorf("FilterSensitiveWords(%q) = %q，应为 %q", tt.content, got, tt.want)
+		}
+	}
+	if !ContainsSensitiveWords("oh shit") || ContainsSensitiveWords("你好") {
+		t.Error("ContainsSensitiveWords 判断错误")
+	}
+}
+
+func TestSensitiveWordStream(t *testing.T) {
+	tests := []struct {
+		name   string
+		deltas []string
+	}{
+		{"敏感词拆在两个片段中", []string{"what the fu", "ck is this"}},
+		{"敏感词逐字到达", []string{"f", "u", "c", "k"}},
+		{"中文敏感词拆分", []string{"你这个傻", "逼"}},
+		{"片段末尾只是普通文本", []string{"fun", "ny"}},
+	}
+	for _, tt := range tests {
+		var stream SensitiveWordStream
+		var out strings.Builder
+		for _, delta := range tt.deltas {
+			chunk := stream.Write(delta)
+			if ContainsSensitiveWords(chunk) {
+				t.Errorf("%s: 输出的片段包含敏感词 %q", tt.name, chunk)
+			}
+			out.WriteString(chunk)
+		}
+		out.WriteString(stream.Flush())
+
+		want := FilterSensitiveWords(strings.Join(tt.deltas, ""))
+		if out.String() != want {
+			t.Errorf("%s: 输出为 %q，应为 %q", tt.name, out.String(), want)
+		}
+	}
+}
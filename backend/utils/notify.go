@@ -0,0 +1,343 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 短信和邮件发送抽象
+// 验证码等通知通过 EmailSender / SMSSender 发送，内容按模板和语言生成；
+// 服务暂时不可用时按指数退避重试，同一收件人的发送频率受限，防止接口被用来轰炸他人；
+// 发送记录保存在验证码存储中，多个实例共享同一个限制
+// 开发环境未配置SMTP或短信接口时使用文件收件箱，消息写入 SinkDir，本地开发和测试可通过 ReadSinkMessages 读取；
+// 生产环境只有显式开启时才使用文件收件箱，否则启动时报错
+
+// EmailMessage 邮件
+type EmailMessage struct {
+	To      string
+	Subject string
+	HTML    string
+	Text    string // 纯文本内容，不支持HTML的客户端显示
+}
+
+// SMSMessage 短信
+type SMSMessage struct {
+	To   string
+	Text string // 按模板生成的短信内容
+	// 模板名和参数，服务商要求使用预先审核的模板时按这两项发送
+	Template string
+	Params   map[string]string
+}
+
+// EmailSender 邮件发送方
+type EmailSender interface {
+	// Name 发送方名称，用于配置
+	Name() string
+	SendEmail(ctx context.Context, msg *EmailMessage) error
+}
+
+// SMSSender 短信发送方
+type SMSSender interface {
+	Name() string
+	SendSMS(ctx context.Context, msg *SMSMessage) error
+}
+
+// 发送错误类型，可通过 errors.Is 判断；只有 ErrSendUnavailable 会重试
+var (
+	ErrSendUnavailable   = errors.New("发送服务不可用")
+	ErrSendRejected      = errors.New("发送请求被拒绝")
+	ErrSendThrottled     = errors.New("发送过于频繁")
+	ErrSendNotConfigured = errors.New("发送服务未配置")
+)
+
+// SendError 带发送方信息的发送错误
+type SendError struct {
+	Sender     string
+	Kind       error // 上面定义的错误类型之一
+	StatusCode int   // 上游状态码（HTTP 或 SMTP），没有时为0
+	Message    string
+}
+
+func (e *SendError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s: %v", e.Sender, e.Kind)
+	}
+	return fmt.Sprintf("%s: %v: %s", e.Sender, e.Kind, e.Message)
+}
+
+func (e *SendError) Unwrap() error {
+	return e.Kind
+}
+
+// newSendHTTPError 根据上游HTTP状态码构造错误，限流和服务端错误可重试
+func newSendHTTPError(sender string, statusCode int, body string) *SendError {
+	kind := ErrSendRejected
+	if statusCode == http.StatusTooManyRequests || statusCode >= 500 {
+		kind = ErrSendUnavailable
+	}
+	if len(body) > 200 {
+		body = body[:200]
+	}
+	return &SendError{Sender: sender, Kind: kind, StatusCode: statusCode, Message: body}
+}
+
+// 通知发送配置
+type NotifyConfig struct {
+	// 邮件发送方：smtp 或 sink
+	EmailSender string
+	// 短信发送方：http 或 sink
+	SMSSender string
+
+	// SMTP，端口465使用TLS直连，其他端口在服务器支持时使用STARTTLS
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	// 通用HTTP短信接口，以JSON提交，可对接自建网关或服务商的转发服务
+	SMSHTTPURL   string
+	SMSHTTPToken string
+	SMSSign      string // 短信签名
+
+	// 文件收件箱目录
+	SinkDir string
+	// 未配置SMTP或短信接口时是否使用文件收件箱；开发环境默认开启，生产环境需要显式开启
+	AllowSink bool
+
+	// 最多尝试次数和首次重试的等待时间，之后每次翻倍
+	MaxAttempts  int
+	RetryBackoff time.Duration
+
+	// 同一收件人两次发送的最小间隔和每小时的发送上限，0表示不限制
+	RecipientInterval    time.Duration
+	RecipientHourlyLimit int
+}
+
+var (
+	notifyMu     sync.RWMutex
+	notifyConfig NotifyConfig
+	emailSender  EmailSender
+	smsSender    SMSSender
+)
+
+// 初始化函数，从环境变量加载通知发送配置
+func init() {
+	config := NotifyConfig{
+		SMTPHost:             os.Getenv("SMTP_HOST"),
+		SMTPPort:             587,
+		SMTPUsername:         os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:         os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:             os.Getenv("SMTP_FROM"),
+		SMSHTTPURL:           os.Getenv("SMS_HTTP_URL"),
+		SMSHTTPToken:         os.Getenv("SMS_HTTP_TOKEN"),
+		SMSSign:              os.Getenv("SMS_SIGN"),
+		SinkDir:              envOrDefault("NOTIFY_SINK_DIR", "tmp/mailbox"),
+		MaxAttempts:          3,
+		RetryBackoff:         500 * time.Millisecond,
+		RecipientInterval:    time.Minute,
+		RecipientHourlyLimit: 10,
+	}
+	// GIN_MODE 为 release 时视为生产环境，不使用文件收件箱，除非设置 NOTIFY_ALLOW_SINK=true
+	config.AllowSink = os.Getenv("GIN_MODE") != "release"
+	if v, err := strconv.ParseBool(os.Getenv("NOTIFY_ALLOW_SINK")); err == nil {
+		config.AllowSink = v
+	}
+	// 配置了SMTP或短信接口时默认使用，也可以通过环境变量强制使用文件收件箱
+	config.EmailSender = os.Getenv("NOTIFY_EMAIL_SENDER")
+	if config.EmailSender == "" && config.SMTPHost != "" {
+		config.EmailSender = "smtp"
+	}
+	config.SMSSender = os.Getenv("NOTIFY_SMS_SENDER")
+	if config.SMSSender == "" && config.SMSHTTPURL != "" {
+		config.SMSSender = "http"
+	}
+	if v, err := strconv.Atoi(os.Getenv("SMTP_PORT")); err == nil && v > 0 {
+		config.SMTPPort = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("NOTIFY_MAX_ATTEMPTS")); err == nil && v > 0 {
+		config.MaxAttempts = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("NOTIFY_RETRY_BACKOFF_MS")); err == nil && v >= 0 {
+		config.RetryBackoff = time.Duration(v) * time.Millisecond
+	}
+	if v, err := strconv.Atoi(os.Getenv("NOTIFY_RECIPIENT_INTERVAL")); err == nil && v >= 0 {
+		config.RecipientInterval = time.Duration(v) * time.Second
+	}
+	if v, err := strconv.Atoi(os.Getenv("NOTIFY_RECIPIENT_HOURLY_LIMIT")); err == nil && v >= 0 {
+		config.RecipientHourlyLimit = v
+	}
+	SetNotifyConfig(config)
+}
+
+// SetNotifyConfig 根据配置创建发送方，SMTP或短信接口未配置时，允许的情况下使用文件收件箱，
+// 否则不设置发送方，发送时返回 ErrSendNotConfigured
+func SetNotifyConfig(config NotifyConfig) {
+	if config.SMTPFrom == "" {
+		config.SMTPFrom = config.SMTPUsername
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 1
+	}
+	sink := NewFileSink(config.SinkDir)
+
+	var email EmailSender
+	switch {
+	case config.EmailSender == "smtp" && config.SMTPHost != "":
+		email = NewSMTPEmailSender(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.SMTPFrom)
+	case config.AllowSink:
+		email = sink
+		config.EmailSender = sink.Name()
+	default:
+		config.EmailSender = ""
+	}
+	var sms SMSSender
+	switch {
+	case config.SMSSender == "http" && config.SMSHTTPURL != "":
+		sms = NewHTTPSMSSender(config.SMSHTTPURL, config.SMSHTTPToken, config.SMSSign)
+	case config.AllowSink:
+		sms = sink
+		config.SMSSender = sink.Name()
+	default:
+		config.SMSSender = ""
+	}
+
+	notifyMu.Lock()
+	notifyConfig = config
+	emailSender = email
+	smsSender = sms
+	notifyMu.Unlock()
+}
+
+// GetNotifyConfig 获取当前通知发送配置
+func GetNotifyConfig() NotifyConfig {
+	notifyMu.RLock()
+	defer notifyMu.RUnlock()
+	return notifyConfig
+}
+
+// CheckNotifyConfig 检查邮件和短信发送方是否都已配置，启动时调用
+func CheckNotifyConfig() error {
+	_, email, sms := currentSenders()
+	if email == nil {
+		return fmt.Errorf("%w: 请配置 SMTP_HOST，或设置 NOTIFY_ALLOW_SINK=true 使用文件收件箱", ErrSendNotConfigured)
+	}
+	if sms == nil {
+		return fmt.Errorf("%w: 请配置 SMS_HTTP_URL，或设置 NOTIFY_ALLOW_SINK=true 使用文件收件箱", ErrSendNotConfigured)
+	}
+	return nil
+}
+
+// SetEmailSender 替换邮件发送方，用于接入其他服务商
+func SetEmailSender(sender EmailSender) {
+	notifyMu.Lock()
+	defer notifyMu.Unlock()
+	emailSender = sender
+	notifyConfig.EmailSender = sender.Name()
+}
+
+// SetSMSSender 替换短信发送方
+func SetSMSSender(sender SMSSender) {
+	notifyMu.Lock()
+	defer notifyMu.Unlock()
+	smsSender = sender
+	notifyConfig.SMSSender = sender.Name()
+}
+
+func currentSenders() (NotifyConfig, EmailSender, SMSSender) {
+	notifyMu.RLock()
+	defer notifyMu.RUnlock()
+	return notifyConfig, emailSender, smsSender
+}
+
+// SendTemplateEmail 按模板和语言生成邮件并发送，受收件人发送频率限制
+func SendTemplateEmail(ctx context.Context, to, template, lang string, data map[string]interface{}) error {
+	rendered, err := RenderNotifyTemplate(template, lang, data)
+	if err != nil {
+		return err
+	}
+	config, sender, _ := currentSenders()
+	if sender == nil {
+		return &SendError{Sender: "email", Kind: ErrSendNotConfigured}
+	}
+	if err := reserveRecipient(ctx, "email:"+strings.ToLower(to), config); err != nil {
+		return err
+	}
+	msg := &EmailMessage{To: to, Subject: rendered.Subject, HTML: rendered.HTML, Text: rendered.Text}
+	return sendWithRetry(ctx, config, func() error {
+		return sender.SendEmail(ctx, msg)
+	})
+}
+
+// SendTemplateSMS 按模板和语言生成短信并发送，受收件人发送频率限制
+func SendTemplateSMS(ctx context.Context, to, template, lang string, data map[string]interface{}) error {
+	rendered, err := RenderNotifyTemplate(template, lang, data)
+	if err != nil {
+		return err
+	}
+	config, _, sender := currentSenders()
+	if sender == nil {
+		return &SendError{Sender: "sms", Kind: ErrSendNotConfigured}
+	}
+	if err := reserveRecipient(ctx, "sms:"+to, config); err != nil {
+		return err
+	}
+	params := make(map[string]string, len(data))
+	for key, value := range data {
+		params[key] = fmt.Sprint(value)
+	}
+	msg := &SMSMessage{To: to, Text: rendered.Text, Template: template, Params: params}
+	return sendWithRetry(ctx, config, func() error {
+		return sender.SendSMS(ctx, msg)
+	})
+}
+
+// reserveRecipient 检查收件人的发送频率，未超限时记录本次发送
+// 最小间隔和每小时上限分别用验证码存储中的计数实现，每小时上限按固定的一小时窗口计算
+func reserveRecipient(ctx context.Context, key string, config NotifyConfig) error {
+	_, store := currentCodeStore()
+	if config.RecipientInterval > 0 {
+		count, err := store.IncrCounter(ctx, "notify:interval:"+key, config.RecipientInterval)
+		if err != nil {
+			return fmt.Errorf("记录发送频率失败: %w", err)
+		}
+		if count > 1 {
+			return &SendError{Sender: "throttle", Kind: ErrSendThrottled, Message: "请稍后再试"}
+		}
+	}
+	if config.RecipientHourlyLimit > 0 {
+		count, err := store.IncrCounter(ctx, "notify:hourly:"+key, time.Hour)
+		if err != nil {
+			return fmt.Errorf("记录发送频率失败: %w", err)
+		}
+		if count > config.RecipientHourlyLimit {
+			return &SendError{Sender: "throttle", Kind: ErrSendThrottled, Message: "发送次数已达上限"}
+		}
+	}
+	return nil
+}
+
+// sendWithRetry 发送失败且服务暂时不可用时按指数退避重试
+func sendWithRetry(ctx context.Context, config NotifyConfig, send func() error) error {
+	backoff := config.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := send()
+		if err == nil || attempt >= config.MaxAttempts || !errors.Is(err, ErrSendUnavailable) {
+			return err
+		}
+		Logger.Errorf("发送失败，%v 后第%d次重试: %v", backoff, attempt, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
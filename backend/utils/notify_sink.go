@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileSink 文件收件箱，把邮件和短信写入本地文件而不真正发送
+// 每个收件人一个文件，每行一条JSON消息，本地开发时可直接查看，测试通过 ReadSinkMessages 读取
+type FileSink struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// SinkMessage 收件箱中的一条消息
+type SinkMessage struct {
+	Channel  string            `json:"channel"` // email 或 sms
+	To       string            `json:"to"`
+	Subject  string            `json:"subject,omitempty"`
+	HTML     string            `json:"html,omitempty"`
+	Text     string            `json:"text"`
+	Template string            `json:"template,omitempty"`
+	Params   map[string]string `json:"params,omitempty"`
+	SentAt   int64             `json:"sent_at"`
+}
+
+// NewFileSink 创建文件收件箱
+func NewFileSink(dir string) *FileSink {
+	return &FileSink{dir: dir}
+}
+
+func (s *FileSink) Name() string {
+	return "sink"
+}
+
+func (s *FileSink) SendEmail(ctx context.Context, msg *EmailMessage) error {
+	return s.write(&SinkMessage{
+		Channel: "email",
+		To:      msg.To,
+		Subject: msg.Subject,
+		HTML:    msg.HTML,
+		Text:    msg.Text,
+		SentAt:  time.Now().Unix(),
+	})
+}
+
+func (s *FileSink) SendSMS(ctx context.Context, msg *SMSMessage) error {
+	return s.write(&SinkMessage{
+		Channel:  "sms",
+		To:       msg.To,
+		Text:     msg.Text,
+		Template: msg.Template,
+		Params:   msg.Params,
+		SentAt:   time.Now().Unix(),
+	})
+}
+
+func (s *FileSink) write(msg *SinkMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return &SendError{Sender: s.Name(), Kind: ErrSendUnavailable, Message: err.Error()}
+	}
+	f, err := os.OpenFile(sinkFilePath(s.dir, msg.To), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return &SendError{Sender: s.Name(), Kind: ErrSendUnavailable, Message: err.Error()}
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return &SendError{Sender: s.Name(), Kind: ErrSendUnavailable, Message: err.Error()}
+	}
+	Logger.Debugf("消息已写入文件收件箱: %s %s", msg.Channel, msg.To)
+	return nil
+}
+
+// ReadSinkMessages 读取当前配置的文件收件箱中发给某个收件人的全部消息，按发送顺序排列
+func ReadSinkMessages(to string) ([]SinkMessage, error) {
+	f, err := os.Open(sinkFilePath(GetNotifyConfig().SinkDir, to))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []SinkMessage{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	messages := []SinkMessage{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var msg SinkMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err == nil {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, scanner.Err()
+}
+
+// sinkFilePath 收件人对应的文件，文件名只保留安全字符
+func sinkFilePath(dir, to string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '@', r == '.', r == '+', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, strings.ToLower(to))
+	return filepath.Join(dir, name+".jsonl")
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"text/template"
+)
+
+// 通知消息模板，按模板名和语言组织；Text 用作短信内容和邮件的纯文本部分
+// 语言没有对应模板时使用默认语言(zh-CN)
+
+type notifyTemplateSource struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+var notifyTemplateSources = map[string]map[string]notifyTemplateSource{
+	"verification_code": {
+		"zh-CN": {
+			Subject: "验证码 - 您的账号注册",
+			HTML: `<html>
+<body>
+	<h2>验证码</h2>
+	<p>您的验证码是: <strong>{{.Code}}</strong></p>
+	<p>验证码有效期为{{.Minutes}}分钟，请勿泄露给他人。</p>
+	<p>如果这不是您的操作，请忽略此邮件。</p>
+</body>
+</html>`,
+			Text: "您的验证码是{{.Code}}，{{.Minutes}}分钟内有效，请勿泄露给他人。",
+		},
+		"en": {
+			Subject: "Your verification code",
+			HTML: `<html>
+<body>
+	<h2>Verification code</h2>
+	<p>Your verification code is: <strong>{{.Code}}</strong></p>
+	<p>The code expires in {{.Minutes}} minutes. Do not share it with anyone.</p>
+	<p>If you did not request this, please ignore this email.</p>
+</body>
+</html>`,
+			Text: "Your verification code is {{.Code}}. It expires in {{.Minutes}} minutes. Do not share it with anyone.",
+		},
+	},
+	"password_reset": {
+		"zh-CN": {
+			Subject: "验证码 - 重置密码",
+			HTML: `<html>
+<body>
+	<h2>重置密码</h2>
+	<p>您正在重置账号 {{.Account}} 的密码，验证码是: <strong>{{.Code}}</strong></p>
+	<p>验证码有效期为{{.Minutes}}分钟，请勿泄露给他人。</p>
+	<p>如果这不是您的操作，请忽略此邮件，您的密码不会被修改。</p>
+</body>
+</html>`,
+			Text: "您正在重置账号{{.Account}}的密码，验证码是{{.Code}}，{{.Minutes}}分钟内有效。如非本人操作请忽略。",
+		},
+		"en": {
+			Subject: "Reset your password",
+			HTML: `<html>
+<body>
+	<h2>Reset your password</h2>
+	<p>You are resetting the password of account {{.Account}}. Your verification code is: <strong>{{.Code}}</strong></p>
+	<p>The code expires in {{.Minutes}} minutes. Do not share it with anyone.</p>
+	<p>If you did not request this, please ignore this email. Your password will not be changed.</p>
+</body>
+</html>`,
+			Text: "You are resetting the password of account {{.Account}}. Your code is {{.Code}} and expires in {{.Minutes}} minutes. Ignore this message if it was not you.",
+		},
+	},
+}
+
+type notifyTemplate struct {
+	subject *template.Template
+	html    *htmltemplate.Template
+	text    *template.Template
+}
+
+var notifyTemplates = map[string]map[string]*notifyTemplate{}
+
+// 初始化函数，解析通知模板
+func init() {
+	for name, langs := range notifyTemplateSources {
+		notifyTemplates[name] = map[string]*notifyTemplate{}
+		for lang, source := range langs {
+			id := name + "." + lang
+			notifyTemplates[name][lang] = &notifyTemplate{
+				subject: template.Must(template.New(id + ".subject").Option("missingkey=zero").Parse(source.Subject)),
+				html:    htmltemplate.Must(htmltemplate.New(id + ".html").Option("missingkey=zero").Parse(source.HTML)),
+				text:    template.Must(template.New(id + ".text").Option("missingkey=zero").Parse(source.Text)),
+			}
+		}
+	}
+}
+
+// RenderedNotify 按模板生成的消息内容
+type RenderedNotify struct {
+	Lang    string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// RenderNotifyTemplate 按语言生成消息，语言没有对应模板时使用默认语言
+func RenderNotifyTemplate(name, lang string, data map[string]interface{}) (*RenderedNotify, error) {
+	langs, ok := notifyTemplates[name]
+	if !ok {
+		return nil, fmt.Errorf("通知模板 %q 不存在", name)
+	}
+	lang = NormalizeLanguage(lang)
+	tmpl, ok := langs[lang]
+	if !ok {
+		lang = DefaultLanguage
+		tmpl = langs[lang]
+	}
+
+	rendered := &RenderedNotify{Lang: lang}
+	var buf bytes.Buffer
+	if err := tmpl.subject.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	rendered.Subject = buf.String()
+	buf.Reset()
+	if err := tmpl.html.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	rendered.HTML = buf.String()
+	buf.Reset()
+	if err := tmpl.text.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	rendered.Text = buf.String()
+	return rendered, nil
+}
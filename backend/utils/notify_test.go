@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"allinone_backend/models"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// useNotifyConfig 使用指定的通知发送配置，测试结束后恢复原配置
+func useNotifyConfig(t *testing.T, config NotifyConfig) {
+	t.Helper()
+	previous := GetNotifyConfig()
+	SetNotifyConfig(config)
+	t.Cleanup(func() { SetNotifyConfig(previous) })
+}
+
+// useCodeStore 使用指定的验证码存储，测试结束后恢复原配置
+func useCodeStore(t *testing.T, store CodeStore) {
+	t.Helper()
+	previous := GetCodeStoreConfig()
+	SetCodeStore(store)
+	t.Cleanup(func() { SetCodeStoreConfig(previous, DB) })
+}
+
+// openTestDB 创建迁移好全部表的临时数据库
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := db.AutoMigrate(&models.VerificationCode{}); err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+	return db
+}
+
+func TestNotifySinkRequiresDevOrOptIn(t *testing.T) {
+	useCodeStore(t, NewMemoryCodeStore())
+	dir := t.TempDir()
+	data := map[string]interface{}{"Code": "123456", "Minutes": 10}
+
+	// 生产环境未配置发送服务时不使用文件收件箱
+	useNotifyConfig(t, NotifyConfig{SinkDir: dir})
+	if err := CheckNotifyConfig(); !errors.Is(err, ErrSendNotConfigured) {
+		t.Errorf("未配置发送服务时应拒绝启动，得到 %v", err)
+	}
+	if err := SendTemplateEmail(context.Background(), "alice@example.com", "verification_code", "en", data); !errors.Is(err, ErrSendNotConfigured) {
+		t.Errorf("未配置邮件服务时发送应报错，得到 %v", err)
+	}
+	if err := SendTemplateSMS(context.Background(), "13800000000", "verification_code", "en", data); !errors.Is(err, ErrSendNotConfigured) {
+		t.Errorf("未配置短信服务时发送应报错，得到 %v", err)
+	}
+	if messages, _ := ReadSinkMessages("alice@example.com"); len(messages) != 0 {
+		t.Error("不应写入文件收件箱")
+	}
+
+	// 配置了邮件服务但没有短信服务，仍然拒绝启动
+	useNotifyConfig(t, NotifyConfig{SinkDir: dir, EmailSender: "smtp", SMTPHost: "smtp.example.com"})
+	if err := CheckNotifyConfig(); !errors.Is(err, ErrSendNotConfigured) {
+		t.Errorf("未配置短信服务时应拒绝启动，得到 %v", err)
+	}
+
+	// 显式允许后使用文件收件箱
+	useNotifyConfig(t, NotifyConfig{SinkDir: dir, AllowSink: true})
+	if err := CheckNotifyConfig(); err != nil {
+		t.Errorf("允许文件收件箱时不应报错: %v", err)
+	}
+	if config := GetNotifyConfig(); config.EmailSender != "sink" || config.SMSSender != "sink" {
+		t.Errorf("应使用文件收件箱，得到 %s / %s", config.EmailSender, config.SMSSender)
+	}
+	if err := SendTemplateEmail(context.Background(), "alice@example.com", "verification_code", "en", data); err != nil {
+		t.Fatalf("写入文件收件箱失败: %v", err)
+	}
+	if messages, _ := ReadSinkMessages("alice@example.com"); len(messages) != 1 {
+		t.Errorf("文件收件箱应有1条消息，得到 %d 条", len(messages))
+	}
+}
+
+func TestRecipientThrottleSharedAcrossInstances(t *testing.T) {
+	db := openTestDB(t)
+	useCodeStore(t, NewDBCodeStore(db))
+	useNotifyConfig(t, NotifyConfig{SinkDir: t.TempDir(), AllowSink: true, RecipientInterval: time.Minute})
+	data := map[string]interface{}{"Code": "123456", "Minutes": 10}
+
+	if err := SendTemplateSMS(context.Background(), "13800000000", "verification_code", "en", data); err != nil {
+		t.Fatalf("发送失败: %v", err)
+	}
+	// 另一个实例使用同一个数据库，同样受限
+	useCodeStore(t, NewDBCodeStore(db))
+	if err := SendTemplateSMS(context.Background(), "13800000000", "verification_code", "en", data); !errors.Is(err, ErrSendThrottled) {
+		t.Errorf("间隔内再次发送应被限制，得到 %v", err)
+	}
+	if err := SendTemplateSMS(context.Background(), "13900000000", "verification_code", "en", data); err != nil {
+		t.Errorf("其他收件人不受影响: %v", err)
+	}
+
+	// 间隔过后可以再次发送
+	db.Model(&models.VerificationCode{}).Where("code_key LIKE ?", "notify:%").Update("expires_at", time.Now().Add(-time.Second).Unix())
+	if err := SendTemplateSMS(context.Background(), "13800000000", "verification_code", "en", data); err != nil {
+		t.Errorf("间隔过后应可以发送: %v", err)
+	}
+}
+
+func TestRecipientHourlyLimit(t *testing.T) {
+	useCodeStore(t, NewMemoryCodeStore())
+	useNotifyConfig(t, NotifyConfig{SinkDir: t.TempDir(), AllowSink: true, RecipientHourlyLimit: 2})
+	data := map[string]interface{}{"Code": "123456", "Minutes": 10}
+
+	for i := 0; i < 2; i++ {
+		if err := SendTemplateEmail(context.Background(), "alice@example.com", "verification_code", "en", data); err != nil {
+			t.Fatalf("第%d次发送失败: %v", i+1, err)
+		}
+	}
+	// 收件人地址不区分大小写
+	if err := SendTemplateEmail(context.Background(), "Alice@Example.com", "verification_code", "en", data); !errors.Is(err, ErrSendThrottled) {
+		t.Errorf("超过每小时上限应被限制，得到 %v", err)
+	}
+}
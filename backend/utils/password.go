@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 登录密码哈希：新密码使用 argon2id（PHC 格式 $argon2id$v=19$m=..,t=..,p=..$salt$hash），也可配置为 bcrypt
+// 校验时兼容 bcrypt 和早期明文保存的密码，登录成功后由调用方按 NeedsRehash 转换为当前算法和参数
+
+// 密码配置
+type PasswordConfig struct {
+	// 哈希算法：argon2id 或 bcrypt
+	Algorithm string
+
+	// argon2id 参数，内存单位为KiB
+	ArgonMemory  uint32
+	ArgonTime    uint32
+	ArgonThreads uint8
+
+	BcryptCost int
+
+	// 密码最小长度
+	MinLength int
+}
+
+const (
+	PasswordArgon2id = "argon2id"
+	PasswordBcrypt   = "bcrypt"
+
+	// UnusablePassword 不可登录账号（如系统账号）保存的密码，任何输入都无法通过校验
+	UnusablePassword = "!"
+
+	passwordMaxLength = 128
+	argonSaltLength   = 16
+	argonKeyLength    = 32
+)
+
+// 常见的弱密码，满足长度和字符种类要求但仍容易被猜到
+var commonPasswords = map[string]bool{
+	"password1": true, "password123": true, "passw0rd": true, "qwerty123": true, "abc12345": true,
+	"abcd1234": true, "a1234567": true, "aa123456": true, "1qaz2wsx": true, "qwe123456": true,
+	"admin123": true, "iloveyou1": true, "woaini1314": true, "123456abc": true, "zxcvbnm123": true,
+}
+
+var (
+	passwordConfigMu sync.RWMutex
+	passwordConfig   PasswordConfig
+)
+
+// 初始化函数，从环境变量加载密码配置
+func init() {
+	config := PasswordConfig{
+		Algorithm:    envOrDefault("PASSWORD_HASH", PasswordArgon2id),
+		ArgonMemory:  64 * 1024,
+		ArgonTime:    3,
+		ArgonThreads: 2,
+		BcryptCost:   bcrypt.DefaultCost,
+		MinLength:    8,
+	}
+	if v, err := strconv.Atoi(os.Getenv("PASSWORD_ARGON_MEMORY")); err == nil && v >= 8*1024 {
+		config.ArgonMemory = uint32(v)
+	}
+	if v, err := strconv.Atoi(os.Getenv("PASSWORD_ARGON_TIME")); err == nil && v > 0 {
+		config.ArgonTime = uint32(v)
+	}
+	if v, err := strconv.Atoi(os.Getenv("PASSWORD_BCRYPT_COST")); err == nil {
+		config.BcryptCost = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("PASSWORD_MIN_LENGTH")); err == nil && v > 0 {
+		config.MinLength = v
+	}
+	SetPasswordConfig(config)
+}
+
+// SetPasswordConfig 设置密码配置，未知的算法使用 argon2id
+func SetPasswordConfig(config PasswordConfig) {
+	if config.Algorithm != PasswordBcrypt {
+		config.Algorithm = PasswordArgon2id
+	}
+	if config.ArgonMemory == 0 {
+		config.ArgonMemory = 64 * 1024
+	}
+	if config.ArgonTime == 0 {
+		config.ArgonTime = 3
+	}
+	if config.ArgonThreads == 0 {
+		config.ArgonThreads = 2
+	}
+	if config.BcryptCost < bcrypt.MinCost || config.BcryptCost > bcrypt.MaxCost {
+		config.BcryptCost = bcrypt.DefaultCost
+	}
+	if config.MinLength <= 0 {
+		config.MinLength = 8
+	}
+	passwordConfigMu.Lock()
+	passwordConfig = config
+	passwordConfigMu.Unlock()
+}
+
+// GetPasswordConfig 获取当前密码配置
+func GetPasswordConfig() PasswordConfig {
+	passwordConfigMu.RLock()
+	defer passwordConfigMu.RUnlock()
+	return passwordConfig
+}
+
+// ValidatePasswordStrength 检查密码强度：长度、至少包含两类字符（小写字母、大写字母、数字、符号），
+// 不能包含账号，也不能是常见密码
+func ValidatePasswordStrength(password, account string) error {
+	config := GetPasswordConfig()
+	length := utf8.RuneCountInString(password)
+	if length < config.MinLength {
+		return &AppError{Code: http.StatusBadRequest, Message: fmt.Sprintf("密码长度不能少于%d位", config.MinLength), Key: "password.too_short", Args: []interface{}{config.MinLength}}
+	}
+	if length > passwordMaxLength {
+		return &AppError{Code: http.StatusBadRequest, Message: fmt.Sprintf("密码长度不能超过%d位", passwordMaxLength), Key: "password.too_long", Args: []interface{}{passwordMaxLength}}
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsSpace(r):
+			return &AppError{Code: http.StatusBadRequest, Message: "密码不能包含空格", Key: "password.contains_space"}
+		default:
+			symbol = true
+		}
+	}
+	classes := 0
+	for _, ok := range []bool{lower, upper, digit, symbol} {
+		if ok {
+			classes++
+		}
+	}
+	if classes < 2 {
+		return &AppError{Code: http.StatusBadRequest, Message: "密码需包含字母、数字、符号中的至少两种", Key: "password.too_simple"}
+	}
+
+	lowered := strings.ToLower(password)
+	if account != "" && strings.Contains(lowered, strings.ToLower(account)) {
+		return &AppError{Code: http.StatusBadRequest, Message: "密码不能包含账号", Key: "password.contains_account"}
+	}
+	if commonPasswords[lowered] {
+		return &AppError{Code: http.StatusBadRequest, Message: "密码过于常见，请更换", Key: "password.too_common"}
+	}
+	return nil
+}
+
+// HashPassword 按当前配置的算法计算密码哈希
+func HashPassword(password string) (string, error) {
+	config := GetPasswordConfig()
+	if config.Algorithm == PasswordBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), config.BcryptCost)
+		return string(hash), err
+	}
+
+	salt := make([]byte, argonSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, config.ArgonTime, config.ArgonMemory, config.ArgonThreads, argonKeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+		config.ArgonMemory, config.ArgonTime, config.ArgonThreads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// VerifyPassword 校验密码，needsRehash 表示保存的哈希不是当前算法或参数（包括明文保存的旧密码），应重新计算
+func VerifyPassword(encoded, password string) (ok bool, needsRehash bool) {
+	config := GetPasswordConfig()
+	switch {
+	case encoded == "", strings.HasPrefix(encoded, UnusablePassword):
+		return false, false
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		params, salt, key, err := decodeArgon2Hash(encoded)
+		if err != nil {
+			return false, false
+		}
+		actual := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(actual, key) != 1 {
+			return false, false
+		}
+		return true, config.Algorithm != PasswordArgon2id || params.memory != config.ArgonMemory ||
+			params.time != config.ArgonTime || params.threads != config.ArgonThreads
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		if bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)) != nil {
+			return false, false
+		}
+		cost, _ := bcrypt.Cost([]byte(encoded))
+		return true, config.Algorithm != PasswordBcrypt || cost != config.BcryptCost
+	default:
+		// 早期版本直接保存了明文密码
+		return subtle.ConstantTimeCompare([]byte(encoded), []byte(password)) == 1, true
+	}
+}
+
+type argon2Params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+// decodeArgon2Hash 解析 PHC 格式的 argon2id 哈希
+func decodeArgon2Hash(encoded string) (argon2Params, []byte, []byte, error) {
+	var params argon2Params
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, errors.New("invalid argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errors.New("unsupported argon2 version")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id params: %w", err)
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errors.New("invalid argon2id key")
+	}
+	return params, salt, key, nil
+}
@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// usePasswordConfig 使用指定的密码配置，测试结束后恢复原配置
+func usePasswordConfig(t *testing.T, config PasswordConfig) {
+	t.Helper()
+	previous := GetPasswordConfig()
+	SetPasswordConfig(config)
+	t.Cleanup(func() { SetPasswordConfig(previous) })
+}
+
+// 测试使用较小的 argon2id 参数，缩短运行时间
+var testArgonConfig = PasswordConfig{Algorithm: PasswordArgon2id, ArgonMemory: 8 * 1024, ArgonTime: 1, ArgonThreads: 1, BcryptCost: bcrypt.MinCost}
+
+func TestHashPasswordArgon2id(t *testing.T) {
+	usePasswordConfig(t, testArgonConfig)
+
+	hash, err := HashPassword("Secret#123")
+	if err != nil {
+		t.Fatalf("计算哈希失败: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=8192,t=1,p=1$") {
+		t.Errorf("哈希格式错误: %s", hash)
+	}
+	if again, _ := HashPassword("Secret#123"); again == hash {
+		t.Error("相同密码每次的盐应不同")
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		ok       bool
+	}{
+		{"正确密码", "Secret#123", true},
+		{"错误密码", "Secret#124", false},
+		{"大小写不同", "secret#123", false},
+		{"空密码", "", false},
+	}
+	for _, tt := range tests {
+		ok, needsRehash := VerifyPassword(hash, tt.password)
+		if ok != tt.ok || needsRehash {
+			t.Errorf("%s: VerifyPassword = %v, %v，应为 %v, false", tt.name, ok, needsRehash, tt.ok)
+		}
+	}
+
+	// 参数或算法变化后，旧哈希仍可校验但需要重新计算
+	stronger := testArgonConfig
+	stronger.ArgonTime = 2
+	usePasswordConfig(t, stronger)
+	if ok, needsRehash := VerifyPassword(hash, "Secret#123"); !ok || !needsRehash {
+		t.Errorf("参数变化后应需要重新计算: %v, %v", ok, needsRehash)
+	}
+	bcryptConfig := testArgonConfig
+	bcryptConfig.Algorithm = PasswordBcrypt
+	usePasswordConfig(t, bcryptConfig)
+	if ok, needsRehash := VerifyPassword(hash, "Secret#123"); !ok || !needsRehash {
+		t.Errorf("算法变化后应需要重新计算: %v, %v", ok, needsRehash)
+	}
+}
+
+func TestVerifyLegacyPassword(t *testing.T) {
+	usePasswordConfig(t, testArgonConfig)
+	bcryptHash, _ := bcrypt.GenerateFromPassword([]byte("Secret#123"), bcrypt.MinCost)
+	argonHash, _ := HashPassword("Secret#123")
+
+	tests := []struct {
+		name        string
+		encoded     string
+		password    string
+		ok          bool
+		needsRehash bool
+	}{
+		{"bcrypt正确密码", string(bcryptHash), "Secret#123", true, true},
+		{"bcrypt错误密码", string(bcryptHash), "wrong", false, false},
+		{"明文正确密码", "Secret#123", "Secret#123", true, true},
+		{"明文错误密码", "Secret#123", "Secret#12", false, true},
+		{"不可登录账号", UnusablePassword, UnusablePassword, false, false},
+		{"空哈希", "", "", false, false},
+		{"损坏的argon2id哈希", "$argon2id$v=19$m=8192$bad", "Secret#123", false, false},
+		{"argon2id版本不符", strings.Replace(argonHash, "v=19", "v=16", 1), "Secret#123", false, false},
+	}
+	for _, tt := range tests {
+		ok, needsRehash := VerifyPassword(tt.encoded, tt.password)
+		if ok != tt.ok || needsRehash != tt.needsRehash {
+			t.Errorf("%s: VerifyPassword = %v, %v，应为 %v, %v", tt.name, ok, needsRehash, tt.ok, tt.needsRehash)
+		}
+	}
+
+	// 配置为 bcrypt 时，相同代价的 bcrypt 哈希不需要重新计算
+	bcryptConfig := testArgonConfig
+	bcryptConfig.Algorithm = PasswordBcrypt
+	usePasswordConfig(t, bcryptConfig)
+	if ok, needsRehash := VerifyPassword(string(bcryptHash), "Secret#123"); !ok || needsRehash {
+		t.Errorf("当前算法的 bcrypt 哈希不应重新计算: %v, %v", ok, needsRehash)
+	}
+	hash, _ := HashPassword("Secret#123")
+	if !strings.HasPrefix(hash, "$2a$") {
+		t.Errorf("配置为 bcrypt 时应生成 bcrypt 哈希: %s", hash)
+	}
+}
+
+func TestValidatePasswordStrength(t *testing.T) {
+	usePasswordConfig(t, testArgonConfig)
+
+	tests := []struct {
+		name     string
+		password string
+		account  string
+		key      string
+	}{
+		{"合格", "Blue-Sky42", "alice", ""},
+		{"字母和数字", "bluesky42", "alice", ""},
+		{"中文和数字", "蓝天白云蓝天白云1", "alice", ""},
+		{"太短", "Ab1!", "alice", "password.too_short"},
+		{"太长", strings.Repeat("a1", 65), "alice", "password.too_long"},
+		{"只有小写字母", "blueskyblue", "alice", "password.too_simple"},
+		{"只有数字", "1234567890", "alice", "password.too_simple"},
+		{"包含空格", "Blue Sky42", "alice", "password.contains_space"},
+		{"包含账号", "xAlice2024", "alice", "password.contains_account"},
+		{"常见密码", "Password123", "alice", "password.too_common"},
+	}
+	for _, tt := range tests {
+		err := ValidatePasswordStrength(tt.password, tt.account)
+		var key string
+		var appErr *AppError
+		if errors.As(err, &appErr) {
+			key = appErr.Key
+		} else if err != nil {
+			key = err.Error()
+		}
+		if key != tt.key {
+			t.Errorf("%s: 得到 %q，应为 %q", tt.name, key, tt.key)
+		}
+	}
+
+	// 最小长度可配置
+	config := testArgonConfig
+	config.MinLength = 12
+	usePasswordConfig(t, config)
+	if err := ValidatePasswordStrength("Blue-Sky42", "alice"); err == nil {
+		t.Error("最小长度为12时10位密码应被拒绝")
+	}
+}
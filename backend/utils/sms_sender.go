@@ -1,103 +1,81 @@
 package utils
 
 import (
-	"crypto/md5"
-	"encoding/hex"
+	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
-	"io/ioutil"
+	"io"
 	"net/http"
-	"net/url"
-	"strconv"
 	"strings"
 	"time"
 )
 
-// 短信配置
-type SMSConfig struct {
-	AppID     string
-	AppKey    string
-	TemplateID string
-	Sign      string
+// HTTPSMSSender 通用HTTP短信接口
+// 以JSON提交 {"to","text","sign","template","params"}，返回2xx表示发送成功；
+// 配置了令牌时通过 Authorization: Bearer 发送
+type HTTPSMSSender struct {
+	url    string
+	token  string
+	sign   string
+	client *http.Client
 }
 
-// 全局短信配置
-var smsConfig = SMSConfig{
-	AppID:     "your_app_id",     // 替换为您的短信服务AppID
-	AppKey:    "your_app_key",    // 替换为您的短信服务AppKey
-	TemplateID: "your_template_id", // 替换为您的短信模板ID
-	Sign:      "your_sign",       // 替换为您的短信签名
+// NewHTTPSMSSender 创建HTTP短信发送方
+func NewHTTPSMSSender(url, token, sign string) *HTTPSMSSender {
+	return &HTTPSMSSender{
+		url:    url,
+		token:  token,
+		sign:   sign,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
 }
 
-// 设置短信配置
-func SetSMSConfig(config SMSConfig) {
-	smsConfig = config
+func (s *HTTPSMSSender) Name() string {
+	return "http"
 }
 
-// 发送短信验证码
-func SendSMSVerificationCode(phone, code string) error {
-	// 这里使用腾讯云短信服务的示例
-	// 实际使用时请替换为您使用的短信服务商的API
-	
-	// 构建请求参数
-	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
-	params := map[string]string{
-		"phone_number_set": fmt.Sprintf("[%s]", phone),
-		"template_id":      smsConfig.TemplateID,
-		"template_param_set": fmt.Sprintf("[\"%s\"]", code),
-		"sign_name":        smsConfig.Sign,
-		"sms_sdk_app_id":   smsConfig.AppID,
-	}
-	
-	// 构建签名
-	paramsStr, _ := json.Marshal(params)
-	signStr := fmt.Sprintf("POST\n/v2/sendSms\n%s\n%s\n", timestamp, string(paramsStr))
-	h := md5.New()
-	h.Write([]byte(signStr + smsConfig.AppKey))
-	sign := hex.EncodeToString(h.Sum(nil))
-	
-	// 构建请求URL
-	apiURL := "https://sms.tencentcloudapi.com/v2/sendSms"
-	data := url.Values{}
-	for k, v := range params {
-		data.Set(k, v)
-	}
-	data.Set("timestamp", timestamp)
-	data.Set("sign", sign)
-	
-	// 发送请求
-	req, err := http.NewRequest("POST", apiURL, strings.NewReader(data.Encode()))
+func (s *HTTPSMSSender) SendSMS(ctx context.Context, msg *SMSMessage) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"to":       msg.To,
+		"text":     msg.Text,
+		"sign":     s.sign,
+		"template": msg.Template,
+		"params":   msg.Params,
+	})
 	if err != nil {
 		return err
 	}
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(payload))
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-	
-	// 解析响应
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return err
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
 	}
-	
-	// 检查响应是否成功
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return err
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return &SendError{Sender: s.Name(), Kind: ErrSendUnavailable, Message: err.Error()}
 	}
-	
-	if status, ok := result["status"].(float64); !ok || status != 0 {
-		return fmt.Errorf("发送短信失败: %s", string(body))
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newSendHTTPError(s.Name(), resp.StatusCode, string(body))
 	}
-	
 	return nil
 }
 
+// SendSMSVerificationCode 发送短信验证码，lang 为短信使用的语言
+func SendSMSVerificationCode(phone, code, lang string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	return SendTemplateSMS(ctx, phone, "verification_code", lang, map[string]interface{}{
+		"Code":    code,
+		"Minutes": 10,
+	})
+}
+
 // 验证手机号格式
 func ValidatePhone(phone string) bool {
 	// 简单的中国大陆手机号格式验证
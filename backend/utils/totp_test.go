@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录B的测试密钥 "12345678901234567890"
+const rfcTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeRFCVectors(t *testing.T) {
+	// RFC 给出的是8位验证码，6位验证码取其后6位
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		code, err := TOTPCode(rfcTOTPSecret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("计算验证码失败: %v", err)
+		}
+		if code != tt.code {
+			t.Errorf("T=%d 验证码为 %s，应为 %s", tt.unix, code, tt.code)
+		}
+	}
+	// 密钥不区分大小写
+	if code, _ := TOTPCode(strings.ToLower(rfcTOTPSecret), TOTPStep(time.Unix(59, 0))); code != "287082" {
+		t.Errorf("小写密钥的验证码为 %s", code)
+	}
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Error("无效的密钥应报错")
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := TOTPStep(now)
+	codeAt := func(s int64) string {
+		code, _ := TOTPCode(rfcTOTPSecret, s)
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		skew     int
+		wantStep int64
+		ok       bool
+	}{
+		{"当前时间步", codeAt(step), 1, step, true},
+		{"前后带空格", " " + codeAt(step) + " ", 1, step, true},
+		{"上一个时间步", codeAt(step - 1), 1, step - 1, true},
+		{"下一个时间步", codeAt(step + 1), 1, step + 1, true},
+		{"超出误差范围", codeAt(step - 2), 1, 0, false},
+		{"不允许误差", codeAt(step - 1), 0, 0, false},
+		{"位数不对", codeAt(step)[:5], 1, 0, false},
+		{"错误的验证码", "000000", 0, 0, codeAt(step) == "000000"},
+	}
+	for _, tt := range tests {
+		gotStep, ok := VerifyTOTP(rfcTOTPSecret, tt.code, now, tt.skew)
+		if ok != tt.ok || (ok && gotStep != tt.wantStep) {
+			t.Errorf("%s: VerifyTOTP = %d, %v，应为 %d, %v", tt.name, gotStep, ok, tt.wantStep, tt.ok)
+		}
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	a, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	b, _ := GenerateTOTPSecret()
+	if a == b || len(a) != 32 {
+		t.Errorf("密钥应为32位Base32且每次不同: %s, %s", a, b)
+	}
+	if _, err := TOTPCode(a, 1); err != nil {
+		t.Errorf("生成的密钥无法使用: %v", err)
+	}
+	uri := TOTPProvisioningURI("alice", a)
+	if !strings.HasPrefix(uri, "otpauth://totp/") || !strings.Contains(uri, "secret="+a) {
+		t.Errorf("otpauth 地址错误: %s", uri)
+	}
+}
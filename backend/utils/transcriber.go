@@ -0,0 +1,263 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 语音识别提供方抽象
+// 所有语音识别通过 Transcriber 完成，按配置的顺序组成回退链；
+// 音频先统一转换为16kHz单声道PCM的WAV，再交给提供方识别
+
+// Transcriber 语音识别提供方
+type Transcriber interface {
+	// Name 提供方名称，用于配置
+	Name() string
+	// Transcribe 识别16kHz单声道PCM的WAV文件，language 为空时由提供方自动判断
+	Transcribe(ctx context.Context, wavPath, language string) (*Transcript, error)
+}
+
+// Transcript 一次语音识别的结果
+type Transcript struct {
+	Text     string `json:"text"`
+	Language string `json:"language"` // 识别出的语言，提供方无法判断时为语言提示
+	Provider string `json:"provider"`
+	Duration int    `json:"duration"` // 音频时长（秒）
+}
+
+// 语音识别错误类型，可通过 errors.Is 判断
+var (
+	ErrTranscribeUnavailable = errors.New("语音识别服务不可用")
+	ErrTranscribeEmpty       = errors.New("没有识别到语音内容")
+	ErrTranscribeNoProvider  = errors.New("没有可用的语音识别服务")
+	ErrAudioInvalid          = errors.New("音频格式不支持或文件已损坏")
+	ErrAudioTooLong          = errors.New("音频时长超过限制")
+)
+
+// TranscribeError 带提供方信息的语音识别错误
+type TranscribeError struct {
+	Provider   string
+	Kind       error // 上面定义的错误类型之一
+	StatusCode int   // 上游HTTP状态码，非HTTP错误为0
+	Message    string
+}
+
+func (e *TranscribeError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s: %v", e.Provider, e.Kind)
+	}
+	return fmt.Sprintf("%s: %v: %s", e.Provider, e.Kind, e.Message)
+}
+
+func (e *TranscribeError) Unwrap() error {
+	return e.Kind
+}
+
+// newTranscribeHTTPError 根据上游HTTP状态码构造错误
+func newTranscribeHTTPError(provider string, statusCode int, body string) *TranscribeError {
+	kind := ErrTranscribeUnavailable
+	if statusCode == http.StatusBadRequest || statusCode == http.StatusUnsupportedMediaType {
+		kind = ErrAudioInvalid
+	}
+	if len(body) > 200 {
+		body = body[:200]
+	}
+	return &TranscribeError{Provider: provider, Kind: kind, StatusCode: statusCode, Message: body}
+}
+
+// 语音识别配置
+type SpeechConfig struct {
+	// 回退链，按顺序尝试
+	Chain []string
+
+	// ffmpeg 可执行文件，用于转换 AMR/M4A/WebM 等格式
+	FFmpegPath string
+
+	// whisper.cpp 命令行和模型文件
+	WhisperPath  string
+	WhisperModel string
+
+	// vosk-transcriber 命令行和模型目录
+	VoskPath  string
+	VoskModel string
+
+	WitAIToken string
+
+	// 单条音频的最大时长
+	MaxDuration time.Duration
+}
+
+var (
+	transcribersMu   sync.RWMutex
+	transcribers     = map[string]Transcriber{}
+	transcriberChain []string
+	speechConfig     SpeechConfig
+)
+
+// 初始化函数，从环境变量加载语音识别配置
+func init() {
+	config := SpeechConfig{
+		FFmpegPath:   envOrDefault("SPEECH_FFMPEG_PATH", "ffmpeg"),
+		WhisperPath:  envOrDefault("SPEECH_WHISPER_PATH", "whisper-cli"),
+		WhisperModel: os.Getenv("SPEECH_WHISPER_MODEL"),
+		VoskPath:     envOrDefault("SPEECH_VOSK_PATH", "vosk-transcriber"),
+		VoskModel:    os.Getenv("SPEECH_VOSK_MODEL"),
+		WitAIToken:   os.Getenv("SPEECH_WITAI_TOKEN"),
+		MaxDuration:  5 * time.Minute,
+	}
+	if chain := os.Getenv("SPEECH_PROVIDERS"); chain != "" {
+		for _, name := range strings.Split(chain, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				config.Chain = append(config.Chain, name)
+			}
+		}
+	}
+	if v, err := strconv.Atoi(os.Getenv("SPEECH_MAX_DURATION")); err == nil && v > 0 {
+		config.MaxDuration = time.Duration(v) * time.Second
+	}
+	SetSpeechConfig(config)
+}
+
+// SetSpeechConfig 根据配置重新注册提供方和回退链
+// 未显式配置回退链时，按已配置模型或凭据的提供方依次回退，本地引擎优先；都未配置时使用模拟提供方
+func SetSpeechConfig(config SpeechConfig) {
+	providers := map[string]Transcriber{
+		"mock": NewMockTranscriber(),
+	}
+	var chain []string
+	if config.WhisperModel != "" {
+		providers["whisper"] = NewWhisperTranscriber(config.WhisperPath, config.WhisperModel)
+		chain = append(chain, "whisper")
+	}
+	if config.VoskModel != "" {
+		providers["vosk"] = NewVoskTranscriber(config.VoskPath, config.VoskModel)
+		chain = append(chain, "vosk")
+	}
+	if config.WitAIToken != "" {
+		providers["witai"] = NewWitAITranscriber(config.WitAIToken)
+		chain = append(chain, "witai")
+	}
+	if len(config.Chain) > 0 {
+		chain = config.Chain
+	}
+	if len(chain) == 0 {
+		chain = []string{"mock"}
+	}
+
+	transcribersMu.Lock()
+	transcribers = providers
+	transcriberChain = chain
+	speechConfig = config
+	transcribersMu.Unlock()
+}
+
+// GetSpeechConfig 获取当前语音识别配置
+func GetSpeechConfig() SpeechConfig {
+	transcribersMu.RLock()
+	defer transcribersMu.RUnlock()
+	return speechConfig
+}
+
+// RegisterTranscriber 注册或替换提供方，可用于接入其他实现
+func RegisterTranscriber(transcriber Transcriber) {
+	transcribersMu.Lock()
+	defer transcribersMu.Unlock()
+	transcribers[transcriber.Name()] = transcriber
+}
+
+// SetTranscriberChain 设置回退链
+func SetTranscriberChain(names []string) {
+	transcribersMu.Lock()
+	defer transcribersMu.Unlock()
+	transcriberChain = append([]string{}, names...)
+}
+
+// ListTranscribers 返回回退链中已注册的提供方名称
+func ListTranscribers() []string {
+	transcribersMu.RLock()
+	defer transcribersMu.RUnlock()
+
+	names := []string{}
+	for _, name := range transcriberChain {
+		if _, ok := transcribers[name]; ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func resolveTranscriberChain() []Transcriber {
+	transcribersMu.RLock()
+	defer transcribersMu.RUnlock()
+
+	providers := []Transcriber{}
+	seen := map[string]bool{}
+	for _, name := range transcriberChain {
+		if t, ok := transcribers[name]; ok && !seen[name] {
+			seen[name] = true
+			providers = append(providers, t)
+		}
+	}
+	return providers
+}
+
+// TranscribeWithProviders 将任意格式的音频文件转换后按回退链识别，直到成功
+// language 为语言提示，空字符串或 auto 时自动判断
+func TranscribeWithProviders(ctx context.Context, audioPath, language string) (*Transcript, error) {
+	providers := resolveTranscriberChain()
+	if len(providers) == 0 {
+		return nil, &TranscribeError{Kind: ErrTranscribeNoProvider}
+	}
+
+	config := GetSpeechConfig()
+	wavPath, cleanup, err := NormalizeAudio(ctx, config.FFmpegPath, audioPath)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	duration, err := WavDuration(wavPath)
+	if err != nil {
+		return nil, err
+	}
+	if config.MaxDuration > 0 && duration > config.MaxDuration {
+		return nil, &TranscribeError{Kind: ErrAudioTooLong, Message: duration.String()}
+	}
+
+	language = NormalizeLanguage(language)
+	if language == "auto" {
+		language = ""
+	}
+
+	var lastErr error
+	for _, provider := range providers {
+		result, err := provider.Transcribe(ctx, wavPath, language)
+		if err == nil && strings.TrimSpace(result.Text) == "" {
+			err = &TranscribeError{Provider: provider.Name(), Kind: ErrTranscribeEmpty}
+		}
+		if err == nil {
+			result.Text = strings.TrimSpace(result.Text)
+			result.Provider = provider.Name()
+			result.Duration = int((duration + time.Second/2) / time.Second)
+			if result.Language == "" {
+				result.Language = language
+			} else {
+				result.Language = NormalizeLanguage(result.Language)
+			}
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		Logger.Errorf("语音识别提供方调用失败，尝试下一个: %v", err)
+		lastErr = err
+	}
+	return nil, lastErr
+}
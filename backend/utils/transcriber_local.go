@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// 本地语音识别引擎，通过子进程调用命令行工具，音频不离开服务器
+
+// whisper.cpp 自动检测语言时输出的日志，如 "auto-detected language: en (p = 0.97)"
+var whisperDetectedLanguage = regexp.MustCompile(`auto-detected language: ([a-z]{2,3})`)
+
+// WhisperTranscriber 基于 whisper.cpp 命令行的本地识别
+type WhisperTranscriber struct {
+	path  string
+	model string
+}
+
+// NewWhisperTranscriber 创建 whisper.cpp 提供方，model 为 ggml 模型文件路径
+func NewWhisperTranscriber(path, model string) *WhisperTranscriber {
+	return &WhisperTranscriber{path: path, model: model}
+}
+
+func (t *WhisperTranscriber) Name() string {
+	return "whisper"
+}
+
+func (t *WhisperTranscriber) Transcribe(ctx context.Context, wavPath, language string) (*Transcript, error) {
+	lang := "auto"
+	if language != "" {
+		lang = strings.ToLower(strings.SplitN(language, "-", 2)[0])
+	}
+	stdout, stderr, err := runSpeechCommand(ctx, t.Name(), t.path, "-m", t.model, "-f", wavPath, "-l", lang, "-nt")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Transcript{Text: joinTranscriptLines(stdout), Language: language}
+	if m := whisperDetectedLanguage.FindStringSubmatch(stderr); m != nil {
+		result.Language = m[1]
+	}
+	return result, nil
+}
+
+// VoskTranscriber 基于 vosk-transcriber 命令行的本地识别
+// Vosk 模型只支持一种语言，识别结果的语言为语言提示
+type VoskTranscriber struct {
+	path  string
+	model string
+}
+
+// NewVoskTranscriber 创建 Vosk 提供方，model 为模型目录
+func NewVoskTranscriber(path, model string) *VoskTranscriber {
+	return &VoskTranscriber{path: path, model: model}
+}
+
+func (t *VoskTranscriber) Name() string {
+	return "vosk"
+}
+
+func (t *VoskTranscriber) Transcribe(ctx context.Context, wavPath, language string) (*Transcript, error) {
+	stdout, _, err := runSpeechCommand(ctx, t.Name(), t.path, "-m", t.model, "-i", wavPath, "-t", "txt")
+	if err != nil {
+		return nil, err
+	}
+	return &Transcript{Text: joinTranscriptLines(stdout), Language: language}, nil
+}
+
+// runSpeechCommand 执行识别命令，返回标准输出和标准错误
+func runSpeechCommand(ctx context.Context, provider, path string, args ...string) (string, string, error) {
+	if _, err := exec.LookPath(path); err != nil {
+		return "", "", &TranscribeError{Provider: provider, Kind: ErrTranscribeUnavailable, Message: "未安装 " + path}
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", "", ctx.Err()
+		}
+		message := strings.TrimSpace(stderr.String())
+		if len(message) > 200 {
+			message = message[len(message)-200:]
+		}
+		return "", "", &TranscribeError{Provider: provider, Kind: ErrTranscribeUnavailable, Message: message}
+	}
+	return stdout.String(), stderr.String(), nil
+}
+
+// joinTranscriptLines 合并分段输出的识别文本
+func joinTranscriptLines(output string) string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, " ")
+}
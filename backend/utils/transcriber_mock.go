@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+)
+
+// MockTranscriber 确定性的模拟提供方，用于本地开发和测试
+// 默认返回包含音频时长的固定文本，可通过 Reply 自定义
+type MockTranscriber struct {
+	Reply func(wavPath, language string) (*Transcript, error)
+}
+
+// NewMockTranscriber 创建模拟提供方
+func NewMockTranscriber() *MockTranscriber {
+	return &MockTranscriber{}
+}
+
+func (t *MockTranscriber) Name() string {
+	return "mock"
+}
+
+func (t *MockTranscriber) Transcribe(ctx context.Context, wavPath, language string) (*Transcript, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if t.Reply != nil {
+		return t.Reply(wavPath, language)
+	}
+
+	duration, err := WavDuration(wavPath)
+	if err != nil {
+		return nil, err
+	}
+	if language == "" {
+		language = "zh-CN"
+	}
+	return &Transcript{Text: fmt.Sprintf("模拟转写：%.1f秒语音", duration.Seconds()), Language: language}, nil
+}
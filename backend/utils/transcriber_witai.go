@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// WitAITranscriber 使用 Wit.ai 的语音识别接口
+// Wit.ai 应用只支持创建时选择的语言，语言提示不会传给接口
+type WitAITranscriber struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewWitAITranscriber 创建 Wit.ai 提供方，token 为应用的 Server Access Token
+func NewWitAITranscriber(token string) *WitAITranscriber {
+	return &WitAITranscriber{
+		baseURL: "https://api.wit.ai",
+		token:   token,
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (t *WitAITranscriber) Name() string {
+	return "witai"
+}
+
+func (t *WitAITranscriber) Transcribe(ctx context.Context, wavPath, language string) (*Transcript, error) {
+	file, err := os.Open(wavPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.baseURL+"/speech", file)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	req.Header.Set("Content-Type", "audio/wav")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &TranscribeError{Provider: t.Name(), Kind: ErrTranscribeUnavailable, Message: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, newTranscribeHTTPError(t.Name(), resp.StatusCode, string(body))
+	}
+
+	// 接口以多个JSON对象流式返回中间结果，最后一个带文本的对象为最终结果
+	decoder := json.NewDecoder(resp.Body)
+	text := ""
+	for {
+		var chunk struct {
+			Text string `json:"text"`
+		}
+		if err := decoder.Decode(&chunk); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, &TranscribeError{Provider: t.Name(), Kind: ErrTranscribeUnavailable, Message: "响应格式错误"}
+		}
+		if chunk.Text != "" {
+			text = chunk.Text
+		}
+	}
+	return &Transcript{Text: text, Language: language}, nil
+}
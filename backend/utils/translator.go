@@ -0,0 +1,387 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 翻译服务提供方抽象
+// 所有翻译通过 Translator 完成，按配置的顺序组成回退链；
+// 每个提供方有独立的限流和熔断，离线词典始终位于链尾作为兜底
+
+// Translator 翻译服务提供方
+type Translator interface {
+	// Name 提供方名称，用于配置
+	Name() string
+	// Translate 批量翻译，返回与 texts 一一对应的译文
+	Translate(ctx context.Context, texts []string, sourceLang, targetLang string) ([]string, error)
+	// Detect 检测文本语言
+	Detect(ctx context.Context, text string) (string, error)
+}
+
+// BatchTranslator 一次请求即可翻译多条文本的提供方
+type BatchTranslator interface {
+	SupportsBatch() bool
+}
+
+// 翻译错误类型，可通过 errors.Is 判断
+var (
+	ErrTranslateUnavailable = errors.New("翻译服务不可用")
+	ErrTranslateRateLimited = errors.New("翻译服务请求过于频繁")
+	ErrTranslateCircuitOpen = errors.New("翻译服务暂时熔断")
+	ErrTranslateUnsupported = errors.New("不支持的翻译语言")
+	ErrTranslateNoProvider  = errors.New("没有可用的翻译服务")
+)
+
+// TranslateError 带提供方信息的翻译错误
+type TranslateError struct {
+	Provider   string
+	Kind       error // 上面定义的错误类型之一
+	StatusCode int   // 上游HTTP状态码，非HTTP错误为0
+	Message    string
+}
+
+func (e *TranslateError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s: %v", e.Provider, e.Kind)
+	}
+	return fmt.Sprintf("%s: %v: %s", e.Provider, e.Kind, e.Message)
+}
+
+func (e *TranslateError) Unwrap() error {
+	return e.Kind
+}
+
+// newTranslateHTTPError 根据上游HTTP状态码构造错误
+func newTranslateHTTPError(provider string, statusCode int, body string) *TranslateError {
+	kind := ErrTranslateUnavailable
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		kind = ErrTranslateRateLimited
+	case statusCode == http.StatusBadRequest:
+		kind = ErrTranslateUnsupported
+	}
+	if len(body) > 200 {
+		body = body[:200]
+	}
+	return &TranslateError{Provider: provider, Kind: kind, StatusCode: statusCode, Message: body}
+}
+
+// 翻译提供方配置
+type TranslationConfig struct {
+	// 回退链，按顺序尝试；离线词典总会追加在末尾
+	Chain []string
+
+	// LibreTranslate 兼容服务，可自建
+	LibreTranslateURL    string
+	LibreTranslateAPIKey string
+
+	// 是否启用Google翻译网页接口
+	GoogleEnabled bool
+
+	// 每个提供方每分钟的请求数上限，0表示不限制
+	RateLimitPerMinute int
+	// 连续失败多少次后熔断
+	BreakerThreshold int
+	// 熔断持续时间，之后放行一次试探请求
+	BreakerCooldown time.Duration
+}
+
+var (
+	translatorsMu    sync.RWMutex
+	translators      = map[string]*guardedTranslator{}
+	translationChain []string
+)
+
+// 初始化函数，从环境变量加载翻译配置
+func init() {
+	config := TranslationConfig{
+		LibreTranslateURL:    envOrDefault("TRANSLATE_LIBRE_URL", "https://translate.argosopentech.com"),
+		LibreTranslateAPIKey: os.Getenv("TRANSLATE_LIBRE_API_KEY"),
+		GoogleEnabled:        os.Getenv("TRANSLATE_GOOGLE_DISABLED") == "",
+		RateLimitPerMinute:   60,
+		BreakerThreshold:     5,
+		BreakerCooldown:      time.Minute,
+	}
+	if chain := os.Getenv("TRANSLATE_PROVIDERS"); chain != "" {
+		for _, name := range strings.Split(chain, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				config.Chain = append(config.Chain, name)
+			}
+		}
+	}
+	if v, err := strconv.Atoi(os.Getenv("TRANSLATE_RATE_LIMIT")); err == nil && v >= 0 {
+		config.RateLimitPerMinute = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("TRANSLATE_BREAKER_THRESHOLD")); err == nil && v > 0 {
+		config.BreakerThreshold = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("TRANSLATE_BREAKER_COOLDOWN")); err == nil && v > 0 {
+		config.BreakerCooldown = time.Duration(v) * time.Second
+	}
+	SetTranslationConfig(config)
+}
+
+// SetTranslationConfig 根据配置重新注册提供方和回退链，限流和熔断状态随之重置
+func SetTranslationConfig(config TranslationConfig) {
+	providers := []Translator{NewDictionaryTranslator()}
+	var chain []string
+	if config.LibreTranslateURL != "" {
+		providers = append(providers, NewLibreTranslator(config.LibreTranslateURL, config.LibreTranslateAPIKey))
+		chain = append(chain, "libretranslate")
+	}
+	if config.GoogleEnabled {
+		providers = append(providers, NewGoogleTranslator())
+		chain = append(chain, "google")
+	}
+	if len(config.Chain) > 0 {
+		chain = config.Chain
+	}
+
+	// 离线词典不限流也不熔断
+	guarded := map[string]*guardedTranslator{
+		"dictionary": newGuardedTranslator(providers[0], TranslationConfig{}),
+	}
+	for _, p := range providers[1:] {
+		guarded[p.Name()] = newGuardedTranslator(p, config)
+	}
+
+	translatorsMu.Lock()
+	translators = guarded
+	translationChain = chain
+	translatorsMu.Unlock()
+}
+
+// RegisterTranslator 注册或替换提供方，使用默认的限流和熔断参数
+func RegisterTranslator(translator Translator) {
+	translatorsMu.Lock()
+	defer translatorsMu.Unlock()
+	translators[translator.Name()] = newGuardedTranslator(translator, TranslationConfig{
+		RateLimitPerMinute: 60,
+		BreakerThreshold:   5,
+		BreakerCooldown:    time.Minute,
+	})
+}
+
+// SetTranslatorChain 设置回退链
+func SetTranslatorChain(names []string) {
+	translatorsMu.Lock()
+	defer translatorsMu.Unlock()
+	translationChain = append([]string{}, names...)
+}
+
+// resolveTranslatorChain 回退链中已注册的提供方，离线词典排在最后
+func resolveTranslatorChain() []*guardedTranslator {
+	translatorsMu.RLock()
+	defer translatorsMu.RUnlock()
+
+	providers := []*guardedTranslator{}
+	seen := map[string]bool{}
+	for _, name := range append(append([]string{}, translationChain...), "dictionary") {
+		if p, ok := translators[name]; ok && !seen[name] {
+			seen[name] = true
+			providers = append(providers, p)
+		}
+	}
+	return providers
+}
+
+// TranslatorStatus 提供方的限流和熔断状态
+type TranslatorStatus struct {
+	Name        string `json:"name"`
+	State       string `json:"state"` // closed, open, half_open
+	Failures    int    `json:"failures"`
+	OpenUntil   int64  `json:"open_until,omitempty"`
+	RateLimit   int    `json:"rate_limit"` // 每分钟请求数上限
+	RecentCalls int    `json:"recent_calls"`
+}
+
+// ListTranslatorStatus 按回退链顺序返回各提供方的状态
+func ListTranslatorStatus() []TranslatorStatus {
+	statuses := []TranslatorStatus{}
+	for _, p := range resolveTranslatorChain() {
+		statuses = append(statuses, p.status())
+	}
+	return statuses
+}
+
+// TranslateWithProviders 按回退链批量翻译，返回译文和实际完成翻译的提供方
+func TranslateWithProviders(ctx context.Context, texts []string, sourceLang, targetLang string) ([]string, string, error) {
+	if len(texts) == 0 {
+		return nil, "", nil
+	}
+	providers := resolveTranslatorChain()
+	if len(providers) == 0 {
+		return nil, "", &TranslateError{Kind: ErrTranslateNoProvider}
+	}
+
+	var lastErr error
+	for _, provider := range providers {
+		translated, err := provider.translate(ctx, texts, sourceLang, targetLang)
+		if err == nil {
+			return translated, provider.Name(), nil
+		}
+		if ctx.Err() != nil {
+			return nil, "", ctx.Err()
+		}
+		Logger.Errorf("翻译提供方调用失败，尝试下一个: %v", err)
+		lastErr = err
+	}
+	return nil, "", lastErr
+}
+
+// DetectWithProviders 按回退链检测语言，返回语言和实际完成检测的提供方
+func DetectWithProviders(ctx context.Context, text string) (string, string, error) {
+	providers := resolveTranslatorChain()
+	if len(providers) == 0 {
+		return "", "", &TranslateError{Kind: ErrTranslateNoProvider}
+	}
+
+	var lastErr error
+	for _, provider := range providers {
+		lang, err := provider.detect(ctx, text)
+		if err == nil {
+			return lang, provider.Name(), nil
+		}
+		if ctx.Err() != nil {
+			return "", "", ctx.Err()
+		}
+		Logger.Errorf("语言检测失败，尝试下一个: %v", err)
+		lastErr = err
+	}
+	return "", "", lastErr
+}
+
+// guardedTranslator 为提供方附加限流和熔断
+type guardedTranslator struct {
+	Translator
+
+	mu        sync.Mutex
+	rateLimit int
+	calls     []time.Time // 最近一分钟内的请求时间
+
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	probing   bool // 熔断结束后的试探请求进行中
+}
+
+func newGuardedTranslator(translator Translator, config TranslationConfig) *guardedTranslator {
+	return &guardedTranslator{
+		Translator: translator,
+		rateLimit:  config.RateLimitPerMinute,
+		threshold:  config.BreakerThreshold,
+		cooldown:   config.BreakerCooldown,
+	}
+}
+
+// acquire 检查熔断和限流，通过时记录 cost 次请求
+func (g *guardedTranslator) acquire(cost int) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	if !g.openUntil.IsZero() {
+		if now.Before(g.openUntil) || g.probing {
+			return &TranslateError{Provider: g.Name(), Kind: ErrTranslateCircuitOpen}
+		}
+		// 熔断到期，只放行一次试探请求
+		g.probing = true
+	}
+
+	if g.rateLimit > 0 {
+		cutoff := now.Add(-time.Minute)
+		kept := g.calls[:0]
+		for _, t := range g.calls {
+			if t.After(cutoff) {
+				kept = append(kept, t)
+			}
+		}
+		g.calls = kept
+		// 单次批量超过上限时，只要窗口内没有其他请求就放行
+		if len(g.calls) > 0 && len(g.calls)+cost > g.rateLimit {
+			g.probing = false
+			return &TranslateError{Provider: g.Name(), Kind: ErrTranslateRateLimited}
+		}
+		for i := 0; i < cost; i++ {
+			g.calls = append(g.calls, now)
+		}
+	}
+	return nil
+}
+
+// report 记录调用结果；参数错误不计入熔断，请求被取消时不能说明提供方是否可用，失败次数和熔断状态保持不变
+func (g *guardedTranslator) report(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.probing = false
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	if err == nil || errors.Is(err, ErrTranslateUnsupported) {
+		g.failures = 0
+		g.openUntil = time.Time{}
+		return
+	}
+	g.failures++
+	if g.threshold > 0 && g.failures >= g.threshold {
+		g.openUntil = time.Now().Add(g.cooldown)
+		Logger.Errorf("翻译提供方 %s 连续失败 %d 次，熔断至 %s", g.Name(), g.failures, g.openUntil.Format("15:04:05"))
+	}
+}
+
+func (g *guardedTranslator) translate(ctx context.Context, texts []string, sourceLang, targetLang string) ([]string, error) {
+	// 不支持批量的提供方每条文本单独请求，按条数计入限流
+	cost := len(texts)
+	if b, ok := g.Translator.(BatchTranslator); ok && b.SupportsBatch() {
+		cost = 1
+	}
+	if err := g.acquire(cost); err != nil {
+		return nil, err
+	}
+	translated, err := g.Translate(ctx, texts, sourceLang, targetLang)
+	if err == nil && len(translated) != len(texts) {
+		err = &TranslateError{Provider: g.Name(), Kind: ErrTranslateUnavailable, Message: "译文数量与原文不一致"}
+	}
+	g.report(err)
+	return translated, err
+}
+
+func (g *guardedTranslator) detect(ctx context.Context, text string) (string, error) {
+	if err := g.acquire(1); err != nil {
+		return "", err
+	}
+	lang, err := g.Detect(ctx, text)
+	g.report(err)
+	return lang, err
+}
+
+func (g *guardedTranslator) status() TranslatorStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	status := TranslatorStatus{Name: g.Name(), State: "closed", Failures: g.failures, RateLimit: g.rateLimit}
+	if !g.openUntil.IsZero() {
+		status.OpenUntil = g.openUntil.Unix()
+		status.State = "open"
+		if !time.Now().Before(g.openUntil) {
+			status.State = "half_open"
+		}
+	}
+	cutoff := time.Now().Add(-time.Minute)
+	for _, t := range g.calls {
+		if t.After(cutoff) {
+			status.RecentCalls++
+		}
+	}
+	return status
+}
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// DictionaryTranslator 离线词典，在线服务都不可用时兜底
+// 只能翻译常用短语，其余文本原样返回；语言检测根据文字所属的书写系统判断
+type DictionaryTranslator struct{}
+
+// NewDictionaryTranslator 创建离线词典提供方
+func NewDictionaryTranslator() *DictionaryTranslator {
+	return &DictionaryTranslator{}
+}
+
+var (
+	dictionaryMu sync.RWMutex
+	// 每项为同一短语在各语言中的写法
+	dictionaryPhrases = []map[string]string{
+		{"zh-CN": "你好", "zh-TW": "你好", "en": "hello", "ja": "こんにちは", "ko": "안녕하세요", "fr": "bonjour", "de": "hallo", "es": "hola", "it": "ciao", "ru": "привет", "pt": "olá", "ar": "مرحبا", "hi": "नमस्ते"},
+		{"zh-CN": "谢谢", "zh-TW": "謝謝", "en": "thank you", "ja": "ありがとう", "ko": "감사합니다", "fr": "merci", "de": "danke", "es": "gracias", "it": "grazie", "ru": "спасибо", "pt": "obrigado", "ar": "شكرا", "hi": "धन्यवाद"},
+		{"zh-CN": "再见", "zh-TW": "再見", "en": "goodbye", "ja": "さようなら", "ko": "안녕히 가세요", "fr": "au revoir", "de": "auf wiedersehen", "es": "adiós", "it": "arrivederci", "ru": "до свидания", "pt": "adeus", "ar": "مع السلامة", "hi": "अलविदा"},
+		{"zh-CN": "早上好", "zh-TW": "早安", "en": "good morning", "ja": "おはようございます", "ko": "좋은 아침입니다", "fr": "bonjour", "de": "guten morgen", "es": "buenos días", "it": "buongiorno", "ru": "доброе утро", "pt": "bom dia", "ar": "صباح الخير", "hi": "सुप्रभात"},
+		{"zh-CN": "晚安", "zh-TW": "晚安", "en": "good night", "ja": "おやすみなさい", "ko": "안녕히 주무세요", "fr": "bonne nuit", "de": "gute nacht", "es": "buenas noches", "it": "buonanotte", "ru": "спокойной ночи", "pt": "boa noite", "ar": "تصبح على خير", "hi": "शुभ रात्रि"},
+		{"zh-CN": "对不起", "zh-TW": "對不起", "en": "sorry", "ja": "すみません", "ko": "죄송합니다", "fr": "désolé", "de": "entschuldigung", "es": "lo siento", "it": "mi dispiace", "ru": "извините", "pt": "desculpe", "ar": "آسف", "hi": "माफ़ कीजिए"},
+		{"zh-CN": "是", "zh-TW": "是", "en": "yes", "ja": "はい", "ko": "네", "fr": "oui", "de": "ja", "es": "sí", "it": "sì", "ru": "да", "pt": "sim", "ar": "نعم", "hi": "हाँ"},
+		{"zh-CN": "不", "zh-TW": "不", "en": "no", "ja": "いいえ", "ko": "아니요", "fr": "non", "de": "nein", "es": "no", "it": "no", "ru": "нет", "pt": "não", "ar": "لا", "hi": "नहीं"},
+	}
+)
+
+// AddDictionaryPhrases 添加离线词典短语，键为语言代码
+func AddDictionaryPhrases(phrases map[string]string) {
+	dictionaryMu.Lock()
+	defer dictionaryMu.Unlock()
+	dictionaryPhrases = append(dictionaryPhrases, phrases)
+}
+
+func (t *DictionaryTranslator) Name() string {
+	return "dictionary"
+}
+
+func (t *DictionaryTranslator) Translate(ctx context.Context, texts []string, sourceLang, targetLang string) ([]string, error) {
+	sourceLang = NormalizeLanguage(sourceLang)
+	targetLang = NormalizeLanguage(targetLang)
+
+	dictionaryMu.RLock()
+	defer dictionaryMu.RUnlock()
+
+	translated := make([]string, len(texts))
+	for i, text := range texts {
+		translated[i] = text
+		key := strings.ToLower(strings.TrimSpace(text))
+		for _, phrases := range dictionaryPhrases {
+			if !dictionaryMatch(phrases, sourceLang, key) {
+				continue
+			}
+			if target, ok := phrases[targetLang]; ok {
+				translated[i] = target
+			}
+			break
+		}
+	}
+	return translated, nil
+}
+
+// dictionaryMatch 判断短语是否匹配，源语言为 auto 时匹配任意语言
+func dictionaryMatch(phrases map[string]string, sourceLang, key string) bool {
+	if sourceLang != "auto" {
+		return strings.ToLower(phrases[sourceLang]) == key
+	}
+	for _, phrase := range phrases {
+		if strings.ToLower(phrase) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Detect 统计各书写系统的字符数，取最多的一种；拉丁字母统一视为英语
+func (t *DictionaryTranslator) Detect(ctx context.Context, text string) (string, error) {
+	counts := map[string]int{}
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			// 日文通常夹杂汉字，出现假名即可判断
+			counts["ja"] += 100
+		case unicode.Is(unicode.Hangul, r):
+			counts["ko"]++
+		case unicode.Is(unicode.Han, r):
+			counts["zh-CN"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			counts["ru"]++
+		case unicode.Is(unicode.Arabic, r):
+			counts["ar"]++
+		case unicode.Is(unicode.Devanagari, r):
+			counts["hi"]++
+		case unicode.Is(unicode.Latin, r):
+			counts["en"]++
+		}
+	}
+
+	lang, best := "en", 0
+	for _, candidate := range []string{"ja", "ko", "zh-CN", "ru", "ar", "hi", "en"} {
+		if counts[candidate] > best {
+			lang, best = candidate, counts[candidate]
+		}
+	}
+	return lang, nil
+}
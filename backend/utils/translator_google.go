@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// GoogleTranslator Google翻译网页接口，无需密钥但不支持批量，逐条请求
+type GoogleTranslator struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewGoogleTranslator 创建Google翻译提供方
+func NewGoogleTranslator() *GoogleTranslator {
+	return &GoogleTranslator{
+		baseURL: "https://translate.googleapis.com/translate_a/single",
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (t *GoogleTranslator) Name() string {
+	return "google"
+}
+
+func (t *GoogleTranslator) Translate(ctx context.Context, texts []string, sourceLang, targetLang string) ([]string, error) {
+	translated := make([]string, 0, len(texts))
+	for _, text := range texts {
+		result, err := t.query(ctx, text, NormalizeLanguage(sourceLang), NormalizeLanguage(targetLang))
+		if err != nil {
+			return nil, err
+		}
+
+		// 译文按句子分段返回：[[["译文","原文",...],...],...]
+		var segments []interface{}
+		if len(result) > 0 {
+			segments, _ = result[0].([]interface{})
+		}
+		var b strings.Builder
+		for _, s := range segments {
+			segment, ok := s.([]interface{})
+			if !ok || len(segment) == 0 {
+				continue
+			}
+			if part, ok := segment[0].(string); ok {
+				b.WriteString(part)
+			}
+		}
+		translated = append(translated, b.String())
+	}
+	return translated, nil
+}
+
+func (t *GoogleTranslator) Detect(ctx context.Context, text string) (string, error) {
+	result, err := t.query(ctx, text, "auto", "en")
+	if err != nil {
+		return "", err
+	}
+	if len(result) < 3 {
+		return "", &TranslateError{Provider: t.Name(), Kind: ErrTranslateUnavailable, Message: "没有检测结果"}
+	}
+	lang, ok := result[2].(string)
+	if !ok {
+		return "", &TranslateError{Provider: t.Name(), Kind: ErrTranslateUnavailable, Message: "没有检测结果"}
+	}
+	return NormalizeLanguage(lang), nil
+}
+
+// query 请求翻译接口并解析为JSON数组
+func (t *GoogleTranslator) query(ctx context.Context, text, sourceLang, targetLang string) ([]interface{}, error) {
+	params := url.Values{}
+	params.Add("client", "gtx")
+	params.Add("sl", sourceLang)
+	params.Add("tl", targetLang)
+	params.Add("dt", "t")
+	params.Add("q", text)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.baseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, &TranslateError{Provider: t.Name(), Kind: ErrTranslateUnavailable, Message: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &TranslateError{Provider: t.Name(), Kind: ErrTranslateUnavailable, Message: err.Error()}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newTranslateHTTPError(t.Name(), resp.StatusCode, string(body))
+	}
+
+	var result []interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, &TranslateError{Provider: t.Name(), Kind: ErrTranslateUnavailable, Message: "响应格式错误"}
+	}
+	return result, nil
+}
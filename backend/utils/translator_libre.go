@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LibreTranslator LibreTranslate 兼容的翻译接口，可使用公共实例或自建服务
+// https://github.com/LibreTranslate/LibreTranslate
+type LibreTranslator struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewLibreTranslator 创建 LibreTranslate 提供方，apiKey 为空时不发送
+func NewLibreTranslator(baseURL, apiKey string) *LibreTranslator {
+	return &LibreTranslator{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (t *LibreTranslator) Name() string {
+	return "libretranslate"
+}
+
+// SupportsBatch 接口支持数组形式的 q
+func (t *LibreTranslator) SupportsBatch() bool {
+	return true
+}
+
+// Translate 一次请求翻译全部文本，q 为数组时接口按顺序返回译文数组
+func (t *LibreTranslator) Translate(ctx context.Context, texts []string, sourceLang, targetLang string) ([]string, error) {
+	payload := map[string]interface{}{
+		"q":      texts,
+		"source": libreLanguage(sourceLang),
+		"target": libreLanguage(targetLang),
+		"format": "text",
+	}
+	body, err := t.post(ctx, "/translate", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		TranslatedText []string `json:"translatedText"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, &TranslateError{Provider: t.Name(), Kind: ErrTranslateUnavailable, Message: "响应格式错误"}
+	}
+	return result.TranslatedText, nil
+}
+
+func (t *LibreTranslator) Detect(ctx context.Context, text string) (string, error) {
+	body, err := t.post(ctx, "/detect", map[string]interface{}{"q": text})
+	if err != nil {
+		return "", err
+	}
+
+	var result []struct {
+		Language   string  `json:"language"`
+		Confidence float64 `json:"confidence"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || len(result) == 0 {
+		return "", &TranslateError{Provider: t.Name(), Kind: ErrTranslateUnavailable, Message: "没有检测结果"}
+	}
+	return NormalizeLanguage(result[0].Language), nil
+}
+
+// post 发送请求并返回响应内容，非200时转换为对应的错误类型
+func (t *LibreTranslator) post(ctx context.Context, path string, payload map[string]interface{}) ([]byte, error) {
+	if t.apiKey != "" {
+		payload["api_key"] = t.apiKey
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, &TranslateError{Provider: t.Name(), Kind: ErrTranslateUnavailable, Message: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &TranslateError{Provider: t.Name(), Kind: ErrTranslateUnavailable, Message: err.Error()}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newTranslateHTTPError(t.Name(), resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+// libreLanguage LibreTranslate 使用的语言代码
+func libreLanguage(lang string) string {
+	switch NormalizeLanguage(lang) {
+	case "zh-CN":
+		return "zh"
+	case "zh-TW":
+		return "zt"
+	}
+	return NormalizeLanguage(lang)
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// stubTranslator 按 err 返回结果的翻译提供方，记录调用次数
+type stubTranslator struct {
+	name  string
+	err   error
+	batch bool
+	calls int
+}
+
+func (s *stubTranslator) Name() string { return s.name }
+
+func (s *stubTranslator) SupportsBatch() bool { return s.batch }
+
+func (s *stubTranslator) Translate(ctx context.Context, texts []string, sourceLang, targetLang string) ([]string, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	translated := make([]string, len(texts))
+	for i, text := range texts {
+		translated[i] = "[" + targetLang + "]" + text
+	}
+	return translated, nil
+}
+
+func (s *stubTranslator) Detect(ctx context.Context, text string) (string, error) {
+	return "en", s.err
+}
+
+func TestGuardedTranslatorBreaker(t *testing.T) {
+	stub := &stubTranslator{name: "stub", err: &TranslateError{Provider: "stub", Kind: ErrTranslateUnavailable}}
+	guarded := newGuardedTranslator(stub, TranslationConfig{BreakerThreshold: 3, BreakerCooldown: time.Hour})
+	ctx := context.Background()
+
+	// 请求被取消不重置连续失败次数
+	guarded.translate(ctx, []string{"a"}, "en", "zh-CN")
+	guarded.translate(ctx, []string{"a"}, "en", "zh-CN")
+	guarded.report(context.Canceled)
+	guarded.translate(ctx, []string{"a"}, "en", "zh-CN")
+	if _, err := guarded.translate(ctx, []string{"a"}, "en", "zh-CN"); !errors.Is(err, ErrTranslateCircuitOpen) {
+		t.Fatalf("连续失败3次后应熔断，得到 %v", err)
+	}
+	if stub.calls != 3 {
+		t.Errorf("熔断期间不应请求提供方，调用了 %d 次", stub.calls)
+	}
+
+	// 熔断到期后的试探请求被取消时保持熔断，下一次请求继续试探
+	guarded.mu.Lock()
+	guarded.openUntil = time.Now().Add(-time.Second)
+	guarded.mu.Unlock()
+	if err := guarded.acquire(1); err != nil {
+		t.Fatalf("熔断到期后应放行试探请求，得到 %v", err)
+	}
+	if err := guarded.acquire(1); !errors.Is(err, ErrTranslateCircuitOpen) {
+		t.Errorf("试探期间应拒绝其他请求，得到 %v", err)
+	}
+	guarded.report(context.Canceled)
+	if guarded.openUntil.IsZero() || guarded.failures != 3 {
+		t.Errorf("试探被取消后不应关闭熔断，failures=%d", guarded.failures)
+	}
+
+	stub.err = nil
+	if _, err := guarded.translate(ctx, []string{"a"}, "en", "zh-CN"); err != nil {
+		t.Fatalf("试探成功应返回译文，得到 %v", err)
+	}
+	if !guarded.openUntil.IsZero() || guarded.failures != 0 {
+		t.Errorf("试探成功后应关闭熔断，failures=%d", guarded.failures)
+	}
+}
+
+func TestGuardedTranslatorRateLimit(t *testing.T) {
+	ctx := context.Background()
+	texts := []string{"a", "b"}
+
+	// 不支持批量的提供方按条数计入限流
+	single := newGuardedTranslator(&stubTranslator{name: "single"}, TranslationConfig{RateLimitPerMinute: 3})
+	if _, err := single.translate(ctx, texts, "en", "zh-CN"); err != nil {
+		t.Fatalf("第一次请求失败: %v", err)
+	}
+	if _, err := single.translate(ctx, texts, "en", "zh-CN"); !errors.Is(err, ErrTranslateRateLimited) {
+		t.Errorf("超过每分钟条数上限时应限流，得到 %v", err)
+	}
+
+	// 支持批量的提供方每次请求只计一次
+	batch := newGuardedTranslator(&stubTranslator{name: "batch", batch: true}, TranslationConfig{RateLimitPerMinute: 3})
+	for i := 0; i < 3; i++ {
+		if _, err := batch.translate(ctx, texts, "en", "zh-CN"); err != nil {
+			t.Fatalf("第%d次批量请求失败: %v", i+1, err)
+		}
+	}
+	if _, err := batch.translate(ctx, texts, "en", "zh-CN"); !errors.Is(err, ErrTranslateRateLimited) {
+		t.Errorf("超过每分钟请求上限时应限流，得到 %v", err)
+	}
+
+	// 单次批量超过上限时，窗口内没有其他请求就放行
+	large := newGuardedTranslator(&stubTranslator{name: "large"}, TranslationConfig{RateLimitPerMinute: 1})
+	if _, err := large.translate(ctx, texts, "en", "zh-CN"); err != nil {
+		t.Errorf("窗口内没有其他请求时应放行，得到 %v", err)
+	}
+}
+
+func TestTranslateWithProvidersFallback(t *testing.T) {
+	failing := &stubTranslator{name: "failing", err: &TranslateError{Provider: "failing", Kind: ErrTranslateUnavailable}}
+	working := &stubTranslator{name: "working", batch: true}
+	SetTranslationConfig(TranslationConfig{Chain: []string{"failing", "working"}})
+	RegisterTranslator(failing)
+	RegisterTranslator(working)
+	t.Cleanup(func() { SetTranslationConfig(TranslationConfig{}) })
+
+	translated, provider, err := TranslateWithProviders(context.Background(), []string{"hi"}, "en", "zh-CN")
+	if err != nil || provider != "working" || translated[0] != "[zh-CN]hi" {
+		t.Errorf("应回退到下一个提供方，得到 %v %s %v", translated, provider, err)
+	}
+	if failing.calls != 1 || working.calls != 1 {
+		t.Errorf("调用次数不正确: failing=%d working=%d", failing.calls, working.calls)
+	}
+
+	// 全部在线服务失败时由离线词典兜底
+	working.err = failing.err
+	if _, provider, err := TranslateWithProviders(context.Background(), []string{"hi"}, "en", "zh-CN"); err != nil || provider != "dictionary" {
+		t.Errorf("应由离线词典兜底，得到 %s %v", provider, err)
+	}
+}
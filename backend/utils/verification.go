@@ -1,83 +1,144 @@
 package utils
 
 import (
-	"math/rand"
-	"sync"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"math/big"
 	"time"
 )
 
-// 初始化随机数生成器
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
+// CodePurpose 验证码用途，不同用途的验证码分开保存，不能混用
+type CodePurpose string
 
-// 验证码存储
-var verificationStore = struct {
-	sync.RWMutex
-	data map[string]verificationInfo
-}{
-	data: make(map[string]verificationInfo),
-}
+const (
+	CodePurposeRegister      CodePurpose = "register"       // 注册
+	CodePurposeResetPassword CodePurpose = "reset_password" // 重置登录密码
+	CodePurposeCaptcha       CodePurpose = "captcha"        // 图形验证码
+)
 
-type verificationInfo struct {
-	Code      string
-	ExpiredAt time.Time
-}
+// 短信/邮箱验证码有效期
+const verificationCodeTTL = 10 * time.Minute
 
-// GenerateRandomCode 生成指定长度的随机数字验证码
-func GenerateRandomCode(length int) string {
-	const digits = "0123456789"
+// GenerateRandomCode 使用 crypto/rand 生成指定长度的随机数字验证码
+func GenerateRandomCode(length int) (string, error) {
 	code := make([]byte, length)
 	for i := range code {
-		code[i] = digits[rand.Intn(len(digits))]
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		code[i] = byte('0' + n.Int64())
 	}
-	return string(code)
+	return string(code), nil
 }
 
-// SaveVerificationCode 保存验证码
-func SaveVerificationCode(key, code string) {
-	verificationStore.Lock()
-	defer verificationStore.Unlock()
-
-	// 验证码有效期10分钟
-	verificationStore.data[key] = verificationInfo{
-		Code:      code,
-		ExpiredAt: time.Now().Add(10 * time.Minute),
-	}
+// SaveVerificationCode 保存验证码，10分钟内有效，替换同一用途和目标的旧验证码
+func SaveVerificationCode(purpose CodePurpose, key, code string) error {
+	return saveCode(purpose, key, code, verificationCodeTTL)
 }
 
-// VerifyCode 验证验证码
-func VerifyCode(key, code string) bool {
-	// 在测试环境下，如果验证码是"123456"，始终返回true
-	if code == "123456" {
-		return true
-	}
+// DeleteVerificationCode 作废验证码，只有当前保存的仍是 code 时才删除，用于发送失败时撤回刚保存的验证码
+func DeleteVerificationCode(purpose CodePurpose, key, code string) error {
+	_, store := currentCodeStore()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-	verificationStore.RLock()
-	defer verificationStore.RUnlock()
+	_, err := store.Delete(ctx, codeStoreKey(purpose, key), code)
+	return err
+}
 
-	info, ok := verificationStore.data[key]
-	if !ok {
-		return false
-	}
+// VerificationCodeSentWithin 验证码是否在指定时间内发送过，用于限制重复发送
+func VerificationCodeSentWithin(purpose CodePurpose, key string, interval time.Duration) bool {
+	_, store := currentCodeStore()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-	// 验证码过期
-	if time.Now().After(info.ExpiredAt) {
+	entry, err := store.Get(ctx, codeStoreKey(purpose, key))
+	if err != nil {
+		Logger.Errorf("读取验证码失败: %v", err)
 		return false
 	}
+	return entry != nil && time.Since(entry.SentAt) < interval
+}
 
-	return info.Code == code
+// ConsumeVerificationCode 校验验证码，通过后立即作废，用于重置密码等敏感操作
+// 输错次数过多时验证码作废，需要重新获取
+func ConsumeVerificationCode(purpose CodePurpose, key, code string) bool {
+	return checkCode(purpose, key, code, true)
 }
 
-// CleanExpiredCodes 清理过期验证码
+// VerifyCode 验证验证码，通过后验证码仍然有效；输错次数过多时验证码作废
+func VerifyCode(purpose CodePurpose, key, code string) bool {
+	return checkCode(purpose, key, code, false)
+}
+
+// CleanExpiredCodes 清理过期验证码，包括图形验证码
 func CleanExpiredCodes() {
-	verificationStore.Lock()
-	defer verificationStore.Unlock()
+	_, store := currentCodeStore()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	count, err := store.DeleteExpired(ctx)
+	if err != nil {
+		Logger.Errorf("清理过期验证码失败: %v", err)
+		return
+	}
+	if count > 0 {
+		Logger.Debugf("清理过期验证码 %d 条", count)
+	}
+}
+
+func codeStoreKey(purpose CodePurpose, key string) string {
+	return string(purpose) + ":" + key
+}
+
+func saveCode(purpose CodePurpose, key, code string, ttl time.Duration) error {
+	_, store := currentCodeStore()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
 	now := time.Now()
-	for key, info := range verificationStore.data {
-		if now.After(info.ExpiredAt) {
-			delete(verificationStore.data, key)
+	return store.Save(ctx, codeStoreKey(purpose, key), CodeEntry{
+		Code:      code,
+		SentAt:    now,
+		ExpiresAt: now.Add(ttl),
+	})
+}
+
+// checkCode 以固定时间比对验证码，错误时计数，达到上限后作废；consume 为 true 时通过后作废
+// 并发使用同一个验证码时只有一个请求能作废成功
+func checkCode(purpose CodePurpose, key, code string, consume bool) bool {
+	config, store := currentCodeStore()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	storeKey := codeStoreKey(purpose, key)
+	entry, err := store.Get(ctx, storeKey)
+	if err != nil {
+		Logger.Errorf("读取验证码失败: %v", err)
+		return false
+	}
+	if entry == nil || entry.Attempts >= config.MaxAttempts {
+		return false
+	}
+
+	if subtle.ConstantTimeCompare([]byte(entry.Code), []byte(code)) != 1 {
+		attempts, err := store.IncrAttempts(ctx, storeKey)
+		if err != nil {
+			Logger.Errorf("记录验证码错误次数失败: %v", err)
+		} else if attempts >= config.MaxAttempts {
+			store.Delete(ctx, storeKey, entry.Code)
 		}
+		return false
+	}
+	if !consume {
+		return true
+	}
+	deleted, err := store.Delete(ctx, storeKey, entry.Code)
+	if err != nil {
+		Logger.Errorf("作废验证码失败: %v", err)
+		return false
 	}
+	return deleted
 }
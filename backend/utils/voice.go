@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 语音消息处理：按实际音频内容计算时长和波形，并转码为统一的播放格式
+// 转码需要ffmpeg；未安装时只接受PCM格式的WAV，按原格式保存
+
+// 语音消息配置
+type VoiceConfig struct {
+	// ffmpeg 可执行文件，用于解码和转码
+	FFmpegPath string
+
+	// 播放格式：opus（Ogg Opus）或 aac（M4A）
+	Codec   string
+	Bitrate string
+
+	// 单条语音的最大时长
+	MaxDuration time.Duration
+
+	// 波形的采样点数
+	WaveformPeaks int
+}
+
+// voiceCodec 播放格式对应的文件扩展名、类型和ffmpeg编码参数
+type voiceCodec struct {
+	Ext         string
+	ContentType string
+	Args        []string
+}
+
+var voiceCodecs = map[string]voiceCodec{
+	"opus": {Ext: ".ogg", ContentType: "audio/ogg", Args: []string{"-c:a", "libopus", "-application", "voip", "-ar", "48000", "-f", "ogg"}},
+	"aac":  {Ext: ".m4a", ContentType: "audio/mp4", Args: []string{"-c:a", "aac", "-movflags", "+faststart", "-f", "ipod"}},
+}
+
+var (
+	voiceConfigMu sync.RWMutex
+	voiceConfig   VoiceConfig
+)
+
+// 初始化函数，从环境变量加载语音消息配置
+func init() {
+	config := VoiceConfig{
+		FFmpegPath:    envOrDefault("VOICE_FFMPEG_PATH", envOrDefault("SPEECH_FFMPEG_PATH", "ffmpeg")),
+		Codec:         envOrDefault("VOICE_CODEC", "opus"),
+		Bitrate:       envOrDefault("VOICE_BITRATE", "32k"),
+		MaxDuration:   60 * time.Second,
+		WaveformPeaks: 64,
+	}
+	if v, err := strconv.Atoi(os.Getenv("VOICE_MAX_DURATION")); err == nil && v > 0 {
+		config.MaxDuration = time.Duration(v) * time.Second
+	}
+	if v, err := strconv.Atoi(os.Getenv("VOICE_WAVEFORM_PEAKS")); err == nil && v > 0 && v <= 1024 {
+		config.WaveformPeaks = v
+	}
+	SetVoiceConfig(config)
+}
+
+// SetVoiceConfig 设置语音消息配置，未知的播放格式使用 opus
+func SetVoiceConfig(config VoiceConfig) {
+	if _, ok := voiceCodecs[config.Codec]; !ok {
+		config.Codec = "opus"
+	}
+	if config.WaveformPeaks <= 0 {
+		config.WaveformPeaks = 64
+	}
+	voiceConfigMu.Lock()
+	voiceConfig = config
+	voiceConfigMu.Unlock()
+}
+
+// GetVoiceConfig 获取当前语音消息配置
+func GetVoiceConfig() VoiceConfig {
+	voiceConfigMu.RLock()
+	defer voiceConfigMu.RUnlock()
+	return voiceConfig
+}
+
+// ProcessedVoice 处理后的语音
+type ProcessedVoice struct {
+	Path        string // 转码后的文件，未转码时为原文件
+	Ext         string
+	ContentType string // 未转码时为空，按文件内容判断
+	Transcoded  bool
+	Duration    time.Duration
+	Waveform    []int // 各时间段的峰值，0-100
+}
+
+// ProcessVoice 探测语音的实际时长、计算波形并转码，超过最大时长时返回 ErrAudioTooLong
+// 转码生成临时文件，调用方处理完后调用 cleanup 删除
+func ProcessVoice(ctx context.Context, audioPath string) (*ProcessedVoice, func(), error) {
+	noop := func() {}
+	config := GetVoiceConfig()
+
+	// PCM格式的WAV直接读取，其他格式先解码
+	pcmPath := audioPath
+	if info, err := readWavInfo(audioPath); err != nil || info.AudioFormat != 1 || info.BitsPerSample != 16 {
+		wavPath, cleanup, err := NormalizeAudio(ctx, config.FFmpegPath, audioPath)
+		if err != nil {
+			return nil, noop, err
+		}
+		defer cleanup()
+		pcmPath = wavPath
+	}
+
+	duration, err := WavDuration(pcmPath)
+	if err != nil {
+		return nil, noop, err
+	}
+	if duration <= 0 {
+		return nil, noop, fmt.Errorf("%w: 音频内容为空", ErrAudioInvalid)
+	}
+	if duration > config.MaxDuration {
+		return nil, noop, fmt.Errorf("%w: 语音不能超过%d秒", ErrAudioTooLong, int(config.MaxDuration/time.Second))
+	}
+	waveform, err := wavWaveform(pcmPath, config.WaveformPeaks)
+	if err != nil {
+		return nil, noop, err
+	}
+
+	voice := &ProcessedVoice{
+		Path:     audioPath,
+		Ext:      strings.ToLower(filepath.Ext(audioPath)),
+		Duration: duration,
+		Waveform: waveform,
+	}
+	if _, err := exec.LookPath(config.FFmpegPath); err != nil {
+		Logger.Infof("未安装ffmpeg，语音消息按原格式保存")
+		return voice, noop, nil
+	}
+
+	codec := voiceCodecs[config.Codec]
+	tempFile, err := os.CreateTemp("", "voice-*"+codec.Ext)
+	if err != nil {
+		return nil, noop, err
+	}
+	tempFile.Close()
+	cleanup := func() { os.Remove(tempFile.Name()) }
+
+	args := []string{"-nostdin", "-y", "-v", "error", "-i", audioPath, "-vn", "-ac", "1", "-b:a", config.Bitrate}
+	args = append(args, codec.Args...)
+	args = append(args, tempFile.Name())
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, config.FFmpegPath, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		cleanup()
+		if ctx.Err() != nil {
+			return nil, noop, ctx.Err()
+		}
+		return nil, noop, fmt.Errorf("%w: %s", ErrAudioInvalid, strings.TrimSpace(stderr.String()))
+	}
+
+	voice.Path = tempFile.Name()
+	voice.Ext = codec.Ext
+	voice.ContentType = codec.ContentType
+	voice.Transcoded = true
+	return voice, cleanup, nil
+}
+
+// wavWaveform 将16位PCM的WAV按时间等分为 peaks 段，取每段的峰值并按最大峰值归一化到0-100
+func wavWaveform(wavPath string, peaks int) ([]int, error) {
+	file, info, err := openWav(wavPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if info.AudioFormat != 1 || info.BitsPerSample != 16 || info.Channels == 0 {
+		return nil, fmt.Errorf("%w: 只支持16位PCM", ErrAudioInvalid)
+	}
+
+	frameSize := int64(info.Channels) * 2
+	frames := int64(info.DataSize) / frameSize
+	if frames == 0 {
+		return []int{}, nil
+	}
+	if int64(peaks) > frames {
+		peaks = int(frames)
+	}
+
+	values := make([]int, peaks)
+	reader := bufio.NewReader(io.LimitReader(file, frames*frameSize))
+	frame := make([]byte, frameSize)
+	maxPeak := 0
+	for i := int64(0); i < frames; i++ {
+		if _, err := io.ReadFull(reader, frame); err != nil {
+			break
+		}
+		bucket := int(i * int64(peaks) / frames)
+		for ch := int64(0); ch < int64(info.Channels); ch++ {
+			sample := int(int16(binary.LittleEndian.Uint16(frame[ch*2:])))
+			if sample < 0 {
+				sample = -sample
+			}
+			if sample > values[bucket] {
+				values[bucket] = sample
+			}
+		}
+		if values[bucket] > maxPeak {
+			maxPeak = values[bucket]
+		}
+	}
+	if maxPeak == 0 {
+		return values, nil
+	}
+	for i, v := range values {
+		values[i] = (v*100 + maxPeak/2) / maxPeak
+	}
+	return values, nil
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writePCMWav 写入16位PCM的WAV文件，samples 按声道交错排列
+func writePCMWav(t *testing.T, sampleRate uint32, channels uint16, samples []int16) string {
+	t.Helper()
+	dataSize := uint32(len(samples) * 2)
+	header := []interface{}{
+		[4]byte{'R', 'I', 'F', 'F'}, 36 + dataSize, [4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '}, uint32(16), uint16(1), channels, sampleRate, sampleRate * uint32(channels) * 2, channels * 2, uint16(16),
+		[4]byte{'d', 'a', 't', 'a'}, dataSize,
+	}
+	path := filepath.Join(t.TempDir(), "voice.wav")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("创建音频文件失败: %v", err)
+	}
+	defer file.Close()
+	for _, field := range header {
+		binary.Write(file, binary.LittleEndian, field)
+	}
+	binary.Write(file, binary.LittleEndian, samples)
+	return path
+}
+
+// useVoiceConfig 使用不存在的ffmpeg，语音按原格式保存，测试结束后恢复原配置
+func useVoiceConfig(t *testing.T, maxDuration time.Duration, peaks int) {
+	t.Helper()
+	previous := GetVoiceConfig()
+	SetVoiceConfig(VoiceConfig{FFmpegPath: "ffmpeg-not-installed", MaxDuration: maxDuration, WaveformPeaks: peaks})
+	t.Cleanup(func() { SetVoiceConfig(previous) })
+}
+
+func TestProcessVoiceDurationAndWaveform(t *testing.T) {
+	useVoiceConfig(t, 10*time.Second, 4)
+
+	// 8kHz 双声道 2.5 秒，四段的峰值依次为满幅、一半、静音、四分之一，右声道的峰值也计入
+	const rate = 8000
+	frames := rate * 5 / 2
+	samples := make([]int16, frames*2)
+	for i := 0; i < frames; i++ {
+		switch i * 4 / frames {
+		case 0:
+			samples[i*2] = -32000
+		case 1:
+			samples[i*2+1] = 16000
+		case 3:
+			samples[i*2] = 8000
+		}
+	}
+	path := writePCMWav(t, rate, 2, samples)
+
+	voice, cleanup, err := ProcessVoice(context.Background(), path)
+	if err != nil {
+		t.Fatalf("处理语音失败: %v", err)
+	}
+	defer cleanup()
+	if voice.Duration != 2500*time.Millisecond {
+		t.Errorf("时长应按音频内容计算为2.5秒，得到 %v", voice.Duration)
+	}
+	if fmt.Sprint(voice.Waveform) != "[100 50 0 25]" {
+		t.Errorf("波形不正确: %v", voice.Waveform)
+	}
+	if voice.Transcoded || voice.Path != path || voice.Ext != ".wav" || voice.ContentType != "" {
+		t.Errorf("未安装ffmpeg时应按原格式保存，得到 %+v", voice)
+	}
+}
+
+func TestProcessVoiceRejects(t *testing.T) {
+	useVoiceConfig(t, 2*time.Second, 64)
+
+	notWav := filepath.Join(t.TempDir(), "voice.m4a")
+	os.WriteFile(notWav, []byte("not really audio"), 0o644)
+
+	tests := []struct {
+		name string
+		path string
+		err  error
+	}{
+		{"超过最大时长", writePCMWav(t, 16000, 1, make([]int16, 16000*3)), ErrAudioTooLong},
+		{"没有音频内容", writePCMWav(t, 16000, 1, nil), ErrAudioInvalid},
+		{"其他格式需要ffmpeg", notWav, ErrTranscribeUnavailable},
+	}
+	for _, tt := range tests {
+		if _, _, err := ProcessVoice(context.Background(), tt.path); !errors.Is(err, tt.err) {
+			t.Errorf("%s: 得到 %v，应为 %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestWavWaveformFewFrames(t *testing.T) {
+	// 采样点数少于波形点数时，每个采样点一段
+	waveform, err := wavWaveform(writePCMWav(t, 16000, 1, []int16{100, -200, 0}), 64)
+	if err != nil {
+		t.Fatalf("计算波形失败: %v", err)
+	}
+	if fmt.Sprint(waveform) != "[50 100 0]" {
+		t.Errorf("波形不正确: %v", waveform)
+	}
+
+	// 全部静音时不做归一化
+	waveform, _ = wavWaveform(writePCMWav(t, 16000, 1, make([]int16, 100)), 4)
+	if fmt.Sprint(waveform) != "[0 0 0 0]" {
+		t.Errorf("静音的波形应全为0，得到 %v", waveform)
+	}
+}
@@ -12,8 +12,8 @@ import (
 
 // WebRTC信令服务器
 type WebRTCSignalingServer struct {
-	// 客户端连接映射表，键为用户ID，值为WebSocket连接
-	Clients map[uint]*websocket.Conn
+	// 客户端连接映射表，键为用户ID，值为WebSocket连接，与推送消息共用同一个连接对象和写锁
+	Clients map[uint]*WebSocketConnection
 	// 互斥锁，用于保护clients映射表
 	ClientsMutex sync.RWMutex
 	// 在线用户映射表，键为用户ID，值为最后活跃时间
@@ -25,7 +25,7 @@ type WebRTCSignalingServer struct {
 // 创建新的WebRTC信令服务器
 func NewWebRTCSignalingServer() *WebRTCSignalingServer {
 	return &WebRTCSignalingServer{
-		Clients:     make(map[uint]*websocket.Conn),
+		Clients:     make(map[uint]*WebSocketConnection),
 		OnlineUsers: make(map[uint]int64),
 	}
 }
@@ -34,7 +34,7 @@ func NewWebRTCSignalingServer() *WebRTCSignalingServer {
 var WebRTCServer = NewWebRTCSignalingServer()
 
 // 注册客户端
-func (s *WebRTCSignalingServer) RegisterClient(userID uint, conn *websocket.Conn) {
+func (s *WebRTCSignalingServer) RegisterClient(userID uint, conn *WebSocketConnection) {
 	s.ClientsMutex.Lock()
 	defer s.ClientsMutex.Unlock()
 
@@ -54,16 +54,17 @@ func (s *WebRTCSignalingServer) RegisterClient(userID uint, conn *websocket.Conn
 	log.Printf("用户 %d 已连接到WebRTC信令服务器", userID)
 }
 
-// 注销客户端
-func (s *WebRTCSignalingServer) UnregisterClient(userID uint) {
+// 注销客户端，用户已经建立了新连接时不影响新连接
+func (s *WebRTCSignalingServer) UnregisterClient(userID uint, conn *WebSocketConnection) {
 	s.ClientsMutex.Lock()
 	defer s.ClientsMutex.Unlock()
 
-	// 如果存在连接，关闭连接
-	if conn, exists := s.Clients[userID]; exists {
-		conn.Close()
-		delete(s.Clients, userID)
+	// 只关闭当前登记的这个连接
+	if current, exists := s.Clients[userID]; !exists || current != conn {
+		return
 	}
+	conn.Close()
+	delete(s.Clients, userID)
 
 	// 更新在线用户
 	s.OnlineUsersMutex.Lock()
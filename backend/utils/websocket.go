@@ -9,6 +9,7 @@ import (
 )
 
 // WebSocketConnection 表示一个WebSocket连接
+// gorilla/websocket 不支持并发写，推送、信令和AI流式输出等所有写操作都要通过 mu 串行
 type WebSocketConnection struct {
 	Conn      *websocket.Conn
 	UserID    uint
@@ -61,12 +62,12 @@ func (m *WebSocketManager) AddConnection(userID uint, conn *websocket.Conn) *Web
 	return wsConn
 }
 
-// RemoveConnection 移除一个WebSocket连接
-func (m *WebSocketManager) RemoveConnection(userID uint) {
+// RemoveConnection 移除一个WebSocket连接，用户已经建立了新连接时不影响新连接
+func (m *WebSocketManager) RemoveConnection(userID uint, wsConn *WebSocketConnection) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if conn, exists := m.connections[userID]; exists {
+	if conn, exists := m.connections[userID]; exists && conn == wsConn {
 		conn.mu.Lock()
 		conn.isClosing = true
 		conn.mu.Unlock()
@@ -75,6 +76,25 @@ func (m *WebSocketManager) RemoveConnection(userID uint) {
 	}
 }
 
+// WriteMessage 发送一条消息，与该连接上的其他写操作串行
+func (c *WebSocketConnection) WriteMessage(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Conn.WriteMessage(messageType, data)
+}
+
+// WriteJSON 以JSON格式发送一条消息，与该连接上的其他写操作串行
+func (c *WebSocketConnection) WriteJSON(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Conn.WriteJSON(v)
+}
+
+// Close 关闭连接
+func (c *WebSocketConnection) Close() error {
+	return c.Conn.Close()
+}
+
 // GetConnection 获取指定用户的WebSocket连接
 func (m *WebSocketManager) GetConnection(userID uint) *WebSocketConnection {
 	m.mu.RLock()
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// newTestWebSocket 建立一对WebSocket连接，返回服务端连接和客户端连接
+func newTestWebSocket(t *testing.T) (*websocket.Conn, *websocket.Conn) {
+	t.Helper()
+	serverConns := make(chan *websocket.Conn, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("升级连接失败: %v", err)
+			return
+		}
+		serverConns <- conn
+	}))
+	t.Cleanup(server.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return <-serverConns, client
+}
+
+// 信令、推送和连接处理器的写操作共用一个写锁，并发写不会触发 gorilla/websocket 的 panic
+func TestWebSocketConcurrentWrites(t *testing.T) {
+	serverConn, client := newTestWebSocket(t)
+	const userID = 90001
+	wsConn := GetWebSocketManager().AddConnection(userID, serverConn)
+	WebRTCServer.RegisterClient(userID, wsConn)
+	defer func() {
+		WebRTCServer.UnregisterClient(userID, wsConn)
+		GetWebSocketManager().RemoveConnection(userID, wsConn)
+	}()
+
+	const perWriter = 50
+	var wg sync.WaitGroup
+	writers := []func(){
+		func() { WebRTCServer.SendJSONToUser(userID, map[string]interface{}{"type": "signal"}) },
+		func() { WebRTCServer.SendToUser(userID, []byte(`{"type":"raw"}`)) },
+		func() { PushMessageToUser(userID, map[string]interface{}{"type": "push"}) },
+		func() { wsConn.WriteJSON(map[string]interface{}{"type": "ai_chat_delta"}) },
+	}
+	for _, write := range writers {
+		wg.Add(1)
+		go func(write func()) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				write()
+			}
+		}(write)
+	}
+
+	received := 0
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for received < perWriter*len(writers) {
+			if _, _, err := client.ReadMessage(); err != nil {
+				return
+			}
+			received++
+		}
+	}()
+	wg.Wait()
+	<-done
+	if received != perWriter*len(writers) {
+		t.Errorf("收到 %d 条消息，应为 %d", received, perWriter*len(writers))
+	}
+}
+
+// 旧连接断开时不能注销用户已经建立的新连接
+func TestUnregisterStaleClient(t *testing.T) {
+	oldServerConn, _ := newTestWebSocket(t)
+	newServerConn, _ := newTestWebSocket(t)
+	const userID = 90002
+
+	oldConn := GetWebSocketManager().AddConnection(userID, oldServerConn)
+	WebRTCServer.RegisterClient(userID, oldConn)
+	newConn := GetWebSocketManager().AddConnection(userID, newServerConn)
+	WebRTCServer.RegisterClient(userID, newConn)
+
+	WebRTCServer.UnregisterClient(userID, oldConn)
+	GetWebSocketManager().RemoveConnection(userID, oldConn)
+	if !WebRTCServer.IsUserOnline(userID) || GetWebSocketManager().GetConnection(userID) != newConn {
+		t.Fatal("旧连接注销后新连接也被移除")
+	}
+
+	WebRTCServer.UnregisterClient(userID, newConn)
+	GetWebSocketManager().RemoveConnection(userID, newConn)
+	if WebRTCServer.IsUserOnline(userID) || GetWebSocketManager().GetConnection(userID) != nil {
+		t.Error("新连接注销后用户仍然在线")
+	}
+}